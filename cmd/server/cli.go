@@ -0,0 +1,63 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+// subcommands are the commands of the binary other than serving the app, by name, each given the
+// arguments after its name.
+var subcommands = map[string]func(args []string) error{
+	"compile-texts": compileTexts,
+	"fetch-texts":   fetchTexts,
+	"migrate":       migrate,
+}
+
+// plan makes the changes of a subcommand that overwrites or deletes data, or, with -dry-run, only
+// reports them, so that every such subcommand can be tried first in the same way. Subcommands get one
+// from destructiveFlags and make each change through Do.
+type plan struct {
+	dryRun  bool
+	out     io.Writer
+	changes int
+}
+
+// destructiveFlags returns the flag set of the subcommand name, which changes data, with a -dry-run
+// flag, and the plan to make its changes through.
+func destructiveFlags(name string) (*flag.FlagSet, *plan) {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	p := &plan{out: os.Stdout}
+	fs.BoolVar(&p.dryRun, "dry-run", false, "report what would change without changing anything")
+	return fs, p
+}
+
+// Do makes the change described by what, e.g. "replace /srv/texts/2027.json", by calling apply. In a
+// dry run it reports the change instead, with details, one per line.
+func (p *plan) Do(what string, details []string, apply func() error) error {
+	p.changes++
+	if !p.dryRun {
+		return apply()
+	}
+	fmt.Fprintf(p.out, "Would %s\n", what)
+	for _, d := range details {
+		fmt.Fprintf(p.out, "  %s\n", d)
+	}
+	return nil
+}
+
+// Done ends a dry run with how many changes it would have made.
+func (p *plan) Done() {
+	if !p.dryRun {
+		return
+	}
+	switch p.changes {
+	case 0:
+		fmt.Fprintln(p.out, "Dry run: nothing would change.")
+	case 1:
+		fmt.Fprintln(p.out, "Dry run: 1 change would be made; nothing was changed.")
+	default:
+		fmt.Fprintf(p.out, "Dry run: %d changes would be made; nothing was changed.\n", p.changes)
+	}
+}
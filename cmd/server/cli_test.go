@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPlan_DryRun(t *testing.T) {
+	var out bytes.Buffer
+	p := &plan{dryRun: true, out: &out}
+	applied := false
+	for _, what := range []string{"replace /srv/texts/2027.json", "create /srv/texts/2027.dtx"} {
+		if err := p.Do(what, []string{"2027-01-01: verses changed"}, func() error {
+			applied = true
+			return nil
+		}); err != nil {
+			t.Fatalf("Do failed: %v", err)
+		}
+	}
+	p.Done()
+
+	if applied {
+		t.Error("dry run applied a change")
+	}
+	want := "Would replace /srv/texts/2027.json\n  2027-01-01: verses changed\n" +
+		"Would create /srv/texts/2027.dtx\n  2027-01-01: verses changed\n" +
+		"Dry run: 2 changes would be made; nothing was changed.\n"
+	if got := out.String(); got != want {
+		t.Errorf("report = %q, want %q", got, want)
+	}
+
+	out.Reset()
+	(&plan{dryRun: true, out: &out}).Done()
+	if got, want := out.String(), "Dry run: nothing would change.\n"; got != want {
+		t.Errorf("report of no changes = %q, want %q", got, want)
+	}
+}
+
+func TestPlan_Apply(t *testing.T) {
+	var out bytes.Buffer
+	p := &plan{out: &out}
+	applied := 0
+	if err := p.Do("replace /srv/texts/2027.json", nil, func() error {
+		applied++
+		return nil
+	}); err != nil {
+		t.Fatalf("Do failed: %v", err)
+	}
+	errFailed := errors.New("disk full")
+	if err := p.Do("create /srv/texts/2027.dtx", nil, func() error { return errFailed }); !errors.Is(err, errFailed) {
+		t.Errorf("Do = %v, want the error of the change", err)
+	}
+	p.Done()
+
+	if applied != 1 {
+		t.Errorf("change applied %d times, want once", applied)
+	}
+	if out.Len() != 0 {
+		t.Errorf("applying reported %q, want nothing", out.String())
+	}
+}
+
+// embeddedYear returns the source of the embedded daily texts of 2026.
+func embeddedYear(t *testing.T) []byte {
+	t.Helper()
+	data, err := os.ReadFile("../../internal/dailytexts/texts/2026.json")
+	if err != nil {
+		t.Fatalf("reading texts: %v", err)
+	}
+	return data
+}
+
+func TestCompileTexts_DryRunWritesNothing(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "2026.json"), embeddedYear(t), 0o644); err != nil {
+		t.Fatalf("writing year file: %v", err)
+	}
+
+	if err := compileTexts([]string{"-dry-run", "-dir", dir}); err != nil {
+		t.Fatalf("compile-texts -dry-run failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "2026.dtx")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("dry run wrote the compiled year: %v", err)
+	}
+
+	if err := compileTexts([]string{"-dir", dir}); err != nil {
+		t.Fatalf("compile-texts failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "2026.dtx")); err != nil {
+		t.Errorf("compiled year not written: %v", err)
+	}
+}
+
+func TestFetchTexts_DryRunWritesNothing(t *testing.T) {
+	data := embeddedYear(t)
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(data)
+	}))
+	defer upstream.Close()
+	dir := t.TempDir()
+	args := []string{"-year", "2026", "-url", upstream.URL + "/{year}.json", "-dir", dir}
+
+	if err := fetchTexts(append([]string{"-dry-run"}, args...)); err != nil {
+		t.Fatalf("fetch-texts -dry-run failed: %v", err)
+	}
+	if entries, err := os.ReadDir(dir); err != nil || len(entries) != 0 {
+		t.Errorf("dry run wrote %v, %v; want nothing", entries, err)
+	}
+
+	if err := fetchTexts(args); err != nil {
+		t.Fatalf("fetch-texts failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "2026.json")); err != nil {
+		t.Errorf("year file not written: %v", err)
+	}
+}
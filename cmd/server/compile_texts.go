@@ -0,0 +1,69 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"derrclan.com/moravian-soap/internal/dailytexts"
+)
+
+// compileTexts implements the compile-texts subcommand, which compiles every year file (YYYY.json) in a
+// directory into a compiled year file (YYYY.dtx) beside it, indexed by date so that the server can look
+// up a date without decoding the whole year. The embedded texts are compiled with it by go generate. With
+// -dry-run it reports the compiled files it would write instead.
+func compileTexts(args []string) error {
+	fs, p := destructiveFlags("compile-texts")
+	dir := fs.String("dir", os.Getenv("DAILY_TEXTS_DIR"), "directory of the year files to compile")
+	if err := fs.Parse(args); errors.Is(err, flag.ErrHelp) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("parsing flags: %w", err)
+	}
+	if *dir == "" {
+		return errors.New("no texts directory: set DAILY_TEXTS_DIR or pass -dir")
+	}
+
+	names, err := filepath.Glob(filepath.Join(*dir, "*.json"))
+	if err != nil {
+		return fmt.Errorf("listing year files in %s: %w", *dir, err)
+	}
+	if len(names) == 0 {
+		return fmt.Errorf("no year files in %s", *dir)
+	}
+	for _, name := range names {
+		year, err := strconv.Atoi(strings.TrimSuffix(filepath.Base(name), ".json"))
+		if err != nil {
+			continue
+		}
+		data, err := os.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		yearData, err := dailytexts.ParseYear(data, year)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		filename := filepath.Join(*dir, fmt.Sprintf("%04d.dtx", year))
+		what := fmt.Sprintf("create %s with %d days", filename, len(yearData))
+		if _, err := os.Stat(filename); err == nil {
+			what = fmt.Sprintf("replace %s with %d days", filename, len(yearData))
+		}
+		if err := p.Do(what, nil, func() error {
+			if _, err := dailytexts.WriteCompiled(*dir, year, yearData); err != nil {
+				return fmt.Errorf("compiling daily texts for %d: %w", year, err)
+			}
+			slog.Info("compiled daily texts", "year", year, "days", len(yearData), "file", filename)
+			return nil
+		}); err != nil {
+			return err
+		}
+	}
+	p.Done()
+	return nil
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"derrclan.com/moravian-soap/internal/dailytexts"
+)
+
+// fetchTexts implements the fetch-texts subcommand, which downloads a year's daily texts from the upstream
+// URL in DAILY_TEXTS_URL into the external texts directory (DAILY_TEXTS_DIR), where the server picks them
+// up without a rebuild. With -dry-run it reports the dates whose texts would change instead.
+func fetchTexts(args []string) error {
+	fs, p := destructiveFlags("fetch-texts")
+	year := fs.Int("year", time.Now().Year()+1, "year of the daily texts to fetch")
+	url := fs.String("url", os.Getenv("DAILY_TEXTS_URL"), "upstream URL of the year file; {year} is replaced by the year")
+	dir := fs.String("dir", os.Getenv("DAILY_TEXTS_DIR"), "directory to write the year file to")
+	if err := fs.Parse(args); errors.Is(err, flag.ErrHelp) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("parsing flags: %w", err)
+	}
+
+	if *url == "" {
+		return errors.New("no upstream URL: set DAILY_TEXTS_URL or pass -url")
+	}
+	if *dir == "" {
+		return errors.New("no texts directory: set DAILY_TEXTS_DIR or pass -dir")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	yearData, err := dailytexts.Fetch(ctx, &http.Client{Timeout: 30 * time.Second}, *url, *year)
+	if err != nil {
+		return fmt.Errorf("fetching daily texts for %d: %w", *year, err)
+	}
+	filename := filepath.Join(*dir, fmt.Sprintf("%04d.json", *year))
+	what, details := yearFileChanges(filename, *year, yearData)
+	if err := p.Do(what, details, func() error {
+		if _, err := dailytexts.WriteYear(*dir, *year, yearData); err != nil {
+			return fmt.Errorf("saving daily texts for %d: %w", *year, err)
+		}
+		slog.Info("fetched daily texts", "year", *year, "days", len(yearData), "file", filename)
+		return nil
+	}); err != nil {
+		return err
+	}
+	p.Done()
+	return nil
+}
+
+// yearFileChanges describes writing yearData to the year file filename, and lists the dates whose
+// texts it changes if the file is already there.
+func yearFileChanges(filename string, year int, yearData dailytexts.Year) (string, []string) {
+	data, err := os.ReadFile(filename)
+	if errors.Is(err, fs.ErrNotExist) {
+		return fmt.Sprintf("create %s with %d days", filename, len(yearData)), nil
+	}
+	if err != nil {
+		return fmt.Sprintf("replace %s, which cannot be read (%v)", filename, err), nil
+	}
+	old, err := dailytexts.ParseYear(data, year)
+	if err != nil {
+		return fmt.Sprintf("replace %s, which is invalid (%v)", filename, err), nil
+	}
+	changes := dailytexts.Diff(old, yearData)
+	if len(changes) == 0 {
+		return fmt.Sprintf("rewrite %s, changing none of its days", filename), nil
+	}
+	details := make([]string, len(changes))
+	for i, c := range changes {
+		details[i] = c.String()
+	}
+	return fmt.Sprintf("replace %s, changing the texts of these dates:", filename), details
+}
@@ -4,52 +4,124 @@ package main
 import (
 	"context"
 	"errors"
+	"flag"
 	"fmt"
 	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"syscall"
 	"time"
 
-	"github.com/joho/godotenv"
-
+	"derrclan.com/moravian-soap/internal/commentary"
+	"derrclan.com/moravian-soap/internal/config"
+	"derrclan.com/moravian-soap/internal/metrics"
 	"derrclan.com/moravian-soap/internal/server"
 )
 
 func main() {
-	opts := &slog.HandlerOptions{Level: slog.LevelInfo}
+	opts := &slog.HandlerOptions{Level: config.LogLevel}
 	handler := slog.NewTextHandler(os.Stderr, opts)
-	slog.SetDefault(slog.New(handler))
+	slog.SetDefault(slog.New(metrics.CountErrors(handler)))
+
+	if len(os.Args) > 1 {
+		if cmd, ok := subcommands[os.Args[1]]; ok {
+			_ = config.LoadDotenv()
+			if err := cmd(os.Args[2:]); err != nil {
+				slog.Error(os.Args[1]+" failed", "error", err)
+				os.Exit(1)
+			}
+			return
+		}
+	}
 
-	if err := run(); err != nil {
+	if err := run(os.Args[1:]); err != nil {
 		slog.Error("application failed", "error", err)
 		os.Exit(1)
 	}
 }
 
-func run() error {
-	_ = godotenv.Load()
+// run serves the app until it is interrupted. The -addr flag takes precedence over LISTEN_ADDR.
+func run(args []string) error {
+	fs := flag.NewFlagSet("server", flag.ContinueOnError)
+	addr := fs.String("addr", "", "address to listen on, e.g. :8080 (default LISTEN_ADDR)")
+	if err := fs.Parse(args); errors.Is(err, flag.ErrHelp) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	_ = config.LoadDotenv()
+	if *addr != "" {
+		if err := os.Setenv("LISTEN_ADDR", *addr); err != nil {
+			return err
+		}
+	}
+	startup, err := config.LoadStartup()
+	if err != nil {
+		return err
+	}
+	server.ApplyConfig(config.Current())
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	if err := server.InitDB(ctx); err != nil {
-		return fmt.Errorf("initializing database: %w", err)
+	cfg := server.Config{DBPath: startup.DBPath}
+	if startup.TemplatesDir != "" {
+		cfg.Options = append(cfg.Options, server.WithPartials(os.DirFS(startup.TemplatesDir), "*.gotmpl"))
 	}
-
-	mux := server.Muxer()
-
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
+	switch {
+	case startup.CommentaryPack != "":
+		pack, err := commentary.LoadPack(os.DirFS(filepath.Dir(startup.CommentaryPack)), filepath.Base(startup.CommentaryPack))
+		if err != nil {
+			return err
+		}
+		cfg.Options = append(cfg.Options, server.WithCommentary(pack))
+	case startup.CommentaryURL != "":
+		remote, err := commentary.NewRemote(startup.CommentaryURL)
+		if err != nil {
+			return err
+		}
+		cfg.Options = append(cfg.Options, server.WithCommentary(remote))
+	}
+	app, err := server.New(ctx, cfg)
+	if err != nil {
+		return fmt.Errorf("starting server: %w", err)
 	}
+	defer func() {
+		// Stop the background services before closing the database they use.
+		cancel()
+		if err := app.Close(); err != nil {
+			slog.Error("failed to close database", "error", err)
+		}
+	}()
 
 	srv := http.Server{
-		Addr:              ":" + port,
-		Handler:           mux,
+		Addr:              startup.ListenAddr,
+		Handler:           app,
 		ReadHeaderTimeout: 10 * time.Second,
 	}
+	var redirect *http.Server
+	if startup.TLS() {
+		redirect = configureTLS(&srv, startup)
+	}
+
+	go func() {
+		sighup := make(chan os.Signal, 1)
+		signal.Notify(sighup, syscall.SIGHUP)
+		for {
+			select {
+			case <-sighup:
+				if _, err := app.ReloadConfig(); err != nil {
+					slog.Error("failed to reload configuration, keeping current configuration", "error", err)
+				}
+			case <-ctx.Done():
+				signal.Stop(sighup)
+				return
+			}
+		}
+	}()
 
 	idleConns := make(chan struct{})
 	go func() {
@@ -66,11 +138,31 @@ func run() error {
 		if err := srv.Shutdown(shutdownCtx); err != nil {
 			slog.Error("shutting down http server", "error", err)
 		}
+		if redirect != nil {
+			if err := redirect.Shutdown(shutdownCtx); err != nil {
+				slog.Error("shutting down redirect server", "error", err)
+			}
+		}
 		close(idleConns)
 	}()
 
-	slog.Info("starting server", "addr", srv.Addr)
-	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+	if redirect != nil {
+		go func() {
+			slog.Info("redirecting to HTTPS", "addr", redirect.Addr)
+			if err := redirect.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				slog.Error("redirect server failed", "error", err)
+			}
+		}()
+	}
+
+	slog.Info("starting server", "addr", srv.Addr, "tls", startup.TLS())
+	if startup.TLS() {
+		// With Let's Encrypt the certificates come from srv.TLSConfig, so no files are given.
+		err = srv.ListenAndServeTLS(startup.TLSCertFile, startup.TLSKeyFile)
+	} else {
+		err = srv.ListenAndServe()
+	}
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
 		return fmt.Errorf("listen and serve: %w", err)
 	}
 	<-idleConns
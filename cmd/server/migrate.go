@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"derrclan.com/moravian-soap/internal/config"
+	"derrclan.com/moravian-soap/internal/migrations"
+	"derrclan.com/moravian-soap/internal/store/sqlite"
+)
+
+// migrate implements the migrate subcommand. "migrate status" lists every migration and whether it has
+// been applied; "migrate up" applies the pending ones as the server does on startup, or with -dry-run
+// only reports them.
+func migrate(args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: migrate status | migrate up [-dry-run]")
+	}
+	// Only "up" changes the database; "status" only reads it, so it has no -dry-run.
+	var fs *flag.FlagSet
+	var p *plan
+	if args[0] == "up" {
+		fs, p = destructiveFlags("migrate up")
+	} else {
+		fs = flag.NewFlagSet("migrate "+args[0], flag.ContinueOnError)
+	}
+	dbPath := fs.String("db", config.DBPath(), "path of the SQLite database")
+	if err := fs.Parse(args[1:]); errors.Is(err, flag.ErrHelp) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("parsing flags: %w", err)
+	}
+
+	db, err := sqlite.Open(*dbPath)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Minute)
+	defer cancel()
+
+	switch args[0] {
+	case "status":
+		all, err := migrations.Status(ctx, db)
+		if err != nil {
+			return err
+		}
+		return printMigrations(all)
+	case "up":
+		all, err := migrations.Status(ctx, db)
+		if err != nil {
+			return err
+		}
+		var pending []string
+		for _, m := range all {
+			if !m.Pending() {
+				continue
+			}
+			if m.Destructive {
+				pending = append(pending, m.Name+" (destructive, so the database is backed up first)")
+			} else {
+				pending = append(pending, m.Name)
+			}
+		}
+		if len(pending) > 0 {
+			what := fmt.Sprintf("apply %d pending migrations to %s:", len(pending), *dbPath)
+			if err := p.Do(what, pending, func() error {
+				return migrations.Run(ctx, db, migrations.WithBackup(*dbPath))
+			}); err != nil {
+				return err
+			}
+		}
+		p.Done()
+		return nil
+	default:
+		return fmt.Errorf("unknown migrate command %q", args[0])
+	}
+}
+
+// printMigrations writes a table of migrations to standard output.
+func printMigrations(list []*migrations.Migration) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "MIGRATION\tAPPLIED\tDESTRUCTIVE")
+	for _, m := range list {
+		applied := "pending"
+		if !m.Pending() {
+			applied = m.AppliedAt.Format(time.DateTime)
+		}
+		destructive := ""
+		if m.Destructive {
+			destructive = "yes"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", m.Name, applied, destructive)
+	}
+	return w.Flush()
+}
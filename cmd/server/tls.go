@@ -0,0 +1,63 @@
+package main
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"time"
+
+	"golang.org/x/crypto/acme/autocert"
+
+	"derrclan.com/moravian-soap/internal/config"
+)
+
+// configureTLS sets up srv to serve TLS as the startup settings ask, with the certificate files or
+// certificates obtained from Let's Encrypt. It returns the server for the plain HTTP listener that
+// redirects to HTTPS, or nil if there is none.
+func configureTLS(srv *http.Server, startup *config.Startup) *http.Server {
+	srv.TLSConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	_, port, _ := net.SplitHostPort(startup.ListenAddr)
+	redirect := redirectToHTTPS(port)
+
+	var handler http.Handler = redirect
+	if len(startup.AutocertDomains) > 0 {
+		m := &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(startup.AutocertDomains...),
+			Cache:      autocert.DirCache(startup.AutocertCacheDir),
+			Email:      startup.AutocertEmail,
+		}
+		srv.TLSConfig = m.TLSConfig()
+		srv.TLSConfig.MinVersion = tls.VersionTLS12
+		// The manager answers Let's Encrypt's HTTP challenges and passes other requests on.
+		handler = m.HTTPHandler(redirect)
+	}
+
+	if startup.RedirectAddr == "" {
+		return nil
+	}
+	return &http.Server{
+		Addr:              startup.RedirectAddr,
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+}
+
+// redirectToHTTPS returns a handler that redirects plain HTTP requests to the same URL over HTTPS, on
+// port.
+func redirectToHTTPS(port string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			http.Error(w, "Use HTTPS", http.StatusBadRequest)
+			return
+		}
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if port != "443" {
+			host = net.JoinHostPort(host, port)
+		}
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusMovedPermanently)
+	}
+}
@@ -13,6 +13,7 @@ require (
 	github.com/mailgun/mailgun-go/v5 v5.10.1
 	github.com/pressly/goose/v3 v3.26.0
 	golang.org/x/crypto v0.46.0
+	golang.org/x/sync v0.19.0
 )
 
 require (
@@ -24,6 +25,6 @@ require (
 	github.com/oapi-codegen/runtime v1.1.2 // indirect
 	github.com/sethvargo/go-retry v0.3.0 // indirect
 	go.uber.org/multierr v1.11.0 // indirect
-	golang.org/x/sync v0.16.0 // indirect
 	golang.org/x/sys v0.39.0 // indirect
+	golang.org/x/text v0.32.0 // indirect
 )
@@ -0,0 +1,97 @@
+// Package announcements provides the release notes shown to users when new features are added.
+//
+// Notes are embedded from announcements.json. Each user sees the newest note they have not dismissed, so
+// a note's ID must never change once it has been released.
+package announcements
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"derrclan.com/moravian-soap/internal/civil"
+)
+
+//go:embed announcements.json
+var notes []byte
+
+// Announcement is a release note about a new feature.
+type Announcement struct {
+	// ID identifies the announcement in users' dismissals.
+	ID string `json:"id"`
+	// Date is the day the feature was released (YYYY-MM-DD).
+	Date  string `json:"date"`
+	Title string `json:"title"`
+	Body  string `json:"body"`
+	// Link is an optional path to the feature, e.g. "/settings/journal".
+	Link string `json:"link,omitempty"`
+}
+
+// all holds the embedded announcements, newest first.
+var all = func() []Announcement {
+	as, err := parse(notes)
+	if err != nil {
+		panic(fmt.Sprintf("announcements.json: %v", err))
+	}
+	return as
+}()
+
+// parse decodes and validates announcements, ordering them newest first. Announcements released on the
+// same day keep the order they are listed in.
+func parse(data []byte) ([]Announcement, error) {
+	var as []Announcement
+	if err := json.Unmarshal(data, &as); err != nil {
+		return nil, fmt.Errorf("decoding announcements: %w", err)
+	}
+	seen := make(map[string]bool, len(as))
+	for _, a := range as {
+		if a.ID == "" {
+			return nil, errors.New("announcement has no ID")
+		}
+		if seen[a.ID] {
+			return nil, fmt.Errorf("duplicate announcement ID %q", a.ID)
+		}
+		seen[a.ID] = true
+		if _, err := civil.ParseDate(a.Date); err != nil {
+			return nil, fmt.Errorf("announcement %q: %w", a.ID, err)
+		}
+		if a.Title == "" {
+			return nil, fmt.Errorf("announcement %q has no title", a.ID)
+		}
+		if a.Link != "" && !strings.HasPrefix(a.Link, "/") {
+			return nil, fmt.Errorf("announcement %q: link %q is not a path", a.ID, a.Link)
+		}
+	}
+	slices.SortStableFunc(as, func(a, b Announcement) int {
+		return strings.Compare(b.Date, a.Date)
+	})
+	return as, nil
+}
+
+// All returns every announcement, newest first.
+func All() []Announcement {
+	return slices.Clone(all)
+}
+
+// Get returns the announcement with the given ID.
+func Get(id string) (Announcement, bool) {
+	i := slices.IndexFunc(all, func(a Announcement) bool { return a.ID == id })
+	if i < 0 {
+		return Announcement{}, false
+	}
+	return all[i], true
+}
+
+// Unread returns the announcements whose IDs are not in dismissed, newest first.
+func Unread(dismissed []string) []Announcement {
+	var unread []Announcement
+	for _, a := range all {
+		if !slices.Contains(dismissed, a.ID) {
+			unread = append(unread, a)
+		}
+	}
+	return unread
+}
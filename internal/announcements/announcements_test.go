@@ -0,0 +1,44 @@
+package announcements_test
+
+import (
+	"testing"
+
+	"derrclan.com/moravian-soap/internal/announcements"
+)
+
+func TestAll(t *testing.T) {
+	all := announcements.All()
+	if len(all) == 0 {
+		t.Fatal("expected embedded announcements")
+	}
+	for i := 1; i < len(all); i++ {
+		if all[i].Date > all[i-1].Date {
+			t.Errorf("announcements not newest first: %s (%s) after %s (%s)", all[i].ID, all[i].Date, all[i-1].ID, all[i-1].Date)
+		}
+	}
+	for _, a := range all {
+		if got, ok := announcements.Get(a.ID); !ok || got != a {
+			t.Errorf("Get(%q) = %+v, %v; want %+v", a.ID, got, ok, a)
+		}
+	}
+	if _, ok := announcements.Get("no-such-announcement"); ok {
+		t.Error("Get found an unknown announcement")
+	}
+}
+
+func TestUnread(t *testing.T) {
+	all := announcements.All()
+	if got := announcements.Unread(nil); len(got) != len(all) {
+		t.Errorf("expected all %d announcements unread, got %d", len(all), len(got))
+	}
+
+	unread := announcements.Unread([]string{all[0].ID, "retired-announcement"})
+	if len(unread) != len(all)-1 {
+		t.Fatalf("expected %d unread announcements, got %d", len(all)-1, len(unread))
+	}
+	for _, a := range unread {
+		if a.ID == all[0].ID {
+			t.Errorf("dismissed announcement %q is unread", a.ID)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+// Package card draws the images shown in previews of links to the site, such as the card of a shared
+// year in review. Text is drawn in a small built-in pixel font, so no font files are needed; it is
+// shown in capitals, and characters the font lacks are left out.
+package card
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Width and Height are the size of a card, that of an OpenGraph image.
+const (
+	Width  = 1200
+	Height = 630
+)
+
+// The colors of a card, from the site's default theme.
+var (
+	background = color.RGBA{0x4A, 0x6F, 0xA5, 0xFF}
+	text       = color.RGBA{0xFF, 0xFF, 0xFF, 0xFF}
+	emptyDay   = color.RGBA{0x6B, 0x8F, 0xA3, 0xFF}
+	filledDay  = color.RGBA{0xFF, 0xD5, 0x4F, 0xFF}
+)
+
+// The layout of a card, in pixels. Text is drawn at a scale, the size of each pixel of the font.
+const (
+	margin       = 60
+	headingScale = 16
+	lineScale    = 6
+	lineGap      = 18
+	maxLines     = 3
+	cellSize     = 16
+	cellGap      = 4
+)
+
+// Year draws the card of a year in review: the year in large type, up to three lines of text below it,
+// and a calendar of the year's days, a column to a week starting on first, with the days in journaled
+// ("2006-01-02") filled in. Lines too long for the card are cut short.
+func Year(year int, lines []string, journaled []string, first time.Weekday) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, Width, Height))
+	draw.Draw(img, img.Bounds(), image.NewUniform(background), image.Point{}, draw.Src)
+
+	drawText(img, margin, margin, headingScale, fmt.Sprint(year))
+	y := margin + glyphHeight*headingScale + 2*lineGap
+	for i, line := range lines {
+		if i == maxLines {
+			break
+		}
+		drawText(img, margin, y, lineScale, fit(line, (Width-2*margin)/(glyphAdvance*lineScale)))
+		y += glyphHeight*lineScale + lineGap
+	}
+
+	filled := make(map[string]bool, len(journaled))
+	for _, d := range journaled {
+		filled[d] = true
+	}
+	start := time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+	top := Height - margin - 7*(cellSize+cellGap) + cellGap
+	for d := start; d.Year() == year; d = d.AddDate(0, 0, 1) {
+		c := emptyDay
+		if filled[d.Format(time.DateOnly)] {
+			c = filledDay
+		}
+		week := (d.YearDay() - 1 + daysIntoWeek(start, first)) / 7
+		x := margin + week*(cellSize+cellGap)
+		y := top + daysIntoWeek(d, first)*(cellSize+cellGap)
+		draw.Draw(img, image.Rect(x, y, x+cellSize, y+cellSize), image.NewUniform(c), image.Point{}, draw.Src)
+	}
+	return img
+}
+
+// daysIntoWeek returns how many days of its week, starting on first, come before t.
+func daysIntoWeek(t time.Time, first time.Weekday) int {
+	return (int(t.Weekday()) - int(first) + 7) % 7
+}
+
+// PNG encodes a card as a PNG image.
+func PNG(img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("encoding card: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// fit cuts s short to at most n characters, ending it with "..." if it is cut.
+func fit(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n-3]) + "..."
+}
+
+// drawText draws s in capitals with its top left corner at x, y, each pixel of the font scale pixels
+// square.
+func drawText(img *image.RGBA, x, y, scale int, s string) {
+	for _, r := range strings.ToUpper(s) {
+		g, ok := glyphs[r]
+		if !ok {
+			continue
+		}
+		for row, bits := range g {
+			for col := range glyphWidth {
+				if bits&(1<<(glyphWidth-1-col)) == 0 {
+					continue
+				}
+				px, py := x+col*scale, y+row*scale
+				draw.Draw(img, image.Rect(px, py, px+scale, py+scale), image.NewUniform(text), image.Point{}, draw.Src)
+			}
+		}
+		x += glyphAdvance * scale
+	}
+}
@@ -0,0 +1,42 @@
+package card_test
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+	"time"
+
+	"derrclan.com/moravian-soap/internal/card"
+)
+
+func TestYear(t *testing.T) {
+	img := card.Year(2026, []string{"42 days journaled", "Psalms · Romans · John", "a line far too long to fit on the card without being cut short"}, []string{"2026-01-01", "2026-12-31", "2025-06-01"}, time.Monday)
+	data, err := card.PNG(img)
+	if err != nil {
+		t.Fatalf("PNG failed: %v", err)
+	}
+	decoded, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("decoding card: %v", err)
+	}
+	if got := decoded.Bounds(); got != image.Rect(0, 0, card.Width, card.Height) {
+		t.Fatalf("card bounds = %v, want %dx%d", got, card.Width, card.Height)
+	}
+
+	counts := map[color.RGBA]int{}
+	b := decoded.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			counts[color.RGBAModel.Convert(decoded.At(x, y)).(color.RGBA)]++
+		}
+	}
+	// Two days of 2026 are filled in, each a 16-pixel square.
+	if got, want := counts[color.RGBA{0xFF, 0xD5, 0x4F, 0xFF}], 2*16*16; got != want {
+		t.Errorf("got %d pixels of filled-in days, want %d", got, want)
+	}
+	if counts[color.RGBA{0xFF, 0xFF, 0xFF, 0xFF}] == 0 {
+		t.Error("card has no text")
+	}
+}
@@ -0,0 +1,79 @@
+// Package civil provides a calendar date type without a time of day or time zone.
+package civil
+
+import (
+	"fmt"
+	"time"
+)
+
+// Date is a calendar date, such as the date of a daily text or journal entry.
+type Date struct {
+	Year  int
+	Month time.Month
+	Day   int
+}
+
+// ParseDate parses a date in YYYY-MM-DD format, rejecting dates that don't exist such as 2026-02-30.
+func ParseDate(s string) (Date, error) {
+	t, err := time.Parse(time.DateOnly, s)
+	if err != nil {
+		return Date{}, fmt.Errorf("parsing date %q: %w", s, err)
+	}
+	return DateOf(t), nil
+}
+
+// DateOf returns the date on which t falls in t's location.
+func DateOf(t time.Time) Date {
+	y, m, d := t.Date()
+	return Date{Year: y, Month: m, Day: d}
+}
+
+// String returns the date in YYYY-MM-DD format.
+func (d Date) String() string {
+	return fmt.Sprintf("%04d-%02d-%02d", d.Year, d.Month, d.Day)
+}
+
+// IsZero reports whether d is the zero value.
+func (d Date) IsZero() bool {
+	return d == Date{}
+}
+
+// Time returns midnight UTC at the start of the date.
+func (d Date) Time() time.Time {
+	return time.Date(d.Year, d.Month, d.Day, 0, 0, 0, 0, time.UTC)
+}
+
+// AddDays returns the date n days after d (or before, if n is negative).
+func (d Date) AddDays(n int) Date {
+	return DateOf(d.Time().AddDate(0, 0, n))
+}
+
+// Weekday returns the day of the week of the date.
+func (d Date) Weekday() time.Weekday {
+	return d.Time().Weekday()
+}
+
+// Before reports whether d is before other.
+func (d Date) Before(other Date) bool {
+	return d.Time().Before(other.Time())
+}
+
+// After reports whether d is after other.
+func (d Date) After(other Date) bool {
+	return d.Time().After(other.Time())
+}
+
+// MarshalText implements encoding.TextMarshaler using the YYYY-MM-DD format.
+func (d Date) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler using the YYYY-MM-DD format.
+func (d *Date) UnmarshalText(b []byte) error {
+	parsed, err := ParseDate(string(b))
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
@@ -0,0 +1,69 @@
+package civil_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"derrclan.com/moravian-soap/internal/civil"
+)
+
+func TestParseDate(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    civil.Date
+		wantErr bool
+	}{
+		{in: "2026-01-05", want: civil.Date{Year: 2026, Month: time.January, Day: 5}},
+		{in: "2024-02-29", want: civil.Date{Year: 2024, Month: time.February, Day: 29}},
+		{in: "2025-02-29", wantErr: true},
+		{in: "2026-02-30", wantErr: true},
+		{in: "2026-13-01", wantErr: true},
+		{in: "2026-1-5", wantErr: true},
+		{in: "2026-01-05T00:00:00Z", wantErr: true},
+		{in: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := civil.ParseDate(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseDate(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseDate(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestDate_AddDays(t *testing.T) {
+	d := civil.Date{Year: 2025, Month: time.December, Day: 31}
+	if got := d.AddDays(1).String(); got != "2026-01-01" {
+		t.Errorf("AddDays(1) = %s, want 2026-01-01", got)
+	}
+	if got := d.AddDays(-365).String(); got != "2024-12-31" {
+		t.Errorf("AddDays(-365) = %s, want 2024-12-31", got)
+	}
+}
+
+func TestDate_JSON(t *testing.T) {
+	var v struct {
+		Date civil.Date `json:"date"`
+	}
+	if err := json.Unmarshal([]byte(`{"date":"2026-03-01"}`), &v); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if v.Date.String() != "2026-03-01" {
+		t.Errorf("unexpected date: %v", v.Date)
+	}
+	if err := json.Unmarshal([]byte(`{"date":"2026-02-30"}`), &v); err == nil {
+		t.Error("expected error for impossible date")
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	if string(b) != `{"date":"2026-03-01"}` {
+		t.Errorf("unexpected JSON: %s", b)
+	}
+}
@@ -0,0 +1,42 @@
+package civil
+
+import "time"
+
+// Weeks is how days are grouped into weeks and the weeks of a year numbered, as a user prefers.
+type Weeks struct {
+	// First is the day weeks start on, Sunday for the zero value.
+	First time.Weekday
+	// ISO numbers the weeks as ISO 8601 does, from the week holding the year's first Thursday, and
+	// starts them on Monday whatever First is.
+	ISO bool
+}
+
+// FirstDay returns the day weeks start on, which is Monday for ISO 8601 weeks.
+func (w Weeks) FirstDay() time.Weekday {
+	if w.ISO {
+		return time.Monday
+	}
+	return w.First
+}
+
+// Start returns the first day of the week holding d.
+func (w Weeks) Start(d Date) Date {
+	return d.AddDays(-w.daysIntoWeek(d))
+}
+
+// daysIntoWeek returns how many days of its week come before d.
+func (w Weeks) daysIntoWeek(d Date) int {
+	return (int(d.Weekday()) - int(w.FirstDay()) + 7) % 7
+}
+
+// Number returns the number of the week holding d and the year it is numbered in. Weeks are numbered
+// from 1, for the week holding January 1, unless the numbering is ISO 8601, in which the first days of
+// January can be in the last week of the year before and the last days of December in the first week of
+// the year after.
+func (w Weeks) Number(d Date) (year, week int) {
+	if w.ISO {
+		return d.Time().ISOWeek()
+	}
+	jan1 := Date{Year: d.Year, Month: time.January, Day: 1}
+	return d.Year, (d.Time().YearDay()-1+w.daysIntoWeek(jan1))/7 + 1
+}
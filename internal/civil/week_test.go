@@ -0,0 +1,45 @@
+package civil_test
+
+import (
+	"testing"
+	"time"
+
+	"derrclan.com/moravian-soap/internal/civil"
+)
+
+func TestWeeks(t *testing.T) {
+	sunday := civil.Weeks{}
+	monday := civil.Weeks{First: time.Monday}
+	iso := civil.Weeks{ISO: true}
+	tests := []struct {
+		weeks     civil.Weeks
+		date      string
+		wantStart string
+		wantYear  int
+		wantWeek  int
+	}{
+		// January 1, 2026 is a Thursday.
+		{sunday, "2026-01-01", "2025-12-28", 2026, 1},
+		{sunday, "2026-01-04", "2026-01-04", 2026, 2},
+		{sunday, "2026-03-07", "2026-03-01", 2026, 10},
+		{monday, "2026-01-04", "2025-12-29", 2026, 1},
+		{monday, "2026-01-05", "2026-01-05", 2026, 2},
+		{monday, "2025-12-31", "2025-12-29", 2025, 53},
+		// ISO weeks start on Monday even if First says otherwise.
+		{civil.Weeks{First: time.Sunday, ISO: true}, "2026-01-04", "2025-12-29", 2026, 1},
+		{iso, "2027-01-01", "2026-12-28", 2026, 53},
+		{iso, "2024-12-30", "2024-12-30", 2025, 1},
+	}
+	for _, tt := range tests {
+		d, err := civil.ParseDate(tt.date)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := tt.weeks.Start(d).String(); got != tt.wantStart {
+			t.Errorf("%+v.Start(%s) = %s, want %s", tt.weeks, tt.date, got, tt.wantStart)
+		}
+		if year, week := tt.weeks.Number(d); year != tt.wantYear || week != tt.wantWeek {
+			t.Errorf("%+v.Number(%s) = %d week %d, want %d week %d", tt.weeks, tt.date, year, week, tt.wantYear, tt.wantWeek)
+		}
+	}
+}
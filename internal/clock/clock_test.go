@@ -0,0 +1,26 @@
+package clock_test
+
+import (
+	"testing"
+	"time"
+
+	"derrclan.com/moravian-soap/internal/clock"
+)
+
+func TestFake(t *testing.T) {
+	start := time.Date(2026, time.December, 31, 23, 59, 0, 0, time.UTC)
+	c := clock.NewFake(start)
+	if got := c.Now(); !got.Equal(start) {
+		t.Errorf("Now() = %v, want %v", got, start)
+	}
+
+	c.Advance(2 * time.Minute)
+	if want := time.Date(2027, time.January, 1, 0, 1, 0, 0, time.UTC); !c.Now().Equal(want) {
+		t.Errorf("after Advance, Now() = %v, want %v", c.Now(), want)
+	}
+
+	c.Set(start)
+	if !c.Now().Equal(start) {
+		t.Errorf("after Set, Now() = %v, want %v", c.Now(), start)
+	}
+}
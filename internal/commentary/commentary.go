@@ -0,0 +1,109 @@
+// Package commentary supplies devotional commentary on the daily readings, shown below the passages.
+// The app has none of its own: a deployment supplies it, either from a pack of commentary it ships
+// (see Pack) or from a provider it runs at a URL (see Remote), or by implementing Commentary itself.
+package commentary
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+	"time"
+
+	"derrclan.com/moravian-soap/internal/civil"
+)
+
+// Note is a piece of commentary.
+type Note struct {
+	// Reference is the passage the note is on, e.g. "John 3:16-21", or empty if it is on the day.
+	Reference string `json:"reference,omitempty"`
+	Title     string `json:"title,omitempty"`
+	// Body is the commentary, in the Markdown that journal entries are written in.
+	Body string `json:"body"`
+	// Source credits the note's author or where it was taken from.
+	Source string `json:"source,omitempty"`
+}
+
+// Commentary supplies the commentary on the days' readings.
+type Commentary interface {
+	// For returns the commentary for date, whose readings are passages, or none if it has none. Notes
+	// on the day come before notes on its passages, which are in the order of the passages.
+	For(ctx context.Context, date civil.Date, passages []string) ([]Note, error)
+}
+
+// Pack is commentary written ahead of time, such as a devotional a deployment embeds in its build.
+// It is decoded from JSON of the form
+//
+//	{
+//	  "dates": {"2026-12-25": [{"title": "Christmas Day", "body": "..."}], "01-06": [...]},
+//	  "passages": {"John 3:16-21": [{"body": "..."}]}
+//	}
+//
+// Notes on a day are keyed by its date, or by its month and day to be shown on that day every year,
+// and notes on a passage by its reference as the daily texts give it.
+type Pack struct {
+	Dates    map[string][]Note `json:"dates"`
+	Passages map[string][]Note `json:"passages"`
+}
+
+// ParsePack decodes and validates a pack.
+func ParsePack(data []byte) (*Pack, error) {
+	var p Pack
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("decoding commentary pack: %w", err)
+	}
+	var errs []error
+	for key, notes := range p.Dates {
+		if _, err := civil.ParseDate(key); err != nil {
+			if _, err := time.Parse("01-02", key); err != nil {
+				errs = append(errs, fmt.Errorf("date %q: must be YYYY-MM-DD or MM-DD", key))
+			}
+		}
+		errs = append(errs, checkNotes(key, notes))
+	}
+	for ref, notes := range p.Passages {
+		if strings.TrimSpace(ref) == "" {
+			errs = append(errs, errors.New("passage with no reference"))
+		}
+		for i := range notes {
+			notes[i].Reference = ref
+		}
+		errs = append(errs, checkNotes(ref, notes))
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, fmt.Errorf("invalid commentary pack: %w", err)
+	}
+	return &p, nil
+}
+
+// checkNotes returns an error if any of the notes under key has no body.
+func checkNotes(key string, notes []Note) error {
+	for i, n := range notes {
+		if strings.TrimSpace(n.Body) == "" {
+			return fmt.Errorf("%s: note %d has no body", key, i+1)
+		}
+	}
+	return nil
+}
+
+// LoadPack reads and parses the pack in the file name of fsys, which may be an embed.FS.
+func LoadPack(fsys fs.FS, name string) (*Pack, error) {
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return nil, fmt.Errorf("reading commentary pack: %w", err)
+	}
+	return ParsePack(data)
+}
+
+// For returns the pack's notes on date, those keyed by its full date first, and on each of passages.
+func (p *Pack) For(_ context.Context, date civil.Date, passages []string) ([]Note, error) {
+	var notes []Note
+	notes = append(notes, p.Dates[date.String()]...)
+	notes = append(notes, p.Dates[fmt.Sprintf("%02d-%02d", date.Month, date.Day)]...)
+	for _, ref := range passages {
+		notes = append(notes, p.Passages[ref]...)
+	}
+	return notes, nil
+}
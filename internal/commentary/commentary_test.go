@@ -0,0 +1,124 @@
+package commentary_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"testing/fstest"
+
+	"derrclan.com/moravian-soap/internal/civil"
+	"derrclan.com/moravian-soap/internal/commentary"
+)
+
+const pack = `{
+	"dates": {
+		"2026-12-25": [{"title": "Christmas Day", "body": "Unto us a child is born."}],
+		"12-25": [{"body": "Every Christmas."}],
+		"02-29": [{"body": "A leap day."}]
+	},
+	"passages": {
+		"John 3:16-21": [{"body": "God so loved the world.", "source": "A Devotional"}],
+		"Psalm 23": [{"body": "The Lord is my shepherd."}]
+	}
+}`
+
+func TestPack_For(t *testing.T) {
+	p, err := commentary.LoadPack(fstest.MapFS{"pack.json": {Data: []byte(pack)}}, "pack.json")
+	if err != nil {
+		t.Fatalf("LoadPack failed: %v", err)
+	}
+
+	notes, err := p.For(context.Background(), civil.Date{Year: 2026, Month: 12, Day: 25}, []string{"Psalm 23", "Luke 2:1-7", "John 3:16-21"})
+	if err != nil {
+		t.Fatalf("For failed: %v", err)
+	}
+	var got []string
+	for _, n := range notes {
+		got = append(got, n.Reference+": "+n.Body)
+	}
+	want := []string{
+		": Unto us a child is born.",
+		": Every Christmas.",
+		"Psalm 23: The Lord is my shepherd.",
+		"John 3:16-21: God so loved the world.",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("For(2026-12-25) = %q, want %q", got, want)
+	}
+
+	notes, _ = p.For(context.Background(), civil.Date{Year: 2027, Month: 12, Day: 25}, nil)
+	if len(notes) != 1 || notes[0].Body != "Every Christmas." {
+		t.Errorf("For(2027-12-25) = %+v, want only the yearly note", notes)
+	}
+	if notes, _ := p.For(context.Background(), civil.Date{Year: 2026, Month: 3, Day: 1}, []string{"Genesis 1"}); len(notes) != 0 {
+		t.Errorf("For a day with no commentary = %+v", notes)
+	}
+}
+
+func TestParsePack_Invalid(t *testing.T) {
+	tests := map[string]string{
+		"not JSON":     `{`,
+		"bad date":     `{"dates": {"Christmas": [{"body": "x"}]}}`,
+		"no such day":  `{"dates": {"02-30": [{"body": "x"}]}}`,
+		"empty body":   `{"dates": {"2026-12-25": [{"title": "Christmas"}]}}`,
+		"no reference": `{"passages": {" ": [{"body": "x"}]}}`,
+	}
+	for name, data := range tests {
+		if _, err := commentary.ParsePack([]byte(data)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestRemote(t *testing.T) {
+	var requests []string
+	provider := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.RawQuery)
+		switch r.URL.Query().Get("date") {
+		case "2026-03-01":
+			_, _ = w.Write([]byte(`{"notes": [{"reference": "Psalm 23", "body": "The Lord is my shepherd."}, {"title": "Empty"}]}`))
+		case "2026-03-02":
+			http.NotFound(w, r)
+		default:
+			http.Error(w, "broken", http.StatusInternalServerError)
+		}
+	}))
+	defer provider.Close()
+
+	remote, err := commentary.NewRemote(provider.URL + "/commentary?translation=esv")
+	if err != nil {
+		t.Fatalf("NewRemote failed: %v", err)
+	}
+	ctx := context.Background()
+	day := civil.Date{Year: 2026, Month: 3, Day: 1}
+	for range 2 {
+		notes, err := remote.For(ctx, day, []string{"Psalm 23", "John 3:16-21"})
+		if err != nil {
+			t.Fatalf("For failed: %v", err)
+		}
+		if len(notes) != 1 || notes[0].Reference != "Psalm 23" {
+			t.Errorf("For = %+v, want the note with a body", notes)
+		}
+	}
+	want := []string{"translation=esv&date=2026-03-01&passage=Psalm+23&passage=John+3%3A16-21"}
+	if !slices.Equal(requests, want) {
+		t.Errorf("provider was asked %q, want %q", requests, want)
+	}
+
+	if notes, err := remote.For(ctx, day.AddDays(1), nil); err != nil || len(notes) != 0 {
+		t.Errorf("For a day the provider has nothing for = %+v, %v", notes, err)
+	}
+	if _, err := remote.For(ctx, day.AddDays(2), nil); err == nil {
+		t.Error("expected an error when the provider fails")
+	}
+}
+
+func TestNewRemote_Invalid(t *testing.T) {
+	for _, u := range []string{"", "commentary.example.com", "ftp://commentary.example.com", "https://"} {
+		if _, err := commentary.NewRemote(u); err == nil {
+			t.Errorf("NewRemote(%q): expected an error", u)
+		}
+	}
+}
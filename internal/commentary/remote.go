@@ -0,0 +1,132 @@
+package commentary
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"derrclan.com/moravian-soap/internal/civil"
+)
+
+// DefaultRemoteTTL is how long a Remote keeps the commentary it fetched for a day.
+const DefaultRemoteTTL = time.Hour
+
+// maxRemoteBody limits the size of a provider's response.
+const maxRemoteBody = 1 << 20
+
+// Remote fetches commentary from a provider a deployment runs. It asks for a day's commentary with
+//
+//	GET URL?date=2026-03-01&passage=Psalm+23&passage=John+3:16-21
+//
+// and the provider answers with the notes as JSON, {"notes": [...]}, or 404 Not Found if it has none.
+// Notes without a body are dropped. What it answers is kept for TTL, so that the provider is asked
+// about each day only so often however many read it.
+type Remote struct {
+	URL        string
+	HTTPClient *http.Client
+	TTL        time.Duration
+
+	mu     sync.Mutex
+	cached map[string]remoteNotes
+}
+
+// remoteNotes are the notes fetched for a day and when they were fetched.
+type remoteNotes struct {
+	notes []Note
+	at    time.Time
+}
+
+// NewRemote returns a Remote of the provider at rawURL, which must be an http or https URL.
+func NewRemote(rawURL string) (*Remote, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("commentary provider %q: must be an http or https URL", rawURL)
+	}
+	return &Remote{
+		URL:        rawURL,
+		HTTPClient: &http.Client{Timeout: 5 * time.Second},
+		TTL:        DefaultRemoteTTL,
+	}, nil
+}
+
+// For returns the provider's commentary for date, from what it answered within the last TTL if it
+// was asked about the same day and passages.
+func (r *Remote) For(ctx context.Context, date civil.Date, passages []string) ([]Note, error) {
+	key := date.String() + "|" + strings.Join(passages, "|")
+	now := time.Now()
+	r.mu.Lock()
+	if c, ok := r.cached[key]; ok && now.Sub(c.at) < r.TTL {
+		r.mu.Unlock()
+		return c.notes, nil
+	}
+	r.mu.Unlock()
+
+	notes, err := r.fetch(ctx, date, passages)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.cached == nil {
+		r.cached = make(map[string]remoteNotes)
+	}
+	for k, c := range r.cached {
+		if now.Sub(c.at) >= r.TTL {
+			delete(r.cached, k)
+		}
+	}
+	r.cached[key] = remoteNotes{notes: notes, at: now}
+	return notes, nil
+}
+
+// fetch asks the provider for the commentary for date.
+func (r *Remote) fetch(ctx context.Context, date civil.Date, passages []string) ([]Note, error) {
+	params := url.Values{"date": {date.String()}, "passage": passages}
+	sep := "?"
+	if strings.Contains(r.URL, "?") {
+		sep = "&"
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.URL+sep+params.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	client := r.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetching commentary for %s: %w", date, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, nil
+	default:
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("fetching commentary for %s: %s: %s", date, resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	var body struct {
+		Notes []Note `json:"notes"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxRemoteBody)).Decode(&body); err != nil {
+		return nil, fmt.Errorf("decoding commentary for %s: %w", date, err)
+	}
+	var notes []Note
+	for _, n := range body.Notes {
+		if strings.TrimSpace(n.Body) != "" {
+			notes = append(notes, n)
+		}
+	}
+	return notes, nil
+}
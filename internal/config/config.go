@@ -0,0 +1,310 @@
+// Package config provides the settings that can be changed while the server is running.
+//
+// Settings are read from environment variables (and the .env file, if present). They are re-read and
+// re-validated by Reload, which is triggered by SIGHUP or the admin reload endpoint, and only swapped in
+// when the new settings are valid.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"derrclan.com/moravian-soap/internal/features"
+	"github.com/joho/godotenv"
+)
+
+// Config holds the reloadable settings.
+type Config struct {
+	// LogLevel is the minimum level of log messages (LOG_LEVEL: debug, info, warn or error).
+	LogLevel slog.Level
+	// PrefetchInterval is the minimum time between ESV cache prefetches for a single user (PREFETCH_INTERVAL).
+	PrefetchInterval time.Duration
+	// ESVDailyQuota is the number of ESV API requests the instance may make per day (ESV_DAILY_QUOTA).
+	ESVDailyQuota int
+	// ESVCacheMaxBytes caps the total size of cached ESV passages; the least recently used are evicted
+	// beyond it (ESV_CACHE_MAX_BYTES). Zero disables the cap.
+	ESVCacheMaxBytes int64
+	// ESVCacheTTL is how long a cached ESV passage is used before it is fetched again (ESV_CACHE_TTL).
+	ESVCacheTTL time.Duration
+	// ESVCacheMaxEntries is the most ESV passages kept in the cache (ESV_CACHE_MAX_ENTRIES).
+	ESVCacheMaxEntries int
+	// ESVVerifyTransform checks that no text of an ESV passage is lost when its verses are made
+	// selectable, and shows the passage as the ESV API sent it if any was (ESV_VERIFY_TRANSFORM).
+	ESVVerifyTransform bool
+	// TextsDir is a directory of YYYY.json daily text files that take precedence over the embedded
+	// texts (DAILY_TEXTS_DIR). It is empty to use only the embedded texts.
+	TextsDir string
+	// TextsStitchYears takes a New Year's Eve or New Year's Day text missing from its own year file from
+	// the adjacent year's file (DAILY_TEXTS_STITCH_YEARS), for datasets that key watch-night texts that way.
+	TextsStitchYears bool
+	// PageBudget is how long a page may take to load before it is sent with the content that is ready,
+	// leaving slower parts to load afterwards (PAGE_BUDGET). ESV API fetches get a share of it.
+	PageBudget time.Duration
+	// RenderMarkdown renders the sections of exported entries as sanitized Markdown rather than as
+	// plain text (RENDER_MARKDOWN).
+	RenderMarkdown bool
+	// PublicCacheTTL is how long browsers and shared caches, such as a CDN in front of the instance, may
+	// keep responses to public requests like shared year in review cards (PUBLIC_CACHE_TTL). Zero has
+	// them revalidate every time.
+	PublicCacheTTL time.Duration
+	// CacheSigningKey signs the versions in the URLs of public responses that change, so that only the
+	// server can make a URL that a shared cache keeps (CACHE_SIGNING_KEY). Without it the versions are
+	// digests of the content, which anyone can compute but not choose.
+	CacheSigningKey string
+	// TrustProxy takes the address of a request from the X-Forwarded-For header, for an instance behind a
+	// reverse proxy that sets it (TRUST_PROXY). Otherwise the header, which anyone can send, is ignored.
+	TrustProxy bool
+	// Features are the modes of the optional subsystems, such as "api=off,psalter=optin" (FEATURES).
+	// Features not listed have their defaults, which is on for all but guest_comments.
+	Features features.Modes
+}
+
+// maxESVDailyQuota is the number of queries per day allowed by the ESV API terms of use.
+const maxESVDailyQuota = 5000
+
+// The ESV API terms of use allow keeping no more than 500 passages, and none for longer than 30 days.
+const (
+	maxESVCacheTTL     = 30 * 24 * time.Hour
+	maxESVCacheEntries = 500
+)
+
+var current atomic.Pointer[Config]
+
+var (
+	dotenvMu sync.Mutex
+	// dotenvKeys records the variables that were set from the .env file rather than by the process
+	// environment, so that reloading the file can update them without overriding the environment.
+	dotenvKeys = map[string]bool{}
+)
+
+// LoadDotenv sets the variables in the .env file, if present, that are not already set by the process
+// environment. Variables it set earlier are updated to the file's current values.
+func LoadDotenv() error {
+	values, err := godotenv.Read()
+	if err != nil {
+		return fmt.Errorf("reading .env: %w", err)
+	}
+
+	dotenvMu.Lock()
+	defer dotenvMu.Unlock()
+	for key, value := range values {
+		if _, set := os.LookupEnv(key); set && !dotenvKeys[key] {
+			continue
+		}
+		if err := os.Setenv(key, value); err != nil {
+			return fmt.Errorf("setting %s: %w", key, err)
+		}
+		dotenvKeys[key] = true
+	}
+	return nil
+}
+
+// LogLevel is the level used by the default logger. It is updated whenever the configuration changes.
+var LogLevel = new(slog.LevelVar)
+
+// Current returns the active configuration, loading it from the environment on first use.
+func Current() *Config {
+	if c := current.Load(); c != nil {
+		return c
+	}
+	c, err := Load()
+	if err != nil {
+		slog.Error("invalid configuration, using defaults", "error", err)
+		c = Default()
+	}
+	if current.CompareAndSwap(nil, c) {
+		LogLevel.Set(c.LogLevel)
+	}
+	return current.Load()
+}
+
+// Default returns the configuration used when no settings are provided.
+func Default() *Config {
+	return &Config{
+		LogLevel:           slog.LevelInfo,
+		PrefetchInterval:   2 * time.Second,
+		ESVDailyQuota:      maxESVDailyQuota,
+		ESVCacheMaxBytes:   32 << 20,
+		ESVCacheTTL:        28 * 24 * time.Hour,
+		ESVCacheMaxEntries: maxESVCacheEntries,
+		PageBudget:         4 * time.Second,
+		PublicCacheTTL:     time.Hour,
+	}
+}
+
+// Load reads and validates the configuration from the environment.
+func Load() (*Config, error) {
+	c := Default()
+	var errs []error
+
+	if v := os.Getenv("LOG_LEVEL"); v != "" {
+		if err := c.LogLevel.UnmarshalText([]byte(v)); err != nil {
+			errs = append(errs, fmt.Errorf("LOG_LEVEL: %w", err))
+		}
+	}
+	if v := os.Getenv("PREFETCH_INTERVAL"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("PREFETCH_INTERVAL: %w", err))
+		} else if d < 0 {
+			errs = append(errs, fmt.Errorf("PREFETCH_INTERVAL: must not be negative, got %s", d))
+		}
+		c.PrefetchInterval = d
+	}
+	if v := os.Getenv("ESV_DAILY_QUOTA"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("ESV_DAILY_QUOTA: %w", err))
+		} else if n < 0 || n > maxESVDailyQuota {
+			errs = append(errs, fmt.Errorf("ESV_DAILY_QUOTA: must be between 0 and %d, got %d", maxESVDailyQuota, n))
+		}
+		c.ESVDailyQuota = n
+	}
+	if v := os.Getenv("ESV_CACHE_MAX_BYTES"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("ESV_CACHE_MAX_BYTES: %w", err))
+		} else if n < 0 {
+			errs = append(errs, fmt.Errorf("ESV_CACHE_MAX_BYTES: must not be negative, got %d", n))
+		}
+		c.ESVCacheMaxBytes = n
+	}
+	if v := os.Getenv("ESV_CACHE_TTL"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("ESV_CACHE_TTL: %w", err))
+		} else if d <= 0 || d > maxESVCacheTTL {
+			errs = append(errs, fmt.Errorf("ESV_CACHE_TTL: must be positive and at most %s, got %s", maxESVCacheTTL, d))
+		}
+		c.ESVCacheTTL = d
+	}
+	if v := os.Getenv("ESV_CACHE_MAX_ENTRIES"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("ESV_CACHE_MAX_ENTRIES: %w", err))
+		} else if n < 1 || n > maxESVCacheEntries {
+			errs = append(errs, fmt.Errorf("ESV_CACHE_MAX_ENTRIES: must be between 1 and %d, got %d", maxESVCacheEntries, n))
+		}
+		c.ESVCacheMaxEntries = n
+	}
+	if v := os.Getenv("ESV_VERIFY_TRANSFORM"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("ESV_VERIFY_TRANSFORM: %w", err))
+		}
+		c.ESVVerifyTransform = b
+	}
+	if v := os.Getenv("PAGE_BUDGET"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("PAGE_BUDGET: %w", err))
+		} else if d <= 0 {
+			errs = append(errs, fmt.Errorf("PAGE_BUDGET: must be positive, got %s", d))
+		}
+		c.PageBudget = d
+	}
+	if v := os.Getenv("DAILY_TEXTS_DIR"); v != "" {
+		info, err := os.Stat(v)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("DAILY_TEXTS_DIR: %w", err))
+		} else if !info.IsDir() {
+			errs = append(errs, fmt.Errorf("DAILY_TEXTS_DIR: %s is not a directory", v))
+		}
+		c.TextsDir = v
+	}
+	if v := os.Getenv("DAILY_TEXTS_STITCH_YEARS"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("DAILY_TEXTS_STITCH_YEARS: %w", err))
+		}
+		c.TextsStitchYears = b
+	}
+	if v := os.Getenv("RENDER_MARKDOWN"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("RENDER_MARKDOWN: %w", err))
+		}
+		c.RenderMarkdown = b
+	}
+	if v := os.Getenv("PUBLIC_CACHE_TTL"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("PUBLIC_CACHE_TTL: %w", err))
+		} else if d < 0 {
+			errs = append(errs, fmt.Errorf("PUBLIC_CACHE_TTL: must not be negative, got %s", d))
+		}
+		c.PublicCacheTTL = d
+	}
+	c.CacheSigningKey = os.Getenv("CACHE_SIGNING_KEY")
+	if v := os.Getenv("TRUST_PROXY"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("TRUST_PROXY: %w", err))
+		}
+		c.TrustProxy = b
+	}
+	if v := os.Getenv("FEATURES"); v != "" {
+		modes, err := features.ParseModes(v)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("FEATURES: %w", err))
+		}
+		c.Features = modes
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+	return c, nil
+}
+
+// Reload re-reads the .env file and the environment and, if the new configuration is valid, makes it
+// the active configuration. Variables set by the process environment take precedence over the .env
+// file, as they do at startup. It returns the previous and new configurations.
+func Reload() (old, updated *Config, err error) {
+	if err := LoadDotenv(); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, nil, err
+	}
+	updated, err = Load()
+	if err != nil {
+		return nil, nil, err
+	}
+	old = Current()
+	current.Store(updated)
+	LogLevel.Set(updated.LogLevel)
+	return old, updated, nil
+}
+
+// Diff describes the settings that differ between two configurations, e.g. "LogLevel: INFO -> DEBUG".
+func Diff(a, b *Config) []string {
+	var changes []string
+	add := func(name string, from, to any) {
+		if from != to {
+			changes = append(changes, fmt.Sprintf("%s: %v -> %v", name, from, to))
+		}
+	}
+	add("LogLevel", a.LogLevel, b.LogLevel)
+	add("PrefetchInterval", a.PrefetchInterval, b.PrefetchInterval)
+	add("ESVDailyQuota", a.ESVDailyQuota, b.ESVDailyQuota)
+	add("ESVCacheMaxBytes", a.ESVCacheMaxBytes, b.ESVCacheMaxBytes)
+	add("ESVCacheTTL", a.ESVCacheTTL, b.ESVCacheTTL)
+	add("ESVCacheMaxEntries", a.ESVCacheMaxEntries, b.ESVCacheMaxEntries)
+	add("ESVVerifyTransform", a.ESVVerifyTransform, b.ESVVerifyTransform)
+	add("PageBudget", a.PageBudget, b.PageBudget)
+	add("TextsDir", strconv.Quote(a.TextsDir), strconv.Quote(b.TextsDir))
+	add("TextsStitchYears", a.TextsStitchYears, b.TextsStitchYears)
+	add("RenderMarkdown", a.RenderMarkdown, b.RenderMarkdown)
+	add("PublicCacheTTL", a.PublicCacheTTL, b.PublicCacheTTL)
+	if a.CacheSigningKey != b.CacheSigningKey {
+		// The key itself is a secret and is never logged.
+		changes = append(changes, "CacheSigningKey: changed")
+	}
+	add("TrustProxy", a.TrustProxy, b.TrustProxy)
+	add("Features", strconv.Quote(a.Features.String()), strconv.Quote(b.Features.String()))
+	return changes
+}
@@ -0,0 +1,148 @@
+package config_test
+
+import (
+	"log/slog"
+	"os"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	"derrclan.com/moravian-soap/internal/config"
+	"derrclan.com/moravian-soap/internal/features"
+)
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("LOG_LEVEL", "debug")
+	t.Setenv("PREFETCH_INTERVAL", "5s")
+	t.Setenv("ESV_DAILY_QUOTA", "1000")
+	t.Setenv("ESV_CACHE_MAX_BYTES", "1048576")
+	t.Setenv("ESV_CACHE_TTL", "168h")
+	t.Setenv("ESV_CACHE_MAX_ENTRIES", "200")
+	t.Setenv("ESV_VERIFY_TRANSFORM", "true")
+	t.Setenv("DAILY_TEXTS_DIR", dir)
+	t.Setenv("DAILY_TEXTS_STITCH_YEARS", "true")
+	t.Setenv("PAGE_BUDGET", "2500ms")
+	t.Setenv("RENDER_MARKDOWN", "1")
+	t.Setenv("PUBLIC_CACHE_TTL", "10m")
+	t.Setenv("CACHE_SIGNING_KEY", "s3cret")
+	t.Setenv("TRUST_PROXY", "true")
+	t.Setenv("FEATURES", "api=off, psalter=OptIn,stats=on")
+
+	c, err := config.Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	want := &config.Config{
+		LogLevel:           slog.LevelDebug,
+		PrefetchInterval:   5 * time.Second,
+		ESVDailyQuota:      1000,
+		ESVCacheMaxBytes:   1 << 20,
+		ESVCacheTTL:        7 * 24 * time.Hour,
+		ESVCacheMaxEntries: 200,
+		ESVVerifyTransform: true,
+		TextsDir:           dir,
+		TextsStitchYears:   true,
+		PageBudget:         2500 * time.Millisecond,
+		RenderMarkdown:     true,
+		PublicCacheTTL:     10 * time.Minute,
+		CacheSigningKey:    "s3cret",
+		TrustProxy:         true,
+		Features:           features.Modes{features.API: features.Off, features.Psalter: features.OptIn, features.Stats: features.On},
+	}
+	if !reflect.DeepEqual(c, want) {
+		t.Errorf("Load() = %+v, want %+v", c, want)
+	}
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		key   string
+		value string
+	}{
+		{"log level", "LOG_LEVEL", "loud"},
+		{"prefetch interval", "PREFETCH_INTERVAL", "soon"},
+		{"negative prefetch interval", "PREFETCH_INTERVAL", "-1s"},
+		{"quota", "ESV_DAILY_QUOTA", "lots"},
+		{"quota above ESV limit", "ESV_DAILY_QUOTA", "5001"},
+		{"cache size", "ESV_CACHE_MAX_BYTES", "32MB"},
+		{"negative cache size", "ESV_CACHE_MAX_BYTES", "-1"},
+		{"cache ttl", "ESV_CACHE_TTL", "a month"},
+		{"cache ttl above ESV limit", "ESV_CACHE_TTL", "721h"},
+		{"zero cache ttl", "ESV_CACHE_TTL", "0s"},
+		{"cache entries", "ESV_CACHE_MAX_ENTRIES", "many"},
+		{"cache entries above ESV limit", "ESV_CACHE_MAX_ENTRIES", "501"},
+		{"zero cache entries", "ESV_CACHE_MAX_ENTRIES", "0"},
+		{"verify transform", "ESV_VERIFY_TRANSFORM", "always"},
+		{"page budget", "PAGE_BUDGET", "4"},
+		{"zero page budget", "PAGE_BUDGET", "0s"},
+		{"texts dir", "DAILY_TEXTS_DIR", "/does/not/exist"},
+		{"stitch years", "DAILY_TEXTS_STITCH_YEARS", "sometimes"},
+		{"render markdown", "RENDER_MARKDOWN", "maybe"},
+		{"public cache ttl", "PUBLIC_CACHE_TTL", "an hour"},
+		{"negative public cache ttl", "PUBLIC_CACHE_TTL", "-1m"},
+		{"trust proxy", "TRUST_PROXY", "nginx"},
+		{"unknown feature", "FEATURES", "groups=off"},
+		{"feature mode", "FEATURES", "api=disabled"},
+		{"feature without mode", "FEATURES", "api"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(tt.key, tt.value)
+			if _, err := config.Load(); err == nil {
+				t.Errorf("expected error for %s=%q", tt.key, tt.value)
+			}
+		})
+	}
+}
+
+func TestDiff(t *testing.T) {
+	a := config.Default()
+	b := config.Default()
+	if changes := config.Diff(a, b); len(changes) != 0 {
+		t.Errorf("expected no changes, got %v", changes)
+	}
+
+	b.LogLevel = slog.LevelDebug
+	b.TextsDir = "/srv/texts"
+	b.Features = features.Modes{features.Stats: features.Off, features.API: features.On}
+	b.CacheSigningKey = "s3cret"
+	want := []string{`LogLevel: INFO -> DEBUG`, `TextsDir: "" -> "/srv/texts"`, `CacheSigningKey: changed`, `Features: "" -> "stats=off"`}
+	if changes := config.Diff(a, b); !slices.Equal(changes, want) {
+		t.Errorf("Diff() = %v, want %v", changes, want)
+	}
+}
+
+func TestReload_EnvironmentOverridesDotenv(t *testing.T) {
+	t.Chdir(t.TempDir())
+	writeDotenv := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(".env", []byte(content), 0o600); err != nil {
+			t.Fatalf("writing .env: %v", err)
+		}
+	}
+	t.Setenv("LOG_LEVEL", "warn")
+	// Unset, but restored when the test ends.
+	t.Setenv("ESV_DAILY_QUOTA", "")
+	os.Unsetenv("ESV_DAILY_QUOTA")
+	t.Cleanup(func() { config.LogLevel.Set(slog.LevelInfo) })
+
+	writeDotenv("LOG_LEVEL=debug\nESV_DAILY_QUOTA=100\n")
+	_, c, err := config.Reload()
+	if err != nil {
+		t.Fatalf("Reload failed: %v", err)
+	}
+	if c.LogLevel != slog.LevelWarn || c.ESVDailyQuota != 100 {
+		t.Errorf("after Reload: LogLevel = %v, ESVDailyQuota = %d; want WARN from the environment and 100 from .env", c.LogLevel, c.ESVDailyQuota)
+	}
+
+	writeDotenv("LOG_LEVEL=debug\nESV_DAILY_QUOTA=200\n")
+	if _, c, err = config.Reload(); err != nil {
+		t.Fatalf("Reload failed: %v", err)
+	}
+	if c.LogLevel != slog.LevelWarn || c.ESVDailyQuota != 200 {
+		t.Errorf("after editing .env: LogLevel = %v, ESVDailyQuota = %d; want WARN and 200", c.LogLevel, c.ESVDailyQuota)
+	}
+}
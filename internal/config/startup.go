@@ -0,0 +1,153 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DefaultDBPath is the path of the SQLite database when DB_PATH is not set.
+const DefaultDBPath = "/data/app.db"
+
+// Startup holds the settings read once when the server starts. Unlike Config, changing them takes a
+// restart.
+type Startup struct {
+	// ESVAPIKey authenticates requests to the ESV API (ESV_API_KEY). It is required.
+	ESVAPIKey string
+	// DBPath is the path of the SQLite database (DB_PATH).
+	DBPath string
+	// ListenAddr is the address the server listens on (LISTEN_ADDR), by default all interfaces on
+	// the port in PORT, or 8080 (443 when serving TLS).
+	ListenAddr string
+	// TemplatesDir is a directory of partial templates that replace the built-in ones (TEMPLATES_DIR).
+	TemplatesDir string
+	// CommentaryPack is a JSON file of commentary shown with the days' readings (COMMENTARY_PACK), and
+	// CommentaryURL a provider that is asked for it instead (COMMENTARY_URL). At most one may be set.
+	CommentaryPack, CommentaryURL string
+
+	// TLSCertFile and TLSKeyFile are the certificate and key the server serves TLS with
+	// (TLS_CERT_FILE, TLS_KEY_FILE). Both or neither must be set.
+	TLSCertFile, TLSKeyFile string
+	// AutocertDomains are the domains to serve TLS for with certificates obtained from Let's Encrypt
+	// (TLS_AUTOCERT_DOMAINS, comma-separated), in place of TLSCertFile and TLSKeyFile.
+	AutocertDomains []string
+	// AutocertEmail is the contact address given to Let's Encrypt (TLS_AUTOCERT_EMAIL).
+	AutocertEmail string
+	// AutocertCacheDir keeps the certificates obtained from Let's Encrypt across restarts
+	// (TLS_AUTOCERT_CACHE_DIR), by default the autocert directory beside the database.
+	AutocertCacheDir string
+	// RedirectAddr is the address of a plain HTTP listener that redirects to HTTPS when serving TLS
+	// (HTTP_REDIRECT_ADDR), e.g. ":80". It also answers Let's Encrypt's challenges. It is off if unset.
+	RedirectAddr string
+}
+
+// TLS reports whether the server serves TLS itself rather than plain HTTP.
+func (s *Startup) TLS() bool {
+	return s.TLSCertFile != "" || len(s.AutocertDomains) > 0
+}
+
+// DBPath returns the path of the SQLite database, set by DB_PATH.
+func DBPath() string {
+	if path := os.Getenv("DB_PATH"); path != "" {
+		return path
+	}
+	return DefaultDBPath
+}
+
+// LoadStartup reads the startup settings from the environment (and the .env file, once loaded by
+// LoadDotenv), reporting every missing or invalid setting at once so the server can fail fast.
+func LoadStartup() (*Startup, error) {
+	s := &Startup{
+		ESVAPIKey:        os.Getenv("ESV_API_KEY"),
+		DBPath:           DBPath(),
+		ListenAddr:       os.Getenv("LISTEN_ADDR"),
+		TemplatesDir:     os.Getenv("TEMPLATES_DIR"),
+		CommentaryPack:   os.Getenv("COMMENTARY_PACK"),
+		CommentaryURL:    os.Getenv("COMMENTARY_URL"),
+		TLSCertFile:      os.Getenv("TLS_CERT_FILE"),
+		TLSKeyFile:       os.Getenv("TLS_KEY_FILE"),
+		AutocertEmail:    os.Getenv("TLS_AUTOCERT_EMAIL"),
+		AutocertCacheDir: os.Getenv("TLS_AUTOCERT_CACHE_DIR"),
+		RedirectAddr:     os.Getenv("HTTP_REDIRECT_ADDR"),
+	}
+	for domain := range strings.SplitSeq(os.Getenv("TLS_AUTOCERT_DOMAINS"), ",") {
+		if domain = strings.TrimSpace(domain); domain != "" {
+			s.AutocertDomains = append(s.AutocertDomains, domain)
+		}
+	}
+	var errs []error
+
+	if s.ESVAPIKey == "" {
+		errs = append(errs, errors.New("ESV_API_KEY: must be set to an ESV API key (see https://api.esv.org/)"))
+	}
+	if (s.TLSCertFile == "") != (s.TLSKeyFile == "") {
+		errs = append(errs, errors.New("TLS_CERT_FILE, TLS_KEY_FILE: both must be set to serve TLS"))
+	}
+	if s.TLSCertFile != "" {
+		if _, err := os.Stat(s.TLSCertFile); err != nil {
+			errs = append(errs, fmt.Errorf("TLS_CERT_FILE: %w", err))
+		}
+	}
+	if s.TLSKeyFile != "" {
+		if _, err := os.Stat(s.TLSKeyFile); err != nil {
+			errs = append(errs, fmt.Errorf("TLS_KEY_FILE: %w", err))
+		}
+	}
+	if len(s.AutocertDomains) > 0 {
+		if s.TLSCertFile != "" {
+			errs = append(errs, errors.New("TLS_AUTOCERT_DOMAINS: cannot be set with TLS_CERT_FILE"))
+		}
+		if s.AutocertCacheDir == "" {
+			s.AutocertCacheDir = filepath.Join(filepath.Dir(s.DBPath), "autocert")
+		}
+	}
+	if s.ListenAddr == "" {
+		port := os.Getenv("PORT")
+		if port == "" {
+			port = "8080"
+			if s.TLS() {
+				port = "443"
+			}
+		}
+		s.ListenAddr = ":" + port
+	}
+	if _, _, err := net.SplitHostPort(s.ListenAddr); err != nil {
+		errs = append(errs, fmt.Errorf("LISTEN_ADDR: %w", err))
+	}
+	if s.RedirectAddr != "" {
+		if !s.TLS() {
+			errs = append(errs, errors.New("HTTP_REDIRECT_ADDR: requires TLS_CERT_FILE or TLS_AUTOCERT_DOMAINS"))
+		} else if _, _, err := net.SplitHostPort(s.RedirectAddr); err != nil {
+			errs = append(errs, fmt.Errorf("HTTP_REDIRECT_ADDR: %w", err))
+		}
+	}
+	if s.TemplatesDir != "" {
+		if info, err := os.Stat(s.TemplatesDir); err != nil {
+			errs = append(errs, fmt.Errorf("TEMPLATES_DIR: %w", err))
+		} else if !info.IsDir() {
+			errs = append(errs, fmt.Errorf("TEMPLATES_DIR: %s is not a directory", s.TemplatesDir))
+		}
+	}
+	if s.CommentaryPack != "" && s.CommentaryURL != "" {
+		errs = append(errs, errors.New("COMMENTARY_PACK, COMMENTARY_URL: only one may be set"))
+	}
+	if s.CommentaryPack != "" {
+		if _, err := os.Stat(s.CommentaryPack); err != nil {
+			errs = append(errs, fmt.Errorf("COMMENTARY_PACK: %w", err))
+		}
+	}
+	if s.CommentaryURL != "" {
+		if u, err := url.Parse(s.CommentaryURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs = append(errs, fmt.Errorf("COMMENTARY_URL: must be an http or https URL, not %q", s.CommentaryURL))
+		}
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, fmt.Errorf("invalid startup configuration: %w", err)
+	}
+	return s, nil
+}
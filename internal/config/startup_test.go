@@ -0,0 +1,127 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"derrclan.com/moravian-soap/internal/config"
+)
+
+func TestLoadStartup(t *testing.T) {
+	t.Setenv("ESV_API_KEY", "key")
+	t.Setenv("DB_PATH", "")
+	t.Setenv("LISTEN_ADDR", "")
+	t.Setenv("PORT", "9090")
+	t.Setenv("TEMPLATES_DIR", "")
+	clearTLS(t)
+
+	s, err := config.LoadStartup()
+	if err != nil {
+		t.Fatalf("LoadStartup failed: %v", err)
+	}
+	want := &config.Startup{ESVAPIKey: "key", DBPath: config.DefaultDBPath, ListenAddr: ":9090"}
+	if !reflect.DeepEqual(s, want) {
+		t.Errorf("LoadStartup() = %+v, want %+v", s, want)
+	}
+
+	t.Setenv("LISTEN_ADDR", "127.0.0.1:8000")
+	if s, err := config.LoadStartup(); err != nil || s.ListenAddr != "127.0.0.1:8000" {
+		t.Errorf("LoadStartup() = %+v, %v; want LISTEN_ADDR to take precedence over PORT", s, err)
+	}
+}
+
+// clearTLS unsets the TLS settings for the test.
+func clearTLS(t *testing.T) {
+	t.Helper()
+	for _, key := range []string{"TLS_CERT_FILE", "TLS_KEY_FILE", "TLS_AUTOCERT_DOMAINS", "TLS_AUTOCERT_EMAIL", "TLS_AUTOCERT_CACHE_DIR", "HTTP_REDIRECT_ADDR"} {
+		t.Setenv(key, "")
+	}
+}
+
+func TestLoadStartup_TLS(t *testing.T) {
+	t.Setenv("ESV_API_KEY", "key")
+	t.Setenv("DB_PATH", "/srv/soap/app.db")
+	t.Setenv("LISTEN_ADDR", "")
+	t.Setenv("PORT", "")
+	t.Setenv("TEMPLATES_DIR", "")
+	clearTLS(t)
+	t.Setenv("TLS_AUTOCERT_DOMAINS", "soap.example.com, www.soap.example.com")
+	t.Setenv("HTTP_REDIRECT_ADDR", ":80")
+
+	s, err := config.LoadStartup()
+	if err != nil {
+		t.Fatalf("LoadStartup failed: %v", err)
+	}
+	want := &config.Startup{
+		ESVAPIKey:        "key",
+		DBPath:           "/srv/soap/app.db",
+		ListenAddr:       ":443",
+		AutocertDomains:  []string{"soap.example.com", "www.soap.example.com"},
+		AutocertCacheDir: filepath.Join("/srv/soap", "autocert"),
+		RedirectAddr:     ":80",
+	}
+	if !reflect.DeepEqual(s, want) || !s.TLS() {
+		t.Errorf("LoadStartup() = %+v, want %+v", s, want)
+	}
+
+	clearTLS(t)
+	cert := filepath.Join(t.TempDir(), "cert.pem")
+	if err := os.WriteFile(cert, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("TLS_CERT_FILE", cert)
+	t.Setenv("TLS_KEY_FILE", cert)
+	if s, err := config.LoadStartup(); err != nil || !s.TLS() || s.ListenAddr != ":443" {
+		t.Errorf("LoadStartup() = %+v, %v; want TLS with the certificate files", s, err)
+	}
+}
+
+func TestLoadStartup_Invalid(t *testing.T) {
+	t.Setenv("ESV_API_KEY", "")
+	t.Setenv("LISTEN_ADDR", "localhost")
+	t.Setenv("TEMPLATES_DIR", "/does/not/exist")
+	clearTLS(t)
+	t.Setenv("TLS_CERT_FILE", "/does/not/exist.pem")
+	t.Setenv("HTTP_REDIRECT_ADDR", "nowhere")
+	t.Setenv("COMMENTARY_PACK", "/does/not/exist.json")
+	t.Setenv("COMMENTARY_URL", "commentary.example.com")
+
+	_, err := config.LoadStartup()
+	if err == nil {
+		t.Fatal("expected error for missing and invalid settings")
+	}
+	for _, name := range []string{"ESV_API_KEY", "LISTEN_ADDR", "TEMPLATES_DIR", "TLS_CERT_FILE", "TLS_KEY_FILE", "HTTP_REDIRECT_ADDR", "COMMENTARY_PACK", "COMMENTARY_URL"} {
+		if !strings.Contains(err.Error(), name) {
+			t.Errorf("error %q does not mention %s", err, name)
+		}
+	}
+}
+
+func TestLoadStartup_Commentary(t *testing.T) {
+	t.Setenv("ESV_API_KEY", "key")
+	t.Setenv("LISTEN_ADDR", "")
+	t.Setenv("TEMPLATES_DIR", "")
+	clearTLS(t)
+	pack := filepath.Join(t.TempDir(), "commentary.json")
+	if err := os.WriteFile(pack, []byte(`{}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("COMMENTARY_PACK", pack)
+	t.Setenv("COMMENTARY_URL", "")
+	if s, err := config.LoadStartup(); err != nil || s.CommentaryPack != pack {
+		t.Errorf("LoadStartup() = %+v, %v; want the commentary pack", s, err)
+	}
+	t.Setenv("COMMENTARY_PACK", "")
+	t.Setenv("COMMENTARY_URL", "https://commentary.example.com/notes")
+	if s, err := config.LoadStartup(); err != nil || s.CommentaryURL != "https://commentary.example.com/notes" {
+		t.Errorf("LoadStartup() = %+v, %v; want the commentary provider", s, err)
+	}
+	t.Setenv("COMMENTARY_PACK", pack)
+	if _, err := config.LoadStartup(); err == nil || !strings.Contains(err.Error(), "only one may be set") {
+		t.Errorf("LoadStartup() error = %v, want the pack and provider rejected together", err)
+	}
+}
@@ -0,0 +1,190 @@
+// Package dailyemail emails subscribed users the day's watchword and readings each morning, together
+// with what they wrote in their journal the day before, at a time chosen by each user in their own
+// timezone. On the first of each month, the email also looks back on the user's favorite entries.
+package dailyemail
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"derrclan.com/moravian-soap/internal/civil"
+	"derrclan.com/moravian-soap/internal/clock"
+	"derrclan.com/moravian-soap/internal/config"
+	"derrclan.com/moravian-soap/internal/dailytexts"
+	"derrclan.com/moravian-soap/internal/email"
+	"derrclan.com/moravian-soap/internal/esv"
+	"derrclan.com/moravian-soap/internal/features"
+	"derrclan.com/moravian-soap/internal/htmltext"
+	"derrclan.com/moravian-soap/internal/journal"
+	"derrclan.com/moravian-soap/internal/maintenance"
+	"derrclan.com/moravian-soap/internal/store"
+)
+
+// due returns the date in the subscriber's timezone at now, and whether the email for that date is due:
+// its send time has passed and it has not been sent yet.
+func due(sub *store.DailyEmailSubscriber, now time.Time) (civil.Date, bool) {
+	loc, err := time.LoadLocation(sub.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	local := now.In(loc)
+	today := civil.DateOf(local)
+	if today.String() == sub.LastSentDate {
+		return today, false
+	}
+	sendAt, err := time.Parse("15:04", sub.SendTime)
+	if err != nil {
+		return today, false
+	}
+	return today, local.Hour()*60+local.Minute() >= sendAt.Hour()*60+sendAt.Minute()
+}
+
+// Compose renders the email for the subscriber on date, linking to the instance at baseURL. It returns
+// nil if there is no daily text for the date.
+func Compose(ctx context.Context, s store.Store, sub *store.DailyEmailSubscriber, date civil.Date, baseURL string) (*email.Message, error) {
+	text, err := dailytexts.GetDailyText(date.String())
+	if err != nil {
+		return nil, fmt.Errorf("getting daily text for %s: %w", date, err)
+	}
+	if text == nil {
+		return nil, nil
+	}
+	// The watchword is linked rather than quoted, so that emails do not spend the ESV API's quota.
+	w, err := email.NewWatchword(text.WatchwordReference(), text.DailyWatchWord, esv.Response{})
+	if err != nil {
+		return nil, err
+	}
+
+	yesterday := date.AddDays(-1).String()
+	entry, err := s.GetSOAPData(ctx, sub.UserID, yesterday)
+	if err != nil {
+		return nil, fmt.Errorf("getting journal entry for %s: %w", yesterday, err)
+	}
+	prefs, err := s.GetPreferences(ctx, sub.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("getting preferences: %w", err)
+	}
+	schema, err := journal.ParseSchema(prefs.JournalSchema)
+	if err != nil {
+		schema = journal.DefaultSchema()
+	}
+
+	baseURL = strings.TrimSuffix(baseURL, "/")
+	var favorites []email.Favorite
+	if date.Day == 1 {
+		if favorites, err = listFavorites(ctx, s, sub.UserID, schema, baseURL); err != nil {
+			return nil, err
+		}
+	}
+	msg := email.DailyEmail(date.String(), w, text.Verses, schema.Entries(entry.Sections), favorites,
+		baseURL+"/?date="+date.String(), baseURL+"/?date="+yesterday, baseURL+"/settings/reminders",
+		baseURL+"/subscriptions/unsubscribe?token="+url.QueryEscape(sub.Token))
+	return &msg, nil
+}
+
+// maxFavorites is the most favorite entries listed in the email on the first of the month, and
+// maxFavoriteExcerpt the length of the excerpt of each, in characters.
+const (
+	maxFavorites       = 5
+	maxFavoriteExcerpt = 160
+)
+
+// listFavorites returns the user's favorite journal entries to look back on in the email on the first of
+// the month, in the order they arranged them, linking to the instance at baseURL.
+func listFavorites(ctx context.Context, s store.Store, userID int64, schema journal.Schema, baseURL string) ([]email.Favorite, error) {
+	dates, err := s.ListFavorites(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("listing favorites: %w", err)
+	}
+	var favorites []email.Favorite
+	for _, date := range dates[:min(len(dates), maxFavorites)] {
+		entry, err := s.GetSOAPData(ctx, userID, date)
+		if err != nil {
+			return nil, fmt.Errorf("getting journal entry for %s: %w", date, err)
+		}
+		f := email.Favorite{Date: date, URL: baseURL + "/?date=" + date}
+		for _, sec := range schema.Entries(entry.Sections) {
+			if sec.Content != "" {
+				f.Excerpt = htmltext.Excerpt(sec.Content, maxFavoriteExcerpt)
+				break
+			}
+		}
+		favorites = append(favorites, f)
+	}
+	return favorites, nil
+}
+
+// SendDue queues the daily email for every subscriber due one at now who has email available, linking
+// to the instance at baseURL. An email that fails to queue is retried the next time SendDue runs.
+func SendDue(ctx context.Context, s store.Store, now time.Time, baseURL string) {
+	subscribers, err := s.ListDailyEmailSubscribers(ctx)
+	if err != nil {
+		slog.Error("failed to list daily email subscribers", "error", err)
+		return
+	}
+	modes := config.Current().Features
+	for _, sub := range subscribers {
+		date, ok := due(sub, now)
+		if !ok {
+			continue
+		}
+		overrides, err := s.GetFeatureOverrides(ctx, sub.UserID)
+		if err != nil {
+			slog.Error("failed to get feature overrides", "user_id", sub.UserID, "error", err)
+			continue
+		}
+		if !modes.Enabled(features.Email, overrides) {
+			continue
+		}
+		msg, err := Compose(ctx, s, sub, date, baseURL)
+		if err != nil {
+			slog.Error("failed to build daily email", "user_id", sub.UserID, "date", date, "error", err)
+			continue
+		}
+		if msg == nil {
+			slog.Debug("no daily text to email", "user_id", sub.UserID, "date", date)
+			continue
+		}
+		if err := email.Queue(ctx, s, sub.UserID, sub.Email, *msg); err != nil {
+			slog.Error("failed to queue daily email", "user_id", sub.UserID, "error", err)
+			continue
+		}
+		if err := s.MarkDailyEmailSent(ctx, sub.UserID, date.String()); err != nil {
+			slog.Error("failed to mark daily email sent", "user_id", sub.UserID, "error", err)
+			continue
+		}
+		slog.Info("queued daily email", "user_id", sub.UserID, "date", date)
+	}
+}
+
+// Start checks every minute for daily emails that are due at the time told by clk, until ctx is
+// cancelled.
+func Start(ctx context.Context, s store.Store, clk clock.Clock) {
+	go func() {
+		ticker := time.NewTicker(time.Minute)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				maintenance.Run(func() { SendDue(ctx, s, clk.Now(), baseURL()) })
+			case <-ctx.Done():
+				slog.Info("stopping daily email service")
+				return
+			}
+		}
+	}()
+}
+
+// baseURL returns the address of the instance that emails link to.
+func baseURL() string {
+	if u := os.Getenv("BASE_URL"); u != "" {
+		return u
+	}
+	return "http://localhost:8080"
+}
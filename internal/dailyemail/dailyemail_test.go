@@ -0,0 +1,106 @@
+package dailyemail_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"derrclan.com/moravian-soap/internal/dailyemail"
+	"derrclan.com/moravian-soap/internal/store"
+	"derrclan.com/moravian-soap/internal/store/sqlite"
+	"derrclan.com/moravian-soap/internal/testutil"
+)
+
+func TestSendDue(t *testing.T) {
+	db := testutil.NewDB(t)
+	s := sqlite.New(db)
+	ctx := context.Background()
+
+	if _, err := db.Exec(`INSERT INTO users (id, email, password_hash, is_verified, timezone) VALUES (1, 'early@example.com', 'x', 1, 'America/Chicago')`); err != nil {
+		t.Fatalf("failed to insert user: %v", err)
+	}
+	prefs := store.DefaultPreferences()
+	prefs.DailyEmailTime = "06:30"
+	if err := s.SavePreferences(ctx, 1, prefs); err != nil {
+		t.Fatalf("SavePreferences failed: %v", err)
+	}
+	sub := &store.EmailSubscription{UserID: 1, List: store.DailyEmailList, Status: store.SubscriptionActive, Token: "token-1"}
+	if err := s.SaveEmailSubscription(ctx, sub); err != nil {
+		t.Fatalf("SaveEmailSubscription failed: %v", err)
+	}
+	yesterday := &store.SOAPData{Date: "2026-01-09", Sections: map[string]string{"observation": "Grace <upon> grace"}}
+	if err := s.SaveSOAPData(ctx, 1, yesterday); err != nil {
+		t.Fatalf("SaveSOAPData failed: %v", err)
+	}
+	if err := s.SaveFavorite(ctx, 1, yesterday.Date, true); err != nil {
+		t.Fatalf("SaveFavorite failed: %v", err)
+	}
+
+	chicago, _ := time.LoadLocation("America/Chicago")
+	// 06:29 in Chicago is before the send time; 06:30 and later on the same day send only once.
+	for _, at := range []string{"06:29", "06:30", "09:00"} {
+		clock, _ := time.Parse("15:04", at)
+		now := time.Date(2026, time.January, 10, clock.Hour(), clock.Minute(), 0, 0, chicago)
+		dailyemail.SendDue(ctx, s, now.UTC(), "https://soap.example.com/")
+	}
+
+	type queued struct{ recipient, subject, body string }
+	emails := func() []queued {
+		t.Helper()
+		rows, err := db.Query(`SELECT recipient, subject, body_html FROM queued_emails ORDER BY id`)
+		if err != nil {
+			t.Fatalf("failed to query queued emails: %v", err)
+		}
+		defer rows.Close()
+		var emails []queued
+		for rows.Next() {
+			var e queued
+			if err := rows.Scan(&e.recipient, &e.subject, &e.body); err != nil {
+				t.Fatalf("failed to scan queued email: %v", err)
+			}
+			emails = append(emails, e)
+		}
+		return emails
+	}
+
+	sent := emails()
+	if len(sent) != 1 {
+		t.Fatalf("got %d daily emails, want 1", len(sent))
+	}
+	if sent[0].recipient != "early@example.com" || !strings.Contains(sent[0].subject, "2026-01-10") {
+		t.Errorf("unexpected daily email: %+v", sent[0])
+	}
+	for _, want := range []string{"Today's Readings", "Observation", "Grace &lt;upon&gt; grace", `href="https://soap.example.com/?date=2026-01-10"`,
+		`href="https://soap.example.com/subscriptions/unsubscribe?token=token-1"`} {
+		if !strings.Contains(sent[0].body, want) {
+			t.Errorf("daily email missing %q: %s", want, sent[0].body)
+		}
+	}
+
+	// The next morning, with nothing written the day before.
+	dailyemail.SendDue(ctx, s, time.Date(2026, time.January, 11, 7, 0, 0, 0, chicago).UTC(), "https://soap.example.com/")
+	if sent := emails(); len(sent) != 2 || !strings.Contains(sent[1].body, "did not write in your journal yesterday") {
+		t.Errorf("got %d daily emails, want a second one noting the missed day", len(sent))
+	}
+	if strings.Contains(sent[0].body, "From Your Favorites") {
+		t.Errorf("daily email in the middle of the month lists favorites: %s", sent[0].body)
+	}
+
+	// On the first of the month, the email looks back on the user's favorites.
+	dailyemail.SendDue(ctx, s, time.Date(2026, time.February, 1, 7, 0, 0, 0, chicago).UTC(), "https://soap.example.com/")
+	if sent := emails(); len(sent) != 3 || !strings.Contains(sent[2].body, "From Your Favorites") ||
+		!strings.Contains(sent[2].body, `<a href="https://soap.example.com/?date=2026-01-09">2026-01-09</a>: Grace &lt;upon&gt; grace`) {
+		t.Errorf("got %d daily emails, want a third one listing the favorite entry", len(sent))
+	}
+
+	// Unsubscribing stops the emails.
+	sub.Status = store.SubscriptionUnsubscribed
+	if err := s.SaveEmailSubscription(ctx, sub); err != nil {
+		t.Fatalf("SaveEmailSubscription failed: %v", err)
+	}
+	dailyemail.SendDue(ctx, s, time.Date(2026, time.February, 2, 7, 0, 0, 0, chicago).UTC(), "https://soap.example.com/")
+	if sent := emails(); len(sent) != 3 {
+		t.Errorf("got %d daily emails after unsubscribing, want 3", len(sent))
+	}
+}
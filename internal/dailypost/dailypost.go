@@ -0,0 +1,249 @@
+// Package dailypost posts the daily watchword and readings to Discord and Slack channels through their
+// incoming webhooks, once a day at a time chosen for each channel.
+package dailypost
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+
+	"derrclan.com/moravian-soap/internal/civil"
+	"derrclan.com/moravian-soap/internal/clock"
+	"derrclan.com/moravian-soap/internal/dailytexts"
+	"derrclan.com/moravian-soap/internal/maintenance"
+	"derrclan.com/moravian-soap/internal/store"
+)
+
+// The kinds of channel that can be posted to.
+const (
+	Discord = "discord"
+	Slack   = "slack"
+)
+
+// discordMaxLength is the longest message Discord accepts from a webhook.
+const discordMaxLength = 2000
+
+// defaultTemplates are used for channels without a template of their own. Discord and Slack disagree on
+// the markup for bold text and links.
+var defaultTemplates = map[string]string{
+	Discord: "**Watchword for {{.Date}}**\n> {{.Watchword}}\n\nToday's readings: {{join .Readings \", \"}}\n" +
+		"Read and journal: {{.URL}}\n-# {{.Attribution}}",
+	Slack: "*Watchword for {{.Date}}*\n> {{.Watchword}}\n\nToday's readings: {{join .Readings \", \"}}\n" +
+		"<{{.URL}}|Read and journal>\n_{{.Attribution}}_",
+}
+
+// DefaultTemplate returns the template used for channels of the kind that have no template of their own.
+func DefaultTemplate(kind string) string {
+	return defaultTemplates[kind]
+}
+
+// Post is the data a channel's template is rendered with.
+type Post struct {
+	// Date is the day of the post, as YYYY-MM-DD.
+	Date string
+	// Watchword is the watchword as printed in the Daily Texts, including its reference.
+	Watchword string
+	// Reference is the Bible reference of the watchword, e.g. "Psalm 115:2-3".
+	Reference string
+	// Readings are the references of the day's readings.
+	Readings    []string
+	URL         string
+	Attribution string
+}
+
+// NewPost returns the post for date, linking to the instance at baseURL. It returns nil if there is no
+// daily text for the date.
+func NewPost(date civil.Date, baseURL string) (*Post, error) {
+	text, err := dailytexts.GetDailyText(date.String())
+	if err != nil {
+		return nil, fmt.Errorf("getting daily text for %s: %w", date, err)
+	}
+	if text == nil {
+		return nil, nil
+	}
+	return &Post{
+		Date:        date.String(),
+		Watchword:   text.DailyWatchWord,
+		Reference:   text.WatchwordReference(),
+		Readings:    text.Verses,
+		URL:         strings.TrimSuffix(baseURL, "/") + "/?date=" + date.String(),
+		Attribution: dailytexts.Attribution,
+	}, nil
+}
+
+// ParseTemplate parses a channel template.
+func ParseTemplate(text string) (*template.Template, error) {
+	return template.New("post").Funcs(template.FuncMap{"join": strings.Join}).Parse(text)
+}
+
+// Render renders the post with the channel's template, or the default template of its kind.
+func Render(ch *store.DailyPostChannel, post *Post) (string, error) {
+	text := ch.Template
+	if text == "" {
+		text = DefaultTemplate(ch.Kind)
+	}
+	t, err := ParseTemplate(text)
+	if err != nil {
+		return "", fmt.Errorf("parsing template: %w", err)
+	}
+	var b strings.Builder
+	if err := t.Execute(&b, post); err != nil {
+		return "", fmt.Errorf("rendering template: %w", err)
+	}
+	return b.String(), nil
+}
+
+// Validate checks that a channel can be posted to, describing the first problem found.
+func Validate(ch *store.DailyPostChannel) error {
+	if ch.Name == "" {
+		return errors.New("the channel needs a name")
+	}
+	if DefaultTemplate(ch.Kind) == "" {
+		return fmt.Errorf("unknown channel kind %q", ch.Kind)
+	}
+	if u, err := url.Parse(ch.WebhookURL); err != nil || u.Scheme != "https" || u.Host == "" {
+		return errors.New("the webhook URL must be an https:// URL")
+	}
+	if _, err := time.Parse("15:04", ch.PostTime); err != nil {
+		return errors.New("the post time must be given as HH:MM")
+	}
+	if _, err := time.LoadLocation(ch.Timezone); err != nil || ch.Timezone == "Local" {
+		return fmt.Errorf("unknown timezone %q", ch.Timezone)
+	}
+	// Rendering a sample post catches fields that do not exist, which parsing alone does not.
+	if _, err := Render(ch, &Post{Readings: []string{}}); err != nil {
+		return fmt.Errorf("invalid template: %w", err)
+	}
+	return nil
+}
+
+// Send posts content to the channel's webhook.
+func Send(ctx context.Context, ch *store.DailyPostChannel, content string) error {
+	var payload any
+	switch ch.Kind {
+	case Discord:
+		if r := []rune(content); len(r) > discordMaxLength {
+			content = string(r[:discordMaxLength-1]) + "…"
+		}
+		payload = map[string]string{"content": content}
+	case Slack:
+		payload = map[string]string{"text": content}
+	default:
+		return fmt.Errorf("unknown channel kind %q", ch.Kind)
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("encoding payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, ch.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("posting to %s webhook: %w", ch.Kind, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%s webhook returned status %d", ch.Kind, resp.StatusCode)
+	}
+	return nil
+}
+
+// due returns the date in the channel's timezone at now, and whether the post for that date is due:
+// its post time has passed and it has not been sent yet.
+func due(ch *store.DailyPostChannel, now time.Time) (civil.Date, bool) {
+	loc, err := time.LoadLocation(ch.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	local := now.In(loc)
+	today := civil.DateOf(local)
+	if today.String() == ch.LastPostedDate {
+		return today, false
+	}
+	postAt, err := time.Parse("15:04", ch.PostTime)
+	if err != nil {
+		return today, false
+	}
+	return today, local.Hour()*60+local.Minute() >= postAt.Hour()*60+postAt.Minute()
+}
+
+// PostDue sends the posts that are due at now to every channel. A post that fails is retried the next
+// time PostDue runs.
+func PostDue(ctx context.Context, s store.Store, now time.Time, baseURL string) {
+	channels, err := s.ListDailyPostChannels(ctx)
+	if err != nil {
+		slog.Error("failed to list daily post channels", "error", err)
+		return
+	}
+	for _, ch := range channels {
+		date, ok := due(ch, now)
+		if !ok {
+			continue
+		}
+		post, err := NewPost(date, baseURL)
+		if err != nil {
+			slog.Error("failed to build daily post", "channel", ch.Name, "date", date, "error", err)
+			continue
+		}
+		if post == nil {
+			slog.Debug("no daily text to post", "channel", ch.Name, "date", date)
+			continue
+		}
+		content, err := Render(ch, post)
+		if err != nil {
+			slog.Error("failed to render daily post", "channel", ch.Name, "error", err)
+			continue
+		}
+		if err := Send(ctx, ch, content); err != nil {
+			slog.Error("failed to send daily post", "channel", ch.Name, "error", err)
+			continue
+		}
+		if err := s.MarkDailyPostSent(ctx, ch.ID, date.String()); err != nil {
+			slog.Error("failed to mark daily post sent", "channel", ch.Name, "error", err)
+			continue
+		}
+		slog.Info("sent daily post", "channel", ch.Name, "date", date)
+	}
+}
+
+// Start checks every minute for daily posts that are due at the time told by clk, until ctx is
+// cancelled.
+func Start(ctx context.Context, s store.Store, clk clock.Clock) {
+	go func() {
+		ticker := time.NewTicker(time.Minute)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				maintenance.Run(func() { PostDue(ctx, s, clk.Now(), baseURL()) })
+			case <-ctx.Done():
+				slog.Info("stopping daily post service")
+				return
+			}
+		}
+	}()
+}
+
+// baseURL returns the address of the instance that posts link to.
+func baseURL() string {
+	if u := os.Getenv("BASE_URL"); u != "" {
+		return u
+	}
+	return "http://localhost:8080"
+}
@@ -0,0 +1,154 @@
+package dailypost_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"derrclan.com/moravian-soap/internal/civil"
+	"derrclan.com/moravian-soap/internal/dailypost"
+	"derrclan.com/moravian-soap/internal/store"
+	"derrclan.com/moravian-soap/internal/store/sqlite"
+	"derrclan.com/moravian-soap/internal/testutil"
+)
+
+func TestRender(t *testing.T) {
+	date, _ := civil.ParseDate("2026-01-10")
+	post, err := dailypost.NewPost(date, "https://soap.example.com/")
+	if err != nil || post == nil {
+		t.Fatalf("NewPost = %v, %v; want the post for %s", post, err, date)
+	}
+
+	for _, kind := range []string{dailypost.Discord, dailypost.Slack} {
+		content, err := dailypost.Render(&store.DailyPostChannel{Kind: kind}, post)
+		if err != nil {
+			t.Fatalf("%s: Render failed: %v", kind, err)
+		}
+		for _, want := range []string{post.Watchword, post.Readings[0], "https://soap.example.com/?date=2026-01-10", "Moravian Daily Texts"} {
+			if !strings.Contains(content, want) {
+				t.Errorf("%s: post %q is missing %q", kind, content, want)
+			}
+		}
+	}
+
+	custom := &store.DailyPostChannel{Kind: dailypost.Slack, Template: "{{.Reference}} | {{join .Readings \" / \"}}"}
+	content, err := dailypost.Render(custom, post)
+	if err != nil {
+		t.Fatalf("Render with a custom template failed: %v", err)
+	}
+	if want := post.Reference + " | " + strings.Join(post.Readings, " / "); content != want {
+		t.Errorf("got %q, want %q", content, want)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	valid := store.DailyPostChannel{
+		Name:       "#devotions",
+		Kind:       dailypost.Discord,
+		WebhookURL: "https://discord.com/api/webhooks/1/token",
+		PostTime:   "07:00",
+		Timezone:   "America/Chicago",
+	}
+	if err := dailypost.Validate(&valid); err != nil {
+		t.Errorf("Validate(valid) = %v", err)
+	}
+
+	invalid := map[string]func(*store.DailyPostChannel){
+		"no name":         func(ch *store.DailyPostChannel) { ch.Name = "" },
+		"unknown kind":    func(ch *store.DailyPostChannel) { ch.Kind = "teams" },
+		"plain http":      func(ch *store.DailyPostChannel) { ch.WebhookURL = "http://discord.com/api/webhooks/1/token" },
+		"bad time":        func(ch *store.DailyPostChannel) { ch.PostTime = "7am" },
+		"bad timezone":    func(ch *store.DailyPostChannel) { ch.Timezone = "Mars/Olympus" },
+		"unparseable":     func(ch *store.DailyPostChannel) { ch.Template = "{{.Date" },
+		"unknown field":   func(ch *store.DailyPostChannel) { ch.Template = "{{.Psalm}}" },
+		"server timezone": func(ch *store.DailyPostChannel) { ch.Timezone = "Local" },
+	}
+	for name, change := range invalid {
+		ch := valid
+		change(&ch)
+		if err := dailypost.Validate(&ch); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestPostDue(t *testing.T) {
+	var posts []map[string]string
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]string
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Errorf("decoding webhook payload: %v", err)
+		}
+		posts = append(posts, payload)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer hook.Close()
+
+	s := sqlite.New(testutil.NewDB(t))
+	ctx := context.Background()
+	ch := &store.DailyPostChannel{Name: "#morning", Kind: dailypost.Slack, WebhookURL: hook.URL, PostTime: "07:00", Timezone: "America/Chicago"}
+	if err := s.CreateDailyPostChannel(ctx, ch); err != nil {
+		t.Fatalf("CreateDailyPostChannel failed: %v", err)
+	}
+
+	chicago, _ := time.LoadLocation("America/Chicago")
+	// 06:59 in Chicago is before the post time; 07:00 and later on the same day post only once.
+	for _, at := range []string{"06:59", "07:00", "08:30"} {
+		clock, _ := time.Parse("15:04", at)
+		now := time.Date(2026, time.January, 10, clock.Hour(), clock.Minute(), 0, 0, chicago)
+		dailypost.PostDue(ctx, s, now.UTC(), "https://soap.example.com")
+	}
+
+	if len(posts) != 1 {
+		t.Fatalf("got %d posts, want 1", len(posts))
+	}
+	if !strings.Contains(posts[0]["text"], "Watchword for 2026-01-10") {
+		t.Errorf("unexpected Slack payload: %v", posts[0])
+	}
+	channels, err := s.ListDailyPostChannels(ctx)
+	if err != nil || len(channels) != 1 || channels[0].LastPostedDate != "2026-01-10" {
+		t.Errorf("ListDailyPostChannels = %+v, %v; want the post recorded", channels, err)
+	}
+}
+
+func TestPostDue_DaylightSavingTime(t *testing.T) {
+	posts := 0
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		posts++
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer hook.Close()
+
+	s := sqlite.New(testutil.NewDB(t))
+	ctx := context.Background()
+	// 02:30 does not exist in Chicago on 2026-03-08, when clocks jump from 02:00 to 03:00.
+	ch := &store.DailyPostChannel{Name: "#night", Kind: dailypost.Discord, WebhookURL: hook.URL, PostTime: "02:30", Timezone: "America/Chicago"}
+	if err := s.CreateDailyPostChannel(ctx, ch); err != nil {
+		t.Fatalf("CreateDailyPostChannel failed: %v", err)
+	}
+
+	// 01:59 CST, then 03:00 CDT one minute later.
+	dailypost.PostDue(ctx, s, time.Date(2026, time.March, 8, 7, 59, 0, 0, time.UTC), "https://soap.example.com")
+	if posts != 0 {
+		t.Fatalf("got %d posts before the post time, want 0", posts)
+	}
+	dailypost.PostDue(ctx, s, time.Date(2026, time.March, 8, 8, 0, 0, 0, time.UTC), "https://soap.example.com")
+	if posts != 1 {
+		t.Fatalf("got %d posts after the skipped hour, want 1", posts)
+	}
+
+	// 23:59 CDT is still the same day, and midnight is the next one, whose post waits for 02:30.
+	dailypost.PostDue(ctx, s, time.Date(2026, time.March, 9, 4, 59, 0, 0, time.UTC), "https://soap.example.com")
+	dailypost.PostDue(ctx, s, time.Date(2026, time.March, 9, 5, 0, 0, 0, time.UTC), "https://soap.example.com")
+	if posts != 1 {
+		t.Errorf("got %d posts by midnight, want 1", posts)
+	}
+	dailypost.PostDue(ctx, s, time.Date(2026, time.March, 9, 7, 30, 0, 0, time.UTC), "https://soap.example.com")
+	if posts != 2 {
+		t.Errorf("got %d posts after the next day's post time, want 2", posts)
+	}
+}
@@ -0,0 +1,159 @@
+package dailytexts
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+)
+
+// A compiled year file (YYYY.dtx) holds a year's daily texts indexed by date, so that the text of a date
+// is found and decoded without decoding the rest of the year. It is compiledMagic, the length of the
+// index as a big-endian uint32, the index and then the texts. The index is the number of dates followed
+// by each date in order, as YYYY-MM-DD, with the offset from the first text and the length of its text,
+// all numbers as uvarints. Each text is encoded as JSON, as in the year files.
+const compiledMagic = "DTX1"
+
+// compiledHeaderLen is the length of the magic and the index length at the start of a compiled year.
+const compiledHeaderLen = len(compiledMagic) + 4
+
+// span is where the text of a date is in a compiled year, relative to the first text.
+type span struct {
+	off, n int64
+}
+
+// indexedYear is a year's daily texts indexed by date, each decoded when it is looked up.
+type indexedYear struct {
+	r io.ReaderAt
+	// base is the offset in r of the first text.
+	base  int64
+	spans map[string]span
+}
+
+// Compile encodes the daily texts of a year as a compiled year file. The encoding of a year is always
+// the same, so compiled files can be checked in and compared with their sources.
+func Compile(y Year) ([]byte, error) {
+	dates := slices.Sorted(maps.Keys(y))
+	var index, records bytes.Buffer
+	index.Write(binary.AppendUvarint(nil, uint64(len(dates))))
+	for _, date := range dates {
+		if len(date) != len("2006-01-02") {
+			return nil, fmt.Errorf("%s: not a date", date)
+		}
+		record, err := json.Marshal(y[date])
+		if err != nil {
+			return nil, fmt.Errorf("marshaling %s: %w", date, err)
+		}
+		index.WriteString(date)
+		index.Write(binary.AppendUvarint(nil, uint64(records.Len())))
+		index.Write(binary.AppendUvarint(nil, uint64(len(record))))
+		records.Write(record)
+	}
+
+	out := make([]byte, 0, compiledHeaderLen+index.Len()+records.Len())
+	out = append(out, compiledMagic...)
+	out = binary.BigEndian.AppendUint32(out, uint32(index.Len()))
+	out = append(out, index.Bytes()...)
+	return append(out, records.Bytes()...), nil
+}
+
+// openCompiled reads the index of the compiled year of size bytes in r. The texts are read from r as
+// they are looked up, so r must stay open while the year is in use.
+func openCompiled(r io.ReaderAt, size int64) (*indexedYear, error) {
+	header := make([]byte, compiledHeaderLen)
+	if _, err := r.ReadAt(header, 0); err != nil {
+		return nil, fmt.Errorf("reading header: %w", err)
+	}
+	if string(header[:len(compiledMagic)]) != compiledMagic {
+		return nil, errors.New("not a compiled year file")
+	}
+	indexLen := int64(binary.BigEndian.Uint32(header[len(compiledMagic):]))
+	base := int64(compiledHeaderLen) + indexLen
+	if base > size {
+		return nil, errors.New("index runs past the end of the file")
+	}
+	index := make([]byte, indexLen)
+	if _, err := r.ReadAt(index, int64(compiledHeaderLen)); err != nil {
+		return nil, fmt.Errorf("reading index: %w", err)
+	}
+
+	count, n := binary.Uvarint(index)
+	if n <= 0 {
+		return nil, errors.New("invalid index")
+	}
+	index = index[n:]
+	y := &indexedYear{r: r, base: base, spans: make(map[string]span, count)}
+	for range count {
+		if len(index) < len("2006-01-02") {
+			return nil, errors.New("index is truncated")
+		}
+		date := string(index[:len("2006-01-02")])
+		index = index[len("2006-01-02"):]
+		off, n := binary.Uvarint(index)
+		if n <= 0 {
+			return nil, fmt.Errorf("%s: invalid offset", date)
+		}
+		index = index[n:]
+		length, n := binary.Uvarint(index)
+		if n <= 0 {
+			return nil, fmt.Errorf("%s: invalid length", date)
+		}
+		index = index[n:]
+		if int64(off+length) > size-base {
+			return nil, fmt.Errorf("%s: text runs past the end of the file", date)
+		}
+		y.spans[date] = span{off: int64(off), n: int64(length)}
+	}
+	return y, nil
+}
+
+// indexYear indexes the daily texts of a year in memory, as if compiled.
+func indexYear(y Year) (*indexedYear, error) {
+	data, err := Compile(y)
+	if err != nil {
+		return nil, err
+	}
+	return openCompiled(bytes.NewReader(data), int64(len(data)))
+}
+
+// get returns the daily text for date, or nil if the year has none. A nil year has no texts.
+func (y *indexedYear) get(date string) (*DailyText, error) {
+	if y == nil {
+		return nil, nil
+	}
+	s, ok := y.spans[date]
+	if !ok {
+		return nil, nil
+	}
+	record := make([]byte, s.n)
+	if _, err := y.r.ReadAt(record, y.base+s.off); err != nil {
+		return nil, fmt.Errorf("reading %s: %w", date, err)
+	}
+	var text DailyText
+	if err := json.Unmarshal(record, &text); err != nil {
+		return nil, fmt.Errorf("unmarshaling %s: %w", date, err)
+	}
+	return &text, nil
+}
+
+// dates returns the dates of the year that have a text, in order.
+func (y *indexedYear) dates() []string {
+	return slices.Sorted(maps.Keys(y.spans))
+}
+
+// decode decodes every text of the year.
+func (y *indexedYear) decode() (Year, error) {
+	decoded := make(Year, len(y.spans))
+	for date := range y.spans {
+		text, err := y.get(date)
+		if err != nil {
+			return nil, err
+		}
+		decoded[date] = *text
+	}
+	return decoded, nil
+}
@@ -0,0 +1,110 @@
+package dailytexts_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"testing"
+
+	"derrclan.com/moravian-soap/internal/dailytexts"
+)
+
+func TestCompile_EmbeddedTextsUpToDate(t *testing.T) {
+	years, err := dailytexts.Years()
+	if err != nil {
+		t.Fatalf("Years failed: %v", err)
+	}
+	for _, year := range years {
+		var y dailytexts.Year
+		if err := json.Unmarshal(readEmbeddedYear(t, year), &y); err != nil {
+			t.Fatalf("unmarshaling %d: %v", year, err)
+		}
+		want, err := dailytexts.Compile(y)
+		if err != nil {
+			t.Fatalf("Compile(%d) failed: %v", year, err)
+		}
+		got, err := os.ReadFile(fmt.Sprintf("texts/%d.dtx", year))
+		if err != nil {
+			t.Fatalf("reading compiled texts: %v", err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("texts/%d.dtx is out of date with texts/%d.json; run go generate", year, year)
+		}
+
+		// Every text reads back as it was compiled.
+		for date, text := range y {
+			d, err := dailytexts.GetDailyText(date)
+			if err != nil || d == nil || d.Prayer != text.Prayer || d.DailyWatchWord != text.DailyWatchWord {
+				t.Fatalf("GetDailyText(%s) = %+v, %v; want %+v", date, d, err, text)
+			}
+		}
+	}
+}
+
+func TestCompile_Deterministic(t *testing.T) {
+	y := dailytexts.Year{
+		"2030-01-02": {Verses: []string{"Psalm 91"}, Prayer: "Amen."},
+		"2030-01-01": {Verses: []string{"Psalm 90"}, SpecialRemarks: []string{"New Year's Day"}},
+	}
+	a, err := dailytexts.Compile(y)
+	if err != nil {
+		t.Fatalf("Compile failed: %v", err)
+	}
+	for range 5 {
+		if b, _ := dailytexts.Compile(y); !bytes.Equal(a, b) {
+			t.Fatal("Compile encoded the same year differently")
+		}
+	}
+	if _, err := dailytexts.Compile(dailytexts.Year{"Jan 1": {}}); err == nil {
+		t.Error("Compile accepted a year keyed by something other than dates")
+	}
+}
+
+// BenchmarkParseYear measures decoding a whole year file, as every text of a year was loaded before
+// years were compiled.
+func BenchmarkParseYear(b *testing.B) {
+	data, err := os.ReadFile("texts/2026.json")
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	for b.Loop() {
+		var y dailytexts.Year
+		if err := json.Unmarshal(data, &y); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkGetDailyText_Cold measures looking up a text in a year not yet loaded, which reads only the
+// compiled year's index and the one text.
+func BenchmarkGetDailyText_Cold(b *testing.B) {
+	empty := b.TempDir()
+	b.Cleanup(func() { dailytexts.SetDir("") })
+	b.ReportAllocs()
+	for i := 0; b.Loop(); i++ {
+		// Changing the directory forgets the loaded years.
+		if i%2 == 0 {
+			dailytexts.SetDir(empty)
+		} else {
+			dailytexts.SetDir("")
+		}
+		if d, err := dailytexts.GetDailyText("2026-03-07"); err != nil || d == nil {
+			b.Fatalf("GetDailyText = %v, %v", d, err)
+		}
+	}
+}
+
+// BenchmarkGetDailyText measures looking up a text in a loaded year.
+func BenchmarkGetDailyText(b *testing.B) {
+	if _, err := dailytexts.GetDailyText("2026-03-07"); err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	for b.Loop() {
+		if d, err := dailytexts.GetDailyText("2026-03-07"); err != nil || d == nil {
+			b.Fatalf("GetDailyText = %v, %v", d, err)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package dailytexts
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// Change is a date whose daily text differs between two versions of its year's file, such as after a
+// correction to a file in the external directory.
+type Change struct {
+	Date string
+	// Old and New are the text before and after the change. Old is nil if the date was added, and New
+	// if it was removed.
+	Old, New *DailyText
+	// Fields names the fields that changed, by their names in the year files.
+	Fields []string
+}
+
+// String describes the change, e.g. "2026-03-01: verses, daily_watchword changed".
+func (c Change) String() string {
+	switch {
+	case c.Old == nil:
+		return c.Date + ": added"
+	case c.New == nil:
+		return c.Date + ": removed"
+	default:
+		return c.Date + ": " + strings.Join(c.Fields, ", ") + " changed"
+	}
+}
+
+// VersesChanged reports whether the readings of the date changed, and with them its passages.
+func (c Change) VersesChanged() bool {
+	return c.Old == nil || c.New == nil || slices.Contains(c.Fields, "verses")
+}
+
+// Diff returns the dates whose texts differ between old and updated, in date order.
+func Diff(old, updated Year) []Change {
+	dates := slices.Sorted(maps.Keys(old))
+	for date := range updated {
+		if _, ok := old[date]; !ok {
+			dates = append(dates, date)
+		}
+	}
+	slices.Sort(dates)
+
+	var changes []Change
+	for _, date := range dates {
+		o, inOld := old[date]
+		u, inUpdated := updated[date]
+		switch {
+		case !inOld:
+			changes = append(changes, Change{Date: date, New: &u})
+		case !inUpdated:
+			changes = append(changes, Change{Date: date, Old: &o})
+		default:
+			if fields := changedFields(o, u); len(fields) > 0 {
+				changes = append(changes, Change{Date: date, Old: &o, New: &u, Fields: fields})
+			}
+		}
+	}
+	return changes
+}
+
+// changedFields returns the names of the fields that differ between a and b.
+func changedFields(a, b DailyText) []string {
+	var fields []string
+	if !slices.Equal(a.Verses, b.Verses) {
+		fields = append(fields, "verses")
+	}
+	if a.Prayer != b.Prayer {
+		fields = append(fields, "prayer")
+	}
+	if a.DailyWatchWord != b.DailyWatchWord {
+		fields = append(fields, "daily_watchword")
+	}
+	if a.Doctrinal != b.Doctrinal {
+		fields = append(fields, "doctrinal")
+	}
+	if a.WeeklyWatchword != b.WeeklyWatchword {
+		fields = append(fields, "weekly_watchword")
+	}
+	if !slices.Equal(a.SpecialRemarks, b.SpecialRemarks) {
+		fields = append(fields, "special_remarks")
+	}
+	return fields
+}
+
+// Reload reads the files of the years loaded so far again and replaces the loaded texts of those that
+// changed, returning the dates whose texts changed in date order. A year whose file can no longer be
+// read or is invalid keeps the texts loaded before, and is reported in the error.
+func Reload() ([]Change, error) {
+	cacheMutex.RLock()
+	loaded := maps.Clone(yearDataCache)
+	cacheMutex.RUnlock()
+
+	var changes []Change
+	var errs []error
+	for _, year := range slices.Sorted(maps.Keys(loaded)) {
+		updated, filename, err := readYear(year)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		yearChanges, err := diffIndexed(loaded[year], updated)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("comparing %s: %w", filename, err))
+			continue
+		}
+		if len(yearChanges) == 0 {
+			continue
+		}
+		cacheMutex.Lock()
+		yearDataCache[year] = updated
+		cacheMutex.Unlock()
+		slog.Info("reloaded year data", "year", year, "file", filename, "changed_dates", len(yearChanges))
+		changes = append(changes, yearChanges...)
+	}
+	return changes, errors.Join(errs...)
+}
+
+// diffIndexed returns the dates whose texts differ between two indexed versions of a year, decoding both.
+func diffIndexed(old, updated *indexedYear) ([]Change, error) {
+	o, err := old.decode()
+	if err != nil {
+		return nil, err
+	}
+	u, err := updated.decode()
+	if err != nil {
+		return nil, err
+	}
+	return Diff(o, u), nil
+}
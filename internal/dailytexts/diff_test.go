@@ -0,0 +1,80 @@
+package dailytexts_test
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"derrclan.com/moravian-soap/internal/dailytexts"
+)
+
+func TestDiff(t *testing.T) {
+	old := dailytexts.Year{
+		"2030-01-01": {Verses: []string{"Psalm 90"}, Prayer: "Amen."},
+		"2030-01-02": {Verses: []string{"Psalm 91"}, Prayer: "Amen."},
+		"2030-01-03": {Verses: []string{"Psalm 92"}, Prayer: "Amen."},
+	}
+	updated := dailytexts.Year{
+		"2030-01-01": {Verses: []string{"Psalm 90"}, Prayer: "Amen."},
+		"2030-01-02": {Verses: []string{"Psalm 91:1-6"}, Prayer: "Amen!", Doctrinal: "Psalm 91"},
+		"2030-01-04": {Verses: []string{"Psalm 93"}},
+	}
+
+	var got []string
+	for _, change := range dailytexts.Diff(old, updated) {
+		got = append(got, change.String())
+		if !change.VersesChanged() {
+			t.Errorf("%s: VersesChanged() = false, want true", change)
+		}
+	}
+	want := []string{"2030-01-02: verses, prayer, doctrinal changed", "2030-01-03: removed", "2030-01-04: added"}
+	if !slices.Equal(got, want) {
+		t.Errorf("Diff() = %q, want %q", got, want)
+	}
+
+	prayerOnly := dailytexts.Diff(old, dailytexts.Year{"2030-01-01": {Verses: []string{"Psalm 90"}, Prayer: "Amen!"}})
+	if len(prayerOnly) != 3 || prayerOnly[0].VersesChanged() {
+		t.Errorf("Diff() of a corrected prayer = %v, want its verses unchanged", prayerOnly)
+	}
+}
+
+func TestReload(t *testing.T) {
+	dir := t.TempDir()
+	write := func(y dailytexts.Year) {
+		t.Helper()
+		if _, err := dailytexts.WriteYear(dir, 2030, y); err != nil {
+			t.Fatalf("WriteYear failed: %v", err)
+		}
+	}
+	write(dailytexts.Year{"2030-01-01": {Verses: []string{"Psalm 90"}, Prayer: "Amen."}})
+	dailytexts.SetDir(dir)
+	t.Cleanup(func() { dailytexts.SetDir("") })
+
+	if got := prayer(t, "2030-01-01"); got != "Amen." {
+		t.Fatalf("prayer = %q before the correction", got)
+	}
+	if changes, err := dailytexts.Reload(); err != nil || len(changes) != 0 {
+		t.Errorf("Reload() of unchanged files = %v, %v; want no changes", changes, err)
+	}
+
+	write(dailytexts.Year{"2030-01-01": {Verses: []string{"Psalm 90"}, Prayer: "Amen!"}})
+	changes, err := dailytexts.Reload()
+	if err != nil || len(changes) != 1 || changes[0].String() != "2030-01-01: prayer changed" {
+		t.Fatalf("Reload() = %v, %v; want the corrected prayer", changes, err)
+	}
+	if got := prayer(t, "2030-01-01"); got != "Amen!" {
+		t.Errorf("prayer = %q after reloading, want the correction", got)
+	}
+
+	// A file broken by a correction keeps the texts loaded before.
+	if err := os.WriteFile(filepath.Join(dir, "2030.json"), []byte("{"), 0o600); err != nil {
+		t.Fatalf("writing broken file: %v", err)
+	}
+	if _, err := dailytexts.Reload(); err == nil {
+		t.Error("Reload() of a broken file succeeded, want an error")
+	}
+	if got := prayer(t, "2030-01-01"); got != "Amen!" {
+		t.Errorf("prayer = %q after a failed reload, want the texts loaded before", got)
+	}
+}
@@ -0,0 +1,161 @@
+package dailytexts
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxYearFileSize bounds the size of a downloaded year file. Year files are about 200 KB.
+const maxYearFileSize = 10 << 20
+
+// upstreamEntry is a daily text in the list format, where each entry carries its own date.
+type upstreamEntry struct {
+	Date string `json:"date"`
+	DailyText
+}
+
+// Fetch downloads the daily texts for a year. The url may contain "{year}", which is replaced by the year.
+// The response is converted and validated by ParseYear.
+func Fetch(ctx context.Context, client *http.Client, url string, year int) (Year, error) {
+	url = strings.ReplaceAll(url, "{year}", strconv.Itoa(year))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("downloading %s: %w", url, err)
+	}
+	defer func() {
+		if cerr := resp.Body.Close(); cerr != nil {
+			slog.Error("failed to close response body", "error", cerr)
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("downloading %s: unexpected status %s", url, resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxYearFileSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", url, err)
+	}
+	if len(data) > maxYearFileSize {
+		return nil, fmt.Errorf("downloading %s: response larger than %d bytes", url, maxYearFileSize)
+	}
+	return ParseYear(data, year)
+}
+
+// ParseYear parses the daily texts for a year and validates them. It accepts the year file format (an
+// object keyed by date) as well as a list of entries that each have a "date" field.
+func ParseYear(data []byte, year int) (Year, error) {
+	var yearData Year
+	if err := json.Unmarshal(data, &yearData); err != nil {
+		var entries []upstreamEntry
+		if listErr := json.Unmarshal(data, &entries); listErr != nil {
+			return nil, fmt.Errorf("unmarshaling daily texts: %w", err)
+		}
+		yearData = make(Year, len(entries))
+		for _, e := range entries {
+			if _, ok := yearData[e.Date]; ok {
+				return nil, fmt.Errorf("duplicate entry for %s", e.Date)
+			}
+			yearData[e.Date] = e.DailyText
+		}
+	}
+
+	if err := yearData.Validate(year); err != nil {
+		return nil, err
+	}
+	return yearData, nil
+}
+
+// Validate checks that the year has a complete daily text for every day of the year and nothing else,
+// apart from the watch-night texts on the boundary dates either side of it.
+func (y Year) Validate(year int) error {
+	var errs []error
+	start := time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+	for d := start; d.Year() == year; d = d.AddDate(0, 0, 1) {
+		date := d.Format(time.DateOnly)
+		text, ok := y[date]
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s: missing", date))
+			continue
+		}
+		if len(text.Verses) == 0 {
+			errs = append(errs, fmt.Errorf("%s: no verses", date))
+		}
+		if text.DailyWatchWord == "" {
+			errs = append(errs, fmt.Errorf("%s: no daily watchword", date))
+		} else if text.WatchwordReference() == "" {
+			errs = append(errs, fmt.Errorf("%s: no reference in daily watchword %q", date, text.DailyWatchWord))
+		}
+		if text.Doctrinal == "" {
+			errs = append(errs, fmt.Errorf("%s: no doctrinal text", date))
+		}
+	}
+	if err := checkDates(slices.Sorted(maps.Keys(y)), year); err != nil {
+		errs = append(errs, err)
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("invalid daily texts for %d: %w", year, err)
+	}
+	return nil
+}
+
+// WriteYear writes the daily texts for a year to dir as YYYY.json, replacing any existing file atomically.
+func WriteYear(dir string, year int, yearData Year) (string, error) {
+	data, err := json.Marshal(yearData)
+	if err != nil {
+		return "", fmt.Errorf("marshaling daily texts: %w", err)
+	}
+	return writeFile(dir, fmt.Sprintf("%04d.json", year), data)
+}
+
+// WriteCompiled compiles the daily texts for a year (see Compile) and writes them to dir as YYYY.dtx,
+// replacing any existing file atomically.
+func WriteCompiled(dir string, year int, yearData Year) (string, error) {
+	data, err := Compile(yearData)
+	if err != nil {
+		return "", fmt.Errorf("compiling daily texts: %w", err)
+	}
+	return writeFile(dir, fmt.Sprintf("%04d.dtx", year), data)
+}
+
+// writeFile writes data to the file name in dir, creating dir if need be and replacing any existing file
+// atomically.
+func writeFile(dir, name string, data []byte) (string, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("creating %s: %w", dir, err)
+	}
+	filename := filepath.Join(dir, name)
+	tmp, err := os.CreateTemp(dir, ".texts-*"+filepath.Ext(name))
+	if err != nil {
+		return "", fmt.Errorf("creating temporary file: %w", err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return "", fmt.Errorf("writing %s: %w", tmp.Name(), err)
+	}
+	if err := tmp.Close(); err != nil {
+		return "", fmt.Errorf("closing %s: %w", tmp.Name(), err)
+	}
+	if err := os.Rename(tmp.Name(), filename); err != nil {
+		return "", fmt.Errorf("renaming to %s: %w", filename, err)
+	}
+	return filename, nil
+}
@@ -0,0 +1,148 @@
+package dailytexts_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"derrclan.com/moravian-soap/internal/dailytexts"
+)
+
+// readEmbeddedYear returns the contents of an embedded year file, which is known to be valid.
+func readEmbeddedYear(t *testing.T, year int) []byte {
+	t.Helper()
+	data, err := os.ReadFile(fmt.Sprintf("texts/%d.json", year))
+	if err != nil {
+		t.Fatalf("failed to read texts: %v", err)
+	}
+	return data
+}
+
+func TestParseYear(t *testing.T) {
+	data := readEmbeddedYear(t, 2026)
+
+	t.Run("Year file format", func(t *testing.T) {
+		y, err := dailytexts.ParseYear(data, 2026)
+		if err != nil {
+			t.Fatalf("ParseYear failed: %v", err)
+		}
+		if len(y) != 365 {
+			t.Errorf("expected 365 days, got %d", len(y))
+		}
+	})
+
+	t.Run("List format", func(t *testing.T) {
+		var y dailytexts.Year
+		if err := json.Unmarshal(data, &y); err != nil {
+			t.Fatalf("failed to unmarshal: %v", err)
+		}
+		var entries []map[string]any
+		for date, text := range y {
+			entries = append(entries, map[string]any{
+				"date":            date,
+				"verses":          text.Verses,
+				"daily_watchword": text.DailyWatchWord,
+				"doctrinal":       text.Doctrinal,
+				"prayer":          text.Prayer,
+			})
+		}
+		list, _ := json.Marshal(entries)
+
+		got, err := dailytexts.ParseYear(list, 2026)
+		if err != nil {
+			t.Fatalf("ParseYear failed: %v", err)
+		}
+		if got["2026-01-01"].Verses[0] != y["2026-01-01"].Verses[0] {
+			t.Errorf("unexpected conversion: %+v", got["2026-01-01"])
+		}
+	})
+
+	t.Run("Wrong year", func(t *testing.T) {
+		if _, err := dailytexts.ParseYear(data, 2027); err == nil {
+			t.Error("expected error for texts of another year")
+		}
+	})
+
+	t.Run("Incomplete", func(t *testing.T) {
+		var y dailytexts.Year
+		_ = json.Unmarshal(data, &y)
+		delete(y, "2026-02-14")
+		incomplete, _ := json.Marshal(y)
+		if _, err := dailytexts.ParseYear(incomplete, 2026); err == nil {
+			t.Error("expected error for missing day")
+		}
+	})
+
+	t.Run("Leap day in a common year", func(t *testing.T) {
+		var y dailytexts.Year
+		_ = json.Unmarshal(data, &y)
+		y["2026-02-29"] = y["2026-02-28"]
+		leap, _ := json.Marshal(y)
+		if _, err := dailytexts.ParseYear(leap, 2026); err == nil {
+			t.Error("expected error for Feb 29 in a common year")
+		}
+	})
+
+	t.Run("Boundary dates", func(t *testing.T) {
+		var y dailytexts.Year
+		_ = json.Unmarshal(data, &y)
+		y["2025-12-31"] = y["2026-01-01"]
+		y["2027-01-01"] = y["2026-12-31"]
+		boundary, _ := json.Marshal(y)
+		if _, err := dailytexts.ParseYear(boundary, 2026); err != nil {
+			t.Errorf("ParseYear with watch-night texts on the boundary dates failed: %v", err)
+		}
+
+		y["2027-01-02"] = y["2026-12-31"]
+		beyond, _ := json.Marshal(y)
+		if _, err := dailytexts.ParseYear(beyond, 2026); err == nil {
+			t.Error("expected error for a date beyond the boundary")
+		}
+	})
+
+	t.Run("Not JSON", func(t *testing.T) {
+		if _, err := dailytexts.ParseYear([]byte("<html>"), 2026); err == nil {
+			t.Error("expected error for invalid JSON")
+		}
+	})
+}
+
+func TestFetch(t *testing.T) {
+	data := readEmbeddedYear(t, 2025)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/texts/2025.json" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write(data)
+	}))
+	defer srv.Close()
+
+	y, err := dailytexts.Fetch(context.Background(), srv.Client(), srv.URL+"/texts/{year}.json", 2025)
+	if err != nil {
+		t.Fatalf("Fetch failed: %v", err)
+	}
+
+	// The texts directory is created if it does not exist yet.
+	dir := filepath.Join(t.TempDir(), "texts")
+	filename, err := dailytexts.WriteYear(dir, 2025, y)
+	if err != nil {
+		t.Fatalf("WriteYear failed: %v", err)
+	}
+	written, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatalf("failed to read written file: %v", err)
+	}
+	if _, err := dailytexts.ParseYear(written, 2025); err != nil {
+		t.Errorf("written file is invalid: %v", err)
+	}
+
+	if _, err := dailytexts.Fetch(context.Background(), srv.Client(), srv.URL+"/texts/{year}.json", 2027); err == nil {
+		t.Error("expected error for missing year")
+	}
+}
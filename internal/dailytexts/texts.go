@@ -4,19 +4,44 @@ package dailytexts
 import (
 	"embed"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"log/slog"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
+
+	"derrclan.com/moravian-soap/internal/civil"
 )
 
-//go:embed texts
+// Attribution credits the source of the texts, and must be shown wherever their watchwords are.
+const Attribution = "Moravian Daily Texts, Moravian Church in North America"
+
+// The embedded texts are compiled from the year files beside them, which are kept as their source.
+//
+//go:generate go run derrclan.com/moravian-soap/cmd/server compile-texts -dir texts
+
+//go:embed texts/*.dtx
 var texts embed.FS
 
 var (
 	// Cache of loaded year data, keyed by year (e.g., "2025", "2026").
-	yearDataCache = make(map[string]Year)
+	yearDataCache = make(map[string]*indexedYear)
 	cacheMutex    sync.RWMutex
+
+	// Directory of year files that take precedence over the embedded texts, if set.
+	externalDir string
+
+	// Whether boundary dates missing from their own year file are looked up in the adjacent year's file.
+	stitchYears bool
 )
 
 // Year represents a map of dates to daily texts for a specific year.
@@ -32,39 +57,344 @@ type DailyText struct {
 	SpecialRemarks  []string `json:"special_remarks,omitempty"`
 }
 
+// bookPattern matches the book of a Bible reference, e.g. "1 Samuel" or "Song of Solomon", and
+// versesPattern its chapter and verses, e.g. "2:8" or "9:4-5".
+const (
+	bookPattern   = `(?:[1-3] )?[A-Z][A-Za-z]+(?: of [A-Z][a-z]+)?`
+	versesPattern = `\d+:\d+(?:[-–,] ?\d+(?::\d+)?)*[a-z]?`
+)
+
+// referenceRe matches the citation at the end of a watchword, e.g. "1 Samuel 2:8", "Daniel 9:4-5 NIV"
+// or "Psalm 34:8; 1 Peter 2:3", capturing the citation without the translation. A citation of several
+// references separates them with semicolons, and a reference in the same book as the one before may
+// leave out the book, as in "Isaiah 41:10; 43:1".
+var referenceRe = regexp.MustCompile(`(` + bookPattern + ` ` + versesPattern + `(?:; (?:` + bookPattern + ` )?` + versesPattern + `)*)(?: [A-Z]{2,5})?\s*$`)
+
+// versesOnlyRe matches a reference in a citation that leaves out its book.
+var versesOnlyRe = regexp.MustCompile(`^` + versesPattern + `$`)
+
+// WatchwordReference returns the citation of the daily watchword as printed, which joins its
+// references with semicolons if it has several, or "" if it has none.
+func (d *DailyText) WatchwordReference() string {
+	return citation(d.DailyWatchWord)
+}
+
+// WatchwordReferences returns each of the Bible references of the daily watchword, in order and each
+// with its book, so that "Isaiah 41:10; 43:1" gives "Isaiah 41:10" and "Isaiah 43:1". It returns nil if
+// the watchword has no reference.
+func (d *DailyText) WatchwordReferences() []string {
+	return splitCitation(d.WatchwordReference())
+}
+
+// DoctrinalReference returns the citation of the doctrinal text as printed, like WatchwordReference,
+// or "" if it is not a quotation of scripture.
+func (d *DailyText) DoctrinalReference() string {
+	return citation(d.Doctrinal)
+}
+
+// DoctrinalReferences returns each of the Bible references of the doctrinal text, like
+// WatchwordReferences, or nil if it is not a quotation of scripture.
+func (d *DailyText) DoctrinalReferences() []string {
+	return splitCitation(d.DoctrinalReference())
+}
+
+// citation returns the Bible citation at the end of text, without its translation, or "".
+func citation(text string) string {
+	m := referenceRe.FindStringSubmatch(text)
+	if m == nil {
+		return ""
+	}
+	return m[1]
+}
+
+// splitCitation returns each of the references of a citation, giving those that leave out their book
+// the book of the reference before.
+func splitCitation(citation string) []string {
+	if citation == "" {
+		return nil
+	}
+	var refs []string
+	book := ""
+	for ref := range strings.SplitSeq(citation, "; ") {
+		if versesOnlyRe.MatchString(ref) {
+			ref = book + " " + ref
+		} else {
+			book = ref[:strings.LastIndex(ref, " ")]
+		}
+		refs = append(refs, ref)
+	}
+	return refs
+}
+
 // GetDailyText retrieves the daily text for a given date (YYYY-MM-DD format).
-// It will automatically load the year file if it hasn't been loaded yet.
+// It will automatically load the year file if it hasn't been loaded yet. If stitching across years is
+// enabled (see SetStitchYears), a New Year's Eve or New Year's Day text missing from its own year file
+// is taken from the adjacent year's file.
 func GetDailyText(dateStr string) (*DailyText, error) {
-	// Extract year from date string (first 4 characters)
-	if len(dateStr) < 4 {
-		return nil, fmt.Errorf("invalid date format: %s", dateStr)
+	date, err := civil.ParseDate(dateStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid date: %w", err)
+	}
+
+	yearData, err := getYear(date.Year)
+	if err != nil && !(stitchingYears() && errors.Is(err, fs.ErrNotExist)) {
+		return nil, fmt.Errorf("failed to load year data for %d: %w", date.Year, err)
+	}
+	if dailyText, err := yearData.get(dateStr); dailyText != nil || err != nil {
+		return dailyText, err
+	}
+
+	if adjacent, ok := adjacentYear(date); ok && stitchingYears() {
+		adjacentData, adjacentErr := getYear(adjacent)
+		if adjacentErr != nil && !errors.Is(adjacentErr, fs.ErrNotExist) {
+			return nil, fmt.Errorf("failed to load year data for %d: %w", adjacent, adjacentErr)
+		}
+		if dailyText, err := adjacentData.get(dateStr); dailyText != nil || err != nil {
+			return dailyText, err
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load year data for %d: %w", date.Year, err)
+	}
+	return nil, nil // Date not found, but not an error
+}
+
+// AdjacentDates returns the nearest dates before and after dateStr that have a daily text, skipping
+// dates without one. Either is "" if there is no such date in the available years.
+func AdjacentDates(dateStr string) (prev, next string, err error) {
+	date, err := civil.ParseDate(dateStr)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid date: %w", err)
+	}
+	years, err := Years()
+	if err != nil || len(years) == 0 {
+		return "", "", err
+	}
+	if prev, err = nearestDate(date, -1, years); err != nil {
+		return "", "", err
+	}
+	if next, err = nearestDate(date, 1, years); err != nil {
+		return "", "", err
+	}
+	return prev, next, nil
+}
+
+// nearestDate returns the first date with a daily text stepping a day at a time from date in the
+// direction of step, or "" if there is none in years. Years without a file are skipped but for their
+// boundary dates, which may be stitched from an adjacent year's file.
+func nearestDate(date civil.Date, step int, years []int) (string, error) {
+	first, last := years[0], years[len(years)-1]
+	for d := date.AddDays(step); d.Year >= first-1 && d.Year <= last+1; d = d.AddDays(step) {
+		if _, boundary := adjacentYear(d); !boundary && !slices.Contains(years, d.Year) {
+			// Move to the day before the year's boundary date in the direction of travel.
+			if step > 0 {
+				d = civil.Date{Year: d.Year, Month: time.December, Day: 30}
+			} else {
+				d = civil.Date{Year: d.Year, Month: time.January, Day: 2}
+			}
+			continue
+		}
+		dailyText, err := GetDailyText(d.String())
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		if dailyText != nil {
+			return d.String(), nil
+		}
+	}
+	return "", nil
+}
+
+// getYear returns the daily texts of a year, loading its file if it hasn't been loaded yet.
+func getYear(year int) (*indexedYear, error) {
+	key := strconv.Itoa(year)
+	cacheMutex.RLock()
+	yearData, ok := yearDataCache[key]
+	cacheMutex.RUnlock()
+	if ok {
+		return yearData, nil
+	}
+
+	if err := loadYearData(key); err != nil {
+		return nil, err
+	}
+	cacheMutex.RLock()
+	defer cacheMutex.RUnlock()
+	return yearDataCache[key], nil
+}
+
+// adjacentYear returns the year whose file may hold the text for date in place of date's own year:
+// the following year for New Year's Eve and the previous year for New Year's Day.
+func adjacentYear(date civil.Date) (int, bool) {
+	switch {
+	case date.Month == time.December && date.Day == 31:
+		return date.Year + 1, true
+	case date.Month == time.January && date.Day == 1:
+		return date.Year - 1, true
+	default:
+		return 0, false
+	}
+}
+
+// isBoundaryDate reports whether date is New Year's Eve before year or New Year's Day after it. Some
+// published year files include the watch-night texts on either side of the year they cover.
+func isBoundaryDate(date civil.Date, year int) bool {
+	adjacent, ok := adjacentYear(date)
+	return ok && adjacent == year
+}
+
+// checkDates checks that every one of dates is a real date, so that Feb 29 appears only in leap years,
+// and that it falls in year or is one of the boundary dates on either side of it.
+func checkDates(dates []string, year int) error {
+	var errs []error
+	for _, key := range dates {
+		date, err := civil.ParseDate(key)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: not a date", key))
+		} else if date.Year != year && !isBoundaryDate(date, year) {
+			errs = append(errs, fmt.Errorf("%s: not in %d", key, year))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// HasYear reports whether daily texts are available for the year, either embedded or in the
+// external directory.
+func HasYear(year int) bool {
+	name := fmt.Sprintf("%04d.json", year)
+	if _, err := fs.Stat(texts, fmt.Sprintf("texts/%04d.dtx", year)); err == nil {
+		return true
 	}
-	year := dateStr[:4]
 
-	// Check if year data is already loaded
 	cacheMutex.RLock()
-	yearData, ok := yearDataCache[year]
+	dir := externalDir
 	cacheMutex.RUnlock()
+	if dir == "" {
+		return false
+	}
+	_, err := os.Stat(filepath.Join(dir, name))
+	return err == nil
+}
 
-	if !ok {
-		// Load year data if not in cache
-		if err := loadYearData(year); err != nil {
-			return nil, fmt.Errorf("failed to load year data for %s: %w", year, err)
+// Years returns the years for which daily texts are available, embedded or in the external
+// directory, in order.
+func Years() ([]int, error) {
+	names, err := fs.Glob(texts, "texts/*.dtx")
+	if err != nil {
+		return nil, fmt.Errorf("listing embedded texts: %w", err)
+	}
+	cacheMutex.RLock()
+	dir := externalDir
+	cacheMutex.RUnlock()
+	if dir != "" {
+		external, err := filepath.Glob(filepath.Join(dir, "*.json"))
+		if err != nil {
+			return nil, fmt.Errorf("listing texts in %s: %w", dir, err)
 		}
-		cacheMutex.RLock()
-		yearData = yearDataCache[year]
-		cacheMutex.RUnlock()
+		names = append(names, external...)
+	}
+
+	var years []int
+	for _, name := range names {
+		year, err := strconv.Atoi(strings.TrimSuffix(filepath.Base(name), filepath.Ext(name)))
+		if err == nil && !slices.Contains(years, year) {
+			years = append(years, year)
+		}
+	}
+	slices.Sort(years)
+	return years, nil
+}
+
+// SetDir sets a directory of year files (e.g., "2027.json") that take precedence over the embedded
+// texts and clears the loaded year data. An empty dir uses only the embedded texts.
+func SetDir(dir string) {
+	cacheMutex.Lock()
+	defer cacheMutex.Unlock()
+	if dir == externalDir {
+		return
+	}
+	externalDir = dir
+	yearDataCache = make(map[string]*indexedYear)
+}
+
+// SetStitchYears sets whether a New Year's Eve or New Year's Day text missing from its own year file is
+// taken from the adjacent year's file, for year files that carry the watch-night texts over the boundary.
+func SetStitchYears(stitch bool) {
+	cacheMutex.Lock()
+	defer cacheMutex.Unlock()
+	stitchYears = stitch
+}
+
+// stitchingYears reports whether stitching across years is enabled.
+func stitchingYears() bool {
+	cacheMutex.RLock()
+	defer cacheMutex.RUnlock()
+	return stitchYears
+}
+
+// readYear reads and validates the file for a year, without loading it. A year file in the external
+// directory is indexed in memory; otherwise the embedded compiled year is read as its texts are looked up.
+func readYear(year string) (*indexedYear, string, error) {
+	y, err := strconv.Atoi(year)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid year %q: %w", year, err)
 	}
 
-	// Get the daily text for the date
-	dailyText, ok := yearData[dateStr]
-	if !ok {
-		return nil, nil // Date not found, but not an error
+	cacheMutex.RLock()
+	dir := externalDir
+	cacheMutex.RUnlock()
+	if dir != "" {
+		filename := filepath.Join(dir, year+".json")
+		data, err := os.ReadFile(filename)
+		if err == nil {
+			yearData, err := parseYearFile(data, filename, y)
+			return yearData, filename, err
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return nil, filename, fmt.Errorf("failed to read %s: %w", filename, err)
+		}
 	}
 
-	return &dailyText, nil
+	filename := fmt.Sprintf("texts/%s.dtx", year)
+	f, err := texts.Open(filename)
+	if err != nil {
+		return nil, filename, fmt.Errorf("failed to read %s: %w", filename, err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return nil, filename, fmt.Errorf("failed to read %s: %w", filename, err)
+	}
+	// Embedded files are read in place, so the texts are not copied into memory.
+	yearData, err := openCompiled(f.(io.ReaderAt), info.Size())
+	if err != nil {
+		return nil, filename, fmt.Errorf("failed to read %s: %w", filename, err)
+	}
+	if err := checkDates(yearData.dates(), y); err != nil {
+		return nil, filename, fmt.Errorf("invalid dates in %s: %w", filename, err)
+	}
+	return yearData, filename, nil
+}
+
+// parseYearFile parses and indexes the contents of the year file filename for year.
+func parseYearFile(data []byte, filename string, year int) (*indexedYear, error) {
+	var yearData Year
+	if err := json.Unmarshal(data, &yearData); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal JSON from %s: %w", filename, err)
+	}
+	if err := checkDates(slices.Sorted(maps.Keys(yearData)), year); err != nil {
+		return nil, fmt.Errorf("invalid dates in %s: %w", filename, err)
+	}
+	indexed, err := indexYear(yearData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to index %s: %w", filename, err)
+	}
+	return indexed, nil
 }
 
+// loadYearData loads the daily texts of a year into the cache.
 // The year should be in format "YYYY" (e.g., "2025", "2026").
 func loadYearData(year string) error {
 	// Check if already loaded
@@ -75,17 +405,9 @@ func loadYearData(year string) error {
 	}
 	cacheMutex.RUnlock()
 
-	// Read the year file
-	filename := fmt.Sprintf("texts/%s.json", year)
-	data, err := texts.ReadFile(filename)
+	yearData, filename, err := readYear(year)
 	if err != nil {
-		return fmt.Errorf("failed to read %s: %w", filename, err)
-	}
-
-	// Unmarshal JSON
-	var yearData Year
-	if err := json.Unmarshal(data, &yearData); err != nil {
-		return fmt.Errorf("failed to unmarshal JSON from %s: %w", filename, err)
+		return err
 	}
 
 	// Store in cache
@@ -93,14 +415,14 @@ func loadYearData(year string) error {
 	yearDataCache[year] = yearData
 	cacheMutex.Unlock()
 
-	slog.Info("loaded year data", "year", year)
+	slog.Info("loaded year data", "year", year, "file", filename)
 	return nil
 }
 
-func init() {
-	// Load current year data
-	currentYear := time.Now().Format("2006")
-	if err := loadYearData(currentYear); err != nil {
-		slog.Error("failed to load year data", "year", currentYear, "error", err)
+// Preload loads the daily texts of a year ahead of the first request for them, typically the current
+// year at startup.
+func Preload(year int) {
+	if err := loadYearData(strconv.Itoa(year)); err != nil {
+		slog.Error("failed to load year data", "year", year, "error", err)
 	}
 }
@@ -0,0 +1,220 @@
+package dailytexts_test
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"derrclan.com/moravian-soap/internal/dailytexts"
+)
+
+func TestWatchwordReference(t *testing.T) {
+	tests := []struct {
+		watchword string
+		want      string
+	}{
+		{"Do not fear, for I am with you. Isaiah 41:10", "Isaiah 41:10"},
+		{"He will raise up a deliverer. Judges 3:9 NIV", "Judges 3:9"},
+		{"There is no Holy One like the LORD. 1 Samuel 2:2", "1 Samuel 2:2"},
+		{"We have sinned and done wrong. Daniel 9:4-5", "Daniel 9:4-5"},
+		{"Arise, my love. Song of Solomon 2:10", "Song of Solomon 2:10"},
+		{"Taste and see that the LORD is good. Psalm 34:8; 1 Peter 2:3 NIV", "Psalm 34:8; 1 Peter 2:3"},
+		{"No reference here.", ""},
+	}
+	for _, tt := range tests {
+		d := &dailytexts.DailyText{DailyWatchWord: tt.watchword}
+		if got := d.WatchwordReference(); got != tt.want {
+			t.Errorf("WatchwordReference(%q) = %q, want %q", tt.watchword, got, tt.want)
+		}
+	}
+}
+
+func TestWatchwordReferences(t *testing.T) {
+	tests := []struct {
+		watchword string
+		want      []string
+	}{
+		{"Do not fear, for I am with you. Isaiah 41:10", []string{"Isaiah 41:10"}},
+		{"Taste and see that the LORD is good. Psalm 34:8; 1 Peter 2:3 NIV", []string{"Psalm 34:8", "1 Peter 2:3"}},
+		{"Fear not, for I have redeemed you. Isaiah 41:10; 43:1-2; John 14:27", []string{"Isaiah 41:10", "Isaiah 43:1-2", "John 14:27"}},
+		{"No reference here.", nil},
+	}
+	for _, tt := range tests {
+		d := &dailytexts.DailyText{DailyWatchWord: tt.watchword}
+		if got := d.WatchwordReferences(); !slices.Equal(got, tt.want) {
+			t.Errorf("WatchwordReferences(%q) = %q, want %q", tt.watchword, got, tt.want)
+		}
+	}
+}
+
+func TestDoctrinalReferences(t *testing.T) {
+	tests := []struct {
+		doctrinal string
+		want      []string
+	}{
+		{"Do not repay evil for evil or abuse for abuse, but, on the contrary, repay with a blessing. 1 Peter 3:9", []string{"1 Peter 3:9"}},
+		{"Jesus said to him, \u201cRise, take up your bed and walk.\u201d John 5:7-8 NKJV", []string{"John 5:7-8"}},
+		{"We believe in the triune God. Moravian Covenant for Christian Living", nil},
+	}
+	for _, tt := range tests {
+		d := &dailytexts.DailyText{Doctrinal: tt.doctrinal}
+		if got := d.DoctrinalReferences(); !slices.Equal(got, tt.want) {
+			t.Errorf("DoctrinalReferences(%q) = %q, want %q", tt.doctrinal, got, tt.want)
+		}
+	}
+}
+
+func TestWatchwordReference_AllTexts(t *testing.T) {
+	start := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2027, time.January, 1, 0, 0, 0, 0, time.UTC)
+	for day := start; day.Before(end); day = day.AddDate(0, 0, 1) {
+		date := day.Format(time.DateOnly)
+		d, err := dailytexts.GetDailyText(date)
+		if err != nil || d == nil {
+			t.Fatalf("GetDailyText(%s) = %v, %v", date, d, err)
+		}
+		if d.WatchwordReference() == "" {
+			t.Errorf("no reference found in watchword for %s: %q", date, d.DailyWatchWord)
+		}
+	}
+}
+
+func TestYears(t *testing.T) {
+	writeYears(t, map[int][]string{2030: {"2030-01-01"}, 2025: {"2025-01-01"}})
+
+	years, err := dailytexts.Years()
+	if err != nil {
+		t.Fatalf("Years failed: %v", err)
+	}
+	if want := []int{2025, 2026, 2030}; !slices.Equal(years, want) {
+		t.Errorf("Years() = %v, want %v", years, want)
+	}
+}
+
+func TestHasYear(t *testing.T) {
+	if !dailytexts.HasYear(2026) {
+		t.Error("expected texts for 2026")
+	}
+	if dailytexts.HasYear(1999) {
+		t.Error("expected no texts for 1999")
+	}
+}
+
+// writeYears writes year files with texts for the given dates to a new texts directory and gives it
+// precedence over the embedded texts. Each text's prayer names its date and the year of its file.
+func writeYears(t *testing.T, years map[int][]string) {
+	t.Helper()
+	dir := t.TempDir()
+	for year, dates := range years {
+		y := make(dailytexts.Year)
+		for _, date := range dates {
+			y[date] = dailytexts.DailyText{Verses: []string{"Psalm 90"}, Prayer: fmt.Sprintf("%s from %d", date, year)}
+		}
+		if _, err := dailytexts.WriteYear(dir, year, y); err != nil {
+			t.Fatalf("WriteYear failed: %v", err)
+		}
+	}
+	dailytexts.SetDir(dir)
+	t.Cleanup(func() {
+		dailytexts.SetDir("")
+		dailytexts.SetStitchYears(false)
+	})
+}
+
+// prayer returns the prayer of the daily text for date, or "none" if there is none.
+func prayer(t *testing.T, date string) string {
+	t.Helper()
+	d, err := dailytexts.GetDailyText(date)
+	if err != nil {
+		t.Fatalf("GetDailyText(%s) failed: %v", date, err)
+	}
+	if d == nil {
+		return "none"
+	}
+	return d.Prayer
+}
+
+func TestGetDailyText_LeapDay(t *testing.T) {
+	writeYears(t, map[int][]string{
+		2028: {"2028-02-28", "2028-02-29", "2028-03-01"},
+		2029: {"2029-02-28", "2029-03-01"},
+	})
+
+	if got := prayer(t, "2028-02-29"); got != "2028-02-29 from 2028" {
+		t.Errorf("leap day text = %q", got)
+	}
+	if _, err := dailytexts.GetDailyText("2029-02-29"); err == nil {
+		t.Error("expected error for Feb 29 in a common year")
+	}
+}
+
+func TestGetDailyText_InvalidDatesInFile(t *testing.T) {
+	writeYears(t, map[int][]string{2030: {"2030-01-01", "2030-02-29"}})
+
+	if _, err := dailytexts.GetDailyText("2030-01-01"); err == nil {
+		t.Error("expected error for a year file with Feb 29 in a common year")
+	}
+}
+
+func TestGetDailyText_StitchYears(t *testing.T) {
+	// The 2031 file carries the watch-night texts of New Year's Eve before it and New Year's Day after
+	// it, and there is no 2032 file.
+	writeYears(t, map[int][]string{
+		2030: {"2030-12-30"},
+		2031: {"2030-12-31", "2031-01-01", "2031-12-31", "2032-01-01"},
+	})
+
+	if got := prayer(t, "2030-12-31"); got != "none" {
+		t.Errorf("text from the adjacent year without stitching = %q", got)
+	}
+	if _, err := dailytexts.GetDailyText("2032-01-01"); err == nil {
+		t.Error("expected error for a year without a file without stitching")
+	}
+
+	dailytexts.SetStitchYears(true)
+	tests := []struct {
+		date string
+		want string
+	}{
+		{"2030-12-31", "2030-12-31 from 2031"},
+		{"2031-01-01", "2031-01-01 from 2031"},
+		{"2031-12-31", "2031-12-31 from 2031"},
+		{"2032-01-01", "2032-01-01 from 2031"},
+		{"2030-12-29", "none"},
+	}
+	for _, tt := range tests {
+		if got := prayer(t, tt.date); got != tt.want {
+			t.Errorf("text for %s = %q, want %q", tt.date, got, tt.want)
+		}
+	}
+	if _, err := dailytexts.GetDailyText("2032-01-02"); err == nil {
+		t.Error("expected error for a year without a file outside the boundary dates")
+	}
+}
+
+func TestAdjacentDates(t *testing.T) {
+	// 2034 has no file; the embedded texts start in 2025.
+	writeYears(t, map[int][]string{
+		2033: {"2033-03-01", "2033-03-05"},
+		2035: {"2035-01-10"},
+	})
+
+	tests := []struct {
+		date, prev, next string
+	}{
+		{"2033-03-03", "2033-03-01", "2033-03-05"},
+		{"2033-03-05", "2033-03-01", "2035-01-10"},
+		{"2035-01-10", "2033-03-05", ""},
+		{"2025-01-01", "", "2025-01-02"},
+	}
+	for _, tt := range tests {
+		prev, next, err := dailytexts.AdjacentDates(tt.date)
+		if err != nil {
+			t.Fatalf("AdjacentDates(%s) failed: %v", tt.date, err)
+		}
+		if prev != tt.prev || next != tt.next {
+			t.Errorf("AdjacentDates(%s) = %q, %q; want %q, %q", tt.date, prev, next, tt.prev, tt.next)
+		}
+	}
+}
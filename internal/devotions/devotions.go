@@ -0,0 +1,117 @@
+// Package devotions provides discussion questions for family devotions on the daily watchword.
+//
+// Questions are embedded from questions.json. They are grouped by the genre of the book a watchword is
+// taken from, with one set for young children and another for older children and adults.
+package devotions
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+
+	"derrclan.com/moravian-soap/internal/civil"
+)
+
+//go:embed questions.json
+var bank []byte
+
+// Audience is who a set of questions is written for.
+type Audience string
+
+const (
+	// Kids questions are for young children, and are shown in the kid-friendly layout.
+	Kids Audience = "kids"
+	// Family questions are for older children and adults.
+	Family Audience = "family"
+)
+
+// ParseAudience returns the audience named s, or false if there is no such audience.
+func ParseAudience(s string) (Audience, bool) {
+	switch a := Audience(s); a {
+	case Kids, Family:
+		return a, true
+	}
+	return "", false
+}
+
+// PerDay is the number of questions suggested for a day.
+const PerDay = 3
+
+// genre is a group of books of the Bible and the questions asked about passages from them.
+type genre struct {
+	ID     string   `json:"genre"`
+	Name   string   `json:"name"`
+	Books  []string `json:"books"`
+	Kids   []string `json:"kids"`
+	Family []string `json:"family"`
+}
+
+// Devotion is the discussion for a day's watchword.
+type Devotion struct {
+	// Genre is the display name of the genre of the watchword's book, e.g. "The Gospels".
+	Genre     string
+	Questions []string
+}
+
+// genres maps each book name, as it appears in references, to its genre.
+var genres = func() map[string]*genre {
+	gs, err := parse(bank)
+	if err != nil {
+		panic(fmt.Sprintf("questions.json: %v", err))
+	}
+	return gs
+}()
+
+// parse decodes and validates a question bank, indexing its genres by book.
+func parse(data []byte) (map[string]*genre, error) {
+	var gs []*genre
+	if err := json.Unmarshal(data, &gs); err != nil {
+		return nil, fmt.Errorf("decoding question bank: %w", err)
+	}
+	byBook := make(map[string]*genre)
+	for _, g := range gs {
+		if g.ID == "" || g.Name == "" {
+			return nil, errors.New("genre has no ID or name")
+		}
+		if len(g.Kids) < PerDay || len(g.Family) < PerDay {
+			return nil, fmt.Errorf("genre %q needs at least %d questions for each audience", g.ID, PerDay)
+		}
+		for _, book := range g.Books {
+			if other, ok := byBook[book]; ok {
+				return nil, fmt.Errorf("book %q is in genres %q and %q", book, other.ID, g.ID)
+			}
+			byBook[book] = g
+		}
+	}
+	return byBook, nil
+}
+
+// bookRe matches the book at the start of a reference, e.g. "1 Samuel" in "1 Samuel 2:8".
+var bookRe = regexp.MustCompile(`^(.+?) \d`)
+
+// For returns the questions for discussing a watchword with the audience on date. Each genre's
+// questions are suggested in turn, PerDay at a time, so consecutive days in the same genre differ. It
+// returns false if the book of the reference is not known.
+func For(reference string, audience Audience, date civil.Date) (Devotion, bool) {
+	m := bookRe.FindStringSubmatch(reference)
+	if m == nil {
+		return Devotion{}, false
+	}
+	g, ok := genres[m[1]]
+	if !ok {
+		return Devotion{}, false
+	}
+
+	questions := g.Family
+	if audience == Kids {
+		questions = g.Kids
+	}
+	start := date.Time().YearDay() * PerDay
+	d := Devotion{Genre: g.Name}
+	for i := range PerDay {
+		d.Questions = append(d.Questions, questions[(start+i)%len(questions)])
+	}
+	return d, true
+}
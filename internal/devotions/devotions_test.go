@@ -0,0 +1,91 @@
+package devotions
+
+import (
+	"slices"
+	"testing"
+
+	"derrclan.com/moravian-soap/internal/civil"
+)
+
+func TestFor(t *testing.T) {
+	date := civil.Date{Year: 2026, Month: 3, Day: 1}
+	tests := []struct {
+		reference string
+		genre     string
+	}{
+		{"Genesis 1:1", "The Law"},
+		{"1 Samuel 2:8", "History"},
+		{"Psalm 115:2-3", "Songs and Prayers"},
+		{"Song of Solomon 2:4", "Songs and Prayers"},
+		{"Proverbs 3:5-6", "Wisdom"},
+		{"Revelation 21:4", "The Prophets"},
+		{"John 3:16", "The Gospels"},
+		{"3 John 4", "The Letters"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.reference, func(t *testing.T) {
+			for _, audience := range []Audience{Kids, Family} {
+				d, ok := For(tt.reference, audience, date)
+				if !ok {
+					t.Fatalf("For(%q, %s) found no questions", tt.reference, audience)
+				}
+				if d.Genre != tt.genre {
+					t.Errorf("For(%q, %s).Genre = %q, want %q", tt.reference, audience, d.Genre, tt.genre)
+				}
+				if len(d.Questions) != PerDay || len(slices.Compact(slices.Clone(d.Questions))) != PerDay {
+					t.Errorf("For(%q, %s) = %q, want %d different questions", tt.reference, audience, d.Questions, PerDay)
+				}
+			}
+		})
+	}
+
+	for _, ref := range []string{"", "Tobit 1:1", "Jesus wept"} {
+		if d, ok := For(ref, Family, date); ok {
+			t.Errorf("For(%q) = %+v, want no questions", ref, d)
+		}
+	}
+}
+
+func TestFor_Audience(t *testing.T) {
+	date := civil.Date{Year: 2026, Month: 3, Day: 1}
+	kids, _ := For("John 3:16", Kids, date)
+	family, _ := For("John 3:16", Family, date)
+	if slices.Equal(kids.Questions, family.Questions) {
+		t.Errorf("kids and family were asked the same questions: %q", kids.Questions)
+	}
+}
+
+func TestFor_Rotates(t *testing.T) {
+	day := civil.Date{Year: 2026, Month: 3, Day: 1}
+	today, _ := For("John 3:16", Family, day)
+	tomorrow, _ := For("John 3:16", Family, day.AddDays(1))
+	if slices.Equal(today.Questions, tomorrow.Questions) {
+		t.Errorf("the same questions were suggested on consecutive days: %q", today.Questions)
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	questions := `["a", "b", "c"]`
+	tests := map[string]string{
+		"not JSON":    `{`,
+		"no name":     `[{"genre": "law", "books": ["Genesis"], "kids": ` + questions + `, "family": ` + questions + `}]`,
+		"too few":     `[{"genre": "law", "name": "Law", "books": ["Genesis"], "kids": ["a"], "family": ` + questions + `}]`,
+		"book in two": `[{"genre": "law", "name": "Law", "books": ["Genesis"], "kids": ` + questions + `, "family": ` + questions + `}, {"genre": "history", "name": "History", "books": ["Genesis"], "kids": ` + questions + `, "family": ` + questions + `}]`,
+	}
+	for name, data := range tests {
+		if _, err := parse([]byte(data)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestParseAudience(t *testing.T) {
+	for _, s := range []string{"kids", "family"} {
+		if a, ok := ParseAudience(s); !ok || string(a) != s {
+			t.Errorf("ParseAudience(%q) = %q, %v", s, a, ok)
+		}
+	}
+	if _, ok := ParseAudience("adults"); ok {
+		t.Error("ParseAudience accepted an unknown audience")
+	}
+}
@@ -4,7 +4,6 @@ package email
 import (
 	"context"
 	"fmt"
-	"log/slog"
 	"os"
 	"sync"
 	"time"
@@ -50,95 +49,47 @@ func GetClient() (*Client, error) {
 	return defaultClient, clientErr
 }
 
-// SendWelcomeEmail sends a welcome email using the client instance.
-func (c *Client) SendWelcomeEmail(ctx context.Context, recipientEmail, confirmationURL string) error {
-	subject := "Welcome to your Daily SOAP Journal - Please Confirm Your Email"
-	body := fmt.Sprintf(`
-<html>
-<body>
-	<h1>Welcome!</h1>
-	<p>Thank you for registering for your Daily SOAP Journal.</p>
-	<p>Please click the link below to confirm your email address and activate your account:</p>
-	<p><a href="%s">Confirm Email</a></p>
-	<p>Or copy and paste this link into your browser:</p>
-	<p>%s</p>
-	<p>This link will expire in 24 hours.</p>
-</body>
-</html>
-`, confirmationURL, confirmationURL)
-
-	return c.send(ctx, recipientEmail, subject, body, "sent welcome email")
-}
-
-// SendPasswordResetEmail sends a password reset email using the client instance.
-func (c *Client) SendPasswordResetEmail(ctx context.Context, recipientEmail, resetURL string) error {
-	subject := "Reset Your Password - Daily SOAP Journal"
-	body := fmt.Sprintf(`
-<html>
-<body>
-	<h1>Password Reset Request</h1>
-	<p>We received a request to reset your password for your Daily SOAP Journal account.</p>
-	<p>Click the link below to reset your password:</p>
-	<p><a href="%s">Reset Password</a></p>
-	<p>Or copy and paste this link into your browser:</p>
-	<p>%s</p>
-	<p>This link will expire in 1 hour.</p>
-	<p>If you didn't request this, you can safely ignore this email.</p>
-</body>
-</html>
-`, resetURL, resetURL)
-
-	return c.send(ctx, recipientEmail, subject, body, "sent password reset email")
-}
-
-// send handles the actual email sending with exponential backoff retry logic.
-func (c *Client) send(ctx context.Context, recipient, subject, htmlBody, logMsg string) error {
+// send makes a single attempt to send an email. Failed sends are retried by the worker, with backoff,
+// from the queue.
+func (c *Client) send(ctx context.Context, recipient, subject, htmlBody string) error {
 	message := mailgun.NewMessage(c.domain, c.sender, subject, "")
 	if err := message.AddRecipient(recipient); err != nil {
 		return fmt.Errorf("adding recipient %q: %w", recipient, err)
 	}
 	message.SetHTML(htmlBody)
 
-	var lastErr error
-	maxRetries := 5
-	backoff := time.Second
-
-	for i := range maxRetries {
-		sendCtx, cancel := context.WithTimeout(ctx, time.Second*10)
-		_, lastErr = c.mg.Send(sendCtx, message)
-		cancel()
-
-		if lastErr == nil {
-			slog.Info(logMsg, "recipient", recipient, "subject", subject)
-			return nil
-		}
-
-		if i < maxRetries-1 {
-			select {
-			case <-ctx.Done():
-				return fmt.Errorf("waiting for retry backoff: %w", ctx.Err())
-			case <-time.After(backoff):
-				backoff *= 2
-			}
-		}
+	sendCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	if _, err := c.mg.Send(sendCtx, message); err != nil {
+		return fmt.Errorf("sending email to %q: %w", recipient, err)
 	}
+	return nil
+}
 
-	return fmt.Errorf("failed to send email after %d attempts: %w", maxRetries, lastErr)
+// Queue adds msg to the delivery queue for recipient, on behalf of the user, and wakes the worker to
+// send it.
+func Queue(ctx context.Context, s store.Store, userID int64, recipient string, msg Message) error {
+	email := &store.QueuedEmail{
+		UserID:        userID,
+		Recipient:     recipient,
+		Subject:       msg.Subject,
+		BodyHTML:      msg.BodyHTML,
+		Status:        "pending",
+		NextAttemptAt: time.Now(),
+	}
+	if err := s.QueueEmail(ctx, email); err != nil {
+		return fmt.Errorf("queuing email for %s: %w", recipient, err)
+	}
+	Wake()
+	return nil
 }
 
 // QueueExportEmail creates a queued email for each recipient for a SOAP export.
 func QueueExportEmail(ctx context.Context, s store.Store, user *store.User, date string, recipients []string, body string) error {
-	subject := fmt.Sprintf("SOAP Journal Entry - %s", date)
+	msg := Message{Subject: exportSubject(date), BodyHTML: body}
 	for _, recipient := range recipients {
-		email := &store.QueuedEmail{
-			UserID:    user.ID,
-			Recipient: recipient,
-			Subject:   subject,
-			BodyHTML:  body,
-			Status:    "pending",
-		}
-		if err := s.QueueEmail(ctx, email); err != nil {
-			return fmt.Errorf("queuing email for %s: %w", recipient, err)
+		if err := Queue(ctx, s, user.ID, recipient, msg); err != nil {
+			return err
 		}
 	}
 	return nil
@@ -0,0 +1,233 @@
+package email
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"maps"
+	"slices"
+	"strings"
+
+	"derrclan.com/moravian-soap/internal/export"
+	"derrclan.com/moravian-soap/internal/journal"
+	"derrclan.com/moravian-soap/internal/store"
+)
+
+// ErrUnknownTemplate is returned when previewing an email template that does not exist.
+var ErrUnknownTemplate = errors.New("unknown email template")
+
+// Message is a rendered email subject and HTML body.
+type Message struct {
+	Subject  string
+	BodyHTML string
+}
+
+// WelcomeEmail renders the email asking a new user to confirm their address.
+func WelcomeEmail(confirmationURL string) Message {
+	return Message{
+		Subject: "Welcome to your Daily SOAP Journal - Please Confirm Your Email",
+		BodyHTML: fmt.Sprintf(`
+<html>
+<body>
+	<h1>Welcome!</h1>
+	<p>Thank you for registering for your Daily SOAP Journal.</p>
+	<p>Please click the link below to confirm your email address and activate your account:</p>
+	<p><a href="%s">Confirm Email</a></p>
+	<p>Or copy and paste this link into your browser:</p>
+	<p>%s</p>
+	<p>This link will expire in 24 hours.</p>
+</body>
+</html>
+`, confirmationURL, confirmationURL),
+	}
+}
+
+// PasswordResetEmail renders the email containing a password reset link.
+func PasswordResetEmail(resetURL string) Message {
+	return Message{
+		Subject: "Reset Your Password - Daily SOAP Journal",
+		BodyHTML: fmt.Sprintf(`
+<html>
+<body>
+	<h1>Password Reset Request</h1>
+	<p>We received a request to reset your password for your Daily SOAP Journal account.</p>
+	<p>Click the link below to reset your password:</p>
+	<p><a href="%s">Reset Password</a></p>
+	<p>Or copy and paste this link into your browser:</p>
+	<p>%s</p>
+	<p>This link will expire in 1 hour.</p>
+	<p>If you didn't request this, you can safely ignore this email.</p>
+</body>
+</html>
+`, resetURL, resetURL),
+	}
+}
+
+// SubscriptionConfirmationEmail renders the email asking a user to confirm their subscription to the
+// daily email.
+func SubscriptionConfirmationEmail(confirmURL string) Message {
+	return Message{
+		Subject: "Confirm your daily email - Daily SOAP Journal",
+		BodyHTML: fmt.Sprintf(`
+<html>
+<body>
+	<h1>Confirm your daily email</h1>
+	<p>You asked to be emailed the day's watchword and readings each morning, along with what you wrote in your journal the day before.</p>
+	<p>Click the link below to start the emails:</p>
+	<p><a href="%s">Confirm Subscription</a></p>
+	<p>Or copy and paste this link into your browser:</p>
+	<p>%s</p>
+	<p>If you didn't ask for this, you can safely ignore this email.</p>
+</body>
+</html>
+`, confirmURL, confirmURL),
+	}
+}
+
+// AdminNotificationEmail renders the email telling the admin that a new user verified their account.
+func AdminNotificationEmail(userEmail string) Message {
+	return Message{
+		Subject:  "New User Registration: " + userEmail,
+		BodyHTML: "A new user has registered and verified their account: " + userEmail,
+	}
+}
+
+// DailyTextsChangedEmail renders the email telling the admin that reloading the daily texts found
+// corrections, one line per changed date.
+func DailyTextsChangedEmail(changes []string) Message {
+	var b strings.Builder
+	b.WriteString("<p>Reloading the daily texts changed the texts of these dates:</p>\n<ul>\n")
+	for _, change := range changes {
+		fmt.Fprintf(&b, "<li>%s</li>\n", html.EscapeString(change))
+	}
+	b.WriteString("</ul>\n")
+	return Message{
+		Subject:  fmt.Sprintf("Daily texts corrected: %d dates changed", len(changes)),
+		BodyHTML: b.String(),
+	}
+}
+
+// exportSubject returns the subject of the email carrying the journal entry for date.
+func exportSubject(date string) string {
+	return fmt.Sprintf("SOAP Journal Entry - %s", date)
+}
+
+// exportPreview renders a sample journal entry the way handleExport does for export emails.
+func exportPreview() (Message, error) {
+	exporter, err := export.NewHTMLExporter()
+	if err != nil {
+		return Message{}, fmt.Errorf("creating HTML exporter: %w", err)
+	}
+	entry := &store.SOAPData{
+		Date: "2026-01-01",
+		Sections: map[string]string{
+			"observation": "God's love is the starting point of the gospel.",
+			"application": "Rest in being loved before trying to earn it.",
+			"prayer":      "Father, thank you for giving your Son.",
+		},
+	}
+	scripture := `<p><span class="verse"><b class="verse-num">16</b>For God so loved the world, that he gave his only Son, that whoever believes in him should not perish but have eternal life.</span></p>`
+
+	var buf bytes.Buffer
+	if err := exporter.Export(context.Background(), &buf, entry, journal.DefaultSchema(), scripture); err != nil {
+		return Message{}, fmt.Errorf("exporting sample entry: %w", err)
+	}
+	return Message{Subject: exportSubject(entry.Date), BodyHTML: buf.String()}, nil
+}
+
+// previews renders each email template with sample data.
+var previews = map[string]func() (Message, error){
+	"welcome": func() (Message, error) {
+		return WelcomeEmail("http://localhost:8080/confirm?token=sample-token"), nil
+	},
+	"password-reset": func() (Message, error) {
+		return PasswordResetEmail("http://localhost:8080/reset-password?token=sample-token"), nil
+	},
+	"admin-notification": func() (Message, error) {
+		return AdminNotificationEmail("new.user@example.com"), nil
+	},
+	"daily-texts-changed": func() (Message, error) {
+		return DailyTextsChangedEmail([]string{"2026-03-01: verses changed", "2026-03-02: daily_watchword, prayer changed"}), nil
+	},
+	"export": exportPreview,
+	"instance-report": func() (Message, error) {
+		r := InstanceReport{
+			InstanceMetrics: store.InstanceMetrics{
+				DatabaseBytes: 48_300_000,
+				CacheEntries:  212,
+				CacheBytes:    3_400_000,
+				ESVRequests:   530,
+				EmailsFailed:  2,
+				EmailsPending: 1,
+			},
+			From: "2026-12-01", To: "2026-12-07",
+			CacheHits: 4120, CacheMisses: 530,
+			ESVQuota:     35000,
+			Errors:       3,
+			MissingYears: []int{2027},
+		}
+		return InstanceReportEmail(r, "http://localhost:8080/admin/emails"), nil
+	},
+	"inactivity-reminder": func() (Message, error) {
+		return InactivityReminderEmail(3, "http://localhost:8080/", "http://localhost:8080/settings/reminders"), nil
+	},
+	"daily": func() (Message, error) {
+		yesterday := journal.DefaultSchema().Entries(map[string]string{
+			"observation": "The day is a gift.\n\nIt is the Lord's doing.",
+			"prayer":      "Help me to rejoice in it.",
+		})
+		return DailyEmail("2026-01-02", sampleWatchword, []string{"Genesis 1:1-2:3", "Matthew 1:1-17"}, yesterday, nil,
+			"http://localhost:8080/?date=2026-01-02", "http://localhost:8080/?date=2026-01-01", "http://localhost:8080/settings/reminders",
+			"http://localhost:8080/subscriptions/unsubscribe?token=sample-token"), nil
+	},
+	"daily-favorites": func() (Message, error) {
+		// On the first of the month, the daily email looks back on the user's favorite entries.
+		favorites := []Favorite{
+			{Date: "2025-12-24", Excerpt: "The light shines in the darkness.", URL: "http://localhost:8080/?date=2025-12-24"},
+			{Date: "2026-01-02", Excerpt: "The day is a gift.", URL: "http://localhost:8080/?date=2026-01-02"},
+		}
+		return DailyEmail("2026-02-01", sampleWatchword, []string{"Exodus 1", "Mark 1:1-13"}, nil, favorites,
+			"http://localhost:8080/?date=2026-02-01", "http://localhost:8080/?date=2026-01-31", "http://localhost:8080/settings/reminders",
+			"http://localhost:8080/subscriptions/unsubscribe?token=sample-token"), nil
+	},
+	"mentor-comment": func() (Message, error) {
+		return MentorCommentEmail("mentor@example.com", "2026-01-02", "What a good reminder.\nThank you for sharing it.",
+			"http://localhost:8080/?date=2026-01-02"), nil
+	},
+	"subscription-confirmation": func() (Message, error) {
+		return SubscriptionConfirmationEmail("http://localhost:8080/subscriptions/confirm?token=sample-token"), nil
+	},
+	"reminder": func() (Message, error) {
+		return ReminderEmail("2026-01-01", "http://localhost:8080/?date=2026-01-01", sampleWatchword), nil
+	},
+	"reminder-linked": func() (Message, error) {
+		// A passage too long to quote is linked instead.
+		w := sampleWatchword
+		w.Text, w.Copyright = "", ""
+		return ReminderEmail("2026-01-01", "http://localhost:8080/?date=2026-01-01", w), nil
+	},
+}
+
+// sampleWatchword is a watchword with its quoted text, for previews.
+var sampleWatchword = Watchword{
+	Reference: "Psalm 118:24",
+	Watchword: "This is the day that the Lord has made; let us rejoice and be glad in it. Psalm 118:24",
+	Text:      "This is the day that the Lord has made; let us rejoice and be glad in it.",
+	Copyright: "Scripture quotations are from the ESV® Bible (The Holy Bible, English Standard Version®), copyright © 2001 by Crossway, a publishing ministry of Good News Publishers. Used by permission. All rights reserved.",
+}
+
+// PreviewNames returns the names of all email templates that can be previewed, sorted alphabetically.
+func PreviewNames() []string {
+	return slices.Sorted(maps.Keys(previews))
+}
+
+// Preview renders the named email template with sample data.
+func Preview(name string) (Message, error) {
+	render, ok := previews[name]
+	if !ok {
+		return Message{}, fmt.Errorf("%w: %s", ErrUnknownTemplate, name)
+	}
+	return render()
+}
@@ -0,0 +1,158 @@
+package email_test
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"derrclan.com/moravian-soap/internal/email"
+	"derrclan.com/moravian-soap/internal/esv"
+)
+
+func TestPreview(t *testing.T) {
+	names := email.PreviewNames()
+	if len(names) == 0 {
+		t.Fatal("expected at least one previewable template")
+	}
+
+	for _, name := range names {
+		t.Run(name, func(t *testing.T) {
+			msg, err := email.Preview(name)
+			if err != nil {
+				t.Fatalf("Preview(%q) failed: %v", name, err)
+			}
+			if msg.Subject == "" || msg.BodyHTML == "" {
+				t.Errorf("Preview(%q) returned empty subject or body: %+v", name, msg)
+			}
+		})
+	}
+}
+
+func TestPreview_UnknownTemplate(t *testing.T) {
+	_, err := email.Preview("does-not-exist")
+	if !errors.Is(err, email.ErrUnknownTemplate) {
+		t.Errorf("expected ErrUnknownTemplate, got %v", err)
+	}
+}
+
+func TestPreview_Export(t *testing.T) {
+	msg, err := email.Preview("export")
+	if err != nil {
+		t.Fatalf("Preview(export) failed: %v", err)
+	}
+	if msg.Subject != "SOAP Journal Entry - 2026-01-01" {
+		t.Errorf("unexpected subject: %q", msg.Subject)
+	}
+	// The preview must match what handleExport sends, which is rendered by the HTML exporter.
+	if !strings.Contains(msg.BodyHTML, "<!DOCTYPE html>") || !strings.Contains(msg.BodyHTML, "For God so loved the world") {
+		t.Errorf("export preview not rendered by the HTML exporter: %s", msg.BodyHTML)
+	}
+}
+
+func TestNewWatchword(t *testing.T) {
+	const copyright = "ESV copyright notice"
+	short := `<p><span class="verse">This is the day that the Lord has made; let us rejoice &amp; be glad in it.</span></p>`
+	long := "<p>" + strings.Repeat("word ", email.MaxEmbeddedWords+1) + "</p>"
+
+	tests := []struct {
+		name     string
+		resp     esv.Response
+		wantText string
+	}{
+		{"short passage", esv.Response{Passages: []string{short}, Copyright: copyright}, "This is the day that the Lord has made; let us rejoice & be glad in it."},
+		{"long passage", esv.Response{Passages: []string{long}, Copyright: copyright}, ""},
+		{"cached word count", esv.Response{Passages: []string{short}, Copyright: copyright, WordCounts: []int{email.MaxEmbeddedWords + 1}}, ""},
+		{"no copyright", esv.Response{Passages: []string{short}}, ""},
+		{"not fetched", esv.Response{}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, err := email.NewWatchword("Psalm 118:24", "This is the day. Psalm 118:24", tt.resp)
+			if err != nil {
+				t.Fatalf("NewWatchword failed: %v", err)
+			}
+			if w.Text != tt.wantText {
+				t.Errorf("Text = %q, want %q", w.Text, tt.wantText)
+			}
+
+			body := email.ReminderEmail("2026-01-01", "https://soap.example.com/", w).BodyHTML
+			if tt.wantText != "" {
+				if !strings.Contains(body, "rejoice &amp; be glad") || !strings.Contains(body, copyright) {
+					t.Errorf("quoted watchword missing text or attribution: %s", body)
+				}
+			} else if !strings.Contains(body, `href="https://www.esv.org/Psalm%20118:24/"`) {
+				t.Errorf("unquoted watchword missing passage link: %s", body)
+			}
+			if !strings.Contains(body, "Moravian Daily Texts") {
+				t.Errorf("watchword missing Daily Texts attribution: %s", body)
+			}
+		})
+	}
+}
+
+func TestParseWebhook(t *testing.T) {
+	const key = "webhook-signing-key"
+	now := time.Unix(1760600000, 0)
+	payload := func(key string, timestamp int64, event string) []byte {
+		ts := strconv.FormatInt(timestamp, 10)
+		mac := hmac.New(sha256.New, []byte(key))
+		mac.Write([]byte(ts + "token"))
+		return fmt.Appendf(nil, `{"signature": {"timestamp": %q, "token": "token", "signature": %q}, "event-data": %s}`,
+			ts, hex.EncodeToString(mac.Sum(nil)), event)
+	}
+	bounce := `{"event": "failed", "severity": "permanent", "recipient": "gone@example.com"}`
+
+	e, err := email.ParseWebhook(payload(key, now.Unix(), bounce), key, now)
+	if err != nil {
+		t.Fatalf("ParseWebhook failed: %v", err)
+	}
+	if e.Recipient != "gone@example.com" || e.SuppressionReason() != email.ReasonBounce {
+		t.Errorf("event = %+v, want a bounce from gone@example.com", e)
+	}
+
+	invalid := map[string][]byte{
+		"wrong key": payload("other-key", now.Unix(), bounce),
+		"stale":     payload(key, now.Add(-time.Hour).Unix(), bounce),
+		"unsigned":  []byte(`{"event-data": ` + bounce + `}`),
+	}
+	for name, body := range invalid {
+		if _, err := email.ParseWebhook(body, key, now); !errors.Is(err, email.ErrInvalidWebhookSignature) {
+			t.Errorf("%s: got %v, want ErrInvalidWebhookSignature", name, err)
+		}
+	}
+	if _, err := email.ParseWebhook(payload("", now.Unix(), bounce), "", now); !errors.Is(err, email.ErrInvalidWebhookSignature) {
+		t.Errorf("empty signing key: got %v, want ErrInvalidWebhookSignature", err)
+	}
+}
+
+func TestWebhookEvent_SuppressionReason(t *testing.T) {
+	tests := []struct {
+		event email.WebhookEvent
+		want  string
+	}{
+		{email.WebhookEvent{Event: "failed", Severity: "permanent"}, email.ReasonBounce},
+		{email.WebhookEvent{Event: "failed", Severity: "temporary"}, ""},
+		{email.WebhookEvent{Event: "complained"}, email.ReasonComplaint},
+		{email.WebhookEvent{Event: "delivered"}, ""},
+	}
+	for _, tt := range tests {
+		if got := tt.event.SuppressionReason(); got != tt.want {
+			t.Errorf("%+v.SuppressionReason() = %q, want %q", tt.event, got, tt.want)
+		}
+	}
+}
+
+func TestInactivityReminderEmail(t *testing.T) {
+	msg := email.InactivityReminderEmail(4, "https://soap.example.com/", "https://soap.example.com/settings/reminders")
+	for _, want := range []string{"4 days", `href="https://soap.example.com/"`, `href="https://soap.example.com/settings/reminders"`} {
+		if !strings.Contains(msg.BodyHTML, want) {
+			t.Errorf("reminder is missing %q: %s", want, msg.BodyHTML)
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package email
+
+import (
+	"fmt"
+	"html"
+	"net/url"
+	"strings"
+
+	"derrclan.com/moravian-soap/internal/dailytexts"
+	"derrclan.com/moravian-soap/internal/esv"
+	"derrclan.com/moravian-soap/internal/htmltext"
+	"derrclan.com/moravian-soap/internal/journal"
+)
+
+// MaxEmbeddedWords is the longest passage, in words of scripture, whose text is quoted in an email.
+// Emails are copies we cannot take back once sent, so only short passages are quoted, well within
+// the ESV's terms for quotation without permission; longer ones are linked instead.
+const MaxEmbeddedWords = 100
+
+// Watchword is a day's watchword as shown in an email.
+type Watchword struct {
+	// Reference is the Bible reference of the watchword, e.g. "Psalm 115:2-3".
+	Reference string
+	// Watchword is the watchword as printed in the Daily Texts, including its reference.
+	Watchword string
+	// Text is the plain ESV text of Reference, or empty if it is not to be quoted.
+	Text string
+	// Copyright is the ESV copyright notice that must accompany Text.
+	Copyright string
+}
+
+// NewWatchword returns the watchword for an email, quoting the ESV text in resp when it is a single
+// passage of at most MaxEmbeddedWords words. resp may be empty if the text could not be fetched.
+func NewWatchword(reference, watchword string, resp esv.Response) (Watchword, error) {
+	w := Watchword{Reference: reference, Watchword: watchword}
+	if len(resp.Passages) != 1 || resp.Copyright == "" {
+		return w, nil
+	}
+	words := esv.WordCount(resp.Passages[0])
+	if len(resp.WordCounts) == 1 {
+		words = resp.WordCounts[0]
+	}
+	if words > MaxEmbeddedWords {
+		return w, nil
+	}
+	text, err := htmltext.ToText(resp.Passages[0])
+	if err != nil {
+		return w, fmt.Errorf("converting %s to text: %w", reference, err)
+	}
+	w.Text, w.Copyright = text, resp.Copyright
+	return w, nil
+}
+
+// passageURL returns the address of reference on esv.org, for readers of emails that do not quote it.
+func passageURL(reference string) string {
+	return "https://www.esv.org/" + url.PathEscape(reference) + "/"
+}
+
+// WatchwordHTML renders w for the body of an email: the quoted ESV text with its attribution when w
+// has any, and otherwise the watchword as printed with a link to read the passage.
+func WatchwordHTML(w Watchword) string {
+	var b strings.Builder
+	if w.Text != "" {
+		b.WriteString("<blockquote>\n")
+		for para := range strings.SplitSeq(w.Text, "\n\n") {
+			fmt.Fprintf(&b, "\t<p>%s</p>\n", strings.ReplaceAll(html.EscapeString(para), "\n", "<br>"))
+		}
+		fmt.Fprintf(&b, "\t<p><cite>%s</cite></p>\n</blockquote>\n", html.EscapeString(w.Reference))
+		fmt.Fprintf(&b, "<p><small>%s</small></p>\n", html.EscapeString(w.Copyright))
+		return b.String()
+	}
+	fmt.Fprintf(&b, "<blockquote><p>%s</p></blockquote>\n", html.EscapeString(w.Watchword))
+	if w.Reference != "" {
+		fmt.Fprintf(&b, "<p><a href=\"%s\">Read %s</a></p>\n", passageURL(w.Reference), html.EscapeString(w.Reference))
+	}
+	return b.String()
+}
+
+// ReminderEmail renders the email reminding a user to journal on date, with that day's watchword.
+func ReminderEmail(date, journalURL string, w Watchword) Message {
+	return Message{
+		Subject: "Today's Watchword - " + date,
+		BodyHTML: fmt.Sprintf(`
+<html>
+<body>
+	<h1>Today's Watchword</h1>
+	%s
+	<p><small>Watchword from the %s.</small></p>
+	<p><a href="%s">Open your journal</a></p>
+</body>
+</html>
+`, WatchwordHTML(w), dailytexts.Attribution, journalURL),
+	}
+}
+
+// InactivityReminderEmail renders the email reminding a user who has not journaled for days days to
+// come back, with a link to turn the reminders off.
+func InactivityReminderEmail(days int, journalURL, settingsURL string) Message {
+	return Message{
+		Subject: "Your journal is waiting for you",
+		BodyHTML: fmt.Sprintf(`
+<html>
+<body>
+	<h1>Your journal is waiting for you</h1>
+	<p>It has been %d days since you last wrote in your Daily SOAP Journal. Today's reading is ready whenever you are.</p>
+	<p><a href="%s">Open your journal</a></p>
+	<p><small>You asked for this reminder when you stop journaling for a while. <a href="%s">Change or turn off reminders</a>.</small></p>
+</body>
+</html>
+`, days, journalURL, settingsURL),
+	}
+}
+
+// MentorCommentEmail renders the email telling a user that their mentor commented on their entry on
+// date, with a link to the entry.
+func MentorCommentEmail(mentor, date, comment, entryURL string) Message {
+	return Message{
+		Subject: fmt.Sprintf("%s commented on your journal entry of %s", mentor, date),
+		BodyHTML: fmt.Sprintf(`
+<html>
+<body>
+	<h1>A comment on your journal</h1>
+	<p>%s commented on your entry of %s:</p>
+	<blockquote>%s</blockquote>
+	<p><a href="%s">Open the entry</a></p>
+	<p><small>You shared this entry with %s. You can revoke their access on the Mentors page.</small></p>
+</body>
+</html>
+`, html.EscapeString(mentor), date, strings.ReplaceAll(html.EscapeString(comment), "\n", "<br>"), entryURL, html.EscapeString(mentor)),
+	}
+}
+
+// Favorite is one of the user's favorite journal entries, as listed in an email.
+type Favorite struct {
+	Date string
+	// Excerpt is the start of what was written in the entry, as plain text.
+	Excerpt string
+	URL     string
+}
+
+// DailyEmail renders the morning email for date: its watchword and readings, the sections the user
+// wrote in their journal the day before, the favorite entries to look back on, if any, and links to
+// today's and yesterday's entries, to the settings that change the email's time, and to unsubscribing.
+func DailyEmail(date string, w Watchword, readings []string, yesterday []journal.Entry, favorites []Favorite, journalURL, yesterdayURL, settingsURL, unsubscribeURL string) Message {
+	var b strings.Builder
+	if len(readings) > 0 {
+		b.WriteString("<h2>Today's Readings</h2>\n<ul>\n")
+		for _, ref := range readings {
+			fmt.Fprintf(&b, "\t<li>%s</li>\n", html.EscapeString(ref))
+		}
+		b.WriteString("</ul>\n")
+	}
+	b.WriteString("<h2>Yesterday's Journal</h2>\n")
+	written := false
+	for _, e := range yesterday {
+		if strings.TrimSpace(e.Content) == "" {
+			continue
+		}
+		written = true
+		fmt.Fprintf(&b, "<h3>%s</h3>\n", html.EscapeString(e.Name))
+		for para := range strings.SplitSeq(strings.TrimSpace(e.Content), "\n\n") {
+			fmt.Fprintf(&b, "<p>%s</p>\n", strings.ReplaceAll(html.EscapeString(para), "\n", "<br>"))
+		}
+	}
+	if !written {
+		fmt.Fprintf(&b, "<p>You did not write in your journal yesterday. <a href=\"%s\">There is still time to catch up</a>.</p>\n", yesterdayURL)
+	}
+	if len(favorites) > 0 {
+		b.WriteString("<h2>From Your Favorites</h2>\n<ul>\n")
+		for _, f := range favorites {
+			fmt.Fprintf(&b, "\t<li><a href=\"%s\">%s</a>", f.URL, html.EscapeString(f.Date))
+			if f.Excerpt != "" {
+				fmt.Fprintf(&b, ": %s", html.EscapeString(f.Excerpt))
+			}
+			b.WriteString("</li>\n")
+		}
+		b.WriteString("</ul>\n")
+	}
+
+	return Message{
+		Subject: "Today's Watchword - " + date,
+		BodyHTML: fmt.Sprintf(`
+<html>
+<body>
+	<h1>Today's Watchword</h1>
+	%s
+	<p><small>Watchword from the %s.</small></p>
+	%s
+	<p><a href="%s">Open your journal</a></p>
+	<p><small>You subscribed to this email each morning. <a href="%s">Change the time</a> or <a href="%s">unsubscribe</a>.</small></p>
+</body>
+</html>
+`, WatchwordHTML(w), dailytexts.Attribution, b.String(), journalURL, settingsURL, unsubscribeURL),
+	}
+}
@@ -0,0 +1,85 @@
+package email
+
+import (
+	"fmt"
+	"html"
+	"strings"
+
+	"derrclan.com/moravian-soap/internal/store"
+)
+
+// InstanceReport is the state of the instance over a week, as shown in the admin's metrics email.
+type InstanceReport struct {
+	store.InstanceMetrics
+	// From and To are the first and last dates of the week reported on.
+	From, To string
+	// CacheHits and CacheMisses count the passages looked up in the ESV cache during the week.
+	CacheHits, CacheMisses int64
+	// ESVQuota is the number of ESV API requests the instance could have made during the week.
+	ESVQuota int
+	// Errors is the number of errors logged during the week.
+	Errors int64
+	// MissingYears are the current and coming years whose daily texts have not been installed.
+	MissingYears []int
+}
+
+// formatBytes returns n bytes in the largest unit that keeps it at least 1, e.g. "1.5 MB".
+func formatBytes(n int64) string {
+	const unit = 1000
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	value, prefix := float64(n)/unit, 0
+	for value >= unit && prefix < 3 {
+		value /= unit
+		prefix++
+	}
+	return fmt.Sprintf("%.1f %cB", value, "kMGT"[prefix])
+}
+
+// percent returns part as a percentage of whole, or "n/a" if whole is zero.
+func percent(part, whole int64) string {
+	if whole == 0 {
+		return "n/a"
+	}
+	return fmt.Sprintf("%.0f%%", float64(part)*100/float64(whole))
+}
+
+// InstanceReportEmail renders the weekly email telling the admin how the instance is running, with a
+// link to the admin email page.
+func InstanceReportEmail(r InstanceReport, emailsURL string) Message {
+	var warnings strings.Builder
+	for _, year := range r.MissingYears {
+		fmt.Fprintf(&warnings, "\t<p><strong>The daily texts for %d are not installed.</strong> Readings for %d will not be shown until they are.</p>\n", year, year)
+	}
+	if r.EmailsFailed > 0 {
+		fmt.Fprintf(&warnings, "\t<p><strong>%d emails could not be delivered.</strong> <a href=\"%s\">Review email delivery</a>.</p>\n", r.EmailsFailed, emailsURL)
+	}
+
+	row := func(label, value string) string {
+		return fmt.Sprintf("\t\t<tr><th align=\"left\">%s</th><td>%s</td></tr>\n", label, html.EscapeString(value))
+	}
+	var table strings.Builder
+	table.WriteString(row("Database size", formatBytes(r.DatabaseBytes)))
+	table.WriteString(row("ESV cache", fmt.Sprintf("%d passages, %s", r.CacheEntries, formatBytes(r.CacheBytes))))
+	table.WriteString(row("ESV cache hit rate", fmt.Sprintf("%s (%d of %d lookups)", percent(r.CacheHits, r.CacheHits+r.CacheMisses), r.CacheHits, r.CacheHits+r.CacheMisses)))
+	table.WriteString(row("ESV API requests", fmt.Sprintf("%d of %d (%s of quota)", r.ESVRequests, r.ESVQuota, percent(int64(r.ESVRequests), int64(r.ESVQuota)))))
+	table.WriteString(row("Errors logged", fmt.Sprint(r.Errors)))
+	table.WriteString(row("Emails not delivered", fmt.Sprint(r.EmailsFailed)))
+	table.WriteString(row("Emails waiting to be sent", fmt.Sprint(r.EmailsPending)))
+
+	return Message{
+		Subject: fmt.Sprintf("Daily SOAP Journal weekly report - %s to %s", r.From, r.To),
+		BodyHTML: fmt.Sprintf(`
+<html>
+<body>
+	<h1>Weekly report</h1>
+	<p>How your Daily SOAP Journal instance ran from %s to %s.</p>
+%s	<table cellpadding="4">
+%s	</table>
+	<p><small>Errors and cache lookups are counted since the server last started if that was during the week.</small></p>
+</body>
+</html>
+`, r.From, r.To, warnings.String(), table.String()),
+	}
+}
@@ -0,0 +1,80 @@
+package email
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// ErrInvalidWebhookSignature is returned by ParseWebhook for requests that were not signed by Mailgun.
+var ErrInvalidWebhookSignature = errors.New("invalid webhook signature")
+
+// maxWebhookAge is how old a webhook's timestamp may be, to limit replays of captured requests.
+const maxWebhookAge = 15 * time.Minute
+
+// Suppression reasons recorded for addresses that must not be sent to again.
+const (
+	ReasonBounce    = "bounce"
+	ReasonComplaint = "complaint"
+)
+
+// WebhookEvent is a delivery event reported by a Mailgun webhook.
+type WebhookEvent struct {
+	// Event is the kind of event, e.g. "delivered", "failed" or "complained".
+	Event string `json:"event"`
+	// Severity is "permanent" or "temporary" for failed deliveries.
+	Severity  string `json:"severity"`
+	Recipient string `json:"recipient"`
+}
+
+// SuppressionReason returns the reason to stop sending to the event's recipient: ReasonBounce for a
+// permanent delivery failure and ReasonComplaint for a spam complaint. It returns "" for other events,
+// including temporary failures, which the queue retries.
+func (e *WebhookEvent) SuppressionReason() string {
+	switch {
+	case e.Event == "failed" && e.Severity == "permanent":
+		return ReasonBounce
+	case e.Event == "complained":
+		return ReasonComplaint
+	}
+	return ""
+}
+
+// ParseWebhook decodes the body of a Mailgun webhook request and checks that it was signed with
+// signingKey within maxWebhookAge of now.
+// See https://documentation.mailgun.com/docs/mailgun/user-manual/tracking-messages/#securing-webhooks.
+func ParseWebhook(body []byte, signingKey string, now time.Time) (*WebhookEvent, error) {
+	var payload struct {
+		Signature struct {
+			Timestamp string `json:"timestamp"`
+			Token     string `json:"token"`
+			Signature string `json:"signature"`
+		} `json:"signature"`
+		EventData WebhookEvent `json:"event-data"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("decoding webhook: %w", err)
+	}
+
+	sig := payload.Signature
+	mac := hmac.New(sha256.New, []byte(signingKey))
+	mac.Write([]byte(sig.Timestamp + sig.Token))
+	want := mac.Sum(nil)
+	got, err := hex.DecodeString(sig.Signature)
+	if signingKey == "" || err != nil || !hmac.Equal(got, want) {
+		return nil, ErrInvalidWebhookSignature
+	}
+	seconds, err := strconv.ParseInt(sig.Timestamp, 10, 64)
+	if err != nil {
+		return nil, ErrInvalidWebhookSignature
+	}
+	if age := now.Sub(time.Unix(seconds, 0)); age > maxWebhookAge || age < -maxWebhookAge {
+		return nil, fmt.Errorf("%w: timestamp is %s old", ErrInvalidWebhookSignature, age.Round(time.Second))
+	}
+	return &payload.EventData, nil
+}
@@ -5,10 +5,28 @@ import (
 	"log/slog"
 	"time"
 
+	"derrclan.com/moravian-soap/internal/maintenance"
 	"derrclan.com/moravian-soap/internal/store"
 )
 
-// StartWorker starts a background worker that polls for pending emails and sends them.
+// mailer sends a single email. It is implemented by *Client.
+type mailer interface {
+	send(ctx context.Context, recipient, subject, htmlBody string) error
+}
+
+// wake tells the worker that an email was queued, so it does not wait for the next poll to send it.
+var wake = make(chan struct{}, 1)
+
+// Wake asks the worker to check the queue now.
+func Wake() {
+	select {
+	case wake <- struct{}{}:
+	default:
+	}
+}
+
+// StartWorker starts a background worker that sends pending emails when they are queued, and polls
+// for emails that are due to be retried.
 func StartWorker(ctx context.Context, s store.Store, client *Client) {
 	ticker := time.NewTicker(1 * time.Minute)
 	defer ticker.Stop()
@@ -18,12 +36,13 @@ func StartWorker(ctx context.Context, s store.Store, client *Client) {
 		case <-ctx.Done():
 			return
 		case <-ticker.C:
-			processPendingEmails(ctx, s, client)
+		case <-wake:
 		}
+		maintenance.Run(func() { processPendingEmails(ctx, s, client) })
 	}
 }
 
-func processPendingEmails(ctx context.Context, s store.Store, client *Client) {
+func processPendingEmails(ctx context.Context, s store.Store, m mailer) {
 	emails, err := s.GetPendingEmails(ctx, 10)
 	if err != nil {
 		slog.Error("error getting pending emails", "error", err)
@@ -31,8 +50,20 @@ func processPendingEmails(ctx context.Context, s store.Store, client *Client) {
 	}
 
 	for _, e := range emails {
-		err := client.send(ctx, e.Recipient, e.Subject, e.BodyHTML, "sent queued email")
+		suppressed, err := s.IsEmailSuppressed(ctx, e.Recipient)
 		if err != nil {
+			slog.Error("error checking email suppression", "email_id", e.ID, "error", err)
+			continue
+		}
+		if suppressed {
+			slog.Info("not sending email to suppressed address", "email_id", e.ID, "recipient", e.Recipient)
+			if err := s.MarkEmailSuppressed(ctx, e.ID); err != nil {
+				slog.Error("error marking email as suppressed", "email_id", e.ID, "error", err)
+			}
+			continue
+		}
+
+		if err := m.send(ctx, e.Recipient, e.Subject, e.BodyHTML); err != nil {
 			slog.Error("error sending email", "email_id", e.ID, "recipient", e.Recipient, "error", err)
 			handleFailure(ctx, s, e)
 			continue
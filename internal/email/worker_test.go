@@ -2,6 +2,8 @@ package email
 
 import (
 	"context"
+	"errors"
+	"slices"
 	"testing"
 	"time"
 
@@ -13,6 +15,8 @@ type mockStore struct {
 	pendingEmails []*store.QueuedEmail
 	sentEmails    []int64
 	updatedEmails []updatedEmail
+	suppressed    map[string]bool
+	suppressedIDs []int64
 }
 
 type updatedEmail struct {
@@ -38,6 +42,56 @@ func (m *mockStore) UpdateEmailStatus(_ context.Context, id int64, status string
 	return nil
 }
 
+func (m *mockStore) IsEmailSuppressed(_ context.Context, address string) (bool, error) {
+	return m.suppressed[address], nil
+}
+
+func (m *mockStore) MarkEmailSuppressed(_ context.Context, id int64) error {
+	m.suppressedIDs = append(m.suppressedIDs, id)
+	return nil
+}
+
+// mockMailer records the recipients of the emails it sends, failing for those in fail.
+type mockMailer struct {
+	sent []string
+	fail map[string]bool
+}
+
+func (m *mockMailer) send(_ context.Context, recipient, _, _ string) error {
+	if m.fail[recipient] {
+		return errors.New("mailgun unavailable")
+	}
+	m.sent = append(m.sent, recipient)
+	return nil
+}
+
+func TestProcessPendingEmails(t *testing.T) {
+	ms := &mockStore{
+		pendingEmails: []*store.QueuedEmail{
+			{ID: 1, Recipient: "reader@example.com"},
+			{ID: 2, Recipient: "bounced@example.com"},
+			{ID: 3, Recipient: "down@example.com"},
+		},
+		suppressed: map[string]bool{"bounced@example.com": true},
+	}
+	mm := &mockMailer{fail: map[string]bool{"down@example.com": true}}
+
+	processPendingEmails(context.Background(), ms, mm)
+
+	if !slices.Equal(mm.sent, []string{"reader@example.com"}) {
+		t.Errorf("sent to %q, want only the unsuppressed address", mm.sent)
+	}
+	if !slices.Equal(ms.sentEmails, []int64{1}) {
+		t.Errorf("marked sent %v, want [1]", ms.sentEmails)
+	}
+	if !slices.Equal(ms.suppressedIDs, []int64{2}) {
+		t.Errorf("marked suppressed %v, want [2]", ms.suppressedIDs)
+	}
+	if len(ms.updatedEmails) != 1 || ms.updatedEmails[0].id != 3 || ms.updatedEmails[0].status != "pending" {
+		t.Errorf("updated %+v, want the failed email rescheduled", ms.updatedEmails)
+	}
+}
+
 // TestHandleFailure tests the handleFailure function.
 func TestHandleFailure(t *testing.T) {
 	ms := &mockStore{}
@@ -0,0 +1,151 @@
+package esv
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// DefaultBibleAPIURL is the bible-api.com endpoint, which serves public domain translations. It is
+// overridden by the BIBLE_API_URL environment variable, e.g. to point tests at a fake server.
+const DefaultBibleAPIURL = "https://bible-api.com/"
+
+// bibleAPITranslation fetches passages in a public domain translation from bible-api.com.
+type bibleAPITranslation struct {
+	// name is the translation's abbreviation, title its full name, and id its translation parameter on
+	// bible-api.com. The short copyright after each passage links to site.
+	name, title, id, site string
+}
+
+// bibleAPIResponse is the part of a bible-api.com response used to render a passage.
+type bibleAPIResponse struct {
+	Reference string `json:"reference"`
+	Verses    []struct {
+		BookName string `json:"book_name"`
+		Chapter  int    `json:"chapter"`
+		Verse    int    `json:"verse"`
+		Text     string `json:"text"`
+	} `json:"verses"`
+}
+
+// Name returns the translation's abbreviation.
+func (t bibleAPITranslation) Name() string { return t.name }
+
+// Copyright returns the notice the translation is quoted with. The translations on bible-api.com are in
+// the public domain.
+func (t bibleAPITranslation) Copyright() string {
+	return fmt.Sprintf("Scripture quotations are from the %s (%s), which is in the public domain.", t.title, t.name)
+}
+
+// maxConcurrentFetches is the most passages fetched from bible-api.com at once for one request.
+const maxConcurrentFetches = 4
+
+// FetchPassages fetches each passage from bible-api.com, up to maxConcurrentFetches at once, and renders
+// it in the shape of a transformed ESV passage, so that its verses can be selected in the same way.
+// Headings, indented poetry and the words of Christ are not marked by the API, so only verse numbers
+// and the short copyright follow opts. The passages are returned in the order of references.
+func (t bibleAPITranslation) FetchPassages(ctx context.Context, references []string, opts Options) (Response, error) {
+	resp := Response{
+		Query:       strings.Join(references, ";"),
+		Copyright:   t.Copyright(),
+		PassageMeta: make([]PassageMeta, len(references)),
+		Passages:    make([]string, len(references)),
+		WordCounts:  make([]int, len(references)),
+		Directions:  make([]Direction, len(references)),
+	}
+	client := &http.Client{
+		Timeout: 10 * time.Second,
+	}
+	g, ctx := errgroup.WithContext(ctx)
+	g.SetLimit(maxConcurrentFetches)
+	for i, ref := range references {
+		g.Go(func() error {
+			passage, err := t.fetch(ctx, client, ref)
+			if err != nil {
+				return err
+			}
+			meta, rendered, err := t.render(passage, opts)
+			if err != nil {
+				return fmt.Errorf("rendering %s: %w", ref, err)
+			}
+			resp.PassageMeta[i] = meta
+			resp.Passages[i] = rendered
+			resp.WordCounts[i] = WordCount(rendered)
+			resp.Directions[i] = PassageDirection(rendered)
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return Response{}, err
+	}
+	return resp, nil
+}
+
+// fetch fetches one passage from bible-api.com.
+func (t bibleAPITranslation) fetch(ctx context.Context, client *http.Client, reference string) (bibleAPIResponse, error) {
+	// See https://bible-api.com/ for API documentation.
+	apiURL := DefaultBibleAPIURL
+	if u := os.Getenv("BIBLE_API_URL"); u != "" {
+		apiURL = u
+	}
+	// The daily texts write ranges with dashes and mark optional verses with parentheses, neither of
+	// which the API understands.
+	reference = strings.NewReplacer("–", "-", "—", "-", "(", "", ")", "").Replace(reference)
+	apiURL = strings.TrimSuffix(apiURL, "/") + "/" + url.PathEscape(reference) + "?" + url.Values{"translation": {t.id}}.Encode()
+
+	var passage bibleAPIResponse
+	slog.Debug("fetching verses", "reference", reference, "apiURL", Redact(apiURL))
+	status, body, err := get(ctx, client, nil, t.name, []string{reference}, apiURL, nil)
+	if err != nil {
+		return passage, err
+	}
+	if status != http.StatusOK {
+		return passage, fmt.Errorf("bible-api.com returned status %d for %s", status, reference)
+	}
+	if err := json.Unmarshal(body, &passage); err != nil {
+		return passage, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return passage, nil
+}
+
+// render returns the metadata and HTML of a passage.
+func (t bibleAPITranslation) render(passage bibleAPIResponse, opts Options) (PassageMeta, string, error) {
+	meta := PassageMeta{Canonical: passage.Reference}
+	var b strings.Builder
+	if opts.IncludeHeadings {
+		fmt.Fprintf(&b, `<h2 class="extra_text">%s</h2>`, html.EscapeString(passage.Reference))
+	}
+	b.WriteString("<p>")
+	for _, v := range passage.Verses {
+		book, ok := bookNumbers[v.BookName]
+		if !ok {
+			return meta, "", fmt.Errorf("unknown book %q", v.BookName)
+		}
+		id := book*1000000 + v.Chapter*1000 + v.Verse
+		if meta.ChapterStart == nil {
+			meta.ChapterStart = []int{id}
+		}
+		meta.ChapterEnd = []int{id}
+
+		fmt.Fprintf(&b, `<span class="verse" data-ref="%08d">`, id)
+		if opts.IncludeVerseNumbers {
+			fmt.Fprintf(&b, `<b class="verse-num">%d</b>`, v.Verse)
+		}
+		b.WriteString(html.EscapeString(strings.Join(strings.Fields(v.Text), " ")))
+		b.WriteString(" </span>")
+	}
+	b.WriteString("</p>")
+	if opts.IncludeShortCopyright {
+		fmt.Fprintf(&b, `<p>(<a href="%s" class="copyright">%s</a>)</p>`, html.EscapeString(t.site), html.EscapeString(t.name))
+	}
+	return meta, b.String(), nil
+}
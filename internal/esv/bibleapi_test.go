@@ -0,0 +1,106 @@
+package esv
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBibleAPIFetchPassages(t *testing.T) {
+	var queries []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.Path+"?"+r.URL.RawQuery)
+		if _, err := w.Write([]byte(`{"reference":"John 11:35-36","verses":[
+			{"book_name":"John","chapter":11,"verse":35,"text":"Jesus wept.\n"},
+			{"book_name":"John","chapter":11,"verse":36,"text":"The Jews therefore said, \"See how much he loved him!\"\n"}
+		]}`)); err != nil {
+			t.Errorf("writing response: %v", err)
+		}
+	}))
+	defer srv.Close()
+	t.Setenv("BIBLE_API_URL", srv.URL)
+
+	opts := DefaultOptions()
+	opts.Translation = "web"
+	resp, err := FetchPassages(context.Background(), []string{"John 11:35–36"}, opts)
+	if err != nil {
+		t.Fatalf("FetchPassages() error = %v", err)
+	}
+	if want := []string{"/John 11:35-36?translation=web"}; !slices.Equal(queries, want) {
+		t.Errorf("queried %v, want %v", queries, want)
+	}
+	want := `<h2 class="extra_text">John 11:35-36</h2><p>` +
+		`<span class="verse" data-ref="43011035"><b class="verse-num">35</b>Jesus wept. </span>` +
+		`<span class="verse" data-ref="43011036"><b class="verse-num">36</b>The Jews therefore said, &#34;See how much he loved him!&#34; </span>` +
+		`</p><p>(<a href="https://ebible.org/web/" class="copyright">WEB</a>)</p>`
+	if resp.Passages[0] != want {
+		t.Errorf("passage = %s\nwant %s", resp.Passages[0], want)
+	}
+	if m := resp.PassageMeta[0]; m.ChapterStart[0] != 43011035 || m.ChapterEnd[0] != 43011036 {
+		t.Errorf("PassageMeta = %+v, want John 11:35-36", m)
+	}
+	if resp.WordCounts[0] != 12 {
+		t.Errorf("WordCounts = %v, want [12]", resp.WordCounts)
+	}
+	if !strings.Contains(resp.Copyright, "World English Bible") {
+		t.Errorf("Copyright = %q, want the World English Bible", resp.Copyright)
+	}
+
+	if _, err := FetchPassages(context.Background(), []string{"John 11:35"}, Options{Translation: "KJV"}); err == nil {
+		t.Error("FetchPassages() in an unknown translation succeeded")
+	}
+}
+
+func TestBibleAPIFetchPassagesConcurrently(t *testing.T) {
+	var mu sync.Mutex
+	var inFlight, maxInFlight int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		inFlight++
+		maxInFlight = max(maxInFlight, inFlight)
+		mu.Unlock()
+		defer func() {
+			mu.Lock()
+			inFlight--
+			mu.Unlock()
+		}()
+
+		// Psalm 1 answers last, so the passages come back out of order.
+		chapter := strings.TrimPrefix(r.URL.Path, "/Psalm ")
+		if chapter == "1" {
+			time.Sleep(50 * time.Millisecond)
+		}
+		time.Sleep(10 * time.Millisecond)
+		if _, err := w.Write([]byte(`{"reference":"Psalm ` + chapter + `:1","verses":[
+			{"book_name":"Psalms","chapter":` + chapter + `,"verse":1,"text":"Verse.\n"}
+		]}`)); err != nil {
+			t.Errorf("writing response: %v", err)
+		}
+	}))
+	defer srv.Close()
+	t.Setenv("BIBLE_API_URL", srv.URL)
+
+	var refs []string
+	for chapter := 1; chapter <= 8; chapter++ {
+		refs = append(refs, "Psalm "+strconv.Itoa(chapter))
+	}
+	resp, err := FetchPassages(context.Background(), refs, Options{Translation: "web"})
+	if err != nil {
+		t.Fatalf("FetchPassages() error = %v", err)
+	}
+	for i, m := range resp.PassageMeta {
+		if want := fmt.Sprintf("Psalm %d:1", i+1); m.Canonical != want {
+			t.Errorf("passage %d is %q, want %q", i, m.Canonical, want)
+		}
+	}
+	if maxInFlight < 2 || maxInFlight > maxConcurrentFetches {
+		t.Errorf("fetched %d passages at once, want between 2 and %d", maxInFlight, maxConcurrentFetches)
+	}
+}
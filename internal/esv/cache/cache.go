@@ -0,0 +1,155 @@
+// Package cache keeps passages fetched from the ESV API in the database, so that each passage is fetched
+// once however often it is read, within the limits the ESV API terms of use put on keeping passages.
+package cache
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"derrclan.com/moravian-soap/internal/esv"
+	"derrclan.com/moravian-soap/internal/store"
+)
+
+// ErrMiss is returned by Get when the passages are not cached, or were cached longer ago than the TTL.
+var ErrMiss = errors.New("passages not cached")
+
+// hits and misses count the passages looked up in any cache since the process started.
+var hits, misses atomic.Int64
+
+// Stats returns the number of passages found in and missing from the cache since the process started.
+func Stats() (hit, missed int64) {
+	return hits.Load(), misses.Load()
+}
+
+// Options limits what the cache keeps.
+type Options struct {
+	// TTL is how long cached passages are used before they must be fetched again.
+	TTL time.Duration
+	// MaxEntries is the most passages kept; saving more evicts the least recently used.
+	MaxEntries int
+}
+
+// Cache is a cache of ESV passages backed by the store's esv_cache table.
+type Cache struct {
+	store store.CacheStore
+	opts  Options
+}
+
+// New creates a cache of ESV passages in s, limited by opts.
+func New(s store.CacheStore, opts Options) *Cache {
+	return &Cache{store: s, opts: opts}
+}
+
+// Key returns the key under which verses rendered with opts are cached.
+func Key(references []string, opts esv.Options) string {
+	key := strings.Join(references, ";")
+	if suffix := opts.CacheKey(); suffix != "" {
+		key += "|" + suffix
+	}
+	return key
+}
+
+// ParseKey returns the references and options of the verses cached under key.
+func ParseKey(key string) ([]string, esv.Options, error) {
+	base, suffix, _ := strings.Cut(key, "|")
+	if base == "" {
+		return nil, esv.Options{}, fmt.Errorf("cache key %q has no references", key)
+	}
+	opts, err := esv.ParseCacheKey(suffix)
+	if err != nil {
+		return nil, esv.Options{}, fmt.Errorf("parsing cache key %q: %w", key, err)
+	}
+	return strings.Split(base, ";"), opts, nil
+}
+
+// Get returns the cached verses rendered with opts, or ErrMiss.
+func (c *Cache) Get(ctx context.Context, references []string, opts esv.Options) (esv.Response, error) {
+	var response esv.Response
+	key := Key(references, opts)
+	content, err := c.store.GetCachedESV(ctx, key, c.opts.TTL)
+	if errors.Is(err, sql.ErrNoRows) {
+		misses.Add(1)
+		return response, ErrMiss
+	}
+	if err != nil {
+		return response, err
+	}
+	hits.Add(1)
+	if err := json.Unmarshal([]byte(content), &response); err != nil {
+		return response, fmt.Errorf("unmarshaling cached passages (key=%s): %w", key, err)
+	}
+	return response, nil
+}
+
+// GetBatch returns the cached passages of each of keys that is in the cache, by key, in one query.
+// Entries that cannot be read are left out, to be fetched again.
+func (c *Cache) GetBatch(ctx context.Context, keys []string) (map[string]esv.Response, error) {
+	contents, err := c.store.GetCachedESVBatch(ctx, keys, c.opts.TTL)
+	if err != nil {
+		return nil, err
+	}
+	responses := make(map[string]esv.Response, len(contents))
+	for key, content := range contents {
+		var response esv.Response
+		if err := json.Unmarshal([]byte(content), &response); err != nil {
+			slog.Error("failed to unmarshal cached ESV response", "reference", key, "error", err)
+			continue
+		}
+		responses[key] = response
+	}
+	hits.Add(int64(len(responses)))
+	misses.Add(int64(len(keys) - len(responses)))
+	return responses, nil
+}
+
+// Sample returns up to n cached passages chosen at random, by key, without counting them as read.
+// Entries that cannot be read are left out.
+func (c *Cache) Sample(ctx context.Context, n int) (map[string]esv.Response, error) {
+	contents, err := c.store.SampleCachedESV(ctx, c.opts.TTL, n)
+	if err != nil {
+		return nil, err
+	}
+	responses := make(map[string]esv.Response, len(contents))
+	for key, content := range contents {
+		var response esv.Response
+		if err := json.Unmarshal([]byte(content), &response); err != nil {
+			slog.Error("failed to unmarshal cached ESV response", "reference", key, "error", err)
+			continue
+		}
+		responses[key] = response
+	}
+	return responses, nil
+}
+
+// Put caches response as the verses rendered with opts, then evicts the least recently used passages
+// beyond MaxEntries.
+func (c *Cache) Put(ctx context.Context, references []string, opts esv.Options, response esv.Response) error {
+	key := Key(references, opts)
+	content, err := json.Marshal(response)
+	if err != nil {
+		return fmt.Errorf("marshaling passages (key=%s): %w", key, err)
+	}
+	if err := c.store.SaveCachedESV(ctx, key, string(content)); err != nil {
+		return err
+	}
+	// Expired passages are left to the expunger; a zero time matches none of them.
+	return c.store.ExpungeCache(ctx, time.Time{}, c.opts.MaxEntries)
+}
+
+// Invalidate removes the cached verses rendered with opts, so that they are fetched again.
+func (c *Cache) Invalidate(ctx context.Context, references []string, opts esv.Options) error {
+	return c.store.DeleteCachedESV(ctx, []string{Key(references, opts)})
+}
+
+// InvalidateAll removes the cached verses of references however they were rendered, such as once no
+// daily text reads them any longer.
+func (c *Cache) InvalidateAll(ctx context.Context, references []string) error {
+	return c.store.DeleteCachedESVVariants(ctx, strings.Join(references, ";"))
+}
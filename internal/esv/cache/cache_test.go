@@ -0,0 +1,182 @@
+package cache_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+
+	"derrclan.com/moravian-soap/internal/esv"
+	"derrclan.com/moravian-soap/internal/esv/cache"
+	"derrclan.com/moravian-soap/internal/store/sqlite"
+)
+
+func setupTestDB(t *testing.T) *sql.DB {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("failed to open test db: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	query := `
+	CREATE TABLE esv_cache (
+		reference TEXT PRIMARY KEY,
+		content TEXT NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		size_bytes INTEGER NOT NULL DEFAULT 0,
+		last_accessed DATETIME
+	);`
+	if _, err := db.Exec(query); err != nil {
+		t.Fatalf("failed to create table: %v", err)
+	}
+	return db
+}
+
+func TestCache(t *testing.T) {
+	db := setupTestDB(t)
+	c := cache.New(sqlite.New(db), cache.Options{TTL: time.Hour, MaxEntries: 10})
+	ctx := context.Background()
+	refs := []string{"John 1:1"}
+	opts := esv.DefaultOptions()
+	response := esv.Response{
+		Query:      "John 1:1",
+		Passages:   []string{"<p>In the beginning was the Word.</p>", `<p dir="rtl">בְּרֵאשִׁית הָיָה הַדָּבָר</p>`},
+		Directions: []esv.Direction{esv.LeftToRight, esv.RightToLeft},
+	}
+
+	hits, misses := cache.Stats()
+	if _, err := c.Get(ctx, refs, opts); !errors.Is(err, cache.ErrMiss) {
+		t.Fatalf("Get before Put error = %v, want ErrMiss", err)
+	}
+	if err := c.Put(ctx, refs, opts, response); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	got, err := c.Get(ctx, refs, opts)
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if !reflect.DeepEqual(got, response) {
+		t.Errorf("Get = %+v, want %+v", got, response)
+	}
+
+	// The same verses rendered differently are cached separately.
+	plain := opts
+	plain.IncludeHeadings = false
+	if _, err := c.Get(ctx, refs, plain); !errors.Is(err, cache.ErrMiss) {
+		t.Errorf("Get with other options error = %v, want ErrMiss", err)
+	}
+
+	batch, err := c.GetBatch(ctx, []string{cache.Key(refs, opts), cache.Key(refs, plain)})
+	if err != nil {
+		t.Fatalf("GetBatch failed: %v", err)
+	}
+	if len(batch) != 1 || !reflect.DeepEqual(batch[cache.Key(refs, opts)], response) {
+		t.Errorf("GetBatch = %+v, want only the cached passage", batch)
+	}
+	if h, m := cache.Stats(); h-hits != 2 || m-misses != 3 {
+		t.Errorf("Stats counted %d hits, %d misses; want 2, 3", h-hits, m-misses)
+	}
+
+	if err := c.Invalidate(ctx, refs, opts); err != nil {
+		t.Fatalf("Invalidate failed: %v", err)
+	}
+	if _, err := c.Get(ctx, refs, opts); !errors.Is(err, cache.ErrMiss) {
+		t.Errorf("Get after Invalidate error = %v, want ErrMiss", err)
+	}
+}
+
+func TestCache_TTL(t *testing.T) {
+	db := setupTestDB(t)
+	c := cache.New(sqlite.New(db), cache.Options{TTL: time.Hour, MaxEntries: 10})
+	ctx := context.Background()
+
+	_, err := db.Exec(`INSERT INTO esv_cache (reference, content, created_at) VALUES ('John 1:1', '{}', datetime('now', '-2 hours'))`)
+	if err != nil {
+		t.Fatalf("failed to insert cache entry: %v", err)
+	}
+	if _, err := c.Get(ctx, []string{"John 1:1"}, esv.DefaultOptions()); !errors.Is(err, cache.ErrMiss) {
+		t.Errorf("Get of expired passage error = %v, want ErrMiss", err)
+	}
+	if batch, err := c.GetBatch(ctx, []string{"John 1:1"}); err != nil || len(batch) != 0 {
+		t.Errorf("GetBatch of expired passage = %v, %v; want empty, nil", batch, err)
+	}
+}
+
+func TestCache_Sample(t *testing.T) {
+	db := setupTestDB(t)
+	c := cache.New(sqlite.New(db), cache.Options{TTL: time.Hour, MaxEntries: 10})
+	ctx := context.Background()
+
+	refs := []string{"John 1:1", "Psalm 23"}
+	opts := esv.Options{IncludeVerseNumbers: true, Translation: "WEB"}
+	want := esv.Response{Query: "John 1:1; Psalm 23", Passages: []string{"<p>In the beginning</p>", "<p>The Lord</p>"}}
+	if err := c.Put(ctx, refs, opts, want); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+
+	sample, err := c.Sample(ctx, 5)
+	if err != nil {
+		t.Fatalf("Sample failed: %v", err)
+	}
+	if len(sample) != 1 {
+		t.Fatalf("Sample returned %d passages, want 1", len(sample))
+	}
+	for key, got := range sample {
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("sampled passages = %+v, want %+v", got, want)
+		}
+		gotRefs, gotOpts, err := cache.ParseKey(key)
+		if err != nil {
+			t.Fatalf("ParseKey(%q) failed: %v", key, err)
+		}
+		if !reflect.DeepEqual(gotRefs, refs) || gotOpts != opts {
+			t.Errorf("ParseKey(%q) = %v, %+v; want %v, %+v", key, gotRefs, gotOpts, refs, opts)
+		}
+	}
+}
+
+func TestParseKey(t *testing.T) {
+	refs, opts, err := cache.ParseKey("Genesis 1:1")
+	if err != nil || !reflect.DeepEqual(refs, []string{"Genesis 1:1"}) || opts != esv.DefaultOptions() {
+		t.Errorf(`ParseKey("Genesis 1:1") = %v, %+v, %v; want the default options`, refs, opts, err)
+	}
+	for _, key := range []string{"", "|1110", "John 1:1|11"} {
+		if _, _, err := cache.ParseKey(key); err == nil {
+			t.Errorf("ParseKey(%q) succeeded, want an error", key)
+		}
+	}
+}
+
+func TestCache_MaxEntries(t *testing.T) {
+	db := setupTestDB(t)
+	c := cache.New(sqlite.New(db), cache.Options{TTL: time.Hour, MaxEntries: 2})
+	ctx := context.Background()
+
+	// An entry read long ago is the first evicted.
+	_, err := db.Exec(`INSERT INTO esv_cache (reference, content, last_accessed) VALUES ('Genesis 1:1', '{}', datetime('now', '-2 hours'))`)
+	if err != nil {
+		t.Fatalf("failed to insert cache entry: %v", err)
+	}
+	for i := 1; i <= 2; i++ {
+		ref := fmt.Sprintf("John 1:%d", i)
+		if err := c.Put(ctx, []string{ref}, esv.DefaultOptions(), esv.Response{Query: ref}); err != nil {
+			t.Fatalf("Put(%s) failed: %v", ref, err)
+		}
+	}
+
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM esv_cache").Scan(&count); err != nil {
+		t.Fatalf("failed to count entries: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("cache has %d entries, want 2", count)
+	}
+	if _, err := c.Get(ctx, []string{"Genesis 1:1"}, esv.DefaultOptions()); !errors.Is(err, cache.ErrMiss) {
+		t.Errorf("least recently used entry was kept: %v", err)
+	}
+}
@@ -0,0 +1,137 @@
+package esv
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// Call is a request made to a translation's API, recorded for troubleshooting.
+type Call struct {
+	Translation string
+	References  []string
+	// URL is the address requested, with the API key redacted.
+	URL string
+	// Status is the HTTP status of the last attempt, or 0 if there was no response.
+	Status int
+	// Bytes is the size of the last response's body.
+	Bytes int
+	// Latency is the time from the start of the first attempt to the end of the last.
+	Latency time.Duration
+	// Retries counts the attempts after the first.
+	Retries int
+	// Err describes why the call failed, with the API key redacted, or is empty.
+	Err string
+}
+
+// maxRetries is the most times a request to a translation's API is retried after a transient failure,
+// a response of 429 Too Many Requests or 502, 503 or 504 from a gateway.
+const maxRetries = 2
+
+// retryDelay is the wait before the first retry, which doubles before each one after.
+var retryDelay = 250 * time.Millisecond
+
+type callObserverKey struct{}
+
+// WithCallObserver returns a context in which FetchPassages passes each call it makes to a translation's
+// API to observe once the call is over. observe may be called from several goroutines at once.
+func WithCallObserver(ctx context.Context, observe func(Call)) context.Context {
+	return context.WithValue(ctx, callObserverKey{}, observe)
+}
+
+// Redact replaces the ESV API key wherever it appears in s with "[REDACTED]".
+func Redact(s string) string {
+	key := os.Getenv("ESV_API_KEY")
+	if key == "" {
+		return s
+	}
+	return strings.ReplaceAll(s, key, "[REDACTED]")
+}
+
+// get requests apiURL with header for the references in translation, retrying transient failures, and
+// returns the status and body of the last response. Each attempt first passes g, if it is not nil; a
+// call that g stops before its first attempt is never made. Otherwise the call is passed to the
+// context's observer.
+func get(ctx context.Context, client *http.Client, g *guard, translation string, references []string, apiURL string, header http.Header) (int, []byte, error) {
+	if g != nil {
+		if err := g.acquire(ctx); err != nil {
+			return 0, nil, err
+		}
+	}
+	call := Call{Translation: translation, References: references, URL: Redact(apiURL)}
+	start := time.Now()
+	status, body, err := func() (int, []byte, error) {
+		delay := retryDelay
+		for {
+			status, body, err := getOnce(ctx, client, apiURL, header)
+			if g != nil {
+				g.record(ctx, status, err)
+			}
+			if err != nil || !transient(status) || call.Retries == maxRetries {
+				return status, body, err
+			}
+			select {
+			case <-ctx.Done():
+				return status, body, nil
+			case <-time.After(delay):
+			}
+			if g != nil {
+				if err := g.acquire(ctx); err != nil {
+					return status, body, err
+				}
+			}
+			call.Retries++
+			delay *= 2
+		}
+	}()
+
+	call.Status, call.Bytes, call.Latency = status, len(body), time.Since(start)
+	if err != nil {
+		call.Err = Redact(err.Error())
+	} else if status != http.StatusOK {
+		call.Err = http.StatusText(status)
+	}
+	if observe, ok := ctx.Value(callObserverKey{}).(func(Call)); ok {
+		observe(call)
+	}
+	return status, body, err
+}
+
+// getOnce requests apiURL with header and returns the status and body of the response.
+func getOnce(ctx context.Context, client *http.Client, apiURL string, header http.Header) (int, []byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	if header != nil {
+		req.Header = header.Clone()
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to fetch verse: %w", err)
+	}
+	defer func() {
+		if cerr := resp.Body.Close(); cerr != nil {
+			slog.Error("failed to close response body", "error", cerr)
+		}
+	}()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return resp.StatusCode, body, fmt.Errorf("failed to read response: %w", err)
+	}
+	return resp.StatusCode, body, nil
+}
+
+// transient reports whether a response with status is worth retrying.
+func transient(status int) bool {
+	switch status {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,59 @@
+package esv
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFetchPassages_ObservesCalls(t *testing.T) {
+	const body = `{"passages": ["<p><b class=\"verse-num\" id=\"v43011035-1\">35</b>Jesus wept.</p>"]}`
+	attempts := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts == 1 {
+			http.Error(w, "busy", http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(body))
+	}))
+	defer srv.Close()
+	// The key is put in the URL to check that it is redacted wherever it appears.
+	t.Setenv("ESV_API_KEY", "secret-key")
+	t.Setenv("ESV_API_URL", srv.URL+"/secret-key/")
+	retryDelay = time.Millisecond
+	t.Cleanup(func() { retryDelay = 250 * time.Millisecond })
+
+	var calls []Call
+	ctx := WithCallObserver(context.Background(), func(c Call) { calls = append(calls, c) })
+	if _, err := FetchPassages(ctx, []string{"John 11:35"}, DefaultOptions()); err != nil {
+		t.Fatalf("FetchPassages() error = %v", err)
+	}
+	if len(calls) != 1 {
+		t.Fatalf("observed %d calls, want 1", len(calls))
+	}
+	c := calls[0]
+	if c.Translation != "ESV" || !slices.Equal(c.References, []string{"John 11:35"}) || c.Status != http.StatusOK ||
+		c.Retries != 1 || c.Bytes != len(body) || c.Err != "" || c.Latency <= 0 {
+		t.Errorf("call = %+v, want one successful retry", c)
+	}
+	if strings.Contains(c.URL, "secret-key") || !strings.Contains(c.URL, "[REDACTED]") {
+		t.Errorf("call URL = %q, want the API key redacted", c.URL)
+	}
+
+	// Errors other than transient ones are not retried.
+	calls = nil
+	srv.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "forbidden", http.StatusForbidden)
+	})
+	if _, err := FetchPassages(ctx, []string{"John 11:35"}, DefaultOptions()); err == nil {
+		t.Fatal("FetchPassages() of a forbidden passage succeeded")
+	}
+	if len(calls) != 1 || calls[0].Retries != 0 || calls[0].Status != http.StatusForbidden || calls[0].Err != "Forbidden" {
+		t.Errorf("calls = %+v, want one failed call without retries", calls)
+	}
+}
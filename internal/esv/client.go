@@ -1,4 +1,5 @@
-// Package esv provides a client for the ESV API.
+// Package esv provides a client for the ESV API, and for the other Bible translations that passages
+// can be read in.
 package esv
 
 import (
@@ -9,6 +10,7 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -30,61 +32,209 @@ type Response struct {
 	PassageMeta []PassageMeta `json:"passage_meta"`
 	Passages    []string      `json:"passages"`
 	Copyright   string        `json:"copyright"`
+	// WordCounts holds the number of words of scripture in each passage. It is counted once when the
+	// passages are fetched, so it is stored alongside them in the cache.
+	WordCounts []int `json:"word_counts,omitempty"`
+	// Directions holds the direction each passage is written in, so that passages in right-to-left
+	// scripts are laid out as such wherever they are shown.
+	Directions []Direction `json:"directions,omitempty"`
 }
 
-// FetchPassages fetches verses from the ESV API.
-func FetchPassages(ctx context.Context, references []string) (Response, error) {
+// Split splits a response to the sets of references joined in one query into a response for each set.
+// Passages are matched to references by position, with the passage metadata as the ESV API returns it,
+// so it reports an error unless the response has a passage for each reference.
+func (r Response) Split(sets [][]string) ([]Response, error) {
+	n := 0
+	for _, set := range sets {
+		n += len(set)
+	}
+	if len(r.Passages) != n || len(r.PassageMeta) != n {
+		return nil, fmt.Errorf("got %d passages and %d passage meta for %d references", len(r.Passages), len(r.PassageMeta), n)
+	}
+	split := make([]Response, len(sets))
+	start := 0
+	for i, set := range sets {
+		end := start + len(set)
+		split[i] = Response{
+			Query:       strings.Join(set, ";"),
+			PassageMeta: r.PassageMeta[start:end:end],
+			Passages:    r.Passages[start:end:end],
+			Copyright:   r.Copyright,
+		}
+		if len(r.WordCounts) == n {
+			split[i].WordCounts = r.WordCounts[start:end:end]
+		}
+		if len(r.Directions) == n {
+			split[i].Directions = r.Directions[start:end:end]
+		}
+		start = end
+	}
+	return split, nil
+}
+
+// Options controls how the ESV API renders passages, and which translation they are fetched in.
+type Options struct {
+	IncludeHeadings       bool
+	IncludeVerseNumbers   bool
+	IncludeShortCopyright bool
+	IndentPoetry          bool
+	// RedLetter shows the words of Christ in red, where the ESV API marks them.
+	RedLetter bool
+	// Translation is the name of the translation to fetch passages in, or "" for the ESV.
+	Translation string
+	// VerifyTransform checks each transformed ESV passage against the raw passage, which is used
+	// instead if any text was lost, so the output can differ with it. It is left out of the CacheKey
+	// because both produce equivalent cached text: the same passage, with or without selectable verses.
+	VerifyTransform bool
+}
+
+// DefaultOptions returns the rendering options the ESV API uses when none are given.
+func DefaultOptions() Options {
+	return Options{
+		IncludeHeadings:       true,
+		IncludeVerseNumbers:   true,
+		IncludeShortCopyright: true,
+		IndentPoetry:          true,
+	}
+}
+
+// CacheKey returns a short string identifying the options, for use in cache keys.
+// The default options yield an empty string.
+func (o Options) CacheKey() string {
+	// Other translations are marked by a suffix so that keys cached before they existed stay valid.
+	var suffix string
+	if t := strings.ToUpper(o.Translation); t != "" && t != DefaultTranslation {
+		suffix = "@" + t
+	}
+	o.Translation = ""
+	o.VerifyTransform = false
+	if o == DefaultOptions() {
+		return suffix
+	}
+	flag := func(b bool) byte {
+		if b {
+			return '1'
+		}
+		return '0'
+	}
+	key := []byte{flag(o.IncludeHeadings), flag(o.IncludeVerseNumbers), flag(o.IncludeShortCopyright), flag(o.IndentPoetry)}
+	// Red letter is marked by a suffix so that keys cached before the option existed stay valid.
+	if o.RedLetter {
+		key = append(key, 'r')
+	}
+	return string(key) + suffix
+}
+
+// ParseCacheKey returns the options whose CacheKey is key.
+func ParseCacheKey(key string) (Options, error) {
+	opts := DefaultOptions()
+	flags, translation, _ := strings.Cut(key, "@")
+	opts.Translation = translation
+	if flags == "" {
+		return opts, nil
+	}
+	flags, opts.RedLetter = strings.CutSuffix(flags, "r")
+	if len(flags) != 4 || strings.Trim(flags, "01") != "" {
+		return Options{}, fmt.Errorf("invalid options key %q", key)
+	}
+	opts.IncludeHeadings = flags[0] == '1'
+	opts.IncludeVerseNumbers = flags[1] == '1'
+	opts.IncludeShortCopyright = flags[2] == '1'
+	opts.IndentPoetry = flags[3] == '1'
+	return opts, nil
+}
+
+// DefaultAPIURL is the ESV passage HTML endpoint. It is overridden by the ESV_API_URL environment
+// variable, e.g. to point tests at a fake server.
+const DefaultAPIURL = "https://api.esv.org/v3/passage/html/"
+
+// esvTranslation fetches passages from the ESV API.
+type esvTranslation struct{}
+
+// Name returns "ESV".
+func (esvTranslation) Name() string { return DefaultTranslation }
+
+// Copyright returns the notice the ESV must be quoted with.
+func (esvTranslation) Copyright() string {
+	return "Scripture quotations are from the ESV® Bible (The Holy Bible, English Standard Version®), copyright © 2001 by Crossway, a publishing ministry of Good News Publishers. Used by permission. All rights reserved."
+}
+
+// FetchPassages fetches verses from the ESV API, rendered according to opts.
+func (esvTranslation) FetchPassages(ctx context.Context, references []string, opts Options) (Response, error) {
 	// See https://api.esv.org/docs/passage-html/ for API documentation.
-	apiURL := "https://api.esv.org/v3/passage/html/"
+	apiURL := DefaultAPIURL
+	if u := os.Getenv("ESV_API_URL"); u != "" {
+		apiURL = u
+	}
 	params := url.Values{}
 	params.Add("q", strings.Join(references, ";"))
 	params.Add("include-audio-link", "false")
 	params.Add("include-footnotes", "false")
 	params.Add("include-first-verse-numbers", "false")
+	params.Add("include-headings", strconv.FormatBool(opts.IncludeHeadings))
+	params.Add("include-short-copyright", strconv.FormatBool(opts.IncludeShortCopyright))
+	params.Add("indent-poetry", strconv.FormatBool(opts.IndentPoetry))
+	// Verse numbers are always requested because the transform relies on their ids to
+	// find verse boundaries; they are removed afterwards when not wanted.
 	apiURL += "?" + params.Encode()
 
 	var apiResp Response
 
-	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
-	if err != nil {
-		return apiResp, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.Header.Set("Authorization", fmt.Sprintf("Token %s", os.Getenv("ESV_API_KEY")))
-
 	client := &http.Client{
 		Timeout: 10 * time.Second,
 	}
-	slog.Debug("fetching verses", "references", references, "apiURL", apiURL)
-	resp, err := client.Do(req)
+	slog.Debug("fetching verses", "references", references, "apiURL", Redact(apiURL))
+	header := http.Header{"Authorization": {fmt.Sprintf("Token %s", os.Getenv("ESV_API_KEY"))}}
+	status, body, err := get(ctx, client, guardFor(apiURL), DefaultTranslation, references, apiURL, header)
 	if err != nil {
-		return apiResp, fmt.Errorf("failed to fetch verse: %w", err)
+		return apiResp, err
 	}
-	defer func() {
-		if cerr := resp.Body.Close(); cerr != nil {
-			slog.Error("failed to close response body", "error", cerr)
-		}
-	}()
-
-	if resp.StatusCode != http.StatusOK {
-		return apiResp, fmt.Errorf("ESV API returned status %d", resp.StatusCode)
+	if status != http.StatusOK {
+		return apiResp, fmt.Errorf("ESV API returned status %d", status)
 	}
 
 	// Decode the JSON response
-	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
+	if err := json.Unmarshal(body, &apiResp); err != nil {
 		return apiResp, fmt.Errorf("failed to decode response: %w", err)
 	}
 
 	// Post-process the HTML to wrap verses in selectable spans
+	apiResp.WordCounts = make([]int, len(apiResp.Passages))
+	apiResp.Directions = make([]Direction, len(apiResp.Passages))
 	for i, p := range apiResp.Passages {
-		processed, err := processPassageHTML(p)
-		if err != nil {
-			// Getting partial functionality (original HTML) is better than breaking everything.
-			slog.Error("error processing passage HTML", "error", err)
-			continue
+		apiResp.Passages[i] = transformPassage(p, opts.VerifyTransform)
+		if !opts.IncludeVerseNumbers {
+			apiResp.Passages[i] = stripVerseNumbers(apiResp.Passages[i])
+		}
+		if red, err := markWordsOfChrist(apiResp.Passages[i], opts.RedLetter); err != nil {
+			slog.Warn("failed to mark words of Christ", "references", references, "error", err)
+		} else {
+			apiResp.Passages[i] = red
 		}
-		apiResp.Passages[i] = processed
+		apiResp.WordCounts[i] = WordCount(apiResp.Passages[i])
+		apiResp.Directions[i] = PassageDirection(apiResp.Passages[i])
 	}
 
 	return apiResp, nil
 }
+
+// transformPassage wraps the verses of a raw passage in selectable spans.
+// When verify is true the transformed text is compared against the raw text and the raw
+// passage is returned if any characters were lost or reordered along the way.
+func transformPassage(raw string, verify bool) string {
+	processed, err := processPassageHTML(raw)
+	if err != nil {
+		// Getting partial functionality (original HTML) is better than breaking everything.
+		slog.Error("error processing passage HTML", "error", err)
+		return raw
+	}
+
+	if verify {
+		if err := verifyTextContent(raw, processed); err != nil {
+			slog.Error("transformed passage failed verification, using raw passage", "error", err)
+			return raw
+		}
+	}
+
+	return processed
+}
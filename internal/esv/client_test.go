@@ -0,0 +1,110 @@
+package esv
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+func TestOptionsCacheKey(t *testing.T) {
+	if got := DefaultOptions().CacheKey(); got != "" {
+		t.Errorf("DefaultOptions().CacheKey() = %q, want empty", got)
+	}
+	opts := DefaultOptions()
+	opts.IncludeHeadings = false
+	opts.IncludeShortCopyright = false
+	if got, want := opts.CacheKey(), "0101"; got != want {
+		t.Errorf("CacheKey() = %q, want %q", got, want)
+	}
+	opts.RedLetter = true
+	if got, want := opts.CacheKey(), "0101r"; got != want {
+		t.Errorf("CacheKey() = %q, want %q", got, want)
+	}
+	if got, want := (Options{RedLetter: true, IncludeHeadings: true, IncludeVerseNumbers: true, IncludeShortCopyright: true, IndentPoetry: true}).CacheKey(), "1111r"; got != want {
+		t.Errorf("CacheKey() = %q, want %q", got, want)
+	}
+	if got := (Options{IncludeHeadings: true, IncludeVerseNumbers: true, IncludeShortCopyright: true, IndentPoetry: true, Translation: "ESV"}).CacheKey(); got != "" {
+		t.Errorf("CacheKey() for the ESV by name = %q, want empty", got)
+	}
+	opts.Translation = "web"
+	if got, want := opts.CacheKey(), "0101r@WEB"; got != want {
+		t.Errorf("CacheKey() = %q, want %q", got, want)
+	}
+}
+
+func TestParseCacheKey(t *testing.T) {
+	for _, opts := range []Options{
+		DefaultOptions(),
+		{},
+		{IncludeVerseNumbers: true, RedLetter: true},
+		{IncludeHeadings: true, Translation: "WEB"},
+		{IncludeHeadings: true, IncludeVerseNumbers: true, IncludeShortCopyright: true, IndentPoetry: true, Translation: "WEB"},
+	} {
+		got, err := ParseCacheKey(opts.CacheKey())
+		if err != nil {
+			t.Errorf("ParseCacheKey(%q) failed: %v", opts.CacheKey(), err)
+		} else if got != opts {
+			t.Errorf("ParseCacheKey(%q) = %+v, want %+v", opts.CacheKey(), got, opts)
+		}
+	}
+	for _, key := range []string{"01", "0120", "1111rr", "x@WEB"} {
+		if _, err := ParseCacheKey(key); err == nil {
+			t.Errorf("ParseCacheKey(%q) succeeded, want an error", key)
+		}
+	}
+}
+
+func TestResponseSplit(t *testing.T) {
+	r := Response{
+		Query:       "Psalm 34:1-7;Romans 8:1-2;John 11:35",
+		PassageMeta: []PassageMeta{{Canonical: "Psalm 34:1-7"}, {Canonical: "Romans 8:1-2"}, {Canonical: "John 11:35"}},
+		Passages:    []string{"<p>Psalm</p>", "<p>Romans</p>", "<p>John</p>"},
+		Copyright:   "ESV",
+		WordCounts:  []int{120, 35, 2},
+	}
+	split, err := r.Split([][]string{{"Psalm 34:1-7", "Romans 8:1-2"}, {"John 11:35"}})
+	if err != nil {
+		t.Fatalf("Split() error = %v", err)
+	}
+	if len(split) != 2 {
+		t.Fatalf("Split() returned %d responses, want 2", len(split))
+	}
+	if got := split[0]; got.Query != "Psalm 34:1-7;Romans 8:1-2" || !slices.Equal(got.Passages, []string{"<p>Psalm</p>", "<p>Romans</p>"}) ||
+		!slices.Equal(got.WordCounts, []int{120, 35}) || got.Copyright != "ESV" {
+		t.Errorf("split[0] = %+v", got)
+	}
+	if got := split[1]; got.Query != "John 11:35" || len(got.PassageMeta) != 1 || got.PassageMeta[0].Canonical != "John 11:35" ||
+		!slices.Equal(got.Passages, []string{"<p>John</p>"}) || got.Directions != nil {
+		t.Errorf("split[1] = %+v", got)
+	}
+
+	// A reference the ESV API found nothing for leaves the passages out of step with the references.
+	if _, err := r.Split([][]string{{"Psalm 34:1-7"}, {"Nowhere 1:1", "John 11:35"}, {"Romans 8:1-2"}}); err == nil {
+		t.Error("Split() of more references than passages succeeded")
+	}
+}
+
+func TestFetchPassages_WordCounts(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		resp := Response{Passages: []string{
+			`<h2 class="extra_text">John 11:35</h2><p><b class="verse-num" id="v43011035-1">35</b>Jesus wept.</p>`,
+			`<p><b class="verse-num" id="v01001001-1">1</b>In the beginning, God created the heavens and the earth.</p>`,
+		}}
+		if err := json.NewEncoder(w).Encode(resp); err != nil {
+			t.Errorf("encoding response: %v", err)
+		}
+	}))
+	defer srv.Close()
+	t.Setenv("ESV_API_URL", srv.URL)
+
+	resp, err := FetchPassages(context.Background(), []string{"John 11:35", "Genesis 1:1"}, DefaultOptions())
+	if err != nil {
+		t.Fatalf("FetchPassages() error = %v", err)
+	}
+	if want := []int{2, 10}; !slices.Equal(resp.WordCounts, want) {
+		t.Errorf("WordCounts = %v, want %v", resp.WordCounts, want)
+	}
+}
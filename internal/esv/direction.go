@@ -0,0 +1,63 @@
+package esv
+
+import (
+	"unicode"
+
+	"golang.org/x/net/html"
+)
+
+// Direction is the direction a passage is written in, as given to the HTML dir attribute.
+type Direction string
+
+const (
+	LeftToRight Direction = "ltr"
+	RightToLeft Direction = "rtl"
+)
+
+// rtlScripts are the scripts written right to left that Bible translations and original-language texts
+// come in.
+var rtlScripts = []*unicode.RangeTable{
+	unicode.Arabic, unicode.Hebrew, unicode.Mandaic, unicode.Nko, unicode.Samaritan, unicode.Syriac, unicode.Thaana,
+}
+
+// TextDirection returns the direction of text, taken from its first letter as browsers do for
+// dir="auto". Text without letters, such as verse numbers alone, is left to right.
+func TextDirection(text string) Direction {
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		if unicode.IsOneOf(rtlScripts, r) {
+			return RightToLeft
+		}
+		return LeftToRight
+	}
+	return LeftToRight
+}
+
+// nodeDirection returns the direction of the text within n, from its first letter.
+func nodeDirection(n *html.Node) Direction {
+	if n.Type == html.TextNode {
+		for _, r := range n.Data {
+			if unicode.IsLetter(r) {
+				return TextDirection(string(r))
+			}
+		}
+		return ""
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if d := nodeDirection(c); d != "" {
+			return d
+		}
+	}
+	return ""
+}
+
+// Direction returns the direction of the i-th passage. Passages cached before their direction was
+// recorded are left to right, as the ESV is.
+func (r Response) Direction(i int) Direction {
+	if len(r.Directions) == len(r.Passages) && i < len(r.Directions) {
+		return r.Directions[i]
+	}
+	return LeftToRight
+}
@@ -0,0 +1,50 @@
+package esv
+
+import "testing"
+
+func TestTextDirection(t *testing.T) {
+	tests := []struct {
+		text string
+		want Direction
+	}{
+		{"In the beginning", LeftToRight},
+		{"Ἐν ἀρχῇ ἦν ὁ λόγος", LeftToRight},
+		{"בְּרֵאשִׁית בָּרָא", RightToLeft},
+		{"1 فِي الْبَدْءِ", RightToLeft},
+		{"23 “The Lord is my shepherd”", LeftToRight},
+		{"1–2", LeftToRight},
+		{"", LeftToRight},
+	}
+	for _, tt := range tests {
+		if got := TextDirection(tt.text); got != tt.want {
+			t.Errorf("TextDirection(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestResponse_Direction(t *testing.T) {
+	r := Response{Passages: []string{"a", "b"}, Directions: []Direction{LeftToRight, RightToLeft}}
+	if got := r.Direction(1); got != RightToLeft {
+		t.Errorf("Direction(1) = %q, want rtl", got)
+	}
+	// Passages cached without directions are the ESV's.
+	r.Directions = nil
+	if got := r.Direction(1); got != LeftToRight {
+		t.Errorf("Direction(1) without directions = %q, want ltr", got)
+	}
+}
+
+func TestUnescapeString(t *testing.T) {
+	tests := map[string]string{
+		`\u201cLight\u201d`:     "“Light”",
+		`\u05d0\u05de\u05df`:    "אמן",
+		`\ud83d\udd4a peace`:    "🕊 peace",
+		`lone \ud83d surrogate`: "lone \uFFFD surrogate",
+		`not \u12 an escape`:    `not \u12 an escape`,
+	}
+	for in, want := range tests {
+		if got := unescapeString(in); got != want {
+			t.Errorf("unescapeString(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package esv
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrUnavailable is returned, wrapped, when a request to the ESV API is not made because it would exceed
+// the API's query limits or because the API has been failing, so that callers can fall back to cached
+// passages or plain references.
+var ErrUnavailable = errors.New("ESV API unavailable")
+
+// limit is the most queries allowed in a period.
+type limit struct {
+	queries int
+	period  time.Duration
+}
+
+// esvLimits are the ESV API's limits on queries, shortest period first: 60 a minute, 1,000 an hour and
+// 5,000 a day.
+var esvLimits = []limit{{60, time.Minute}, {1000, time.Hour}, {5000, 24 * time.Hour}}
+
+// maxQueueWait is the longest a request waits for the limits to allow it before it is rejected.
+var maxQueueWait = 5 * time.Second
+
+// breakerThreshold is the number of failures in a row, responses of 5xx or requests that time out or
+// fail to connect, after which the circuit breaker opens. It stays open for breakerCooldown, then lets
+// a single request through to try the API again, and closes once one succeeds.
+const breakerThreshold = 5
+
+var breakerCooldown = 30 * time.Second
+
+// limiter keeps queries within limits, counting them in sliding windows.
+type limiter struct {
+	mu     sync.Mutex
+	limits []limit
+	// sent holds the times of the queries within the longest period, oldest first.
+	sent []time.Time
+}
+
+// reserve records a query at now if the limits allow one, and otherwise returns how long until they do.
+func (l *limiter) reserve(now time.Time) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	longest := l.limits[len(l.limits)-1].period
+	drop := sort.Search(len(l.sent), func(i int) bool { return now.Sub(l.sent[i]) < longest })
+	l.sent = l.sent[drop:]
+
+	var wait time.Duration
+	for _, lim := range l.limits {
+		in := len(l.sent) - sort.Search(len(l.sent), func(i int) bool { return now.Sub(l.sent[i]) < lim.period })
+		if in >= lim.queries {
+			// A query is allowed once the oldest of the last lim.queries leaves the window.
+			wait = max(wait, l.sent[len(l.sent)-lim.queries].Add(lim.period).Sub(now))
+		}
+	}
+	if wait == 0 {
+		l.sent = append(l.sent, now)
+	}
+	return wait
+}
+
+// wait waits until the limits allow a query and records it. It returns an error wrapping ErrUnavailable
+// if that would take longer than maxQueueWait.
+func (l *limiter) wait(ctx context.Context) error {
+	deadline := time.Now().Add(maxQueueWait)
+	for {
+		now := time.Now()
+		wait := l.reserve(now)
+		if wait == 0 {
+			return nil
+		}
+		if now.Add(wait).After(deadline) {
+			return fmt.Errorf("%w: query limit reached, next query allowed in %s", ErrUnavailable, wait.Round(time.Second))
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+}
+
+// breaker stops requests to an API that keeps failing.
+type breaker struct {
+	mu       sync.Mutex
+	failures int
+	// openedAt is when the breaker last opened.
+	openedAt time.Time
+	// trying is set while the single request let through after the cooldown is in flight.
+	trying bool
+}
+
+// allow reports an error wrapping ErrUnavailable if the breaker is open at now.
+func (b *breaker) allow(now time.Time) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.failures < breakerThreshold {
+		return nil
+	}
+	if b.trying || now.Sub(b.openedAt) < breakerCooldown {
+		return fmt.Errorf("%w: %d failed requests in a row, trying again after %s", ErrUnavailable, b.failures, b.openedAt.Add(breakerCooldown).Format(time.TimeOnly))
+	}
+	b.trying = true
+	return nil
+}
+
+// record records the outcome at now of a request that allow let through.
+func (b *breaker) record(now time.Time, failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.trying = false
+	if !failed {
+		b.failures = 0
+		return
+	}
+	b.failures++
+	if b.failures >= breakerThreshold {
+		b.openedAt = now
+	}
+}
+
+// guard rate-limits the requests to an API and breaks the circuit to it while it is failing.
+type guard struct {
+	limiter limiter
+	breaker breaker
+}
+
+var (
+	guardsMu sync.Mutex
+	// guards holds the guard of each ESV API endpoint, by its URL without the query.
+	guards = map[string]*guard{}
+)
+
+// guardFor returns the guard of the ESV API endpoint at apiURL.
+func guardFor(apiURL string) *guard {
+	endpoint, _, _ := strings.Cut(apiURL, "?")
+	guardsMu.Lock()
+	defer guardsMu.Unlock()
+	g, ok := guards[endpoint]
+	if !ok {
+		g = &guard{limiter: limiter{limits: esvLimits}}
+		guards[endpoint] = g
+	}
+	return g
+}
+
+// acquire waits until a request may be made, or returns an error wrapping ErrUnavailable if the breaker
+// is open or the limits would keep the request waiting too long.
+func (g *guard) acquire(ctx context.Context) error {
+	if err := g.breaker.allow(time.Now()); err != nil {
+		return err
+	}
+	if err := g.limiter.wait(ctx); err != nil {
+		// Release the trial request, if this was one, since it was never made.
+		g.breaker.mu.Lock()
+		g.breaker.trying = false
+		g.breaker.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// record records the outcome of a request that acquire allowed. Requests cut short by ctx are not held
+// against the API.
+func (g *guard) record(ctx context.Context, status int, err error) {
+	failed := status >= http.StatusInternalServerError || (err != nil && ctx.Err() == nil)
+	g.breaker.record(time.Now(), failed)
+}
@@ -0,0 +1,117 @@
+package esv
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestLimiterReserve(t *testing.T) {
+	l := limiter{limits: []limit{{2, time.Minute}, {3, time.Hour}}}
+	start := time.Date(2026, time.March, 7, 8, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) time.Time { return start.Add(d) }
+
+	for _, d := range []time.Duration{0, time.Second} {
+		if wait := l.reserve(at(d)); wait != 0 {
+			t.Fatalf("reserve at %s = %s, want the query allowed", d, wait)
+		}
+	}
+	if wait := l.reserve(at(2 * time.Second)); wait != 58*time.Second {
+		t.Errorf("third query in a minute waits %s, want 58s", wait)
+	}
+	if wait := l.reserve(at(61 * time.Second)); wait != 0 {
+		t.Errorf("query after the minute waits %s, want none", wait)
+	}
+	if wait := l.reserve(at(2 * time.Minute)); wait != 58*time.Minute {
+		t.Errorf("fourth query in an hour waits %s, want 58m", wait)
+	}
+	if wait := l.reserve(at(time.Hour)); wait != 0 {
+		t.Errorf("query an hour later waits %s, want none", wait)
+	}
+}
+
+// fetchFrom fetches a passage from the ESV API, a fake counting its requests in hits, and reports
+// whether the request reached it.
+func fetchFrom(t *testing.T, hits *int) (bool, error) {
+	t.Helper()
+	before := *hits
+	_, err := FetchPassages(context.Background(), []string{"John 11:35"}, DefaultOptions())
+	return *hits > before, err
+}
+
+func TestFetchPassages_CircuitBreaker(t *testing.T) {
+	status := http.StatusInternalServerError
+	hits := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		if status != http.StatusOK {
+			http.Error(w, "broken", status)
+			return
+		}
+		_, _ = w.Write([]byte(`{"passages": ["<p>Jesus wept.</p>"]}`))
+	}))
+	defer srv.Close()
+	t.Setenv("ESV_API_URL", srv.URL)
+	breakerCooldown = 50 * time.Millisecond
+	t.Cleanup(func() { breakerCooldown = 30 * time.Second })
+
+	for i := range breakerThreshold {
+		if reached, err := fetchFrom(t, &hits); !reached || err == nil || errors.Is(err, ErrUnavailable) {
+			t.Fatalf("failing request %d: reached = %v, err = %v; want it made and failed", i+1, reached, err)
+		}
+	}
+	if reached, err := fetchFrom(t, &hits); reached || !errors.Is(err, ErrUnavailable) {
+		t.Fatalf("request with the breaker open: reached = %v, err = %v; want ErrUnavailable without a request", reached, err)
+	}
+
+	// After the cooldown, one request tries the API again, and its failure opens the breaker again.
+	time.Sleep(breakerCooldown)
+	if reached, err := fetchFrom(t, &hits); !reached || err == nil || errors.Is(err, ErrUnavailable) {
+		t.Fatalf("trial request: reached = %v, err = %v; want it made and failed", reached, err)
+	}
+	if reached, err := fetchFrom(t, &hits); reached || !errors.Is(err, ErrUnavailable) {
+		t.Fatalf("request after a failed trial: reached = %v, err = %v; want ErrUnavailable", reached, err)
+	}
+
+	// A successful trial closes the breaker.
+	status = http.StatusOK
+	time.Sleep(breakerCooldown)
+	for i := range 2 {
+		if reached, err := fetchFrom(t, &hits); !reached || err != nil {
+			t.Fatalf("request %d once the API recovered: reached = %v, err = %v", i+1, reached, err)
+		}
+	}
+}
+
+func TestFetchPassages_RateLimited(t *testing.T) {
+	hits := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		_, _ = w.Write([]byte(`{"passages": ["<p>Jesus wept.</p>"]}`))
+	}))
+	defer srv.Close()
+	t.Setenv("ESV_API_URL", srv.URL)
+	esvLimits = []limit{{1, 50 * time.Millisecond}, {2, time.Hour}}
+	maxQueueWait = time.Second
+	t.Cleanup(func() {
+		esvLimits = []limit{{60, time.Minute}, {1000, time.Hour}, {5000, 24 * time.Hour}}
+		maxQueueWait = 5 * time.Second
+	})
+
+	// The second query waits for the first to leave the short window, and the third exceeds the long one.
+	start := time.Now()
+	for i := range 2 {
+		if reached, err := fetchFrom(t, &hits); !reached || err != nil {
+			t.Fatalf("query %d: reached = %v, err = %v", i+1, reached, err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("two queries took %s, want the second queued", elapsed)
+	}
+	if reached, err := fetchFrom(t, &hits); reached || !errors.Is(err, ErrUnavailable) {
+		t.Errorf("query over the limit: reached = %v, err = %v; want ErrUnavailable without a request", reached, err)
+	}
+}
@@ -2,6 +2,7 @@ package esv
 
 import (
 	"fmt"
+	"regexp"
 	"sort"
 	"strconv"
 	"strings"
@@ -23,6 +24,138 @@ var bookNames = map[int]string{
 	61: "2 Peter", 62: "1 John", 63: "2 John", 64: "3 John", 65: "Jude", 66: "Revelation",
 }
 
+// bookNumbers maps book names, as written in references, to their numbers.
+var bookNumbers = func() map[string]int {
+	numbers := map[string]int{"Psalms": 19, "Song of Songs": 22}
+	for n, name := range bookNames {
+		numbers[name] = n
+	}
+	return numbers
+}()
+
+// singleChapterBooks are the books with only one chapter, whose references number only verses, as in
+// "Philemon 1-21", or nothing at all, as in "2 John".
+var singleChapterBooks = map[int]bool{31: true, 57: true, 63: true, 64: true, 65: true}
+
+// referenceTokenRe matches the next token of a reference: a book name, a chapter or verse number
+// (possibly with a part letter, as in 15a), or a separator. Anything else is skipped.
+var referenceTokenRe = regexp.MustCompile(`^(?:((?:[1-3] )?[A-Z][a-z]+(?: of [A-Z][a-z]+)?)|(\d+)[a-z]?|([:,;-]))`)
+
+// ParseChapters returns the chapters covered by references as written in the daily texts, such as
+// "Genesis 1:1–2:3", "Genesis 3,4" or "John 1:(1-9),10-18", in order and without duplicates. Like
+// Chapters, a passage spanning two books only contributes its first and last chapter. Text that is not
+// a reference, such as a watchword before its reference, is ignored.
+func ParseChapters(ref string) []Chapter {
+	ref = strings.NewReplacer("–", "-", "—", "-", "(", "", ")", "").Replace(ref)
+
+	var chapters []Chapter
+	seen := make(map[Chapter]bool)
+	add := func(c Chapter) {
+		if !seen[c] {
+			seen[c] = true
+			chapters = append(chapters, c)
+		}
+	}
+
+	book, chapter := 0, 0
+	inVerses := false // whether numbers are verses of the chapter rather than chapters
+	sep := ""         // the separator before the current number
+	for ref != "" {
+		m := referenceTokenRe.FindStringSubmatch(ref)
+		if m == nil {
+			ref = ref[1:]
+			continue
+		}
+		ref = ref[len(m[0]):]
+		switch {
+		case m[1] != "":
+			book, sep = bookNumbers[m[1]], "book"
+			if singleChapterBooks[book] {
+				chapter, inVerses = 1, true
+				add(Chapter{Book: book, Chapter: 1})
+			}
+		case m[3] != "":
+			sep = m[3]
+		case book != 0:
+			n, _ := strconv.Atoi(m[2])
+			// A number followed by a colon is always a chapter.
+			nextIsVerse := strings.HasPrefix(strings.TrimSpace(ref), ":")
+			switch {
+			case singleChapterBooks[book]:
+			case sep == "book" || sep == ";" || (sep == "," && (!inVerses || nextIsVerse)):
+				chapter, inVerses = n, false
+				add(Chapter{Book: book, Chapter: n})
+			case sep == ":":
+				inVerses = true
+			case sep == "-" && (!inVerses || nextIsVerse):
+				// A range ends in a later chapter. Ranges over more chapters than any book has are not
+				// references.
+				if n > chapter && n-chapter <= 150 {
+					for c := chapter + 1; c <= n; c++ {
+						add(Chapter{Book: book, Chapter: c})
+					}
+				}
+				chapter, inVerses = n, false
+			}
+			sep = ""
+		}
+	}
+	return chapters
+}
+
+// verseReferenceRe matches one reference to verses of a chapter, such as "Romans 8:1-4, 28" or, after a
+// semicolon, "12:1". The book may be left out to continue the previous reference's, and the chapter of
+// a book with only one. Verse numbers may have a part letter, as in 15a.
+var verseReferenceRe = regexp.MustCompile(`^(?:((?:[1-3] )?[A-Z][a-z]+(?: of [A-Z][a-z]+)?) )?(?:(\d+):)?(\d+[a-z]?(?:-\d+[a-z]?)?(?:, ?\d+[a-z]?(?:-\d+[a-z]?)?)*)$`)
+
+// ParseVerseIDs returns the 8-digit IDs of the verses in ref, such as "John 3:16-18" or
+// "Romans 8:1-4, 28; 12:1", in order and without duplicates. Since the number of verses in each chapter
+// is not known, references to whole chapters or to ranges across chapters are errors.
+func ParseVerseIDs(ref string) ([]string, error) {
+	ref = strings.NewReplacer("–", "-", "—", "-").Replace(strings.TrimSpace(ref))
+	var ids []string
+	seen := make(map[string]bool)
+	book := 0
+	for part := range strings.SplitSeq(ref, ";") {
+		m := verseReferenceRe.FindStringSubmatch(strings.TrimSpace(part))
+		if m == nil {
+			return nil, fmt.Errorf("%q is not a reference to verses of one chapter", strings.TrimSpace(part))
+		}
+		if m[1] != "" {
+			if book = bookNumbers[m[1]]; book == 0 {
+				return nil, fmt.Errorf("unknown book %q", m[1])
+			}
+		} else if book == 0 {
+			return nil, fmt.Errorf("%q does not name a book", strings.TrimSpace(part))
+		}
+		chapter := 1
+		if m[2] != "" {
+			chapter, _ = strconv.Atoi(m[2])
+		} else if !singleChapterBooks[book] {
+			return nil, fmt.Errorf("%q refers to a whole chapter", strings.TrimSpace(part))
+		}
+		for verses := range strings.SplitSeq(m[3], ",") {
+			from, to, isRange := strings.Cut(strings.TrimSpace(verses), "-")
+			first, _ := strconv.Atoi(strings.TrimRight(from, "abcdefghijklmnopqrstuvwxyz"))
+			last := first
+			if isRange {
+				last, _ = strconv.Atoi(strings.TrimRight(to, "abcdefghijklmnopqrstuvwxyz"))
+			}
+			if chapter == 0 || first == 0 || last < first {
+				return nil, fmt.Errorf("%q has an invalid verse range", strings.TrimSpace(part))
+			}
+			for v := first; v <= last; v++ {
+				id := fmt.Sprintf("%02d%03d%03d", book, chapter, v)
+				if !seen[id] {
+					seen[id] = true
+					ids = append(ids, id)
+				}
+			}
+		}
+	}
+	return ids, nil
+}
+
 type verseInfo struct {
 	book    int
 	chapter int
@@ -48,6 +181,101 @@ func parseVerseID(id string) (*verseInfo, error) {
 	return &verseInfo{book: book, chapter: chapter, verse: verse}, nil
 }
 
+// rawVerseIDRe matches the book, chapter and verse numbers at the start of a verse ID as the ESV API
+// writes it, such as "v01002017-1", or as stored before IDs were canonical. The book may lack its
+// leading zero, and anything after the verse number, such as a "-1" suffix, is ignored.
+var rawVerseIDRe = regexp.MustCompile(`^v?(\d{1,2})(\d{3})(\d{3})(?:\D|$)`)
+
+// CanonicalVerseID returns the 8-digit BBCCCVVV form of a verse ID, such as "01002017" for
+// "v01002017-1". It reports false if id does not name a verse of a known book.
+func CanonicalVerseID(id string) (string, bool) {
+	m := rawVerseIDRe.FindStringSubmatch(id)
+	if m == nil {
+		return "", false
+	}
+	book, _ := strconv.Atoi(m[1])
+	chapter, _ := strconv.Atoi(m[2])
+	verse, _ := strconv.Atoi(m[3])
+	if _, ok := bookNames[book]; !ok || chapter == 0 {
+		return "", false
+	}
+	return fmt.Sprintf("%02d%03d%03d", book, chapter, verse), true
+}
+
+// CanonicalVerseIDs returns verse IDs in their canonical form, in order and without duplicates. IDs
+// that do not name a verse are kept as they are.
+func CanonicalVerseIDs(ids []string) []string {
+	if ids == nil {
+		return nil
+	}
+	canonical := make([]string, 0, len(ids))
+	seen := make(map[string]bool)
+	for _, id := range ids {
+		if c, ok := CanonicalVerseID(id); ok {
+			id = c
+		}
+		if !seen[id] {
+			seen[id] = true
+			canonical = append(canonical, id)
+		}
+	}
+	return canonical
+}
+
+// VerseSet is the set of verses in some passages, such as a day's readings, that verses may be selected
+// from.
+type VerseSet struct {
+	verses map[string]bool
+	// chapters holds the chapters all of whose verses are in the set.
+	chapters map[Chapter]bool
+}
+
+// NewVerseSet returns the set of verses in the passages with the references. The number of verses in
+// each chapter is not known, so every verse of a chapter is in the set if a reference covers the whole
+// chapter or a range across chapters.
+func NewVerseSet(refs ...string) VerseSet {
+	set := VerseSet{verses: make(map[string]bool), chapters: make(map[Chapter]bool)}
+	for _, ref := range refs {
+		if ids, err := ParseVerseIDs(ref); err == nil {
+			for _, id := range ids {
+				set.verses[id] = true
+			}
+			continue
+		}
+		for _, c := range ParseChapters(ref) {
+			set.chapters[c] = true
+		}
+	}
+	return set
+}
+
+// Contains reports whether the verse with the 8-digit ID is in the set.
+func (set VerseSet) Contains(id string) bool {
+	if set.verses[id] {
+		return true
+	}
+	info, err := parseVerseID(id)
+	return err == nil && set.chapters[Chapter{Book: info.book, Chapter: info.chapter}]
+}
+
+// Select returns the verse IDs in their canonical form, in order and without duplicates, leaving out
+// the verses that are not in the set. It returns an error if an ID does not name a verse.
+func (set VerseSet) Select(ids []string) ([]string, error) {
+	selected := []string{}
+	seen := make(map[string]bool)
+	for _, id := range ids {
+		c, ok := CanonicalVerseID(id)
+		if !ok {
+			return nil, fmt.Errorf("%q is not a verse ID", id)
+		}
+		if !seen[c] && set.Contains(c) {
+			seen[c] = true
+			selected = append(selected, c)
+		}
+	}
+	return selected, nil
+}
+
 // FormatReferences converts a list of 8-digit verse IDs to a single ESV-compatible reference string.
 func FormatReferences(verseIDs []string) string {
 	if len(verseIDs) == 0 {
@@ -117,3 +345,66 @@ func FormatReferences(verseIDs []string) string {
 
 	return strings.Join(results, "; ")
 }
+
+// Chapter identifies a chapter of the Bible.
+type Chapter struct {
+	Book    int
+	Chapter int
+}
+
+// String returns the chapter as a reference, e.g. "Romans 8".
+func (c Chapter) String() string {
+	return fmt.Sprintf("%s %d", BookName(c.Book), c.Chapter)
+}
+
+// BookName returns the name of the book with the number, e.g. "Romans" for 45.
+func BookName(book int) string {
+	if name := bookNames[book]; name != "" {
+		return name
+	}
+	return fmt.Sprintf("Book %d", book)
+}
+
+// chapterOf returns the chapter of a numeric verse ID such as 45008028.
+func chapterOf(id int) Chapter {
+	return Chapter{Book: id / 1000000, Chapter: id / 1000 % 1000}
+}
+
+// Chapters returns the chapters covered by the passages and the 8-digit verse IDs, sorted and
+// without duplicates. A passage spanning two books only contributes its first and last chapter.
+func Chapters(meta []PassageMeta, verseIDs []string) []Chapter {
+	seen := make(map[Chapter]bool)
+	for _, m := range meta {
+		if len(m.ChapterStart) == 0 || len(m.ChapterEnd) == 0 {
+			continue
+		}
+		start, end := chapterOf(m.ChapterStart[0]), chapterOf(m.ChapterEnd[0])
+		if start.Book != end.Book {
+			seen[start] = true
+			seen[end] = true
+			continue
+		}
+		for c := start.Chapter; c <= end.Chapter; c++ {
+			seen[Chapter{Book: start.Book, Chapter: c}] = true
+		}
+	}
+	for _, id := range verseIDs {
+		info, err := parseVerseID(id)
+		if err != nil {
+			continue
+		}
+		seen[Chapter{Book: info.book, Chapter: info.chapter}] = true
+	}
+
+	chapters := make([]Chapter, 0, len(seen))
+	for c := range seen {
+		chapters = append(chapters, c)
+	}
+	sort.Slice(chapters, func(i, j int) bool {
+		if chapters[i].Book != chapters[j].Book {
+			return chapters[i].Book < chapters[j].Book
+		}
+		return chapters[i].Chapter < chapters[j].Chapter
+	})
+	return chapters
+}
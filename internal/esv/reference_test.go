@@ -1,6 +1,7 @@
 package esv_test
 
 import (
+	"slices"
 	"testing"
 
 	"derrclan.com/moravian-soap/internal/esv"
@@ -47,3 +48,142 @@ func TestFormatReferences(t *testing.T) {
 		})
 	}
 }
+
+func TestChapters(t *testing.T) {
+	meta := []esv.PassageMeta{
+		// Romans 8:28-39
+		{ChapterStart: []int{45008001, 45008039}, ChapterEnd: []int{45008001, 45008039}},
+		// Acts 9:36-10:8
+		{ChapterStart: []int{44009001, 44009043}, ChapterEnd: []int{44010001, 44010048}},
+		// Malachi 4:1-Matthew 1:17
+		{ChapterStart: []int{39004001, 39004006}, ChapterEnd: []int{40001001, 40001025}},
+		{},
+	}
+	verseIDs := []string{"45008028", "19023001", "invalid"}
+
+	got := esv.Chapters(meta, verseIDs)
+	want := []esv.Chapter{
+		{Book: 19, Chapter: 23},
+		{Book: 39, Chapter: 4},
+		{Book: 40, Chapter: 1},
+		{Book: 44, Chapter: 9},
+		{Book: 44, Chapter: 10},
+		{Book: 45, Chapter: 8},
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("Chapters() = %v, want %v", got, want)
+	}
+	if s := want[5].String(); s != "Romans 8" {
+		t.Errorf("String() = %q, want %q", s, "Romans 8")
+	}
+}
+
+func TestParseChapters(t *testing.T) {
+	tests := []struct {
+		ref  string
+		want []esv.Chapter
+	}{
+		{"Psalm 1", []esv.Chapter{{Book: 19, Chapter: 1}}},
+		{"Psalm 7:1–9", []esv.Chapter{{Book: 19, Chapter: 7}}},
+		{"Genesis 1:1–2:3", []esv.Chapter{{Book: 1, Chapter: 1}, {Book: 1, Chapter: 2}}},
+		{"Genesis 3,4", []esv.Chapter{{Book: 1, Chapter: 3}, {Book: 1, Chapter: 4}}},
+		{"Matthew 5-7", []esv.Chapter{{Book: 40, Chapter: 5}, {Book: 40, Chapter: 6}, {Book: 40, Chapter: 7}}},
+		{"John 1:(1-9),10-18", []esv.Chapter{{Book: 43, Chapter: 1}}},
+		{"Romans 8:1-4; 12:1", []esv.Chapter{{Book: 45, Chapter: 8}, {Book: 45, Chapter: 12}}},
+		{"Exodus 40:24–Leviticus 1:17", []esv.Chapter{{Book: 2, Chapter: 40}, {Book: 3, Chapter: 1}}},
+		{"2 Peter 3:8-15a, Mark 1:1-8", []esv.Chapter{{Book: 61, Chapter: 3}, {Book: 41, Chapter: 1}}},
+		{"Song of Solomon 2:10", []esv.Chapter{{Book: 22, Chapter: 2}}},
+		{"Let your steadfast love, O LORD, be upon us, even as we hope in you. Psalm 33:22", []esv.Chapter{{Book: 19, Chapter: 33}}},
+		{"Philemon 1-21", []esv.Chapter{{Book: 57, Chapter: 1}}},
+		{"2 John", []esv.Chapter{{Book: 63, Chapter: 1}}},
+		{"Hezekiah 3:1", nil},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		if got := esv.ParseChapters(tt.ref); !slices.Equal(got, tt.want) {
+			t.Errorf("ParseChapters(%q) = %v, want %v", tt.ref, got, tt.want)
+		}
+	}
+}
+
+func TestParseVerseIDs(t *testing.T) {
+	tests := []struct {
+		ref  string
+		want []string
+	}{
+		{"John 3:16", []string{"43003016"}},
+		{"John 3:16–18", []string{"43003016", "43003017", "43003018"}},
+		{"Romans 8:1-2, 28; 12:1", []string{"45008001", "45008002", "45008028", "45012001"}},
+		{"Psalms 23:1; Psalm 23:1", []string{"19023001"}},
+		{"2 Peter 3:8-9a", []string{"61003008", "61003009"}},
+		{"Jude 24-25", []string{"65001024", "65001025"}},
+		{"Song of Solomon 2:10", []string{"22002010"}},
+	}
+	for _, tt := range tests {
+		if got, err := esv.ParseVerseIDs(tt.ref); err != nil || !slices.Equal(got, tt.want) {
+			t.Errorf("ParseVerseIDs(%q) = %v, %v; want %v", tt.ref, got, err, tt.want)
+		}
+	}
+
+	for _, ref := range []string{"Psalm 23", "Genesis 1:1-2:3", "Hezekiah 3:1", "John 3:18-16", "3:16", ""} {
+		if got, err := esv.ParseVerseIDs(ref); err == nil {
+			t.Errorf("ParseVerseIDs(%q) = %v, want an error", ref, got)
+		}
+	}
+}
+
+func TestCanonicalVerseID(t *testing.T) {
+	tests := []struct {
+		id   string
+		want string
+		ok   bool
+	}{
+		{"01002017", "01002017", true},
+		{"v01002017-1", "01002017", true},
+		{"v01002017-2", "01002017", true},
+		{"v1002017", "01002017", true},
+		{"v43003016a", "43003016", true},
+		{"p01002017_01-1", "", false},
+		{"v67001001", "", false},
+		{"v01000001", "", false},
+		{"bogus", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := esv.CanonicalVerseID(tt.id)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("CanonicalVerseID(%q) = %q, %v; want %q, %v", tt.id, got, ok, tt.want, tt.ok)
+		}
+	}
+
+	if got, want := esv.CanonicalVerseIDs([]string{"v01002017-1", "01002017", "bogus", "v1002018"}), []string{"01002017", "bogus", "01002018"}; !slices.Equal(got, want) {
+		t.Errorf("CanonicalVerseIDs() = %v, want %v", got, want)
+	}
+}
+
+func TestVerseSet(t *testing.T) {
+	set := esv.NewVerseSet("Psalm 1", "Genesis 1:1–2:3", "Matthew 1:1–17")
+	for id, want := range map[string]bool{
+		"19001006": true,  // Psalm 1:6, in a whole chapter
+		"19002001": false, // Psalm 2:1
+		"01002025": true,  // Genesis 2:25, in a chapter of a range across chapters
+		"01003001": false, // Genesis 3:1
+		"40001017": true,  // Matthew 1:17
+		"40001018": false, // Matthew 1:18
+		"bogus":    false,
+	} {
+		if got := set.Contains(id); got != want {
+			t.Errorf("Contains(%q) = %v, want %v", id, got, want)
+		}
+	}
+
+	got, err := set.Select([]string{"v40001002-1", "40001002", "v40001018-1", "19001001"})
+	if want := []string{"40001002", "19001001"}; err != nil || !slices.Equal(got, want) {
+		t.Errorf("Select() = %v, %v; want %v", got, err, want)
+	}
+	if got, err := set.Select(nil); err != nil || got == nil || len(got) != 0 {
+		t.Errorf("Select(nil) = %#v, %v; want an empty selection", got, err)
+	}
+	if got, err := set.Select([]string{"40001002", "bogus"}); err == nil {
+		t.Errorf("Select() with an invalid ID = %v, want an error", got)
+	}
+}
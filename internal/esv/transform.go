@@ -8,6 +8,8 @@ import (
 	"slices"
 	"strconv"
 	"strings"
+	"unicode"
+	"unicode/utf16"
 
 	"golang.org/x/net/html"
 	"golang.org/x/net/html/atom"
@@ -62,6 +64,12 @@ func processPassageHTML(htmlStr string) (string, error) {
 			continue
 		}
 
+		// Mark blocks in right-to-left scripts, so they are laid out right to left however they are
+		// shown. Left-to-right blocks are left as they are.
+		if isBlock(node) && getAttr(node, "dir") == "" && nodeDirection(node) == RightToLeft {
+			setAttr(node, "dir", string(RightToLeft))
+		}
+
 		if err := html.Render(&buf, node); err != nil {
 			return "", fmt.Errorf("failed to render node: %w", err)
 		}
@@ -70,16 +78,89 @@ func processPassageHTML(htmlStr string) (string, error) {
 	return buf.String(), nil
 }
 
-func unescapeString(s string) string {
-	unescapeRegex := regexp.MustCompile(`\\u([0-9a-fA-F]{4})`)
+// verifyTextContent checks that the transformed HTML carries exactly the same text as the
+// raw HTML, ignoring whitespace which the transform is allowed to trim.
+// It returns an error describing the first divergence if characters were lost, added, or reordered.
+func verifyTextContent(rawHTML, processedHTML string) error {
+	want, err := textContent(unescapeString(rawHTML))
+	if err != nil {
+		return fmt.Errorf("extracting text from raw passage: %w", err)
+	}
+	got, err := textContent(processedHTML)
+	if err != nil {
+		return fmt.Errorf("extracting text from processed passage: %w", err)
+	}
+
+	if want == got {
+		return nil
+	}
+
+	wantRunes, gotRunes := []rune(want), []rune(got)
+	i := 0
+	for i < len(wantRunes) && i < len(gotRunes) && wantRunes[i] == gotRunes[i] {
+		i++
+	}
+	return fmt.Errorf("text content diverges at rune %d: want %q, got %q", i, excerpt(wantRunes, i), excerpt(gotRunes, i))
+}
+
+// textContent returns the concatenated text nodes of an HTML fragment with all whitespace removed.
+func textContent(htmlStr string) (string, error) {
+	nodes, err := html.ParseFragment(strings.NewReader(htmlStr), &html.Node{
+		Type:     html.ElementNode,
+		Data:     "body",
+		DataAtom: atom.Body,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to parse HTML fragment: %w", err)
+	}
+
+	var sb strings.Builder
+	for _, node := range nodes {
+		if node.Type == html.TextNode {
+			writeWithoutSpace(&sb, node.Data)
+			continue
+		}
+		for d := range node.Descendants() {
+			if d.Type == html.TextNode {
+				writeWithoutSpace(&sb, d.Data)
+			}
+		}
+	}
+	return sb.String(), nil
+}
+
+func writeWithoutSpace(sb *strings.Builder, s string) {
+	for _, r := range s {
+		if !unicode.IsSpace(r) {
+			sb.WriteRune(r)
+		}
+	}
+}
+
+// excerpt returns a short window of runes starting at i for use in error messages.
+func excerpt(runes []rune, i int) string {
+	end := min(i+20, len(runes))
+	if i >= end {
+		return ""
+	}
+	return string(runes[i:end])
+}
+
+// unescapeRegex matches an escaped UTF-16 code unit, or a surrogate pair of them encoding a character
+// outside the Basic Multilingual Plane.
+var unescapeRegex = regexp.MustCompile(`\\u[dD][89abAB][0-9a-fA-F]{2}\\u[dD][c-fC-F][0-9a-fA-F]{2}|\\u[0-9a-fA-F]{4}`)
 
+func unescapeString(s string) string {
 	return unescapeRegex.ReplaceAllStringFunc(s, func(match string) string {
-		code := match[2:]
-		val, err := strconv.ParseInt(code, 16, 32)
-		if err != nil {
-			return match
+		var units []uint16
+		for code := range strings.SplitSeq(match[2:], `\u`) {
+			val, err := strconv.ParseUint(code, 16, 16)
+			if err != nil {
+				return match
+			}
+			units = append(units, uint16(val))
 		}
-		return string(rune(val))
+		return string(utf16.Decode(units))
 	})
 }
 
@@ -357,15 +438,11 @@ func getVerseRef(n *html.Node) string {
 		return ""
 	}
 
-	// verseIDRegex matches verse IDs like "v01002017-1" and captures the ref "01002017"
-	verseIDRegex := regexp.MustCompile(`^v(\d{8}).*`)
-
-	for _, a := range n.Attr {
-		if a.Key == "id" {
-			matches := verseIDRegex.FindStringSubmatch(a.Val)
-			if len(matches) > 1 {
-				return matches[1]
-			}
+	// Verse markers have IDs like "v01002017-1"; the wrapper carries the canonical ref "01002017" so that
+	// changes to the rest of the ID's format don't change which verse it refers to.
+	if id := getAttr(n, "id"); strings.HasPrefix(id, "v") {
+		if ref, ok := CanonicalVerseID(id); ok {
+			return ref
 		}
 	}
 	return ""
@@ -466,6 +543,16 @@ func setAttr(n *html.Node, key, val string) {
 	n.Attr = append(n.Attr, html.Attribute{Key: key, Val: val})
 }
 
+// getAttr returns the value of an attribute of a node, or "" if it is not set.
+func getAttr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
 // removeID removes the 'id' attribute from a node.
 func removeID(n *html.Node) {
 	for i, a := range n.Attr {
@@ -518,3 +605,118 @@ func hasSection(n *html.Node) bool {
 	}
 	return false
 }
+
+// verseNumberRE matches the verse number markers left in a transformed passage.
+var verseNumberRE = regexp.MustCompile(`<b class="verse-num">.*?</b>`)
+
+// stripVerseNumbers removes the verse numbers from a transformed passage while keeping
+// the verse spans, so verses can still be selected.
+func stripVerseNumbers(passage string) string {
+	return verseNumberRE.ReplaceAllString(passage, "")
+}
+
+// markWordsOfChrist prepares the words of Christ in a transformed passage for display. The ESV API
+// wraps them in "woc" spans; in red letter mode these become "red-letter" spans, which are shown in
+// red, and otherwise the spans are removed and only the words are kept.
+func markWordsOfChrist(passage string, red bool) (string, error) {
+	if !strings.Contains(passage, "woc") {
+		return passage, nil
+	}
+	body := &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body}
+	nodes, err := html.ParseFragment(strings.NewReader(passage), body)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse HTML fragment: %w", err)
+	}
+	for _, n := range nodes {
+		body.AppendChild(n)
+	}
+
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		for c := n.FirstChild; c != nil; {
+			next := c.NextSibling
+			walk(c)
+			if c.DataAtom == atom.Span && hasClass(c, "woc") {
+				if red {
+					removeClass(c, "woc")
+					addClass(c, "red-letter")
+				} else {
+					for gc := c.FirstChild; gc != nil; {
+						gnext := gc.NextSibling
+						c.RemoveChild(gc)
+						n.InsertBefore(gc, c)
+						gc = gnext
+					}
+					n.RemoveChild(c)
+				}
+			}
+			c = next
+		}
+	}
+	walk(body)
+
+	var buf bytes.Buffer
+	for c := body.FirstChild; c != nil; c = c.NextSibling {
+		if err := html.Render(&buf, c); err != nil {
+			return "", fmt.Errorf("failed to render node: %w", err)
+		}
+	}
+	return buf.String(), nil
+}
+
+// verseRefRE matches the verse references of the verse spans in a transformed passage.
+var verseRefRE = regexp.MustCompile(`data-ref="(\d{8})"`)
+
+// VerseRefs returns the 8-digit references of the verses in a transformed passage, in order and
+// without duplicates.
+func VerseRefs(passage string) []string {
+	var refs []string
+	for _, m := range verseRefRE.FindAllStringSubmatch(passage, -1) {
+		if !slices.Contains(refs, m[1]) {
+			refs = append(refs, m[1])
+		}
+	}
+	return refs
+}
+
+// AppendToVerses adds the node returned by mark to the end of the first span of each verse in refs, e.g.
+// to show which verses have notes. Spans inside verse numbers are skipped.
+func AppendToVerses(passage string, refs []string, mark func(ref string) *html.Node) (string, error) {
+	if len(refs) == 0 {
+		return passage, nil
+	}
+	body := &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body}
+	nodes, err := html.ParseFragment(strings.NewReader(passage), body)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse HTML fragment: %w", err)
+	}
+	for _, n := range nodes {
+		body.AppendChild(n)
+	}
+
+	marked := make(map[string]bool)
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.DataAtom == atom.B && hasClass(n, "verse-num") {
+			return
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+		if n.DataAtom == atom.Span && hasClass(n, "verse") {
+			if ref := getAttr(n, "data-ref"); slices.Contains(refs, ref) && !marked[ref] {
+				marked[ref] = true
+				n.AppendChild(mark(ref))
+			}
+		}
+	}
+	walk(body)
+
+	var buf bytes.Buffer
+	for c := body.FirstChild; c != nil; c = c.NextSibling {
+		if err := html.Render(&buf, c); err != nil {
+			return "", fmt.Errorf("failed to render node: %w", err)
+		}
+	}
+	return buf.String(), nil
+}
@@ -1,9 +1,15 @@
 package esv
 
 import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
 )
 
 func TestProcessPassageHTML(t *testing.T) {
@@ -23,6 +29,15 @@ func TestProcessPassageHTML(t *testing.T) {
 </p><p id="p01002023_01-1" class="same-paragraph"><b class="verse-num" id="v01002024-1">24</b>Therefore a man shall leave his father and his mother and hold fast to his wife, and they shall become one flesh. <b class="verse-num">&nbsp;25&nbsp;</b>And the man and his wife were both naked and were not ashamed.</p>
 <p>(<a href="http://www.esv.org" class="copyright">ESV</a>)</p>`,
 			expected: "\n<h2 class=\"extra_text\">Genesis 2:17–25</h2>\n<p><span class=\"verse\" data-ref=\"01002017\"><b class=\"verse-num\">17</b>but of the tree of the knowledge of good and evil you shall not eat, for in the day that you eat of it you shall surely die.”</span></p>\n<p><span class=\"verse\" data-ref=\"01002018\"><b class=\"verse-num\">18</b>Then the LORD God said, “It is not good that the man should be alone; I will make him a helper fit for him.”</span><span class=\"verse\" data-ref=\"01002019\"><b class=\"verse-num\">19</b>Now out of the ground the LORD God had formed every beast of the field and every bird of the heavens and brought them to the man to see what he would call them. And whatever the man called every living creature, that was its name.</span><span class=\"verse\" data-ref=\"01002020\"><b class=\"verse-num\">20</b>The man gave names to all livestock and to the birds of the heavens and to every beast of the field. But for Adam there was not found a helper fit for him.</span><span class=\"verse\" data-ref=\"01002021\"><b class=\"verse-num\">21</b>So the LORD God caused a deep sleep to fall upon the man, and while he slept took one of his ribs and closed up its place with flesh.</span><span class=\"verse\" data-ref=\"01002022\"><b class=\"verse-num\">22</b>And the rib that the LORD God had taken from the man he made into a woman and brought her to the man.</span><span class=\"verse\" data-ref=\"01002023\"><b class=\"verse-num\">23</b>Then the man said,</span></p>\n<section class=\"line-group\">\n<span class=\"line verse\" data-ref=\"01002023\">“This at last is bone of my bones</span><br/><span class=\"indent line verse\" data-ref=\"01002023\">and flesh of my flesh;</span><br/><span class=\"line verse\" data-ref=\"01002023\">she shall be called Woman,</span><br/><span class=\"indent line verse\" data-ref=\"01002023\">because she was taken out of Man.”</span><br/>\n<p class=\"same-paragraph\"><span class=\"verse\" data-ref=\"01002024\"><b class=\"verse-num\">24</b>Therefore a man shall leave his father and his mother and hold fast to his wife, and they shall become one flesh. <b class=\"verse-num\"><span class=\"verse\" data-ref=\"01002024\">25</span></b>And the man and his wife were both naked and were not ashamed.</span></p>\n<p>(<a href=\"http://www.esv.org\" class=\"copyright\">ESV</a>)</p></section>",
+		}, {
+			name:     "Right to left",
+			input:    `<h3 id="h19023001">\u05de\u05b4\u05d6\u05b0\u05de\u05d5\u05b9\u05e8</h3><p id="p19023001_01-1"><b class="verse-num" id="v19023001-1">1</b>\u05d9\u05b0\u05d4\u05d5\u05b8\u05d4 \u05e8\u05b9\u05e2\u05b4\u05d9</p>`,
+			expected: "<h3 dir=\"rtl\">מִזְמוֹר</h3><p dir=\"rtl\"><span class=\"verse\" data-ref=\"19023001\"><b class=\"verse-num\">1</b>יְהוָה רֹעִי</span></p>",
+		},
+		{
+			name:     "Verse ID suffixes",
+			input:    `<p id="p43011035_01-2"><b class="verse-num" id="v43011035-2">35</b>Jesus wept. <b class="verse-num" id="v43011036a">36</b>So the Jews said,</p>`,
+			expected: `<p><span class="verse" data-ref="43011035"><b class="verse-num">35</b>Jesus wept.</span><span class="verse" data-ref="43011036"><b class="verse-num">36</b>So the Jews said,</span></p>`,
 		},
 	}
 
@@ -36,6 +51,145 @@ func TestProcessPassageHTML(t *testing.T) {
 				t.Errorf("processPassageHTML() mismatch (-want +got):\n%s", diff)
 				t.Logf("GOT: %q", got)
 			}
+			if err := verifyTextContent(tt.input, got); err != nil {
+				t.Errorf("verifyTextContent() error = %v", err)
+			}
 		})
 	}
 }
+
+func TestVerifyTextContent(t *testing.T) {
+	tests := []struct {
+		name      string
+		raw       string
+		processed string
+		wantErr   bool
+	}{
+		{
+			name:      "whitespace differences are ignored",
+			raw:       `<p><b class="verse-num" id="v01001001-1">&nbsp;1&nbsp;</b>In the beginning</p>`,
+			processed: `<p><span class="verse" data-ref="01001001"><b class="verse-num">1</b>In the beginning</span></p>`,
+		},
+		{
+			name:      "escaped unicode in raw passage",
+			raw:       `<h2>Genesis 2:17\u201325</h2>`,
+			processed: `<h2>Genesis 2:17–25</h2>`,
+		},
+		{
+			name:      "dropped text",
+			raw:       `<p>Selah</p><p>Praise the LORD</p>`,
+			processed: `<p>Praise the LORD</p>`,
+			wantErr:   true,
+		},
+		{
+			name:      "reordered text",
+			raw:       `<p>first second</p>`,
+			processed: `<p>second first</p>`,
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := verifyTextContent(tt.raw, tt.processed)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("verifyTextContent() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTransformPassage_Verify(t *testing.T) {
+	raw := `<p id="p01001001_01-1"><b class="verse-num" id="v01001001-1">1</b>In the beginning, God created the heavens and the earth.</p>`
+
+	got := transformPassage(raw, true)
+	if got == raw {
+		t.Fatalf("expected transformed passage, got raw passage")
+	}
+	if err := verifyTextContent(raw, got); err != nil {
+		t.Errorf("transformed passage lost text: %v", err)
+	}
+}
+
+func TestFetchPassages_VerifyTransformFails(t *testing.T) {
+	// The transform drops the end-line-group marker along with any text inside it.
+	raw := `<p><b class="verse-num" id="v19003008-1">8</b>Salvation belongs to the LORD</p><span class="end-line-group">Selah</span>`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewEncoder(w).Encode(Response{Passages: []string{raw}}); err != nil {
+			t.Errorf("encoding response: %v", err)
+		}
+	}))
+	defer srv.Close()
+	t.Setenv("ESV_API_URL", srv.URL)
+
+	for _, verify := range []bool{true, false} {
+		opts := DefaultOptions()
+		opts.VerifyTransform = verify
+		resp, err := FetchPassages(context.Background(), []string{"Psalm 3:8"}, opts)
+		if err != nil {
+			t.Fatalf("FetchPassages() error = %v", err)
+		}
+		if got := resp.Passages[0] == raw; got != verify {
+			t.Errorf("VerifyTransform=%v: got raw passage = %v, want %v: %q", verify, got, verify, resp.Passages[0])
+		}
+	}
+}
+
+func TestStripVerseNumbers(t *testing.T) {
+	passage := `<p><span class="verse" data-ref="01001001"><b class="verse-num">1</b>In the beginning</span><span class="verse" data-ref="01001002"><b class="verse-num"><span class="verse" data-ref="01001002">2</span></b>The earth</span></p>`
+	want := `<p><span class="verse" data-ref="01001001">In the beginning</span><span class="verse" data-ref="01001002">The earth</span></p>`
+	if got := stripVerseNumbers(passage); got != want {
+		t.Errorf("stripVerseNumbers() = %q, want %q", got, want)
+	}
+}
+
+func TestMarkWordsOfChrist(t *testing.T) {
+	passage := `<p><span class="verse" data-ref="43011035"><b class="verse-num">35</b>Jesus said, <span class="woc">“Lazarus, come out.”</span></span></p>`
+	tests := []struct {
+		red  bool
+		want string
+	}{
+		{true, `<p><span class="verse" data-ref="43011035"><b class="verse-num">35</b>Jesus said, <span class="red-letter">“Lazarus, come out.”</span></span></p>`},
+		{false, `<p><span class="verse" data-ref="43011035"><b class="verse-num">35</b>Jesus said, “Lazarus, come out.”</span></p>`},
+	}
+	for _, tt := range tests {
+		got, err := markWordsOfChrist(passage, tt.red)
+		if err != nil {
+			t.Fatalf("markWordsOfChrist(red=%v) failed: %v", tt.red, err)
+		}
+		if got != tt.want {
+			t.Errorf("markWordsOfChrist(red=%v) = %q, want %q", tt.red, got, tt.want)
+		}
+	}
+
+	// Passages without words of Christ are returned untouched.
+	plain := `<p>In the beginning</p>`
+	if got, err := markWordsOfChrist(plain, true); err != nil || got != plain {
+		t.Errorf("markWordsOfChrist(plain) = %q, %v; want it unchanged", got, err)
+	}
+}
+
+func TestAppendToVerses(t *testing.T) {
+	passage := `<p><span class="verse" data-ref="01002023"><b class="verse-num">23</b>Then the man said,</span></p>` +
+		`<section class="line-group"><span class="line verse" data-ref="01002023">“This at last is bone of my bones</span><br/>` +
+		`<p class="same-paragraph"><span class="verse" data-ref="01002024"><b class="verse-num">24</b>Therefore a man shall leave</span>` +
+		`<span class="verse" data-ref="01002025"><b class="verse-num"><span class="verse" data-ref="01002025">25</span></b>And the man</span></p></section>`
+
+	if got, want := VerseRefs(passage), []string{"01002023", "01002024", "01002025"}; !cmp.Equal(got, want) {
+		t.Errorf("VerseRefs() = %v, want %v", got, want)
+	}
+
+	got, err := AppendToVerses(passage, []string{"01002023", "01002025"}, func(ref string) *html.Node {
+		return &html.Node{Type: html.ElementNode, Data: "i", DataAtom: atom.I, Attr: []html.Attribute{{Key: "data-note", Val: ref}}}
+	})
+	if err != nil {
+		t.Fatalf("AppendToVerses failed: %v", err)
+	}
+	want := `<p><span class="verse" data-ref="01002023"><b class="verse-num">23</b>Then the man said,<i data-note="01002023"></i></span></p>` +
+		`<section class="line-group"><span class="line verse" data-ref="01002023">“This at last is bone of my bones</span><br/>` +
+		`<p class="same-paragraph"><span class="verse" data-ref="01002024"><b class="verse-num">24</b>Therefore a man shall leave</span>` +
+		`<span class="verse" data-ref="01002025"><b class="verse-num"><span class="verse" data-ref="01002025">25</span></b>And the man<i data-note="01002025"></i></span></p></section>`
+	if got != want {
+		t.Errorf("AppendToVerses() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,55 @@
+package esv
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// DefaultTranslation is the name of the translation passages are read in unless another is chosen.
+const DefaultTranslation = "ESV"
+
+// Translation is a Bible translation that passages can be fetched in.
+type Translation interface {
+	// Name is the translation's abbreviation, such as "ESV", by which it is chosen.
+	Name() string
+	// Copyright is the notice the translation must be quoted with.
+	Copyright() string
+	// FetchPassages fetches the passages with the given references, rendered according to opts as
+	// selectable verse spans like those of the ESV.
+	FetchPassages(ctx context.Context, references []string, opts Options) (Response, error)
+}
+
+// translations are the translations passages can be read in, the default first.
+var translations = []Translation{
+	esvTranslation{},
+	bibleAPITranslation{name: "WEB", title: "World English Bible", id: "web", site: "https://ebible.org/web/"},
+}
+
+// Translations returns the translations passages can be read in, the default first.
+func Translations() []Translation {
+	return translations
+}
+
+// Lookup returns the translation with the given name, ignoring case. An empty name is the default
+// translation.
+func Lookup(name string) (Translation, bool) {
+	if name == "" {
+		return translations[0], true
+	}
+	for _, t := range translations {
+		if strings.EqualFold(t.Name(), name) {
+			return t, true
+		}
+	}
+	return nil, false
+}
+
+// FetchPassages fetches verses in the translation named by opts, rendered according to opts.
+func FetchPassages(ctx context.Context, references []string, opts Options) (Response, error) {
+	t, ok := Lookup(opts.Translation)
+	if !ok {
+		return Response{}, fmt.Errorf("unknown translation %q", opts.Translation)
+	}
+	return t.FetchPassages(ctx, references, opts)
+}
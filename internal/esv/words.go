@@ -0,0 +1,51 @@
+package esv
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+
+	"derrclan.com/moravian-soap/internal/htmltext"
+)
+
+// WordsPerMinute is the reading speed used to estimate reading times. It is a little below the usual
+// 230-250 for silent reading, since scripture tends to be read more slowly than prose.
+const WordsPerMinute = 200
+
+// uncountedRE matches the parts of a passage that are not read as part of the text: headings, verse and
+// chapter numbers, and copyright notices.
+var uncountedRE = regexp.MustCompile(`(?s)<h[1-6][^>]*>.*?</h[1-6]>|<b class="(?:verse|chapter)-num"[^>]*>.*?</b>|<a [^>]*class="copyright"[^>]*>.*?</a>`)
+
+// WordCount returns the number of words of scripture in a passage as returned by FetchPassages.
+func WordCount(passage string) int {
+	text, err := htmltext.ToText(uncountedRE.ReplaceAllString(passage, " "))
+	if err != nil {
+		return 0
+	}
+	n := 0
+	for _, f := range strings.Fields(text) {
+		if strings.IndexFunc(f, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }) >= 0 {
+			n++
+		}
+	}
+	return n
+}
+
+// PassageDirection returns the direction of a passage as returned by FetchPassages, from its first
+// letter of scripture.
+func PassageDirection(passage string) Direction {
+	text, err := htmltext.ToText(uncountedRE.ReplaceAllString(passage, " "))
+	if err != nil {
+		return LeftToRight
+	}
+	return TextDirection(text)
+}
+
+// ReadingMinutes estimates the whole minutes it takes to read the given number of words, rounding up so
+// that any text takes at least a minute.
+func ReadingMinutes(words int) int {
+	if words <= 0 {
+		return 0
+	}
+	return (words + WordsPerMinute - 1) / WordsPerMinute
+}
@@ -0,0 +1,38 @@
+package esv
+
+import "testing"
+
+func TestWordCount(t *testing.T) {
+	tests := []struct {
+		name    string
+		passage string
+		want    int
+	}{
+		{"empty", "", 0},
+		{
+			"headings and numbers are not counted",
+			`<h2 class="extra_text">Romans 8:1–2</h2><h3>Life in the Spirit</h3>` +
+				`<p><span class="verse" data-ref="45008001"><b class="chapter-num">8:1&nbsp;</b>There is therefore now no condemnation</span> ` +
+				`<span class="verse" data-ref="45008002"><b class="verse-num">2</b>For the law &mdash; of the Spirit</span></p>`,
+			12,
+		},
+		{
+			"copyright",
+			`<p>Jesus wept.</p><p>(<a href="http://www.esv.org" class="copyright">ESV</a>)</p>`,
+			2,
+		},
+	}
+	for _, tt := range tests {
+		if got := WordCount(tt.passage); got != tt.want {
+			t.Errorf("%s: WordCount() = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestReadingMinutes(t *testing.T) {
+	for words, want := range map[int]int{0: 0, 1: 1, WordsPerMinute: 1, WordsPerMinute + 1: 2, 1000: 5} {
+		if got := ReadingMinutes(words); got != want {
+			t.Errorf("ReadingMinutes(%d) = %d, want %d", words, got, want)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+// Package events provides the bus on which the app announces what has happened in it, such as a
+// journal entry being saved, to the subsystems that act on it, so that the code making a change need
+// not know each of the things that follow from it.
+package events
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"derrclan.com/moravian-soap/internal/store"
+)
+
+// Kind is the kind of an event, named "subject.verb".
+type Kind string
+
+const (
+	// EntrySaved is published once a journal entry has been saved, with the entry as saved.
+	EntrySaved Kind = "entry.saved"
+	// EntryDeleted is published once a save has left a journal entry that had something written in it
+	// with nothing, which the store keeps no trace of.
+	EntryDeleted Kind = "entry.deleted"
+	// UserRegistered is published once an account has been created and its confirmation email queued.
+	UserRegistered Kind = "user.registered"
+	// CacheExpunged is published once the expunger has removed the expired and least recently used
+	// passages from the cache.
+	CacheExpunged Kind = "cache.expunged"
+)
+
+// Event is something that has happened in the app. Only the fields of its kind are set.
+type Event struct {
+	Kind Kind
+	At   time.Time
+	// UserID is the user the event happened to, for entry and user events.
+	UserID int64
+	// Date is the date of the journal entry of an entry event.
+	Date string
+	// Entry is the journal entry as saved, for EntrySaved.
+	Entry *store.SOAPData
+	// Email is the address of the account registered, for UserRegistered.
+	Email string
+}
+
+// Handler acts on an event.
+type Handler func(ctx context.Context, e Event)
+
+// Bus passes the events published on it to the handlers subscribed to their kind. The zero value is
+// not usable; create one with NewBus.
+type Bus struct {
+	mu       sync.RWMutex
+	handlers map[Kind][]Handler
+}
+
+// NewBus creates a bus with no subscribers.
+func NewBus() *Bus {
+	return &Bus{handlers: make(map[Kind][]Handler)}
+}
+
+// Subscribe calls h with each event of kind published from now on.
+func (b *Bus) Subscribe(kind Kind, h Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers[kind] = append(b.handlers[kind], h)
+}
+
+// Publish calls the handlers subscribed to e's kind, in the order they subscribed, before it returns,
+// so that what they derive from a change is in place by the time the request that made it is answered.
+// A handler that panics is logged and does not keep the rest from being called. Publishing on a nil bus
+// does nothing.
+func (b *Bus) Publish(ctx context.Context, e Event) {
+	if b == nil {
+		return
+	}
+	b.mu.RLock()
+	handlers := b.handlers[e.Kind]
+	b.mu.RUnlock()
+	for _, h := range handlers {
+		call(ctx, h, e)
+	}
+}
+
+// call calls h with e, recovering from any panic in it.
+func call(ctx context.Context, h Handler, e Event) {
+	defer func() {
+		if r := recover(); r != nil {
+			slog.Error("event handler panicked", "kind", e.Kind, "panic", r)
+		}
+	}()
+	h(ctx, e)
+}
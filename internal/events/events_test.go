@@ -0,0 +1,34 @@
+package events_test
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"derrclan.com/moravian-soap/internal/events"
+)
+
+func TestBus(t *testing.T) {
+	bus := events.NewBus()
+	ctx := context.Background()
+
+	var got []string
+	bus.Subscribe(events.EntrySaved, func(ctx context.Context, e events.Event) { got = append(got, "first "+e.Date) })
+	bus.Subscribe(events.EntrySaved, func(ctx context.Context, e events.Event) { panic("broken handler") })
+	bus.Subscribe(events.EntrySaved, func(ctx context.Context, e events.Event) { got = append(got, "third "+e.Date) })
+	bus.Subscribe(events.UserRegistered, func(ctx context.Context, e events.Event) { got = append(got, "registered "+e.Email) })
+
+	bus.Publish(ctx, events.Event{Kind: events.EntrySaved, UserID: 1, Date: "2026-03-07"})
+	bus.Publish(ctx, events.Event{Kind: events.CacheExpunged})
+	bus.Publish(ctx, events.Event{Kind: events.UserRegistered, Email: "reader@example.com"})
+
+	want := []string{"first 2026-03-07", "third 2026-03-07", "registered reader@example.com"}
+	if !slices.Equal(got, want) {
+		t.Errorf("handlers called = %q, want %q", got, want)
+	}
+}
+
+func TestBus_Nil(t *testing.T) {
+	var bus *events.Bus
+	bus.Publish(context.Background(), events.Event{Kind: events.EntrySaved})
+}
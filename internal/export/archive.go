@@ -0,0 +1,52 @@
+package export
+
+import (
+	"archive/zip"
+	"context"
+	"fmt"
+	"io"
+
+	"derrclan.com/moravian-soap/internal/journal"
+)
+
+// ArchiveContentType is the media type of an archive written by WriteArchive.
+const ArchiveContentType = "application/zip"
+
+// ArchiveEntriesName is the name of the file in an archive that holds every entry as JSON.
+const ArchiveEntriesName = "entries.json"
+
+// ArchiveDay is the entries of one day, which an archive keeps together in one Markdown file.
+type ArchiveDay struct {
+	Date    string
+	Entries []*Entry
+}
+
+// WriteArchive writes the days to w as a zip archive. Each day is a Markdown file named by its date, as
+// written by WriteEntriesMarkdown, and ArchiveEntriesName holds the entries of every day, as written by
+// WriteEntriesJSON.
+func WriteArchive(ctx context.Context, w io.Writer, days []ArchiveDay, schema journal.Schema) error {
+	zw := zip.NewWriter(w)
+	var all []*Entry
+	for _, day := range days {
+		f, err := zw.CreateHeader(&zip.FileHeader{Name: day.Date + ".md", Method: zip.Deflate})
+		if err != nil {
+			return fmt.Errorf("adding %s to archive: %w", day.Date, err)
+		}
+		if err := WriteEntriesMarkdown(ctx, f, day.Entries, schema); err != nil {
+			return err
+		}
+		all = append(all, day.Entries...)
+	}
+
+	f, err := zw.CreateHeader(&zip.FileHeader{Name: ArchiveEntriesName, Method: zip.Deflate})
+	if err != nil {
+		return fmt.Errorf("adding %s to archive: %w", ArchiveEntriesName, err)
+	}
+	if err := WriteEntriesJSON(f, all); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("writing archive: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package export_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+
+	"derrclan.com/moravian-soap/internal/export"
+	"derrclan.com/moravian-soap/internal/journal"
+)
+
+func TestWriteArchive(t *testing.T) {
+	days := []export.ArchiveDay{
+		{Date: "2026-04-23", Entries: testEntries},
+		{Date: "2026-04-24", Entries: []*export.Entry{{Type: export.EntryDaily, Date: "2026-04-24", Sections: map[string]string{"observation": "Rested"}}}},
+	}
+	var buf bytes.Buffer
+	if err := export.WriteArchive(context.Background(), &buf, days, journal.DefaultSchema()); err != nil {
+		t.Fatalf("WriteArchive() error = %v", err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("reading archive: %v", err)
+	}
+	files := make(map[string]string)
+	var names []string
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("opening %s: %v", f.Name, err)
+		}
+		b, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatalf("reading %s: %v", f.Name, err)
+		}
+		files[f.Name] = string(b)
+		names = append(names, f.Name)
+	}
+	if want := "2026-04-23.md 2026-04-24.md entries.json"; strings.Join(names, " ") != want {
+		t.Fatalf("archive files = %v, want %s", names, want)
+	}
+
+	if md := files["2026-04-23.md"]; !strings.Contains(md, "Loved") || !strings.Contains(md, "Shepherd me") || strings.Contains(md, "Rested") {
+		t.Errorf("2026-04-23.md = %q, want both of the day's entries only", md)
+	}
+	if md := files["2026-04-24.md"]; !strings.Contains(md, "Rested") {
+		t.Errorf("2026-04-24.md = %q, want the day's entry", md)
+	}
+
+	var entries []*export.Entry
+	if err := json.Unmarshal([]byte(files[export.ArchiveEntriesName]), &entries); err != nil {
+		t.Fatalf("decoding %s: %v", export.ArchiveEntriesName, err)
+	}
+	if len(entries) != 3 || entries[2].Sections["observation"] != "Rested" {
+		t.Errorf("%s = %+v, want every entry", export.ArchiveEntriesName, entries)
+	}
+}
@@ -0,0 +1,91 @@
+package export_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"derrclan.com/moravian-soap/internal/export"
+	"derrclan.com/moravian-soap/internal/store"
+)
+
+// TestBackupRoundTrip checks that every backup version this release has ever written can still be
+// read, and that the current version writes it back unchanged.
+func TestBackupRoundTrip(t *testing.T) {
+	for version := 1; version <= export.BackupVersion; version++ {
+		path := filepath.Join("testdata", fmt.Sprintf("backup-v%d.json", version))
+		want, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("reading %s: %v", path, err)
+		}
+
+		b, err := export.ReadBackup(bytes.NewReader(want))
+		if err != nil {
+			t.Fatalf("ReadBackup(%s) error = %v", path, err)
+		}
+		if b.Version != version {
+			t.Errorf("ReadBackup(%s) version = %d, want %d", path, b.Version, version)
+		}
+
+		var got bytes.Buffer
+		if err := export.WriteBackup(&got, b); err != nil {
+			t.Fatalf("WriteBackup() error = %v", err)
+		}
+		if version == export.BackupVersion && got.String() != string(want) {
+			t.Errorf("round trip of %s differs:\ngot:\n%s\nwant:\n%s", path, got.String(), want)
+		}
+	}
+}
+
+func TestWriteBackupIsDeterministic(t *testing.T) {
+	resolved := "2026-04-20"
+	b := &export.Backup{
+		Entries: []*store.SOAPData{
+			{Date: "2026-04-23", Sections: map[string]string{"prayer": "p", "observation": "o"}, SelectedVerses: []string{}},
+			{Date: "2026-04-22", Sections: map[string]string{}, SelectedVerses: []string{"John 3:16"}},
+		},
+		PrayerItems: []*store.PrayerItem{
+			{ID: 9, UserID: 1, Text: "second", Status: store.PrayerOpen, CreatedDate: "2026-04-22"},
+			{ID: 3, UserID: 1, Text: "first", Status: store.PrayerAnswered, CreatedDate: "2026-04-01", ResolvedDate: &resolved},
+		},
+	}
+
+	var first, second bytes.Buffer
+	if err := export.WriteBackup(&first, b); err != nil {
+		t.Fatalf("WriteBackup() error = %v", err)
+	}
+	if b.Entries[0].Date != "2026-04-23" || b.PrayerItems[0].ID != 9 {
+		t.Errorf("WriteBackup() modified its argument")
+	}
+	if err := export.WriteBackup(&second, b); err != nil {
+		t.Fatalf("WriteBackup() error = %v", err)
+	}
+	if first.String() != second.String() {
+		t.Errorf("WriteBackup() output differs between calls")
+	}
+	out := first.String()
+	if strings.Index(out, "2026-04-22") > strings.Index(out, "2026-04-23") {
+		t.Errorf("entries not written in date order:\n%s", out)
+	}
+	if strings.Index(out, `"first"`) > strings.Index(out, `"second"`) {
+		t.Errorf("prayer items not written in creation order:\n%s", out)
+	}
+	if !strings.Contains(out, `"version": 2`) {
+		t.Errorf("output missing version:\n%s", out)
+	}
+}
+
+func TestReadBackupRejectsUnknownVersions(t *testing.T) {
+	for _, doc := range []string{`{"entries": []}`, `{"version": 99}`} {
+		if _, err := export.ReadBackup(strings.NewReader(doc)); !errors.Is(err, export.ErrUnsupportedBackupVersion) {
+			t.Errorf("ReadBackup(%s) error = %v, want ErrUnsupportedBackupVersion", doc, err)
+		}
+	}
+	if _, err := export.ReadBackup(strings.NewReader("not json")); err == nil {
+		t.Errorf("ReadBackup(invalid JSON) error = nil, want error")
+	}
+}
@@ -0,0 +1,160 @@
+package export
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"derrclan.com/moravian-soap/internal/htmltext"
+	"derrclan.com/moravian-soap/internal/journal"
+	"derrclan.com/moravian-soap/internal/store"
+)
+
+// Entry types, used to choose which kinds of entries a multi-entry export includes.
+const (
+	// EntryDaily is an entry on a day's texts.
+	EntryDaily = "daily"
+	// EntryPassage is an entry on a passage of the user's choosing.
+	EntryPassage = "passage"
+)
+
+// Entry is a journal entry of either type in a multi-entry export.
+type Entry struct {
+	Type string `json:"type"`
+	// Date is the day of a daily entry, or the optional date of a passage entry.
+	Date string `json:"date,omitempty"`
+	// Reference is the passage the entry is written on.
+	Reference string            `json:"reference,omitempty"`
+	Sections  map[string]string `json:"sections"`
+	// Location and Weather are where a daily entry was written and the weather there, if it was
+	// stamped.
+	Location string `json:"location,omitempty"`
+	Weather  string `json:"weather,omitempty"`
+	// Scripture is the passage as HTML, or "" if the export leaves it out.
+	Scripture string `json:"scripture,omitempty"`
+	// CreatedAt is when a passage entry was started, which tells it apart from other entries on the
+	// same passage when it is imported again.
+	CreatedAt *time.Time `json:"createdAt,omitempty"`
+}
+
+// title returns the heading of the entry in a Markdown export.
+func (e *Entry) title() string {
+	switch {
+	case e.Type == EntryDaily || e.Reference == "":
+		return e.Date
+	case e.Date == "":
+		return e.Reference
+	default:
+		return fmt.Sprintf("%s (%s)", e.Reference, e.Date)
+	}
+}
+
+// WriteEntriesMarkdown writes the entries to w as one Markdown document, each formatted as by
+// MarkdownExporter and separated by horizontal rules.
+func WriteEntriesMarkdown(ctx context.Context, w io.Writer, entries []*Entry, schema journal.Schema) error {
+	exporter, err := NewMarkdownExporter()
+	if err != nil {
+		return err
+	}
+	for i, e := range entries {
+		if i > 0 {
+			if _, err := io.WriteString(w, "\n---\n\n"); err != nil {
+				return fmt.Errorf("writing separator: %w", err)
+			}
+		}
+		soapData := &store.SOAPData{Date: e.title(), Sections: e.Sections, Location: e.Location, Weather: e.Weather}
+		if err := exporter.Export(ctx, w, soapData, schema, e.Scripture); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteEntriesJSON writes the entries to w as a JSON array.
+func WriteEntriesJSON(w io.Writer, entries []*Entry) error {
+	if entries == nil {
+		entries = []*Entry{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(entries); err != nil {
+		return fmt.Errorf("encoding entries: %w", err)
+	}
+	return nil
+}
+
+// ReadEntriesJSON reads entries written by WriteEntriesJSON from r.
+func ReadEntriesJSON(r io.Reader) ([]*Entry, error) {
+	var entries []*Entry
+	if err := json.NewDecoder(r).Decode(&entries); err != nil {
+		return nil, fmt.Errorf("decoding entries: %w", err)
+	}
+	return entries, nil
+}
+
+// WriteEntriesCSV writes the entries to w as CSV, one row per entry. The columns are the entry's type,
+// date and reference, then one per section of schema, then any sections outside the schema that an
+// entry has, in the order first seen, then where the entry was written and the weather if any entry was
+// stamped, then the scripture as plain text if any entry includes it.
+func WriteEntriesCSV(w io.Writer, entries []*Entry, schema journal.Schema) error {
+	var sections []journal.Section
+	seen := make(map[string]bool)
+	scripture, stamped := false, false
+	for _, sec := range schema {
+		sections = append(sections, sec)
+		seen[sec.Key] = true
+	}
+	for _, e := range entries {
+		for _, entry := range schema.Entries(e.Sections) {
+			if !seen[entry.Key] {
+				sections = append(sections, entry.Section)
+				seen[entry.Key] = true
+			}
+		}
+		scripture = scripture || e.Scripture != ""
+		stamped = stamped || e.Location != "" || e.Weather != ""
+	}
+
+	cw := csv.NewWriter(w)
+	header := []string{"Type", "Date", "Reference"}
+	for _, sec := range sections {
+		header = append(header, sec.Name)
+	}
+	if stamped {
+		header = append(header, "Location", "Weather")
+	}
+	if scripture {
+		header = append(header, "Scripture")
+	}
+	if err := cw.Write(header); err != nil {
+		return fmt.Errorf("writing CSV header: %w", err)
+	}
+
+	for _, e := range entries {
+		row := []string{e.Type, e.Date, e.Reference}
+		for _, sec := range sections {
+			row = append(row, e.Sections[sec.Key])
+		}
+		if stamped {
+			row = append(row, e.Location, e.Weather)
+		}
+		if scripture {
+			text, err := htmltext.ToText(e.Scripture)
+			if err != nil {
+				return fmt.Errorf("converting scripture to text: %w", err)
+			}
+			row = append(row, text)
+		}
+		if err := cw.Write(row); err != nil {
+			return fmt.Errorf("writing CSV row: %w", err)
+		}
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return fmt.Errorf("writing CSV: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package export_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"slices"
+	"strings"
+	"testing"
+
+	"derrclan.com/moravian-soap/internal/export"
+	"derrclan.com/moravian-soap/internal/journal"
+)
+
+var testEntries = []*export.Entry{
+	{Type: export.EntryDaily, Date: "2026-04-23", Reference: "John 3:16", Sections: map[string]string{"observation": "Loved", "thoughts": "Old schema"}, Location: "Herrnhut", Weather: "Fog, 4°C (39°F)", Scripture: "<p>For God so loved the world.</p>"},
+	{Type: export.EntryPassage, Reference: "Psalm 23", Sections: map[string]string{"prayer": "Shepherd me"}},
+}
+
+func TestWriteEntriesCSV(t *testing.T) {
+	var buf bytes.Buffer
+	if err := export.WriteEntriesCSV(&buf, testEntries, journal.DefaultSchema()); err != nil {
+		t.Fatalf("WriteEntriesCSV() error = %v", err)
+	}
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("reading CSV: %v", err)
+	}
+	want := [][]string{
+		{"Type", "Date", "Reference", "Observation", "Application", "Prayer", "Thoughts", "Location", "Weather", "Scripture"},
+		{"daily", "2026-04-23", "John 3:16", "Loved", "", "", "Old schema", "Herrnhut", "Fog, 4°C (39°F)", "For God so loved the world."},
+		{"passage", "", "Psalm 23", "", "", "Shepherd me", "", "", "", ""},
+	}
+	if len(rows) != len(want) {
+		t.Fatalf("CSV has %d rows, want %d: %v", len(rows), len(want), rows)
+	}
+	for i := range want {
+		if !slices.Equal(rows[i], want[i]) {
+			t.Errorf("row %d = %q, want %q", i, rows[i], want[i])
+		}
+	}
+}
+
+func TestWriteEntriesJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := export.WriteEntriesJSON(&buf, testEntries); err != nil {
+		t.Fatalf("WriteEntriesJSON() error = %v", err)
+	}
+	got, err := export.ReadEntriesJSON(&buf)
+	if err != nil {
+		t.Fatalf("ReadEntriesJSON() error = %v", err)
+	}
+	if len(got) != 2 || got[1].Type != export.EntryPassage || got[1].Sections["prayer"] != "Shepherd me" || got[1].Scripture != "" {
+		t.Errorf("round trip = %+v", got)
+	}
+
+	buf.Reset()
+	if err := export.WriteEntriesJSON(&buf, nil); err != nil || strings.TrimSpace(buf.String()) != "[]" {
+		t.Errorf("WriteEntriesJSON(nil) = %q, %v; want []", buf.String(), err)
+	}
+}
+
+func TestWriteEntriesMarkdown(t *testing.T) {
+	var buf bytes.Buffer
+	if err := export.WriteEntriesMarkdown(context.Background(), &buf, testEntries, journal.DefaultSchema()); err != nil {
+		t.Fatalf("WriteEntriesMarkdown() error = %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{"# SOAP Journal Entry - 2026-04-23\n\n_Herrnhut · Fog, 4°C (39°F)_\n", "# SOAP Journal Entry - Psalm 23\n\n## Scripture", "\n---\n", "Shepherd me"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
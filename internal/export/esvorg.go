@@ -0,0 +1,95 @@
+package export
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ESVHighlight is a passage highlighted in an ESV.org account, as read from the account's export.
+type ESVHighlight struct {
+	// Row is the highlight's row in the export, counting from 1 after the header.
+	Row int
+	// Reference is the passage as ESV.org wrote it, such as "John 3:16–18".
+	Reference string
+	// Note is the note attached to the highlight, or "" if there is none.
+	Note string
+	// Date is the day (YYYY-MM-DD) of the highlight, or "" if the export does not say.
+	Date string
+}
+
+// esvColumns are the names, in lower case, that an ESV.org export may give the columns read from it.
+var esvColumns = map[string][]string{
+	"reference": {"reference", "passage", "verse", "verses"},
+	"note":      {"note", "notes"},
+	"date":      {"date", "created", "created at", "created_at", "date created"},
+}
+
+// ReadESVHighlights reads the highlights and notes exported from an ESV.org account as CSV. Columns are
+// found by the names in the header rather than their order, and only the reference is required; rows
+// without one are skipped.
+func ReadESVHighlights(r io.Reader) ([]*ESVHighlight, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	header, err := cr.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, errors.New("export is empty")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading header: %w", err)
+	}
+	columns := make(map[string]int)
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		for column, names := range esvColumns {
+			if _, ok := columns[column]; !ok && slices.Contains(names, name) {
+				columns[column] = i
+			}
+		}
+	}
+	if _, ok := columns["reference"]; !ok {
+		return nil, errors.New("export has no reference column")
+	}
+	field := func(record []string, column string) string {
+		if i, ok := columns[column]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	var highlights []*ESVHighlight
+	for row := 1; ; row++ {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			return highlights, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading row %d: %w", row, err)
+		}
+		h := &ESVHighlight{Row: row, Reference: field(record, "reference"), Note: field(record, "note")}
+		if h.Reference == "" {
+			continue
+		}
+		h.Date = parseESVDate(field(record, "date"))
+		highlights = append(highlights, h)
+	}
+}
+
+// parseESVDate returns the day of a date or timestamp in an ESV.org export, or "" if s is not one.
+func parseESVDate(s string) string {
+	for _, layout := range []string{time.RFC3339, time.DateTime, time.DateOnly, "1/2/2006", "January 2, 2006"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.Format(time.DateOnly)
+		}
+	}
+	if len(s) > len(time.DateOnly) {
+		if t, err := time.Parse(time.DateOnly, s[:len(time.DateOnly)]); err == nil {
+			return t.Format(time.DateOnly)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,39 @@
+package export_test
+
+import (
+	"strings"
+	"testing"
+
+	"derrclan.com/moravian-soap/internal/export"
+)
+
+func TestReadESVHighlights(t *testing.T) {
+	const csv = "\ufeffCreated,Passage,Color,Note\n" +
+		"2025-12-24T08:15:00Z,John 3:16–18,yellow,\"For God so loved, \"\"the world\"\"\"\n" +
+		"3/1/2026,Psalm 23:1,blue\n" +
+		",,green,A note without a passage\n" +
+		"someday,Romans 8:28,yellow,\n"
+	highlights, err := export.ReadESVHighlights(strings.NewReader(csv))
+	if err != nil {
+		t.Fatalf("ReadESVHighlights() error = %v", err)
+	}
+	want := []export.ESVHighlight{
+		{Row: 1, Reference: "John 3:16–18", Note: `For God so loved, "the world"`, Date: "2025-12-24"},
+		{Row: 2, Reference: "Psalm 23:1", Date: "2026-03-01"},
+		{Row: 4, Reference: "Romans 8:28"},
+	}
+	if len(highlights) != len(want) {
+		t.Fatalf("read %d highlights, want %d: %+v", len(highlights), len(want), highlights)
+	}
+	for i, h := range highlights {
+		if *h != want[i] {
+			t.Errorf("highlight %d = %+v, want %+v", i, *h, want[i])
+		}
+	}
+
+	for _, bad := range []string{"", "Date,Note\n2026-03-01,Hello\n"} {
+		if _, err := export.ReadESVHighlights(strings.NewReader(bad)); err == nil {
+			t.Errorf("ReadESVHighlights(%q) succeeded, want an error", bad)
+		}
+	}
+}
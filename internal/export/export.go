@@ -5,15 +5,16 @@ import (
 	"context"
 	"io"
 
+	"derrclan.com/moravian-soap/internal/journal"
 	"derrclan.com/moravian-soap/internal/store"
 )
 
 // Exporter defines the interface for exporting SOAP entries in different formats
 // (e.g., HTML, Markdown).
 type Exporter interface {
-	// Export writes the formatted SOAP entry to the provided writer.
-	// The scripture parameter should contain the pre-fetched scripture content.
-	Export(ctx context.Context, w io.Writer, entry *store.SOAPData, scripture string) error
+	// Export writes the formatted SOAP entry to the provided writer, with its sections in the order
+	// given by schema. The scripture parameter should contain the pre-fetched scripture content.
+	Export(ctx context.Context, w io.Writer, entry *store.SOAPData, schema journal.Schema, scripture string) error
 	// ContentType returns the MIME type of the exported content (e.g., "text/html").
 	ContentType() string
 }
@@ -7,6 +7,7 @@ import (
 	"testing"
 
 	"derrclan.com/moravian-soap/internal/export"
+	"derrclan.com/moravian-soap/internal/journal"
 	"derrclan.com/moravian-soap/internal/store"
 )
 
@@ -17,15 +18,17 @@ func TestHTMLExporter(t *testing.T) {
 	}
 
 	entry := &store.SOAPData{
-		Date:        "2026-04-23",
-		Observation: "Good observation",
-		Application: "Practical application",
-		Prayer:      "Sincere prayer",
+		Date: "2026-04-23",
+		Sections: map[string]string{
+			"observation": "Good observation",
+			"application": "Practical application",
+			"prayer":      "Sincere prayer",
+		},
 	}
 	scripture := "<p>John 3:16 - For God so loved the world...</p>"
 
 	var buf bytes.Buffer
-	err = exporter.Export(context.Background(), &buf, entry, scripture)
+	err = exporter.Export(context.Background(), &buf, entry, journal.DefaultSchema(), scripture)
 	if err != nil {
 		t.Fatalf("failed to export HTML: %v", err)
 	}
@@ -52,12 +55,12 @@ func TestHTMLExporter_Escaping(t *testing.T) {
 	}
 
 	entry := &store.SOAPData{
-		Observation: "Rock & Roll <script>alert(1)</script>",
+		Sections: map[string]string{"observation": "Rock & Roll <script>alert(1)</script>"},
 	}
 	scripture := "<b>John 3:16</b>"
 
 	var buf bytes.Buffer
-	err = exporter.Export(context.Background(), &buf, entry, scripture)
+	err = exporter.Export(context.Background(), &buf, entry, journal.DefaultSchema(), scripture)
 	if err != nil {
 		t.Fatalf("failed to export HTML: %v", err)
 	}
@@ -79,6 +82,32 @@ func TestHTMLExporter_Escaping(t *testing.T) {
 	}
 }
 
+func TestHTMLExporter_RenderMarkdown(t *testing.T) {
+	exporter, err := export.NewHTMLExporter()
+	if err != nil {
+		t.Fatalf("failed to create HTMLExporter: %v", err)
+	}
+	exporter.RenderMarkdown = true
+
+	entry := &store.SOAPData{
+		Sections: map[string]string{"observation": "He is **risen**.\n\n<script>alert(1)</script>"},
+	}
+
+	var buf bytes.Buffer
+	err = exporter.Export(context.Background(), &buf, entry, journal.DefaultSchema(), "")
+	if err != nil {
+		t.Fatalf("failed to export HTML: %v", err)
+	}
+
+	output := buf.String()
+	if !strings.Contains(output, "<p>He is <strong>risen</strong>.</p>") {
+		t.Errorf("observation not rendered as Markdown: %s", output)
+	}
+	if strings.Contains(output, "<script>") {
+		t.Errorf("observation not escaped: <script>")
+	}
+}
+
 func TestMarkdownExporter(t *testing.T) {
 	exporter, err := export.NewMarkdownExporter()
 	if err != nil {
@@ -86,15 +115,17 @@ func TestMarkdownExporter(t *testing.T) {
 	}
 
 	entry := &store.SOAPData{
-		Date:        "2026-04-23",
-		Observation: "Good observation",
-		Application: "Practical application",
-		Prayer:      "Sincere prayer",
+		Date: "2026-04-23",
+		Sections: map[string]string{
+			"observation": "Good observation",
+			"application": "Practical application",
+			"prayer":      "Sincere prayer",
+		},
 	}
 	scripture := "John 3:16 - For God so loved the world..."
 
 	var buf bytes.Buffer
-	err = exporter.Export(context.Background(), &buf, entry, scripture)
+	err = exporter.Export(context.Background(), &buf, entry, journal.DefaultSchema(), scripture)
 	if err != nil {
 		t.Fatalf("failed to export Markdown: %v", err)
 	}
@@ -106,10 +137,35 @@ func TestMarkdownExporter(t *testing.T) {
 	if !strings.Contains(output, scripture) {
 		t.Errorf("output missing scripture")
 	}
-	if !strings.Contains(output, "## Observation\nGood observation") {
-		t.Errorf("output missing observation")
+	if !strings.Contains(output, "## Observation\nGood observation\n\n## Application\nPractical application") {
+		t.Errorf("output missing observation: %s", output)
 	}
 	if exporter.ContentType() != "text/markdown" {
 		t.Errorf("incorrect content type: %s", exporter.ContentType())
 	}
 }
+
+func TestMarkdownExporter_Schema(t *testing.T) {
+	exporter, err := export.NewMarkdownExporter()
+	if err != nil {
+		t.Fatalf("failed to create MarkdownExporter: %v", err)
+	}
+
+	entry := &store.SOAPData{
+		Date:     "2026-04-23",
+		Sections: map[string]string{"adoration": "Holy", "supplication": "Help", "observation": "Earlier"},
+	}
+
+	var buf bytes.Buffer
+	if err := exporter.Export(context.Background(), &buf, entry, journal.Presets["acts"], ""); err != nil {
+		t.Fatalf("failed to export Markdown: %v", err)
+	}
+
+	output := buf.String()
+	adoration := strings.Index(output, "## Adoration\nHoly")
+	supplication := strings.Index(output, "## Supplication\nHelp")
+	observation := strings.Index(output, "## Observation\nEarlier")
+	if adoration < 0 || supplication < adoration || observation < supplication {
+		t.Errorf("sections missing or out of order: %s", output)
+	}
+}
@@ -7,12 +7,16 @@ import (
 	"html/template"
 	"io"
 
+	"derrclan.com/moravian-soap/internal/journal"
+	"derrclan.com/moravian-soap/internal/markdown"
 	"derrclan.com/moravian-soap/internal/store"
 )
 
 // HTMLExporter implements the Exporter interface for HTML format.
 type HTMLExporter struct {
 	tmpl *template.Template
+	// RenderMarkdown renders the sections of entries as sanitized Markdown rather than as plain text.
+	RenderMarkdown bool
 }
 
 const htmlTemplate = `
@@ -27,6 +31,7 @@ const htmlTemplate = `
         h2 { color: #555; margin-top: 30px; }
         .section { margin-bottom: 20px; }
         .scripture { font-style: italic; background: #f9f9f9; padding: 15px; border-left: 5px solid #ccc; }
+        .stamp { color: #777; font-size: 0.9em; }
         @media print {
             body { margin: 0; padding: 0; }
             .no-print { display: none; }
@@ -36,55 +41,56 @@ const htmlTemplate = `
 <body>
     <h1>SOAP Journal Entry</h1>
     <p><strong>Date:</strong> {{.Date}}</p>
+    {{with .Stamp}}<p class="stamp">{{.}}</p>{{end}}
 
     <div class="section">
         <h2>Scripture</h2>
-        <div class="scripture">
+        <div class="scripture" dir="auto">
             {{.Scripture}}
         </div>
     </div>
 
+    {{range .Sections}}
     <div class="section">
-        <h2>Observation</h2>
-        <p>{{.Observation}}</p>
-    </div>
-
-    <div class="section">
-        <h2>Application</h2>
-        <p>{{.Application}}</p>
-    </div>
-
-    <div class="section">
-        <h2>Prayer</h2>
-        <p>{{.Prayer}}</p>
+        <h2>{{.Name}}</h2>
+        <div dir="auto">{{content .Content}}</div>
     </div>
+    {{end}}
 </body>
 </html>
 `
 
 // NewHTMLExporter creates a new HTMLExporter.
 func NewHTMLExporter() (*HTMLExporter, error) {
-	tmpl, err := template.New("soap-html").Parse(htmlTemplate)
+	e := &HTMLExporter{}
+	tmpl, err := template.New("soap-html").Funcs(template.FuncMap{"content": e.content}).Parse(htmlTemplate)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse HTML template: %w", err)
 	}
-	return &HTMLExporter{tmpl: tmpl}, nil
+	e.tmpl = tmpl
+	return e, nil
+}
+
+// content renders the content of a section.
+func (e *HTMLExporter) content(s string) template.HTML {
+	if e.RenderMarkdown {
+		return markdown.Render(s)
+	}
+	return template.HTML("<p>" + template.HTMLEscapeString(s) + "</p>")
 }
 
 // Export writes the SOAP entry as HTML to the writer.
-func (e *HTMLExporter) Export(_ context.Context, w io.Writer, entry *store.SOAPData, scripture string) error {
+func (e *HTMLExporter) Export(_ context.Context, w io.Writer, entry *store.SOAPData, schema journal.Schema, scripture string) error {
 	data := struct {
-		Date        string
-		Scripture   template.HTML
-		Observation string
-		Application string
-		Prayer      string
+		Date      string
+		Stamp     string
+		Scripture template.HTML
+		Sections  []journal.Entry
 	}{
-		Date:        entry.Date,
-		Scripture:   template.HTML(scripture),
-		Observation: entry.Observation,
-		Application: entry.Application,
-		Prayer:      entry.Prayer,
+		Date:      entry.Date,
+		Stamp:     entry.Stamp(),
+		Scripture: template.HTML(scripture),
+		Sections:  schema.Entries(entry.Sections),
 	}
 	if err := e.tmpl.Execute(w, data); err != nil {
 		return fmt.Errorf("failed to execute HTML template: %w", err)
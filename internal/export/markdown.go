@@ -6,6 +6,7 @@ import (
 	"io"
 	"text/template"
 
+	"derrclan.com/moravian-soap/internal/journal"
 	"derrclan.com/moravian-soap/internal/store"
 )
 
@@ -15,19 +16,15 @@ type MarkdownExporter struct {
 }
 
 const markdownTemplate = `# SOAP Journal Entry - {{.Date}}
-
+{{with .Stamp}}
+_{{.}}_
+{{end}}
 ## Scripture
 {{.Scripture}}
-
-## Observation
-{{.Observation}}
-
-## Application
-{{.Application}}
-
-## Prayer
-{{.Prayer}}
-`
+{{range .Sections}}
+## {{.Name}}
+{{.Content}}
+{{end}}`
 
 // NewMarkdownExporter creates a new MarkdownExporter instance.
 func NewMarkdownExporter() (*MarkdownExporter, error) {
@@ -40,19 +37,17 @@ func NewMarkdownExporter() (*MarkdownExporter, error) {
 
 // Export writes the SOAP entry formatted as Markdown to the provided writer.
 // It assumes the scripture content is already in a format suitable for Markdown.
-func (e *MarkdownExporter) Export(_ context.Context, w io.Writer, entry *store.SOAPData, scripture string) error {
+func (e *MarkdownExporter) Export(_ context.Context, w io.Writer, entry *store.SOAPData, schema journal.Schema, scripture string) error {
 	data := struct {
-		Date        string
-		Scripture   string
-		Observation string
-		Application string
-		Prayer      string
+		Date      string
+		Stamp     string
+		Scripture string
+		Sections  []journal.Entry
 	}{
-		Date:        entry.Date,
-		Scripture:   scripture,
-		Observation: entry.Observation,
-		Application: entry.Application,
-		Prayer:      entry.Prayer,
+		Date:      entry.Date,
+		Stamp:     entry.Stamp(),
+		Scripture: scripture,
+		Sections:  schema.Entries(entry.Sections),
 	}
 	if err := e.tmpl.Execute(w, data); err != nil {
 		return fmt.Errorf("failed to execute markdown template: %w", err)
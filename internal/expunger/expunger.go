@@ -1,4 +1,5 @@
-// Package expunger provides a background service to remove expired entries from the ESV cache.
+// Package expunger provides a background service to remove expired and least recently used entries from
+// the ESV cache.
 package expunger
 
 import (
@@ -7,18 +8,21 @@ import (
 	"log/slog"
 	"time"
 
+	"derrclan.com/moravian-soap/internal/clock"
+	"derrclan.com/moravian-soap/internal/config"
+	"derrclan.com/moravian-soap/internal/events"
+	"derrclan.com/moravian-soap/internal/maintenance"
 	"derrclan.com/moravian-soap/internal/store"
 )
 
 // Start initializes the cache expunger service.
 // It runs an initial expunge immediately in a background goroutine and then schedules
-// it to run every 24 hours.
-func Start(ctx context.Context, s store.Store) {
+// it to run every 24 hours. The age of cache entries is measured with clk, and each expunge that
+// succeeds is published on bus.
+func Start(ctx context.Context, s store.CacheStore, clk clock.Clock, bus *events.Bus) {
 	go func() {
 		slog.Debug("starting initial cache expunge")
-		if err := Expunge(ctx, s); err != nil {
-			slog.Error("failed to expunge cache", "error", err)
-		}
+		maintenance.Run(func() { expunge(ctx, s, clk, bus) })
 
 		ticker := time.NewTicker(24 * time.Hour)
 		defer ticker.Stop()
@@ -27,9 +31,7 @@ func Start(ctx context.Context, s store.Store) {
 			select {
 			case <-ticker.C:
 				slog.Debug("starting scheduled cache expunge")
-				if err := Expunge(ctx, s); err != nil {
-					slog.Error("failed to expunge cache", "error", err)
-				}
+				maintenance.Run(func() { expunge(ctx, s, clk, bus) })
 			case <-ctx.Done():
 				slog.Info("stopping cache expunger service")
 				return
@@ -38,10 +40,50 @@ func Start(ctx context.Context, s store.Store) {
 	}()
 }
 
-// Expunge removes old and excess entries from the esv_cache table.
-func Expunge(ctx context.Context, s store.Store) error {
-	if err := s.ExpungeCache(ctx, 28*24*time.Hour, 500); err != nil {
+// expunge runs Expunge at the time told by clk, logging any failure and publishing a success on bus.
+func expunge(ctx context.Context, s store.CacheStore, clk clock.Clock, bus *events.Bus) {
+	now := clk.Now()
+	if err := Expunge(ctx, s, now); err != nil {
+		slog.Error("failed to expunge cache", "error", err)
+		return
+	}
+	bus.Publish(ctx, events.Event{Kind: events.CacheExpunged, At: now})
+}
+
+// evictBatchSize is the most cache entries evicted in one transaction, so a large eviction does not
+// hold the database's write lock for long.
+const evictBatchSize = 50
+
+// Expunge removes entries older than the configured TTL at now and those beyond the configured number
+// of entries from the esv_cache table, then evicts the least recently used entries until the cache fits
+// within the configured size.
+func Expunge(ctx context.Context, s store.CacheStore, now time.Time) error {
+	cfg := config.Current()
+	if err := s.ExpungeCache(ctx, now.Add(-cfg.ESVCacheTTL), cfg.ESVCacheMaxEntries); err != nil {
 		return fmt.Errorf("expunging cache: %w", err)
 	}
+	return Evict(ctx, s, cfg.ESVCacheMaxBytes)
+}
+
+// Evict removes the least recently used entries from the esv_cache table, a batch at a time, until their
+// total size is at most maxBytes. A maxBytes of zero means no limit.
+func Evict(ctx context.Context, s store.CacheStore, maxBytes int64) error {
+	if maxBytes <= 0 {
+		return nil
+	}
+	total := 0
+	for {
+		n, err := s.EvictCache(ctx, maxBytes, evictBatchSize)
+		if err != nil {
+			return fmt.Errorf("evicting cache entries: %w", err)
+		}
+		total += n
+		if n < evictBatchSize || ctx.Err() != nil {
+			break
+		}
+	}
+	if total > 0 {
+		slog.Info("evicted least recently used ESV cache entries", "removed_count", total, "max_bytes", maxBytes)
+	}
 	return nil
 }
@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"fmt"
 	"testing"
+	"time"
 
 	"derrclan.com/moravian-soap/internal/store/sqlite"
 	_ "github.com/mattn/go-sqlite3"
@@ -20,7 +21,9 @@ func setupTestDB(t *testing.T) *sql.DB {
 	CREATE TABLE esv_cache (
 		reference TEXT PRIMARY KEY,
 		content TEXT NOT NULL,
-		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		size_bytes INTEGER NOT NULL DEFAULT 0,
+		last_accessed DATETIME
 	);`
 	if _, err := db.Exec(query); err != nil {
 		t.Fatalf("failed to create table: %v", err)
@@ -49,7 +52,7 @@ func TestExpunge_TimeLimit(t *testing.T) {
 	}
 
 	// Run Expunge
-	if err := Expunge(ctx, s); err != nil {
+	if err := Expunge(ctx, s, time.Now()); err != nil {
 		t.Fatalf("Expunge failed: %v", err)
 	}
 
@@ -99,7 +102,7 @@ func TestExpunge_CountLimit(t *testing.T) {
 	}
 
 	// Run Expunge
-	if err := Expunge(ctx, s); err != nil {
+	if err := Expunge(ctx, s, time.Now()); err != nil {
 		t.Fatalf("Expunge failed: %v", err)
 	}
 
@@ -123,3 +126,58 @@ func TestExpunge_CountLimit(t *testing.T) {
 		t.Errorf("expected all 10 old records to be deleted, found %d", count)
 	}
 }
+
+func TestEvict_SizeLimit(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+	s := sqlite.New(db)
+	ctx := context.TODO()
+
+	// 120 entries of 1000 bytes each, accessed one second apart, more than two batches' worth.
+	for i := 0; i < 120; i++ {
+		_, err := db.Exec(fmt.Sprintf(`INSERT INTO esv_cache (reference, content, size_bytes, last_accessed) VALUES ('ref_%03d', 'content', 1000, datetime('now', '-1 days', '+%d seconds'))`, i, i))
+		if err != nil {
+			t.Fatalf("failed to insert record: %v", err)
+		}
+	}
+
+	if err := Evict(ctx, s, 10_500); err != nil {
+		t.Fatalf("Evict failed: %v", err)
+	}
+
+	var count int
+	var oldest string
+	err := db.QueryRow("SELECT COUNT(*), MIN(reference) FROM esv_cache").Scan(&count, &oldest)
+	if err != nil {
+		t.Fatalf("failed to query remaining records: %v", err)
+	}
+	if count != 10 {
+		t.Errorf("expected 10 records, got %d", count)
+	}
+	if oldest != "ref_110" {
+		t.Errorf("expected the 10 most recently used records to remain, oldest is %s", oldest)
+	}
+}
+
+func TestExpunge_AgesWithClock(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+	s := sqlite.New(db)
+	ctx := context.TODO()
+
+	if _, err := db.Exec(`INSERT INTO esv_cache (reference, content, created_at) VALUES ('recent', 'content', datetime('now', '-1 days'))`); err != nil {
+		t.Fatalf("failed to insert record: %v", err)
+	}
+
+	// A month later, the entry has outlived the cache's maximum age.
+	if err := Expunge(ctx, s, time.Now().AddDate(0, 1, 0)); err != nil {
+		t.Fatalf("Expunge failed: %v", err)
+	}
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM esv_cache").Scan(&count); err != nil {
+		t.Fatalf("failed to query count: %v", err)
+	}
+	if count != 0 {
+		t.Errorf("expected the aged entry to be expunged, got %d entries", count)
+	}
+}
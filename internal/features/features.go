@@ -0,0 +1,168 @@
+// Package features names the optional subsystems of the site, so that an instance can turn off those
+// it does not need, such as a single reader's instance without the API, reminders or passkeys.
+//
+// Each feature has a mode for the instance, set in the configuration. A feature that is on can be
+// turned off for a user, and one that is opt-in is off unless it is turned on for a user; a feature
+// that is off is off for everyone. Features are on unless configured otherwise, but for a few that
+// expose the site to the public and are off until the admin turns them on.
+package features
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Feature is an optional subsystem of the site.
+type Feature string
+
+// The optional subsystems.
+const (
+	API           Feature = "api"
+	Concordance   Feature = "concordance"
+	Devotions     Feature = "devotions"
+	Email         Feature = "email"
+	GuestComments Feature = "guest_comments"
+	Mentors       Feature = "mentors"
+	Passkeys      Feature = "passkeys"
+	Prayers       Feature = "prayers"
+	Psalter       Feature = "psalter"
+	QuietTime     Feature = "quiet_time"
+	Speech        Feature = "speech"
+	Stamps        Feature = "stamps"
+	Stats         Feature = "stats"
+)
+
+// descriptions describe the features, in the order they are listed.
+var descriptions = []struct {
+	feature     Feature
+	description string
+}{
+	{API, "API tokens and the JSON API"},
+	{Concordance, "The concordance of the daily texts"},
+	{Devotions, "Family devotion questions"},
+	{Email, "Inactivity reminders and the daily email"},
+	{GuestComments, "Comments from visitors on shared years in review"},
+	{Mentors, "Sharing entries with mentors who can comment on them"},
+	{Passkeys, "Signing in and unlocking with passkeys"},
+	{Prayers, "The prayer list"},
+	{Psalter, "Reading consecutively through the Psalms"},
+	{QuietTime, "The quiet time timer"},
+	{Speech, "Listening to journal entries read aloud"},
+	{Stamps, "Noting where entries were written and the weather there"},
+	{Stats, "Reading and journaling statistics"},
+}
+
+// All returns the features in alphabetical order.
+func All() []Feature {
+	all := make([]Feature, len(descriptions))
+	for i, d := range descriptions {
+		all[i] = d.feature
+	}
+	return all
+}
+
+// Valid reports whether f is a known feature.
+func (f Feature) Valid() bool {
+	return f.Description() != ""
+}
+
+// Description describes f for the admin, or is empty if f is not a known feature.
+func (f Feature) Description() string {
+	for _, d := range descriptions {
+		if d.feature == f {
+			return d.description
+		}
+	}
+	return ""
+}
+
+// Mode is whether a feature is available on the instance.
+type Mode string
+
+// The modes of a feature.
+const (
+	// On makes a feature available to every user but those it is turned off for.
+	On Mode = "on"
+	// OptIn makes a feature available only to the users it is turned on for.
+	OptIn Mode = "optin"
+	// Off makes a feature unavailable to everyone.
+	Off Mode = "off"
+)
+
+// defaults are the modes of the features that are not on by default.
+var defaults = map[Feature]Mode{
+	GuestComments: Off,
+}
+
+// Default returns the mode of f on an instance that does not configure it.
+func (f Feature) Default() Mode {
+	if mode, ok := defaults[f]; ok {
+		return mode
+	}
+	return On
+}
+
+// Modes are the modes of the features of an instance. Features without a mode have their default.
+type Modes map[Feature]Mode
+
+// ParseModes parses a comma-separated list of feature modes such as "api=off,psalter=optin".
+func ParseModes(s string) (Modes, error) {
+	modes := Modes{}
+	for item := range strings.SplitSeq(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		name, mode, ok := strings.Cut(item, "=")
+		if !ok {
+			return nil, fmt.Errorf("%q is not of the form feature=mode", item)
+		}
+		f := Feature(strings.TrimSpace(name))
+		if !f.Valid() {
+			return nil, fmt.Errorf("unknown feature %q", f)
+		}
+		switch m := Mode(strings.ToLower(strings.TrimSpace(mode))); m {
+		case On, OptIn, Off:
+			modes[f] = m
+		default:
+			return nil, fmt.Errorf("%s: mode must be on, optin or off, got %q", f, m)
+		}
+	}
+	return modes, nil
+}
+
+// Mode returns the mode of f.
+func (m Modes) Mode(f Feature) Mode {
+	if mode, ok := m[f]; ok {
+		return mode
+	}
+	return f.Default()
+}
+
+// Enabled reports whether f is available to a user with the given overrides, which turn features on
+// or off for the user by name.
+func (m Modes) Enabled(f Feature, overrides map[string]bool) bool {
+	switch m.Mode(f) {
+	case Off:
+		return false
+	case OptIn:
+		return overrides[string(f)]
+	default:
+		enabled, ok := overrides[string(f)]
+		return !ok || enabled
+	}
+}
+
+// String formats the modes that are not their features' defaults in the form read by ParseModes,
+// sorted by feature.
+func (m Modes) String() string {
+	var items []string
+	for f, mode := range m {
+		if mode != f.Default() {
+			items = append(items, string(f)+"="+string(mode))
+		}
+	}
+	slices.Sort(items)
+	return strings.Join(items, ",")
+}
@@ -0,0 +1,65 @@
+package features_test
+
+import (
+	"testing"
+
+	"derrclan.com/moravian-soap/internal/features"
+)
+
+func TestParseModes(t *testing.T) {
+	modes, err := features.ParseModes(" api=off,psalter = OPTIN,,stats=on")
+	if err != nil {
+		t.Fatalf("ParseModes failed: %v", err)
+	}
+	for f, want := range map[features.Feature]features.Mode{
+		features.API:           features.Off,
+		features.Psalter:       features.OptIn,
+		features.Stats:         features.On,
+		features.Passkeys:      features.On,
+		features.QuietTime:     features.On,
+		features.GuestComments: features.Off,
+	} {
+		if got := modes.Mode(f); got != want {
+			t.Errorf("Mode(%s) = %s, want %s", f, got, want)
+		}
+	}
+	if got, want := modes.String(), "api=off,psalter=optin"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+
+	// A feature off by default is listed once it is turned on.
+	on, err := features.ParseModes("guest_comments=on,api=on")
+	if err != nil {
+		t.Fatalf("ParseModes failed: %v", err)
+	}
+	if got, want := on.String(), "guest_comments=on"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+
+	for _, s := range []string{"groups=off", "api=maybe", "api"} {
+		if _, err := features.ParseModes(s); err == nil {
+			t.Errorf("ParseModes(%q) succeeded, want an error", s)
+		}
+	}
+}
+
+func TestEnabled(t *testing.T) {
+	modes := features.Modes{features.API: features.Off, features.Psalter: features.OptIn}
+	tests := []struct {
+		feature   features.Feature
+		overrides map[string]bool
+		want      bool
+	}{
+		{features.Stats, nil, true},
+		{features.Stats, map[string]bool{"stats": false}, false},
+		{features.Stats, map[string]bool{"stats": true}, true},
+		{features.Psalter, nil, false},
+		{features.Psalter, map[string]bool{"psalter": true}, true},
+		{features.API, map[string]bool{"api": true}, false},
+	}
+	for _, tt := range tests {
+		if got := modes.Enabled(tt.feature, tt.overrides); got != tt.want {
+			t.Errorf("Enabled(%s, %v) = %v, want %v", tt.feature, tt.overrides, got, tt.want)
+		}
+	}
+}
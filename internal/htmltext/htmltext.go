@@ -0,0 +1,182 @@
+// Package htmltext converts HTML, such as ESV passages or pasted rich text in journal entries, to plain text
+// for search indexing, excerpts and email digests.
+package htmltext
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// skipped lists elements whose content is never displayed as text.
+var skipped = map[atom.Atom]bool{
+	atom.Head:     true,
+	atom.Script:   true,
+	atom.Style:    true,
+	atom.Template: true,
+	atom.Noscript: true,
+}
+
+// blocks lists elements that start on a new line.
+var blocks = map[atom.Atom]bool{
+	atom.Address: true, atom.Article: true, atom.Aside: true, atom.Blockquote: true,
+	atom.Dd: true, atom.Div: true, atom.Dl: true, atom.Dt: true, atom.Figcaption: true,
+	atom.Figure: true, atom.Footer: true, atom.Form: true, atom.H1: true, atom.H2: true,
+	atom.H3: true, atom.H4: true, atom.H5: true, atom.H6: true, atom.Header: true,
+	atom.Hr: true, atom.Li: true, atom.Main: true, atom.Nav: true, atom.Ol: true,
+	atom.P: true, atom.Pre: true, atom.Section: true, atom.Table: true, atom.Tr: true,
+	atom.Ul: true,
+}
+
+// paragraphs lists block elements that are separated from surrounding text by a blank line.
+var paragraphs = map[atom.Atom]bool{
+	atom.Blockquote: true, atom.H1: true, atom.H2: true, atom.H3: true, atom.H4: true,
+	atom.H5: true, atom.H6: true, atom.P: true, atom.Pre: true, atom.Table: true,
+}
+
+// ToText converts an HTML document or fragment to plain text. Entities are decoded, scripts and styles
+// are dropped, block elements start new lines, paragraphs are separated by a single blank line, and runs
+// of whitespace within a line are collapsed to a single space.
+func ToText(htmlStr string) (string, error) {
+	nodes, err := html.ParseFragment(strings.NewReader(htmlStr), &html.Node{
+		Type:     html.ElementNode,
+		Data:     "body",
+		DataAtom: atom.Body,
+	})
+	if err != nil {
+		return "", fmt.Errorf("parsing HTML: %w", err)
+	}
+
+	w := &writer{}
+	for _, n := range nodes {
+		w.render(n)
+	}
+	return w.String(), nil
+}
+
+// Excerpt returns plain text shortened to at most maxRunes runes at a word boundary, with an ellipsis
+// appended if anything was cut. Line breaks are replaced by spaces.
+func Excerpt(text string, maxRunes int) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if utf8.RuneCountInString(text) <= maxRunes {
+		return text
+	}
+
+	cut := []rune(text)[:maxRunes]
+	if i := strings.LastIndexFunc(string(cut), unicode.IsSpace); i > 0 {
+		return strings.TrimRightFunc(string(cut)[:i], isPunctOrSpace) + "…"
+	}
+	return string(cut) + "…"
+}
+
+func isPunctOrSpace(r rune) bool {
+	return unicode.IsSpace(r) || unicode.IsPunct(r)
+}
+
+// writer accumulates text, tracking pending whitespace and line breaks so that they are only written
+// between pieces of text.
+type writer struct {
+	sb           strings.Builder
+	space        bool
+	newlines     int
+	preformatted bool
+}
+
+func (w *writer) render(n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		w.text(n.Data)
+		return
+	case html.ElementNode:
+	default:
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			w.render(c)
+		}
+		return
+	}
+
+	if skipped[n.DataAtom] {
+		return
+	}
+	if n.DataAtom == atom.Br {
+		w.breakLine(1)
+		return
+	}
+
+	switch {
+	case paragraphs[n.DataAtom]:
+		w.breakLine(2)
+	case blocks[n.DataAtom]:
+		w.breakLine(1)
+	case n.DataAtom == atom.Td || n.DataAtom == atom.Th:
+		w.space = true
+	}
+
+	pre := w.preformatted
+	if n.DataAtom == atom.Pre {
+		w.preformatted = true
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		w.render(c)
+	}
+	w.preformatted = pre
+
+	switch {
+	case paragraphs[n.DataAtom]:
+		w.breakLine(2)
+	case blocks[n.DataAtom]:
+		w.breakLine(1)
+	}
+}
+
+// text writes a text node, collapsing whitespace unless inside a <pre> element.
+func (w *writer) text(s string) {
+	if w.preformatted {
+		for i, line := range strings.Split(s, "\n") {
+			if i > 0 {
+				w.breakLine(1)
+			}
+			if line != "" {
+				w.flush()
+				w.sb.WriteString(line)
+			}
+		}
+		return
+	}
+
+	for _, r := range s {
+		if unicode.IsSpace(r) {
+			w.space = true
+			continue
+		}
+		w.flush()
+		w.sb.WriteRune(r)
+	}
+}
+
+// breakLine requests that the next text start after n line breaks.
+func (w *writer) breakLine(n int) {
+	w.newlines = max(w.newlines, n)
+}
+
+// flush writes pending whitespace before the next piece of text.
+func (w *writer) flush() {
+	if w.sb.Len() == 0 {
+		w.newlines, w.space = 0, false
+		return
+	}
+	if w.newlines > 0 {
+		w.sb.WriteString(strings.Repeat("\n", w.newlines))
+	} else if w.space {
+		w.sb.WriteByte(' ')
+	}
+	w.newlines, w.space = 0, false
+}
+
+func (w *writer) String() string {
+	return w.sb.String()
+}
@@ -0,0 +1,85 @@
+package htmltext_test
+
+import (
+	"testing"
+
+	"derrclan.com/moravian-soap/internal/htmltext"
+)
+
+func TestToText(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{
+			name: "plain text",
+			in:   "  Grace   and\npeace  ",
+			want: "Grace and peace",
+		},
+		{
+			name: "entities",
+			in:   "Faith &amp; works &mdash; &ldquo;together&rdquo; &#8220;always&#8221;",
+			want: "Faith & works — “together” “always”",
+		},
+		{
+			name: "paragraphs and headings",
+			in:   "<h2>Psalm 1</h2><p>Blessed is the man</p>\n\n<p>who walks not</p>",
+			want: "Psalm 1\n\nBlessed is the man\n\nwho walks not",
+		},
+		{
+			name: "line breaks and lists",
+			in:   "Pray for:<ul><li>Mom</li><li>Dad</li></ul>line<br>break",
+			want: "Pray for:\nMom\nDad\nline\nbreak",
+		},
+		{
+			name: "inline elements keep words together",
+			in:   `<p><b class="verse-num">16</b>&nbsp;For God so <i>loved</i> the world,</p>`,
+			want: "16 For God so loved the world,",
+		},
+		{
+			name: "scripts and styles dropped",
+			in:   "<style>p{color:red}</style><p>Visible</p><script>alert(1)</script>",
+			want: "Visible",
+		},
+		{
+			name: "preformatted text keeps lines",
+			in:   "<pre>line one\n  line two</pre>",
+			want: "line one\n  line two",
+		},
+		{
+			name: "malformed markup",
+			in:   "<p>unclosed <b>bold<p>next",
+			want: "unclosed bold\n\nnext",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := htmltext.ToText(tt.in)
+			if err != nil {
+				t.Fatalf("ToText failed: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("ToText() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExcerpt(t *testing.T) {
+	tests := []struct {
+		in   string
+		max  int
+		want string
+	}{
+		{"Short text", 20, "Short text"},
+		{"Blessed is the man who walks not in the counsel", 20, "Blessed is the man…"},
+		{"First line,\n\nsecond line", 17, "First line…"},
+		{"Supercalifragilistic", 5, "Super…"},
+	}
+	for _, tt := range tests {
+		if got := htmltext.Excerpt(tt.in, tt.max); got != tt.want {
+			t.Errorf("Excerpt(%q, %d) = %q, want %q", tt.in, tt.max, got, tt.want)
+		}
+	}
+}
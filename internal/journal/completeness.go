@@ -0,0 +1,41 @@
+package journal
+
+import "strings"
+
+// Completeness describes how much of a journal entry has been written.
+type Completeness string
+
+const (
+	// CompletenessNone is an entry with nothing written and no verses selected.
+	CompletenessNone Completeness = "none"
+	// CompletenessDraft is an entry with verses selected but nothing written yet.
+	CompletenessDraft Completeness = "draft"
+	// CompletenessPartial is an entry with something written, but not in every section of the schema.
+	CompletenessPartial Completeness = "partial"
+	// CompletenessComplete is an entry with every section of the schema written.
+	CompletenessComplete Completeness = "complete"
+)
+
+// Completeness returns how much of an entry with the given section contents and selected verses has
+// been written under the schema. Sections containing only whitespace count as empty, and writing in
+// sections outside the schema makes an entry partial at most.
+func (s Schema) Completeness(contents map[string]string, selectedVerses []string) Completeness {
+	written := 0
+	for _, content := range contents {
+		if strings.TrimSpace(content) != "" {
+			written++
+		}
+	}
+	if written == 0 {
+		if len(selectedVerses) == 0 {
+			return CompletenessNone
+		}
+		return CompletenessDraft
+	}
+	for _, sec := range s {
+		if strings.TrimSpace(contents[sec.Key]) == "" {
+			return CompletenessPartial
+		}
+	}
+	return CompletenessComplete
+}
@@ -0,0 +1,31 @@
+package journal_test
+
+import (
+	"testing"
+
+	"derrclan.com/moravian-soap/internal/journal"
+)
+
+func TestSchema_Completeness(t *testing.T) {
+	schema := journal.DefaultSchema()
+	tests := []struct {
+		name     string
+		contents map[string]string
+		verses   []string
+		want     journal.Completeness
+	}{
+		{"empty", nil, nil, journal.CompletenessNone},
+		{"blank sections", map[string]string{"observation": "  \n"}, nil, journal.CompletenessNone},
+		{"verses only", nil, []string{"43001001"}, journal.CompletenessDraft},
+		{"one section", map[string]string{"observation": "Light"}, nil, journal.CompletenessPartial},
+		{"other schema only", map[string]string{"adoration": "Praise"}, nil, journal.CompletenessPartial},
+		{"one section blank", map[string]string{"observation": "Light", "application": "Walk", "prayer": " "}, nil, journal.CompletenessPartial},
+		{"every section", map[string]string{"observation": "Light", "application": "Walk", "prayer": "Amen"}, nil, journal.CompletenessComplete},
+		{"every section and more", map[string]string{"observation": "Light", "application": "Walk", "prayer": "Amen", "adoration": "Praise"}, nil, journal.CompletenessComplete},
+	}
+	for _, tt := range tests {
+		if got := schema.Completeness(tt.contents, tt.verses); got != tt.want {
+			t.Errorf("%s: Completeness = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
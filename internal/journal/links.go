@@ -0,0 +1,81 @@
+// Package journal provides helpers for working with the contents of SOAP journal entries.
+package journal
+
+import (
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// explicitLinkRe matches explicit wiki-style links such as [[2025-03-03]].
+	explicitLinkRe = regexp.MustCompile(`\[\[(\d{4}-\d{2}-\d{2})\]\]`)
+
+	// phraseLinkRe matches references to earlier entries such as "as I wrote on March 3" or
+	// "I mentioned on Mar 3rd, 2025".
+	phraseLinkRe = regexp.MustCompile(`(?i)\b(?:wrote|written|journaled|mentioned)\s+on\s+` +
+		`(jan(?:uary)?|feb(?:ruary)?|mar(?:ch)?|apr(?:il)?|may|june?|july?|aug(?:ust)?|sep(?:t(?:ember)?)?|oct(?:ober)?|nov(?:ember)?|dec(?:ember)?)\.?` +
+		`\s+(\d{1,2})(?:st|nd|rd|th)?\b(?:,?\s+(\d{4})\b)?`)
+)
+
+// FindLinks returns the sorted, de-duplicated dates (YYYY-MM-DD) of other journal entries referenced in
+// text written on entryDate. Explicit [[YYYY-MM-DD]] links are always honored. Phrases such as "as I wrote
+// on March 3" without a year refer to the most recent such date on or before entryDate. Links from an
+// entry to itself are ignored.
+func FindLinks(entryDate, text string) []string {
+	entry, err := time.Parse(time.DateOnly, entryDate)
+	if err != nil {
+		return nil
+	}
+
+	var links []string
+	add := func(t time.Time) {
+		if d := t.Format(time.DateOnly); d != entryDate {
+			links = append(links, d)
+		}
+	}
+
+	for _, m := range explicitLinkRe.FindAllStringSubmatch(text, -1) {
+		if t, err := time.Parse(time.DateOnly, m[1]); err == nil {
+			add(t)
+		}
+	}
+
+	for _, m := range phraseLinkRe.FindAllStringSubmatch(text, -1) {
+		month := parseMonth(m[1])
+		day, _ := strconv.Atoi(m[2])
+		year := entry.Year()
+		if m[3] != "" {
+			year, _ = strconv.Atoi(m[3])
+		}
+
+		t := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+		if t.Day() != day {
+			// Not a real date, e.g. February 30.
+			continue
+		}
+		if m[3] == "" && t.After(entry) {
+			t = time.Date(year-1, month, day, 0, 0, 0, 0, time.UTC)
+			if t.Day() != day {
+				continue
+			}
+		}
+		add(t)
+	}
+
+	slices.Sort(links)
+	return slices.Compact(links)
+}
+
+// parseMonth returns the month named by a full or abbreviated English month name.
+func parseMonth(name string) time.Month {
+	prefix := strings.ToLower(name)[:3]
+	for m := time.January; m <= time.December; m++ {
+		if strings.ToLower(m.String())[:3] == prefix {
+			return m
+		}
+	}
+	return 0
+}
@@ -0,0 +1,75 @@
+package journal_test
+
+import (
+	"slices"
+	"testing"
+
+	"derrclan.com/moravian-soap/internal/journal"
+)
+
+func TestFindLinks(t *testing.T) {
+	tests := []struct {
+		name  string
+		date  string
+		text  string
+		links []string
+	}{
+		{
+			name:  "explicit link",
+			date:  "2025-06-01",
+			text:  "See [[2025-03-03]] for more.",
+			links: []string{"2025-03-03"},
+		},
+		{
+			name:  "phrase without year",
+			date:  "2025-06-01",
+			text:  "As I wrote on March 3, grace is sufficient.",
+			links: []string{"2025-03-03"},
+		},
+		{
+			name:  "phrase in the future refers to last year",
+			date:  "2025-02-01",
+			text:  "as I wrote on Dec 24th",
+			links: []string{"2024-12-24"},
+		},
+		{
+			name:  "phrase with year",
+			date:  "2025-06-01",
+			text:  "I journaled on Jan. 5, 2023 about this.",
+			links: []string{"2023-01-05"},
+		},
+		{
+			name:  "deduplicated and sorted",
+			date:  "2025-06-01",
+			text:  "[[2025-05-01]] and [[2025-03-03]]; as I wrote on March 3.",
+			links: []string{"2025-03-03", "2025-05-01"},
+		},
+		{
+			name:  "self link ignored",
+			date:  "2025-06-01",
+			text:  "[[2025-06-01]]",
+			links: nil,
+		},
+		{
+			name:  "invalid dates ignored",
+			date:  "2025-06-01",
+			text:  "[[2025-02-30]] and as I wrote on February 30",
+			links: nil,
+		},
+		{
+			name:  "unrelated dates ignored",
+			date:  "2025-06-01",
+			text:  "We met on March 3.",
+			links: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := journal.FindLinks(tt.date, tt.text)
+			if !slices.Equal(got, tt.links) {
+				t.Errorf("FindLinks() = %v, want %v", got, tt.links)
+			}
+		})
+	}
+}
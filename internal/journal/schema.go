@@ -0,0 +1,154 @@
+package journal
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// MaxSections is the largest number of sections a schema may have.
+const MaxSections = 10
+
+// sectionKeyRe matches valid section keys, which are stored in the database and used in form field names.
+var sectionKeyRe = regexp.MustCompile(`^[a-z][a-z0-9_]{0,31}$`)
+
+// Section is a named part of a journal entry, such as Observation or Prayer.
+type Section struct {
+	Key    string `json:"key"`
+	Name   string `json:"name"`
+	Prompt string `json:"prompt,omitempty"`
+}
+
+// Schema is the ordered list of sections a user writes for each entry.
+type Schema []Section
+
+// Presets are the built-in schemas, keyed by the name users choose them by.
+var Presets = map[string]Schema{
+	"soap": {
+		{Key: "observation", Name: "Observation", Prompt: "What do you observe in these verses?"},
+		{Key: "application", Name: "Application", Prompt: "How can you apply this to your life?"},
+		{Key: "prayer", Name: "Prayer", Prompt: "What is your prayer?"},
+	},
+	"acts": {
+		{Key: "adoration", Name: "Adoration", Prompt: "What do these verses show you to praise God for?"},
+		{Key: "confession", Name: "Confession", Prompt: "What do you need to confess?"},
+		{Key: "thanksgiving", Name: "Thanksgiving", Prompt: "What are you thankful for?"},
+		{Key: "supplication", Name: "Supplication", Prompt: "What do you ask of God for yourself and others?"},
+	},
+	"lectio": {
+		{Key: "lectio", Name: "Lectio", Prompt: "Which word or phrase stands out as you read?"},
+		{Key: "meditatio", Name: "Meditatio", Prompt: "What is God saying to you through it?"},
+		{Key: "oratio", Name: "Oratio", Prompt: "How do you respond in prayer?"},
+		{Key: "contemplatio", Name: "Contemplatio", Prompt: "Rest in God's presence. What remains with you?"},
+	},
+}
+
+// DefaultPreset is the name of the schema used by users who have not chosen one.
+const DefaultPreset = "soap"
+
+// DefaultSchema returns the schema used by users who have not chosen one.
+func DefaultSchema() Schema {
+	return Presets[DefaultPreset]
+}
+
+// ParseSchema decodes and validates a schema stored as JSON. An empty string yields the default schema.
+func ParseSchema(data string) (Schema, error) {
+	if strings.TrimSpace(data) == "" {
+		return DefaultSchema(), nil
+	}
+	var schema Schema
+	if err := json.Unmarshal([]byte(data), &schema); err != nil {
+		return nil, fmt.Errorf("decoding journal schema: %w", err)
+	}
+	if err := schema.Validate(); err != nil {
+		return nil, err
+	}
+	return schema, nil
+}
+
+// ValidSectionKey reports whether key may be used as a section key.
+func ValidSectionKey(key string) bool {
+	return sectionKeyRe.MatchString(key)
+}
+
+// Validate checks that the schema has between one and MaxSections sections, each with a unique
+// lowercase key and a name.
+func (s Schema) Validate() error {
+	if len(s) == 0 {
+		return errors.New("journal schema has no sections")
+	}
+	if len(s) > MaxSections {
+		return fmt.Errorf("journal schema has %d sections, the most allowed is %d", len(s), MaxSections)
+	}
+	seen := make(map[string]bool, len(s))
+	for _, sec := range s {
+		if !ValidSectionKey(sec.Key) {
+			return fmt.Errorf("invalid section key %q: use lowercase letters, digits and underscores", sec.Key)
+		}
+		if seen[sec.Key] {
+			return fmt.Errorf("duplicate section key %q", sec.Key)
+		}
+		seen[sec.Key] = true
+		if strings.TrimSpace(sec.Name) == "" {
+			return fmt.Errorf("section %q has no name", sec.Key)
+		}
+	}
+	return nil
+}
+
+// PresetName returns the name of the preset the schema matches, or "" if it is a custom schema.
+func (s Schema) PresetName() string {
+	for name, preset := range Presets {
+		if slices.Equal(s, preset) {
+			return name
+		}
+	}
+	return ""
+}
+
+// Entry is a section of a journal entry together with what was written in it.
+type Entry struct {
+	Section
+	Content string
+	// Extra is set for sections that are not part of the schema, such as those written under a
+	// schema the user has since replaced.
+	Extra bool
+}
+
+// Entries pairs the schema's sections with their contents, in schema order. Non-empty contents of
+// sections not in the schema follow in key order, so switching schemas never hides earlier writing.
+func (s Schema) Entries(contents map[string]string) []Entry {
+	entries := make([]Entry, 0, len(s))
+	inSchema := make(map[string]bool, len(s))
+	for _, sec := range s {
+		inSchema[sec.Key] = true
+		entries = append(entries, Entry{Section: sec, Content: contents[sec.Key]})
+	}
+
+	var extra []string
+	for key, content := range contents {
+		if !inSchema[key] && key != "" && content != "" {
+			extra = append(extra, key)
+		}
+	}
+	slices.Sort(extra)
+	for _, key := range extra {
+		entries = append(entries, Entry{Section: knownSection(key), Content: contents[key], Extra: true})
+	}
+	return entries
+}
+
+// knownSection returns the preset section with the given key, or a section named after the key.
+func knownSection(key string) Section {
+	for _, preset := range Presets {
+		for _, sec := range preset {
+			if sec.Key == key {
+				return sec
+			}
+		}
+	}
+	return Section{Key: key, Name: strings.ToUpper(key[:1]) + strings.ReplaceAll(key[1:], "_", " ")}
+}
@@ -0,0 +1,84 @@
+package journal_test
+
+import (
+	"strings"
+	"testing"
+
+	"derrclan.com/moravian-soap/internal/journal"
+)
+
+func TestParseSchema(t *testing.T) {
+	schema, err := journal.ParseSchema("")
+	if err != nil {
+		t.Fatalf("ParseSchema failed: %v", err)
+	}
+	if schema.PresetName() != journal.DefaultPreset {
+		t.Errorf("empty schema = %v, want the default", schema)
+	}
+
+	schema, err = journal.ParseSchema(`[{"key":"gratitude","name":"Gratitude"},{"key":"prayer","name":"Prayer","prompt":"Pray."}]`)
+	if err != nil {
+		t.Fatalf("ParseSchema failed: %v", err)
+	}
+	if len(schema) != 2 || schema[1].Prompt != "Pray." {
+		t.Errorf("unexpected schema: %v", schema)
+	}
+	if schema.PresetName() != "" {
+		t.Errorf("custom schema matched preset %q", schema.PresetName())
+	}
+
+	invalid := []struct {
+		name, data string
+	}{
+		{"not JSON", `observation`},
+		{"no sections", `[]`},
+		{"too many sections", `[` + strings.Repeat(`{"key":"a","name":"A"},`, journal.MaxSections) + `{"key":"b","name":"B"}]`},
+		{"duplicate key", `[{"key":"a","name":"A"},{"key":"a","name":"B"}]`},
+		{"invalid key", `[{"key":"Bad Key","name":"A"}]`},
+		{"missing name", `[{"key":"a","name":" "}]`},
+	}
+	for _, tt := range invalid {
+		if _, err := journal.ParseSchema(tt.data); err == nil {
+			t.Errorf("%s: expected error", tt.name)
+		}
+	}
+}
+
+func TestPresetsAreValid(t *testing.T) {
+	for name, schema := range journal.Presets {
+		if err := schema.Validate(); err != nil {
+			t.Errorf("preset %q: %v", name, err)
+		}
+		if schema.PresetName() != name {
+			t.Errorf("preset %q reports name %q", name, schema.PresetName())
+		}
+	}
+}
+
+func TestSchemaEntries(t *testing.T) {
+	schema := journal.Presets["acts"]
+	entries := schema.Entries(map[string]string{
+		"confession":  "Impatience.",
+		"observation": "Written before switching to ACTS.",
+		"my_notes":    "Custom section.",
+		"prayer":      "",
+	})
+
+	var keys []string
+	for _, e := range entries {
+		keys = append(keys, e.Key)
+	}
+	want := "adoration,confession,thanksgiving,supplication,my_notes,observation"
+	if got := strings.Join(keys, ","); got != want {
+		t.Fatalf("entry keys = %s, want %s", got, want)
+	}
+	if entries[1].Content != "Impatience." || entries[1].Extra {
+		t.Errorf("unexpected confession entry: %+v", entries[1])
+	}
+	if e := entries[4]; !e.Extra || e.Name != "My notes" {
+		t.Errorf("unexpected extra entry: %+v", e)
+	}
+	if e := entries[5]; e.Name != "Observation" || e.Content != "Written before switching to ACTS." {
+		t.Errorf("extra preset section lost its name: %+v", e)
+	}
+}
@@ -0,0 +1,84 @@
+// Package maintenance holds whether the instance is in maintenance mode, in which users are shown a
+// notice instead of the site and background jobs start no new work, so that the database can be
+// migrated or backed up while nothing is writing to it.
+package maintenance
+
+import (
+	"context"
+	"sync"
+)
+
+var (
+	mu sync.Mutex
+	on bool
+	// running counts the background jobs in progress, and idle is closed once the last of them ends.
+	running int
+	idle    chan struct{}
+)
+
+// Enabled reports whether maintenance mode is on.
+func Enabled() bool {
+	mu.Lock()
+	defer mu.Unlock()
+	return on
+}
+
+// Begin starts a run of a background job, unless maintenance mode is on, in which case ok is false and
+// the job should wait for its next run. A job that begins must call done when it finishes.
+func Begin() (done func(), ok bool) {
+	mu.Lock()
+	defer mu.Unlock()
+	if on {
+		return nil, false
+	}
+	running++
+	if running == 1 {
+		idle = make(chan struct{})
+	}
+	return sync.OnceFunc(func() {
+		mu.Lock()
+		defer mu.Unlock()
+		running--
+		if running == 0 {
+			close(idle)
+		}
+	}), true
+}
+
+// Run runs one run of a background job unless maintenance mode is on, and reports whether it ran.
+func Run(job func()) bool {
+	done, ok := Begin()
+	if !ok {
+		return false
+	}
+	defer done()
+	job()
+	return true
+}
+
+// Enable turns maintenance mode on and waits for the background jobs already running to finish. It
+// returns ctx's error if ctx is done first, leaving maintenance mode on.
+func Enable(ctx context.Context) error {
+	mu.Lock()
+	on = true
+	if running == 0 {
+		mu.Unlock()
+		return nil
+	}
+	wait := idle
+	mu.Unlock()
+
+	select {
+	case <-wait:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Disable turns maintenance mode off.
+func Disable() {
+	mu.Lock()
+	defer mu.Unlock()
+	on = false
+}
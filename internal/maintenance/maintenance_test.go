@@ -0,0 +1,49 @@
+package maintenance_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"derrclan.com/moravian-soap/internal/maintenance"
+)
+
+func TestEnableDrainsJobs(t *testing.T) {
+	t.Cleanup(maintenance.Disable)
+
+	done, ok := maintenance.Begin()
+	if !ok {
+		t.Fatal("Begin() refused a job outside maintenance mode")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := maintenance.Enable(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Enable() with a job running = %v, want the deadline", err)
+	}
+	if !maintenance.Enabled() {
+		t.Error("Enabled() = false after Enable timed out")
+	}
+	if _, ok := maintenance.Begin(); ok {
+		t.Error("Begin() started a job in maintenance mode")
+	}
+
+	drained := make(chan error)
+	go func() { drained <- maintenance.Enable(context.Background()) }()
+	done()
+	done() // Calling done twice counts the job once.
+	if err := <-drained; err != nil {
+		t.Fatalf("Enable() = %v once the job finished", err)
+	}
+
+	maintenance.Disable()
+	done, ok = maintenance.Begin()
+	if !ok {
+		t.Fatal("Begin() refused a job after Disable")
+	}
+	done()
+	if err := maintenance.Enable(context.Background()); err != nil {
+		t.Errorf("Enable() with no jobs running = %v", err)
+	}
+}
@@ -0,0 +1,144 @@
+// Package markdown renders the Markdown users write in their journal as sanitized HTML.
+//
+// Only a small subset of Markdown is supported: paragraphs and line breaks, headings, *emphasis*,
+// **strong** text, `code` and fenced code blocks, bulleted and numbered lists, block quotes, and
+// [links](https://example.com) to http, https and mailto URLs. The HTML is built from escaped text
+// and that allowlist of elements rather than passed through, so no markup a user writes, whether HTML
+// or a link to a javascript: URL, reaches the page.
+package markdown
+
+import (
+	"html"
+	"html/template"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+var (
+	headingRe    = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*\s*$`)
+	bulletRe     = regexp.MustCompile(`^\s{0,3}[-*+]\s+(.*)$`)
+	numberedRe   = regexp.MustCompile(`^\s{0,3}\d{1,9}[.)]\s+(.*)$`)
+	quoteRe      = regexp.MustCompile(`^\s{0,3}>\s?(.*)$`)
+	fenceRe      = regexp.MustCompile("^\\s{0,3}(```|~~~)")
+	continuedRe  = regexp.MustCompile(`^\s{2,}\S`)
+	inlineCodeRe = regexp.MustCompile("`([^`]+)`")
+	linkRe       = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)\)`)
+	strongRe     = regexp.MustCompile(`\*\*([^*]+)\*\*`)
+	emRe         = regexp.MustCompile(`\*([^*\s](?:[^*]*[^*\s])?)\*`)
+)
+
+// linkSchemes are the URL schemes that links may use.
+var linkSchemes = map[string]bool{"http": true, "https": true, "mailto": true}
+
+// Render renders src as sanitized HTML.
+func Render(src string) template.HTML {
+	lines := strings.Split(strings.ReplaceAll(src, "\r\n", "\n"), "\n")
+	var b strings.Builder
+	renderBlocks(&b, lines)
+	return template.HTML(b.String())
+}
+
+// renderBlocks renders lines as a sequence of block elements.
+func renderBlocks(b *strings.Builder, lines []string) {
+	for i := 0; i < len(lines); {
+		line := lines[i]
+		switch {
+		case strings.TrimSpace(line) == "":
+			i++
+		case fenceRe.MatchString(line):
+			fence := fenceRe.FindStringSubmatch(line)[1]
+			var code []string
+			for i++; i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), fence); i++ {
+				code = append(code, lines[i])
+			}
+			i++ // the closing fence
+			b.WriteString("<pre><code>")
+			b.WriteString(html.EscapeString(strings.Join(code, "\n")))
+			b.WriteString("</code></pre>\n")
+		case headingRe.MatchString(line):
+			m := headingRe.FindStringSubmatch(line)
+			// Entries are shown under their own headings, so the largest heading in an entry is an h3.
+			tag := []string{"h3", "h4", "h5", "h6", "h6", "h6"}[len(m[1])-1]
+			b.WriteString("<" + tag + ">" + renderInline(m[2]) + "</" + tag + ">\n")
+			i++
+		case quoteRe.MatchString(line):
+			var quoted []string
+			for ; i < len(lines) && quoteRe.MatchString(lines[i]); i++ {
+				quoted = append(quoted, quoteRe.FindStringSubmatch(lines[i])[1])
+			}
+			b.WriteString("<blockquote>\n")
+			renderBlocks(b, quoted)
+			b.WriteString("</blockquote>\n")
+		case bulletRe.MatchString(line):
+			i = renderList(b, lines, i, "ul", bulletRe)
+		case numberedRe.MatchString(line):
+			i = renderList(b, lines, i, "ol", numberedRe)
+		default:
+			var para []string
+			for ; i < len(lines) && strings.TrimSpace(lines[i]) != "" && !startsBlock(lines[i]); i++ {
+				para = append(para, renderInline(strings.TrimSpace(lines[i])))
+			}
+			b.WriteString("<p>" + strings.Join(para, "<br>\n") + "</p>\n")
+		}
+	}
+}
+
+// startsBlock reports whether line starts a block other than a paragraph, ending any paragraph before it.
+func startsBlock(line string) bool {
+	return fenceRe.MatchString(line) || headingRe.MatchString(line) || quoteRe.MatchString(line) ||
+		bulletRe.MatchString(line) || numberedRe.MatchString(line)
+}
+
+// renderList renders the list whose items match itemRe starting at lines[i], and returns the index of
+// the line after it. Indented lines continue the item before them.
+func renderList(b *strings.Builder, lines []string, i int, tag string, itemRe *regexp.Regexp) int {
+	b.WriteString("<" + tag + ">\n")
+	for i < len(lines) && itemRe.MatchString(lines[i]) {
+		item := []string{renderInline(itemRe.FindStringSubmatch(lines[i])[1])}
+		for i++; i < len(lines) && continuedRe.MatchString(lines[i]) && !startsBlock(lines[i]); i++ {
+			item = append(item, renderInline(strings.TrimSpace(lines[i])))
+		}
+		b.WriteString("<li>" + strings.Join(item, "<br>\n") + "</li>\n")
+	}
+	b.WriteString("</" + tag + ">\n")
+	return i
+}
+
+// renderInline renders the inline elements of s: code spans, links, strong text and emphasis, in that
+// order of precedence. Everything else is escaped.
+func renderInline(s string) string {
+	var b strings.Builder
+	for s != "" {
+		// Find the earliest element; of those starting at the same place, the first in precedence.
+		start, end := len(s), len(s)
+		var re *regexp.Regexp
+		for _, candidate := range []*regexp.Regexp{inlineCodeRe, linkRe, strongRe, emRe} {
+			if loc := candidate.FindStringIndex(s); loc != nil && loc[0] < start {
+				start, end, re = loc[0], loc[1], candidate
+			}
+		}
+		b.WriteString(html.EscapeString(s[:start]))
+		if re == nil {
+			break
+		}
+
+		m := re.FindStringSubmatch(s[start:end])
+		switch re {
+		case inlineCodeRe:
+			b.WriteString("<code>" + html.EscapeString(m[1]) + "</code>")
+		case linkRe:
+			if u, err := url.Parse(m[2]); err == nil && linkSchemes[strings.ToLower(u.Scheme)] {
+				b.WriteString(`<a href="` + html.EscapeString(u.String()) + `" rel="nofollow noopener noreferrer">` + renderInline(m[1]) + "</a>")
+			} else {
+				b.WriteString(html.EscapeString(m[0]))
+			}
+		case strongRe:
+			b.WriteString("<strong>" + renderInline(m[1]) + "</strong>")
+		case emRe:
+			b.WriteString("<em>" + renderInline(m[1]) + "</em>")
+		}
+		s = s[end:]
+	}
+	return b.String()
+}
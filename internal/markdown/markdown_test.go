@@ -0,0 +1,61 @@
+package markdown_test
+
+import (
+	"strings"
+	"testing"
+
+	"derrclan.com/moravian-soap/internal/markdown"
+)
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{"paragraphs", "First line\nsecond line\n\nNext paragraph", "<p>First line<br>\nsecond line</p>\n<p>Next paragraph</p>\n"},
+		{"emphasis", "He is *risen*, **indeed**.", "<p>He is <em>risen</em>, <strong>indeed</strong>.</p>\n"},
+		{"code", "Read `John 3:16` and `<b>`", "<p>Read <code>John 3:16</code> and <code>&lt;b&gt;</code></p>\n"},
+		{"heading", "# Thanks\n## Requests", "<h3>Thanks</h3>\n<h4>Requests</h4>\n"},
+		{"bullets", "- one\n* two\n  continued\n\nAfter", "<ul>\n<li>one</li>\n<li>two<br>\ncontinued</li>\n</ul>\n<p>After</p>\n"},
+		{"numbered", "1. one\n2) two", "<ol>\n<li>one</li>\n<li>two</li>\n</ol>\n"},
+		{"quote", "> The Lord is my shepherd\n> I shall not want", "<blockquote>\n<p>The Lord is my shepherd<br>\nI shall not want</p>\n</blockquote>\n"},
+		{"fence", "```\n<b>bold</b>\n```", "<pre><code>&lt;b&gt;bold&lt;/b&gt;</code></pre>\n"},
+		{"link", "[ESV](https://www.esv.org/)", `<p><a href="https://www.esv.org/" rel="nofollow noopener noreferrer">ESV</a></p>` + "\n"},
+		{"mailto link", "[Write](mailto:pastor@example.com)", `<p><a href="mailto:pastor@example.com" rel="nofollow noopener noreferrer">Write</a></p>` + "\n"},
+		{"stray asterisks", "2 * 3 * 4", "<p>2 * 3 * 4</p>\n"},
+		{"empty", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(markdown.Render(tt.src)); got != tt.want {
+				t.Errorf("Render(%q) = %q, want %q", tt.src, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRender_Unsafe(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+	}{
+		{"script", "<script>alert(1)</script>"},
+		{"event handler", `<img src=x onerror="alert(1)">`},
+		{"javascript link", "[click](javascript:alert(1))"},
+		{"data link", "[click](data:text/html;base64,PHNjcmlwdD4=)"},
+		{"attribute injection", `[click](https://example.com/"onmouseover="alert(1))`},
+		{"html in emphasis", "**<iframe src=x>**"},
+		{"html in link text", "[<script>alert(1)</script>](https://example.com/)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := string(markdown.Render(tt.src))
+			for _, unsafe := range []string{"<script", "<img", "<iframe", `href="javascript:`, `href="data:`, `"onmouseover=`} {
+				if strings.Contains(got, unsafe) {
+					t.Errorf("Render(%q) = %q, contains %q", tt.src, got, unsafe)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,193 @@
+// Package metrics emails the instance administrator a weekly report of how the instance is running:
+// the size of the database and ESV cache, ESV API quota use, errors logged, emails that could not be
+// delivered, and daily texts that still need installing.
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"derrclan.com/moravian-soap/internal/civil"
+	"derrclan.com/moravian-soap/internal/clock"
+	"derrclan.com/moravian-soap/internal/config"
+	"derrclan.com/moravian-soap/internal/dailytexts"
+	"derrclan.com/moravian-soap/internal/email"
+	"derrclan.com/moravian-soap/internal/esv/cache"
+	"derrclan.com/moravian-soap/internal/maintenance"
+	"derrclan.com/moravian-soap/internal/store"
+)
+
+// reportInterval is how often the admin is sent a report.
+const reportInterval = 7 * 24 * time.Hour
+
+// missingYearNotice is how long before a year begins the report warns that its daily texts are missing.
+const missingYearNotice = 90 * 24 * time.Hour
+
+// sentAtSetting is the instance setting recording when the last report was sent.
+const sentAtSetting = "metrics_report_sent_at"
+
+// errorCount counts the errors logged since the process started.
+var errorCount atomic.Int64
+
+// CountErrors returns a handler that counts the records logged at error level or above before passing
+// them to h, so that the report can tell the admin how many errors were logged.
+func CountErrors(h slog.Handler) slog.Handler {
+	return errorCounter{h}
+}
+
+// errorCounter is a slog.Handler counting the errors it handles.
+type errorCounter struct {
+	slog.Handler
+}
+
+func (c errorCounter) Handle(ctx context.Context, r slog.Record) error {
+	if r.Level >= slog.LevelError {
+		errorCount.Add(1)
+	}
+	return c.Handler.Handle(ctx, r)
+}
+
+func (c errorCounter) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return errorCounter{c.Handler.WithAttrs(attrs)}
+}
+
+func (c errorCounter) WithGroup(name string) slog.Handler {
+	return errorCounter{c.Handler.WithGroup(name)}
+}
+
+// counters are the process's running counts, as of the last report.
+type counters struct {
+	errors, hits, misses int64
+}
+
+var (
+	lastMu sync.Mutex
+	last   counters
+)
+
+// current returns the process's running counts.
+func current() counters {
+	hits, misses := cache.Stats()
+	return counters{errors: errorCount.Load(), hits: hits, misses: misses}
+}
+
+// Collect reports on the instance from since to now. Counts kept by the process rather than the store
+// cover the time since the last report, or since the process started if it has not sent one.
+func Collect(ctx context.Context, s store.Store, since, now time.Time) (*email.InstanceReport, error) {
+	m, err := s.GetInstanceMetrics(ctx, since)
+	if err != nil {
+		return nil, fmt.Errorf("getting instance metrics: %w", err)
+	}
+
+	lastMu.Lock()
+	prev := last
+	lastMu.Unlock()
+	c := current()
+
+	from, to := civil.DateOf(since.UTC()), civil.DateOf(now.UTC())
+	days := int(to.Time().Sub(from.Time())/(24*time.Hour)) + 1
+	r := &email.InstanceReport{
+		InstanceMetrics: *m,
+		From:            from.String(),
+		To:              to.String(),
+		CacheHits:       c.hits - prev.hits,
+		CacheMisses:     c.misses - prev.misses,
+		ESVQuota:        config.Current().ESVDailyQuota * days,
+		Errors:          c.errors - prev.errors,
+	}
+	year := now.Year()
+	if !dailytexts.HasYear(year) {
+		r.MissingYears = append(r.MissingYears, year)
+	}
+	if now.Add(missingYearNotice).Year() > year && !dailytexts.HasYear(year+1) {
+		r.MissingYears = append(r.MissingYears, year+1)
+	}
+	return r, nil
+}
+
+// SendDue queues a report to the admin configured by ADMIN_EMAIL if a week has passed at now since the
+// last one, linking to the instance at baseURL. The first report is sent a week after the instance
+// first checks, so that it covers a whole week.
+func SendDue(ctx context.Context, s store.Store, now time.Time, baseURL string) {
+	adminEmail := os.Getenv("ADMIN_EMAIL")
+	if adminEmail == "" {
+		return
+	}
+	settings, err := s.GetInstanceSettings(ctx)
+	if err != nil {
+		slog.Error("failed to get instance settings", "error", err)
+		return
+	}
+	sentAt, err := time.Parse(time.RFC3339, settings[sentAtSetting])
+	if err != nil {
+		markSent(ctx, s, now)
+		return
+	}
+	if now.Sub(sentAt) < reportInterval {
+		return
+	}
+
+	admin, err := s.GetUserByEmail(ctx, adminEmail)
+	if err != nil {
+		slog.Error("failed to get admin user for weekly report", "error", err)
+		return
+	}
+	c := current()
+	r, err := Collect(ctx, s, sentAt, now)
+	if err != nil {
+		slog.Error("failed to collect weekly report", "error", err)
+		return
+	}
+	msg := email.InstanceReportEmail(*r, strings.TrimSuffix(baseURL, "/")+"/admin/emails")
+	if err := email.Queue(ctx, s, admin.ID, admin.Email, msg); err != nil {
+		slog.Error("failed to queue weekly report", "error", err)
+		return
+	}
+	lastMu.Lock()
+	last = c
+	lastMu.Unlock()
+	markSent(ctx, s, now)
+	slog.Info("queued weekly report", "from", r.From, "to", r.To)
+}
+
+// markSent records that a report was sent, or that reports are due from, at now.
+func markSent(ctx context.Context, s store.Store, now time.Time) {
+	if err := s.SaveInstanceSettings(ctx, map[string]string{sentAtSetting: now.UTC().Format(time.RFC3339)}); err != nil {
+		slog.Error("failed to record weekly report sent", "error", err)
+	}
+}
+
+// Start checks every hour whether the weekly report is due at the time told by clk, until ctx is
+// cancelled.
+func Start(ctx context.Context, s store.Store, clk clock.Clock) {
+	go func() {
+		maintenance.Run(func() { SendDue(ctx, s, clk.Now(), baseURL()) })
+
+		ticker := time.NewTicker(time.Hour)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				maintenance.Run(func() { SendDue(ctx, s, clk.Now(), baseURL()) })
+			case <-ctx.Done():
+				slog.Info("stopping weekly report service")
+				return
+			}
+		}
+	}()
+}
+
+// baseURL returns the address of the instance that reports link to.
+func baseURL() string {
+	if u := os.Getenv("BASE_URL"); u != "" {
+		return u
+	}
+	return "http://localhost:8080"
+}
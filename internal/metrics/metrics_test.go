@@ -0,0 +1,89 @@
+package metrics_test
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"derrclan.com/moravian-soap/internal/metrics"
+	"derrclan.com/moravian-soap/internal/store"
+	"derrclan.com/moravian-soap/internal/store/sqlite"
+	"derrclan.com/moravian-soap/internal/testutil"
+)
+
+func TestSendDue(t *testing.T) {
+	t.Setenv("ADMIN_EMAIL", "admin@example.com")
+	db := testutil.NewDB(t)
+	s := sqlite.New(db)
+	ctx := context.Background()
+
+	if _, err := db.Exec(`INSERT INTO users (id, email, password_hash, is_verified, timezone) VALUES (1, 'admin@example.com', 'x', 1, 'UTC')`); err != nil {
+		t.Fatalf("failed to insert user: %v", err)
+	}
+
+	queued := func() []*store.QueuedEmail {
+		t.Helper()
+		emails, err := s.ListRecentEmails(ctx, 10)
+		if err != nil {
+			t.Fatalf("ListRecentEmails failed: %v", err)
+		}
+		return emails
+	}
+
+	// The first check starts the week the first report covers.
+	start := time.Date(2026, time.March, 2, 8, 0, 0, 0, time.UTC)
+	metrics.SendDue(ctx, s, start, "https://soap.example.com/")
+	metrics.SendDue(ctx, s, start.Add(6*24*time.Hour), "https://soap.example.com/")
+	if emails := queued(); len(emails) != 0 {
+		t.Fatalf("got %d reports within the first week, want none", len(emails))
+	}
+
+	metrics.SendDue(ctx, s, start.Add(7*24*time.Hour), "https://soap.example.com/")
+	metrics.SendDue(ctx, s, start.Add(7*24*time.Hour+time.Hour), "https://soap.example.com/")
+	emails := queued()
+	if len(emails) != 1 {
+		t.Fatalf("got %d reports, want 1", len(emails))
+	}
+	if emails[0].Recipient != "admin@example.com" || !strings.Contains(emails[0].Subject, "2026-03-02 to 2026-03-09") {
+		t.Errorf("unexpected report: %+v", emails[0])
+	}
+	var body string
+	if err := db.QueryRow("SELECT body_html FROM queued_emails").Scan(&body); err != nil {
+		t.Fatalf("failed to query report body: %v", err)
+	}
+	if !strings.Contains(body, "Database size") {
+		t.Errorf("report missing metrics: %s", body)
+	}
+}
+
+func TestCollect(t *testing.T) {
+	s := sqlite.New(testutil.NewDB(t))
+	ctx := context.Background()
+	now := time.Date(2026, time.March, 9, 8, 0, 0, 0, time.UTC)
+
+	before, err := metrics.Collect(ctx, s, now.Add(-7*24*time.Hour), now)
+	if err != nil {
+		t.Fatalf("Collect failed: %v", err)
+	}
+	logger := slog.New(metrics.CountErrors(slog.NewTextHandler(io.Discard, nil))).With("component", "test")
+	logger.Error("first")
+	logger.Warn("not an error")
+	logger.WithGroup("g").Error("second")
+
+	r, err := metrics.Collect(ctx, s, now.Add(-7*24*time.Hour), now)
+	if err != nil {
+		t.Fatalf("Collect failed: %v", err)
+	}
+	if got := r.Errors - before.Errors; got != 2 {
+		t.Errorf("counted %d errors, want 2", got)
+	}
+	if r.From != "2026-03-02" || r.To != "2026-03-09" {
+		t.Errorf("report covers %s to %s, want 2026-03-02 to 2026-03-09", r.From, r.To)
+	}
+	if r.ESVQuota != 8*5000 {
+		t.Errorf("ESVQuota = %d, want 8 days of the default quota", r.ESVQuota)
+	}
+}
@@ -0,0 +1,45 @@
+// Package middleware provides helpers for composing HTTP middleware and applying it to groups of routes.
+package middleware
+
+import "net/http"
+
+// Middleware wraps an http.Handler with additional behavior.
+type Middleware func(http.Handler) http.Handler
+
+// Chain composes middleware into a single Middleware. The first middleware is the outermost, so
+// Chain(a, b)(h) is equivalent to a(b(h)): a request passes through a, then b, then reaches h.
+func Chain(mws ...Middleware) Middleware {
+	return func(h http.Handler) http.Handler {
+		for i := len(mws) - 1; i >= 0; i-- {
+			h = mws[i](h)
+		}
+		return h
+	}
+}
+
+// Group registers routes on a ServeMux with a shared middleware stack.
+type Group struct {
+	mux *http.ServeMux
+	mws []Middleware
+}
+
+// NewGroup returns a group that registers routes on mux wrapped in the given middleware.
+func NewGroup(mux *http.ServeMux, mws ...Middleware) *Group {
+	return &Group{mux: mux, mws: mws}
+}
+
+// With returns a group that shares g's mux and wraps routes in g's middleware followed by mws.
+// Routes already registered on g are unaffected.
+func (g *Group) With(mws ...Middleware) *Group {
+	return &Group{mux: g.mux, mws: append(append([]Middleware{}, g.mws...), mws...)}
+}
+
+// Handle registers the handler for the pattern, wrapped in the group's middleware.
+func (g *Group) Handle(pattern string, h http.Handler) {
+	g.mux.Handle(pattern, Chain(g.mws...)(h))
+}
+
+// HandleFunc registers the handler function for the pattern, wrapped in the group's middleware.
+func (g *Group) HandleFunc(pattern string, h http.HandlerFunc) {
+	g.Handle(pattern, h)
+}
@@ -0,0 +1,94 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"derrclan.com/moravian-soap/internal/middleware"
+)
+
+// record returns middleware that appends name to calls before and "/"+name after calling the next handler.
+func record(calls *[]string, name string) middleware.Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			*calls = append(*calls, name)
+			next.ServeHTTP(w, r)
+			*calls = append(*calls, "/"+name)
+		})
+	}
+}
+
+// handler returns a handler that records that it was called.
+func handler(calls *[]string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*calls = append(*calls, "handler")
+	})
+}
+
+func TestChain_Order(t *testing.T) {
+	var calls []string
+	h := middleware.Chain(record(&calls, "a"), record(&calls, "b"), record(&calls, "c"))(handler(&calls))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	want := []string{"a", "b", "c", "handler", "/c", "/b", "/a"}
+	if !slices.Equal(calls, want) {
+		t.Errorf("calls = %v, want %v", calls, want)
+	}
+}
+
+func TestChain_Empty(t *testing.T) {
+	var calls []string
+	middleware.Chain()(handler(&calls)).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	if !slices.Equal(calls, []string{"handler"}) {
+		t.Errorf("calls = %v, want [handler]", calls)
+	}
+}
+
+func TestChain_ShortCircuit(t *testing.T) {
+	var calls []string
+	deny := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls = append(calls, "deny")
+			http.Error(w, "Forbidden", http.StatusForbidden)
+		})
+	}
+	h := middleware.Chain(record(&calls, "a"), deny, record(&calls, "b"))(handler(&calls))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("expected 403, got %d", rec.Code)
+	}
+	if want := []string{"a", "deny", "/a"}; !slices.Equal(calls, want) {
+		t.Errorf("calls = %v, want %v", calls, want)
+	}
+}
+
+func TestGroup(t *testing.T) {
+	var calls []string
+	mux := http.NewServeMux()
+	site := middleware.NewGroup(mux, record(&calls, "auth"))
+	admin := site.With(record(&calls, "admin"))
+	site.Handle("/page", handler(&calls))
+	admin.Handle("/admin", handler(&calls))
+
+	// Adding middleware to a derived group must not affect the parent group.
+	_ = site.With(record(&calls, "other"))
+
+	tests := []struct {
+		path string
+		want []string
+	}{
+		{"/page", []string{"auth", "handler", "/auth"}},
+		{"/admin", []string{"auth", "admin", "handler", "/admin", "/auth"}},
+	}
+	for _, tt := range tests {
+		calls = nil
+		mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if !slices.Equal(calls, tt.want) {
+			t.Errorf("%s: calls = %v, want %v", tt.path, calls, tt.want)
+		}
+	}
+}
@@ -0,0 +1,26 @@
+-- +goose Up
+CREATE TABLE api_tokens (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    name TEXT NOT NULL,
+    token_hash TEXT UNIQUE NOT NULL,
+    request_count INTEGER NOT NULL DEFAULT 0,
+    last_used_at DATETIME,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+CREATE INDEX idx_api_tokens_user_id ON api_tokens(user_id);
+
+-- ESV API requests attributed to the user whose page view caused them.
+-- A user_id of 0 means the request was not made on behalf of a user.
+CREATE TABLE esv_usage (
+    user_id INTEGER NOT NULL,
+    day TEXT NOT NULL,
+    requests INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (user_id, day)
+);
+
+-- +goose Down
+DROP TABLE esv_usage;
+DROP TABLE api_tokens;
@@ -0,0 +1,15 @@
+-- +goose Up
+CREATE TABLE prayer_items (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    text TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'open', -- open, answered, done
+    created_date TEXT NOT NULL,
+    resolved_date TEXT,
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+CREATE INDEX idx_prayer_items_user_dates ON prayer_items(user_id, created_date, resolved_date);
+
+-- +goose Down
+DROP TABLE prayer_items;
@@ -0,0 +1,13 @@
+-- +goose Up
+CREATE TABLE journal_links (
+    user_id INTEGER NOT NULL,
+    source_date TEXT NOT NULL,
+    target_date TEXT NOT NULL,
+    PRIMARY KEY (user_id, source_date, target_date),
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+CREATE INDEX idx_journal_links_target ON journal_links(user_id, target_date);
+
+-- +goose Down
+DROP TABLE journal_links;
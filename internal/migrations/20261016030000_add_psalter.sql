@@ -0,0 +1,18 @@
+-- +goose Up
+CREATE TABLE psalter_readers (
+    user_id INTEGER PRIMARY KEY,
+    started_date TEXT NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+CREATE TABLE psalter_readings (
+    user_id INTEGER NOT NULL,
+    date TEXT NOT NULL,
+    portion INTEGER NOT NULL,
+    PRIMARY KEY (user_id, date),
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+-- +goose Down
+DROP TABLE psalter_readings;
+DROP TABLE psalter_readers;
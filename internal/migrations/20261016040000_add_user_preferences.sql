@@ -0,0 +1,12 @@
+-- +goose Up
+CREATE TABLE user_preferences (
+    user_id INTEGER PRIMARY KEY,
+    esv_headings INTEGER NOT NULL DEFAULT 1,
+    esv_verse_numbers INTEGER NOT NULL DEFAULT 1,
+    esv_short_copyright INTEGER NOT NULL DEFAULT 1,
+    esv_indent_poetry INTEGER NOT NULL DEFAULT 1,
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+-- +goose Down
+DROP TABLE user_preferences;
@@ -0,0 +1,14 @@
+-- +goose Up
+CREATE TABLE journal_chapters (
+    user_id INTEGER NOT NULL,
+    date TEXT NOT NULL,
+    book INTEGER NOT NULL,
+    chapter INTEGER NOT NULL,
+    PRIMARY KEY (user_id, date, book, chapter),
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+CREATE INDEX idx_journal_chapters_chapter ON journal_chapters(user_id, book, chapter, date);
+
+-- +goose Down
+DROP TABLE journal_chapters;
@@ -0,0 +1,25 @@
+-- +goose Up
+CREATE TABLE webauthn_credentials (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    name TEXT NOT NULL,
+    credential_id BLOB UNIQUE NOT NULL,
+    public_key BLOB NOT NULL,
+    sign_count INTEGER NOT NULL DEFAULT 0,
+    last_used_at DATETIME,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+CREATE INDEX idx_webauthn_credentials_user ON webauthn_credentials(user_id);
+
+-- Challenges of registration (user_id set) and login (user_id 0) ceremonies in progress.
+CREATE TABLE webauthn_challenges (
+    challenge TEXT PRIMARY KEY,
+    user_id INTEGER NOT NULL DEFAULT 0,
+    expires_at DATETIME NOT NULL
+);
+
+-- +goose Down
+DROP TABLE webauthn_challenges;
+DROP TABLE webauthn_credentials;
@@ -0,0 +1,36 @@
+-- +goose Up
+CREATE TABLE journal_sections (
+    user_id INTEGER NOT NULL,
+    date TEXT NOT NULL,
+    section TEXT NOT NULL,
+    content TEXT NOT NULL,
+    PRIMARY KEY (user_id, date, section),
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+INSERT INTO journal_sections (user_id, date, section, content)
+SELECT user_id, date, 'observation', observation FROM journal WHERE observation != ''
+UNION ALL
+SELECT user_id, date, 'application', application FROM journal WHERE application != ''
+UNION ALL
+SELECT user_id, date, 'prayer', prayer FROM journal WHERE prayer != '';
+
+ALTER TABLE journal DROP COLUMN observation;
+ALTER TABLE journal DROP COLUMN application;
+ALTER TABLE journal DROP COLUMN prayer;
+
+ALTER TABLE user_preferences ADD COLUMN journal_schema TEXT NOT NULL DEFAULT '';
+
+-- +goose Down
+ALTER TABLE user_preferences DROP COLUMN journal_schema;
+
+ALTER TABLE journal ADD COLUMN observation TEXT NOT NULL DEFAULT '';
+ALTER TABLE journal ADD COLUMN application TEXT NOT NULL DEFAULT '';
+ALTER TABLE journal ADD COLUMN prayer TEXT NOT NULL DEFAULT '';
+
+UPDATE journal SET
+    observation = COALESCE((SELECT content FROM journal_sections s WHERE s.user_id = journal.user_id AND s.date = journal.date AND s.section = 'observation'), ''),
+    application = COALESCE((SELECT content FROM journal_sections s WHERE s.user_id = journal.user_id AND s.date = journal.date AND s.section = 'application'), ''),
+    prayer = COALESCE((SELECT content FROM journal_sections s WHERE s.user_id = journal.user_id AND s.date = journal.date AND s.section = 'prayer'), '');
+
+DROP TABLE journal_sections;
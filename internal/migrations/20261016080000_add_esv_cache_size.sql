@@ -0,0 +1,9 @@
+-- +goose Up
+ALTER TABLE esv_cache ADD COLUMN size_bytes INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE esv_cache ADD COLUMN last_accessed DATETIME;
+
+UPDATE esv_cache SET size_bytes = length(CAST(content AS BLOB)), last_accessed = created_at;
+
+-- +goose Down
+ALTER TABLE esv_cache DROP COLUMN last_accessed;
+ALTER TABLE esv_cache DROP COLUMN size_bytes;
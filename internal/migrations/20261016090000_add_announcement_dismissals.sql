@@ -0,0 +1,11 @@
+-- +goose Up
+CREATE TABLE announcement_dismissals (
+    user_id INTEGER NOT NULL,
+    announcement_id TEXT NOT NULL,
+    dismissed_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, announcement_id),
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+-- +goose Down
+DROP TABLE announcement_dismissals;
@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE sessions ADD COLUMN last_active_at DATETIME;
+ALTER TABLE user_preferences ADD COLUMN lock_after_minutes INTEGER NOT NULL DEFAULT 0;
+
+-- +goose Down
+ALTER TABLE user_preferences DROP COLUMN lock_after_minutes;
+ALTER TABLE sessions DROP COLUMN last_active_at;
@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE psalter_readers DROP COLUMN started_date;
+
+-- +goose Down
+ALTER TABLE psalter_readers ADD COLUMN started_date TEXT NOT NULL DEFAULT '';
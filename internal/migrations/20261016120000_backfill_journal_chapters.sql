@@ -0,0 +1,14 @@
+-- +goose Up
+-- Entries saved before journal_chapters existed are related through the verses selected in them.
+-- Verse IDs are BBCCCVVV: book, chapter and verse.
+INSERT OR IGNORE INTO journal_chapters (user_id, date, book, chapter)
+SELECT j.user_id, j.date, CAST(v.value AS INTEGER) / 1000000, CAST(v.value AS INTEGER) / 1000 % 1000
+FROM journal j, json_each(j.selected_verses) v
+WHERE json_valid(j.selected_verses)
+    AND json_type(j.selected_verses) = 'array'
+    AND length(v.value) = 8
+    AND v.value NOT GLOB '*[^0-9]*';
+
+-- +goose Down
+-- Backfilled rows cannot be told apart from saved ones, so they are left in place.
+SELECT 1;
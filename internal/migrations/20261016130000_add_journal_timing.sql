@@ -0,0 +1,9 @@
+-- +goose Up
+ALTER TABLE journal ADD COLUMN started_at DATETIME;
+ALTER TABLE journal ADD COLUMN finished_at DATETIME;
+ALTER TABLE journal ADD COLUMN editing_seconds INTEGER NOT NULL DEFAULT 0;
+
+-- +goose Down
+ALTER TABLE journal DROP COLUMN editing_seconds;
+ALTER TABLE journal DROP COLUMN finished_at;
+ALTER TABLE journal DROP COLUMN started_at;
@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE user_preferences ADD COLUMN esv_red_letter INTEGER NOT NULL DEFAULT 0;
+
+-- +goose Down
+ALTER TABLE user_preferences DROP COLUMN esv_red_letter;
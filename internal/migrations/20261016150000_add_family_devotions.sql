@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE user_preferences ADD COLUMN devotions_audience TEXT NOT NULL DEFAULT '';
+
+-- +goose Down
+ALTER TABLE user_preferences DROP COLUMN devotions_audience;
@@ -0,0 +1,9 @@
+-- +goose Up
+CREATE TABLE email_suppressions (
+    address TEXT PRIMARY KEY COLLATE NOCASE,
+    reason TEXT NOT NULL, -- bounce, complaint
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+-- +goose Down
+DROP TABLE email_suppressions;
@@ -0,0 +1,15 @@
+-- +goose Up
+CREATE TABLE daily_post_channels (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    kind TEXT NOT NULL, -- discord, slack
+    webhook_url TEXT NOT NULL,
+    template TEXT NOT NULL DEFAULT '',
+    post_time TEXT NOT NULL DEFAULT '07:00', -- HH:MM in the channel's timezone
+    timezone TEXT NOT NULL DEFAULT 'UTC',
+    last_posted_date TEXT NOT NULL DEFAULT '',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+-- +goose Down
+DROP TABLE daily_post_channels;
@@ -0,0 +1,12 @@
+-- +goose Up
+CREATE TABLE verse_notes (
+    user_id INTEGER NOT NULL,
+    verse_ref TEXT NOT NULL, -- 8-digit verse reference, e.g. 43003016
+    note TEXT NOT NULL,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, verse_ref),
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+-- +goose Down
+DROP TABLE verse_notes;
@@ -0,0 +1,9 @@
+-- +goose Up
+CREATE TABLE instance_settings (
+    key TEXT PRIMARY KEY, -- e.g. theme, custom_css
+    value TEXT NOT NULL,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+-- +goose Down
+DROP TABLE instance_settings;
@@ -0,0 +1,26 @@
+-- +goose Up
+ALTER TABLE journal ADD COLUMN completeness TEXT NOT NULL DEFAULT 'none'; -- none, draft, partial or complete
+
+-- Entries saved before completeness was tracked are measured against the user's schema, or the default
+-- SOAP sections for users who have not chosen one.
+UPDATE journal SET completeness = CASE
+    WHEN NOT EXISTS (
+        SELECT 1 FROM journal_sections s
+        WHERE s.user_id = journal.user_id AND s.date = journal.date AND trim(s.content, char(9, 10, 13, 32)) != ''
+    ) THEN CASE WHEN COALESCE(selected_verses, '') IN ('', '[]', 'null') THEN 'none' ELSE 'draft' END
+    WHEN NOT EXISTS (
+        SELECT 1 FROM json_each(COALESCE(
+            NULLIF((SELECT p.journal_schema FROM user_preferences p WHERE p.user_id = journal.user_id), ''),
+            '[{"key":"observation"},{"key":"application"},{"key":"prayer"}]'
+        )) k
+        WHERE NOT EXISTS (
+            SELECT 1 FROM journal_sections s
+            WHERE s.user_id = journal.user_id AND s.date = journal.date
+                AND s.section = json_extract(k.value, '$.key') AND trim(s.content, char(9, 10, 13, 32)) != ''
+        )
+    ) THEN 'complete'
+    ELSE 'partial'
+END;
+
+-- +goose Down
+ALTER TABLE journal DROP COLUMN completeness;
@@ -0,0 +1,15 @@
+-- +goose Up
+ALTER TABLE user_preferences ADD COLUMN inactivity_reminder_days INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE user_preferences ADD COLUMN inactivity_cooldown_days INTEGER NOT NULL DEFAULT 7;
+
+CREATE TABLE inactivity_reminders (
+    user_id INTEGER PRIMARY KEY,
+    sent_at DATETIME NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+-- +goose Down
+DROP TABLE inactivity_reminders;
+
+ALTER TABLE user_preferences DROP COLUMN inactivity_cooldown_days;
+ALTER TABLE user_preferences DROP COLUMN inactivity_reminder_days;
@@ -0,0 +1,16 @@
+-- +goose Up
+CREATE TABLE passage_entries (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    reference TEXT NOT NULL,
+    date TEXT,
+    sections TEXT NOT NULL DEFAULT '{}',
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+CREATE INDEX idx_passage_entries_user_id ON passage_entries(user_id, created_at);
+
+-- +goose Down
+DROP TABLE passage_entries;
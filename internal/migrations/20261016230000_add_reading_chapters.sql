@@ -0,0 +1,11 @@
+-- +goose Up
+-- The chapters of each day's readings in the daily texts, indexed by the server when it starts.
+CREATE TABLE reading_chapters (
+    date TEXT NOT NULL,
+    book INTEGER NOT NULL,
+    chapter INTEGER NOT NULL,
+    PRIMARY KEY (date, book, chapter)
+);
+
+-- +goose Down
+DROP TABLE reading_chapters;
@@ -0,0 +1,17 @@
+-- +goose Up
+-- Timed quiet time sessions, started from the journal entry for a date. A session ends when its timer
+-- runs out, completing it, or when the user stops it early.
+CREATE TABLE quiet_times (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    date TEXT NOT NULL,
+    started_at DATETIME NOT NULL,
+    duration_seconds INTEGER NOT NULL,
+    ended_at DATETIME,
+    completed BOOLEAN NOT NULL DEFAULT 0,
+    FOREIGN KEY(user_id) REFERENCES users(id)
+);
+CREATE INDEX idx_quiet_times_user_date ON quiet_times(user_id, date);
+
+-- +goose Down
+DROP TABLE quiet_times;
@@ -0,0 +1,21 @@
+-- +goose Up
+-- Each save of a journal entry bumps its revision, so that a save based on an older revision, such as
+-- one from another tab, is detected as a conflict rather than overwriting the newer text.
+ALTER TABLE journal ADD COLUMN revision INTEGER NOT NULL DEFAULT 0;
+
+-- Conflicting saves that the user resolved by merging, with the revision each side was based on.
+CREATE TABLE journal_merges (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    date TEXT NOT NULL,
+    base_revision INTEGER NOT NULL,
+    theirs_revision INTEGER NOT NULL,
+    merged_revision INTEGER NOT NULL,
+    merged_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY(user_id) REFERENCES users(id)
+);
+CREATE INDEX idx_journal_merges_user_date ON journal_merges(user_id, date);
+
+-- +goose Down
+DROP TABLE journal_merges;
+ALTER TABLE journal DROP COLUMN revision;
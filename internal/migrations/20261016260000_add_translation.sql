@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE user_preferences ADD COLUMN translation TEXT NOT NULL DEFAULT 'ESV';
+
+-- +goose Down
+ALTER TABLE user_preferences DROP COLUMN translation;
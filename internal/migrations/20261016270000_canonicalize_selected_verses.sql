@@ -0,0 +1,47 @@
+-- +goose Up
+-- Selected verses are stored as canonical BBCCCVVV IDs. Selections saved with a verse ID as the ESV API
+-- wrote it, such as "v01002017-1" or with the book's leading zero missing, are rewritten to match,
+-- keeping their order and dropping any that become duplicates. Anything else is left as it is.
+UPDATE journal SET selected_verses = (
+    SELECT json_group_array(canonical) FROM (
+        SELECT canonical FROM (
+            SELECT key, CASE
+                WHEN id GLOB '[0-9][0-9][0-9][0-9][0-9][0-9][0-9][0-9]' THEN id
+                WHEN id GLOB '[0-9][0-9][0-9][0-9][0-9][0-9][0-9]' THEN '0' || id
+                ELSE raw
+            END AS canonical
+            FROM (
+                SELECT key, raw, CASE WHEN instr(unprefixed, '-') > 0
+                    THEN substr(unprefixed, 1, instr(unprefixed, '-') - 1)
+                    ELSE unprefixed
+                END AS id
+                FROM (
+                    SELECT key, CAST(value AS TEXT) AS raw,
+                        CASE WHEN CAST(value AS TEXT) LIKE 'v%' THEN substr(CAST(value AS TEXT), 2) ELSE CAST(value AS TEXT) END AS unprefixed
+                    FROM json_each(journal.selected_verses)
+                )
+            )
+        )
+        GROUP BY canonical
+        ORDER BY MIN(key)
+    )
+)
+WHERE json_valid(selected_verses)
+    AND json_type(selected_verses) = 'array'
+    AND EXISTS (
+        SELECT 1 FROM json_each(journal.selected_verses)
+        WHERE CAST(value AS TEXT) NOT GLOB '[0-9][0-9][0-9][0-9][0-9][0-9][0-9][0-9]'
+    );
+
+-- The chapters of rewritten selections were missed by the backfill of journal_chapters.
+INSERT OR IGNORE INTO journal_chapters (user_id, date, book, chapter)
+SELECT j.user_id, j.date, CAST(v.value AS INTEGER) / 1000000, CAST(v.value AS INTEGER) / 1000 % 1000
+FROM journal j, json_each(j.selected_verses) v
+WHERE json_valid(j.selected_verses)
+    AND json_type(j.selected_verses) = 'array'
+    AND length(v.value) = 8
+    AND v.value NOT GLOB '*[^0-9]*';
+
+-- +goose Down
+-- The IDs selections were saved with are not kept, so canonical IDs are left in place.
+SELECT 1;
@@ -0,0 +1,15 @@
+-- +goose Up
+-- The time of day, as HH:MM in the user's timezone, at which they are emailed the day's texts, or ''
+-- if they have not subscribed.
+ALTER TABLE user_preferences ADD COLUMN daily_email_time TEXT NOT NULL DEFAULT '';
+
+CREATE TABLE daily_emails (
+    user_id INTEGER PRIMARY KEY,
+    last_sent_date TEXT NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+-- +goose Down
+DROP TABLE daily_emails;
+
+ALTER TABLE user_preferences DROP COLUMN daily_email_time;
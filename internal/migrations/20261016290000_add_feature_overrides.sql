@@ -0,0 +1,13 @@
+-- +goose Up
+-- Features turned on or off for a user, overriding the instance's configured mode for the feature
+-- unless the feature is off for everyone.
+CREATE TABLE feature_overrides (
+    user_id INTEGER NOT NULL,
+    feature TEXT NOT NULL,
+    enabled BOOLEAN NOT NULL,
+    PRIMARY KEY (user_id, feature),
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+-- +goose Down
+DROP TABLE feature_overrides;
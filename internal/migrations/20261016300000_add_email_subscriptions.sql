@@ -0,0 +1,22 @@
+-- +goose Up
+-- Users' subscriptions to the emails they opt in to, such as the daily email. A subscription is
+-- pending until it is confirmed from the emailed link, and its token also unsubscribes from the
+-- link in each email.
+CREATE TABLE email_subscriptions (
+    user_id INTEGER NOT NULL,
+    list TEXT NOT NULL,
+    status TEXT NOT NULL,
+    token TEXT NOT NULL UNIQUE,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, list),
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+-- Users who had already chosen a time for the daily email opted in to it.
+INSERT INTO email_subscriptions (user_id, list, status, token)
+SELECT user_id, 'daily', 'active', lower(hex(randomblob(16)))
+FROM user_preferences
+WHERE daily_email_time != '';
+
+-- +goose Down
+DROP TABLE email_subscriptions;
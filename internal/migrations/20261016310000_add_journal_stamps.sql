@@ -0,0 +1,9 @@
+-- +goose Up
+-- Where an entry was written and the weather there at the time, if the user stamped it, e.g.
+-- "Bethlehem, PA" and "Light rain, 12°C (54°F)".
+ALTER TABLE journal ADD COLUMN location TEXT NOT NULL DEFAULT '';
+ALTER TABLE journal ADD COLUMN weather TEXT NOT NULL DEFAULT '';
+
+-- +goose Down
+ALTER TABLE journal DROP COLUMN weather;
+ALTER TABLE journal DROP COLUMN location;
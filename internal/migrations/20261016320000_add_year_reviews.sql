@@ -0,0 +1,17 @@
+-- +goose Up
+-- Years in review that users opted in to share on a public page, found by token. The summary (JSON)
+-- and the card image are made once the year is over, and are NULL until then.
+CREATE TABLE year_reviews (
+    user_id INTEGER NOT NULL,
+    year INTEGER NOT NULL,
+    token TEXT NOT NULL UNIQUE,
+    verses TEXT NOT NULL DEFAULT '[]',
+    summary TEXT,
+    card BLOB,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, year),
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+-- +goose Down
+DROP TABLE year_reviews;
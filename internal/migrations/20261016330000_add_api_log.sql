@@ -0,0 +1,19 @@
+-- +goose Up
+-- A rolling log of the calls made to the translations' APIs, for the admin to troubleshoot with. Only
+-- the newest calls are kept. The URL never includes the API key.
+CREATE TABLE api_log (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    at DATETIME NOT NULL,
+    translation TEXT NOT NULL,
+    refs TEXT NOT NULL,
+    url TEXT NOT NULL,
+    status INTEGER NOT NULL,
+    bytes INTEGER NOT NULL,
+    latency_ms INTEGER NOT NULL,
+    retries INTEGER NOT NULL,
+    cache TEXT NOT NULL,
+    error TEXT NOT NULL DEFAULT ''
+);
+
+-- +goose Down
+DROP TABLE api_log;
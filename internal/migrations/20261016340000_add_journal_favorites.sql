@@ -0,0 +1,13 @@
+-- +goose Up
+-- Journal entries users pinned as favorites, in the order they arranged them.
+CREATE TABLE journal_favorites (
+    user_id INTEGER NOT NULL,
+    date TEXT NOT NULL,
+    position INTEGER NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, date),
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+-- +goose Down
+DROP TABLE journal_favorites;
@@ -0,0 +1,14 @@
+-- +goose Up
+-- Verses users highlighted outside the journal, such as on ESV.org, with where each was highlighted.
+CREATE TABLE highlights (
+    user_id INTEGER NOT NULL,
+    verse_ref TEXT NOT NULL, -- 8-digit verse reference, e.g. 43003016
+    source TEXT NOT NULL,
+    highlighted_on TEXT NOT NULL DEFAULT '', -- YYYY-MM-DD, or '' if the source does not say
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, verse_ref),
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+-- +goose Down
+DROP TABLE highlights;
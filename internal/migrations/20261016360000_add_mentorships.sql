@@ -0,0 +1,29 @@
+-- +goose Up
+-- Read access to users' journals granted to mentors, who may comment on the entries they can read.
+CREATE TABLE mentorships (
+    user_id INTEGER NOT NULL,
+    mentor_id INTEGER NOT NULL,
+    from_date TEXT NOT NULL DEFAULT '', -- first date (YYYY-MM-DD) of the entries shared, or '' for all before to_date
+    to_date TEXT NOT NULL DEFAULT '', -- last date shared, or '' for all after from_date
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, mentor_id),
+    FOREIGN KEY (user_id) REFERENCES users(id),
+    FOREIGN KEY (mentor_id) REFERENCES users(id)
+);
+
+-- Comments mentors left on journal entries. They are kept when access is revoked.
+CREATE TABLE mentor_comments (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    date TEXT NOT NULL,
+    author_id INTEGER NOT NULL,
+    body TEXT NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id),
+    FOREIGN KEY (author_id) REFERENCES users(id)
+);
+CREATE INDEX idx_mentor_comments_user_date ON mentor_comments(user_id, date);
+
+-- +goose Down
+DROP TABLE mentor_comments;
+DROP TABLE mentorships;
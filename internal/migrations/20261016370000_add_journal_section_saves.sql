@@ -0,0 +1,12 @@
+-- +goose Up
+-- When each section of an entry was last changed, and the entry's revision that changed it. Sections
+-- saved before are taken to have changed when their entry was last saved.
+ALTER TABLE journal_sections ADD COLUMN saved_at DATETIME;
+ALTER TABLE journal_sections ADD COLUMN revision INTEGER NOT NULL DEFAULT 0;
+UPDATE journal_sections SET
+    saved_at = (SELECT j.timestamp FROM journal j WHERE j.user_id = journal_sections.user_id AND j.date = journal_sections.date),
+    revision = COALESCE((SELECT j.revision FROM journal j WHERE j.user_id = journal_sections.user_id AND j.date = journal_sections.date), 0);
+
+-- +goose Down
+ALTER TABLE journal_sections DROP COLUMN revision;
+ALTER TABLE journal_sections DROP COLUMN saved_at;
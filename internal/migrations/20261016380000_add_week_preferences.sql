@@ -0,0 +1,9 @@
+-- +goose Up
+-- The day the user's weeks start on, as a time.Weekday (0 for Sunday, 1 for Monday), and whether weeks
+-- are numbered as ISO 8601 does.
+ALTER TABLE user_preferences ADD COLUMN week_start INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE user_preferences ADD COLUMN iso_weeks INTEGER NOT NULL DEFAULT 0;
+
+-- +goose Down
+ALTER TABLE user_preferences DROP COLUMN iso_weeks;
+ALTER TABLE user_preferences DROP COLUMN week_start;
@@ -0,0 +1,32 @@
+-- +goose Up
+-- Whether visitors may comment on a shared year in review. Off until its owner turns it on.
+ALTER TABLE year_reviews ADD COLUMN comments_enabled INTEGER NOT NULL DEFAULT 0;
+
+-- Comments visitors left on shared years in review. Comments left while the admin moderates them are
+-- not approved, and are shown only once the admin approves them.
+CREATE TABLE guest_comments (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    year INTEGER NOT NULL,
+    name TEXT NOT NULL,
+    body TEXT NOT NULL,
+    ip TEXT NOT NULL,
+    approved INTEGER NOT NULL DEFAULT 1,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
+CREATE INDEX idx_guest_comments_review ON guest_comments(user_id, year);
+
+-- Addresses and words the admin blocked from guest comments.
+CREATE TABLE comment_blocklist (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    kind TEXT NOT NULL, -- 'ip' or 'word'
+    value TEXT NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (kind, value)
+);
+
+-- +goose Down
+DROP TABLE comment_blocklist;
+DROP TABLE guest_comments;
+ALTER TABLE year_reviews DROP COLUMN comments_enabled;
@@ -0,0 +1,17 @@
+-- +goose Up
+-- Printable yearbooks of a year of users' journals. A yearbook is queued when the user asks for it and
+-- made by a background job, which saves the page or why it could not be made.
+CREATE TABLE yearbooks (
+    user_id INTEGER NOT NULL,
+    year INTEGER NOT NULL,
+    status TEXT NOT NULL DEFAULT 'queued', -- 'queued', 'ready' or 'failed'
+    html BLOB,
+    error TEXT NOT NULL DEFAULT '',
+    requested_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    made_at DATETIME,
+    PRIMARY KEY (user_id, year),
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+-- +goose Down
+DROP TABLE yearbooks;
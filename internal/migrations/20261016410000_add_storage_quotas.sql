@@ -0,0 +1,12 @@
+-- +goose Up
+-- Users' own limits on the space their data may take up, in bytes, set by the admin in place of the
+-- instance's limit.
+CREATE TABLE storage_quotas (
+    user_id INTEGER PRIMARY KEY,
+    quota_bytes INTEGER NOT NULL,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+-- +goose Down
+DROP TABLE storage_quotas;
@@ -0,0 +1,13 @@
+-- +goose Up
+-- The daily checks of a sample of cached passages against the text the API now returns, and the cache
+-- keys (a JSON array) of those that had changed. Only the newest checks are kept.
+CREATE TABLE cache_verifications (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    verified_at DATETIME NOT NULL,
+    checked INTEGER NOT NULL,
+    failed INTEGER NOT NULL,
+    changed TEXT NOT NULL DEFAULT '[]'
+);
+
+-- +goose Down
+DROP TABLE cache_verifications;
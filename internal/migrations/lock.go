@@ -0,0 +1,95 @@
+package migrations
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+)
+
+// lockLease is how long the migration lock is held before another process may take it over. It is
+// far longer than migrations take, so a lease only runs out if its holder died mid-migration; the
+// interrupted migration was rolled back, and the next process starts it again.
+const lockLease = 10 * time.Minute
+
+// lockRetryInterval is how often a process waiting for the migration lock tries to take it.
+const lockRetryInterval = time.Second
+
+// acquireLock takes the migration lock, waiting while another process holds it, and returns a function
+// that releases it. The lock is a row in its own table rather than an open transaction, so the
+// migrations themselves can use any connection.
+func acquireLock(ctx context.Context, db *sql.DB) (release func(), err error) {
+	query := `
+		CREATE TABLE IF NOT EXISTS migration_lock (
+			id INTEGER PRIMARY KEY CHECK (id = 1),
+			holder TEXT NOT NULL,
+			expires_at DATETIME NOT NULL
+		)
+	`
+	if _, err := db.ExecContext(ctx, query); err != nil {
+		return nil, fmt.Errorf("creating migration lock table: %w", err)
+	}
+
+	holder, err := lockHolder()
+	if err != nil {
+		return nil, err
+	}
+	lease := fmt.Sprintf("+%d seconds", int(lockLease.Seconds()))
+	query = `
+		INSERT INTO migration_lock (id, holder, expires_at) VALUES (1, ?, datetime('now', ?))
+		ON CONFLICT(id) DO UPDATE SET holder = excluded.holder, expires_at = excluded.expires_at
+		WHERE migration_lock.expires_at < datetime('now')
+	`
+	waiting := false
+	for {
+		res, err := db.ExecContext(ctx, query, holder, lease)
+		if err != nil {
+			return nil, fmt.Errorf("taking migration lock: %w", err)
+		}
+		if n, err := res.RowsAffected(); err != nil {
+			return nil, fmt.Errorf("getting rows affected: %w", err)
+		} else if n == 1 {
+			break
+		}
+
+		if !waiting {
+			var current string
+			if err := db.QueryRowContext(ctx, "SELECT holder FROM migration_lock WHERE id = 1").Scan(&current); err != nil && !errors.Is(err, sql.ErrNoRows) {
+				return nil, fmt.Errorf("reading migration lock: %w", err)
+			}
+			slog.Info("waiting for another process to finish migrating", "holder", current)
+			waiting = true
+		}
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("waiting for migration lock: %w", ctx.Err())
+		case <-time.After(lockRetryInterval):
+		}
+	}
+
+	return func() {
+		// The lock must be released even if ctx was canceled during the migrations.
+		ctx := context.WithoutCancel(ctx)
+		if _, err := db.ExecContext(ctx, "DELETE FROM migration_lock WHERE id = 1 AND holder = ?", holder); err != nil {
+			slog.Error("failed to release migration lock", "error", err)
+		}
+	}, nil
+}
+
+// lockHolder returns a name for this process that is unique among those sharing the database.
+func lockHolder() (string, error) {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	b := make([]byte, 4)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generating lock holder: %w", err)
+	}
+	return fmt.Sprintf("%s/%d/%s", host, os.Getpid(), hex.EncodeToString(b)), nil
+}
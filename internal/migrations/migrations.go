@@ -1,4 +1,9 @@
 // Package migrations handles database schema migrations using goose.
+//
+// Each migration runs in its own transaction, which in SQLite covers schema changes as well as data,
+// so a migration that fails or is interrupted leaves the database at the previous version. Only one
+// process migrates at a time: Run holds a lock, kept in the database itself, while it applies
+// migrations.
 package migrations
 
 import (
@@ -6,6 +11,12 @@ import (
 	"database/sql"
 	"embed"
 	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"regexp"
+	"strings"
+	"time"
 
 	"github.com/pressly/goose/v3"
 )
@@ -13,17 +24,161 @@ import (
 //go:embed *.sql
 var embedMigrations embed.FS
 
+// destructiveRe matches statements in a migration that drop or delete data.
+var destructiveRe = regexp.MustCompile(`(?i)\b(DROP\s+TABLE|DROP\s+COLUMN|DELETE\s+FROM|ALTER\s+TABLE\s+\S+\s+DROP)\b`)
+
+// Migration describes a migration and whether it has been applied.
+type Migration struct {
+	Version int64
+	Name    string
+	// AppliedAt is when the migration was applied, or the zero time if it is pending.
+	AppliedAt time.Time
+	// Destructive reports whether the migration drops or deletes data when applied.
+	Destructive bool
+}
+
+// Pending reports whether the migration has yet to be applied.
+func (m *Migration) Pending() bool {
+	return m.AppliedAt.IsZero()
+}
+
+// Option configures Run.
+type Option func(*options)
+
+type options struct {
+	backupPath string
+}
+
+// WithBackup makes Run copy the database at dbPath before applying any destructive migration to it. The
+// copy is written next to it, named after the first pending migration, such as app.db.pre-20261016070000.
+func WithBackup(dbPath string) Option {
+	return func(o *options) {
+		o.backupPath = dbPath
+	}
+}
+
+// newProvider returns a goose provider for the embedded migrations.
+func newProvider(db *sql.DB) (*goose.Provider, error) {
+	provider, err := goose.NewProvider(goose.DialectSQLite3, db, embedMigrations, goose.WithDisableGlobalRegistry(true))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create migration provider: %w", err)
+	}
+	return provider, nil
+}
+
+// Status lists every migration, oldest first, with whether and when it was applied.
+func Status(ctx context.Context, db *sql.DB) ([]*Migration, error) {
+	provider, err := newProvider(db)
+	if err != nil {
+		return nil, err
+	}
+	statuses, err := provider.Status(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get migration status: %w", err)
+	}
+
+	migrations := make([]*Migration, 0, len(statuses))
+	for _, s := range statuses {
+		destructive, err := isDestructive(s.Source.Path)
+		if err != nil {
+			return nil, err
+		}
+		migrations = append(migrations, &Migration{
+			Version:     s.Source.Version,
+			Name:        s.Source.Path,
+			AppliedAt:   s.AppliedAt,
+			Destructive: destructive,
+		})
+	}
+	return migrations, nil
+}
+
+// isDestructive reports whether the Up section of the migration file drops or deletes data.
+func isDestructive(name string) (bool, error) {
+	data, err := fs.ReadFile(embedMigrations, name)
+	if err != nil {
+		return false, fmt.Errorf("reading migration %s: %w", name, err)
+	}
+	up, _, _ := strings.Cut(string(data), "-- +goose Down")
+	return destructiveRe.MatchString(up), nil
+}
+
 // Run applies all pending migrations to the database.
-func Run(ctx context.Context, db *sql.DB) error {
-	goose.SetBaseFS(embedMigrations)
+func Run(ctx context.Context, db *sql.DB, opts ...Option) error {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
 
-	if err := goose.SetDialect("sqlite3"); err != nil {
-		return fmt.Errorf("failed to set goose dialect: %w", err)
+	provider, err := newProvider(db)
+	if err != nil {
+		return err
 	}
 
-	if err := goose.UpContext(ctx, db, "."); err != nil {
+	release, err := acquireLock(ctx, db)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	// Another process may have applied the migrations while this one waited for the lock.
+	all, err := Status(ctx, db)
+	if err != nil {
+		return err
+	}
+	pending := pendingOnly(all)
+	if len(pending) == 0 {
+		return nil
+	}
+
+	// A new database has nothing to lose, so it is not backed up.
+	if o.backupPath != "" && len(pending) < len(all) && anyDestructive(pending) {
+		if err := backup(ctx, db, fmt.Sprintf("%s.pre-%d", o.backupPath, pending[0].Version)); err != nil {
+			return err
+		}
+	}
+
+	results, err := provider.Up(ctx)
+	for _, r := range results {
+		slog.Info("applied migration", "name", r.Source.Path, "duration", r.Duration)
+	}
+	if err != nil {
 		return fmt.Errorf("failed to run migrations: %w", err)
 	}
+	return nil
+}
+
+// pendingOnly returns the migrations that have yet to be applied.
+func pendingOnly(migrations []*Migration) []*Migration {
+	var pending []*Migration
+	for _, m := range migrations {
+		if m.Pending() {
+			pending = append(pending, m)
+		}
+	}
+	return pending
+}
 
+// anyDestructive reports whether any of the migrations is destructive.
+func anyDestructive(migrations []*Migration) bool {
+	for _, m := range migrations {
+		if m.Destructive {
+			return true
+		}
+	}
+	return false
+}
+
+// backup copies the database to path, unless a copy is already there from an earlier attempt at the
+// same migrations, which still holds the database as it was before them.
+func backup(ctx context.Context, db *sql.DB, path string) error {
+	if _, err := os.Stat(path); err == nil {
+		slog.Info("keeping existing backup before destructive migrations", "path", path)
+		return nil
+	}
+	if _, err := db.ExecContext(ctx, "VACUUM INTO ?", path); err != nil {
+		return fmt.Errorf("backing up database to %s: %w", path, err)
+	}
+	slog.Info("backed up database before destructive migrations", "path", path)
 	return nil
 }
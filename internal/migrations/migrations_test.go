@@ -3,10 +3,16 @@ package migrations_test
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
 	"testing"
+	"time"
 
 	"derrclan.com/moravian-soap/internal/migrations"
 	_ "github.com/mattn/go-sqlite3"
+	"github.com/pressly/goose/v3"
 )
 
 func TestRun(t *testing.T) {
@@ -37,3 +43,382 @@ func TestRun(t *testing.T) {
 		t.Errorf("failed to find index: %v", err)
 	}
 }
+
+func TestJournalSectionsMigration(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("failed to open in-memory db: %v", err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	ctx := context.Background()
+	goose.SetBaseFS(nil)
+	if err := goose.SetDialect("sqlite3"); err != nil {
+		t.Fatalf("failed to set goose dialect: %v", err)
+	}
+	if err := goose.UpToContext(ctx, db, ".", 20261016060000); err != nil {
+		t.Fatalf("failed to run migrations up to journal sections: %v", err)
+	}
+
+	if _, err := db.Exec(`INSERT INTO users (id, email, password_hash) VALUES (1, 'a@example.com', 'x')`); err != nil {
+		t.Fatalf("failed to insert user: %v", err)
+	}
+	if _, err := db.Exec(`INSERT INTO journal (user_id, date, observation, application, prayer) VALUES (1, '2025-01-01', 'Obs', '', 'Pray')`); err != nil {
+		t.Fatalf("failed to insert journal: %v", err)
+	}
+
+	if err := migrations.Run(ctx, db); err != nil {
+		t.Fatalf("failed to run migrations: %v", err)
+	}
+
+	rows, err := db.Query(`SELECT section, content FROM journal_sections WHERE user_id = 1 AND date = '2025-01-01' ORDER BY section`)
+	if err != nil {
+		t.Fatalf("failed to query journal sections: %v", err)
+	}
+	defer rows.Close()
+	got := map[string]string{}
+	for rows.Next() {
+		var section, content string
+		if err := rows.Scan(&section, &content); err != nil {
+			t.Fatalf("failed to scan journal section: %v", err)
+		}
+		got[section] = content
+	}
+	if len(got) != 2 || got["observation"] != "Obs" || got["prayer"] != "Pray" {
+		t.Errorf("unexpected migrated sections: %v", got)
+	}
+}
+
+func TestJournalChaptersBackfill(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("failed to open in-memory db: %v", err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	ctx := context.Background()
+	goose.SetBaseFS(nil)
+	if err := goose.SetDialect("sqlite3"); err != nil {
+		t.Fatalf("failed to set goose dialect: %v", err)
+	}
+	if err := goose.UpToContext(ctx, db, ".", 20261016110000); err != nil {
+		t.Fatalf("failed to run migrations up to the backfill: %v", err)
+	}
+
+	if _, err := db.Exec(`INSERT INTO users (id, email, password_hash) VALUES (1, 'a@example.com', 'x')`); err != nil {
+		t.Fatalf("failed to insert user: %v", err)
+	}
+	if _, err := db.Exec(`INSERT INTO journal (user_id, date, selected_verses) VALUES
+		(1, '2025-01-01', '["45008001","45008002","01001001","bogus"]'),
+		(1, '2025-01-02', NULL),
+		(1, '2025-01-03', '')`); err != nil {
+		t.Fatalf("failed to insert journal: %v", err)
+	}
+
+	if err := migrations.Run(ctx, db); err != nil {
+		t.Fatalf("failed to run migrations: %v", err)
+	}
+
+	rows, err := db.Query(`SELECT date, book, chapter FROM journal_chapters WHERE user_id = 1 ORDER BY date, book, chapter`)
+	if err != nil {
+		t.Fatalf("failed to query journal chapters: %v", err)
+	}
+	defer rows.Close()
+	var got []string
+	for rows.Next() {
+		var date string
+		var book, chapter int
+		if err := rows.Scan(&date, &book, &chapter); err != nil {
+			t.Fatalf("failed to scan journal chapter: %v", err)
+		}
+		got = append(got, fmt.Sprintf("%s %d:%d", date, book, chapter))
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatalf("rows error: %v", err)
+	}
+	want := []string{"2025-01-01 1:1", "2025-01-01 45:8"}
+	if !slices.Equal(got, want) {
+		t.Errorf("journal chapters = %v, want %v", got, want)
+	}
+}
+
+func TestCanonicalizeSelectedVerses(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("failed to open in-memory db: %v", err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	ctx := context.Background()
+	goose.SetBaseFS(nil)
+	if err := goose.SetDialect("sqlite3"); err != nil {
+		t.Fatalf("failed to set goose dialect: %v", err)
+	}
+	if err := goose.UpToContext(ctx, db, ".", 20261016260000); err != nil {
+		t.Fatalf("failed to run migrations up to the rewrite: %v", err)
+	}
+
+	for _, stmt := range []string{
+		`INSERT INTO users (id, email, password_hash) VALUES (1, 'a@example.com', 'x')`,
+		`INSERT INTO journal (user_id, date, selected_verses) VALUES
+			(1, '2025-01-01', '["v45008002-1","45008001","45008002","v1001001","bogus"]'),
+			(1, '2025-01-02', '["43003016"]'),
+			(1, '2025-01-03', NULL)`,
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("failed to insert test data: %v", err)
+		}
+	}
+
+	if err := migrations.Run(ctx, db); err != nil {
+		t.Fatalf("failed to run migrations: %v", err)
+	}
+
+	want := map[string]string{
+		"2025-01-01": `["45008002","45008001","01001001","bogus"]`,
+		"2025-01-02": `["43003016"]`,
+		"2025-01-03": "",
+	}
+	for date, verses := range want {
+		var got sql.NullString
+		if err := db.QueryRow(`SELECT selected_verses FROM journal WHERE user_id = 1 AND date = ?`, date).Scan(&got); err != nil {
+			t.Fatalf("failed to query journal: %v", err)
+		}
+		if got.String != verses {
+			t.Errorf("selected verses on %s = %q, want %q", date, got.String, verses)
+		}
+	}
+
+	var chapters int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM journal_chapters WHERE user_id = 1 AND date = '2025-01-01' AND book = 1 AND chapter = 1`).Scan(&chapters); err != nil {
+		t.Fatalf("failed to query journal chapters: %v", err)
+	}
+	if chapters != 1 {
+		t.Errorf("Genesis 1 related to the rewritten entry %d times, want once", chapters)
+	}
+}
+
+func TestEmailSubscriptionsBackfill(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("failed to open in-memory db: %v", err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	ctx := context.Background()
+	goose.SetBaseFS(nil)
+	if err := goose.SetDialect("sqlite3"); err != nil {
+		t.Fatalf("failed to set goose dialect: %v", err)
+	}
+	if err := goose.UpToContext(ctx, db, ".", 20261016290000); err != nil {
+		t.Fatalf("failed to run migrations up to the subscriptions: %v", err)
+	}
+
+	for _, stmt := range []string{
+		`INSERT INTO users (id, email, password_hash) VALUES (1, 'a@example.com', 'x'), (2, 'b@example.com', 'x')`,
+		`INSERT INTO user_preferences (user_id, daily_email_time) VALUES (1, '06:30'), (2, '')`,
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("failed to insert test data: %v", err)
+		}
+	}
+
+	if err := migrations.Run(ctx, db); err != nil {
+		t.Fatalf("failed to run migrations: %v", err)
+	}
+
+	rows, err := db.Query(`SELECT user_id, list, status, token FROM email_subscriptions`)
+	if err != nil {
+		t.Fatalf("failed to query subscriptions: %v", err)
+	}
+	defer rows.Close()
+	var users []int64
+	for rows.Next() {
+		var userID int64
+		var list, status, token string
+		if err := rows.Scan(&userID, &list, &status, &token); err != nil {
+			t.Fatalf("failed to scan subscription: %v", err)
+		}
+		if list != "daily" || status != "active" || len(token) != 32 {
+			t.Errorf("subscription of user %d = %s, %s, %q; want an active daily subscription with a token", userID, list, status, token)
+		}
+		users = append(users, userID)
+	}
+	if !slices.Equal(users, []int64{1}) {
+		t.Errorf("subscribed users = %v, want only the user with a send time", users)
+	}
+}
+
+func TestJournalCompletenessBackfill(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("failed to open in-memory db: %v", err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	ctx := context.Background()
+	goose.SetBaseFS(nil)
+	if err := goose.SetDialect("sqlite3"); err != nil {
+		t.Fatalf("failed to set goose dialect: %v", err)
+	}
+	if err := goose.UpToContext(ctx, db, ".", 20261016190000); err != nil {
+		t.Fatalf("failed to run migrations up to the backfill: %v", err)
+	}
+
+	// User 1 journals in the default sections, user 2 in a single custom section.
+	for _, stmt := range []string{
+		`INSERT INTO users (id, email, password_hash) VALUES (1, 'a@example.com', 'x'), (2, 'b@example.com', 'x')`,
+		`INSERT INTO user_preferences (user_id, journal_schema) VALUES (2, '[{"key":"thoughts","name":"Thoughts"}]')`,
+		`INSERT INTO journal (user_id, date, selected_verses) VALUES
+			(1, '2025-01-01', NULL),
+			(1, '2025-01-02', '["45008001"]'),
+			(1, '2025-01-03', '[]'),
+			(1, '2025-01-04', NULL),
+			(2, '2025-01-01', NULL)`,
+		`INSERT INTO journal_sections (user_id, date, section, content) VALUES
+			(1, '2025-01-01', 'observation', 'Obs'),
+			(1, '2025-01-01', 'application', 'App'),
+			(1, '2025-01-01', 'prayer', 'Pray'),
+			(1, '2025-01-03', 'observation', 'Obs'),
+			(1, '2025-01-03', 'prayer', '  '),
+			(2, '2025-01-01', 'thoughts', 'Thoughts')`,
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("failed to insert test data: %v", err)
+		}
+	}
+
+	if err := migrations.Run(ctx, db); err != nil {
+		t.Fatalf("failed to run migrations: %v", err)
+	}
+
+	rows, err := db.Query(`SELECT user_id, date, completeness FROM journal ORDER BY user_id, date`)
+	if err != nil {
+		t.Fatalf("failed to query journal: %v", err)
+	}
+	defer rows.Close()
+	var got []string
+	for rows.Next() {
+		var userID int64
+		var date, completeness string
+		if err := rows.Scan(&userID, &date, &completeness); err != nil {
+			t.Fatalf("failed to scan journal: %v", err)
+		}
+		got = append(got, fmt.Sprintf("%d %s %s", userID, date, completeness))
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatalf("rows error: %v", err)
+	}
+	want := []string{
+		"1 2025-01-01 complete",
+		"1 2025-01-02 draft",
+		"1 2025-01-03 partial",
+		"1 2025-01-04 none",
+		"2 2025-01-01 complete",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("completeness = %v, want %v", got, want)
+	}
+}
+
+func TestRunWaitsForLock(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("failed to open in-memory db: %v", err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	// Another process holds the lock.
+	if _, err := db.Exec(`CREATE TABLE migration_lock (id INTEGER PRIMARY KEY CHECK (id = 1), holder TEXT NOT NULL, expires_at DATETIME NOT NULL)`); err != nil {
+		t.Fatalf("failed to create lock table: %v", err)
+	}
+	if _, err := db.Exec(`INSERT INTO migration_lock VALUES (1, 'other', datetime('now', '+1 minute'))`); err != nil {
+		t.Fatalf("failed to take lock: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if err := migrations.Run(ctx, db); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Run while locked = %v, want it to wait until the context expires", err)
+	}
+	var tables int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE name = 'users'`).Scan(&tables); err != nil || tables != 0 {
+		t.Errorf("Run applied migrations without the lock (%d, %v)", tables, err)
+	}
+
+	// Its lease runs out, as when a process dies mid-migration.
+	if _, err := db.Exec(`UPDATE migration_lock SET expires_at = datetime('now', '-1 minute')`); err != nil {
+		t.Fatalf("failed to expire lock: %v", err)
+	}
+	if err := migrations.Run(context.Background(), db); err != nil {
+		t.Fatalf("Run after the lease expired failed: %v", err)
+	}
+	var held int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM migration_lock`).Scan(&held); err != nil || held != 0 {
+		t.Errorf("lock still held after Run (%d, %v)", held, err)
+	}
+}
+
+func TestRunBacksUpBeforeDestructiveMigrations(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.db")
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatalf("failed to open db: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	goose.SetBaseFS(nil)
+	if err := goose.SetDialect("sqlite3"); err != nil {
+		t.Fatalf("failed to set goose dialect: %v", err)
+	}
+	if err := goose.UpToContext(ctx, db, ".", 20261016060000); err != nil {
+		t.Fatalf("failed to run migrations up to journal sections: %v", err)
+	}
+
+	status, err := migrations.Status(ctx, db)
+	if err != nil {
+		t.Fatalf("Status failed: %v", err)
+	}
+	var next *migrations.Migration
+	for _, m := range status {
+		if m.Pending() {
+			next = m
+			break
+		}
+	}
+	if next == nil || next.Version != 20261016070000 || !next.Destructive {
+		t.Fatalf("first pending migration = %+v, want the destructive journal sections migration", next)
+	}
+
+	if err := migrations.Run(ctx, db, migrations.WithBackup(path)); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	backup, err := sql.Open("sqlite3", path+".pre-20261016070000")
+	if err != nil {
+		t.Fatalf("failed to open backup: %v", err)
+	}
+	defer backup.Close()
+	var version int64
+	if err := backup.QueryRow(`SELECT MAX(version_id) FROM goose_db_version`).Scan(&version); err != nil {
+		t.Fatalf("failed to read backup version: %v", err)
+	}
+	if version != 20261016060000 {
+		t.Errorf("backup is at version %d, want the version before the migrations", version)
+	}
+
+	status, err = migrations.Status(ctx, db)
+	if err != nil {
+		t.Fatalf("Status failed: %v", err)
+	}
+	for _, m := range status {
+		if m.Pending() {
+			t.Errorf("migration %s still pending after Run", m.Name)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+// Package pace projects how much of a year's daily texts a reader will have journaled by the end of the
+// year at their pace so far, and plans how to catch up on the days they have missed.
+package pace
+
+import (
+	"math"
+
+	"derrclan.com/moravian-soap/internal/civil"
+	"derrclan.com/moravian-soap/internal/journal"
+)
+
+// Projection is a reader's progress through a year's daily texts, and where it will end at their pace.
+type Projection struct {
+	Year int
+	// Days is the number of days in the year.
+	Days int
+	// Elapsed is the number of days of the year up to and including today.
+	Elapsed int
+	// Journaled and Completed are the days so far with something written, and with every section
+	// written.
+	Journaled int
+	Completed int
+	// ProjectedJournaled and ProjectedCompleted are the days there will be of each by the end of the
+	// year if the rest of it is journaled at the same rate as so far.
+	ProjectedJournaled int
+	ProjectedCompleted int
+	// Missed is the days before today with nothing written, oldest first.
+	Missed []civil.Date
+}
+
+// Project returns the reader's projection for the year of today, given the completeness of their
+// entries by date.
+func Project(today civil.Date, completeness map[string]string) Projection {
+	start := civil.Date{Year: today.Year, Month: 1, Day: 1}
+	end := civil.Date{Year: today.Year, Month: 12, Day: 31}
+	p := Projection{
+		Year:    today.Year,
+		Days:    daysBetween(start, end) + 1,
+		Elapsed: daysBetween(start, today) + 1,
+	}
+	for d := start; !d.After(today); d = d.AddDays(1) {
+		switch journal.Completeness(completeness[d.String()]) {
+		case journal.CompletenessComplete:
+			p.Completed++
+			p.Journaled++
+		case journal.CompletenessPartial:
+			p.Journaled++
+		default:
+			if d.Before(today) {
+				p.Missed = append(p.Missed, d)
+			}
+		}
+	}
+	p.ProjectedJournaled = p.project(p.Journaled)
+	p.ProjectedCompleted = p.project(p.Completed)
+	return p
+}
+
+// project returns n days so far carried on at the same rate to the end of the year.
+func (p Projection) project(n int) int {
+	rate := float64(n) / float64(p.Elapsed)
+	return n + int(math.Round(rate*float64(p.Days-p.Elapsed)))
+}
+
+// Coverage returns the percentage of the year's daily texts projected to be journaled by its end.
+func (p Projection) Coverage() int {
+	return p.ProjectedJournaled * 100 / p.Days
+}
+
+// Week is one week of a catch-up plan.
+type Week struct {
+	// Start is the first day of the week.
+	Start civil.Date
+	// Days is the missed days to catch up on during the week, oldest first.
+	Days []civil.Date
+}
+
+// CatchUp spreads the missed days, oldest first, as evenly as possible across the given number of weeks
+// starting today, in order and with any extra days in the earlier weeks. Missed days falling in another
+// year are left out, and weeks past the end of the year are not planned.
+func CatchUp(today civil.Date, missed []civil.Date, weeks int) []Week {
+	end := civil.Date{Year: today.Year, Month: 12, Day: 31}
+	if left := daysBetween(today, end)/7 + 1; weeks > left {
+		weeks = left
+	}
+	if weeks < 1 {
+		return nil
+	}
+
+	var days []civil.Date
+	for _, d := range missed {
+		if d.Year == today.Year && d.Before(today) {
+			days = append(days, d)
+		}
+	}
+	plan := make([]Week, weeks)
+	for i := range plan {
+		n := len(days) / (weeks - i)
+		if len(days)%(weeks-i) != 0 {
+			n++
+		}
+		plan[i] = Week{Start: today.AddDays(7 * i), Days: days[:n:n]}
+		days = days[n:]
+	}
+	return plan
+}
+
+// daysBetween returns the number of days from a to b.
+func daysBetween(a, b civil.Date) int {
+	return int(b.Time().Sub(a.Time()).Hours() / 24)
+}
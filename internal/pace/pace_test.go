@@ -0,0 +1,74 @@
+package pace_test
+
+import (
+	"testing"
+
+	"derrclan.com/moravian-soap/internal/civil"
+	"derrclan.com/moravian-soap/internal/pace"
+)
+
+func date(t *testing.T, s string) civil.Date {
+	t.Helper()
+	d, err := civil.ParseDate(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+func TestProject(t *testing.T) {
+	completeness := map[string]string{
+		"2026-01-01": "complete",
+		"2026-01-02": "partial",
+		"2026-01-03": "draft",
+		"2026-01-05": "complete",
+		"2025-12-31": "complete",
+	}
+	p := pace.Project(date(t, "2026-01-05"), completeness)
+
+	if p.Days != 365 || p.Elapsed != 5 {
+		t.Errorf("Days, Elapsed = %d, %d; want 365, 5", p.Days, p.Elapsed)
+	}
+	if p.Journaled != 3 || p.Completed != 2 {
+		t.Errorf("Journaled, Completed = %d, %d; want 3, 2", p.Journaled, p.Completed)
+	}
+	// Three in five days carried on for the other 360 is 216 more.
+	if p.ProjectedJournaled != 219 || p.ProjectedCompleted != 146 {
+		t.Errorf("projected = %d, %d; want 219, 146", p.ProjectedJournaled, p.ProjectedCompleted)
+	}
+	if p.Coverage() != 60 {
+		t.Errorf("Coverage() = %d, want 60", p.Coverage())
+	}
+	if len(p.Missed) != 2 || p.Missed[0].String() != "2026-01-03" || p.Missed[1].String() != "2026-01-04" {
+		t.Errorf("Missed = %v, want the draft on 2026-01-03 and 2026-01-04", p.Missed)
+	}
+}
+
+func TestCatchUp(t *testing.T) {
+	today := date(t, "2026-03-02")
+	missed := []civil.Date{date(t, "2025-12-30")}
+	for i := 10; i >= 1; i-- {
+		missed = append(missed, today.AddDays(-i*3))
+	}
+
+	plan := pace.CatchUp(today, missed, 4)
+	if len(plan) != 4 {
+		t.Fatalf("CatchUp() planned %d weeks, want 4", len(plan))
+	}
+	want := []int{3, 3, 2, 2}
+	for i, w := range plan {
+		if len(w.Days) != want[i] {
+			t.Errorf("week %d has %d days, want %d", i, len(w.Days), want[i])
+		}
+		if w.Start != today.AddDays(7*i) {
+			t.Errorf("week %d starts %s, want %s", i, w.Start, today.AddDays(7*i))
+		}
+	}
+	if plan[0].Days[0] != missed[1] {
+		t.Errorf("first day planned = %s, want %s", plan[0].Days[0], missed[1])
+	}
+
+	if plan := pace.CatchUp(date(t, "2026-12-20"), missed, 4); len(plan) != 2 {
+		t.Errorf("CatchUp() near the end of the year planned %d weeks, want 2", len(plan))
+	}
+}
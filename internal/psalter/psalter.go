@@ -0,0 +1,78 @@
+// Package psalter divides the Psalms into daily portions for reading consecutively through the psalter.
+package psalter
+
+import (
+	"fmt"
+
+	"derrclan.com/moravian-soap/internal/store"
+)
+
+// TotalPsalms is the number of psalms in the psalter.
+const TotalPsalms = 150
+
+// Portion is one day's reading from the psalter.
+type Portion struct {
+	// Reference is the passage reference passed to the ESV API, e.g. "Psalm 23" or "Psalm 119:1-8".
+	Reference string
+	// Psalm is the psalm the portion is taken from.
+	Psalm int
+}
+
+// portions lists every portion in reading order. Each psalm is one portion except Psalm 119, which is
+// read one stanza (eight verses) at a time.
+var portions = func() []Portion {
+	var ps []Portion
+	for n := 1; n <= TotalPsalms; n++ {
+		if n != 119 {
+			ps = append(ps, Portion{Reference: fmt.Sprintf("Psalm %d", n), Psalm: n})
+			continue
+		}
+		for v := 1; v <= 176; v += 8 {
+			ps = append(ps, Portion{Reference: fmt.Sprintf("Psalm 119:%d-%d", v, v+7), Psalm: n})
+		}
+	}
+	return ps
+}()
+
+// Count returns the number of portions in the psalter.
+func Count() int {
+	return len(portions)
+}
+
+// Get returns the portion at index i (0-based), wrapping around to the start of the psalter.
+func Get(i int) Portion {
+	return portions[((i%len(portions))+len(portions))%len(portions)]
+}
+
+// PortionOn returns the index of the portion to read on a date given the user's readings, ordered by date.
+// It is the portion read that day if there is one, and otherwise the portion after the last one read
+// before the date.
+func PortionOn(dateStr string, readings []*store.PsalterReading) (index int, read bool) {
+	next := 0
+	for _, r := range readings {
+		if r.Date == dateStr {
+			return r.Portion, true
+		}
+		if r.Date < dateStr {
+			next = (r.Portion + 1) % len(portions)
+		}
+	}
+	return next, false
+}
+
+// PsalmsRead returns the number of psalms read in full in the readings. Psalm 119 counts once every
+// stanza has been read.
+func PsalmsRead(readings []*store.PsalterReading) int {
+	read := make(map[int]bool)
+	for _, r := range readings {
+		read[r.Portion%len(portions)] = true
+	}
+
+	remaining := make(map[int]int)
+	for i, p := range portions {
+		if !read[i] {
+			remaining[p.Psalm]++
+		}
+	}
+	return TotalPsalms - len(remaining)
+}
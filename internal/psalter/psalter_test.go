@@ -0,0 +1,76 @@
+package psalter_test
+
+import (
+	"testing"
+
+	"derrclan.com/moravian-soap/internal/psalter"
+	"derrclan.com/moravian-soap/internal/store"
+)
+
+func TestPortions(t *testing.T) {
+	if got := psalter.Count(); got != 171 {
+		t.Errorf("Count() = %d, want 171 (149 psalms plus 22 stanzas of Psalm 119)", got)
+	}
+	if got := psalter.Get(0).Reference; got != "Psalm 1" {
+		t.Errorf("first portion = %q, want Psalm 1", got)
+	}
+	if got := psalter.Get(118).Reference; got != "Psalm 119:1-8" {
+		t.Errorf("portion 118 = %q, want Psalm 119:1-8", got)
+	}
+	if got := psalter.Get(139).Reference; got != "Psalm 119:169-176" {
+		t.Errorf("portion 139 = %q, want Psalm 119:169-176", got)
+	}
+	if got := psalter.Get(psalter.Count() - 1).Reference; got != "Psalm 150" {
+		t.Errorf("last portion = %q, want Psalm 150", got)
+	}
+	if got := psalter.Get(psalter.Count()).Reference; got != "Psalm 1" {
+		t.Errorf("Get wraps to %q, want Psalm 1", got)
+	}
+}
+
+func TestPortionOn(t *testing.T) {
+	readings := []*store.PsalterReading{
+		{Date: "2026-01-01", Portion: 0},
+		{Date: "2026-01-03", Portion: 1},
+		{Date: "2026-01-04", Portion: 170},
+	}
+	tests := []struct {
+		date     string
+		want     int
+		wantRead bool
+	}{
+		{"2025-12-31", 0, false},
+		{"2026-01-01", 0, true},
+		{"2026-01-02", 1, false},
+		{"2026-01-03", 1, true},
+		{"2026-01-05", 0, false},
+	}
+	for _, tt := range tests {
+		got, read := psalter.PortionOn(tt.date, readings)
+		if got != tt.want || read != tt.wantRead {
+			t.Errorf("PortionOn(%s) = %d, %v; want %d, %v", tt.date, got, read, tt.want, tt.wantRead)
+		}
+	}
+}
+
+func TestPsalmsRead(t *testing.T) {
+	var readings []*store.PsalterReading
+	for i := range 118 {
+		readings = append(readings, &store.PsalterReading{Portion: i})
+	}
+	if got := psalter.PsalmsRead(readings); got != 118 {
+		t.Errorf("PsalmsRead() = %d, want 118", got)
+	}
+
+	// Psalm 119 only counts once all of its stanzas are read.
+	for i := 118; i < 139; i++ {
+		readings = append(readings, &store.PsalterReading{Portion: i})
+	}
+	if got := psalter.PsalmsRead(readings); got != 118 {
+		t.Errorf("PsalmsRead() = %d, want 118 with one stanza of Psalm 119 unread", got)
+	}
+	readings = append(readings, &store.PsalterReading{Portion: 139})
+	if got := psalter.PsalmsRead(readings); got != 119 {
+		t.Errorf("PsalmsRead() = %d, want 119", got)
+	}
+}
@@ -0,0 +1,93 @@
+// Package reminders emails users who have stopped journaling, once they have gone the number of days
+// they chose without saving an entry, and then no more often than their cool-down allows.
+package reminders
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"derrclan.com/moravian-soap/internal/clock"
+	"derrclan.com/moravian-soap/internal/config"
+	"derrclan.com/moravian-soap/internal/email"
+	"derrclan.com/moravian-soap/internal/features"
+	"derrclan.com/moravian-soap/internal/maintenance"
+	"derrclan.com/moravian-soap/internal/store"
+)
+
+// Reminders are only sent between these hours of the day in the user's timezone, so nobody is emailed
+// in the middle of the night. A reminder that comes due overnight waits for the morning.
+const (
+	firstHour = 8
+	lastHour  = 20
+)
+
+// SendDue queues an inactivity reminder for every user due one at now who has email available, linking
+// to the instance at baseURL.
+func SendDue(ctx context.Context, s store.Store, now time.Time, baseURL string) {
+	users, err := s.ListInactiveUsers(ctx, now)
+	if err != nil {
+		slog.Error("failed to list inactive users", "error", err)
+		return
+	}
+	baseURL = strings.TrimSuffix(baseURL, "/")
+	modes := config.Current().Features
+	for _, u := range users {
+		overrides, err := s.GetFeatureOverrides(ctx, u.UserID)
+		if err != nil {
+			slog.Error("failed to get feature overrides", "user_id", u.UserID, "error", err)
+			continue
+		}
+		if !modes.Enabled(features.Email, overrides) {
+			continue
+		}
+		loc, err := time.LoadLocation(u.Timezone)
+		if err != nil {
+			loc = time.UTC
+		}
+		if hour := now.In(loc).Hour(); hour < firstHour || hour > lastHour {
+			continue
+		}
+
+		days := int(now.Sub(u.LastSavedAt) / (24 * time.Hour))
+		msg := email.InactivityReminderEmail(days, baseURL+"/", baseURL+"/settings/reminders")
+		if err := email.Queue(ctx, s, u.UserID, u.Email, msg); err != nil {
+			slog.Error("failed to queue inactivity reminder", "user_id", u.UserID, "error", err)
+			continue
+		}
+		if err := s.MarkInactivityReminderSent(ctx, u.UserID, now); err != nil {
+			slog.Error("failed to mark inactivity reminder sent", "user_id", u.UserID, "error", err)
+			continue
+		}
+		slog.Info("queued inactivity reminder", "user_id", u.UserID, "days", days)
+	}
+}
+
+// Start checks every hour for users due an inactivity reminder at the time told by clk, until ctx is
+// cancelled. Checking hourly rather than once a day lets each user be reminded in their own morning.
+func Start(ctx context.Context, s store.Store, clk clock.Clock) {
+	go func() {
+		ticker := time.NewTicker(time.Hour)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				maintenance.Run(func() { SendDue(ctx, s, clk.Now(), baseURL()) })
+			case <-ctx.Done():
+				slog.Info("stopping inactivity reminder service")
+				return
+			}
+		}
+	}()
+}
+
+// baseURL returns the address of the instance that reminders link to.
+func baseURL() string {
+	if u := os.Getenv("BASE_URL"); u != "" {
+		return u
+	}
+	return "http://localhost:8080"
+}
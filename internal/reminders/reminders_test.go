@@ -0,0 +1,59 @@
+package reminders_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"derrclan.com/moravian-soap/internal/reminders"
+	"derrclan.com/moravian-soap/internal/store"
+	"derrclan.com/moravian-soap/internal/store/sqlite"
+	"derrclan.com/moravian-soap/internal/testutil"
+)
+
+func TestSendDue(t *testing.T) {
+	db := testutil.NewDB(t)
+	s := sqlite.New(db)
+	ctx := context.Background()
+
+	if _, err := db.Exec(`INSERT INTO users (id, email, password_hash, is_verified, timezone) VALUES (1, 'idle@example.com', 'x', 1, 'America/Chicago')`); err != nil {
+		t.Fatalf("failed to insert user: %v", err)
+	}
+	prefs := store.DefaultPreferences()
+	prefs.InactivityReminderDays = 3
+	if err := s.SavePreferences(ctx, 1, prefs); err != nil {
+		t.Fatalf("SavePreferences failed: %v", err)
+	}
+	if _, err := db.Exec(`INSERT INTO journal (user_id, date, timestamp) VALUES (1, '2026-03-01', '2026-03-01 12:00:00')`); err != nil {
+		t.Fatalf("failed to insert journal entry: %v", err)
+	}
+
+	queued := func() []*store.QueuedEmail {
+		t.Helper()
+		emails, err := s.ListRecentEmails(ctx, 10)
+		if err != nil {
+			t.Fatalf("ListRecentEmails failed: %v", err)
+		}
+		return emails
+	}
+
+	// 02:00 in Chicago, four days later: due, but too early in the user's day.
+	night := time.Date(2026, time.March, 5, 8, 0, 0, 0, time.UTC)
+	reminders.SendDue(ctx, s, night, "https://soap.example.com/")
+	if emails := queued(); len(emails) != 0 {
+		t.Fatalf("got %d reminders overnight, want none", len(emails))
+	}
+
+	// 09:00 in Chicago.
+	morning := night.Add(7 * time.Hour)
+	reminders.SendDue(ctx, s, morning, "https://soap.example.com/")
+	reminders.SendDue(ctx, s, morning.Add(time.Hour), "https://soap.example.com/")
+	emails := queued()
+	if len(emails) != 1 {
+		t.Fatalf("got %d reminders, want 1", len(emails))
+	}
+	if emails[0].Recipient != "idle@example.com" || !strings.Contains(emails[0].Subject, "journal is waiting") {
+		t.Errorf("unexpected reminder: %+v", emails[0])
+	}
+}
@@ -0,0 +1,285 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+
+	"derrclan.com/moravian-soap/internal/config"
+	"derrclan.com/moravian-soap/internal/dailytexts"
+	"derrclan.com/moravian-soap/internal/email"
+	"derrclan.com/moravian-soap/internal/store"
+)
+
+// isAdmin reports whether the user is the instance administrator configured by ADMIN_EMAIL.
+func isAdmin(user *store.User) bool {
+	adminEmail := os.Getenv("ADMIN_EMAIL")
+	return adminEmail != "" && user != nil && strings.EqualFold(user.Email, adminEmail)
+}
+
+// adminMiddleware restricts a route to the instance administrator.
+// It must be wrapped by authMiddleware so the user is available in the context.
+// Non-admins get a 404 so admin routes are not advertised.
+func adminMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, _ := r.Context().Value(userContextKey).(*store.User)
+		if !isAdmin(user) {
+			http.NotFound(w, r)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// handleEmailPreview renders an email template with sample data in the browser.
+// A POST queues the rendered email to the admin's own address.
+func (s *Server) handleEmailPreview(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(userContextKey).(*store.User)
+	name := r.PathValue("template")
+
+	msg, err := email.Preview(name)
+	if errors.Is(err, email.ErrUnknownTemplate) {
+		http.NotFound(w, r)
+		return
+	} else if err != nil {
+		slog.Error("failed to render email preview", "template", name, "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	var success, errMsg string
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		preview := email.Message{Subject: "[Preview] " + msg.Subject, BodyHTML: msg.BodyHTML}
+		if err := email.Queue(r.Context(), s.store, user.ID, user.Email, preview); err != nil {
+			slog.Error("failed to queue email preview", "template", name, "error", err)
+			errMsg = "Failed to queue the preview email."
+		} else {
+			success = "Preview queued for delivery to " + user.Email + "."
+		}
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	data := map[string]any{
+		"user":      user,
+		"template":  name,
+		"templates": email.PreviewNames(),
+		"subject":   msg.Subject,
+		"body":      msg.BodyHTML,
+		"Success":   success,
+		"Error":     errMsg,
+		"CSRFToken": r.Context().Value(csrfContextKey).(string),
+		"Nonce":     r.Context().Value(nonceContextKey).(string),
+	}
+	if err := s.tmpl.ExecuteTemplate(w, "email_preview.html", data); err != nil {
+		slog.Error("failed to execute email_preview template", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
+
+// ReloadConfig reloads the runtime configuration, applies it, and logs the settings that changed.
+// The previous configuration stays in effect if the new one is invalid.
+func (s *Server) ReloadConfig() ([]string, error) {
+	old, updated, err := config.Reload()
+	if err != nil {
+		return nil, fmt.Errorf("reloading configuration: %w", err)
+	}
+	ApplyConfig(updated)
+	ctx := context.Background()
+	corrections := s.reloadDailyTexts(ctx)
+	// The texts directory may have changed or gained years, or its files been corrected.
+	if err := s.indexReadings(ctx); err != nil {
+		slog.Error("failed to index daily readings", "error", err)
+	}
+
+	changes := config.Diff(old, updated)
+	for _, correction := range corrections {
+		changes = append(changes, "daily text "+correction)
+	}
+	if len(changes) == 0 {
+		slog.Info("reloaded configuration, no changes")
+	}
+	for _, change := range changes {
+		slog.Info("reloaded configuration", "change", change)
+	}
+	return changes, nil
+}
+
+// reloadDailyTexts rereads the files of the daily texts loaded so far, so that corrections to them take
+// effect, and returns the dates whose texts changed. Each change is logged and the admin is alerted by
+// email, and the cached passages of readings that were replaced are dropped.
+func (s *Server) reloadDailyTexts(ctx context.Context) []string {
+	changes, err := dailytexts.Reload()
+	if err != nil {
+		slog.Error("failed to reload daily texts", "error", err)
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+
+	passages := s.passageCache()
+	descriptions := make([]string, 0, len(changes))
+	for _, change := range changes {
+		slog.Warn("daily text changed", "date", change.Date, "change", change)
+		descriptions = append(descriptions, change.String())
+		if change.VersesChanged() && change.Old != nil && len(change.Old.Verses) > 0 {
+			if err := passages.InvalidateAll(ctx, change.Old.Verses); err != nil {
+				slog.Error("failed to drop cached passages", "date", change.Date, "error", err)
+			}
+		}
+	}
+
+	if adminEmail := os.Getenv("ADMIN_EMAIL"); adminEmail != "" {
+		admin, err := s.store.GetUserByEmail(ctx, adminEmail)
+		if err != nil {
+			slog.Error("failed to get admin user for daily text changes", "error", err)
+			return descriptions
+		}
+		if err := email.Queue(ctx, s.store, admin.ID, admin.Email, email.DailyTextsChangedEmail(descriptions)); err != nil {
+			slog.Error("failed to queue daily text changes email", "error", err)
+		}
+	}
+	return descriptions
+}
+
+// ApplyConfig applies settings that are not read at their use site.
+func ApplyConfig(cfg *config.Config) {
+	dailytexts.SetDir(cfg.TextsDir)
+	dailytexts.SetStitchYears(cfg.TextsStitchYears)
+}
+
+// handleReloadConfig reloads the runtime configuration and reports the settings that changed.
+func (s *Server) handleReloadConfig(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	changes, err := s.ReloadConfig()
+	if err != nil {
+		slog.Error("failed to reload configuration", "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	if len(changes) == 0 {
+		fmt.Fprintln(w, "Configuration reloaded, no changes.")
+		return
+	}
+	fmt.Fprintln(w, "Configuration reloaded:")
+	for _, change := range changes {
+		fmt.Fprintln(w, change)
+	}
+}
+
+// recentEmailsShown is the number of queued emails listed on the admin email page.
+const recentEmailsShown = 50
+
+// handleAdminEmails shows the delivery status of recently queued emails and the suppressed addresses.
+// A POST removes an address from the suppression list.
+func (s *Server) handleAdminEmails(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(userContextKey).(*store.User)
+
+	var success string
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		address := r.FormValue("address")
+		if address == "" {
+			http.Error(w, "Bad request", http.StatusBadRequest)
+			return
+		}
+		if err := s.store.UnsuppressEmail(r.Context(), address); err != nil {
+			slog.Error("failed to unsuppress email address", "address", address, "error", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		success = "Emails to " + address + " will be sent again."
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	emails, err := s.store.ListRecentEmails(r.Context(), recentEmailsShown)
+	if err != nil {
+		slog.Error("failed to list recent emails", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	suppressions, err := s.store.ListEmailSuppressions(r.Context())
+	if err != nil {
+		slog.Error("failed to list email suppressions", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	data := map[string]any{
+		"user":         user,
+		"emails":       emails,
+		"suppressions": suppressions,
+		"Success":      success,
+		"CSRFToken":    r.Context().Value(csrfContextKey).(string),
+		"Nonce":        r.Context().Value(nonceContextKey).(string),
+	}
+	if err := s.tmpl.ExecuteTemplate(w, "admin_emails.html", data); err != nil {
+		slog.Error("failed to execute admin_emails template", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
+
+// recentAPICallsShown is the number of calls to translations' APIs listed on the admin API log page.
+const recentAPICallsShown = 200
+
+// recentCacheVerificationsShown is the number of the cache verifier's daily checks listed on the admin
+// API log page.
+const recentCacheVerificationsShown = 30
+
+// handleAdminAPILog lists the recent calls to the translations' APIs, for troubleshooting slow or
+// missing passages, and the recent checks of cached passages with how often they had drifted.
+func (s *Server) handleAdminAPILog(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	user := r.Context().Value(userContextKey).(*store.User)
+
+	calls, err := s.store.ListAPICalls(r.Context(), recentAPICallsShown)
+	if err != nil {
+		slog.Error("failed to list API calls", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	verifications, err := s.store.ListCacheVerifications(r.Context(), recentCacheVerificationsShown)
+	if err != nil {
+		slog.Error("failed to list cache verifications", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	var checked, changed int
+	for _, v := range verifications {
+		checked += v.Checked
+		changed += len(v.Changed)
+	}
+
+	data := map[string]any{
+		"user":          user,
+		"calls":         calls,
+		"verifications": verifications,
+		"checked":       checked,
+		"changed":       changed,
+		"CSRFToken":     r.Context().Value(csrfContextKey).(string),
+		"Nonce":         r.Context().Value(nonceContextKey).(string),
+	}
+	if err := s.tmpl.ExecuteTemplate(w, "admin_api_log.html", data); err != nil {
+		slog.Error("failed to execute admin_api_log template", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,88 @@
+package server
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"derrclan.com/moravian-soap/internal/announcements"
+	"derrclan.com/moravian-soap/internal/store"
+)
+
+// unreadAnnouncements returns the announcements the user has not dismissed, newest first. None are
+// returned if the dismissals cannot be loaded, rather than showing the user news they have already seen.
+func (s *Server) unreadAnnouncements(ctx context.Context, user *store.User) []announcements.Announcement {
+	dismissed, err := s.store.GetDismissedAnnouncements(ctx, user.ID)
+	if err != nil {
+		slog.Error("failed to get dismissed announcements", "user_id", user.ID, "error", err)
+		return nil
+	}
+	return announcements.Unread(dismissed)
+}
+
+// addAnnouncement adds the newest of the unread announcements to template data for the announcement banner.
+func addAnnouncement(data map[string]any, unread []announcements.Announcement) {
+	if len(unread) == 0 {
+		return
+	}
+	data["announcement"] = unread[0]
+	data["moreAnnouncements"] = len(unread) - 1
+}
+
+// handleDismissAnnouncement records that the user has dismissed an announcement and renders the banner
+// for the next unread one, if any. A browser without JavaScript is sent back to the journal.
+func (s *Server) handleDismissAnnouncement(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	user := r.Context().Value(userContextKey).(*store.User)
+	id := r.PathValue("id")
+	if _, ok := announcements.Get(id); !ok {
+		http.NotFound(w, r)
+		return
+	}
+	if err := s.store.DismissAnnouncement(r.Context(), user.ID, id); err != nil {
+		slog.Error("failed to dismiss announcement", "user_id", user.ID, "announcement", id, "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if !isHTMX(r) {
+		http.Redirect(w, r, "/", http.StatusSeeOther)
+		return
+	}
+
+	data := map[string]any{"CSRFToken": r.Context().Value(csrfContextKey).(string)}
+	addAnnouncement(data, s.unreadAnnouncements(r.Context(), user))
+	if err := s.tmpl.ExecuteTemplate(w, "announcement.gotmpl", data); err != nil {
+		slog.Error("failed to execute announcement template", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
+
+// handleWhatsNew renders every announcement, marking those the user has not dismissed.
+func (s *Server) handleWhatsNew(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	user := r.Context().Value(userContextKey).(*store.User)
+	unread := make(map[string]bool)
+	for _, a := range s.unreadAnnouncements(r.Context(), user) {
+		unread[a.ID] = true
+	}
+
+	data := map[string]any{
+		"user":          user,
+		"announcements": announcements.All(),
+		"unread":        unread,
+		"CSRFToken":     r.Context().Value(csrfContextKey).(string),
+		"Nonce":         r.Context().Value(nonceContextKey).(string),
+	}
+	if err := s.tmpl.ExecuteTemplate(w, "whats_new.html", data); err != nil {
+		slog.Error("failed to execute whats_new template", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,261 @@
+package server
+
+import (
+	"cmp"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"derrclan.com/moravian-soap/internal/civil"
+	"derrclan.com/moravian-soap/internal/dailytexts"
+	"derrclan.com/moravian-soap/internal/journal"
+	"derrclan.com/moravian-soap/internal/store"
+)
+
+// weekDay is one day of the week-at-a-glance API, kept small for home-screen widgets.
+type weekDay struct {
+	Date      string `json:"date"`
+	Watchword string `json:"watchword,omitempty"`
+	Journaled bool   `json:"journaled"`
+	// Completeness is how much of the day's entry has been written: none, draft, partial or complete.
+	Completeness string `json:"completeness"`
+}
+
+// handleAPIWeek returns seven days of watchword references and whether the user journaled on each day.
+// The week starts on the "start" query parameter (YYYY-MM-DD) or, by default, on the first day of the
+// current week in the user's timezone, by the day they start weeks on. The response also numbers the
+// week as the user numbers weeks. Responses carry an ETag so widgets can revalidate cheaply.
+func (s *Server) handleAPIWeek(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	user := r.Context().Value(userContextKey).(*store.User)
+
+	weeks := s.userWeeks(r.Context(), user.ID)
+	var start civil.Date
+	if v := r.URL.Query().Get("start"); v != "" {
+		var err error
+		start, err = parseDate(v)
+		if err != nil {
+			http.Error(w, "Invalid start date", http.StatusBadRequest)
+			return
+		}
+	} else {
+		start = weeks.Start(s.userToday(user))
+	}
+	end := start.AddDays(6)
+
+	journaled, err := s.store.GetJournaledDates(r.Context(), user.ID, start.String(), end.String())
+	if err != nil {
+		slog.Error("failed to get journaled dates", "user_id", user.ID, "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	completeness, err := s.store.GetEntryCompleteness(r.Context(), user.ID, start.String(), end.String())
+	if err != nil {
+		slog.Error("failed to get entry completeness", "user_id", user.ID, "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	days := make([]weekDay, 0, 7)
+	for d := start; !d.After(end); d = d.AddDays(1) {
+		day := weekDay{Date: d.String()}
+		day.Journaled = slices.Contains(journaled, day.Date)
+		day.Completeness = cmp.Or(completeness[day.Date], string(journal.CompletenessNone))
+		if text, err := dailytexts.GetDailyText(day.Date); err == nil && text != nil {
+			day.Watchword = text.WatchwordReference()
+		}
+		days = append(days, day)
+	}
+
+	_, week := weeks.Number(start)
+	writeCacheableJSON(w, r, map[string]any{"days": days, "week": week})
+}
+
+// Limits on the number of days returned by the watchwords API.
+const (
+	defaultWatchwordDays = 30
+	maxWatchwordDays     = 366
+)
+
+// watchword is one day of the watchwords API. Only the reference is given, never the copyrighted text.
+type watchword struct {
+	Date string `json:"date"`
+	// Reference is the citation of the watchword, and References each of the Bible references in it.
+	Reference  string   `json:"reference"`
+	References []string `json:"references"`
+}
+
+// handleAPIWatchwords returns the watchword references of recent days, newest first, for widgets and
+// chat bots. The list ends on the "until" query parameter (YYYY-MM-DD) or, by default, on today in the
+// user's timezone, and covers "limit" days (30 by default). Days without a watchword are left out.
+func (s *Server) handleAPIWatchwords(w http.ResponseWriter, r *http.Request) {
+	days, ok := s.recentWatchwords(w, r)
+	if !ok {
+		return
+	}
+	writeCacheableJSON(w, r, map[string]any{"watchwords": days})
+}
+
+// handleAPIWatchwordsText is handleAPIWatchwords as plain text, one "date reference" line per day, so
+// the watchword can be fetched with curl, e.g. "curl -H 'Authorization: Bearer …' …/watchwords.txt?limit=1".
+func (s *Server) handleAPIWatchwordsText(w http.ResponseWriter, r *http.Request) {
+	days, ok := s.recentWatchwords(w, r)
+	if !ok {
+		return
+	}
+	var b strings.Builder
+	for _, d := range days {
+		fmt.Fprintf(&b, "%s %s\n", d.Date, d.Reference)
+	}
+	w.Header().Set("Cache-Control", "private, max-age=300")
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	if _, err := w.Write([]byte(b.String())); err != nil {
+		slog.Error("failed to write API response", "path", r.URL.Path, "error", err)
+	}
+}
+
+// recentWatchwords collects the watchwords requested from the watchwords API. It writes an error
+// response and returns false if the request is invalid.
+func (s *Server) recentWatchwords(w http.ResponseWriter, r *http.Request) ([]watchword, bool) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return nil, false
+	}
+	user := r.Context().Value(userContextKey).(*store.User)
+
+	limit := defaultWatchwordDays
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxWatchwordDays {
+			http.Error(w, fmt.Sprintf("Invalid limit, must be between 1 and %d", maxWatchwordDays), http.StatusBadRequest)
+			return nil, false
+		}
+		limit = n
+	}
+	until := s.userToday(user)
+	if v := r.URL.Query().Get("until"); v != "" {
+		var err error
+		until, err = parseDate(v)
+		if err != nil {
+			http.Error(w, "Invalid until date", http.StatusBadRequest)
+			return nil, false
+		}
+	}
+
+	days := []watchword{}
+	for d := until; d.After(until.AddDays(-limit)); d = d.AddDays(-1) {
+		text, err := dailytexts.GetDailyText(d.String())
+		if err != nil || text == nil {
+			continue
+		}
+		if ref := text.WatchwordReference(); ref != "" {
+			days = append(days, watchword{Date: d.String(), Reference: ref, References: text.WatchwordReferences()})
+		}
+	}
+	return days, true
+}
+
+// writeCacheableJSON writes v as JSON with an ETag, so API clients can revalidate cheaply, and answers
+// requests whose If-None-Match matches with 304 Not Modified.
+func writeCacheableJSON(w http.ResponseWriter, r *http.Request, v any) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		slog.Error("failed to encode API response", "path", r.URL.Path, "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:8]) + `"`
+
+	w.Header().Set("Cache-Control", "private, max-age=300")
+	w.Header().Set("ETag", etag)
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if _, err := w.Write(body); err != nil {
+		slog.Error("failed to write API response", "path", r.URL.Path, "error", err)
+	}
+}
+
+// apiMethods are the methods the API answers to, as listed to browsers in preflight responses.
+const apiMethods = "GET, POST, PUT, DELETE, OPTIONS"
+
+// apiMiddleware lets the API be called from web apps on other origins and gives its errors JSON bodies.
+// Any origin is allowed, since the API authenticates with bearer tokens rather than cookies, which
+// browsers do not send with requests allowed this way. Preflight requests are answered here, before
+// authentication, as browsers send them without the Authorization header.
+func apiMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Header().Set("Access-Control-Expose-Headers", "ETag")
+		if r.Method == http.MethodOptions {
+			w.Header().Set("Allow", apiMethods)
+			w.Header().Set("Access-Control-Allow-Methods", apiMethods)
+			w.Header().Set("Access-Control-Allow-Headers", "Authorization, Content-Type, If-None-Match")
+			w.Header().Set("Access-Control-Max-Age", "86400")
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		ew := &apiErrorWriter{ResponseWriter: w}
+		next.ServeHTTP(ew, r)
+		ew.finish()
+	})
+}
+
+// apiErrorWriter turns the plain text error responses written with http.Error into the API's JSON
+// error envelope, so that handlers and the middleware before them report errors as the rest of the
+// server does.
+type apiErrorWriter struct {
+	http.ResponseWriter
+	// status is the status of the error being held back to be rewritten, or zero.
+	status int
+	msg    strings.Builder
+}
+
+func (w *apiErrorWriter) WriteHeader(status int) {
+	if status >= http.StatusBadRequest && strings.HasPrefix(w.Header().Get("Content-Type"), "text/plain") {
+		w.status = status
+		return
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *apiErrorWriter) Write(b []byte) (int, error) {
+	if w.status != 0 {
+		return w.msg.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap returns the wrapped writer, for http.ResponseController.
+func (w *apiErrorWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// finish writes the error held back, if any.
+func (w *apiErrorWriter) finish() {
+	if w.status != 0 {
+		writeAPIError(w.ResponseWriter, w.status, strings.TrimSpace(w.msg.String()))
+	}
+}
+
+// writeAPIError writes an API error response: {"error": {"status": 404, "message": "…"}}.
+func writeAPIError(w http.ResponseWriter, status int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	body := map[string]any{"error": map[string]any{"status": status, "message": msg}}
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		slog.Error("failed to encode API error", "error", err)
+	}
+}
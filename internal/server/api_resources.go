@@ -0,0 +1,323 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"derrclan.com/moravian-soap/internal/dailytexts"
+	"derrclan.com/moravian-soap/internal/esv"
+	"derrclan.com/moravian-soap/internal/htmltext"
+	"derrclan.com/moravian-soap/internal/store"
+)
+
+// Limits on the entries and passages returned by the API.
+const (
+	apiEntriesPageSize = 20
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+	maxAPIPassages     = 10
+)
+
+// apiText is a day of the Daily Texts returned by the API.
+type apiText struct {
+	Date string `json:"date"`
+	// Watchword is the watchword as printed, and Reference and References its citation and each of the
+	// Bible references in it.
+	Watchword  string   `json:"watchword"`
+	Reference  string   `json:"reference"`
+	References []string `json:"references"`
+	// Verses are the day's readings, e.g. "John 3:16-21".
+	Verses          []string `json:"verses"`
+	Doctrinal       string   `json:"doctrinal"`
+	Prayer          string   `json:"prayer"`
+	WeeklyWatchword string   `json:"weeklyWatchword,omitempty"`
+	SpecialRemarks  []string `json:"specialRemarks,omitempty"`
+	Attribution     string   `json:"attribution"`
+}
+
+// handleAPIText returns the Daily Texts for the date in the path.
+func (s *Server) handleAPIText(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	date, err := parseDate(r.PathValue("date"))
+	if err != nil {
+		http.Error(w, "Invalid date", http.StatusBadRequest)
+		return
+	}
+	text, err := dailytexts.GetDailyText(date.String())
+	if err != nil {
+		slog.Error("failed to get daily text", "date", date, "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if text == nil {
+		http.Error(w, "No daily text for this date", http.StatusNotFound)
+		return
+	}
+
+	t := apiText{
+		Date:            date.String(),
+		Watchword:       text.DailyWatchWord,
+		Reference:       text.WatchwordReference(),
+		References:      text.WatchwordReferences(),
+		Verses:          text.Verses,
+		Doctrinal:       text.Doctrinal,
+		Prayer:          text.Prayer,
+		WeeklyWatchword: text.WeeklyWatchword,
+		SpecialRemarks:  text.SpecialRemarks,
+		Attribution:     dailytexts.Attribution,
+	}
+	if t.References == nil {
+		t.References = []string{}
+	}
+	if t.Verses == nil {
+		t.Verses = []string{}
+	}
+	writeCacheableJSON(w, r, map[string]any{"text": t})
+}
+
+// handleAPIPassages returns the text of the passages given by the "ref" query parameters, e.g.
+// "?ref=John+3:16-21&ref=Psalm+23", in the user's translation and rendered as a bare quotation like the
+// verse of the day. The passages come from the passage cache.
+func (s *Server) handleAPIPassages(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	refs := r.URL.Query()["ref"]
+	if len(refs) == 0 || len(refs) > maxAPIPassages {
+		http.Error(w, "Give between 1 and "+strconv.Itoa(maxAPIPassages)+" references", http.StatusBadRequest)
+		return
+	}
+	for _, ref := range refs {
+		if len(esv.ParseChapters(ref)) == 0 {
+			http.Error(w, "Invalid reference: "+ref, http.StatusBadRequest)
+			return
+		}
+	}
+
+	resp, err := s.fetchPassagesWithCache(r.Context(), refs, votdOptions)
+	if errors.Is(err, esv.ErrUnavailable) {
+		http.Error(w, "Passages are unavailable, try again later", http.StatusServiceUnavailable)
+		return
+	}
+	if err != nil {
+		slog.Error("failed to fetch passages", "references", refs, "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if len(resp.Passages) != len(refs) {
+		http.Error(w, "Passage not found", http.StatusNotFound)
+		return
+	}
+
+	passages := make([]votdPassage, 0, len(refs))
+	for i, passage := range resp.Passages {
+		text, err := htmltext.ToText(passage)
+		if err != nil {
+			slog.Error("failed to convert passage to text", "reference", refs[i], "error", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		passages = append(passages, votdPassage{Reference: refs[i], Text: text, HTML: passage})
+	}
+	writeCacheableJSON(w, r, map[string]any{"passages": passages, "attribution": resp.Copyright})
+}
+
+// handleAPIEntries lists the user's journal entries with anything written in them, newest first, a page
+// ("page" query parameter, from 1) at a time.
+func (s *Server) handleAPIEntries(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	user := r.Context().Value(userContextKey).(*store.User)
+
+	page := 1
+	if v := r.URL.Query().Get("page"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			http.Error(w, "Invalid page", http.StatusBadRequest)
+			return
+		}
+		page = n
+	}
+
+	// One entry more than fits on the page tells whether there is another page.
+	entries, err := s.store.ListRecentSOAPData(r.Context(), user.ID, apiEntriesPageSize+1, (page-1)*apiEntriesPageSize)
+	if err != nil {
+		slog.Error("failed to list journal entries", "user_id", user.ID, "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	hasMore := len(entries) > apiEntriesPageSize
+	if hasMore {
+		entries = entries[:apiEntriesPageSize]
+	}
+	if entries == nil {
+		entries = []*store.SOAPData{}
+	}
+	writeJSON(w, map[string]any{"entries": entries, "page": page, "hasMore": hasMore})
+}
+
+// handleAPIEntry returns (GET) or saves (PUT) the user's journal entry for the date in the path. A PUT
+// takes the entry's sections and selected verses, and, to be refused with 409 rather than overwrite
+// edits made elsewhere, the revision it is based on as "baseRevision". Sections left out are kept as
+// they were, but the selected verses are replaced. Both return the entry as saved.
+func (s *Server) handleAPIEntry(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(userContextKey).(*store.User)
+	date, err := parseDate(r.PathValue("date"))
+	if err != nil {
+		http.Error(w, "Invalid date", http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut:
+		var save soapSave
+		if err := json.NewDecoder(r.Body).Decode(&save); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		save.Date = date.String()
+		_, err := s.saveEntry(r.Context(), user, &save)
+		var refused *entrySaveError
+		switch {
+		case errors.As(err, &refused):
+			http.Error(w, refused.msg, refused.status)
+			return
+		case errors.Is(err, store.ErrConflict):
+			http.Error(w, "The entry has been changed since the base revision", http.StatusConflict)
+			return
+		case err != nil:
+			slog.Error("failed to save SOAP data", "user_id", user.ID, "date", date, "error", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	entry, err := s.store.GetSOAPData(r.Context(), user.ID, date.String())
+	if err != nil {
+		slog.Error("failed to load SOAP data", "user_id", user.ID, "date", date, "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if entry.Revision == 0 && len(entry.Sections) == 0 {
+		http.Error(w, "No entry for this date", http.StatusNotFound)
+		return
+	}
+	writeJSON(w, map[string]any{"entry": entry})
+}
+
+// handleAPISearch returns the user's journal entries containing the "q" query parameter, newest first,
+// up to "limit" of them (20 by default).
+func (s *Server) handleAPISearch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	user := r.Context().Value(userContextKey).(*store.User)
+
+	q := strings.TrimSpace(r.URL.Query().Get("q"))
+	if q == "" {
+		http.Error(w, "Missing query", http.StatusBadRequest)
+		return
+	}
+	limit := defaultSearchLimit
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxSearchLimit {
+			http.Error(w, "Invalid limit, must be between 1 and "+strconv.Itoa(maxSearchLimit), http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	entries, err := s.store.SearchSOAPData(r.Context(), user.ID, q, limit)
+	if err != nil {
+		slog.Error("failed to search journal entries", "user_id", user.ID, "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, map[string]any{"entries": entries, "query": q})
+}
+
+// apiJournalingStats summarizes the user's journaling over a period for the API. Times are in minutes.
+type apiJournalingStats struct {
+	Entries        int            `json:"entries"`
+	Minutes        int            `json:"minutes"`
+	AverageMinutes int            `json:"averageMinutes"`
+	LongestMinutes int            `json:"longestMinutes"`
+	Completeness   map[string]int `json:"completeness"`
+	QuietTimes     int            `json:"quietTimes"`
+	QuietMinutes   int            `json:"quietMinutes"`
+}
+
+// handleAPIStats returns the user's journaling stats of all time and of the last 30 days, as on the
+// stats dashboard.
+func (s *Server) handleAPIStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	user := r.Context().Value(userContextKey).(*store.User)
+
+	since := s.userToday(user).AddDays(-(statsWindowDays - 1)).String()
+	recent, err := s.apiJournalingStats(r.Context(), user.ID, since)
+	if err != nil {
+		slog.Error("failed to get journaling stats", "user_id", user.ID, "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	allTime, err := s.apiJournalingStats(r.Context(), user.ID, "")
+	if err != nil {
+		slog.Error("failed to get journaling stats", "user_id", user.ID, "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, map[string]any{"stats": map[string]any{
+		"windowDays": statsWindowDays,
+		"recent":     recent,
+		"allTime":    allTime,
+	}})
+}
+
+// apiJournalingStats collects the user's journaling stats since the date given, or of all time if it
+// is empty.
+func (s *Server) apiJournalingStats(ctx context.Context, userID int64, since string) (*apiJournalingStats, error) {
+	journaling, err := s.store.GetJournalingStats(ctx, userID, since)
+	if err != nil {
+		return nil, err
+	}
+	completeness, err := s.store.CountEntryCompleteness(ctx, userID, since)
+	if err != nil {
+		return nil, err
+	}
+	quietTime, err := s.store.GetQuietTimeStats(ctx, userID, since)
+	if err != nil {
+		return nil, err
+	}
+	if completeness == nil {
+		completeness = map[string]int{}
+	}
+	return &apiJournalingStats{
+		Entries:        journaling.Entries,
+		Minutes:        int(journaling.Total.Minutes()),
+		AverageMinutes: int(journaling.Average().Minutes()),
+		LongestMinutes: int(journaling.Longest.Minutes()),
+		Completeness:   completeness,
+		QuietTimes:     quietTime.Sessions,
+		QuietMinutes:   int(quietTime.Total.Minutes()),
+	}, nil
+}
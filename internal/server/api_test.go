@@ -0,0 +1,191 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+
+	"derrclan.com/moravian-soap/internal/clock"
+	"derrclan.com/moravian-soap/internal/store"
+	"derrclan.com/moravian-soap/internal/store/sqlite"
+)
+
+func TestHandleAPIWeek(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("failed to open test db: %v", err)
+	}
+	defer db.Close()
+	srv := &Server{db: db, store: sqlite.New(db), clock: clock.Real}
+
+	createJournalSQL := `
+	CREATE TABLE journal (
+		user_id INTEGER NOT NULL,
+		date TEXT NOT NULL,
+		selected_verses TEXT,
+		timestamp DATETIME DEFAULT CURRENT_TIMESTAMP,
+		started_at DATETIME,
+		finished_at DATETIME,
+		editing_seconds INTEGER NOT NULL DEFAULT 0,
+		completeness TEXT NOT NULL DEFAULT 'none',
+		revision INTEGER NOT NULL DEFAULT 0,
+		PRIMARY KEY (user_id, date)
+	);
+	CREATE TABLE journal_sections (
+		user_id INTEGER NOT NULL,
+		date TEXT NOT NULL,
+		section TEXT NOT NULL,
+		content TEXT NOT NULL,
+		saved_at DATETIME,
+		revision INTEGER NOT NULL DEFAULT 0,
+		PRIMARY KEY (user_id, date, section)
+	);
+	CREATE TABLE user_preferences (
+		user_id INTEGER PRIMARY KEY,
+		esv_headings INTEGER NOT NULL DEFAULT 1,
+		esv_verse_numbers INTEGER NOT NULL DEFAULT 1,
+		esv_short_copyright INTEGER NOT NULL DEFAULT 1,
+		esv_indent_poetry INTEGER NOT NULL DEFAULT 1,
+		esv_red_letter INTEGER NOT NULL DEFAULT 0,
+		journal_schema TEXT NOT NULL DEFAULT '',
+		lock_after_minutes INTEGER NOT NULL DEFAULT 0,
+		devotions_audience TEXT NOT NULL DEFAULT '',
+		inactivity_reminder_days INTEGER NOT NULL DEFAULT 0,
+		inactivity_cooldown_days INTEGER NOT NULL DEFAULT 7,
+		translation TEXT NOT NULL DEFAULT 'ESV',
+		daily_email_time TEXT NOT NULL DEFAULT '',
+		week_start INTEGER NOT NULL DEFAULT 0,
+		iso_weeks INTEGER NOT NULL DEFAULT 0
+	);`
+	if _, err := db.Exec(createJournalSQL); err != nil {
+		t.Fatalf("failed to create table: %v", err)
+	}
+	if err := srv.store.SaveSOAPData(context.Background(), 1, &store.SOAPData{Date: "2026-01-06", Sections: map[string]string{"observation": "obs"}}); err != nil {
+		t.Fatalf("failed to save SOAP data: %v", err)
+	}
+	if err := srv.store.SaveEntryCompleteness(context.Background(), 1, "2026-01-06", "partial"); err != nil {
+		t.Fatalf("failed to save entry completeness: %v", err)
+	}
+
+	user := &store.User{ID: 1, Timezone: "UTC"}
+	request := func(etag string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/week?start=2026-01-04", nil)
+		req = req.WithContext(context.WithValue(req.Context(), userContextKey, user))
+		if etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		rec := httptest.NewRecorder()
+		srv.handleAPIWeek(rec, req)
+		return rec
+	}
+
+	rec := request("")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+	var resp struct {
+		Days []weekDay `json:"days"`
+		Week int       `json:"week"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(resp.Days) != 7 || resp.Days[0].Date != "2026-01-04" || resp.Days[6].Date != "2026-01-10" {
+		t.Fatalf("unexpected days: %+v", resp.Days)
+	}
+	// Weeks start on Sunday by default, so January 4 starts the second week of 2026.
+	if resp.Week != 2 {
+		t.Errorf("week = %d, want 2", resp.Week)
+	}
+	for _, d := range resp.Days {
+		if d.Watchword == "" {
+			t.Errorf("expected watchword reference for %s", d.Date)
+		}
+		if d.Journaled != (d.Date == "2026-01-06") {
+			t.Errorf("unexpected journaled=%v for %s", d.Journaled, d.Date)
+		}
+		if want := map[bool]string{true: "partial", false: "none"}[d.Date == "2026-01-06"]; d.Completeness != want {
+			t.Errorf("completeness = %q for %s, want %q", d.Completeness, d.Date, want)
+		}
+	}
+
+	etag := rec.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("expected ETag header")
+	}
+	if rec := request(etag); rec.Code != http.StatusNotModified {
+		t.Errorf("expected 304 for matching ETag, got %d", rec.Code)
+	}
+
+	// A user numbering weeks as ISO 8601 does gets the week from Monday, numbered in ISO weeks.
+	prefs := store.DefaultPreferences()
+	prefs.ISOWeeks = true
+	if err := srv.store.SavePreferences(context.Background(), 1, prefs); err != nil {
+		t.Fatalf("failed to save preferences: %v", err)
+	}
+	srv.clock = clock.NewFake(time.Date(2026, time.January, 4, 12, 0, 0, 0, time.UTC))
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/week", nil)
+	req = req.WithContext(context.WithValue(req.Context(), userContextKey, user))
+	rec = httptest.NewRecorder()
+	srv.handleAPIWeek(rec, req)
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.Days[0].Date != "2025-12-29" || resp.Week != 1 {
+		t.Errorf("ISO week of 2026-01-04 starts on %s and is numbered %d, want 2025-12-29 and 1", resp.Days[0].Date, resp.Week)
+	}
+}
+
+func TestHandleAPIWatchwords(t *testing.T) {
+	srv := &Server{clock: clock.Real}
+	user := &store.User{ID: 1, Timezone: "UTC"}
+	request := func(handler http.HandlerFunc, query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/watchwords?"+query, nil)
+		req = req.WithContext(context.WithValue(req.Context(), userContextKey, user))
+		rec := httptest.NewRecorder()
+		handler(rec, req)
+		return rec
+	}
+
+	rec := request(srv.handleAPIWatchwords, "until=2026-01-10&limit=3")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+	var resp struct {
+		Watchwords []watchword `json:"watchwords"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	var dates []string
+	for _, w := range resp.Watchwords {
+		dates = append(dates, w.Date)
+		if w.Reference == "" {
+			t.Errorf("expected watchword reference for %s", w.Date)
+		}
+	}
+	if want := []string{"2026-01-10", "2026-01-09", "2026-01-08"}; !slices.Equal(dates, want) {
+		t.Errorf("got dates %v, want %v", dates, want)
+	}
+
+	rec = request(srv.handleAPIWatchwordsText, "until=2026-01-10&limit=1")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+	if want := "2026-01-10 " + resp.Watchwords[0].Reference + "\n"; rec.Body.String() != want {
+		t.Errorf("got plain text %q, want %q", rec.Body, want)
+	}
+
+	for _, query := range []string{"limit=0", "limit=367", "limit=ten", "until=yesterday"} {
+		if rec := request(srv.handleAPIWatchwords, query); rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", query, rec.Code)
+		}
+	}
+}
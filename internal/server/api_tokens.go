@@ -0,0 +1,158 @@
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"derrclan.com/moravian-soap/internal/config"
+	"derrclan.com/moravian-soap/internal/store"
+)
+
+// apiTokenPrefix makes personal API tokens recognizable in configuration files and secret scanners.
+const apiTokenPrefix = "soap_"
+
+// hashAPIToken returns the hex-encoded SHA-256 hash under which a token is stored.
+func hashAPIToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// bearerToken returns the personal API token from the Authorization header, if any.
+func bearerToken(r *http.Request) string {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || !strings.HasPrefix(token, apiTokenPrefix) {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
+
+// apiAuthMiddleware authenticates API requests with a personal API token, falling back to the
+// session cookie so the API can also be used from a signed-in browser. Tokens are accepted only
+// here; every other route requires a session. Requests with the session cookie are subject to the
+// privacy lock, as on the site; those with a token are not.
+func (s *Server) apiAuthMiddleware(next http.Handler) http.Handler {
+	sessionAuth := s.authMiddleware(s.lockMiddleware(next))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := bearerToken(r)
+		if token == "" {
+			sessionAuth.ServeHTTP(w, r)
+			return
+		}
+		user, err := s.store.AuthenticateAPIToken(r.Context(), hashAPIToken(token))
+		if err != nil {
+			slog.Warn("invalid API token", "path", r.URL.Path, "error", err)
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		ctx := context.WithValue(r.Context(), userContextKey, user)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// recordESVUsage attributes a request to the ESV API to the user in the context.
+func (s *Server) recordESVUsage(ctx context.Context) {
+	var userID int64
+	if user, ok := ctx.Value(userContextKey).(*store.User); ok {
+		userID = user.ID
+	}
+	day := s.clock.Now().UTC().Format(time.DateOnly)
+	if err := s.store.RecordESVUsage(ctx, userID, day, 1); err != nil {
+		slog.Error("failed to record ESV usage", "user_id", userID, "error", err)
+	}
+}
+
+// handleSettingsAPI shows the user's API tokens and ESV usage, and creates or revokes tokens.
+func (s *Server) handleSettingsAPI(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(userContextKey).(*store.User)
+
+	var newToken, errMsg string
+	if r.Method == http.MethodPost {
+		switch r.FormValue("action") {
+		case "create":
+			name := strings.TrimSpace(r.FormValue("name"))
+			if name == "" {
+				errMsg = "Token name is required"
+				break
+			}
+			token := apiTokenPrefix + generateRandomString(32)
+			if _, err := s.store.CreateAPIToken(r.Context(), user.ID, name, hashAPIToken(token)); err != nil {
+				slog.Error("failed to create API token", "user_id", user.ID, "error", err)
+				errMsg = "Failed to create token"
+				break
+			}
+			newToken = token
+		case "revoke":
+			tokenID, err := strconv.ParseInt(r.FormValue("id"), 10, 64)
+			if err != nil {
+				http.Error(w, "Bad request", http.StatusBadRequest)
+				return
+			}
+			if err := s.store.DeleteAPIToken(r.Context(), user.ID, tokenID); err != nil {
+				slog.Error("failed to revoke API token", "user_id", user.ID, "token_id", tokenID, "error", err)
+				errMsg = "Failed to revoke token"
+				break
+			}
+			http.Redirect(w, r, "/settings/api", http.StatusSeeOther)
+			return
+		default:
+			http.Error(w, "Bad request", http.StatusBadRequest)
+			return
+		}
+	} else if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	tokens, err := s.store.ListAPITokens(r.Context(), user.ID)
+	if err != nil {
+		slog.Error("failed to list API tokens", "user_id", user.ID, "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	now := s.clock.Now().UTC()
+	today := now.Format(time.DateOnly)
+	usage, err := s.store.GetESVUsage(r.Context(), user.ID, now.AddDate(0, 0, -29).Format(time.DateOnly))
+	if err != nil {
+		slog.Error("failed to get ESV usage", "user_id", user.ID, "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	var usageToday, usageMonth int
+	for _, u := range usage {
+		usageMonth += u.Requests
+		if u.Day == today {
+			usageToday = u.Requests
+		}
+	}
+	instanceToday, err := s.store.GetInstanceESVUsage(r.Context(), today)
+	if err != nil {
+		slog.Error("failed to get instance ESV usage", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	data := map[string]any{
+		"user":          user,
+		"tokens":        tokens,
+		"newToken":      newToken,
+		"Error":         errMsg,
+		"usage":         usage,
+		"usageToday":    usageToday,
+		"usageMonth":    usageMonth,
+		"instanceToday": instanceToday,
+		"quota":         config.Current().ESVDailyQuota,
+		"CSRFToken":     r.Context().Value(csrfContextKey).(string),
+		"Nonce":         r.Context().Value(nonceContextKey).(string),
+	}
+	if err := s.tmpl.ExecuteTemplate(w, "settings_api.html", data); err != nil {
+		slog.Error("failed to execute settings_api template", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
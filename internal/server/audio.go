@@ -0,0 +1,206 @@
+package server
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"derrclan.com/moravian-soap/internal/civil"
+	"derrclan.com/moravian-soap/internal/events"
+	"derrclan.com/moravian-soap/internal/journal"
+	"derrclan.com/moravian-soap/internal/maintenance"
+	"derrclan.com/moravian-soap/internal/store"
+	"derrclan.com/moravian-soap/internal/tts"
+)
+
+// newSpeechCache returns the cache of entries read aloud by the backend chosen by TTS_BACKEND, kept in
+// TTS_CACHE_DIR or beside the database at dbPath. Audio unused for TTS_CACHE_TTL, or beyond
+// TTS_CACHE_MAX_BYTES in all, is pruned. It returns nil if no backend is chosen.
+func newSpeechCache(dbPath string) (*tts.Cache, error) {
+	backend, err := tts.FromEnv()
+	if err != nil || backend == nil {
+		return nil, err
+	}
+	dir := cmp.Or(os.Getenv("TTS_CACHE_DIR"), filepath.Join(filepath.Dir(dbPath), "tts-cache"))
+	cache, err := tts.NewCache(dir, backend)
+	if err != nil {
+		return nil, err
+	}
+	cache.MaxAge, cache.MaxBytes = 28*24*time.Hour, 256<<20
+	if v := os.Getenv("TTS_CACHE_TTL"); v != "" {
+		if cache.MaxAge, err = time.ParseDuration(v); err != nil || cache.MaxAge <= 0 {
+			return nil, fmt.Errorf("TTS_CACHE_TTL: must be a positive duration, got %q", v)
+		}
+	}
+	if v := os.Getenv("TTS_CACHE_MAX_BYTES"); v != "" {
+		if cache.MaxBytes, err = strconv.ParseInt(v, 10, 64); err != nil || cache.MaxBytes < 0 {
+			return nil, fmt.Errorf("TTS_CACHE_MAX_BYTES: must be a number of bytes, got %q", v)
+		}
+	}
+	return cache, nil
+}
+
+// startSpeechPruner prunes the cached speech now and then once a day, until ctx is done. It does
+// nothing if no text-to-speech backend is configured.
+func (s *Server) startSpeechPruner(ctx context.Context) {
+	if s.speech == nil {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(24 * time.Hour)
+		defer ticker.Stop()
+		for {
+			maintenance.Run(func() { s.pruneSpeech() })
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// pruneSpeech prunes the cached speech, logging any failure.
+func (s *Server) pruneSpeech() {
+	n, err := s.speech.Prune(s.clock.Now())
+	if err != nil {
+		slog.Error("failed to prune cached speech", "error", err)
+		return
+	}
+	slog.Debug("pruned cached speech", "removed", n)
+}
+
+// removeSpeech removes the cached speech of the user whose entry was deleted, so that no audio of it is
+// left on disk. The audio of their other entries is synthesized again when next listened to.
+func (s *Server) removeSpeech(_ context.Context, e events.Event) {
+	if s.speech == nil {
+		return
+	}
+	if err := s.speech.RemoveUser(e.UserID); err != nil {
+		slog.Error("failed to remove cached speech", "user_id", e.UserID, "error", err)
+	}
+}
+
+// entryScript returns the text an entry is read aloud from: its date, then the name and content of
+// each section written. It is empty if nothing was written.
+func entryScript(date civil.Date, entry *store.SOAPData, schema journal.Schema) string {
+	var b strings.Builder
+	for _, sec := range schema.Entries(entry.Sections) {
+		if content := strings.TrimSpace(sec.Content); content != "" {
+			fmt.Fprintf(&b, "%s.\n\n%s\n\n", sec.Name, content)
+		}
+	}
+	if b.Len() == 0 {
+		return ""
+	}
+	return date.Time().Format("Monday, January 2, 2006") + ".\n\n" + b.String()
+}
+
+// handleEntryAudio serves the user's journal entry for the requested date read aloud.
+func (s *Server) handleEntryAudio(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	user := r.Context().Value(userContextKey).(*store.User)
+	date := requestDate(r)
+
+	entry, err := s.store.GetSOAPData(r.Context(), user.ID, date.String())
+	if err != nil {
+		slog.Error("failed to get SOAP data", "date", date, "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	s.serveSpeech(w, r, user.ID, "entry-"+date.String(), entryScript(date, entry, s.journalSchema(r.Context())))
+}
+
+// handleWeekAudio serves a digest of the user's week read aloud: the entries of the week that contains
+// the requested date, from the day the user starts weeks on, one after another.
+func (s *Server) handleWeekAudio(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	user := r.Context().Value(userContextKey).(*store.User)
+	date := requestDate(r)
+	start := s.userWeeks(r.Context(), user.ID).Start(date)
+
+	script, err := s.weekScript(r.Context(), user.ID, start)
+	if err != nil {
+		slog.Error("failed to read week aloud", "user_id", user.ID, "start", start, "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	s.serveSpeech(w, r, user.ID, "week-"+start.String(), script)
+}
+
+// weekScript returns the text the week starting on start is read aloud from, which is empty if the
+// user wrote nothing that week.
+func (s *Server) weekScript(ctx context.Context, userID int64, start civil.Date) (string, error) {
+	end := start.AddDays(6)
+	dates, err := s.store.GetJournaledDates(ctx, userID, start.String(), end.String())
+	if err != nil {
+		return "", fmt.Errorf("getting journaled dates: %w", err)
+	}
+	schema := s.journalSchema(ctx)
+	var scripts []string
+	for d := start; !d.After(end); d = d.AddDays(1) {
+		if !slices.Contains(dates, d.String()) {
+			continue
+		}
+		entry, err := s.store.GetSOAPData(ctx, userID, d.String())
+		if err != nil {
+			return "", fmt.Errorf("getting entry for %s: %w", d, err)
+		}
+		if script := entryScript(d, entry, schema); script != "" {
+			scripts = append(scripts, script)
+		}
+	}
+	if len(scripts) == 0 {
+		return "", nil
+	}
+	return "Your journal for the week of " + start.Time().Format("January 2, 2006") + ".\n\n" + strings.Join(scripts, "\n"), nil
+}
+
+// serveSpeech serves script read aloud for the user with the given ID, with support for range requests
+// so that players can seek. The audio is downloaded as name, with the extension of its format.
+func (s *Server) serveSpeech(w http.ResponseWriter, r *http.Request, userID int64, name, script string) {
+	if s.speech == nil {
+		http.NotFound(w, r)
+		return
+	}
+	if script == "" {
+		http.Error(w, "There is nothing to read aloud", http.StatusNotFound)
+		return
+	}
+	f, err := s.speech.Open(r.Context(), userID, script)
+	if err != nil {
+		slog.Error("failed to synthesize speech", "name", name, "error", err)
+		http.Error(w, "Failed to read the entry aloud", http.StatusBadGateway)
+		return
+	}
+	defer func() { _ = f.Close() }()
+	info, err := f.Stat()
+	if err != nil {
+		slog.Error("failed to stat speech", "name", name, "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	format := s.speech.Format()
+	w.Header().Set("Content-Type", format.ContentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=%q", name+format.Ext))
+	// The audio changes whenever the entry does, so browsers check that theirs is current. Its file is
+	// named by a hash of the text, which makes a strong ETag.
+	w.Header().Set("Cache-Control", "private, no-cache")
+	w.Header().Set("ETag", `"`+strings.TrimSuffix(filepath.Base(f.Name()), format.Ext)+`"`)
+	http.ServeContent(w, r, name+format.Ext, info.ModTime(), f)
+}
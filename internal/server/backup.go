@@ -0,0 +1,161 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"derrclan.com/moravian-soap/internal/export"
+	"derrclan.com/moravian-soap/internal/journal"
+	"derrclan.com/moravian-soap/internal/store"
+)
+
+// maxBackupSize limits the size of an uploaded backup.
+const maxBackupSize = 32 << 20
+
+// handleSettingsBackup shows the backup page and restores an uploaded backup.
+func (s *Server) handleSettingsBackup(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(userContextKey).(*store.User)
+
+	var restored bool
+	var errMsg string
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		file, _, err := r.FormFile("backup")
+		if err != nil {
+			errMsg = "Choose a backup file to restore"
+			break
+		}
+		defer file.Close()
+
+		backup, err := export.ReadBackup(http.MaxBytesReader(w, file, maxBackupSize))
+		if errors.Is(err, export.ErrUnsupportedBackupVersion) {
+			errMsg = "This backup was made by a newer version of Daily SOAP and cannot be restored"
+			break
+		}
+		if err != nil {
+			slog.Warn("failed to read backup", "user_id", user.ID, "error", err)
+			errMsg = "This file is not a Daily SOAP backup"
+			break
+		}
+		if err := s.restoreBackup(r.Context(), user.ID, backup); err != nil {
+			slog.Error("failed to restore backup", "user_id", user.ID, "error", err)
+			errMsg = "Failed to restore backup"
+			break
+		}
+		restored = true
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	yearbooks, err := s.store.ListYearbooks(r.Context(), user.ID)
+	if err != nil {
+		slog.Error("failed to list yearbooks", "user_id", user.ID, "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	years, err := s.journaledYears(r, user.ID)
+	if err != nil {
+		slog.Error("failed to list journaled years", "user_id", user.ID, "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	data := map[string]any{
+		"user":      user,
+		"restored":  restored,
+		"yearbooks": yearbooks,
+		"years":     years,
+		"Error":     errMsg,
+		"CSRFToken": r.Context().Value(csrfContextKey).(string),
+		"Nonce":     r.Context().Value(nonceContextKey).(string),
+	}
+	if err := s.tmpl.ExecuteTemplate(w, "settings_backup.html", data); err != nil {
+		slog.Error("failed to execute settings_backup template", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
+
+// handleBackup downloads a backup of the user's journal, passage entries and prayer list.
+func (s *Server) handleBackup(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	user := r.Context().Value(userContextKey).(*store.User)
+
+	entries, err := s.store.ListSOAPData(r.Context(), user.ID)
+	if err != nil {
+		slog.Error("failed to list journal entries for backup", "user_id", user.ID, "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	passages, err := s.store.ListPassageEntries(r.Context(), user.ID)
+	if err != nil {
+		slog.Error("failed to list passage entries for backup", "user_id", user.ID, "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	items, err := s.store.ListPrayerItems(r.Context(), user.ID)
+	if err != nil {
+		slog.Error("failed to list prayer items for backup", "user_id", user.ID, "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	filename := fmt.Sprintf("soap-backup-%s.json", s.clock.Now().Format(time.DateOnly))
+	w.Header().Set("Content-Type", export.BackupContentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+	if err := export.WriteBackup(w, &export.Backup{Entries: entries, PassageEntries: passages, PrayerItems: items}); err != nil {
+		slog.Error("failed to write backup", "user_id", user.ID, "error", err)
+	}
+}
+
+// restoreBackup saves the entries, passage entries and prayer items in backup to the user's account in
+// one transaction. Entries replace the sections they contain, and passage entries and prayer items
+// already in the account, matched by reference or text and creation time, are updated rather than
+// added again, so restoring the same backup twice changes nothing.
+func (s *Server) restoreBackup(ctx context.Context, userID int64, backup *export.Backup) error {
+	entries := make([]*store.RestoredEntry, 0, len(backup.Entries))
+	for _, entry := range backup.Entries {
+		if _, err := parseDate(entry.Date); err != nil {
+			return fmt.Errorf("invalid entry date %q: %w", entry.Date, err)
+		}
+		for key := range entry.Sections {
+			if !journal.ValidSectionKey(key) {
+				return fmt.Errorf("invalid section %q in entry for %s", key, entry.Date)
+			}
+		}
+		entries = append(entries, &store.RestoredEntry{
+			Entry:    entry,
+			Links:    journalLinks(entry),
+			Chapters: s.journalChapters(ctx, entry),
+		})
+	}
+	for _, entry := range backup.PassageEntries {
+		if err := validatePassageEntry(entry); err != nil {
+			return err
+		}
+	}
+	for _, item := range backup.PrayerItems {
+		if _, err := parseDate(item.CreatedDate); err != nil {
+			return fmt.Errorf("invalid prayer item date %q: %w", item.CreatedDate, err)
+		}
+	}
+
+	if err := s.store.RestoreJournal(ctx, userID, entries, backup.PassageEntries, backup.PrayerItems); err != nil {
+		return fmt.Errorf("restoring journal: %w", err)
+	}
+
+	dates := make([]string, 0, len(backup.Entries))
+	for _, entry := range backup.Entries {
+		dates = append(dates, entry.Date)
+	}
+	s.updateCompleteness(ctx, userID, s.journalSchema(ctx), dates...)
+	return nil
+}
@@ -9,26 +9,28 @@ import (
 
 	_ "github.com/mattn/go-sqlite3"
 
+	"derrclan.com/moravian-soap/internal/clock"
 	"derrclan.com/moravian-soap/internal/esv"
 	"derrclan.com/moravian-soap/internal/store/sqlite"
 )
 
 func TestFetchPassagesWithCache_Hit(t *testing.T) {
 	// 1. Setup in-memory DB
-	var err error
-	db, err = sql.Open("sqlite3", ":memory:")
+	db, err := sql.Open("sqlite3", ":memory:")
 	if err != nil {
 		t.Fatalf("failed to open test db: %v", err)
 	}
 	defer db.Close()
-	appStore = sqlite.New(db)
+	srv := &Server{db: db, store: sqlite.New(db), clock: clock.Real}
 
 	// 2. Create table
 	createCacheSQL := `
 	CREATE TABLE esv_cache (
 		reference TEXT PRIMARY KEY,
 		content TEXT NOT NULL,
-		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		size_bytes INTEGER NOT NULL DEFAULT 0,
+		last_accessed DATETIME
 	);`
 	if _, err := db.Exec(createCacheSQL); err != nil {
 		t.Fatalf("failed to create table: %v", err)
@@ -48,8 +50,7 @@ func TestFetchPassagesWithCache_Hit(t *testing.T) {
 	}
 
 	// 4. Call function under test
-	// Note: fetchPassagesWithCache uses the global 'db' variable which we set above
-	result, err := fetchPassagesWithCache(context.TODO(), []string{fakeRef})
+	result, err := srv.fetchPassagesWithCache(context.TODO(), []string{fakeRef}, esv.DefaultOptions())
 	if err != nil {
 		t.Fatalf("fetchPassagesWithCache failed: %v", err)
 	}
@@ -59,3 +60,48 @@ func TestFetchPassagesWithCache_Hit(t *testing.T) {
 		t.Errorf("expected %v, got %v", fakeResponse, result)
 	}
 }
+
+func TestPrerenderPassages_CachedBatch(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("failed to open test db: %v", err)
+	}
+	defer db.Close()
+	srv := &Server{db: db, store: sqlite.New(db), clock: clock.Real}
+
+	if _, err := db.Exec(`
+	CREATE TABLE esv_cache (
+		reference TEXT PRIMARY KEY,
+		content TEXT NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		size_bytes INTEGER NOT NULL DEFAULT 0,
+		last_accessed DATETIME
+	);`); err != nil {
+		t.Fatalf("failed to create table: %v", err)
+	}
+
+	want := map[string]esv.Response{}
+	for _, ref := range []string{"Test 1:1", "Test 2:2"} {
+		response := esv.Response{Query: ref, Passages: []string{"<p>" + ref + "</p>"}}
+		responseBytes, _ := json.Marshal(response)
+		if _, err := db.Exec("INSERT INTO esv_cache (reference, content) VALUES (?, ?)", ref, string(responseBytes)); err != nil {
+			t.Fatalf("failed to insert fake cache: %v", err)
+		}
+		want[ref] = response
+	}
+
+	// Every set is cached, so the ESV API is never called.
+	sets := [][]string{{"Test 2:2"}, {"Test 1:1"}, {"Test 2:2"}}
+	got, err := srv.prerenderPassages(context.TODO(), sets, esv.DefaultOptions())
+	if err != nil {
+		t.Fatalf("prerenderPassages failed: %v", err)
+	}
+	if len(got) != len(sets) {
+		t.Fatalf("expected %d responses, got %d", len(sets), len(got))
+	}
+	for i, set := range sets {
+		if !reflect.DeepEqual(got[i], want[set[0]]) {
+			t.Errorf("response %d: expected %v, got %v", i, want[set[0]], got[i])
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package server
+
+import (
+	"context"
+	"log/slog"
+
+	"derrclan.com/moravian-soap/internal/journal"
+)
+
+// updateCompleteness recomputes the completeness of the user's entries for dates under schema. The
+// saved entries are read back because a save only carries the sections that changed.
+func (s *Server) updateCompleteness(ctx context.Context, userID int64, schema journal.Schema, dates ...string) {
+	for _, date := range dates {
+		soapData, err := s.store.GetSOAPData(ctx, userID, date)
+		if err != nil {
+			slog.Error("failed to get SOAP data for completeness", "date", date, "error", err)
+			continue
+		}
+		completeness := schema.Completeness(soapData.Sections, soapData.SelectedVerses)
+		if err := s.store.SaveEntryCompleteness(ctx, userID, date, string(completeness)); err != nil {
+			slog.Error("failed to save entry completeness", "date", date, "error", err)
+		}
+	}
+}
+
+// updateAllCompleteness recomputes the completeness of all of the user's entries, after they change
+// the sections they journal in.
+func (s *Server) updateAllCompleteness(ctx context.Context, userID int64, schema journal.Schema) {
+	entries, err := s.store.ListSOAPData(ctx, userID)
+	if err != nil {
+		slog.Error("failed to list SOAP data for completeness", "user_id", userID, "error", err)
+		return
+	}
+	for _, e := range entries {
+		completeness := schema.Completeness(e.Sections, e.SelectedVerses)
+		if err := s.store.SaveEntryCompleteness(ctx, userID, e.Date, string(completeness)); err != nil {
+			slog.Error("failed to save entry completeness", "date", e.Date, "error", err)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"derrclan.com/moravian-soap/internal/dailytexts"
+	"derrclan.com/moravian-soap/internal/esv"
+	"derrclan.com/moravian-soap/internal/store"
+)
+
+// concordanceBook is a book of the Bible in the concordance, with its chapters in order.
+type concordanceBook struct {
+	Name     string
+	Chapters []concordanceChapter
+}
+
+// concordanceChapter is a chapter in the concordance with the dates it appeared on.
+type concordanceChapter struct {
+	Name      string
+	Dates     []store.ConcordanceDate
+	Journaled int
+}
+
+// indexReadings records the chapters of every day's readings in the available daily texts, which the
+// concordance lists alongside the chapters of the user's entries. Each year is indexed afresh, so that
+// changed year files are picked up.
+func (s *Server) indexReadings(ctx context.Context) error {
+	years, err := dailytexts.Years()
+	if err != nil {
+		return err
+	}
+	for _, year := range years {
+		readings := make(map[string][]store.Chapter)
+		start := time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+		for d := start; d.Year() == year; d = d.AddDate(0, 0, 1) {
+			date := d.Format(time.DateOnly)
+			dailyText, err := dailytexts.GetDailyText(date)
+			if err != nil {
+				return fmt.Errorf("reading daily text for %s: %w", date, err)
+			}
+			if dailyText == nil {
+				continue
+			}
+			for _, verses := range dailyText.Verses {
+				for _, c := range esv.ParseChapters(verses) {
+					readings[date] = append(readings[date], store.Chapter{Book: c.Book, Chapter: c.Chapter})
+				}
+			}
+		}
+		if err := s.store.SaveReadingChapters(ctx, year, readings); err != nil {
+			return err
+		}
+	}
+	slog.Info("indexed daily readings", "years", years)
+	return nil
+}
+
+// concordanceBooks groups the chapters of the concordance by book for display.
+func concordanceBooks(chapters []*store.ConcordanceChapter) []concordanceBook {
+	var books []concordanceBook
+	for i, c := range chapters {
+		if i == 0 || chapters[i-1].Chapter.Book != c.Chapter.Book {
+			books = append(books, concordanceBook{Name: esv.BookName(c.Chapter.Book)})
+		}
+		chapter := concordanceChapter{
+			Name:  esv.Chapter{Book: c.Chapter.Book, Chapter: c.Chapter.Chapter}.String(),
+			Dates: c.Dates,
+		}
+		for _, d := range c.Dates {
+			if d.Journaled {
+				chapter.Journaled++
+			}
+		}
+		books[len(books)-1].Chapters = append(books[len(books)-1].Chapters, chapter)
+	}
+	return books
+}
+
+// handleConcordance lists every chapter in the user's daily readings since their first journal entry,
+// and in their entries, with links to the dates it appeared on and whether they journaled each day.
+func (s *Server) handleConcordance(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	user := r.Context().Value(userContextKey).(*store.User)
+	today := s.userToday(user).String()
+
+	journaled, err := s.store.GetJournaledDates(r.Context(), user.ID, "0000-01-01", today)
+	if err != nil {
+		slog.Error("failed to get journaled dates", "user_id", user.ID, "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	var books []concordanceBook
+	if len(journaled) > 0 {
+		chapters, err := s.store.GetConcordance(r.Context(), user.ID, journaled[0], today)
+		if err != nil {
+			slog.Error("failed to get concordance", "user_id", user.ID, "error", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		books = concordanceBooks(chapters)
+	}
+
+	data := map[string]any{
+		"user":      user,
+		"books":     books,
+		"CSRFToken": r.Context().Value(csrfContextKey).(string),
+		"Nonce":     r.Context().Value(nonceContextKey).(string),
+	}
+	if err := s.tmpl.ExecuteTemplate(w, "concordance.html", data); err != nil {
+		slog.Error("failed to execute concordance template", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
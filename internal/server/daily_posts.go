@@ -0,0 +1,134 @@
+package server
+
+import (
+	"log/slog"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"derrclan.com/moravian-soap/internal/dailypost"
+	"derrclan.com/moravian-soap/internal/store"
+)
+
+// handleAdminDailyPosts manages the Discord and Slack channels that the daily watchword is posted to.
+// A POST adds a channel, deletes one, or sends one today's post right away as a test.
+func (s *Server) handleAdminDailyPosts(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(userContextKey).(*store.User)
+
+	var success, errMsg string
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		switch r.FormValue("action") {
+		case "create":
+			ch := &store.DailyPostChannel{
+				Name:       strings.TrimSpace(r.FormValue("name")),
+				Kind:       r.FormValue("kind"),
+				WebhookURL: strings.TrimSpace(r.FormValue("webhook_url")),
+				Template:   strings.TrimSpace(r.FormValue("template")),
+				PostTime:   r.FormValue("post_time"),
+				Timezone:   r.FormValue("timezone"),
+			}
+			if err := dailypost.Validate(ch); err != nil {
+				errMsg = "Could not add the channel: " + err.Error() + "."
+				break
+			}
+			if err := s.store.CreateDailyPostChannel(r.Context(), ch); err != nil {
+				slog.Error("failed to create daily post channel", "error", err)
+				http.Error(w, "Internal server error", http.StatusInternalServerError)
+				return
+			}
+			success = ch.Name + " will get the watchword every day at " + ch.PostTime + "."
+		case "delete":
+			id, err := strconv.ParseInt(r.FormValue("id"), 10, 64)
+			if err != nil {
+				http.Error(w, "Bad request", http.StatusBadRequest)
+				return
+			}
+			if err := s.store.DeleteDailyPostChannel(r.Context(), id); err != nil {
+				slog.Error("failed to delete daily post channel", "id", id, "error", err)
+				http.Error(w, "Internal server error", http.StatusInternalServerError)
+				return
+			}
+			success = "The channel has been removed."
+		case "test":
+			id, err := strconv.ParseInt(r.FormValue("id"), 10, 64)
+			if err != nil {
+				http.Error(w, "Bad request", http.StatusBadRequest)
+				return
+			}
+			success, errMsg = s.sendTestDailyPost(r, id)
+		default:
+			http.Error(w, "Bad request", http.StatusBadRequest)
+			return
+		}
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	channels, err := s.store.ListDailyPostChannels(r.Context())
+	if err != nil {
+		slog.Error("failed to list daily post channels", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	data := map[string]any{
+		"user":     user,
+		"channels": channels,
+		"defaultTemplates": map[string]string{
+			dailypost.Discord: dailypost.DefaultTemplate(dailypost.Discord),
+			dailypost.Slack:   dailypost.DefaultTemplate(dailypost.Slack),
+		},
+		"timezone":  user.Timezone,
+		"Success":   success,
+		"Error":     errMsg,
+		"CSRFToken": r.Context().Value(csrfContextKey).(string),
+		"Nonce":     r.Context().Value(nonceContextKey).(string),
+	}
+	if err := s.tmpl.ExecuteTemplate(w, "admin_daily_posts.html", data); err != nil {
+		slog.Error("failed to execute admin_daily_posts template", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
+
+// sendTestDailyPost sends today's post to a channel without recording it, so the day's scheduled post
+// is still sent. It returns a message describing the outcome.
+func (s *Server) sendTestDailyPost(r *http.Request, id int64) (success, errMsg string) {
+	channels, err := s.store.ListDailyPostChannels(r.Context())
+	if err != nil {
+		slog.Error("failed to list daily post channels", "error", err)
+		return "", "Failed to load the channel."
+	}
+	i := slices.IndexFunc(channels, func(ch *store.DailyPostChannel) bool { return ch.ID == id })
+	if i < 0 {
+		return "", "The channel no longer exists."
+	}
+	ch := channels[i]
+
+	loc, err := time.LoadLocation(ch.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	baseURL := os.Getenv("BASE_URL")
+	if baseURL == "" {
+		baseURL = "http://localhost:8080"
+	}
+	post, err := dailypost.NewPost(s.todayIn(loc), baseURL)
+	if err != nil || post == nil {
+		return "", "There is no daily text to post today."
+	}
+	content, err := dailypost.Render(ch, post)
+	if err != nil {
+		return "", "Failed to render the post: " + err.Error() + "."
+	}
+	if err := dailypost.Send(r.Context(), ch, content); err != nil {
+		slog.Warn("failed to send test daily post", "channel", ch.Name, "error", err)
+		return "", "Failed to send the post: " + err.Error() + "."
+	}
+	return "Today's post was sent to " + ch.Name + ".", ""
+}
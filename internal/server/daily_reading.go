@@ -0,0 +1,196 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"derrclan.com/moravian-soap/internal/civil"
+	"derrclan.com/moravian-soap/internal/config"
+	"derrclan.com/moravian-soap/internal/dailytexts"
+	"derrclan.com/moravian-soap/internal/esv"
+	"derrclan.com/moravian-soap/internal/features"
+	"derrclan.com/moravian-soap/internal/markdown"
+	"derrclan.com/moravian-soap/internal/psalter"
+	"derrclan.com/moravian-soap/internal/store"
+)
+
+// errNoDailyText is returned when loading the reading of a day that has no daily text.
+var errNoDailyText = errors.New("no daily text")
+
+// dailyReading is the part of a day's reading page that is the same for everyone who reads it with the
+// same passage options: its passages and how long they take to read.
+type dailyReading struct {
+	passages esv.Response
+	// loaded is false if the passages were not ready within the page budget; passages is then empty.
+	loaded  bool
+	lengths []passageLength
+	total   passageLength
+	// references are the day's readings, set only if the ESV API is unavailable and passages is empty,
+	// so that the page can list them instead.
+	references []string
+	// doctrinal is the day's doctrinal text, or nil if it has none, and prayer its prayer.
+	doctrinal *doctrinalText
+	prayer    string
+	// commentary is the deployment's commentary on the day, if it supplies any.
+	commentary []commentaryNote
+}
+
+// commentaryNote is a note of the commentary shown below a day's passages.
+type commentaryNote struct {
+	// Reference is the passage the note is on, or empty if it is on the day.
+	Reference string
+	Title     string
+	Body      template.HTML
+	Source    string
+}
+
+// doctrinalText is the doctrinal text shown after a day's reading.
+type doctrinalText struct {
+	// Text is the doctrinal text as printed, and Reference the citation it ends with, if it quotes
+	// scripture.
+	Text      string
+	Reference string
+	// Passages are the passages the doctrinal text quotes, rendered like the readings, or empty if it
+	// quotes none or they could not be fetched in time, in which case it is shown as printed.
+	Passages esv.Response
+}
+
+// loadDailyReading returns the reading for date with its passages rendered with opts, or errNoDailyText.
+// Concurrent loads of the same date with the same options wait for the first and share its result, which
+// must not be modified.
+func (s *Server) loadDailyReading(ctx context.Context, date string, opts esv.Options) (*dailyReading, error) {
+	loaded := s.dailyReadings.DoChan(date+"|"+opts.CacheKey(), func() (any, error) {
+		// The load is shared, so it must not be cut short when the request that started it goes away;
+		// fetchPassagesWithinBudget bounds how long it waits for the ESV API.
+		ctx := context.WithoutCancel(ctx)
+		text, err := dailytexts.GetDailyText(date)
+		if err != nil {
+			return nil, fmt.Errorf("getting daily text for %s: %w", date, err)
+		}
+		if text == nil {
+			return nil, errNoDailyText
+		}
+		// The doctrinal text's passages and the commentary are fetched alongside the readings so as not
+		// to add to the wait.
+		doctrinal := make(chan *doctrinalText, 1)
+		go func() { doctrinal <- s.loadDoctrinalText(ctx, date, text, opts) }()
+		notes := make(chan []commentaryNote, 1)
+		go func() { notes <- s.loadCommentary(ctx, date, text) }()
+		passages, ok, err := s.fetchPassagesWithinBudget(ctx, text.Verses, opts)
+		if errors.Is(err, esv.ErrUnavailable) {
+			slog.Warn("ESV API unavailable, listing the references instead", "date", date, "error", err)
+			return &dailyReading{loaded: true, references: text.Verses, doctrinal: <-doctrinal, prayer: text.Prayer, commentary: <-notes}, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		reading := &dailyReading{passages: passages, loaded: ok, doctrinal: <-doctrinal, prayer: text.Prayer, commentary: <-notes}
+		reading.lengths, reading.total = readingLengths(passages)
+		return reading, nil
+	})
+	select {
+	case res := <-loaded:
+		if res.Err != nil {
+			return nil, res.Err
+		}
+		return res.Val.(*dailyReading), nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// loadDoctrinalText returns the doctrinal text of text, the daily text for date, with the passages it
+// quotes rendered with opts if they can be fetched within the page budget, or nil if it has none.
+func (s *Server) loadDoctrinalText(ctx context.Context, date string, text *dailytexts.DailyText, opts esv.Options) *doctrinalText {
+	if text.Doctrinal == "" {
+		return nil
+	}
+	doctrinal := &doctrinalText{Text: text.Doctrinal, Reference: text.DoctrinalReference()}
+	refs := text.DoctrinalReferences()
+	if len(refs) == 0 {
+		return doctrinal
+	}
+	passages, ok, err := s.fetchPassagesWithinBudget(ctx, refs, opts)
+	if err != nil {
+		slog.Warn("failed to fetch doctrinal text passages, showing it as printed", "date", date, "references", refs, "error", err)
+		return doctrinal
+	}
+	if ok && len(passages.Passages) == len(refs) {
+		doctrinal.Passages = passages
+	}
+	return doctrinal
+}
+
+// loadCommentary returns the deployment's commentary on text, the daily text for date, with the bodies
+// of its notes rendered, or none if it supplies none or it is not ready within the page budget.
+func (s *Server) loadCommentary(ctx context.Context, date string, text *dailytexts.DailyText) []commentaryNote {
+	if s.commentary == nil {
+		return nil
+	}
+	day, err := civil.ParseDate(date)
+	if err != nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(float64(config.Current().PageBudget)*esvBudgetShare))
+	defer cancel()
+	notes, err := s.commentary.For(ctx, day, text.Verses)
+	if err != nil {
+		slog.Warn("failed to load commentary", "date", date, "error", err)
+		return nil
+	}
+	rendered := make([]commentaryNote, 0, len(notes))
+	for _, n := range notes {
+		rendered = append(rendered, commentaryNote{Reference: n.Reference, Title: n.Title, Body: markdown.Render(n.Body), Source: n.Source})
+	}
+	return rendered
+}
+
+// dailyReadingOrError loads the reading for date for the request, or responds with an error and reports
+// false if it cannot be loaded.
+func (s *Server) dailyReadingOrError(w http.ResponseWriter, r *http.Request, date string) (*dailyReading, bool) {
+	reading, err := s.loadDailyReading(r.Context(), date, s.passageOptions(r.Context()))
+	if errors.Is(err, errNoDailyText) {
+		slog.Warn("no data found for date", "date", date)
+		http.Error(w, fmt.Sprintf("No data found for date: %s", date), http.StatusNotFound)
+		return nil, false
+	}
+	if err != nil {
+		slog.Error("failed to load daily reading", "date", date, "error", err)
+		http.Error(w, fmt.Sprintf("Error loading data for date: %s", date), http.StatusInternalServerError)
+		return nil, false
+	}
+	return reading, true
+}
+
+// selectableVerses returns the verses the user may select in their entry for date: those of the day's
+// readings and, for psalter readers, of the day's psalter portion.
+func (s *Server) selectableVerses(ctx context.Context, user *store.User, date string) (esv.VerseSet, error) {
+	text, err := dailytexts.GetDailyText(date)
+	if err != nil {
+		return esv.VerseSet{}, fmt.Errorf("getting daily text for %s: %w", date, err)
+	}
+	var refs []string
+	if text != nil {
+		refs = append(refs, text.Verses...)
+	}
+	if featureEnabled(user, features.Psalter) {
+		reader, err := s.store.IsPsalterReader(ctx, user.ID)
+		if err != nil {
+			return esv.VerseSet{}, fmt.Errorf("checking psalter mode: %w", err)
+		}
+		if reader {
+			readings, err := s.store.GetPsalterReadings(ctx, user.ID)
+			if err != nil {
+				return esv.VerseSet{}, fmt.Errorf("getting psalter readings: %w", err)
+			}
+			index, _ := psalter.PortionOn(date, readings)
+			refs = append(refs, psalter.Get(index).Reference)
+		}
+	}
+	return esv.NewVerseSet(refs...), nil
+}
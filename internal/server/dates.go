@@ -0,0 +1,87 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"derrclan.com/moravian-soap/internal/civil"
+	"derrclan.com/moravian-soap/internal/dailytexts"
+	"derrclan.com/moravian-soap/internal/store"
+)
+
+// errDateOutOfRange is returned for dates in years without daily texts.
+var errDateOutOfRange = errors.New("no daily texts available for date")
+
+// parseDate parses a client-provided date in YYYY-MM-DD format and checks that daily texts are
+// available for its year.
+func parseDate(s string) (civil.Date, error) {
+	d, err := civil.ParseDate(s)
+	if err != nil {
+		return civil.Date{}, fmt.Errorf("invalid date: %w", err)
+	}
+	if !dailytexts.HasYear(d.Year) {
+		return civil.Date{}, fmt.Errorf("%w: %s", errDateOutOfRange, d)
+	}
+	return d, nil
+}
+
+// dateMiddleware parses and validates the "date" query or form parameter and stores it in the
+// context, defaulting to today in the user's timezone. Invalid dates are rejected with a 400.
+// It must be wrapped by authMiddleware so the user is available in the context.
+func (s *Server) dateMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var date civil.Date
+		if v := r.FormValue("date"); v != "" {
+			var err error
+			date, err = parseDate(v)
+			if err != nil {
+				http.Error(w, "Invalid date", http.StatusBadRequest)
+				return
+			}
+		} else {
+			user, _ := r.Context().Value(userContextKey).(*store.User)
+			date = s.userToday(user)
+		}
+
+		ctx := context.WithValue(r.Context(), dateContextKey, date)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// requestDate returns the date parsed by dateMiddleware.
+func requestDate(r *http.Request) civil.Date {
+	date, _ := r.Context().Value(dateContextKey).(civil.Date)
+	return date
+}
+
+// addDayNav adds the dates of the previous and next daily texts around dateStr to the data of a template
+// that includes verses.gotmpl, for its day navigation. A date is left out if there is none.
+func addDayNav(data map[string]any, dateStr string) {
+	prev, next, err := dailytexts.AdjacentDates(dateStr)
+	if err != nil {
+		slog.Warn("failed to find adjacent dates", "date", dateStr, "error", err)
+		return
+	}
+	data["prevDate"] = prev
+	data["nextDate"] = next
+}
+
+// userToday returns the current date in the user's timezone, or in UTC if it is unknown.
+func (s *Server) userToday(user *store.User) civil.Date {
+	loc := time.UTC
+	if user != nil {
+		if l, err := time.LoadLocation(user.Timezone); err == nil {
+			loc = l
+		}
+	}
+	return s.todayIn(loc)
+}
+
+// todayIn returns the current date in loc, according to the server's clock.
+func (s *Server) todayIn(loc *time.Location) civil.Date {
+	return civil.DateOf(s.clock.Now().In(loc))
+}
@@ -0,0 +1,86 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"derrclan.com/moravian-soap/internal/clock"
+	"derrclan.com/moravian-soap/internal/store"
+)
+
+func TestDateMiddleware(t *testing.T) {
+	srv := &Server{clock: clock.Real}
+	user := &store.User{ID: 1, Timezone: "America/Chicago"}
+	tests := []struct {
+		name     string
+		query    string
+		wantCode int
+		wantDate string
+	}{
+		{name: "valid date", query: "?date=2026-03-01", wantCode: http.StatusOK, wantDate: "2026-03-01"},
+		{name: "defaults to today", query: "", wantCode: http.StatusOK, wantDate: srv.userToday(user).String()},
+		{name: "impossible date", query: "?date=2026-02-30", wantCode: http.StatusBadRequest},
+		{name: "malformed date", query: "?date=2026-3-1", wantCode: http.StatusBadRequest},
+		{name: "year without texts", query: "?date=1999-03-01", wantCode: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			handler := srv.dateMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = requestDate(r).String()
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/reading"+tt.query, nil)
+			req = req.WithContext(context.WithValue(req.Context(), userContextKey, user))
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantCode {
+				t.Fatalf("expected status %d, got %d", tt.wantCode, rec.Code)
+			}
+			if got != tt.wantDate && tt.wantCode == http.StatusOK {
+				t.Errorf("expected date %s, got %s", tt.wantDate, got)
+			}
+		})
+	}
+}
+
+func TestUserToday(t *testing.T) {
+	fake := clock.NewFake(time.Now())
+	srv := &Server{clock: fake}
+
+	tests := []struct {
+		name     string
+		timezone string
+		now      string
+		want     string
+	}{
+		{"before midnight", "America/Chicago", "2026-03-01T05:59:59Z", "2026-02-28"},
+		{"at midnight", "America/Chicago", "2026-03-01T06:00:00Z", "2026-03-01"},
+		{"before spring forward", "America/Chicago", "2026-03-08T07:59:00Z", "2026-03-08"},
+		{"end of the short day", "America/Chicago", "2026-03-09T04:59:59Z", "2026-03-08"},
+		{"midnight after spring forward", "America/Chicago", "2026-03-09T05:00:00Z", "2026-03-09"},
+		{"repeated hour of fall back", "America/Chicago", "2026-11-01T07:30:00Z", "2026-11-01"},
+		{"end of the long day", "America/Chicago", "2026-11-02T05:59:59Z", "2026-11-01"},
+		{"midnight after fall back", "America/Chicago", "2026-11-02T06:00:00Z", "2026-11-02"},
+		{"New Year's Eve behind UTC", "America/Los_Angeles", "2027-01-01T07:59:59Z", "2026-12-31"},
+		{"New Year behind UTC", "America/Los_Angeles", "2027-01-01T08:00:00Z", "2027-01-01"},
+		{"New Year ahead of UTC", "Pacific/Auckland", "2026-12-31T11:00:00Z", "2027-01-01"},
+		{"unknown timezone uses UTC", "Nowhere/Special", "2026-12-31T23:59:59Z", "2026-12-31"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now, err := time.Parse(time.RFC3339, tt.now)
+			if err != nil {
+				t.Fatalf("parsing %s: %v", tt.now, err)
+			}
+			fake.Set(now)
+			if got := srv.userToday(&store.User{Timezone: tt.timezone}).String(); got != tt.want {
+				t.Errorf("userToday at %s in %s = %s, want %s", tt.now, tt.timezone, got, tt.want)
+			}
+		})
+	}
+}
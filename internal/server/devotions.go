@@ -0,0 +1,67 @@
+package server
+
+import (
+	"log/slog"
+	"net/http"
+
+	"derrclan.com/moravian-soap/internal/dailytexts"
+	"derrclan.com/moravian-soap/internal/devotions"
+	"derrclan.com/moravian-soap/internal/store"
+)
+
+// handleDevotions renders the family devotions partial for a date (GET), or changes who the questions
+// are for and re-renders it (POST). Posting an empty audience leaves family devotions mode.
+func (s *Server) handleDevotions(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(userContextKey).(*store.User)
+	date := requestDate(r)
+
+	prefs, err := s.store.GetPreferences(r.Context(), user.ID)
+	if err != nil {
+		slog.Error("failed to get preferences", "user_id", user.ID, "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		audience := r.FormValue("audience")
+		if _, ok := devotions.ParseAudience(audience); !ok && audience != "" {
+			http.Error(w, "Bad request", http.StatusBadRequest)
+			return
+		}
+		prefs.DevotionsAudience = audience
+		if err := s.store.SavePreferences(r.Context(), user.ID, prefs); err != nil {
+			slog.Error("failed to save preferences", "user_id", user.ID, "error", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	data := map[string]any{
+		"date":     date.String(),
+		"audience": prefs.DevotionsAudience,
+	}
+	if audience, ok := devotions.ParseAudience(prefs.DevotionsAudience); ok {
+		dailyText, err := dailytexts.GetDailyText(date.String())
+		if err != nil {
+			slog.Error("failed to get daily text", "date", date, "error", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		if dailyText != nil {
+			data["watchword"] = dailyText.DailyWatchWord
+			if devotion, ok := devotions.For(dailyText.WatchwordReference(), audience, date); ok {
+				data["devotion"] = devotion
+			}
+		}
+	}
+
+	if err := s.tmpl.ExecuteTemplate(w, "devotions.gotmpl", data); err != nil {
+		slog.Error("failed to execute devotions template", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,78 @@
+package server
+
+import (
+	"context"
+	"log/slog"
+
+	"derrclan.com/moravian-soap/internal/events"
+	"derrclan.com/moravian-soap/internal/store"
+)
+
+// subscribe subscribes the subsystems that act on what happens in the app to the server's events.
+func (s *Server) subscribe() {
+	// Keep what is derived from journal entries in step with them.
+	s.events.Subscribe(events.EntrySaved, s.updateDerivedEntryData)
+
+	// Render the days of a user whose entries change afresh, whatever changed them.
+	for _, kind := range []events.Kind{events.EntrySaved, events.EntryDeleted} {
+		s.events.Subscribe(kind, func(_ context.Context, e events.Event) { s.snapshots.invalidate(e.UserID) })
+	}
+
+	// Remove the audio of a user's entries once one of them is deleted, as it may read the deleted one.
+	s.events.Subscribe(events.EntryDeleted, s.removeSpeech)
+
+	for _, kind := range []events.Kind{events.EntrySaved, events.EntryDeleted, events.UserRegistered, events.CacheExpunged} {
+		s.events.Subscribe(kind, auditEvent)
+	}
+}
+
+// publishEntrySaved publishes the save of entry, which may hold only the sections saved, and, if the
+// save left nothing written in an entry that before had something, its deletion. before is the entry as
+// it was, or nil if that is not known, in which case no deletion is published.
+func (s *Server) publishEntrySaved(ctx context.Context, userID int64, before, entry *store.SOAPData) {
+	now := s.clock.Now()
+	s.events.Publish(ctx, events.Event{Kind: events.EntrySaved, At: now, UserID: userID, Date: entry.Date, Entry: entry})
+	if before == nil || !written(before.Sections) {
+		return
+	}
+	after := make(map[string]string, len(before.Sections))
+	for key, content := range before.Sections {
+		after[key] = content
+	}
+	for key, content := range entry.Sections {
+		after[key] = content
+	}
+	if !written(after) {
+		s.events.Publish(ctx, events.Event{Kind: events.EntryDeleted, At: now, UserID: userID, Date: entry.Date})
+	}
+}
+
+// written reports whether anything is written in any of an entry's sections.
+func written(sections map[string]string) bool {
+	for _, content := range sections {
+		if content != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// updateDerivedEntryData updates the links, chapters and completeness derived from a journal entry
+// once it has been saved.
+func (s *Server) updateDerivedEntryData(ctx context.Context, e events.Event) {
+	s.saveJournalLinks(ctx, e.UserID, e.Entry)
+	s.saveJournalChapters(ctx, e.UserID, e.Entry)
+	s.updateCompleteness(ctx, e.UserID, s.journalSchema(ctx), e.Date)
+}
+
+// auditEvent logs an event, as a record of what happened in the app.
+func auditEvent(ctx context.Context, e events.Event) {
+	attrs := []any{"kind", e.Kind}
+	if e.UserID != 0 {
+		attrs = append(attrs, "user_id", e.UserID)
+	}
+	if e.Date != "" {
+		attrs = append(attrs, "date", e.Date)
+	}
+	slog.InfoContext(ctx, "event", attrs...)
+}
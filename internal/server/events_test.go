@@ -0,0 +1,41 @@
+package server
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"derrclan.com/moravian-soap/internal/clock"
+	"derrclan.com/moravian-soap/internal/events"
+	"derrclan.com/moravian-soap/internal/store"
+)
+
+func TestPublishEntrySaved(t *testing.T) {
+	srv := &Server{clock: clock.Real, events: events.NewBus()}
+	var got []events.Kind
+	for _, kind := range []events.Kind{events.EntrySaved, events.EntryDeleted} {
+		srv.events.Subscribe(kind, func(ctx context.Context, e events.Event) { got = append(got, e.Kind) })
+	}
+	ctx := context.Background()
+	before := &store.SOAPData{Date: "2026-03-07", Sections: map[string]string{"observation": "Seen", "prayer": "Asked"}}
+
+	tests := []struct {
+		name          string
+		before, entry *store.SOAPData
+		want          []events.Kind
+	}{
+		{"edited", before, &store.SOAPData{Date: "2026-03-07", Sections: map[string]string{"observation": ""}}, []events.Kind{events.EntrySaved}},
+		{"emptied", before, &store.SOAPData{Date: "2026-03-07", Sections: map[string]string{"observation": "", "prayer": ""}}, []events.Kind{events.EntrySaved, events.EntryDeleted}},
+		{"empty before", &store.SOAPData{}, &store.SOAPData{Date: "2026-03-07", Sections: map[string]string{"prayer": ""}}, []events.Kind{events.EntrySaved}},
+		{"unknown before", nil, &store.SOAPData{Date: "2026-03-07", Sections: map[string]string{}}, []events.Kind{events.EntrySaved}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got = nil
+			srv.publishEntrySaved(ctx, 1, tt.before, tt.entry)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("published %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,245 @@
+package server
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"mime"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"derrclan.com/moravian-soap/internal/civil"
+	"derrclan.com/moravian-soap/internal/config"
+	"derrclan.com/moravian-soap/internal/dailytexts"
+	"derrclan.com/moravian-soap/internal/esv"
+	"derrclan.com/moravian-soap/internal/export"
+	"derrclan.com/moravian-soap/internal/store"
+)
+
+// newHTMLExporter creates an HTML exporter that renders entries as Markdown if RENDER_MARKDOWN is set.
+func newHTMLExporter() (*export.HTMLExporter, error) {
+	exporter, err := export.NewHTMLExporter()
+	if err != nil {
+		return nil, err
+	}
+	exporter.RenderMarkdown = config.Current().RenderMarkdown
+	return exporter, nil
+}
+
+// decodeExportRequest reads an export request sent as JSON by the journal page or as a form by the
+// backup page. A form exports scripture only if its scripture box is checked.
+func decodeExportRequest(r *http.Request) (exportRequest, error) {
+	var req exportRequest
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "application/x-www-form-urlencoded" {
+		if err := r.ParseForm(); err != nil {
+			return req, fmt.Errorf("parsing export form: %w", err)
+		}
+		scripture := r.PostForm.Get("scripture") != ""
+		req = exportRequest{
+			Date:      r.PostForm.Get("date"),
+			Format:    r.PostForm.Get("format"),
+			Method:    r.PostForm.Get("method"),
+			From:      r.PostForm.Get("from"),
+			To:        r.PostForm.Get("to"),
+			Types:     r.PostForm["types"],
+			Scripture: &scripture,
+		}
+	} else if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return req, fmt.Errorf("decoding export request: %w", err)
+	}
+	if req.Format == "md" {
+		req.Format = "markdown"
+	}
+	return req, nil
+}
+
+// exportedEntry is an entry in an export of several entries, with the day it is ordered by and the
+// passages it is written on.
+type exportedEntry struct {
+	entry      *export.Entry
+	day        string
+	references []string
+}
+
+// listExportEntries returns the user's entries of the given export entry types on days that inRange
+// accepts, ordered by day. Passage entries without a date are placed by the day they were started.
+func (s *Server) listExportEntries(ctx context.Context, userID int64, types []string, inRange func(day string) bool) ([]exportedEntry, error) {
+	var entries []exportedEntry
+	if slices.Contains(types, export.EntryDaily) {
+		soapData, err := s.store.ListSOAPData(ctx, userID)
+		if err != nil {
+			return nil, fmt.Errorf("listing journal entries: %w", err)
+		}
+		for _, d := range soapData {
+			if !inRange(d.Date) {
+				continue
+			}
+			var references []string
+			if len(d.SelectedVerses) > 0 {
+				references = []string{esv.FormatReferences(d.SelectedVerses)}
+			} else if dailyText, err := dailytexts.GetDailyText(d.Date); err == nil && dailyText != nil {
+				references = dailyText.Verses
+			}
+			entries = append(entries, exportedEntry{
+				entry: &export.Entry{
+					Type: export.EntryDaily, Date: d.Date, Reference: strings.Join(references, "; "), Sections: d.Sections,
+					Location: d.Location, Weather: d.Weather,
+				},
+				day:        d.Date,
+				references: references,
+			})
+		}
+	}
+	if slices.Contains(types, export.EntryPassage) {
+		passageEntries, err := s.store.ListPassageEntries(ctx, userID)
+		if err != nil {
+			return nil, fmt.Errorf("listing passage entries: %w", err)
+		}
+		for _, p := range passageEntries {
+			day := cmp.Or(p.Date, p.CreatedAt.Format(time.DateOnly))
+			if !inRange(day) {
+				continue
+			}
+			entries = append(entries, exportedEntry{
+				entry:      &export.Entry{Type: export.EntryPassage, Date: p.Date, Reference: p.Reference, Sections: p.Sections, CreatedAt: &p.CreatedAt},
+				day:        day,
+				references: []string{p.Reference},
+			})
+		}
+	}
+	slices.SortStableFunc(entries, func(a, b exportedEntry) int {
+		return strings.Compare(a.day, b.day)
+	})
+	return entries, nil
+}
+
+// exportEntries downloads every entry in the request's date range as one Markdown, JSON or CSV file.
+// Passage entries without a date are placed by the day they were started.
+func (s *Server) exportEntries(w http.ResponseWriter, r *http.Request, user *store.User, req exportRequest) {
+	if req.Method == "email" {
+		http.Error(w, "Email export only supports a single day", http.StatusBadRequest)
+		return
+	}
+	for _, d := range []string{req.From, req.To} {
+		if _, err := civil.ParseDate(d); d != "" && err != nil {
+			http.Error(w, "Invalid date", http.StatusBadRequest)
+			return
+		}
+	}
+	if req.From != "" && req.To != "" && req.From > req.To {
+		http.Error(w, "The start date must not be after the end date", http.StatusBadRequest)
+		return
+	}
+	types := req.Types
+	if len(types) == 0 {
+		types = []string{export.EntryDaily, export.EntryPassage}
+	}
+	for _, t := range types {
+		if t != export.EntryDaily && t != export.EntryPassage {
+			http.Error(w, fmt.Sprintf("Unknown entry type: %s", t), http.StatusBadRequest)
+			return
+		}
+	}
+	inRange := func(date string) bool {
+		return (req.From == "" || date >= req.From) && (req.To == "" || date <= req.To)
+	}
+	entries, err := s.listExportEntries(r.Context(), user.ID, types, inRange)
+	if err != nil {
+		slog.Error("failed to list entries for export", "user_id", user.ID, "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	if req.Scripture == nil || *req.Scripture {
+		var sets [][]string
+		for _, e := range entries {
+			if len(e.references) > 0 {
+				sets = append(sets, e.references)
+			}
+		}
+		passages, err := s.prerenderPassages(r.Context(), sets, s.passageOptions(r.Context()))
+		if errors.Is(err, errESVQuotaReached) {
+			http.Error(w, "Too many passages to fetch today. Export without scripture, or try again tomorrow.", http.StatusServiceUnavailable)
+			return
+		} else if err != nil {
+			slog.Error("failed to fetch verses for export", "user_id", user.ID, "error", err)
+			http.Error(w, "Error loading verses", http.StatusInternalServerError)
+			return
+		}
+		for _, e := range entries {
+			if len(e.references) > 0 {
+				e.entry.Scripture = strings.Join(passages[0].Passages, "\n")
+				passages = passages[1:]
+			}
+		}
+	}
+
+	out := make([]*export.Entry, len(entries))
+	for i, e := range entries {
+		out[i] = e.entry
+	}
+	schema := s.journalSchema(r.Context())
+	filename := "soap-entries-" + s.clock.Now().Format(time.DateOnly)
+	var write func() error
+	switch req.Format {
+	case "markdown":
+		w.Header().Set("Content-Type", "text/markdown")
+		filename += ".md"
+		write = func() error { return export.WriteEntriesMarkdown(r.Context(), w, out, schema) }
+	case "json":
+		w.Header().Set("Content-Type", "application/json")
+		filename += ".json"
+		write = func() error { return export.WriteEntriesJSON(w, out) }
+	case "csv":
+		w.Header().Set("Content-Type", "text/csv")
+		filename += ".csv"
+		write = func() error { return export.WriteEntriesCSV(w, out, schema) }
+	default:
+		http.Error(w, "Several entries can be exported as Markdown, JSON or CSV", http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+	if err := write(); err != nil {
+		slog.Error("failed to export entries", "user_id", user.ID, "error", err)
+	}
+}
+
+// handleAPIExport downloads every entry in the user's journal as a zip archive, with one Markdown file
+// per day and every entry again as JSON, for backup or to take elsewhere. The archive leaves out the
+// scripture, which would take a request to the ESV API for each day. A locked session must be unlocked
+// first, as for the export on the site.
+func (s *Server) handleAPIExport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	user := r.Context().Value(userContextKey).(*store.User)
+
+	entries, err := s.listExportEntries(r.Context(), user.ID, []string{export.EntryDaily, export.EntryPassage}, func(string) bool { return true })
+	if err != nil {
+		slog.Error("failed to list entries for archive", "user_id", user.ID, "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	var days []export.ArchiveDay
+	for _, e := range entries {
+		if n := len(days); n > 0 && days[n-1].Date == e.day {
+			days[n-1].Entries = append(days[n-1].Entries, e.entry)
+		} else {
+			days = append(days, export.ArchiveDay{Date: e.day, Entries: []*export.Entry{e.entry}})
+		}
+	}
+
+	filename := fmt.Sprintf("soap-archive-%s.zip", s.clock.Now().Format(time.DateOnly))
+	w.Header().Set("Content-Type", export.ArchiveContentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+	if err := export.WriteArchive(r.Context(), w, days, s.journalSchema(r.Context())); err != nil {
+		slog.Error("failed to write archive", "user_id", user.ID, "error", err)
+	}
+}
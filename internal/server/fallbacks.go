@@ -0,0 +1,18 @@
+package server
+
+import (
+	"net/http"
+	"net/url"
+)
+
+// isHTMX reports whether r was sent by HTMX, rather than by a form submitted without JavaScript.
+func isHTMX(r *http.Request) bool {
+	return r.Header.Get("HX-Request") != ""
+}
+
+// redirectToDay sends a browser that submitted a form without JavaScript back to the journal for
+// dateStr, where the change shows in the page as rendered in full. Partial handlers call it for POSTs
+// not sent by HTMX in place of rendering their partial.
+func redirectToDay(w http.ResponseWriter, r *http.Request, dateStr string) {
+	http.Redirect(w, r, "/?date="+url.QueryEscape(dateStr), http.StatusSeeOther)
+}
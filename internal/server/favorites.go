@@ -0,0 +1,174 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+
+	"derrclan.com/moravian-soap/internal/htmltext"
+	"derrclan.com/moravian-soap/internal/store"
+)
+
+// favoriteExcerptRunes is the length of the excerpt of a favorite entry shown beside the journal.
+const favoriteExcerptRunes = 160
+
+// favoriteEntry is a favorite journal entry shown beside the journal, with an excerpt of what was
+// written.
+type favoriteEntry struct {
+	Date    string
+	Excerpt string
+}
+
+// handleFavorite renders the favorite partial for a date's entry (GET), or pins the entry as a
+// favorite (POST, with an action of "add") or unpins it (POST, with an action of "remove"). The partial
+// also suggests one of the user's other favorites at random. A POST submitted without JavaScript is
+// sent back to the journal for the date.
+func (s *Server) handleFavorite(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(userContextKey).(*store.User)
+	dateStr := requestDate(r).String()
+
+	var message string
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		var favorite bool
+		switch r.FormValue("action") {
+		case "add":
+			favorite = true
+		case "remove":
+		default:
+			http.Error(w, "Unknown action", http.StatusBadRequest)
+			return
+		}
+		err := s.store.SaveFavorite(r.Context(), user.ID, dateStr, favorite)
+		if errors.Is(err, sql.ErrNoRows) {
+			message = "Write something first, then pin it."
+		} else if err != nil {
+			slog.Error("failed to save favorite", "user_id", user.ID, "date", dateStr, "error", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if r.Method == http.MethodPost && !isHTMX(r) {
+		redirectToDay(w, r, dateStr)
+		return
+	}
+
+	data := map[string]any{
+		"date":            dateStr,
+		"favoriteMessage": message,
+		"CSRFToken":       r.Context().Value(csrfContextKey).(string),
+	}
+	if err := s.addFavorite(r.Context(), data, user.ID, dateStr); err != nil {
+		slog.Error("failed to load favorites", "user_id", user.ID, "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if err := s.tmpl.ExecuteTemplate(w, "favorite_entry.gotmpl", data); err != nil {
+		slog.Error("failed to execute favorite entry template", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
+
+// addFavorite adds to data whether the user's entry on dateStr is a favorite and, if they have others,
+// one of them at random.
+func (s *Server) addFavorite(ctx context.Context, data map[string]any, userID int64, dateStr string) error {
+	dates, err := s.store.ListFavorites(ctx, userID)
+	if err != nil {
+		return err
+	}
+	data["favorite"] = slices.Contains(dates, dateStr)
+	others := slices.DeleteFunc(dates, func(d string) bool { return d == dateStr })
+	if len(others) == 0 {
+		return nil
+	}
+	date := others[rand.IntN(len(others))]
+	soapData, err := s.store.GetSOAPData(ctx, userID, date)
+	if err != nil {
+		return err
+	}
+	data["randomFavorite"] = favoriteEntry{Date: date, Excerpt: s.entryExcerpt(ctx, soapData)}
+	return nil
+}
+
+// entryExcerpt returns the start of the first section written in the journal entry, as plain text.
+func (s *Server) entryExcerpt(ctx context.Context, d *store.SOAPData) string {
+	for _, sec := range s.journalSchema(ctx).Entries(d.Sections) {
+		if sec.Content != "" {
+			return htmltext.Excerpt(sec.Content, favoriteExcerptRunes)
+		}
+	}
+	return ""
+}
+
+// handleFavorites lists the user's favorite journal entries in the order they arranged them (GET), and
+// moves one earlier (POST, with an action of "up"), later (an action of "down") or off the list (an
+// action of "remove").
+func (s *Server) handleFavorites(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(userContextKey).(*store.User)
+
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		date := r.FormValue("date")
+		var err error
+		switch r.FormValue("action") {
+		case "up":
+			err = s.store.MoveFavorite(r.Context(), user.ID, date, -1)
+		case "down":
+			err = s.store.MoveFavorite(r.Context(), user.ID, date, 1)
+		case "remove":
+			err = s.store.SaveFavorite(r.Context(), user.ID, date, false)
+		default:
+			http.Error(w, "Unknown action", http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			slog.Error("failed to update favorites", "user_id", user.ID, "date", date, "error", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		http.Redirect(w, r, "/favorites", http.StatusSeeOther)
+		return
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	dates, err := s.store.ListFavorites(r.Context(), user.ID)
+	if err != nil {
+		slog.Error("failed to list favorites", "user_id", user.ID, "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	schema := s.journalSchema(r.Context())
+	entries := make([]historyEntry, 0, len(dates))
+	for _, date := range dates {
+		soapData, err := s.store.GetSOAPData(r.Context(), user.ID, date)
+		if err != nil {
+			slog.Error("failed to get SOAP data", "user_id", user.ID, "date", date, "error", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		entries = append(entries, newHistoryEntry(soapData, schema))
+	}
+
+	data := map[string]any{
+		"user":      user,
+		"entries":   entries,
+		"last":      len(entries) - 1,
+		"CSRFToken": r.Context().Value(csrfContextKey).(string),
+		"Nonce":     r.Context().Value(nonceContextKey).(string),
+	}
+	if err := s.tmpl.ExecuteTemplate(w, "favorites.html", data); err != nil {
+		slog.Error("failed to execute favorites template", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
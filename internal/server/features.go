@@ -0,0 +1,134 @@
+package server
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"derrclan.com/moravian-soap/internal/config"
+	"derrclan.com/moravian-soap/internal/features"
+	"derrclan.com/moravian-soap/internal/middleware"
+	"derrclan.com/moravian-soap/internal/store"
+)
+
+// featureEnabled reports whether f is available to user. Without a user, as on the sign-in page, it
+// reports whether f is available to anyone.
+func featureEnabled(user *store.User, f features.Feature) bool {
+	modes := config.Current().Features
+	if user == nil {
+		return modes.Mode(f) != features.Off
+	}
+	return modes.Enabled(f, user.Features)
+}
+
+// featureFunc is the feature template function, with which templates show the parts of the site
+// belonging to a feature only if it is available, as in {{if feature "stats" .user}}.
+func featureFunc(name string, user *store.User) (bool, error) {
+	f := features.Feature(name)
+	if !f.Valid() {
+		return false, fmt.Errorf("unknown feature %q", name)
+	}
+	return featureEnabled(user, f), nil
+}
+
+// requireFeature returns middleware that responds with a 404 to requests for the routes of f when it
+// is not available to the signed-in user, or to anyone on routes without one.
+func requireFeature(f features.Feature) middleware.Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user, _ := r.Context().Value(userContextKey).(*store.User)
+			if !featureEnabled(user, f) {
+				http.NotFound(w, r)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// handleAdminFeatures shows the mode of each feature on the instance and lets the admin turn features
+// on or off for individual users.
+func (s *Server) handleAdminFeatures(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(userContextKey).(*store.User)
+
+	var success, errMsg string
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		f := features.Feature(r.FormValue("feature"))
+		if !f.Valid() {
+			http.Error(w, "Unknown feature", http.StatusBadRequest)
+			return
+		}
+		switch r.FormValue("action") {
+		case "save":
+			email := strings.TrimSpace(r.FormValue("email"))
+			target, err := s.store.GetUserByEmail(r.Context(), email)
+			if err != nil {
+				slog.Warn("no user to override feature for", "email", email, "error", err)
+				errMsg = fmt.Sprintf("There is no user with the email %s.", email)
+				break
+			}
+			enabled := r.FormValue("enabled") == "true"
+			if err := s.store.SaveFeatureOverride(r.Context(), target.ID, string(f), enabled); err != nil {
+				slog.Error("failed to save feature override", "user_id", target.ID, "feature", f, "error", err)
+				http.Error(w, "Internal server error", http.StatusInternalServerError)
+				return
+			}
+			slog.Info("feature overridden", "admin_id", user.ID, "user_id", target.ID, "feature", f, "enabled", enabled)
+			success = fmt.Sprintf("Saved the %s feature for %s.", f, target.Email)
+		case "delete":
+			userID, err := strconv.ParseInt(r.FormValue("user_id"), 10, 64)
+			if err != nil {
+				http.Error(w, "Invalid user", http.StatusBadRequest)
+				return
+			}
+			if err := s.store.DeleteFeatureOverride(r.Context(), userID, string(f)); err != nil {
+				slog.Error("failed to delete feature override", "user_id", userID, "feature", f, "error", err)
+				http.Error(w, "Internal server error", http.StatusInternalServerError)
+				return
+			}
+			slog.Info("feature override removed", "admin_id", user.ID, "user_id", userID, "feature", f)
+			success = fmt.Sprintf("The %s feature follows the instance's mode again.", f)
+		default:
+			http.Error(w, "Unknown action", http.StatusBadRequest)
+			return
+		}
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	overrides, err := s.store.ListFeatureOverrides(r.Context())
+	if err != nil {
+		slog.Error("failed to list feature overrides", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	modes := config.Current().Features
+	type featureRow struct {
+		Name        features.Feature
+		Description string
+		Mode        features.Mode
+	}
+	var rows []featureRow
+	for _, f := range features.All() {
+		rows = append(rows, featureRow{Name: f, Description: f.Description(), Mode: modes.Mode(f)})
+	}
+
+	data := map[string]any{
+		"user":      user,
+		"features":  rows,
+		"overrides": overrides,
+		"Success":   success,
+		"Error":     errMsg,
+		"CSRFToken": r.Context().Value(csrfContextKey).(string),
+		"Nonce":     r.Context().Value(nonceContextKey).(string),
+	}
+	if err := s.tmpl.ExecuteTemplate(w, "admin_features.html", data); err != nil {
+		slog.Error("failed to execute admin_features template", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
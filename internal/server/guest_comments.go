@@ -0,0 +1,289 @@
+package server
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"derrclan.com/moravian-soap/internal/config"
+	"derrclan.com/moravian-soap/internal/features"
+	"derrclan.com/moravian-soap/internal/store"
+)
+
+const (
+	// maxGuestCommentLength and maxGuestNameLength are the longest comment and name a visitor may
+	// leave, in characters.
+	maxGuestCommentLength = 1000
+	maxGuestNameLength    = 60
+
+	// guestCommentLimit is the most comments accepted from an address in guestCommentPeriod.
+	guestCommentLimit  = 3
+	guestCommentPeriod = 10 * time.Minute
+
+	// honeypotField is a form field hidden from visitors. Only bots fill it in, and their comments are
+	// dropped as if accepted.
+	honeypotField = "website"
+
+	// guestModerationSettingKey is the instance setting that holds comments from visitors for the
+	// admin's approval when it is "on".
+	guestModerationSettingKey = "guest_comment_moderation"
+
+	// recentGuestComments is the number of approved comments listed for the admin.
+	recentGuestComments = 50
+)
+
+// commentLimiter counts the comments from each address in a sliding window, to slow down spam.
+type commentLimiter struct {
+	mu sync.Mutex
+	// sent holds the times of the comments from each address within guestCommentPeriod, oldest first.
+	sent map[string][]time.Time
+}
+
+// allow records a comment from ip at now and reports whether it is within guestCommentLimit.
+func (l *commentLimiter) allow(ip string, now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.sent == nil {
+		l.sent = map[string][]time.Time{}
+	}
+	// Forget the addresses with no comment in the window, so the map does not grow without bound.
+	for addr, times := range l.sent {
+		if now.Sub(times[len(times)-1]) >= guestCommentPeriod {
+			delete(l.sent, addr)
+		}
+	}
+	times := slices.DeleteFunc(l.sent[ip], func(t time.Time) bool { return now.Sub(t) >= guestCommentPeriod })
+	if len(times) >= guestCommentLimit {
+		l.sent[ip] = times
+		return false
+	}
+	l.sent[ip] = append(times, now)
+	return true
+}
+
+// clientIP returns the address a request came from: the last one in X-Forwarded-For, added by the
+// reverse proxy in front of the site when the configuration trusts one, or else the address of the
+// connection.
+func clientIP(r *http.Request) string {
+	if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" && config.Current().TrustProxy {
+		addrs := strings.Split(forwarded, ",")
+		if ip := strings.TrimSpace(addrs[len(addrs)-1]); ip != "" {
+			return ip
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// guestCommentsOpen reports whether visitors may comment on review: its owner turned comments on and
+// the guest comments feature is available to them.
+func (s *Server) guestCommentsOpen(ctx context.Context, review *store.YearReview) (bool, error) {
+	if !review.CommentsEnabled {
+		return false, nil
+	}
+	overrides, err := s.store.GetFeatureOverrides(ctx, review.UserID)
+	if err != nil {
+		return false, err
+	}
+	return config.Current().Features.Enabled(features.GuestComments, overrides), nil
+}
+
+// guestModeration reports whether the admin approves comments from visitors before they are shown.
+func (s *Server) guestModeration(ctx context.Context) (bool, error) {
+	settings, err := s.store.GetInstanceSettings(ctx)
+	if err != nil {
+		return false, err
+	}
+	return settings[guestModerationSettingKey] == "on", nil
+}
+
+// blockedComment reports whether the blocklist blocks a comment from ip with name and body: the
+// address is blocked, or the name or body contains a blocked word.
+func blockedComment(blocked []*store.BlockedValue, ip, name, body string) bool {
+	text := strings.ToLower(name + "\n" + body)
+	for _, b := range blocked {
+		switch b.Kind {
+		case store.BlockIP:
+			if b.Value == ip {
+				return true
+			}
+		case store.BlockWord:
+			if strings.Contains(text, strings.ToLower(b.Value)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// addGuestComment adds the comment in the form to review, unless it is spam. It returns a message for
+// the visitor if the form is invalid or they have commented too often, with the status to respond
+// with, and otherwise where to send them. Comments that are dropped as spam are not told apart from
+// those accepted.
+func (s *Server) addGuestComment(r *http.Request, review *store.YearReview) (redirect, errMsg string, status int) {
+	page := "/review/" + review.Token
+	ip := clientIP(r)
+	if r.FormValue(honeypotField) != "" {
+		slog.Info("dropped guest comment with the honeypot filled in", "user_id", review.UserID, "year", review.Year, "ip", ip)
+		return page + "#comments", "", 0
+	}
+
+	name := strings.TrimSpace(r.FormValue("name"))
+	body := strings.TrimSpace(r.FormValue("comment"))
+	switch {
+	case name == "":
+		return "", "Enter your name.", http.StatusBadRequest
+	case utf8.RuneCountInString(name) > maxGuestNameLength:
+		return "", "Your name is too long.", http.StatusBadRequest
+	case body == "":
+		return "", "Write a comment first.", http.StatusBadRequest
+	case utf8.RuneCountInString(body) > maxGuestCommentLength:
+		return "", "Your comment is too long.", http.StatusBadRequest
+	}
+	if !s.guestLimiter.allow(ip, s.clock.Now()) {
+		slog.Warn("guest comments rate-limited", "ip", ip)
+		return "", "You have left several comments in a short time. Try again in a few minutes.", http.StatusTooManyRequests
+	}
+
+	blocked, err := s.store.ListBlockedValues(r.Context())
+	if err != nil {
+		slog.Error("failed to list comment blocklist", "error", err)
+		return "", "Failed to save your comment.", http.StatusInternalServerError
+	}
+	if blockedComment(blocked, ip, name, body) {
+		slog.Info("dropped blocked guest comment", "user_id", review.UserID, "year", review.Year, "ip", ip)
+		return page + "#comments", "", 0
+	}
+	moderated, err := s.guestModeration(r.Context())
+	if err != nil {
+		slog.Error("failed to get instance settings", "error", err)
+		return "", "Failed to save your comment.", http.StatusInternalServerError
+	}
+
+	c := &store.GuestComment{UserID: review.UserID, Year: review.Year, Name: name, Body: body, IP: ip, Approved: !moderated}
+	if err := s.store.AddGuestComment(r.Context(), c); err != nil {
+		slog.Error("failed to add guest comment", "user_id", review.UserID, "year", review.Year, "error", err)
+		return "", "Failed to save your comment.", http.StatusInternalServerError
+	}
+	if moderated {
+		return page + "?held=1#comments", "", 0
+	}
+	return page + "#comments", "", 0
+}
+
+// handleAdminComments shows the admin the comments from visitors waiting for approval, the newest
+// ones shown and the blocklist (GET), and approves a comment (POST, with an action of "approve"),
+// deletes one ("delete"), deletes one and blocks its address ("block-ip"), adds an address or word to
+// the blocklist ("block"), removes one ("unblock"), or turns moderation on or off ("moderation").
+func (s *Server) handleAdminComments(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(userContextKey).(*store.User)
+
+	var errMsg string
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		id, _ := strconv.ParseInt(r.FormValue("id"), 10, 64)
+		var err error
+		switch r.FormValue("action") {
+		case "approve":
+			err = s.store.ApproveGuestComment(r.Context(), id)
+		case "delete":
+			err = s.store.DeleteGuestComment(r.Context(), id)
+		case "block-ip":
+			ip := r.FormValue("ip")
+			if ip == "" {
+				http.Error(w, "Bad request", http.StatusBadRequest)
+				return
+			}
+			if err = s.store.BlockCommentValue(r.Context(), store.BlockIP, ip); err == nil {
+				err = s.store.DeleteGuestComment(r.Context(), id)
+			}
+		case "block":
+			kind, value := r.FormValue("kind"), strings.TrimSpace(r.FormValue("value"))
+			if kind != store.BlockIP && kind != store.BlockWord {
+				http.Error(w, "Unknown kind", http.StatusBadRequest)
+				return
+			}
+			if value == "" {
+				errMsg = "Enter an address or word to block."
+				break
+			}
+			err = s.store.BlockCommentValue(r.Context(), kind, value)
+		case "unblock":
+			err = s.store.DeleteBlockedValue(r.Context(), id)
+		case "moderation":
+			value := ""
+			if r.FormValue("enabled") == "true" {
+				value = "on"
+			}
+			err = s.store.SaveInstanceSettings(r.Context(), map[string]string{guestModerationSettingKey: value})
+		default:
+			http.Error(w, "Unknown action", http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			slog.Error("failed to update guest comments", "action", r.FormValue("action"), "error", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		if errMsg == "" {
+			slog.Info("guest comments updated", "admin_id", user.ID, "action", r.FormValue("action"))
+			http.Redirect(w, r, "/admin/comments", http.StatusSeeOther)
+			return
+		}
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	pending, err := s.store.ListPendingGuestComments(r.Context())
+	if err != nil {
+		slog.Error("failed to list pending guest comments", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	recent, err := s.store.ListRecentGuestComments(r.Context(), recentGuestComments)
+	if err != nil {
+		slog.Error("failed to list guest comments", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	blocked, err := s.store.ListBlockedValues(r.Context())
+	if err != nil {
+		slog.Error("failed to list comment blocklist", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	moderated, err := s.guestModeration(r.Context())
+	if err != nil {
+		slog.Error("failed to get instance settings", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	data := map[string]any{
+		"user":      user,
+		"enabled":   config.Current().Features.Mode(features.GuestComments) != features.Off,
+		"moderated": moderated,
+		"pending":   pending,
+		"recent":    recent,
+		"blocked":   blocked,
+		"Error":     errMsg,
+		"CSRFToken": r.Context().Value(csrfContextKey).(string),
+		"Nonce":     r.Context().Value(nonceContextKey).(string),
+	}
+	if err := s.tmpl.ExecuteTemplate(w, "admin_comments.html", data); err != nil {
+		slog.Error("failed to execute admin_comments template", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,189 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+
+	"derrclan.com/moravian-soap/internal/esv"
+	"derrclan.com/moravian-soap/internal/export"
+	"derrclan.com/moravian-soap/internal/store"
+)
+
+// highlightSourceNames are how the sources of highlights are named to the user.
+var highlightSourceNames = map[string]string{store.HighlightSourceESVOrg: "ESV.org"}
+
+// highlightImport reports the result of importing highlights.
+type highlightImport struct {
+	// Added is the number of verses newly highlighted, and Existing the number already highlighted or
+	// selected in the journal.
+	Added    int
+	Existing int
+	// Notes is the number of notes added to verses that had none.
+	Notes int
+	// Skipped are the rows whose references could not be read.
+	Skipped []importRow
+}
+
+// handleImportHighlights imports the highlights and notes exported from the user's ESV.org account.
+// Each highlighted verse not already highlighted or selected in the journal is added to the user's
+// highlights, marked as coming from ESV.org, and a highlight's note is kept as the note on its first
+// verse unless that verse has one already.
+func (s *Server) handleImportHighlights(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	user := r.Context().Value(userContextKey).(*store.User)
+
+	var result *highlightImport
+	var errMsg string
+	if file, _, err := r.FormFile("highlights"); err != nil {
+		errMsg = "Choose an ESV.org export to import"
+	} else {
+		defer file.Close()
+		exported, err := export.ReadESVHighlights(http.MaxBytesReader(w, file, maxBackupSize))
+		if err != nil {
+			slog.Warn("failed to read ESV.org export", "user_id", user.ID, "error", err)
+			errMsg = "This file is not an export of ESV.org highlights"
+		} else if result, err = s.importHighlights(r.Context(), user.ID, exported); err != nil {
+			slog.Error("failed to import highlights", "user_id", user.ID, "error", err)
+			errMsg = "Failed to import highlights"
+		}
+	}
+
+	data := map[string]any{
+		"user":            user,
+		"highlightImport": result,
+		"Error":           errMsg,
+		"CSRFToken":       r.Context().Value(csrfContextKey).(string),
+		"Nonce":           r.Context().Value(nonceContextKey).(string),
+	}
+	if err := s.tmpl.ExecuteTemplate(w, "settings_backup.html", data); err != nil {
+		slog.Error("failed to execute settings_backup template", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
+
+// importHighlights saves the exported highlights as the user's and reports what was added.
+func (s *Server) importHighlights(ctx context.Context, userID int64, exported []*export.ESVHighlight) (*highlightImport, error) {
+	result := &highlightImport{}
+	var highlights []*store.Highlight
+	notes := make(map[string]string)
+	for _, h := range exported {
+		refs, err := esv.ParseVerseIDs(h.Reference)
+		if err != nil {
+			result.Skipped = append(result.Skipped, importRow{Row: h.Row, Error: err.Error()})
+			continue
+		}
+		for _, ref := range refs {
+			highlights = append(highlights, &store.Highlight{Ref: ref, Source: store.HighlightSourceESVOrg, Date: h.Date})
+		}
+		if h.Note != "" && notes[refs[0]] == "" {
+			notes[refs[0]] = truncateNote(h.Note)
+		}
+	}
+
+	added, err := s.store.ImportHighlights(ctx, userID, highlights)
+	if err != nil {
+		return nil, err
+	}
+	result.Added, result.Existing = added, len(highlights)-added
+
+	if len(notes) > 0 {
+		refs := make([]string, 0, len(notes))
+		for ref := range notes {
+			refs = append(refs, ref)
+		}
+		existing, err := s.store.GetVerseNotes(ctx, userID, refs)
+		if err != nil {
+			return nil, err
+		}
+		for _, n := range existing {
+			delete(notes, n.Ref)
+		}
+		for ref, note := range notes {
+			if err := s.store.SaveVerseNote(ctx, userID, ref, note); err != nil {
+				return nil, err
+			}
+			result.Notes++
+		}
+	}
+	return result, nil
+}
+
+// truncateNote shortens a note to maxVerseNoteLength characters, ending it with an ellipsis if it was
+// cut.
+func truncateNote(note string) string {
+	if utf8.RuneCountInString(note) <= maxVerseNoteLength {
+		return note
+	}
+	runes := []rune(note)
+	return strings.TrimSpace(string(runes[:maxVerseNoteLength-1])) + "…"
+}
+
+// markHighlights returns the passages with a marker after each verse the user highlighted outside the
+// journal. Passages are returned unmarked if the highlights cannot be loaded.
+func (s *Server) markHighlights(ctx context.Context, userID int64, resp esv.Response) esv.Response {
+	var refs []string
+	for _, p := range resp.Passages {
+		refs = append(refs, esv.VerseRefs(p)...)
+	}
+	highlights, err := s.store.GetHighlights(ctx, userID, refs)
+	if err != nil {
+		slog.Error("failed to get highlights", "user_id", userID, "error", err)
+		return resp
+	}
+	if len(highlights) == 0 {
+		return resp
+	}
+	sources := make(map[string]string, len(highlights))
+	highlighted := make([]string, len(highlights))
+	for i, h := range highlights {
+		highlighted[i] = h.Ref
+		sources[h.Ref] = h.Source
+	}
+	marker := func(ref string) *html.Node {
+		return highlightMarker(ref, sources[ref])
+	}
+
+	// The passages may be shared with other requests, so they are marked in a copy.
+	marked := make([]string, len(resp.Passages))
+	for i, p := range resp.Passages {
+		m, err := esv.AppendToVerses(p, highlighted, marker)
+		if err != nil {
+			slog.Error("failed to mark highlights", "user_id", userID, "error", err)
+			return resp
+		}
+		marked[i] = m
+	}
+	resp.Passages = marked
+	return resp
+}
+
+// highlightMarker returns the marker shown after a verse highlighted at source.
+func highlightMarker(ref, source string) *html.Node {
+	name := highlightSourceNames[source]
+	if name == "" {
+		name = source
+	}
+	label := fmt.Sprintf("%s highlighted on %s", esv.FormatReferences([]string{ref}), name)
+	span := &html.Node{
+		Type:     html.ElementNode,
+		Data:     "span",
+		DataAtom: atom.Span,
+		Attr: []html.Attribute{
+			{Key: "class", Val: "highlight-marker"},
+			{Key: "title", Val: label},
+			{Key: "aria-label", Val: label},
+		},
+	}
+	span.AppendChild(&html.Node{Type: html.TextNode, Data: "✦"})
+	return span
+}
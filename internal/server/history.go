@@ -0,0 +1,96 @@
+package server
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"derrclan.com/moravian-soap/internal/dailytexts"
+	"derrclan.com/moravian-soap/internal/journal"
+	"derrclan.com/moravian-soap/internal/store"
+)
+
+// historyPageSize is the number of journal entries on each page of the journal history.
+const historyPageSize = 10
+
+// historyEntry is a journal entry listed in the journal history, with the day's readings.
+type historyEntry struct {
+	Date     string
+	Readings string
+	// Stamp is where the entry was written and the weather, if it was stamped.
+	Stamp    string
+	Sections []journal.Entry
+}
+
+// handleJournalHistory lists the user's journal entries, newest first, a page at a time, so they can
+// re-read old entries without knowing their dates.
+func (s *Server) handleJournalHistory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	user := r.Context().Value(userContextKey).(*store.User)
+
+	page := 1
+	if v := r.URL.Query().Get("page"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			http.Error(w, "Invalid page", http.StatusBadRequest)
+			return
+		}
+		page = n
+	}
+
+	// One entry more than fits on the page tells whether there is another page.
+	soapData, err := s.store.ListRecentSOAPData(r.Context(), user.ID, historyPageSize+1, (page-1)*historyPageSize)
+	if err != nil {
+		slog.Error("failed to list journal entries", "user_id", user.ID, "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	hasNext := len(soapData) > historyPageSize
+	if hasNext {
+		soapData = soapData[:historyPageSize]
+	}
+
+	schema := s.journalSchema(r.Context())
+	entries := make([]historyEntry, 0, len(soapData))
+	for _, d := range soapData {
+		entries = append(entries, newHistoryEntry(d, schema))
+	}
+
+	data := map[string]any{
+		"user":      user,
+		"entries":   entries,
+		"page":      page,
+		"speech":    s.speech != nil,
+		"CSRFToken": r.Context().Value(csrfContextKey).(string),
+		"Nonce":     r.Context().Value(nonceContextKey).(string),
+	}
+	if page > 1 {
+		data["prevPage"] = page - 1
+	}
+	if hasNext {
+		data["nextPage"] = page + 1
+	}
+	if err := s.tmpl.ExecuteTemplate(w, "journal_history.html", data); err != nil {
+		slog.Error("failed to execute journal history template", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
+
+// newHistoryEntry returns the journal entry d as listed in the journal history, with the sections of
+// schema that were written in.
+func newHistoryEntry(d *store.SOAPData, schema journal.Schema) historyEntry {
+	entry := historyEntry{Date: d.Date, Stamp: d.Stamp()}
+	for _, sec := range schema.Entries(d.Sections) {
+		if sec.Content != "" {
+			entry.Sections = append(entry.Sections, sec)
+		}
+	}
+	if dailyText, err := dailytexts.GetDailyText(d.Date); err == nil && dailyText != nil {
+		entry.Readings = strings.Join(dailyText.Verses, "; ")
+	}
+	return entry
+}
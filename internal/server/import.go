@@ -0,0 +1,128 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"derrclan.com/moravian-soap/internal/export"
+	"derrclan.com/moravian-soap/internal/journal"
+	"derrclan.com/moravian-soap/internal/store"
+)
+
+// importRow reports whether one entry of an import was saved, and why not if it wasn't. Rows are
+// numbered from 1 in the order of the imported file.
+type importRow struct {
+	Row   int    `json:"row"`
+	OK    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+}
+
+// importSummary is the response to an import.
+type importSummary struct {
+	Imported int         `json:"imported"`
+	Failed   int         `json:"failed"`
+	Rows     []importRow `json:"rows"`
+}
+
+// handleAPIImport saves entries in the JSON format of an export to the user's journal. Each entry is
+// validated on its own and invalid ones are reported and skipped; the rest are saved in one
+// transaction. Daily entries replace the sections they contain, and passage entries already in the
+// journal, matched by reference and the time they were started, are updated rather than added again.
+func (s *Server) handleAPIImport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	user := r.Context().Value(userContextKey).(*store.User)
+
+	entries, err := export.ReadEntriesJSON(http.MaxBytesReader(w, r.Body, maxBackupSize))
+	if err != nil {
+		slog.Warn("failed to read import", "user_id", user.ID, "error", err)
+		http.Error(w, "Expected a JSON array of exported entries", http.StatusBadRequest)
+		return
+	}
+
+	summary := importSummary{Rows: make([]importRow, len(entries))}
+	var restored []*store.RestoredEntry
+	var passages []*store.PassageEntry
+	var dates []string
+	for i, e := range entries {
+		summary.Rows[i].Row = i + 1
+		switch {
+		case e == nil:
+			err = errors.New("entry is empty")
+		case e.Type == export.EntryDaily:
+			if err = validateImportedEntry(e); err == nil {
+				// The export format only names the passage an entry was written on, so the entry keeps
+				// any verses already selected in the journal.
+				existing, loadErr := s.store.GetSOAPData(r.Context(), user.ID, e.Date)
+				if loadErr != nil {
+					slog.Error("failed to get SOAP data for import", "user_id", user.ID, "date", e.Date, "error", loadErr)
+					http.Error(w, "Internal server error", http.StatusInternalServerError)
+					return
+				}
+				entry := &store.SOAPData{
+					Date: e.Date, Sections: e.Sections, SelectedVerses: existing.SelectedVerses,
+					Location: e.Location, Weather: e.Weather,
+				}
+				restored = append(restored, &store.RestoredEntry{
+					Entry:    entry,
+					Links:    journalLinks(entry),
+					Chapters: s.journalChapters(r.Context(), entry),
+				})
+				dates = append(dates, e.Date)
+			}
+		case e.Type == export.EntryPassage:
+			entry := &store.PassageEntry{Reference: e.Reference, Date: e.Date, Sections: e.Sections}
+			if e.CreatedAt != nil {
+				entry.CreatedAt = *e.CreatedAt
+			} else {
+				entry.CreatedAt = s.clock.Now()
+			}
+			entry.UpdatedAt = s.clock.Now()
+			if err = validatePassageEntry(entry); err == nil {
+				passages = append(passages, entry)
+			}
+		default:
+			err = fmt.Errorf("unknown entry type %q", e.Type)
+		}
+		if err != nil {
+			summary.Rows[i].Error = err.Error()
+			summary.Failed++
+			continue
+		}
+		summary.Rows[i].OK = true
+		summary.Imported++
+	}
+
+	if err := s.store.RestoreJournal(r.Context(), user.ID, restored, passages, nil); err != nil {
+		slog.Error("failed to import entries", "user_id", user.ID, "error", err)
+		http.Error(w, "Failed to import entries", http.StatusInternalServerError)
+		return
+	}
+	s.updateCompleteness(r.Context(), user.ID, s.journalSchema(r.Context()), dates...)
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(summary); err != nil {
+		slog.Error("failed to encode import summary", "error", err)
+	}
+}
+
+// validateImportedEntry checks the date and sections of an imported daily entry.
+func validateImportedEntry(e *export.Entry) error {
+	if _, err := parseDate(e.Date); err != nil {
+		return fmt.Errorf("invalid date %q", e.Date)
+	}
+	if len(e.Sections) == 0 {
+		return errors.New("entry has no sections")
+	}
+	for key := range e.Sections {
+		if !journal.ValidSectionKey(key) {
+			return fmt.Errorf("invalid section %q", key)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,3744 @@
+package server_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"cmp"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"html/template"
+	"io"
+	"maps"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"derrclan.com/moravian-soap/internal/announcements"
+	"derrclan.com/moravian-soap/internal/commentary"
+	"derrclan.com/moravian-soap/internal/config"
+	"derrclan.com/moravian-soap/internal/dailytexts"
+	"derrclan.com/moravian-soap/internal/esv"
+	"derrclan.com/moravian-soap/internal/maintenance"
+	"derrclan.com/moravian-soap/internal/server"
+	"derrclan.com/moravian-soap/internal/store"
+	"derrclan.com/moravian-soap/internal/testutil"
+	"derrclan.com/moravian-soap/internal/yearbook"
+)
+
+// romans8 is a passage as the ESV API renders it.
+var romans8 = testutil.Passage{
+	HTML: `<h2 class="extra_text">Romans 8:1–2</h2>` +
+		`<p id="p45008001_01-1" class="starts-chapter"><b class="chapter-num" id="v45008001-1">8:1&nbsp;</b>There is therefore now no condemnation for those who are in Christ Jesus. ` +
+		`<b class="verse-num" id="v45008002-1">2&nbsp;</b>For the law of the Spirit of life has set you free in Christ Jesus from the law of sin and death.</p>`,
+	Meta: esv.PassageMeta{ChapterStart: []int{45008001, 45008039}, ChapterEnd: []int{45008001, 45008039}},
+}
+
+func readBody(t *testing.T, resp *http.Response) string {
+	t.Helper()
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("reading response body: %v", err)
+	}
+	return string(b)
+}
+
+func TestIntegration_Index(t *testing.T) {
+	srv := testutil.NewServer(t)
+	today := time.Now().UTC().Format(time.DateOnly)
+	srv.SetDailyText(t, today, dailytexts.DailyText{Verses: []string{"Romans 8:1-2"}})
+	srv.ESV.AddPassage("Romans 8:1-2", romans8)
+
+	resp, err := srv.NewClient(t).Get(srv.URL + "/")
+	if err != nil {
+		t.Fatalf("GET / failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusFound || resp.Header.Get("Location") != "/login" {
+		t.Errorf("anonymous GET / = %d to %q, want redirect to /login", resp.StatusCode, resp.Header.Get("Location"))
+	}
+
+	client := srv.Login(t, "reader@example.com")
+	resp, err = client.Get(srv.URL + "/")
+	if err != nil {
+		t.Fatalf("GET / failed: %v", err)
+	}
+	body := readBody(t, resp)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("GET / = %d: %s", resp.StatusCode, body)
+	}
+	for _, want := range []string{
+		`data-ref="45008002"`,
+		"set you free in Christ Jesus",
+		`data-section="observation"`,
+		"About 1 min of reading (35 words)",
+		`data-section="prayer"`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("index page missing %q", want)
+		}
+	}
+}
+
+func TestIntegration_ReadingIsCached(t *testing.T) {
+	srv := testutil.NewServer(t)
+	srv.SetDailyText(t, "2026-03-07", dailytexts.DailyText{Verses: []string{"Psalm 34:1-7", "Romans 8:1-2"}})
+	srv.ESV.AddPassage("Romans 8:1-2", romans8)
+	client := srv.Login(t, "reader@example.com")
+
+	for range 2 {
+		resp, err := client.Get(srv.URL + "/reading?date=2026-03-07")
+		if err != nil {
+			t.Fatalf("GET /reading failed: %v", err)
+		}
+		body := readBody(t, resp)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("GET /reading = %d: %s", resp.StatusCode, body)
+		}
+		if !strings.Contains(body, "Text of Psalm 34:1-7.") || !strings.Contains(body, "no condemnation") {
+			t.Errorf("reading missing passages: %s", body)
+		}
+	}
+
+	if queries := srv.ESV.Queries(); !slices.Equal(queries, []string{"Psalm 34:1-7;Romans 8:1-2"}) {
+		t.Errorf("ESV queries = %q, want a single query for both passages", queries)
+	}
+}
+
+func TestIntegration_ReadingConcurrent(t *testing.T) {
+	srv := testutil.NewServer(t)
+	srv.SetDailyText(t, "2026-03-07", dailytexts.DailyText{Verses: []string{"Romans 8:1-2"}})
+	srv.ESV.AddPassage("Romans 8:1-2", romans8)
+	srv.ESV.Delay(100 * time.Millisecond)
+	client := srv.Login(t, "reader@example.com")
+
+	bodies := make([]string, 5)
+	var wg sync.WaitGroup
+	for i := range bodies {
+		wg.Go(func() {
+			resp, err := client.Get(srv.URL + "/reading?date=2026-03-07")
+			if err != nil {
+				t.Errorf("GET /reading failed: %v", err)
+				return
+			}
+			bodies[i] = readBody(t, resp)
+		})
+	}
+	wg.Wait()
+
+	for i, body := range bodies {
+		if !strings.Contains(body, "no condemnation") || !strings.Contains(body, "35 words") {
+			t.Errorf("reading %d missing passage: %s", i, body)
+		}
+	}
+	if queries := srv.ESV.Queries(); len(queries) != 1 {
+		t.Errorf("ESV queries = %q, want the concurrent readings to share one", queries)
+	}
+}
+
+func TestIntegration_OfflineApp(t *testing.T) {
+	srv := testutil.NewServer(t)
+	visitor := srv.NewClient(t)
+
+	resp, err := visitor.Get(srv.URL + "/sw.js")
+	if err != nil {
+		t.Fatalf("GET /sw.js failed: %v", err)
+	}
+	body := readBody(t, resp)
+	if resp.StatusCode != http.StatusOK || !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/javascript") || resp.Header.Get("Cache-Control") != "no-cache" {
+		t.Errorf("GET /sw.js = %d %s, Cache-Control %q", resp.StatusCode, resp.Header.Get("Content-Type"), resp.Header.Get("Cache-Control"))
+	}
+	for _, want := range []string{`const VERSION = "`, `"/offline"`, `"/web/style.css?v=`, `"/web/logic.js"`, "addEventListener('fetch'"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("service worker missing %q: %s", want, body[:min(len(body), 500)])
+		}
+	}
+
+	resp, err = visitor.Get(srv.URL + "/offline")
+	if err != nil {
+		t.Fatalf("GET /offline failed: %v", err)
+	}
+	body = readBody(t, resp)
+	if resp.StatusCode != http.StatusOK || !strings.Contains(body, "You're Offline") {
+		t.Errorf("GET /offline = %d: %s", resp.StatusCode, body)
+	}
+	manifestURL := regexp.MustCompile(`/web/manifest\.webmanifest\?v=[^"]+`).FindString(body)
+	if manifestURL == "" {
+		t.Fatalf("page does not link the web app manifest:\n%s", body)
+	}
+	resp, err = visitor.Get(srv.URL + html.UnescapeString(manifestURL))
+	if err != nil {
+		t.Fatalf("GET manifest failed: %v", err)
+	}
+	var manifest struct {
+		StartURL string `json:"start_url"`
+	}
+	if err := json.Unmarshal([]byte(readBody(t, resp)), &manifest); err != nil || manifest.StartURL != "/" {
+		t.Errorf("manifest start_url = %q, %v", manifest.StartURL, err)
+	}
+	if got := resp.Header.Get("Content-Type"); got != "application/manifest+json" {
+		t.Errorf("manifest Content-Type = %q", got)
+	}
+
+	// The journal page gives the service worker the days of the week to keep.
+	client := srv.Login(t, "reader@example.com")
+	resp, err = client.Get(srv.URL + "/")
+	if err != nil {
+		t.Fatalf("GET / failed: %v", err)
+	}
+	weekDates := regexp.MustCompile(`weekDates: (\[[^\]]*\])`).FindStringSubmatch(readBody(t, resp))
+	var dates []string
+	if weekDates == nil || json.Unmarshal([]byte(weekDates[1]), &dates) != nil || len(dates) != 7 {
+		t.Errorf("journal page week dates = %q, want the 7 days of the week", weekDates)
+	}
+
+	resp, err = client.Get(srv.URL + "/logout")
+	if err != nil {
+		t.Fatalf("GET /logout failed: %v", err)
+	}
+	_ = readBody(t, resp)
+	if resp.Header.Get("Clear-Site-Data") == "" {
+		t.Error("logout does not clear what the service worker keeps")
+	}
+}
+
+func TestIntegration_StaticAssetsCacheable(t *testing.T) {
+	srv := testutil.NewServer(t)
+	visitor := srv.NewClient(t)
+
+	resp, err := visitor.Get(srv.URL + "/login")
+	if err != nil {
+		t.Fatalf("GET /login failed: %v", err)
+	}
+	body := readBody(t, resp)
+	styleURL := regexp.MustCompile(`/web/style\.css\?v=[^"]+`).FindString(body)
+	if styleURL == "" {
+		t.Fatalf("login page does not link a versioned stylesheet:\n%s", body)
+	}
+
+	// A fresh client has no CSRF cookie, which must not be set on a response a CDN should keep.
+	for path, want := range map[string]string{
+		html.UnescapeString(styleURL): "public, max-age=31536000, immutable",
+		"/web/style.css":              "public, max-age=3600",
+		"/web/style.css?v=old":        "no-store",
+	} {
+		resp, err := srv.NewClient(t).Get(srv.URL + path)
+		if err != nil {
+			t.Fatalf("GET %s failed: %v", path, err)
+		}
+		_ = readBody(t, resp)
+		if resp.StatusCode != http.StatusOK || resp.Header.Get("Cache-Control") != want {
+			t.Errorf("GET %s = %d with Cache-Control %q, want %q", path, resp.StatusCode, resp.Header.Get("Cache-Control"), want)
+		}
+		if cookies := resp.Header.Values("Set-Cookie"); len(cookies) > 0 {
+			t.Errorf("GET %s sets cookies %q", path, cookies)
+		}
+	}
+}
+
+func TestIntegration_ReadingDoctrinalTextAndPrayer(t *testing.T) {
+	srv := testutil.NewServer(t)
+	srv.SetDailyText(t, "2026-03-07", dailytexts.DailyText{
+		Verses:    []string{"Romans 8:1-2"},
+		Doctrinal: "Do not repay evil for evil or abuse for abuse. 1 Peter 3:9",
+		Prayer:    "God of mercy, let our actions reflect your love. Amen.",
+	})
+	srv.SetDailyText(t, "2026-03-08", dailytexts.DailyText{
+		Verses:    []string{"Romans 8:1-2"},
+		Doctrinal: "We believe in the triune God. Moravian Covenant for Christian Living",
+	})
+	srv.ESV.AddPassage("Romans 8:1-2", romans8)
+	client := srv.Login(t, "reader@example.com")
+
+	resp, err := client.Get(srv.URL + "/?date=2026-03-07")
+	if err != nil {
+		t.Fatalf("GET / failed: %v", err)
+	}
+	body := readBody(t, resp)
+	for _, want := range []string{"no condemnation", "Text of 1 Peter 3:9.", "God of mercy, let our actions reflect your love."} {
+		if !strings.Contains(body, want) {
+			t.Errorf("index page missing %q: %s", want, body)
+		}
+	}
+	if strings.Contains(body, "abuse for abuse") {
+		t.Errorf("index page shows the doctrinal text as printed though its passage was fetched: %s", body)
+	}
+
+	// A doctrinal text that quotes no scripture is shown as printed.
+	resp, err = client.Get(srv.URL + "/reading?date=2026-03-08")
+	if err != nil {
+		t.Fatalf("GET /reading failed: %v", err)
+	}
+	if body := readBody(t, resp); !strings.Contains(body, "We believe in the triune God.") {
+		t.Errorf("reading missing the doctrinal text: %s", body)
+	}
+}
+
+func TestIntegration_ReadingCommentary(t *testing.T) {
+	pack, err := commentary.ParsePack([]byte(`{
+		"dates": {"2026-03-07": [{"title": "Walking in the Spirit", "body": "Read it **slowly**. <script>alert(1)</script>"}]},
+		"passages": {"Romans 8:1-2": [{"body": "No condemnation at all.", "source": "A Lenten Devotional"}]}
+	}`))
+	if err != nil {
+		t.Fatalf("ParsePack failed: %v", err)
+	}
+	srv := testutil.NewServer(t, server.WithCommentary(pack))
+	for _, date := range []string{"2026-03-07", "2026-03-08"} {
+		srv.SetDailyText(t, date, dailytexts.DailyText{Verses: []string{"Romans 8:1-2"}})
+	}
+	srv.SetDailyText(t, "2026-03-09", dailytexts.DailyText{Verses: []string{"Psalm 23"}})
+	srv.ESV.AddPassage("Romans 8:1-2", romans8)
+	client := srv.Login(t, "reader@example.com")
+
+	resp, err := client.Get(srv.URL + "/?date=2026-03-07")
+	if err != nil {
+		t.Fatalf("GET / failed: %v", err)
+	}
+	body := readBody(t, resp)
+	for _, want := range []string{`<details class="commentary">`, "Walking in the Spirit", "<strong>slowly</strong>", "No condemnation at all.", "A Lenten Devotional"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("index page missing %q: %s", want, body)
+		}
+	}
+	if strings.Contains(body, "<script>alert(1)") {
+		t.Errorf("commentary was not sanitized: %s", body)
+	}
+
+	// Notes on a passage are shown whenever it is read, and only notes on the day on that day.
+	resp, err = client.Get(srv.URL + "/reading?date=2026-03-08")
+	if err != nil {
+		t.Fatalf("GET /reading failed: %v", err)
+	}
+	body = readBody(t, resp)
+	if !strings.Contains(body, "No condemnation at all.") || strings.Contains(body, "Walking in the Spirit") {
+		t.Errorf("reading of 2026-03-08 has the wrong commentary: %s", body)
+	}
+	resp, err = client.Get(srv.URL + "/reading?date=2026-03-09")
+	if err != nil {
+		t.Fatalf("GET /reading failed: %v", err)
+	}
+	if body := readBody(t, resp); strings.Contains(body, `class="commentary"`) {
+		t.Errorf("reading with no commentary shows the panel: %s", body)
+	}
+}
+
+func TestIntegration_ReadingESVUnavailable(t *testing.T) {
+	t.Setenv("ADMIN_EMAIL", "admin@example.com")
+	srv := testutil.NewServer(t)
+	srv.SetDailyText(t, "2026-03-07", dailytexts.DailyText{Verses: []string{"Psalm 34:1-7"}})
+	srv.ESV.FailWith(http.StatusServiceUnavailable)
+	client := srv.Login(t, "reader@example.com")
+
+	resp, err := client.Get(srv.URL + "/reading?date=2026-03-07")
+	if err != nil {
+		t.Fatalf("GET /reading failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusInternalServerError {
+		t.Errorf("GET /reading = %d, want %d", resp.StatusCode, http.StatusInternalServerError)
+	}
+
+	used, err := srv.Store.GetInstanceESVUsage(context.Background(), time.Now().UTC().Format(time.DateOnly))
+	if err != nil {
+		t.Fatalf("GetInstanceESVUsage failed: %v", err)
+	}
+	if used != 0 {
+		t.Errorf("failed ESV fetches counted %d queries against the quota, want 0", used)
+	}
+
+	// The failed call is logged for the admin, retried, and without the API key.
+	calls, err := srv.Store.ListAPICalls(context.Background(), 10)
+	if err != nil {
+		t.Fatalf("ListAPICalls failed: %v", err)
+	}
+	if len(calls) != 1 || calls[0].Status != http.StatusServiceUnavailable || calls[0].Retries != 2 || calls[0].Cache != "not stored" {
+		t.Fatalf("API log = %+v, want the retried call that failed", calls)
+	}
+	check := &store.CacheVerification{At: time.Now(), Checked: 5, Changed: []string{"John 3:16"}}
+	if err := srv.Store.RecordCacheVerification(context.Background(), check, 10); err != nil {
+		t.Fatalf("RecordCacheVerification failed: %v", err)
+	}
+	admin := srv.Login(t, "admin@example.com")
+	resp, err = admin.Get(srv.URL + "/admin/api-log")
+	if err != nil {
+		t.Fatalf("GET /admin/api-log failed: %v", err)
+	}
+	body := readBody(t, resp)
+	if resp.StatusCode != http.StatusOK || !strings.Contains(body, "Psalm 34:1-7") || !strings.Contains(body, "503") {
+		t.Errorf("GET /admin/api-log = %d, want the failed call listed: %s", resp.StatusCode, body)
+	}
+	if !strings.Contains(body, "John 3:16") {
+		t.Errorf("API log does not list the passage the cache verifier refreshed: %s", body)
+	}
+	if strings.Contains(body, "test-key") {
+		t.Errorf("API log shows the API key: %s", body)
+	}
+}
+
+func TestIntegration_ReadingESVCircuitOpen(t *testing.T) {
+	srv := testutil.NewServer(t)
+	srv.SetDailyText(t, "2026-03-07", dailytexts.DailyText{Verses: []string{"Psalm 34:1-7", "Luke 15:1-7"}})
+	srv.ESV.FailWith(http.StatusServiceUnavailable)
+	client := srv.Login(t, "reader@example.com")
+
+	resp, err := client.Get(srv.URL + "/reading?date=2026-03-07")
+	if err != nil {
+		t.Fatalf("GET /reading failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusInternalServerError {
+		t.Errorf("first GET /reading = %d, want %d", resp.StatusCode, http.StatusInternalServerError)
+	}
+
+	// The breaker opens partway through the next fetch's retries, and the reading lists the references.
+	resp, err = client.Get(srv.URL + "/reading?date=2026-03-07")
+	if err != nil {
+		t.Fatalf("GET /reading failed: %v", err)
+	}
+	body := readBody(t, resp)
+	if resp.StatusCode != http.StatusOK || !strings.Contains(body, "cannot be loaded right now") ||
+		!strings.Contains(body, "https://www.esv.org/Luke%2015:1-7/") {
+		t.Fatalf("GET /reading with the breaker open = %d, want the plain references: %s", resp.StatusCode, body)
+	}
+	queries := len(srv.ESV.Queries())
+	resp, err = client.Get(srv.URL + "/reading?date=2026-03-07")
+	if err != nil {
+		t.Fatalf("GET /reading failed: %v", err)
+	}
+	resp.Body.Close()
+	if got := len(srv.ESV.Queries()); got != queries {
+		t.Errorf("ESV API queried %d more times with the breaker open, want 0", got-queries)
+	}
+}
+
+func TestIntegration_ReadingTranslation(t *testing.T) {
+	srv := testutil.NewServer(t)
+	srv.SetDailyText(t, "2026-03-07", dailytexts.DailyText{Verses: []string{"Romans 8:1-2"}})
+	srv.ESV.AddPassage("Romans 8:1-2", romans8)
+	bibleAPI := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"reference":"Romans 8:1-2","verses":[`+
+			`{"book_name":"Romans","chapter":8,"verse":1,"text":"There is therefore now no condemnation to those who are in Christ Jesus."},`+
+			`{"book_name":"Romans","chapter":8,"verse":2,"text":"For the law of the Spirit of life in Christ Jesus made me free from the law of sin and of death."}]}`)
+	}))
+	t.Cleanup(bibleAPI.Close)
+	t.Setenv("BIBLE_API_URL", bibleAPI.URL)
+	client := srv.Login(t, "reader@example.com")
+
+	reading := func(query string) (int, string) {
+		t.Helper()
+		resp, err := client.Get(srv.URL + "/reading?date=2026-03-07" + query)
+		if err != nil {
+			t.Fatalf("GET /reading failed: %v", err)
+		}
+		body := readBody(t, resp)
+		return resp.StatusCode, body
+	}
+
+	if code, body := reading("&translation=web"); code != http.StatusOK || !strings.Contains(body, "made me free") ||
+		!strings.Contains(body, `data-ref="45008002"`) || !strings.Contains(body, "&translation=WEB") {
+		t.Errorf("GET /reading in the WEB = %d: %s", code, body)
+	}
+	if code, body := reading(""); code != http.StatusOK || !strings.Contains(body, "has set you free") {
+		t.Errorf("GET /reading = %d, want the ESV: %s", code, body)
+	}
+	if code, _ := reading("&translation=nope"); code != http.StatusBadRequest {
+		t.Errorf("GET /reading in an unknown translation = %d, want 400", code)
+	}
+
+	resp, err := client.PostForm(srv.URL+"/settings/reading", url.Values{"translation": {"web"}, "verse_numbers": {"on"}})
+	if err != nil {
+		t.Fatalf("POST /settings/reading failed: %v", err)
+	}
+	if body := readBody(t, resp); resp.StatusCode != http.StatusOK || !strings.Contains(body, `<option value="WEB" selected>`) {
+		t.Fatalf("POST /settings/reading = %d: %s", resp.StatusCode, body)
+	}
+	if code, body := reading(""); code != http.StatusOK || !strings.Contains(body, "made me free") {
+		t.Errorf("GET /reading with the WEB preferred = %d: %s", code, body)
+	}
+
+	used, err := srv.Store.GetInstanceESVUsage(context.Background(), time.Now().UTC().Format(time.DateOnly))
+	if err != nil {
+		t.Fatalf("GetInstanceESVUsage failed: %v", err)
+	}
+	if used != 1 {
+		t.Errorf("ESV usage = %d, want only the ESV fetch counted", used)
+	}
+}
+
+func TestIntegration_APITokens(t *testing.T) {
+	srv := testutil.NewServer(t)
+	client := srv.Login(t, "reader@example.com")
+	ctx := context.Background()
+
+	resp, err := client.PostForm(srv.URL+"/settings/api", url.Values{"action": {"create"}, "name": {"widget"}})
+	if err != nil {
+		t.Fatalf("POST /settings/api failed: %v", err)
+	}
+	body := readBody(t, resp)
+	_, rest, ok := strings.Cut(body, `<code class="api-token">`)
+	token, _, _ := strings.Cut(rest, "</code>")
+	if resp.StatusCode != http.StatusOK || !ok || !strings.HasPrefix(token, "soap_") {
+		t.Fatalf("creating a token = %d: %s", resp.StatusCode, body)
+	}
+
+	withToken := func(method, path string) int {
+		t.Helper()
+		req, err := http.NewRequest(method, srv.URL+path, nil)
+		if err != nil {
+			t.Fatalf("creating request: %v", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := srv.Client().Do(req)
+		if err != nil {
+			t.Fatalf("%s %s failed: %v", method, path, err)
+		}
+		readBody(t, resp)
+		return resp.StatusCode
+	}
+
+	if code := withToken(http.MethodGet, "/api/v1/week?start=2026-03-01"); code != http.StatusOK {
+		t.Errorf("GET /api/v1/week with a token = %d, want 200", code)
+	}
+	if code := withToken(http.MethodGet, "/settings/api"); code != http.StatusUnauthorized {
+		t.Errorf("GET /settings/api with a token = %d, want 401", code)
+	}
+	if code := withToken(http.MethodPost, "/settings/api"); code != http.StatusForbidden {
+		t.Errorf("POST /settings/api with a token = %d, want 403", code)
+	}
+
+	user, err := srv.Store.GetUserByEmail(ctx, "reader@example.com")
+	if err != nil {
+		t.Fatalf("GetUserByEmail failed: %v", err)
+	}
+	tokens, err := srv.Store.ListAPITokens(ctx, user.ID)
+	if err != nil || len(tokens) != 1 {
+		t.Fatalf("ListAPITokens = %v, %v; want one token", tokens, err)
+	}
+	resp, err = client.PostForm(srv.URL+"/settings/api", url.Values{"action": {"revoke"}, "id": {strconv.FormatInt(tokens[0].ID, 10)}})
+	if err != nil {
+		t.Fatalf("POST /settings/api failed: %v", err)
+	}
+	readBody(t, resp)
+	if code := withToken(http.MethodGet, "/api/v1/week?start=2026-03-01"); code != http.StatusUnauthorized {
+		t.Errorf("GET /api/v1/week with a revoked token = %d, want 401", code)
+	}
+}
+
+func TestIntegration_APIPrivacyLock(t *testing.T) {
+	srv := testutil.NewServer(t)
+	client := srv.Login(t, "reader@example.com")
+	ctx := context.Background()
+	user, err := srv.Store.GetUserByEmail(ctx, "reader@example.com")
+	if err != nil {
+		t.Fatalf("GetUserByEmail failed: %v", err)
+	}
+	entry := &store.SOAPData{Date: "2026-03-07", Sections: map[string]string{"observation": "No condemnation."}}
+	if err := srv.Store.SaveSOAPData(ctx, user.ID, entry); err != nil {
+		t.Fatalf("SaveSOAPData failed: %v", err)
+	}
+
+	resp, err := client.PostForm(srv.URL+"/settings/privacy", url.Values{"lock_after": {"15"}})
+	if err != nil {
+		t.Fatalf("POST /settings/privacy failed: %v", err)
+	}
+	readBody(t, resp)
+	resp, err = client.PostForm(srv.URL+"/settings/api", url.Values{"action": {"create"}, "name": {"widget"}})
+	if err != nil {
+		t.Fatalf("POST /settings/api failed: %v", err)
+	}
+	_, rest, _ := strings.Cut(readBody(t, resp), `<code class="api-token">`)
+	token, _, _ := strings.Cut(rest, "</code>")
+
+	get := func(client *http.Client, path, token string) (int, string) {
+		t.Helper()
+		req, err := http.NewRequest(http.MethodGet, srv.URL+path, nil)
+		if err != nil {
+			t.Fatalf("creating request: %v", err)
+		}
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("GET %s failed: %v", path, err)
+		}
+		return resp.StatusCode, readBody(t, resp)
+	}
+	paths := []string{"/api/v1/entries", "/api/v1/entries/2026-03-07"}
+	for _, path := range paths {
+		if code, body := get(client, path, ""); code != http.StatusOK {
+			t.Errorf("GET %s before the lock period = %d: %s", path, code, body)
+		}
+	}
+
+	// Leave the session unused for longer than the lock period.
+	u, err := url.Parse(srv.URL)
+	if err != nil {
+		t.Fatalf("parsing server URL: %v", err)
+	}
+	for _, c := range client.Jar.Cookies(u) {
+		if c.Name == "session_token" {
+			if err := srv.Store.TouchSession(ctx, c.Value, time.Now().Add(-16*time.Minute)); err != nil {
+				t.Fatalf("TouchSession failed: %v", err)
+			}
+		}
+	}
+
+	// The locked session cannot read the journal through the API, but a token, which has no session
+	// to lock, still can.
+	for _, path := range paths {
+		if code, body := get(client, path, ""); code != http.StatusUnauthorized || strings.Contains(body, "No condemnation.") {
+			t.Errorf("locked GET %s = %d: %s", path, code, body)
+		}
+		if code, body := get(srv.Client(), path, token); code != http.StatusOK || !strings.Contains(body, "No condemnation.") {
+			t.Errorf("GET %s with a token = %d: %s", path, code, body)
+		}
+	}
+}
+
+func TestIntegration_DaySnapshot(t *testing.T) {
+	srv := testutil.NewServer(t)
+	srv.SetDailyText(t, "2026-03-07", dailytexts.DailyText{Verses: []string{"Romans 8:1-2"}})
+	srv.ESV.AddPassage("Romans 8:1-2", romans8)
+	client := srv.Login(t, "reader@example.com")
+	ctx := context.Background()
+	user, err := srv.Store.GetUserByEmail(ctx, "reader@example.com")
+	if err != nil {
+		t.Fatalf("GetUserByEmail failed: %v", err)
+	}
+
+	day := func() string {
+		t.Helper()
+		resp, err := client.Get(srv.URL + "/?date=2026-03-07")
+		if err != nil {
+			t.Fatalf("GET / failed: %v", err)
+		}
+		return readBody(t, resp)
+	}
+	if body := day(); !strings.Contains(body, "no condemnation") {
+		t.Fatalf("index page missing the passage: %s", body)
+	}
+
+	// A repeat visit in the session renders the day from its snapshot, which a change made behind the
+	// server's back does not reach...
+	if err := srv.Store.SaveSOAPData(ctx, user.ID, &store.SOAPData{Date: "2026-03-07", Sections: map[string]string{"observation": "Written elsewhere."}}); err != nil {
+		t.Fatalf("SaveSOAPData failed: %v", err)
+	}
+	if body := day(); strings.Contains(body, "Written elsewhere.") {
+		t.Errorf("repeat visit was not rendered from the snapshot: %s", body)
+	}
+
+	// ...but anything the user changes does.
+	resp, err := client.Post(srv.URL+"/soap", "application/json", strings.NewReader(`{"date": "2026-03-07", "sections": {"prayer": "Saved here."}}`))
+	if err != nil {
+		t.Fatalf("POST /soap failed: %v", err)
+	}
+	if body := readBody(t, resp); resp.StatusCode != http.StatusOK {
+		t.Fatalf("POST /soap = %d: %s", resp.StatusCode, body)
+	}
+	if body := day(); !strings.Contains(body, "Written elsewhere.") || !strings.Contains(body, "Saved here.") {
+		t.Errorf("visit after a save shows a stale entry: %s", body)
+	}
+}
+
+func TestIntegration_SaveAndLoad(t *testing.T) {
+	srv := testutil.NewServer(t)
+	srv.SetDailyText(t, "2026-03-07", dailytexts.DailyText{Verses: []string{"Romans 8:1-2"}})
+	srv.ESV.AddPassage("Romans 8:1-2", romans8)
+	client := srv.Login(t, "reader@example.com")
+
+	entry := map[string]any{
+		"date":           "2026-03-07",
+		"sections":       map[string]string{"observation": "No condemnation.", "prayer": "Thank you."},
+		"selectedVerses": []string{"45008001"},
+	}
+	payload, err := json.Marshal(entry)
+	if err != nil {
+		t.Fatalf("encoding entry: %v", err)
+	}
+	resp, err := client.Post(srv.URL+"/soap", "application/json", bytes.NewReader(payload))
+	if err != nil {
+		t.Fatalf("POST /soap failed: %v", err)
+	}
+	if body := readBody(t, resp); resp.StatusCode != http.StatusOK || !strings.Contains(body, "success") {
+		t.Fatalf("POST /soap = %d: %s", resp.StatusCode, body)
+	}
+
+	resp, err = client.Get(srv.URL + "/soap?date=2026-03-07")
+	if err != nil {
+		t.Fatalf("GET /soap failed: %v", err)
+	}
+	var got struct {
+		Sections       map[string]string `json:"sections"`
+		SelectedVerses []string          `json:"selectedVerses"`
+	}
+	if err := json.Unmarshal([]byte(readBody(t, resp)), &got); err != nil {
+		t.Fatalf("decoding GET /soap response: %v", err)
+	}
+	if got.Sections["observation"] != "No condemnation." || got.Sections["prayer"] != "Thank you." {
+		t.Errorf("unexpected sections: %v", got.Sections)
+	}
+	if !slices.Equal(got.SelectedVerses, []string{"45008001"}) {
+		t.Errorf("unexpected selected verses: %v", got.SelectedVerses)
+	}
+
+	user, err := srv.Store.GetUserByEmail(context.Background(), "reader@example.com")
+	if err != nil {
+		t.Fatalf("GetUserByEmail failed: %v", err)
+	}
+	dates, err := srv.Store.GetJournaledDates(context.Background(), user.ID, "2026-03-01", "2026-03-31")
+	if err != nil {
+		t.Fatalf("GetJournaledDates failed: %v", err)
+	}
+	if !slices.Equal(dates, []string{"2026-03-07"}) {
+		t.Errorf("journaled dates = %v, want [2026-03-07]", dates)
+	}
+
+	other := srv.Login(t, "other@example.com")
+	resp, err = other.Get(srv.URL + "/soap?date=2026-03-07")
+	if err != nil {
+		t.Fatalf("GET /soap failed: %v", err)
+	}
+	if body := readBody(t, resp); strings.Contains(body, "No condemnation.") {
+		t.Errorf("another user can read the entry: %s", body)
+	}
+}
+
+func TestIntegration_SOAPFormByDate(t *testing.T) {
+	srv := testutil.NewServer(t)
+	srv.SetDailyText(t, "2026-03-07", dailytexts.DailyText{Verses: []string{"Romans 8:1-2"}})
+	client := srv.Login(t, "reader@example.com")
+
+	payload := `{"date":"2026-03-07","sections":{"observation":"No condemnation.","gratitude":"Freedom."},"selectedVerses":["45008001"]}`
+	resp, err := client.Post(srv.URL+"/soap", "application/json", strings.NewReader(payload))
+	if err != nil {
+		t.Fatalf("POST /soap failed: %v", err)
+	}
+	if body := readBody(t, resp); resp.StatusCode != http.StatusOK {
+		t.Fatalf("POST /soap = %d: %s", resp.StatusCode, body)
+	}
+
+	resp, err = client.Get(srv.URL + "/soap-form?date=2026-03-07")
+	if err != nil {
+		t.Fatalf("GET /soap-form failed: %v", err)
+	}
+	body := readBody(t, resp)
+	if resp.StatusCode != http.StatusOK || !strings.Contains(body, `data-date="2026-03-07"`) || !strings.Contains(body, "45008001") {
+		t.Fatalf("GET /soap-form = %d, want the entry's date and verses: %s", resp.StatusCode, body)
+	}
+	// Sections from outside the user's schema get a field of their own.
+	for _, want := range []string{">No condemnation.</textarea>", `id="section-gratitude"`, ">Freedom.</textarea>"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("GET /soap-form is missing %q: %s", want, body)
+		}
+	}
+
+	resp, err = client.Get(srv.URL + "/soap-form?date=2026-03-08")
+	if err != nil {
+		t.Fatalf("GET /soap-form failed: %v", err)
+	}
+	body = readBody(t, resp)
+	if !strings.Contains(body, `data-date="2026-03-08"`) || strings.Contains(body, "No condemnation.") || !strings.Contains(body, `id="section-observation"`) {
+		t.Errorf("GET /soap-form for another day = %s, want its empty form", body)
+	}
+}
+
+func TestIntegration_FormsWithoutJavaScript(t *testing.T) {
+	srv := testutil.NewServer(t)
+	srv.SetDailyText(t, "2026-03-07", dailytexts.DailyText{Verses: []string{"Romans 8:1-2"}})
+	client := srv.Login(t, "reader@example.com")
+
+	resp, err := client.PostForm(srv.URL+"/soap", url.Values{
+		"date":        {"2026-03-07"},
+		"observation": {"No condemnation."},
+		"verses":      {"Romans 8:2"},
+		"csrf_token":  {"ignored, not a section"},
+	})
+	if err != nil {
+		t.Fatalf("POST /soap failed: %v", err)
+	}
+	if body := readBody(t, resp); resp.StatusCode != http.StatusSeeOther || resp.Header.Get("Location") != "/?date=2026-03-07" {
+		t.Fatalf("POST /soap as a form = %d, want a redirect to the entry: %s", resp.StatusCode, body)
+	}
+	user, err := srv.Store.GetUserByEmail(context.Background(), "reader@example.com")
+	if err != nil {
+		t.Fatalf("GetUserByEmail failed: %v", err)
+	}
+	entry, err := srv.Store.GetSOAPData(context.Background(), user.ID, "2026-03-07")
+	if err != nil {
+		t.Fatalf("GetSOAPData failed: %v", err)
+	}
+	if want := map[string]string{"observation": "No condemnation."}; !maps.Equal(entry.Sections, want) || !slices.Equal(entry.SelectedVerses, []string{"45008002"}) {
+		t.Errorf("saved entry = %v, %v; want the observation and Romans 8:2", entry.Sections, entry.SelectedVerses)
+	}
+
+	resp, err = client.PostForm(srv.URL+"/soap", url.Values{"date": {"2026-03-07"}, "verses": {"Romans"}})
+	if err != nil {
+		t.Fatalf("POST /soap failed: %v", err)
+	}
+	if readBody(t, resp); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("POST /soap as a form with invalid verses = %d, want 400", resp.StatusCode)
+	}
+
+	// The page shows the saved verses for the form, and the partials' forms post without HTMX.
+	resp, err = client.Get(srv.URL + "/?date=2026-03-07")
+	if err != nil {
+		t.Fatalf("GET / failed: %v", err)
+	}
+	if body := readBody(t, resp); !strings.Contains(body, `name="verses" value="Romans 8:2"`) || !strings.Contains(body, `action="/favorite"`) {
+		t.Errorf("GET / is missing the forms for browsers without JavaScript: %s", body)
+	}
+	resp, err = client.PostForm(srv.URL+"/favorite", url.Values{"date": {"2026-03-07"}, "action": {"add"}})
+	if err != nil {
+		t.Fatalf("POST /favorite failed: %v", err)
+	}
+	if readBody(t, resp); resp.StatusCode != http.StatusSeeOther || resp.Header.Get("Location") != "/?date=2026-03-07" {
+		t.Errorf("POST /favorite without HTMX = %d, want a redirect to the entry", resp.StatusCode)
+	}
+	req, err := http.NewRequest(http.MethodPost, srv.URL+"/favorite", strings.NewReader("date=2026-03-07&action=remove"))
+	if err != nil {
+		t.Fatalf("creating request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("HX-Request", "true")
+	resp, err = client.Do(req)
+	if err != nil {
+		t.Fatalf("POST /favorite failed: %v", err)
+	}
+	if body := readBody(t, resp); resp.StatusCode != http.StatusOK || !strings.Contains(body, "Pin as a favorite") {
+		t.Errorf("POST /favorite with HTMX = %d, want the partial: %s", resp.StatusCode, body)
+	}
+}
+
+func TestIntegration_SelectedVersesValidated(t *testing.T) {
+	srv := testutil.NewServer(t)
+	srv.SetDailyText(t, "2026-03-07", dailytexts.DailyText{Verses: []string{"Romans 8:1-2"}})
+	client := srv.Login(t, "reader@example.com")
+
+	save := func(verses string) (int, string) {
+		t.Helper()
+		payload := `{"date":"2026-03-07","sections":{},"selectedVerses":` + verses + `}`
+		resp, err := client.Post(srv.URL+"/soap", "application/json", strings.NewReader(payload))
+		if err != nil {
+			t.Fatalf("POST /soap failed: %v", err)
+		}
+		return resp.StatusCode, readBody(t, resp)
+	}
+
+	// Verses are saved by their canonical IDs, without those outside the day's passages, and the
+	// selection saved is returned.
+	code, body := save(`["v45008002-1","45008001","45008002","45009001"]`)
+	var result struct {
+		SelectedVerses []string `json:"selectedVerses"`
+	}
+	if err := json.Unmarshal([]byte(body), &result); code != http.StatusOK || err != nil {
+		t.Fatalf("POST /soap = %d: %s", code, body)
+	}
+	want := []string{"45008002", "45008001"}
+	if !slices.Equal(result.SelectedVerses, want) {
+		t.Errorf("saved selection = %v, want %v", result.SelectedVerses, want)
+	}
+	ctx := context.Background()
+	user, err := srv.Store.GetUserByEmail(ctx, "reader@example.com")
+	if err != nil {
+		t.Fatalf("GetUserByEmail failed: %v", err)
+	}
+	saved, err := srv.Store.GetSOAPData(ctx, user.ID, "2026-03-07")
+	if err != nil {
+		t.Fatalf("GetSOAPData failed: %v", err)
+	}
+	if !slices.Equal(saved.SelectedVerses, want) {
+		t.Errorf("stored selection = %v, want %v", saved.SelectedVerses, want)
+	}
+
+	if code, body := save(`["45008001","bogus"]`); code != http.StatusBadRequest {
+		t.Errorf("POST /soap with an invalid verse ID = %d, want 400: %s", code, body)
+	}
+}
+
+func TestIntegration_Announcements(t *testing.T) {
+	srv := testutil.NewServer(t)
+	srv.SetDailyText(t, time.Now().UTC().Format(time.DateOnly), dailytexts.DailyText{Verses: []string{"Romans 8:1-2"}})
+	srv.ESV.AddPassage("Romans 8:1-2", romans8)
+	client := testutil.HTMX(srv.Login(t, "reader@example.com"))
+	all := announcements.All()
+
+	resp, err := client.Get(srv.URL + "/")
+	if err != nil {
+		t.Fatalf("GET / failed: %v", err)
+	}
+	if body := readBody(t, resp); !strings.Contains(body, html.EscapeString(all[0].Title)) {
+		t.Errorf("expected banner for %q", all[0].ID)
+	}
+
+	resp, err = client.Post(srv.URL+"/announcements/"+all[0].ID+"/dismiss", "", nil)
+	if err != nil {
+		t.Fatalf("POST dismiss failed: %v", err)
+	}
+	body := readBody(t, resp)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("POST dismiss = %d: %s", resp.StatusCode, body)
+	}
+	if len(all) > 1 && !strings.Contains(body, html.EscapeString(all[1].Title)) {
+		t.Errorf("expected the next announcement after dismissing, got %s", body)
+	}
+
+	resp, err = client.Get(srv.URL + "/")
+	if err != nil {
+		t.Fatalf("GET / failed: %v", err)
+	}
+	if body := readBody(t, resp); strings.Contains(body, html.EscapeString(all[0].Title)) {
+		t.Errorf("dismissed announcement %q is still shown", all[0].ID)
+	}
+
+	other := srv.Login(t, "other@example.com")
+	resp, err = other.Get(srv.URL + "/")
+	if err != nil {
+		t.Fatalf("GET / failed: %v", err)
+	}
+	if body := readBody(t, resp); !strings.Contains(body, html.EscapeString(all[0].Title)) {
+		t.Errorf("dismissal affected another user")
+	}
+
+	resp, err = client.Post(srv.URL+"/announcements/no-such-announcement/dismiss", "", nil)
+	if err != nil {
+		t.Fatalf("POST dismiss failed: %v", err)
+	}
+	readBody(t, resp)
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("dismissing an unknown announcement = %d, want 404", resp.StatusCode)
+	}
+
+	resp, err = client.Get(srv.URL + "/whats-new")
+	if err != nil {
+		t.Fatalf("GET /whats-new failed: %v", err)
+	}
+	body = readBody(t, resp)
+	for _, a := range all {
+		if !strings.Contains(body, html.EscapeString(a.Title)) {
+			t.Errorf("what's new page is missing %q", a.ID)
+		}
+	}
+}
+
+func TestIntegration_PrivacyLock(t *testing.T) {
+	srv := testutil.NewServer(t)
+	srv.SetDailyText(t, time.Now().UTC().Format(time.DateOnly), dailytexts.DailyText{Verses: []string{"Romans 8:1-2"}})
+	srv.ESV.AddPassage("Romans 8:1-2", romans8)
+	client := srv.Login(t, "reader@example.com")
+	ctx := context.Background()
+
+	resp, err := client.PostForm(srv.URL+"/settings/privacy", url.Values{"lock_after": {"15"}})
+	if err != nil {
+		t.Fatalf("POST /settings/privacy failed: %v", err)
+	}
+	if body := readBody(t, resp); resp.StatusCode != http.StatusOK {
+		t.Fatalf("POST /settings/privacy = %d: %s", resp.StatusCode, body)
+	}
+
+	resp, err = client.Get(srv.URL + "/")
+	if err != nil {
+		t.Fatalf("GET / failed: %v", err)
+	}
+	if readBody(t, resp); resp.StatusCode != http.StatusOK {
+		t.Fatalf("GET / before the lock period = %d, want 200", resp.StatusCode)
+	}
+
+	// Leave the session unused for longer than the lock period.
+	u, err := url.Parse(srv.URL)
+	if err != nil {
+		t.Fatalf("parsing server URL: %v", err)
+	}
+	var session string
+	for _, c := range client.Jar.Cookies(u) {
+		if c.Name == "session_token" {
+			session = c.Value
+		}
+	}
+	if err := srv.Store.TouchSession(ctx, session, time.Now().Add(-16*time.Minute)); err != nil {
+		t.Fatalf("TouchSession failed: %v", err)
+	}
+
+	resp, err = client.Get(srv.URL + "/")
+	if err != nil {
+		t.Fatalf("GET / failed: %v", err)
+	}
+	readBody(t, resp)
+	if resp.StatusCode != http.StatusSeeOther || resp.Header.Get("Location") != "/unlock?next=%2F" {
+		t.Fatalf("locked GET / = %d to %q, want redirect to /unlock", resp.StatusCode, resp.Header.Get("Location"))
+	}
+
+	// A bearer token, which only the API accepts, does not get a locked session past the lock.
+	req, err := http.NewRequest(http.MethodGet, srv.URL+"/journal", nil)
+	if err != nil {
+		t.Fatalf("creating request: %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer soap_bogus")
+	resp, err = client.Do(req)
+	if err != nil {
+		t.Fatalf("GET /journal failed: %v", err)
+	}
+	readBody(t, resp)
+	if resp.StatusCode != http.StatusSeeOther || !strings.HasPrefix(resp.Header.Get("Location"), "/unlock") {
+		t.Errorf("locked GET /journal with a bearer token = %d to %q, want redirect to /unlock", resp.StatusCode, resp.Header.Get("Location"))
+	}
+
+	req, err = http.NewRequest(http.MethodGet, srv.URL+"/soap", nil)
+	if err != nil {
+		t.Fatalf("creating request: %v", err)
+	}
+	req.Header.Set("HX-Request", "true")
+	resp, err = client.Do(req)
+	if err != nil {
+		t.Fatalf("GET /soap failed: %v", err)
+	}
+	if body := readBody(t, resp); resp.StatusCode != http.StatusUnauthorized || resp.Header.Get("HX-Redirect") != "/unlock" || strings.Contains(body, "sections") {
+		t.Errorf("locked GET /soap = %d with HX-Redirect %q: %s", resp.StatusCode, resp.Header.Get("HX-Redirect"), body)
+	}
+
+	resp, err = client.PostForm(srv.URL+"/unlock", url.Values{"password": {"wrong"}, "next": {"/"}})
+	if err != nil {
+		t.Fatalf("POST /unlock failed: %v", err)
+	}
+	if body := readBody(t, resp); resp.StatusCode != http.StatusUnauthorized || !strings.Contains(body, "Incorrect password") {
+		t.Errorf("unlock with the wrong password = %d: %s", resp.StatusCode, body)
+	}
+
+	resp, err = client.PostForm(srv.URL+"/unlock", url.Values{"password": {testutil.Password}, "next": {"https://evil.example.com/"}})
+	if err != nil {
+		t.Fatalf("POST /unlock failed: %v", err)
+	}
+	readBody(t, resp)
+	if resp.StatusCode != http.StatusSeeOther || resp.Header.Get("Location") != "/" {
+		t.Errorf("unlock = %d to %q, want redirect to /", resp.StatusCode, resp.Header.Get("Location"))
+	}
+
+	resp, err = client.Get(srv.URL + "/")
+	if err != nil {
+		t.Fatalf("GET / failed: %v", err)
+	}
+	if readBody(t, resp); resp.StatusCode != http.StatusOK {
+		t.Errorf("GET / after unlocking = %d, want 200", resp.StatusCode)
+	}
+}
+
+func TestIntegration_BackupRoundTrip(t *testing.T) {
+	srv := testutil.NewServer(t)
+	client := testutil.HTMX(srv.Login(t, "reader@example.com"))
+
+	for _, entry := range []map[string]any{
+		{"date": "2026-03-07", "sections": map[string]string{"observation": "No condemnation.", "prayer": "Thank you."}, "selectedVerses": []string{"45008001"}},
+		{"date": "2026-03-06", "sections": map[string]string{"application": "Rest."}, "selectedVerses": []string{}},
+	} {
+		payload, err := json.Marshal(entry)
+		if err != nil {
+			t.Fatalf("encoding entry: %v", err)
+		}
+		resp, err := client.Post(srv.URL+"/soap", "application/json", bytes.NewReader(payload))
+		if err != nil {
+			t.Fatalf("POST /soap failed: %v", err)
+		}
+		if body := readBody(t, resp); resp.StatusCode != http.StatusOK {
+			t.Fatalf("POST /soap = %d: %s", resp.StatusCode, body)
+		}
+	}
+	resp, err := client.PostForm(srv.URL+"/prayers?date=2026-03-06", url.Values{"text": {"Peace for the family"}})
+	if err != nil {
+		t.Fatalf("POST /prayers failed: %v", err)
+	}
+	if body := readBody(t, resp); resp.StatusCode != http.StatusOK {
+		t.Fatalf("POST /prayers = %d: %s", resp.StatusCode, body)
+	}
+
+	download := func(client *http.Client) string {
+		t.Helper()
+		resp, err := client.Get(srv.URL + "/backup")
+		if err != nil {
+			t.Fatalf("GET /backup failed: %v", err)
+		}
+		body := readBody(t, resp)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("GET /backup = %d: %s", resp.StatusCode, body)
+		}
+		return body
+	}
+	restore := func(client *http.Client, backup string) string {
+		t.Helper()
+		var buf bytes.Buffer
+		form := multipart.NewWriter(&buf)
+		part, err := form.CreateFormFile("backup", "backup.json")
+		if err != nil {
+			t.Fatalf("creating form file: %v", err)
+		}
+		if _, err := io.WriteString(part, backup); err != nil {
+			t.Fatalf("writing form file: %v", err)
+		}
+		if err := form.Close(); err != nil {
+			t.Fatalf("closing form: %v", err)
+		}
+		resp, err := client.Post(srv.URL+"/settings/backup", form.FormDataContentType(), &buf)
+		if err != nil {
+			t.Fatalf("POST /settings/backup failed: %v", err)
+		}
+		body := readBody(t, resp)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("POST /settings/backup = %d: %s", resp.StatusCode, body)
+		}
+		return body
+	}
+
+	backup := download(client)
+	if !strings.Contains(backup, `"version": 2`) || !strings.Contains(backup, "No condemnation.") || !strings.Contains(backup, "Peace for the family") {
+		t.Fatalf("backup is missing content:\n%s", backup)
+	}
+
+	other := srv.Login(t, "other@example.com")
+	if body := restore(other, backup); !strings.Contains(body, "Your backup has been restored") {
+		t.Fatalf("restoring backup did not succeed: %s", body)
+	}
+	if got := download(other); got != backup {
+		t.Errorf("backup after restore differs:\ngot:\n%s\nwant:\n%s", got, backup)
+	}
+	otherUser, err := srv.Store.GetUserByEmail(context.Background(), "other@example.com")
+	if err != nil {
+		t.Fatalf("GetUserByEmail failed: %v", err)
+	}
+	completeness, err := srv.Store.GetEntryCompleteness(context.Background(), otherUser.ID, "2026-03-01", "2026-03-31")
+	if want := map[string]string{"2026-03-06": "partial", "2026-03-07": "partial"}; err != nil || !maps.Equal(completeness, want) {
+		t.Errorf("GetEntryCompleteness after restore = %v, %v; want %v", completeness, err, want)
+	}
+
+	// Restoring the same backup again must not duplicate prayer items.
+	restore(other, backup)
+	if got := download(other); got != backup {
+		t.Errorf("backup after restoring twice differs:\ngot:\n%s\nwant:\n%s", got, backup)
+	}
+
+	if body := restore(other, `{"version": 1, "entries": [{"date": "2026-03-08", "sections": {"bogus key": "x"}}]}`); !strings.Contains(body, "Failed to restore backup") {
+		t.Errorf("restoring an unknown section did not fail: %s", body)
+	}
+	if got := download(other); got != backup {
+		t.Errorf("a failed restore changed the account:\ngot:\n%s\nwant:\n%s", got, backup)
+	}
+	if body := restore(other, `{"version": 99}`); !strings.Contains(body, "newer version") {
+		t.Errorf("restoring a future backup version did not fail: %s", body)
+	}
+}
+
+func TestIntegration_PassageEntries(t *testing.T) {
+	srv := testutil.NewServer(t)
+	john316 := testutil.Passage{HTML: `<p>For God so loved the world.</p>`, Meta: esv.PassageMeta{Canonical: "John 3:16"}}
+	srv.ESV.AddPassage("john 3:16", john316)
+	srv.ESV.AddPassage("John 3:16", john316)
+	client := srv.Login(t, "reader@example.com")
+
+	// Starting an entry looks the passage up and opens the entry under its canonical reference.
+	resp, err := client.PostForm(srv.URL+"/passages", url.Values{"reference": {"john 3:16"}})
+	if err != nil {
+		t.Fatalf("POST /passages failed: %v", err)
+	}
+	if body := readBody(t, resp); resp.StatusCode != http.StatusSeeOther {
+		t.Fatalf("POST /passages = %d, want 303: %s", resp.StatusCode, body)
+	}
+	entryURL := srv.URL + resp.Header.Get("Location")
+	resp, err = client.Get(entryURL)
+	if err != nil {
+		t.Fatalf("GET %s failed: %v", entryURL, err)
+	}
+	if body := readBody(t, resp); !strings.Contains(body, "John 3:16") || !strings.Contains(body, "For God so loved the world.") {
+		t.Fatalf("new entry is missing its passage:\n%s", body)
+	}
+
+	resp, err = client.PostForm(entryURL, url.Values{"date": {"2026-10-11"}, "observation": {"Sermon notes."}})
+	if err != nil {
+		t.Fatalf("POST %s failed: %v", entryURL, err)
+	}
+	if body := readBody(t, resp); !strings.Contains(body, "Your entry has been saved.") {
+		t.Fatalf("saving the entry did not succeed: %s", body)
+	}
+	resp, err = client.PostForm(entryURL, url.Values{"date": {"someday"}})
+	if err != nil {
+		t.Fatalf("POST %s failed: %v", entryURL, err)
+	}
+	if body := readBody(t, resp); !strings.Contains(body, "Enter the date as YYYY-MM-DD") {
+		t.Errorf("saving an invalid date did not fail: %s", body)
+	}
+
+	resp, err = client.Get(srv.URL + "/passages")
+	if err != nil {
+		t.Fatalf("GET /passages failed: %v", err)
+	}
+	if body := readBody(t, resp); !strings.Contains(body, "John 3:16") || !strings.Contains(body, "2026-10-11") {
+		t.Errorf("passage entry missing from the list: %s", body)
+	}
+
+	resp, err = client.Get(entryURL + "/export?format=markdown")
+	if err != nil {
+		t.Fatalf("GET export failed: %v", err)
+	}
+	if body := readBody(t, resp); !strings.Contains(body, "John 3:16 (2026-10-11)") || !strings.Contains(body, "Sermon notes.") {
+		t.Errorf("exported entry is missing content:\n%s", body)
+	}
+
+	resp, err = client.Get(srv.URL + "/backup")
+	if err != nil {
+		t.Fatalf("GET /backup failed: %v", err)
+	}
+	if body := readBody(t, resp); !strings.Contains(body, `"reference": "John 3:16"`) {
+		t.Errorf("backup is missing the passage entry:\n%s", body)
+	}
+
+	// Other users cannot see the entry.
+	other := srv.Login(t, "other@example.com")
+	resp, err = other.Get(entryURL)
+	if err != nil {
+		t.Fatalf("GET %s failed: %v", entryURL, err)
+	}
+	if readBody(t, resp); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("GET another user's entry = %d, want 404", resp.StatusCode)
+	}
+
+	resp, err = client.PostForm(entryURL, url.Values{"action": {"delete"}})
+	if err != nil {
+		t.Fatalf("POST %s failed: %v", entryURL, err)
+	}
+	readBody(t, resp)
+	resp, err = client.Get(entryURL)
+	if err != nil {
+		t.Fatalf("GET %s failed: %v", entryURL, err)
+	}
+	if readBody(t, resp); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("GET deleted entry = %d, want 404", resp.StatusCode)
+	}
+}
+
+func TestIntegration_Yearbook(t *testing.T) {
+	srv := testutil.NewServer(t)
+	client := srv.Login(t, "reader@example.com")
+	ctx := context.Background()
+	user, err := srv.Store.GetUserByEmail(ctx, "reader@example.com")
+	if err != nil {
+		t.Fatalf("GetUserByEmail failed: %v", err)
+	}
+	srv.SetDailyText(t, "2026-03-07", dailytexts.DailyText{Verses: []string{"Romans 8:1-2"}, DailyWatchWord: "There is now no condemnation."})
+	entry := &store.SOAPData{Date: "2026-03-07", Sections: map[string]string{"observation": "Set free."}, SelectedVerses: []string{"45008001"}}
+	if err := srv.Store.SaveSOAPData(ctx, user.ID, entry); err != nil {
+		t.Fatalf("SaveSOAPData failed: %v", err)
+	}
+	get := func(path string) (int, string) {
+		t.Helper()
+		resp, err := client.Get(srv.URL + path)
+		if err != nil {
+			t.Fatalf("GET %s failed: %v", path, err)
+		}
+		return resp.StatusCode, readBody(t, resp)
+	}
+
+	if code, body := get("/settings/backup"); code != http.StatusOK || !strings.Contains(body, `<option value="2026">`) {
+		t.Fatalf("backup page = %d, want 2026 offered for a yearbook: %s", code, body)
+	}
+	if code, _ := get("/backup/yearbook/2026"); code != http.StatusNotFound {
+		t.Errorf("yearbook before it was asked for = %d, want %d", code, http.StatusNotFound)
+	}
+	resp, err := client.PostForm(srv.URL+"/backup/yearbook", url.Values{"year": {"2026"}})
+	if err != nil {
+		t.Fatalf("POST /backup/yearbook failed: %v", err)
+	}
+	_ = readBody(t, resp)
+	if resp.StatusCode != http.StatusSeeOther {
+		t.Fatalf("asking for a yearbook = %d, want %d", resp.StatusCode, http.StatusSeeOther)
+	}
+	if _, body := get("/settings/backup"); !strings.Contains(body, "Being made") {
+		t.Errorf("backup page does not say the yearbook is being made: %s", body)
+	}
+
+	// The background job makes the yearbook.
+	yearbook.MakeQueued(ctx, srv.Store)
+	if _, body := get("/settings/backup"); !strings.Contains(body, `href="/backup/yearbook/2026"`) {
+		t.Errorf("backup page does not link to the yearbook: %s", body)
+	}
+	code, body := get("/backup/yearbook/2026")
+	if code != http.StatusOK {
+		t.Fatalf("GET yearbook = %d", code)
+	}
+	for _, want := range []string{"There is now no condemnation.", "Set free.", "Romans 8:1", "Index of Highlighted Verses"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("yearbook does not contain %q", want)
+		}
+	}
+}
+
+func TestIntegration_ExportEntries(t *testing.T) {
+	srv := testutil.NewServer(t)
+	client := srv.Login(t, "reader@example.com")
+	ctx := context.Background()
+	user, err := srv.Store.GetUserByEmail(ctx, "reader@example.com")
+	if err != nil {
+		t.Fatalf("GetUserByEmail failed: %v", err)
+	}
+
+	for _, payload := range []string{
+		`{"date":"2026-03-01","sections":{"observation":"Grace upon grace."},"selectedVerses":[]}`,
+		`{"date":"2026-03-05","sections":{"observation":"Out of range."},"selectedVerses":[]}`,
+	} {
+		resp, err := client.Post(srv.URL+"/soap", "application/json", strings.NewReader(payload))
+		if err != nil {
+			t.Fatalf("POST /soap failed: %v", err)
+		}
+		if body := readBody(t, resp); resp.StatusCode != http.StatusOK {
+			t.Fatalf("POST /soap = %d: %s", resp.StatusCode, body)
+		}
+	}
+	passage := &store.PassageEntry{Reference: "Psalm 23", Date: "2026-03-02", Sections: map[string]string{"observation": "Still waters."}}
+	if err := srv.Store.CreatePassageEntry(ctx, user.ID, passage); err != nil {
+		t.Fatalf("CreatePassageEntry failed: %v", err)
+	}
+
+	export := func(payload string) (int, string) {
+		t.Helper()
+		resp, err := client.Post(srv.URL+"/export", "application/json", strings.NewReader(payload))
+		if err != nil {
+			t.Fatalf("POST /export failed: %v", err)
+		}
+		return resp.StatusCode, readBody(t, resp)
+	}
+
+	status, body := export(`{"from":"2026-03-01","to":"2026-03-03","format":"csv","scripture":false}`)
+	if status != http.StatusOK {
+		t.Fatalf("POST /export = %d: %s", status, body)
+	}
+	rows, err := csv.NewReader(strings.NewReader(body)).ReadAll()
+	if err != nil {
+		t.Fatalf("reading exported CSV: %v\n%s", err, body)
+	}
+	if len(rows) != 3 || rows[1][0] != "daily" || rows[1][1] != "2026-03-01" || rows[2][0] != "passage" || rows[2][2] != "Psalm 23" {
+		t.Errorf("exported rows = %q, want the daily entry on 2026-03-01 then the passage entry", rows)
+	}
+	if strings.Contains(body, "Out of range.") || slices.Contains(rows[0], "Scripture") {
+		t.Errorf("export includes an entry outside the range or scripture:\n%s", body)
+	}
+
+	status, body = export(`{"types":["passage"],"format":"md"}`)
+	if status != http.StatusOK || !strings.Contains(body, "Still waters.") || strings.Contains(body, "Grace upon grace.") {
+		t.Errorf("passage-only export = %d:\n%s", status, body)
+	}
+
+	for _, payload := range []string{
+		`{"from":"2026-03-05","to":"2026-03-01","format":"json"}`,
+		`{"types":["sermon"],"format":"json"}`,
+		`{"format":"html"}`,
+	} {
+		if status, body := export(payload); status != http.StatusBadRequest {
+			t.Errorf("export %s = %d, want 400: %s", payload, status, body)
+		}
+	}
+
+	resp, err := client.Get(srv.URL + "/api/v1/export")
+	if err != nil {
+		t.Fatalf("GET /api/v1/export failed: %v", err)
+	}
+	body = readBody(t, resp)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("GET /api/v1/export = %d: %s", resp.StatusCode, body)
+	}
+	zr, err := zip.NewReader(strings.NewReader(body), int64(len(body)))
+	if err != nil {
+		t.Fatalf("reading archive: %v", err)
+	}
+	var names []string
+	for _, f := range zr.File {
+		names = append(names, f.Name)
+	}
+	if want := []string{"2026-03-01.md", "2026-03-02.md", "2026-03-05.md", "entries.json"}; !slices.Equal(names, want) {
+		t.Errorf("archive files = %v, want %v", names, want)
+	}
+
+	// Once the session locks, neither export hands over the journal until it is unlocked.
+	resp, err = client.PostForm(srv.URL+"/settings/privacy", url.Values{"lock_after": {"15"}})
+	if err != nil {
+		t.Fatalf("POST /settings/privacy failed: %v", err)
+	}
+	readBody(t, resp)
+	u, err := url.Parse(srv.URL)
+	if err != nil {
+		t.Fatalf("parsing server URL: %v", err)
+	}
+	for _, c := range client.Jar.Cookies(u) {
+		if c.Name == "session_token" {
+			if err := srv.Store.TouchSession(ctx, c.Value, time.Now().Add(-16*time.Minute)); err != nil {
+				t.Fatalf("TouchSession failed: %v", err)
+			}
+		}
+	}
+	resp, err = client.Get(srv.URL + "/api/v1/export")
+	if err != nil {
+		t.Fatalf("GET /api/v1/export failed: %v", err)
+	}
+	if body := readBody(t, resp); resp.StatusCode != http.StatusUnauthorized || strings.HasPrefix(body, "PK") {
+		t.Errorf("locked GET /api/v1/export = %d: %q", resp.StatusCode, body)
+	}
+	if status, body := export(`{"format":"json"}`); status != http.StatusUnauthorized || strings.Contains(body, "Grace upon grace.") {
+		t.Errorf("locked POST /export = %d: %s", status, body)
+	}
+}
+
+func TestIntegration_ExportScriptureBatched(t *testing.T) {
+	srv := testutil.NewServer(t)
+	srv.SetDailyText(t, "2026-03-01", dailytexts.DailyText{Verses: []string{"Psalm 34:1-7", "Romans 8:1-2"}})
+	srv.SetDailyText(t, "2026-03-02", dailytexts.DailyText{Verses: []string{"John 11:35"}})
+	client := srv.Login(t, "reader@example.com")
+
+	for _, payload := range []string{
+		`{"date":"2026-03-01","sections":{"observation":"No condemnation."},"selectedVerses":[]}`,
+		`{"date":"2026-03-02","sections":{"observation":"He wept."},"selectedVerses":[]}`,
+	} {
+		resp, err := client.Post(srv.URL+"/soap", "application/json", strings.NewReader(payload))
+		if err != nil {
+			t.Fatalf("POST /soap failed: %v", err)
+		}
+		if body := readBody(t, resp); resp.StatusCode != http.StatusOK {
+			t.Fatalf("POST /soap = %d: %s", resp.StatusCode, body)
+		}
+	}
+
+	export := func() []map[string]any {
+		t.Helper()
+		resp, err := client.Post(srv.URL+"/export", "application/json", strings.NewReader(`{"format":"json"}`))
+		if err != nil {
+			t.Fatalf("POST /export failed: %v", err)
+		}
+		body := readBody(t, resp)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("POST /export = %d: %s", resp.StatusCode, body)
+		}
+		var entries []map[string]any
+		if err := json.Unmarshal([]byte(body), &entries); err != nil {
+			t.Fatalf("decoding export: %v\n%s", err, body)
+		}
+		return entries
+	}
+
+	entries := export()
+	if len(entries) != 2 {
+		t.Fatalf("exported %d entries, want 2", len(entries))
+	}
+	for _, e := range entries {
+		scripture, _ := e["scripture"].(string)
+		for _, ref := range strings.Split(e["reference"].(string), "; ") {
+			if !strings.Contains(scripture, "Text of "+ref+".") {
+				t.Errorf("scripture of %v is missing %s: %q", e["date"], ref, scripture)
+			}
+		}
+		if e["date"] == "2026-03-02" && strings.Contains(scripture, "Psalm 34") {
+			t.Errorf("scripture of 2026-03-02 includes another day's passage: %q", scripture)
+		}
+	}
+	if queries := srv.ESV.Queries(); len(queries) != 1 {
+		t.Errorf("ESV queries = %q, want both days fetched in one", queries)
+	}
+
+	// Each day was cached on its own, so exporting again needs no query.
+	export()
+	if queries := srv.ESV.Queries(); len(queries) != 1 {
+		t.Errorf("ESV queries = %q, want the second export read from the cache", queries)
+	}
+}
+
+func TestIntegration_ImportEntries(t *testing.T) {
+	srv := testutil.NewServer(t)
+	client := srv.Login(t, "reader@example.com")
+	ctx := context.Background()
+	user, err := srv.Store.GetUserByEmail(ctx, "reader@example.com")
+	if err != nil {
+		t.Fatalf("GetUserByEmail failed: %v", err)
+	}
+
+	payload := `[
+		{"type":"daily","date":"2026-03-01","sections":{"observation":"Grace upon grace."}},
+		{"type":"daily","date":"2026-02-30","sections":{"observation":"No such day."}},
+		{"type":"passage","reference":"Psalm 23","sections":{"prayer":"Shepherd me."},"createdAt":"2026-03-02T08:00:00Z"},
+		{"type":"passage","sections":{"prayer":"No reference."}},
+		{"type":"daily","date":"2026-03-03","sections":{"<script>":"Bad section."}}
+	]`
+	importEntries := func() map[string]any {
+		t.Helper()
+		resp, err := client.Post(srv.URL+"/api/v1/import", "application/json", strings.NewReader(payload))
+		if err != nil {
+			t.Fatalf("POST /api/v1/import failed: %v", err)
+		}
+		body := readBody(t, resp)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("POST /api/v1/import = %d: %s", resp.StatusCode, body)
+		}
+		var summary map[string]any
+		if err := json.Unmarshal([]byte(body), &summary); err != nil {
+			t.Fatalf("decoding import summary: %v\n%s", err, body)
+		}
+		return summary
+	}
+
+	summary := importEntries()
+	if summary["imported"] != 2.0 || summary["failed"] != 3.0 {
+		t.Errorf("import summary = %v, want 2 imported and 3 failed", summary)
+	}
+	rows := summary["rows"].([]any)
+	for i, ok := range []bool{true, false, true, false, false} {
+		row := rows[i].(map[string]any)
+		if row["ok"] != ok || (ok == (row["error"] != nil)) {
+			t.Errorf("row %d = %v, want ok %v", i+1, row, ok)
+		}
+	}
+
+	// Importing again updates the same entries rather than adding them twice.
+	importEntries()
+	soapData, err := srv.Store.GetSOAPData(ctx, user.ID, "2026-03-01")
+	if err != nil {
+		t.Fatalf("GetSOAPData failed: %v", err)
+	}
+	if soapData.Sections["observation"] != "Grace upon grace." {
+		t.Errorf("imported entry sections = %v", soapData.Sections)
+	}
+	passages, err := srv.Store.ListPassageEntries(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("ListPassageEntries failed: %v", err)
+	}
+	if len(passages) != 1 || passages[0].Sections["prayer"] != "Shepherd me." {
+		t.Errorf("passage entries = %+v, want the one imported entry", passages)
+	}
+
+	resp, err := client.Post(srv.URL+"/api/v1/import", "application/json", strings.NewReader(`{"not":"a list"}`))
+	if err != nil {
+		t.Fatalf("POST /api/v1/import failed: %v", err)
+	}
+	if body := readBody(t, resp); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("importing an object = %d, want 400: %s", resp.StatusCode, body)
+	}
+}
+
+func TestIntegration_PrayerResolvedDate(t *testing.T) {
+	srv := testutil.NewServer(t)
+	client := testutil.HTMX(srv.Login(t, "reader@example.com"))
+	ctx := context.Background()
+
+	post := func(path string, form url.Values) {
+		t.Helper()
+		resp, err := client.PostForm(srv.URL+path, form)
+		if err != nil {
+			t.Fatalf("POST %s failed: %v", path, err)
+		}
+		if body := readBody(t, resp); resp.StatusCode != http.StatusOK {
+			t.Fatalf("POST %s = %d: %s", path, resp.StatusCode, body)
+		}
+	}
+	post("/prayers?date=2026-03-01", url.Values{"text": {"Healing for Ruth"}})
+
+	user, err := srv.Store.GetUserByEmail(ctx, "reader@example.com")
+	if err != nil {
+		t.Fatalf("GetUserByEmail failed: %v", err)
+	}
+	items, err := srv.Store.ListPrayerItems(ctx, user.ID)
+	if err != nil || len(items) != 1 {
+		t.Fatalf("ListPrayerItems = %v, %v; want one item", items, err)
+	}
+	path := "/prayers/" + strconv.FormatInt(items[0].ID, 10)
+
+	post(path+"?date=2026-03-05", url.Values{"text": {"Healing for Ruth"}, "status": {"answered"}})
+	// Editing the answered item while viewing a later date must not move its resolved date.
+	post(path+"?date=2026-03-20", url.Values{"text": {"Healing for Ruth's knee"}, "status": {"answered"}})
+
+	item, err := srv.Store.GetPrayerItem(ctx, user.ID, items[0].ID)
+	if err != nil {
+		t.Fatalf("GetPrayerItem failed: %v", err)
+	}
+	if item.Text != "Healing for Ruth's knee" || item.ResolvedDate == nil || *item.ResolvedDate != "2026-03-05" {
+		t.Errorf("item = %q resolved %v, want the edited text resolved on 2026-03-05", item.Text, item.ResolvedDate)
+	}
+
+	post(path+"?date=2026-03-21", url.Values{"text": {"Healing for Ruth's knee"}, "status": {"open"}})
+	if item, err = srv.Store.GetPrayerItem(ctx, user.ID, items[0].ID); err != nil || item.ResolvedDate != nil {
+		t.Errorf("reopened item resolved %v, %v; want no resolved date", item.ResolvedDate, err)
+	}
+}
+
+func TestIntegration_Concordance(t *testing.T) {
+	srv := testutil.NewServer(t)
+	client := srv.Login(t, "reader@example.com")
+
+	resp, err := client.Get(srv.URL + "/concordance")
+	if err != nil {
+		t.Fatalf("GET /concordance failed: %v", err)
+	}
+	if body := readBody(t, resp); !strings.Contains(body, "begins with your first journal entry") {
+		t.Errorf("concordance before any entry is not empty:\n%s", body)
+	}
+
+	payload := `{"date":"2026-01-01","sections":{"observation":"Blessed is the man."},"selectedVerses":["45008001"]}`
+	resp, err = client.Post(srv.URL+"/soap", "application/json", strings.NewReader(payload))
+	if err != nil {
+		t.Fatalf("POST /soap failed: %v", err)
+	}
+	if body := readBody(t, resp); resp.StatusCode != http.StatusOK {
+		t.Fatalf("POST /soap = %d: %s", resp.StatusCode, body)
+	}
+
+	resp, err = client.Get(srv.URL + "/concordance")
+	if err != nil {
+		t.Fatalf("GET /concordance failed: %v", err)
+	}
+	body := readBody(t, resp)
+	// The readings of 2026-01-01 are Psalm 1, Genesis 1-2 and Matthew 1; Romans 8 was journaled on.
+	for _, want := range []string{"Psalm 1<", "Genesis 2<", "Matthew 1<", "Romans 8<", `<a href="/?date=2026-01-01"><strong>2026-01-01</strong></a>`, `<a href="/?date=2026-01-02">2026-01-02</a>`} {
+		if !strings.Contains(body, want) {
+			t.Errorf("concordance is missing %q", want)
+		}
+	}
+	if strings.Contains(body, "2025-12-31") {
+		t.Error("concordance includes readings from before the first entry")
+	}
+
+	// The links open the day they name.
+	resp, err = client.Get(srv.URL + "/?date=2026-01-01")
+	if err != nil {
+		t.Fatalf("GET /?date=2026-01-01 failed: %v", err)
+	}
+	if body := readBody(t, resp); !strings.Contains(body, "Blessed is the man.") {
+		t.Errorf("index for 2026-01-01 does not show its entry")
+	}
+}
+
+func TestIntegration_QuietTime(t *testing.T) {
+	srv := testutil.NewServer(t)
+	client := testutil.HTMX(srv.Login(t, "reader@example.com"))
+	srv.SetDailyText(t, "2026-03-07", dailytexts.DailyText{Verses: []string{"Psalm 34:1-7"}})
+
+	quietTime := func(action string) string {
+		t.Helper()
+		form := url.Values{"date": {"2026-03-07"}, "action": {action}}
+		resp, err := client.PostForm(srv.URL+"/quiet-time", form)
+		if err != nil {
+			t.Fatalf("POST /quiet-time %s failed: %v", action, err)
+		}
+		body := readBody(t, resp)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("POST /quiet-time %s = %d: %s", action, resp.StatusCode, body)
+		}
+		return body
+	}
+
+	if body := quietTime("start"); !strings.Contains(body, `data-remaining="900"`) {
+		t.Fatalf("started session is not counting down 15 minutes:\n%s", body)
+	}
+	// The session carries on when the page is reloaded.
+	srv.Clock.Advance(5 * time.Minute)
+	resp, err := client.Get(srv.URL + "/?date=2026-03-07")
+	if err != nil {
+		t.Fatalf("GET / failed: %v", err)
+	}
+	if body := readBody(t, resp); !strings.Contains(body, `data-remaining="600"`) {
+		t.Errorf("reloaded page does not resume the session")
+	}
+	// It cannot be completed before its time is up.
+	if body := quietTime("complete"); !strings.Contains(body, `data-remaining="600"`) {
+		t.Errorf("session completed early:\n%s", body)
+	}
+
+	srv.Clock.Advance(10 * time.Minute)
+	if body := quietTime("complete"); !strings.Contains(body, "Quiet time completed") {
+		t.Errorf("session not completed when its time was up:\n%s", body)
+	}
+
+	// A session stopped early is not counted.
+	quietTime("start")
+	srv.Clock.Advance(time.Minute)
+	if body := quietTime("stop"); strings.Contains(body, "data-remaining") || strings.Contains(body, "Quiet time completed") {
+		t.Errorf("stopped session still running or completed:\n%s", body)
+	}
+
+	resp, err = client.Get(srv.URL + "/stats")
+	if err != nil {
+		t.Fatalf("GET /stats failed: %v", err)
+	}
+	body := readBody(t, resp)
+	// The session was on a day before the last 30 days, so it only counts all time.
+	if !strings.Contains(body, "<th>Sessions completed</th>\n                        <td>0</td>\n                        <td>1</td>") ||
+		!strings.Contains(body, "<td>15 min</td>") {
+		t.Errorf("stats do not count the one completed session:\n%s", body)
+	}
+}
+
+func TestIntegration_Stats(t *testing.T) {
+	srv := testutil.NewServer(t)
+	client := srv.Login(t, "reader@example.com")
+	srv.Clock.Set(time.Date(2026, 3, 3, 12, 0, 0, 0, time.UTC))
+
+	payload := `{"date":"2026-03-01","sections":{"observation":"Grace upon grace."},"selectedVerses":[]}`
+	resp, err := client.Post(srv.URL+"/soap", "application/json", strings.NewReader(payload))
+	if err != nil {
+		t.Fatalf("POST /soap failed: %v", err)
+	}
+	if body := readBody(t, resp); resp.StatusCode != http.StatusOK {
+		t.Fatalf("POST /soap = %d: %s", resp.StatusCode, body)
+	}
+
+	resp, err = client.Get(srv.URL + "/stats")
+	if err != nil {
+		t.Fatalf("GET /stats failed: %v", err)
+	}
+	body := readBody(t, resp)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("GET /stats = %d: %s", resp.StatusCode, body)
+	}
+	for _, want := range []string{"Journaling Time", "Average per entry", "<td>1</td>", "0 min", "journaled on 1 of 62 days", "Catch up on 60 missed days"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("stats page missing %q", want)
+		}
+	}
+
+	resp, err = client.Get(srv.URL + "/stats?weeks=2")
+	if err != nil {
+		t.Fatalf("GET /stats failed: %v", err)
+	}
+	body = readBody(t, resp)
+	if !strings.Contains(body, `<a href="/?date=2026-01-01">Jan 1</a>`) || strings.Count(body, "<th>Mar ") != 2 {
+		t.Errorf("catch-up plan does not spread the missed days over two weeks:\n%s", body)
+	}
+}
+
+func TestIntegration_EntryCompleteness(t *testing.T) {
+	srv := testutil.NewServer(t)
+	client := srv.Login(t, "reader@example.com")
+	ctx := context.Background()
+	user, err := srv.Store.GetUserByEmail(ctx, "reader@example.com")
+	if err != nil {
+		t.Fatalf("GetUserByEmail failed: %v", err)
+	}
+
+	completeness := func() string {
+		t.Helper()
+		got, err := srv.Store.GetEntryCompleteness(ctx, user.ID, "2026-03-01", "2026-03-01")
+		if err != nil {
+			t.Fatalf("GetEntryCompleteness failed: %v", err)
+		}
+		return cmp.Or(got["2026-03-01"], "none")
+	}
+
+	// Each save carries only the sections that changed, so completeness must account for earlier saves.
+	for _, step := range []struct {
+		payload string
+		want    string
+	}{
+		{`{"date":"2026-03-01","sections":{},"selectedVerses":["43003016"]}`, "draft"},
+		{`{"date":"2026-03-01","sections":{"observation":"The Word was God."},"selectedVerses":["43003016"]}`, "partial"},
+		{`{"date":"2026-03-01","sections":{"application":"Listen.","prayer":"Amen."},"selectedVerses":["43003016"]}`, "complete"},
+	} {
+		resp, err := client.Post(srv.URL+"/soap", "application/json", strings.NewReader(step.payload))
+		if err != nil {
+			t.Fatalf("POST /soap failed: %v", err)
+		}
+		if body := readBody(t, resp); resp.StatusCode != http.StatusOK {
+			t.Fatalf("POST /soap = %d: %s", resp.StatusCode, body)
+		}
+		if got := completeness(); got != step.want {
+			t.Errorf("after saving %s: completeness = %q, want %q", step.payload, got, step.want)
+		}
+	}
+
+	// Under the ACTS sections none of the SOAP sections count, so the entry is only partly written.
+	resp, err := client.PostForm(srv.URL+"/settings/journal", url.Values{"preset": {"acts"}})
+	if err != nil {
+		t.Fatalf("POST /settings/journal failed: %v", err)
+	}
+	if body := readBody(t, resp); resp.StatusCode != http.StatusOK {
+		t.Fatalf("POST /settings/journal = %d: %s", resp.StatusCode, body)
+	}
+	if got := completeness(); got != "partial" {
+		t.Errorf("after changing the journal template: completeness = %q, want partial", got)
+	}
+
+	resp, err = client.Get(srv.URL + "/stats")
+	if err != nil {
+		t.Fatalf("GET /stats failed: %v", err)
+	}
+	// The entry is too old for the recent column, so only the all-time column counts it.
+	body := readBody(t, resp)
+	_, partial, _ := strings.Cut(body, "Partly written</th>")
+	if row, _, _ := strings.Cut(partial, "</tr>"); !strings.Contains(row, "<td>1</td>") {
+		t.Errorf("stats page does not count the partly written entry: %s", row)
+	}
+}
+
+func TestIntegration_SettingsReminders(t *testing.T) {
+	srv := testutil.NewServer(t)
+	client := srv.Login(t, "reader@example.com")
+
+	resp, err := client.PostForm(srv.URL+"/settings/reminders", url.Values{"inactive_days": {"3"}, "cooldown_days": {"14"}})
+	if err != nil {
+		t.Fatalf("POST /settings/reminders failed: %v", err)
+	}
+	if body := readBody(t, resp); resp.StatusCode != http.StatusOK || !strings.Contains(body, `<option value="3" selected>`) {
+		t.Fatalf("POST /settings/reminders = %d: %s", resp.StatusCode, body)
+	}
+
+	user, err := srv.Store.GetUserByEmail(context.Background(), "reader@example.com")
+	if err != nil {
+		t.Fatalf("GetUserByEmail failed: %v", err)
+	}
+	prefs, err := srv.Store.GetPreferences(context.Background(), user.ID)
+	if err != nil || prefs.InactivityReminderDays != 3 || prefs.InactivityCooldownDays != 14 {
+		t.Errorf("GetPreferences = %+v, %v; want reminders after 3 days, every 14 days", prefs, err)
+	}
+
+	resp, err = client.PostForm(srv.URL+"/settings/reminders", url.Values{"inactive_days": {"1"}, "cooldown_days": {"14"}})
+	if err != nil {
+		t.Fatalf("POST /settings/reminders failed: %v", err)
+	}
+	if readBody(t, resp); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("POST /settings/reminders with an unlisted period = %d, want 400", resp.StatusCode)
+	}
+
+	resp, err = client.PostForm(srv.URL+"/settings/reminders", url.Values{"action": {"daily_email"}, "daily_email_time": {"06:30"}, "timezone": {"America/Chicago"}})
+	if err != nil {
+		t.Fatalf("POST /settings/reminders failed: %v", err)
+	}
+	if body := readBody(t, resp); resp.StatusCode != http.StatusOK || !strings.Contains(body, `value="06:30"`) || !strings.Contains(body, "link to confirm the daily email") {
+		t.Fatalf("POST /settings/reminders for the daily email = %d: %s", resp.StatusCode, body)
+	}
+	subscribers, err := srv.Store.ListDailyEmailSubscribers(context.Background())
+	if err != nil || len(subscribers) != 0 {
+		t.Errorf("ListDailyEmailSubscribers before confirming = %+v, %v; want none", subscribers, err)
+	}
+	confirmDailyEmail(t, srv, client, user.ID)
+	subscribers, err = srv.Store.ListDailyEmailSubscribers(context.Background())
+	if err != nil || len(subscribers) != 1 || subscribers[0].SendTime != "06:30" || subscribers[0].Timezone != "America/Chicago" {
+		t.Errorf("ListDailyEmailSubscribers = %+v, %v; want the user at 06:30 in Chicago", subscribers, err)
+	}
+	prefs, err = srv.Store.GetPreferences(context.Background(), user.ID)
+	if err != nil || prefs.InactivityReminderDays != 3 {
+		t.Errorf("GetPreferences = %+v, %v; want inactivity reminders kept", prefs, err)
+	}
+
+	resp, err = client.PostForm(srv.URL+"/settings/reminders", url.Values{"action": {"daily_email"}, "daily_email_time": {"6am"}, "timezone": {"America/Chicago"}})
+	if err != nil {
+		t.Fatalf("POST /settings/reminders failed: %v", err)
+	}
+	if readBody(t, resp); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("POST /settings/reminders with an invalid send time = %d, want 400", resp.StatusCode)
+	}
+}
+
+// confirmDailyEmail confirms the user's pending subscription to the daily email from the link in the
+// confirmation email.
+func confirmDailyEmail(t *testing.T, srv *testutil.Server, client *http.Client, userID int64) {
+	t.Helper()
+	subs, err := srv.Store.ListEmailSubscriptions(context.Background(), userID)
+	if err != nil || len(subs) != 1 || subs[0].Status != store.SubscriptionPending {
+		t.Fatalf("ListEmailSubscriptions = %+v, %v; want a pending subscription", subs, err)
+	}
+	emails, err := srv.Store.ListRecentEmails(context.Background(), 1)
+	if err != nil || len(emails) != 1 || !strings.Contains(emails[0].Subject, "Confirm your daily email") {
+		t.Fatalf("ListRecentEmails = %+v, %v; want the confirmation email", emails, err)
+	}
+
+	link := srv.URL + "/subscriptions/confirm?token=" + url.QueryEscape(subs[0].Token)
+	resp, err := client.Get(link)
+	if err != nil {
+		t.Fatalf("GET the confirmation link failed: %v", err)
+	}
+	if body := readBody(t, resp); resp.StatusCode != http.StatusOK || !strings.Contains(body, "Confirm Subscription") {
+		t.Fatalf("GET the confirmation link = %d: %s", resp.StatusCode, body)
+	}
+	resp, err = client.PostForm(srv.URL+"/subscriptions/confirm", url.Values{"token": {subs[0].Token}})
+	if err != nil {
+		t.Fatalf("POST /subscriptions/confirm failed: %v", err)
+	}
+	if body := readBody(t, resp); resp.StatusCode != http.StatusOK || !strings.Contains(body, "daily email is confirmed") {
+		t.Fatalf("POST /subscriptions/confirm = %d: %s", resp.StatusCode, body)
+	}
+}
+
+func TestIntegration_Subscriptions(t *testing.T) {
+	srv := testutil.NewServer(t)
+	client := srv.Login(t, "reader@example.com")
+	user, err := srv.Store.GetUserByEmail(context.Background(), "reader@example.com")
+	if err != nil {
+		t.Fatalf("GetUserByEmail failed: %v", err)
+	}
+
+	request := func(method, query, body string) (int, []map[string]string) {
+		t.Helper()
+		req, err := http.NewRequest(method, srv.URL+"/api/v1/subscriptions"+query, strings.NewReader(body))
+		if err != nil {
+			t.Fatalf("creating request: %v", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("%s /api/v1/subscriptions failed: %v", method, err)
+		}
+		respBody := readBody(t, resp)
+		var subs []map[string]string
+		if resp.StatusCode < 300 {
+			if !strings.HasPrefix(respBody, "[") {
+				respBody = "[" + respBody + "]"
+			}
+			if err := json.Unmarshal([]byte(respBody), &subs); err != nil {
+				t.Fatalf("decoding %s /api/v1/subscriptions: %v: %s", method, err, respBody)
+			}
+		}
+		return resp.StatusCode, subs
+	}
+
+	if code, subs := request(http.MethodGet, "", ""); code != http.StatusOK || len(subs) != 0 {
+		t.Errorf("GET /api/v1/subscriptions = %d, %v; want none", code, subs)
+	}
+	if code, _ := request(http.MethodPost, "", `{"list": "weekly", "time": "06:30"}`); code != http.StatusBadRequest {
+		t.Errorf("POST /api/v1/subscriptions to an unknown list = %d, want 400", code)
+	}
+	if code, _ := request(http.MethodPost, "", `{"list": "daily", "time": "6am"}`); code != http.StatusBadRequest {
+		t.Errorf("POST /api/v1/subscriptions with an invalid time = %d, want 400", code)
+	}
+	code, subs := request(http.MethodPost, "", `{"list": "daily", "time": "06:30", "timezone": "America/Chicago"}`)
+	if code != http.StatusAccepted || len(subs) != 1 || subs[0]["status"] != "pending" || subs[0]["timezone"] != "America/Chicago" {
+		t.Fatalf("POST /api/v1/subscriptions = %d, %v; want a pending subscription", code, subs)
+	}
+
+	confirmDailyEmail(t, srv, client, user.ID)
+	if code, subs := request(http.MethodGet, "", ""); code != http.StatusOK || len(subs) != 1 || subs[0]["status"] != "active" || subs[0]["time"] != "06:30" {
+		t.Errorf("GET /api/v1/subscriptions after confirming = %d, %v; want the active subscription", code, subs)
+	}
+	if code, subs := request(http.MethodPost, "", `{"list": "daily", "time": "07:00"}`); code != http.StatusOK || subs[0]["status"] != "active" {
+		t.Errorf("POST /api/v1/subscriptions to change the time = %d, %v; want it to stay active", code, subs)
+	}
+
+	// The link in each daily email unsubscribes without signing in.
+	subscribers, err := srv.Store.ListDailyEmailSubscribers(context.Background())
+	if err != nil || len(subscribers) != 1 || subscribers[0].SendTime != "07:00" {
+		t.Fatalf("ListDailyEmailSubscribers = %+v, %v; want the user at 07:00", subscribers, err)
+	}
+	anonymous := srv.NewClient(t)
+	resp, err := anonymous.PostForm(srv.URL+"/subscriptions/unsubscribe", url.Values{"token": {"bogus"}})
+	if err != nil {
+		t.Fatalf("POST /subscriptions/unsubscribe failed: %v", err)
+	}
+	if readBody(t, resp); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("POST /subscriptions/unsubscribe with an unknown token = %d, want 404", resp.StatusCode)
+	}
+	resp, err = anonymous.PostForm(srv.URL+"/subscriptions/unsubscribe", url.Values{"token": {subscribers[0].Token}})
+	if err != nil {
+		t.Fatalf("POST /subscriptions/unsubscribe failed: %v", err)
+	}
+	if body := readBody(t, resp); resp.StatusCode != http.StatusOK || !strings.Contains(body, "You are unsubscribed") {
+		t.Errorf("POST /subscriptions/unsubscribe = %d: %s", resp.StatusCode, body)
+	}
+	if subscribers, err := srv.Store.ListDailyEmailSubscribers(context.Background()); err != nil || len(subscribers) != 0 {
+		t.Errorf("ListDailyEmailSubscribers after unsubscribing = %+v, %v; want none", subscribers, err)
+	}
+
+	if code, subs := request(http.MethodDelete, "?list=daily", ""); code != http.StatusOK || subs[0]["status"] != "unsubscribed" {
+		t.Errorf("DELETE /api/v1/subscriptions = %d, %v; want unsubscribed", code, subs)
+	}
+	if code, _ := request(http.MethodDelete, "?list=weekly", ""); code != http.StatusBadRequest {
+		t.Errorf("DELETE /api/v1/subscriptions from an unknown list = %d, want 400", code)
+	}
+}
+
+func TestIntegration_SlowPassagesLoadAfterPage(t *testing.T) {
+	t.Cleanup(func() { _, _, _ = config.Reload() })
+	t.Setenv("PAGE_BUDGET", "200ms")
+	if _, _, err := config.Reload(); err != nil {
+		t.Fatalf("Reload failed: %v", err)
+	}
+
+	srv := testutil.NewServer(t)
+	today := time.Now().UTC().Format(time.DateOnly)
+	srv.SetDailyText(t, today, dailytexts.DailyText{Verses: []string{"Romans 8:1-2"}})
+	srv.ESV.AddPassage("Romans 8:1-2", romans8)
+	srv.ESV.Delay(time.Second)
+	client := srv.Login(t, "reader@example.com")
+
+	start := time.Now()
+	resp, err := client.Get(srv.URL + "/")
+	if err != nil {
+		t.Fatalf("GET / failed: %v", err)
+	}
+	body := readBody(t, resp)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("GET / = %d: %s", resp.StatusCode, body)
+	}
+	if elapsed := time.Since(start); elapsed >= time.Second {
+		t.Errorf("page took %v, want it sent before the ESV API responds", elapsed)
+	}
+	if !strings.Contains(body, `hx-get="/reading?date=`+today+`"`) || strings.Contains(body, "There is therefore now no condemnation") {
+		t.Fatalf("expected a slot for the passages that are still loading, got %s", body)
+	}
+
+	// The fetch carries on after the page is sent, so the slot soon loads the cached passages.
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		resp, err := client.Get(srv.URL + "/reading?date=" + today)
+		if err != nil {
+			t.Fatalf("GET /reading failed: %v", err)
+		}
+		body := readBody(t, resp)
+		if strings.Contains(body, "There is therefore now no condemnation") {
+			break
+		}
+		if !strings.Contains(body, "still loading") || time.Now().After(deadline) {
+			t.Fatalf("passages did not load: %s", body)
+		}
+	}
+	if queries := srv.ESV.Queries(); len(queries) != 1 {
+		t.Errorf("ESV queries = %q, want the slow fetch shared rather than repeated", queries)
+	}
+}
+
+func TestIntegration_FamilyDevotions(t *testing.T) {
+	srv := testutil.NewServer(t)
+	srv.SetDailyText(t, "2026-03-07", dailytexts.DailyText{
+		Verses:         []string{"Romans 8:1-2"},
+		DailyWatchWord: "For God so loved the world, that he gave his only Son. John 3:16",
+	})
+	client := srv.Login(t, "reader@example.com")
+
+	get := func() string {
+		t.Helper()
+		resp, err := client.Get(srv.URL + "/devotions?date=2026-03-07")
+		if err != nil {
+			t.Fatalf("GET /devotions failed: %v", err)
+		}
+		body := readBody(t, resp)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("GET /devotions = %d: %s", resp.StatusCode, body)
+		}
+		return body
+	}
+	post := func(audience string) int {
+		t.Helper()
+		resp, err := client.PostForm(srv.URL+"/devotions", url.Values{"audience": {audience}, "date": {"2026-03-07"}})
+		if err != nil {
+			t.Fatalf("POST /devotions failed: %v", err)
+		}
+		readBody(t, resp)
+		return resp.StatusCode
+	}
+
+	if body := get(); !strings.Contains(body, "Discuss the watchword") || strings.Contains(body, "<ol") {
+		t.Errorf("expected an invitation to family devotions, got %s", body)
+	}
+
+	if status := post("family"); status != http.StatusOK {
+		t.Fatalf("POST /devotions = %d", status)
+	}
+	body := get()
+	if !strings.Contains(body, "The Gospels") || !strings.Contains(body, "devotions-questions") || strings.Contains(body, "devotions-kids") {
+		t.Errorf("expected gospel questions for the family, got %s", body)
+	}
+
+	if status := post("kids"); status != http.StatusOK {
+		t.Fatalf("POST /devotions = %d", status)
+	}
+	if body := get(); !strings.Contains(body, "devotions-kids") {
+		t.Errorf("expected the kid-friendly layout, got %s", body)
+	}
+
+	if status := post("adults"); status != http.StatusBadRequest {
+		t.Errorf("POST /devotions with an unknown audience = %d, want %d", status, http.StatusBadRequest)
+	}
+
+	if status := post(""); status != http.StatusOK {
+		t.Fatalf("POST /devotions = %d", status)
+	}
+	if body := get(); !strings.Contains(body, "Discuss the watchword") {
+		t.Errorf("expected family devotions to be off, got %s", body)
+	}
+}
+
+func TestIntegration_MailgunWebhookSuppressesAddress(t *testing.T) {
+	const key = "webhook-signing-key"
+	t.Setenv("MAILGUN_WEBHOOK_SIGNING_KEY", key)
+	t.Setenv("ADMIN_EMAIL", "admin@example.com")
+	srv := testutil.NewServer(t)
+
+	webhook := func(key string) []byte {
+		ts := strconv.FormatInt(time.Now().Unix(), 10)
+		mac := hmac.New(sha256.New, []byte(key))
+		mac.Write([]byte(ts + "token"))
+		return fmt.Appendf(nil, `{"signature": {"timestamp": %q, "token": "token", "signature": %q}, `+
+			`"event-data": {"event": "failed", "severity": "permanent", "recipient": "gone@example.com"}}`,
+			ts, hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	// Mailgun sends no CSRF token, so the webhook is posted with a plain client.
+	resp, err := http.Post(srv.URL+"/webhooks/mailgun", "application/json", bytes.NewReader(webhook("forged")))
+	if err != nil {
+		t.Fatalf("POST /webhooks/mailgun failed: %v", err)
+	}
+	if body := readBody(t, resp); resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("forged webhook = %d, want 401: %s", resp.StatusCode, body)
+	}
+
+	resp, err = http.Post(srv.URL+"/webhooks/mailgun", "application/json", bytes.NewReader(webhook(key)))
+	if err != nil {
+		t.Fatalf("POST /webhooks/mailgun failed: %v", err)
+	}
+	if body := readBody(t, resp); resp.StatusCode != http.StatusOK {
+		t.Fatalf("webhook = %d: %s", resp.StatusCode, body)
+	}
+	if suppressed, err := srv.Store.IsEmailSuppressed(context.Background(), "gone@example.com"); err != nil || !suppressed {
+		t.Fatalf("IsEmailSuppressed = %v, %v; want the bounced address suppressed", suppressed, err)
+	}
+
+	admin := srv.Login(t, "admin@example.com")
+	resp, err = admin.Get(srv.URL + "/admin/emails")
+	if err != nil {
+		t.Fatalf("GET /admin/emails failed: %v", err)
+	}
+	if body := readBody(t, resp); resp.StatusCode != http.StatusOK || !strings.Contains(body, "gone@example.com") {
+		t.Fatalf("GET /admin/emails = %d, want the suppressed address listed: %s", resp.StatusCode, body)
+	}
+
+	resp, err = admin.PostForm(srv.URL+"/admin/emails", url.Values{"address": {"gone@example.com"}})
+	if err != nil {
+		t.Fatalf("POST /admin/emails failed: %v", err)
+	}
+	if body := readBody(t, resp); resp.StatusCode >= http.StatusBadRequest {
+		t.Fatalf("POST /admin/emails = %d: %s", resp.StatusCode, body)
+	}
+	if suppressed, _ := srv.Store.IsEmailSuppressed(context.Background(), "gone@example.com"); suppressed {
+		t.Error("expected the address to be allowed again after removing it")
+	}
+}
+
+func TestIntegration_AdminDailyPosts(t *testing.T) {
+	t.Setenv("ADMIN_EMAIL", "admin@example.com")
+	srv := testutil.NewServer(t)
+	admin := srv.Login(t, "admin@example.com")
+
+	channel := url.Values{
+		"action":      {"create"},
+		"name":        {"#devotions"},
+		"kind":        {"slack"},
+		"webhook_url": {"https://hooks.slack.com/services/T0/B0/secret"},
+		"post_time":   {"06:30"},
+		"timezone":    {"America/Chicago"},
+	}
+	resp, err := admin.PostForm(srv.URL+"/admin/posts", channel)
+	if err != nil {
+		t.Fatalf("POST /admin/posts failed: %v", err)
+	}
+	body := readBody(t, resp)
+	if resp.StatusCode != http.StatusOK || !strings.Contains(body, "06:30 America/Chicago") {
+		t.Fatalf("POST /admin/posts = %d, want the channel listed: %s", resp.StatusCode, body)
+	}
+
+	channel.Set("template", "{{.Psalm}}")
+	resp, err = admin.PostForm(srv.URL+"/admin/posts", channel)
+	if err != nil {
+		t.Fatalf("POST /admin/posts failed: %v", err)
+	}
+	if body := readBody(t, resp); !strings.Contains(body, "Could not add the channel") {
+		t.Errorf("expected an invalid template to be rejected: %s", body)
+	}
+
+	channels, err := srv.Store.ListDailyPostChannels(context.Background())
+	if err != nil || len(channels) != 1 {
+		t.Fatalf("ListDailyPostChannels = %v, %v; want one channel", channels, err)
+	}
+
+	reader := srv.Login(t, "reader@example.com")
+	resp, err = reader.Get(srv.URL + "/admin/posts")
+	if err != nil {
+		t.Fatalf("GET /admin/posts failed: %v", err)
+	}
+	if readBody(t, resp); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("GET /admin/posts as a reader = %d, want 404", resp.StatusCode)
+	}
+}
+
+func TestIntegration_StorageQuota(t *testing.T) {
+	t.Setenv("ADMIN_EMAIL", "admin@example.com")
+	srv := testutil.NewServer(t)
+	admin := srv.Login(t, "admin@example.com")
+	reader := srv.Login(t, "reader@example.com")
+	ctx := context.Background()
+	user, err := srv.Store.GetUserByEmail(ctx, "reader@example.com")
+	if err != nil {
+		t.Fatalf("GetUserByEmail failed: %v", err)
+	}
+	save := func(observation string) (int, string) {
+		t.Helper()
+		payload, err := json.Marshal(map[string]any{"date": "2026-03-01", "sections": map[string]string{"observation": observation}})
+		if err != nil {
+			t.Fatalf("encoding entry: %v", err)
+		}
+		resp, err := reader.Post(srv.URL+"/soap", "application/json", bytes.NewReader(payload))
+		if err != nil {
+			t.Fatalf("POST /soap failed: %v", err)
+		}
+		return resp.StatusCode, readBody(t, resp)
+	}
+
+	if code, body := save("In the beginning was the Word."); code != http.StatusOK {
+		t.Fatalf("POST /soap without a quota = %d: %s", code, body)
+	}
+	if err := srv.Store.SetStorageQuota(ctx, user.ID, 40); err != nil {
+		t.Fatalf("SetStorageQuota failed: %v", err)
+	}
+	if code, body := save("In the beginning was the Word, and the Word was with God."); code != http.StatusInsufficientStorage || !strings.Contains(body, "overQuota") {
+		t.Errorf("POST /soap over the quota = %d, want 507 saying why: %s", code, body)
+	}
+	if code, body := save("The Word."); code != http.StatusOK {
+		t.Errorf("POST /soap shortening the entry over the quota = %d, want it saved: %s", code, body)
+	}
+
+	resp, err := admin.Get(srv.URL + "/admin/storage")
+	if err != nil {
+		t.Fatalf("GET /admin/storage failed: %v", err)
+	}
+	if body := readBody(t, resp); resp.StatusCode != http.StatusOK || !strings.Contains(body, "reader@example.com") || !strings.Contains(body, "27%") {
+		t.Errorf("GET /admin/storage = %d, want the reader at 27%% of their quota: %s", resp.StatusCode, body)
+	}
+	resp, err = admin.PostForm(srv.URL+"/admin/storage", url.Values{"action": {"quota"}, "id": {strconv.FormatInt(user.ID, 10)}, "quota": {"lots"}})
+	if err != nil {
+		t.Fatalf("POST /admin/storage failed: %v", err)
+	}
+	if body := readBody(t, resp); !strings.Contains(body, "whole number of megabytes") {
+		t.Errorf("POST /admin/storage with an invalid quota = %d, want it rejected: %s", resp.StatusCode, body)
+	}
+	resp, err = admin.PostForm(srv.URL+"/admin/storage", url.Values{"action": {"quota"}, "id": {strconv.FormatInt(user.ID, 10)}, "quota": {""}})
+	if err != nil {
+		t.Fatalf("POST /admin/storage failed: %v", err)
+	}
+	if body := readBody(t, resp); resp.StatusCode != http.StatusSeeOther {
+		t.Fatalf("POST /admin/storage = %d, want 303: %s", resp.StatusCode, body)
+	}
+	if code, body := save("In the beginning was the Word, and the Word was with God."); code != http.StatusOK {
+		t.Errorf("POST /soap once the quota was removed = %d: %s", code, body)
+	}
+
+	resp, err = reader.Get(srv.URL + "/admin/storage")
+	if err != nil {
+		t.Fatalf("GET /admin/storage failed: %v", err)
+	}
+	if readBody(t, resp); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("GET /admin/storage as a reader = %d, want 404", resp.StatusCode)
+	}
+}
+
+func TestIntegration_AdminTheme(t *testing.T) {
+	t.Setenv("ADMIN_EMAIL", "admin@example.com")
+	srv := testutil.NewServer(t)
+	admin := srv.Login(t, "admin@example.com")
+	reader := srv.Login(t, "reader@example.com")
+
+	resp, err := admin.PostForm(srv.URL+"/admin/theme/preview", url.Values{"theme": {"evergreen"}, "custom_css": {"h1 { color: tomato; }"}})
+	if err != nil {
+		t.Fatalf("POST /admin/theme/preview failed: %v", err)
+	}
+	if body := readBody(t, resp); !strings.Contains(body, `<style id="theme-css">/* Evergreen`) || !strings.Contains(body, "tomato") {
+		t.Errorf("expected the preview stylesheet: %s", body)
+	}
+	resp, err = reader.Get(srv.URL + "/")
+	if err != nil {
+		t.Fatalf("GET / failed: %v", err)
+	}
+	if body := readBody(t, resp); strings.Contains(body, "tomato") {
+		t.Error("a preview changed the theme")
+	}
+
+	resp, err = admin.PostForm(srv.URL+"/admin/theme", url.Values{"theme": {"evergreen"}, "custom_css": {"h1 { color: tomato; }"}})
+	if err != nil {
+		t.Fatalf("POST /admin/theme failed: %v", err)
+	}
+	if body := readBody(t, resp); resp.StatusCode != http.StatusOK || !strings.Contains(body, "The theme has been saved") {
+		t.Fatalf("POST /admin/theme = %d: %s", resp.StatusCode, body)
+	}
+	resp, err = reader.Get(srv.URL + "/")
+	if err != nil {
+		t.Fatalf("GET / failed: %v", err)
+	}
+	if body := readBody(t, resp); !strings.Contains(body, "/* Evergreen") || !strings.Contains(body, "h1 { color: tomato; }") {
+		t.Errorf("expected the saved theme on every page: %s", body)
+	}
+
+	resp, err = admin.PostForm(srv.URL+"/admin/theme", url.Values{"theme": {"sunrise"}, "custom_css": {"</style><script>alert(1)</script>"}})
+	if err != nil {
+		t.Fatalf("POST /admin/theme failed: %v", err)
+	}
+	if body := readBody(t, resp); !strings.Contains(body, "Could not save the theme") {
+		t.Errorf("expected CSS that closes the style element to be rejected: %s", body)
+	}
+	settings, err := srv.Store.GetInstanceSettings(context.Background())
+	if err != nil || settings["theme"] != "evergreen" {
+		t.Errorf("GetInstanceSettings = %v, %v; want the evergreen theme kept", settings, err)
+	}
+
+	resp, err = reader.Get(srv.URL + "/admin/theme")
+	if err != nil {
+		t.Fatalf("GET /admin/theme failed: %v", err)
+	}
+	if readBody(t, resp); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("GET /admin/theme as a reader = %d, want 404", resp.StatusCode)
+	}
+}
+
+func TestIntegration_MaintenanceMode(t *testing.T) {
+	t.Setenv("ADMIN_EMAIL", "admin@example.com")
+	srv := testutil.NewServer(t)
+	t.Cleanup(maintenance.Disable)
+	admin := srv.Login(t, "admin@example.com")
+	reader := srv.Login(t, "reader@example.com")
+
+	setMaintenance := func(enabled string) string {
+		t.Helper()
+		resp, err := admin.PostForm(srv.URL+"/admin/maintenance", url.Values{"enabled": {enabled}})
+		if err != nil {
+			t.Fatalf("POST /admin/maintenance failed: %v", err)
+		}
+		body := readBody(t, resp)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("POST /admin/maintenance = %d: %s", resp.StatusCode, body)
+		}
+		return body
+	}
+	get := func(client *http.Client, path string) int {
+		t.Helper()
+		resp, err := client.Get(srv.URL + path)
+		if err != nil {
+			t.Fatalf("GET %s failed: %v", path, err)
+		}
+		readBody(t, resp)
+		return resp.StatusCode
+	}
+
+	if body := setMaintenance("true"); !strings.Contains(body, "no background jobs are running") {
+		t.Errorf("turning on maintenance mode did not report the jobs drained:\n%s", body)
+	}
+	if _, ok := maintenance.Begin(); ok {
+		t.Error("a background job started in maintenance mode")
+	}
+	for path, want := range map[string]int{"/": http.StatusServiceUnavailable, "/stats": http.StatusServiceUnavailable, "/healthz": http.StatusOK, "/web/style.css": http.StatusOK} {
+		if got := get(reader, path); got != want {
+			t.Errorf("GET %s in maintenance mode = %d, want %d", path, got, want)
+		}
+	}
+	if got := get(admin, "/admin/maintenance"); got != http.StatusOK {
+		t.Errorf("GET /admin/maintenance in maintenance mode = %d, want 200", got)
+	}
+
+	setMaintenance("false")
+	if got := get(reader, "/stats"); got != http.StatusOK {
+		t.Errorf("GET /stats after maintenance = %d, want 200", got)
+	}
+}
+
+func TestIntegration_Features(t *testing.T) {
+	t.Setenv("ADMIN_EMAIL", "admin@example.com")
+	t.Cleanup(func() { _, _, _ = config.Reload() })
+	t.Setenv("FEATURES", "stats=off,psalter=optin")
+	if _, _, err := config.Reload(); err != nil {
+		t.Fatalf("Reload failed: %v", err)
+	}
+	srv := testutil.NewServer(t)
+	srv.SetDailyText(t, "2026-03-07", dailytexts.DailyText{Verses: []string{"Romans 8:1-2"}})
+	srv.ESV.AddPassage("Romans 8:1-2", romans8)
+	admin := srv.Login(t, "admin@example.com")
+	reader := srv.Login(t, "reader@example.com")
+
+	get := func(client *http.Client, path string) (int, string) {
+		t.Helper()
+		resp, err := client.Get(srv.URL + path)
+		if err != nil {
+			t.Fatalf("GET %s failed: %v", path, err)
+		}
+		body := readBody(t, resp)
+		return resp.StatusCode, body
+	}
+	override := func(values url.Values) string {
+		t.Helper()
+		resp, err := admin.PostForm(srv.URL+"/admin/features", values)
+		if err != nil {
+			t.Fatalf("POST /admin/features failed: %v", err)
+		}
+		body := readBody(t, resp)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("POST /admin/features = %d: %s", resp.StatusCode, body)
+		}
+		return body
+	}
+
+	for path, want := range map[string]int{"/stats": http.StatusNotFound, "/psalter?date=2026-03-07": http.StatusNotFound, "/concordance": http.StatusOK} {
+		if got, _ := get(reader, path); got != want {
+			t.Errorf("GET %s = %d, want %d", path, got, want)
+		}
+	}
+	if _, body := get(reader, "/reading?date=2026-03-07"); strings.Contains(body, `id="psalter"`) || !strings.Contains(body, `id="devotions"`) {
+		t.Errorf("reading shows the psalter only when it is available:\n%s", body)
+	}
+	if _, body := get(reader, "/concordance"); strings.Contains(body, `href="/stats"`) || !strings.Contains(body, `href="/concordance"`) {
+		t.Errorf("header links to stats while it is off:\n%s", body)
+	}
+
+	override(url.Values{"action": {"save"}, "email": {"reader@example.com"}, "feature": {"psalter"}, "enabled": {"true"}})
+	override(url.Values{"action": {"save"}, "email": {"reader@example.com"}, "feature": {"stats"}, "enabled": {"true"}})
+	body := override(url.Values{"action": {"save"}, "email": {"reader@example.com"}, "feature": {"api"}, "enabled": {"false"}})
+	if !strings.Contains(body, "<td>reader@example.com</td>") {
+		t.Errorf("admin page missing the reader's overrides:\n%s", body)
+	}
+	if body := override(url.Values{"action": {"save"}, "email": {"nobody@example.com"}, "feature": {"api"}, "enabled": {"true"}}); !strings.Contains(body, "no user with the email") {
+		t.Errorf("overriding a feature for an unknown user did not fail:\n%s", body)
+	}
+	for path, want := range map[string]int{"/psalter?date=2026-03-07": http.StatusOK, "/stats": http.StatusNotFound, "/settings/api": http.StatusNotFound, "/api/v1/week": http.StatusNotFound} {
+		if got, _ := get(reader, path); got != want {
+			t.Errorf("GET %s with overrides = %d, want %d", path, got, want)
+		}
+	}
+	if got, _ := get(admin, "/settings/api"); got != http.StatusOK {
+		t.Errorf("GET /settings/api as the admin = %d, want 200", got)
+	}
+
+	readerUser, err := srv.Store.GetUserByEmail(context.Background(), "reader@example.com")
+	if err != nil {
+		t.Fatalf("GetUserByEmail failed: %v", err)
+	}
+	override(url.Values{"action": {"delete"}, "user_id": {strconv.FormatInt(readerUser.ID, 10)}, "feature": {"psalter"}})
+	if got, _ := get(reader, "/psalter?date=2026-03-07"); got != http.StatusNotFound {
+		t.Errorf("GET /psalter after removing the override = %d, want 404", got)
+	}
+	if got, _ := get(reader, "/admin/features"); got != http.StatusNotFound {
+		t.Errorf("GET /admin/features as a reader = %d, want 404", got)
+	}
+}
+
+func TestIntegration_CustomFooter(t *testing.T) {
+	partials := fstest.MapFS{
+		"footer.gotmpl": {Data: []byte(`<footer class="site-footer">{{congregation}} &middot; {{.user.Email}}</footer>`)},
+	}
+	srv := testutil.NewServer(t,
+		server.WithTemplateFuncs(template.FuncMap{"congregation": func() string { return "Home Moravian Church" }}),
+		server.WithPartials(partials, "*.gotmpl"),
+	)
+	client := srv.Login(t, "reader@example.com")
+
+	resp, err := client.Get(srv.URL + "/stats")
+	if err != nil {
+		t.Fatalf("GET /stats failed: %v", err)
+	}
+	body := readBody(t, resp)
+	if !strings.Contains(body, "Home Moravian Church &middot; reader@example.com") {
+		t.Errorf("expected the custom footer: %s", body)
+	}
+	if strings.Contains(body, "info@mysoaps.net") {
+		t.Error("the embedded footer was not replaced")
+	}
+}
+
+func TestIntegration_VerseNotes(t *testing.T) {
+	srv := testutil.NewServer(t)
+	srv.SetDailyText(t, "2026-03-07", dailytexts.DailyText{Verses: []string{"Romans 8:1-2"}})
+	srv.ESV.AddPassage("Romans 8:1-2", romans8)
+	client := srv.Login(t, "reader@example.com")
+
+	resp, err := client.PostForm(srv.URL+"/notes/verse", url.Values{"ref": {"45008002"}, "note": {"  Set free!  "}})
+	if err != nil {
+		t.Fatalf("POST /notes/verse failed: %v", err)
+	}
+	body := readBody(t, resp)
+	if resp.StatusCode != http.StatusOK || !strings.Contains(body, "Romans 8:2") || !strings.Contains(body, ">Set free!</textarea>") {
+		t.Fatalf("POST /notes/verse = %d: %s", resp.StatusCode, body)
+	}
+	if trigger := resp.Header.Get("HX-Trigger"); !strings.Contains(trigger, `"hasNote":true`) {
+		t.Errorf("HX-Trigger = %q, want a verseNoteSaved event", trigger)
+	}
+
+	resp, err = client.Get(srv.URL + "/reading?date=2026-03-07")
+	if err != nil {
+		t.Fatalf("GET /reading failed: %v", err)
+	}
+	body = readBody(t, resp)
+	if n := strings.Count(body, `class="verse-note-marker"`); n != 1 || !strings.Contains(body, `data-note-ref="45008002"`) {
+		t.Errorf("expected one note marker on Romans 8:2, got %d in %s", n, body)
+	}
+
+	// The journal entry for the day is untouched by the note.
+	resp, err = client.Get(srv.URL + "/soap?date=2026-03-07")
+	if err != nil {
+		t.Fatalf("GET /soap failed: %v", err)
+	}
+	if body := readBody(t, resp); strings.Contains(body, "Set free") {
+		t.Errorf("verse note leaked into the journal entry: %s", body)
+	}
+
+	resp, err = client.PostForm(srv.URL+"/notes/verse", url.Values{"ref": {"45008002"}, "note": {""}})
+	if err != nil {
+		t.Fatalf("POST /notes/verse failed: %v", err)
+	}
+	if body := readBody(t, resp); !strings.Contains(body, "Note removed.") {
+		t.Errorf("expected the note to be removed: %s", body)
+	}
+	resp, err = client.Get(srv.URL + "/reading?date=2026-03-07")
+	if err != nil {
+		t.Fatalf("GET /reading failed: %v", err)
+	}
+	if body := readBody(t, resp); strings.Contains(body, "verse-note-marker") {
+		t.Errorf("expected no note markers after removing the note: %s", body)
+	}
+
+	resp, err = client.Get(srv.URL + "/notes/verse?ref=Romans+8:2")
+	if err != nil {
+		t.Fatalf("GET /notes/verse failed: %v", err)
+	}
+	if readBody(t, resp); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("GET /notes/verse with an invalid ref = %d, want 400", resp.StatusCode)
+	}
+}
+
+func TestIntegration_ImportESVHighlights(t *testing.T) {
+	srv := testutil.NewServer(t)
+	srv.SetDailyText(t, "2026-03-07", dailytexts.DailyText{Verses: []string{"Romans 8:1-2"}})
+	srv.ESV.AddPassage("Romans 8:1-2", romans8)
+	client := srv.Login(t, "reader@example.com")
+
+	importHighlights := func(export string) string {
+		t.Helper()
+		var buf bytes.Buffer
+		form := multipart.NewWriter(&buf)
+		part, err := form.CreateFormFile("highlights", "esv-highlights.csv")
+		if err != nil {
+			t.Fatalf("creating form file: %v", err)
+		}
+		if _, err := io.WriteString(part, export); err != nil {
+			t.Fatalf("writing form file: %v", err)
+		}
+		if err := form.Close(); err != nil {
+			t.Fatalf("closing form: %v", err)
+		}
+		resp, err := client.Post(srv.URL+"/settings/backup/highlights", form.FormDataContentType(), &buf)
+		if err != nil {
+			t.Fatalf("POST /settings/backup/highlights failed: %v", err)
+		}
+		body := readBody(t, resp)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("POST /settings/backup/highlights = %d: %s", resp.StatusCode, body)
+		}
+		return body
+	}
+
+	const export = "Reference,Note,Created\n" +
+		"Romans 8:1–2,No condemnation,2025-12-24\n" +
+		"Psalm 23,,2025-12-25\n"
+	body := importHighlights(export)
+	if !strings.Contains(body, "Highlighted 2 verses from ESV.org.") || !strings.Contains(body, "Added 1 note.") ||
+		!strings.Contains(body, "Row 2: &#34;Psalm 23&#34; refers to a whole chapter") {
+		t.Fatalf("import summary is wrong: %s", body)
+	}
+
+	resp, err := client.Get(srv.URL + "/reading?date=2026-03-07")
+	if err != nil {
+		t.Fatalf("GET /reading failed: %v", err)
+	}
+	body = readBody(t, resp)
+	if n := strings.Count(body, `class="highlight-marker"`); n != 2 || !strings.Contains(body, "Romans 8:1 highlighted on ESV.org") {
+		t.Errorf("expected both verses marked as highlighted on ESV.org, got %d markers in %s", n, body)
+	}
+	if !strings.Contains(body, `data-note-ref="45008001"`) {
+		t.Errorf("expected the highlight's note on Romans 8:1: %s", body)
+	}
+
+	// Importing the same export again adds nothing.
+	if body := importHighlights(export); !strings.Contains(body, "Highlighted 0 verses from ESV.org, skipping 2 already highlighted") || strings.Contains(body, "Added 1 note") {
+		t.Errorf("reimport summary is wrong: %s", body)
+	}
+	if body := importHighlights("Date,Note\n"); !strings.Contains(body, "This file is not an export of ESV.org highlights") {
+		t.Errorf("importing a file without references did not fail: %s", body)
+	}
+}
+
+func TestIntegration_NewYearRollover(t *testing.T) {
+	srv := testutil.NewServer(t)
+	srv.SetDailyText(t, "2026-12-31", dailytexts.DailyText{Verses: []string{"Romans 8:1-2"}})
+	srv.SetDailyText(t, "2027-01-01", dailytexts.DailyText{Verses: []string{"Romans 8:1-2"}})
+	srv.ESV.AddPassage("Romans 8:1-2", romans8)
+	client := srv.Login(t, "reader@example.com")
+
+	today := func() string {
+		t.Helper()
+		resp, err := client.Get(srv.URL + "/")
+		if err != nil {
+			t.Fatalf("GET / failed: %v", err)
+		}
+		body := readBody(t, resp)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("GET / = %d: %s", resp.StatusCode, body)
+		}
+		_, rest, _ := strings.Cut(body, "<h2>")
+		date, _, _ := strings.Cut(rest, "</h2>")
+		return date
+	}
+
+	srv.Clock.Set(time.Date(2026, time.December, 31, 23, 59, 30, 0, time.UTC))
+	if got := today(); got != "2026-12-31" {
+		t.Errorf("before midnight the reading is for %s, want 2026-12-31", got)
+	}
+	srv.Clock.Advance(time.Minute)
+	if got := today(); got != "2027-01-01" {
+		t.Errorf("after midnight the reading is for %s, want 2027-01-01", got)
+	}
+}
+
+func TestIntegration_DayNavigation(t *testing.T) {
+	srv := testutil.NewServer(t)
+	for _, date := range []string{"2026-03-04", "2026-03-07", "2026-03-08"} {
+		srv.SetDailyText(t, date, dailytexts.DailyText{Verses: []string{"Romans 8:1-2"}})
+	}
+	srv.ESV.AddPassage("Romans 8:1-2", romans8)
+	client := srv.Login(t, "reader@example.com")
+
+	resp, err := client.Get(srv.URL + "/reading?date=2026-03-07")
+	if err != nil {
+		t.Fatalf("GET /reading failed: %v", err)
+	}
+	body := readBody(t, resp)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("GET /reading = %d: %s", resp.StatusCode, body)
+	}
+	// Dates without a text are skipped.
+	for _, want := range []string{`href="/?date=2026-03-04"`, `href="/?date=2026-03-08"`} {
+		if !strings.Contains(body, want) {
+			t.Errorf("reading missing %q", want)
+		}
+	}
+
+	resp, err = client.Get(srv.URL + "/reading?date=2026-03-08")
+	if err != nil {
+		t.Fatalf("GET /reading failed: %v", err)
+	}
+	body = readBody(t, resp)
+	if strings.Contains(body, `aria-label="Next day"`) {
+		t.Error("reading for the last date links to a next day")
+	}
+}
+
+func TestIntegration_EditConflictMerge(t *testing.T) {
+	srv := testutil.NewServer(t)
+	client := srv.Login(t, "reader@example.com")
+
+	save := func(payload string) (int, string) {
+		t.Helper()
+		resp, err := client.Post(srv.URL+"/soap", "application/json", strings.NewReader(payload))
+		if err != nil {
+			t.Fatalf("POST /soap failed: %v", err)
+		}
+		return resp.StatusCode, readBody(t, resp)
+	}
+
+	status, body := save(`{"date":"2026-03-07","sections":{"observation":"First draft."},"selectedVerses":["45008001"],"baseRevision":0}`)
+	if status != http.StatusOK || !strings.Contains(body, `"revision":1`) {
+		t.Fatalf("POST /soap = %d: %s", status, body)
+	}
+	// Another tab saves without knowing of the first draft.
+	status, body = save(`{"date":"2026-03-07","sections":{"observation":"Other tab.","prayer":"Amen."},"selectedVerses":[],"baseRevision":0}`)
+	if status != http.StatusConflict {
+		t.Fatalf("conflicting POST /soap = %d, want %d: %s", status, http.StatusConflict, body)
+	}
+	for _, want := range []string{
+		`name="base_revision" value="0"`,
+		`name="theirs_revision" value="1"`,
+		"Other tab.",
+		"First draft.",
+		`name="section-prayer" rows="6">Amen.</textarea>`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("merge partial missing %q: %s", want, body)
+		}
+	}
+
+	resp, err := client.PostForm(srv.URL+"/soap/merge", url.Values{
+		"date":                {"2026-03-07"},
+		"base_revision":       {"0"},
+		"theirs_revision":     {"1"},
+		"selected_verses":     {`["45008001"]`},
+		"section-observation": {"First draft. Other tab."},
+		"section-prayer":      {"Amen."},
+	})
+	if err != nil {
+		t.Fatalf("POST /soap/merge failed: %v", err)
+	}
+	if body := readBody(t, resp); resp.StatusCode != http.StatusOK || resp.Header.Get("HX-Trigger") != "entryMerged" {
+		t.Fatalf("POST /soap/merge = %d with HX-Trigger %q: %s", resp.StatusCode, resp.Header.Get("HX-Trigger"), body)
+	}
+
+	resp, err = client.Get(srv.URL + "/soap?date=2026-03-07")
+	if err != nil {
+		t.Fatalf("GET /soap failed: %v", err)
+	}
+	body = readBody(t, resp)
+	if !strings.Contains(body, "First draft. Other tab.") || !strings.Contains(body, `"revision":2`) {
+		t.Errorf("merged entry = %s", body)
+	}
+}
+
+func TestIntegration_SectionSaveStatus(t *testing.T) {
+	srv := testutil.NewServer(t)
+	client := srv.Login(t, "reader@example.com")
+
+	for i, payload := range []string{
+		`{"date":"2026-03-07","sections":{"observation":"First draft."},"selectedVerses":[],"baseRevision":0}`,
+		`{"date":"2026-03-07","sections":{"observation":"First draft.","prayer":"Amen."},"selectedVerses":[],"baseRevision":1}`,
+	} {
+		resp, err := client.Post(srv.URL+"/soap", "application/json", strings.NewReader(payload))
+		if err != nil {
+			t.Fatalf("POST /soap failed: %v", err)
+		}
+		if body := readBody(t, resp); resp.StatusCode != http.StatusOK {
+			t.Fatalf("save %d: POST /soap = %d: %s", i+1, resp.StatusCode, body)
+		}
+	}
+
+	// An editor at the first revision has the observation but not the prayer saved since.
+	resp, err := client.Get(srv.URL + "/soap/status?date=2026-03-07&revision=1")
+	if err != nil {
+		t.Fatalf("GET /soap/status failed: %v", err)
+	}
+	var status struct {
+		Date     string
+		Revision int64
+		Sections []struct {
+			Section, Name, State string
+			Revision             int64
+			SavedAt              *time.Time
+		}
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&status); err != nil {
+		t.Fatalf("decoding save status: %v", err)
+	}
+	resp.Body.Close()
+	if status.Date != "2026-03-07" || status.Revision != 2 {
+		t.Errorf("status is of %s at revision %d, want 2026-03-07 at 2", status.Date, status.Revision)
+	}
+	want := map[string]struct {
+		state    string
+		revision int64
+	}{
+		"observation": {"saved", 1},
+		"application": {"empty", 0},
+		"prayer":      {"changed", 2},
+	}
+	for _, sec := range status.Sections {
+		w, ok := want[sec.Section]
+		if !ok {
+			continue
+		}
+		if sec.State != w.state || sec.Revision != w.revision {
+			t.Errorf("%s is %s at revision %d, want %s at %d", sec.Section, sec.State, sec.Revision, w.state, w.revision)
+		}
+		if (sec.SavedAt != nil) != (w.state != "empty") {
+			t.Errorf("%s saved at %v, want a time only if saved", sec.Section, sec.SavedAt)
+		}
+		if sec.Name == "" {
+			t.Errorf("%s has no name", sec.Section)
+		}
+		delete(want, sec.Section)
+	}
+	if len(want) > 0 {
+		t.Errorf("sections missing from the status: %v", want)
+	}
+
+	resp, err = client.Get(srv.URL + "/soap/status?date=2026-03-07&revision=x")
+	if err != nil {
+		t.Fatalf("GET /soap/status failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("GET /soap/status with a bad revision = %d, want %d", resp.StatusCode, http.StatusBadRequest)
+	}
+}
+
+func TestIntegration_WeekPreferences(t *testing.T) {
+	srv := testutil.NewServer(t)
+	client := srv.Login(t, "reader@example.com")
+
+	reading := func() string {
+		t.Helper()
+		resp, err := client.Get(srv.URL + "/reading?date=2026-03-08")
+		if err != nil {
+			t.Fatalf("GET /reading failed: %v", err)
+		}
+		return readBody(t, resp)
+	}
+
+	// March 8, 2026 is a Sunday, which starts the eleventh week of the year counted from Sunday but
+	// ends the tenth ISO week.
+	if body := reading(); !strings.Contains(body, "Watchword for week 11</span> O come, let us worship") {
+		t.Errorf("reading with weeks from Sunday lacks the watchword for week 11: %s", body)
+	}
+
+	resp, err := client.PostForm(srv.URL+"/settings/reading", url.Values{"form": {"weeks"}, "week_start": {"monday"}, "iso_weeks": {"on"}})
+	if err != nil {
+		t.Fatalf("POST /settings/reading failed: %v", err)
+	}
+	if body := readBody(t, resp); resp.StatusCode != http.StatusOK || !strings.Contains(body, `<option value="monday" selected>`) ||
+		!strings.Contains(body, `name="iso_weeks" checked`) {
+		t.Fatalf("POST /settings/reading = %d: %s", resp.StatusCode, body)
+	}
+	prefs, err := srv.Store.GetPreferences(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("GetPreferences failed: %v", err)
+	}
+	if prefs.WeekStart != time.Monday || !prefs.ISOWeeks || prefs.Translation != "ESV" {
+		t.Errorf("preferences = %+v, want ISO weeks from Monday and the rest unchanged", prefs)
+	}
+	if body := reading(); !strings.Contains(body, "Watchword for week 10</span>") {
+		t.Errorf("reading with ISO weeks lacks the watchword for week 10: %s", body)
+	}
+
+	resp, err = client.PostForm(srv.URL+"/settings/reading", url.Values{"form": {"weeks"}, "week_start": {"friday"}})
+	if err != nil {
+		t.Fatalf("POST /settings/reading failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("weeks starting on Friday = %d, want %d", resp.StatusCode, http.StatusBadRequest)
+	}
+}
+
+func TestIntegration_JournalHistory(t *testing.T) {
+	srv := testutil.NewServer(t)
+	srv.SetDailyText(t, "2026-01-01", dailytexts.DailyText{Verses: []string{"Psalm 34:1-7", "Romans 8:1-2"}})
+	client := srv.Login(t, "reader@example.com")
+
+	for day := 1; day <= 12; day++ {
+		payload := fmt.Sprintf(`{"date":"2026-01-%02d","sections":{"observation":"Day %d."},"selectedVerses":[]}`, day, day)
+		resp, err := client.Post(srv.URL+"/soap", "application/json", strings.NewReader(payload))
+		if err != nil {
+			t.Fatalf("POST /soap failed: %v", err)
+		}
+		if body := readBody(t, resp); resp.StatusCode != http.StatusOK {
+			t.Fatalf("POST /soap = %d: %s", resp.StatusCode, body)
+		}
+	}
+
+	get := func(path string) string {
+		t.Helper()
+		resp, err := client.Get(srv.URL + path)
+		if err != nil {
+			t.Fatalf("GET %s failed: %v", path, err)
+		}
+		body := readBody(t, resp)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("GET %s = %d: %s", path, resp.StatusCode, body)
+		}
+		return body
+	}
+
+	body := get("/journal")
+	if !strings.Contains(body, "Day 12.") || strings.Contains(body, "Day 2.") || !strings.Contains(body, `href="/journal?page=2"`) {
+		t.Errorf("first page should list the 10 newest entries with a link to the next: %s", body)
+	}
+	if strings.Index(body, "Day 12.") > strings.Index(body, "Day 11.") {
+		t.Error("entries are not newest first")
+	}
+
+	body = get("/journal?page=2")
+	for _, want := range []string{"Day 2.", "Day 1.", "Psalm 34:1-7; Romans 8:1-2", `href="/journal?page=1"`} {
+		if !strings.Contains(body, want) {
+			t.Errorf("second page missing %q", want)
+		}
+	}
+	if strings.Contains(body, "Day 3.") || strings.Contains(body, `href="/journal?page=3"`) {
+		t.Errorf("second page should list only the two oldest entries: %s", body)
+	}
+}
+
+func TestIntegration_EntryAudio(t *testing.T) {
+	var inputs []string
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]string
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decoding speech request: %v", err)
+		}
+		inputs = append(inputs, req["input"])
+		_, _ = io.WriteString(w, "MP3:"+req["input"])
+	}))
+	t.Cleanup(api.Close)
+	t.Setenv("TTS_BACKEND", "api")
+	t.Setenv("TTS_API_URL", api.URL)
+	t.Setenv("TTS_API_KEY", "test-key")
+	cacheDir := t.TempDir()
+	t.Setenv("TTS_CACHE_DIR", cacheDir)
+
+	srv := testutil.NewServer(t)
+	client := srv.Login(t, "reader@example.com")
+	user, err := srv.Store.GetUserByEmail(context.Background(), "reader@example.com")
+	if err != nil {
+		t.Fatalf("GetUserByEmail failed: %v", err)
+	}
+	for date, observation := range map[string]string{"2026-03-03": "The Lord is my shepherd.", "2026-03-05": "Be still and know."} {
+		entry := &store.SOAPData{Date: date, Sections: map[string]string{"observation": observation, "prayer": "Amen"}}
+		if err := srv.Store.SaveSOAPData(context.Background(), user.ID, entry); err != nil {
+			t.Fatalf("SaveSOAPData failed: %v", err)
+		}
+	}
+
+	get := func(path string, header http.Header) (*http.Response, string) {
+		t.Helper()
+		req, err := http.NewRequest(http.MethodGet, srv.URL+path, nil)
+		if err != nil {
+			t.Fatalf("creating request: %v", err)
+		}
+		maps.Copy(req.Header, header)
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("GET %s failed: %v", path, err)
+		}
+		return resp, readBody(t, resp)
+	}
+
+	resp, body := get("/audio/entry?date=2026-03-03", nil)
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "audio/mpeg" {
+		t.Fatalf("GET /audio/entry = %d %s: %s", resp.StatusCode, resp.Header.Get("Content-Type"), body)
+	}
+	want := "MP3:Tuesday, March 3, 2026.\n\nObservation.\n\nThe Lord is my shepherd.\n\nPrayer.\n\nAmen"
+	if body != want {
+		t.Errorf("GET /audio/entry = %q, want %q", body, want)
+	}
+
+	// Players seek with range requests, which are served from the cached audio.
+	resp, body = get("/audio/entry?date=2026-03-03", http.Header{"Range": {"bytes=0-3"}})
+	if resp.StatusCode != http.StatusPartialContent || body != "MP3:" {
+		t.Errorf("GET /audio/entry with a range = %d %q, want 206 with the first bytes", resp.StatusCode, body)
+	}
+	resp, _ = get("/audio/entry?date=2026-03-03", http.Header{"If-None-Match": {resp.Header.Get("ETag")}})
+	if resp.StatusCode != http.StatusNotModified {
+		t.Errorf("GET /audio/entry with its ETag = %d, want 304", resp.StatusCode)
+	}
+	if len(inputs) != 1 {
+		t.Errorf("entry read aloud %d times, want once", len(inputs))
+	}
+
+	resp, body = get("/audio/week?date=2026-03-06", nil)
+	if resp.StatusCode != http.StatusOK || !strings.HasPrefix(body, "MP3:Your journal for the week of March 1, 2026.") ||
+		!strings.Contains(body, "The Lord is my shepherd.") || !strings.Contains(body, "Thursday, March 5, 2026.\n\nObservation.\n\nBe still and know.") {
+		t.Errorf("GET /audio/week = %d: %q", resp.StatusCode, body)
+	}
+	if resp, _ := get("/audio/entry?date=2026-03-04", nil); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("GET /audio/entry for a day without an entry = %d, want 404", resp.StatusCode)
+	}
+	if resp, _ := get("/audio/week?date=2026-03-10", nil); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("GET /audio/week for a week without entries = %d, want 404", resp.StatusCode)
+	}
+	if _, body := get("/journal", nil); !strings.Contains(body, `src="/audio/entry?date=2026-03-05"`) {
+		t.Errorf("GET /journal does not let the entries be listened to: %s", body)
+	}
+
+	// Deleting an entry removes the user's audio, which read it.
+	userDir := filepath.Join(cacheDir, strconv.FormatInt(user.ID, 10))
+	if entries, err := os.ReadDir(userDir); err != nil || len(entries) != 2 {
+		t.Fatalf("cached audio = %d files, %v; want the entry and the week", len(entries), err)
+	}
+	resp, err = client.Post(srv.URL+"/soap", "application/json", strings.NewReader(`{"date": "2026-03-05", "sections": {"observation": "", "prayer": ""}}`))
+	if err != nil {
+		t.Fatalf("POST /soap failed: %v", err)
+	}
+	if body := readBody(t, resp); resp.StatusCode != http.StatusOK {
+		t.Fatalf("POST /soap = %d: %s", resp.StatusCode, body)
+	}
+	if _, err := os.Stat(userDir); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("audio left behind after an entry was deleted: %v", err)
+	}
+
+	t.Setenv("TTS_BACKEND", "")
+	srv = testutil.NewServer(t)
+	client = srv.Login(t, "reader@example.com")
+	if resp, _ := get("/audio/entry?date=2026-03-03", nil); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("GET /audio/entry without a backend = %d, want 404", resp.StatusCode)
+	}
+	if _, body := get("/journal", nil); strings.Contains(body, "/audio/") {
+		t.Errorf("GET /journal without a backend links to audio: %s", body)
+	}
+}
+
+func TestIntegration_ReloadCorrectedDailyTexts(t *testing.T) {
+	t.Setenv("ADMIN_EMAIL", "admin@example.com")
+	t.Cleanup(func() { _, _, _ = config.Reload() })
+	dir := t.TempDir()
+	t.Setenv("DAILY_TEXTS_DIR", dir)
+	srv := testutil.NewServer(t)
+	writeYear := func(text dailytexts.DailyText) {
+		t.Helper()
+		if _, err := dailytexts.WriteYear(dir, 2026, dailytexts.Year{"2026-03-07": text}); err != nil {
+			t.Fatalf("WriteYear failed: %v", err)
+		}
+	}
+	writeYear(dailytexts.DailyText{Verses: []string{"Romans 8:1"}, Prayer: "Amen."})
+	dailytexts.SetDir(dir)
+	srv.ESV.AddPassage("Romans 8:1-2", romans8)
+	admin := srv.Login(t, "admin@example.com")
+
+	ctx := context.Background()
+	if text, err := dailytexts.GetDailyText("2026-03-07"); err != nil || text.Verses[0] != "Romans 8:1" {
+		t.Fatalf("GetDailyText = %+v, %v; want the text before the correction", text, err)
+	}
+	for _, key := range []string{"Romans 8:1", "Romans 8:1|sample-options"} {
+		if err := srv.Store.SaveCachedESV(ctx, key, "{}"); err != nil {
+			t.Fatalf("SaveCachedESV failed: %v", err)
+		}
+	}
+
+	writeYear(dailytexts.DailyText{Verses: []string{"Romans 8:1-2"}, Prayer: "Amen."})
+	resp, err := admin.Post(srv.URL+"/admin/reload-config", "", nil)
+	if err != nil {
+		t.Fatalf("POST /admin/reload-config failed: %v", err)
+	}
+	if body := readBody(t, resp); resp.StatusCode != http.StatusOK || !strings.Contains(body, "daily text 2026-03-07: verses changed") {
+		t.Fatalf("POST /admin/reload-config = %d, want the corrected date reported: %s", resp.StatusCode, body)
+	}
+
+	if cached, err := srv.Store.GetCachedESVBatch(ctx, []string{"Romans 8:1", "Romans 8:1|sample-options"}, time.Hour); err != nil || len(cached) != 0 {
+		t.Errorf("cached passages of the replaced reading = %d, %v; want them dropped", len(cached), err)
+	}
+	emails, err := srv.Store.ListRecentEmails(ctx, 1)
+	if err != nil || len(emails) != 1 || !strings.Contains(emails[0].Subject, "Daily texts corrected") {
+		t.Fatalf("ListRecentEmails = %+v, %v; want the admin alerted", emails, err)
+	}
+	resp, err = admin.Get(srv.URL + "/reading?date=2026-03-07")
+	if err != nil {
+		t.Fatalf("GET /reading failed: %v", err)
+	}
+	if body := readBody(t, resp); !strings.Contains(body, "no condemnation") {
+		t.Errorf("reading after the correction does not show the corrected passage:\n%s", body)
+	}
+}
+
+func TestIntegration_EntryStamp(t *testing.T) {
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		switch {
+		case q.Get("name") == "Bethlehem":
+			_, _ = io.WriteString(w, `{"results": [{"name": "Bethlehem", "latitude": 40.6259, "longitude": -75.3705, "admin1_code": "PA"}]}`)
+		case q.Has("name"):
+			_, _ = io.WriteString(w, `{}`)
+		case q.Get("latitude") == "40.6" && q.Get("longitude") == "-75.4":
+			_, _ = io.WriteString(w, `{"current": {"temperature_2m": 12.2, "weather_code": 61}}`)
+		default:
+			http.Error(w, "unexpected coordinates", http.StatusBadRequest)
+		}
+	}))
+	t.Cleanup(api.Close)
+	t.Setenv("WEATHER_API_URL", api.URL)
+	t.Setenv("GEOCODING_API_URL", api.URL)
+	srv := testutil.NewServer(t)
+	srv.SetDailyText(t, "2026-03-07", dailytexts.DailyText{Verses: []string{"Romans 8:1-2"}})
+	srv.ESV.AddPassage("Romans 8:1-2", romans8)
+	client := srv.Login(t, "reader@example.com")
+
+	stamp := func(values url.Values) string {
+		t.Helper()
+		values.Set("date", "2026-03-07")
+		resp, err := client.PostForm(srv.URL+"/stamp", values)
+		if err != nil {
+			t.Fatalf("POST /stamp failed: %v", err)
+		}
+		body := readBody(t, resp)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("POST /stamp = %d: %s", resp.StatusCode, body)
+		}
+		return html.UnescapeString(body)
+	}
+
+	if body := stamp(url.Values{"location": {"Bethlehem, PA"}}); !strings.Contains(body, "Write something first") {
+		t.Errorf("stamping an unwritten entry = %s, want it refused", body)
+	}
+	user, err := srv.Store.GetUserByEmail(context.Background(), "reader@example.com")
+	if err != nil {
+		t.Fatalf("GetUserByEmail failed: %v", err)
+	}
+	entry := &store.SOAPData{Date: "2026-03-07", Sections: map[string]string{"observation": "No condemnation."}}
+	if err := srv.Store.SaveSOAPData(context.Background(), user.ID, entry); err != nil {
+		t.Fatalf("SaveSOAPData failed: %v", err)
+	}
+
+	const want = "Bethlehem, PA · Light rain, 12°C (54°F)"
+	if body := stamp(url.Values{"location": {"Bethlehem, PA"}}); !strings.Contains(body, want) {
+		t.Errorf("stamp by place = %s, want %q", body, want)
+	}
+	for path, wantBody := range map[string]string{
+		"/?date=2026-03-07": want,
+		"/journal":          want,
+	} {
+		resp, err := client.Get(srv.URL + path)
+		if err != nil {
+			t.Fatalf("GET %s failed: %v", path, err)
+		}
+		if body := html.UnescapeString(readBody(t, resp)); !strings.Contains(body, wantBody) {
+			t.Errorf("GET %s does not show the stamp:\n%s", path, body)
+		}
+	}
+	resp, err := client.Post(srv.URL+"/export", "application/json", strings.NewReader(`{"date": "2026-03-07", "format": "markdown", "method": "download"}`))
+	if err != nil {
+		t.Fatalf("POST /export failed: %v", err)
+	}
+	if body := readBody(t, resp); !strings.Contains(body, "_"+want+"_") {
+		t.Errorf("export does not show the stamp:\n%s", body)
+	}
+
+	// The browser's coordinates are recorded only roughly, and a place that cannot be found is noted
+	// without the weather.
+	if body := stamp(url.Values{"latitude": {"40.6259"}, "longitude": {"-75.3705"}}); !strings.Contains(body, "40.6, -75.4 · Light rain") {
+		t.Errorf("stamp by coordinates = %s, want the rounded coordinates and the weather", body)
+	}
+	if body := stamp(url.Values{"location": {"Atlantis"}}); !strings.Contains(body, "Atlantis") || strings.Contains(body, "rain") {
+		t.Errorf("stamp of an unknown place = %s, want only the place", body)
+	}
+	if body := stamp(url.Values{"action": {"clear"}}); strings.Contains(body, "Atlantis") {
+		t.Errorf("stamp after clearing = %s, want none", body)
+	}
+	saved, err := srv.Store.GetSOAPData(context.Background(), user.ID, "2026-03-07")
+	if err != nil {
+		t.Fatalf("GetSOAPData failed: %v", err)
+	}
+	if saved.Stamp() != "" {
+		t.Errorf("entry stamp after clearing = %q, want none", saved.Stamp())
+	}
+}
+
+func TestIntegration_YearReview(t *testing.T) {
+	srv := testutil.NewServer(t)
+	client := srv.Login(t, "reader@example.com")
+	ctx := context.Background()
+	user, err := srv.Store.GetUserByEmail(ctx, "reader@example.com")
+	if err != nil {
+		t.Fatalf("GetUserByEmail failed: %v", err)
+	}
+	lastYear := srv.Clock.Now().Year() - 1
+	for i, verse := range []string{"19023001", "45008028"} {
+		date := fmt.Sprintf("%d-0%d-01", lastYear, i+1)
+		entry := &store.SOAPData{Date: date, Sections: map[string]string{"observation": "Noted."}, SelectedVerses: []string{verse}}
+		if err := srv.Store.SaveSOAPData(ctx, user.ID, entry); err != nil {
+			t.Fatalf("SaveSOAPData failed: %v", err)
+		}
+		chapter := store.Chapter{Book: 19, Chapter: 23}
+		if err := srv.Store.SaveJournalChapters(ctx, user.ID, date, []store.Chapter{chapter}); err != nil {
+			t.Fatalf("SaveJournalChapters failed: %v", err)
+		}
+	}
+
+	share := func(year int, values url.Values) (int, string) {
+		t.Helper()
+		values.Set("year", strconv.Itoa(year))
+		resp, err := client.PostForm(srv.URL+"/stats/review", values)
+		if err != nil {
+			t.Fatalf("POST /stats/review failed: %v", err)
+		}
+		return resp.StatusCode, readBody(t, resp)
+	}
+	if code, _ := share(lastYear, url.Values{"action": {"share"}, "verses": {"Genesis 1:1"}}); code != http.StatusBadRequest {
+		t.Errorf("sharing a verse not highlighted = %d, want %d", code, http.StatusBadRequest)
+	}
+	if code, body := share(lastYear, url.Values{"action": {"share"}, "verses": {"Psalm 23:1"}}); code != http.StatusOK || !strings.Contains(body, "is shared") {
+		t.Fatalf("sharing last year = %d: %s", code, body)
+	}
+	review, err := srv.Store.GetYearReview(ctx, user.ID, lastYear)
+	if err != nil {
+		t.Fatalf("GetYearReview failed: %v", err)
+	}
+
+	// The shared page needs no sign-in and names nobody.
+	visitor := srv.NewClient(t)
+	resp, err := visitor.Get(srv.URL + "/review/" + review.Token)
+	if err != nil {
+		t.Fatalf("GET shared review failed: %v", err)
+	}
+	body := readBody(t, resp)
+	for _, want := range []string{"2 days journaled", "Psalm 23:1", "og:image", "/review/" + review.Token + "/card.png"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("shared review does not contain %q:\n%s", want, body)
+		}
+	}
+	if strings.Contains(body, "reader@example.com") || strings.Contains(body, "Romans 8:28") || strings.Contains(body, "Noted.") {
+		t.Errorf("shared review shows what was not shared:\n%s", body)
+	}
+	resp, err = visitor.Get(srv.URL + "/review/" + review.Token + "/card.png")
+	if err != nil {
+		t.Fatalf("GET card failed: %v", err)
+	}
+	if card := readBody(t, resp); resp.Header.Get("Content-Type") != "image/png" || !strings.HasPrefix(card, "\x89PNG") {
+		t.Errorf("card = %s %q, want a PNG image", resp.Header.Get("Content-Type"), card[:min(len(card), 8)])
+	}
+
+	// The page links the card's current version, which caches in front of the instance may keep
+	// without asking again, but only for as long as an unversioned card.
+	cardURL := regexp.MustCompile(`/review/[^"]+/card\.png\?v=[^"]+`).FindString(body)
+	if cardURL == "" {
+		t.Fatalf("shared review does not link a versioned card:\n%s", body)
+	}
+	resp, err = visitor.Get(srv.URL + html.UnescapeString(cardURL))
+	if err != nil {
+		t.Fatalf("GET versioned card failed: %v", err)
+	}
+	_ = readBody(t, resp)
+	if got := resp.Header.Get("Cache-Control"); got != "public, max-age=3600, immutable" || resp.Header.Get("Surrogate-Control") != "max-age=3600" {
+		t.Errorf("versioned card Cache-Control = %q, Surrogate-Control = %q", got, resp.Header.Get("Surrogate-Control"))
+	}
+	resp, err = visitor.Get(srv.URL + "/review/" + review.Token + "/card.png?v=forged")
+	if err != nil {
+		t.Fatalf("GET card with a forged version failed: %v", err)
+	}
+	_ = readBody(t, resp)
+	if got := resp.Header.Get("Cache-Control"); got != "no-store" {
+		t.Errorf("card with a forged version Cache-Control = %q, want no-store", got)
+	}
+
+	// A review of the current year waits for the year-end job.
+	thisYear := lastYear + 1
+	if code, body := share(thisYear, url.Values{"action": {"share"}}); code != http.StatusOK || !strings.Contains(body, "once the year is over") {
+		t.Fatalf("sharing this year = %d: %s", code, body)
+	}
+	pending, err := srv.Store.GetYearReview(ctx, user.ID, thisYear)
+	if err != nil {
+		t.Fatalf("GetYearReview failed: %v", err)
+	}
+	resp, err = visitor.Get(srv.URL + "/review/" + pending.Token)
+	if err != nil {
+		t.Fatalf("GET pending review failed: %v", err)
+	}
+	if body := readBody(t, resp); !strings.Contains(body, "ready once the year is over") || strings.Contains(body, "og:image") {
+		t.Errorf("pending review = %s, want it to say when it will be ready", body)
+	}
+	resp, err = visitor.Get(srv.URL + "/review/" + pending.Token + "/card.png")
+	if err != nil {
+		t.Fatalf("GET pending card failed: %v", err)
+	}
+	_ = readBody(t, resp)
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("pending card = %d, want %d", resp.StatusCode, http.StatusNotFound)
+	}
+
+	if code, body := share(lastYear, url.Values{"action": {"stop"}}); code != http.StatusOK || !strings.Contains(body, "no longer shared") {
+		t.Fatalf("stopping sharing = %d: %s", code, body)
+	}
+	resp, err = visitor.Get(srv.URL + "/review/" + review.Token)
+	if err != nil {
+		t.Fatalf("GET unshared review failed: %v", err)
+	}
+	_ = readBody(t, resp)
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("unshared review = %d, want %d", resp.StatusCode, http.StatusNotFound)
+	}
+}
+
+func TestIntegration_GuestComments(t *testing.T) {
+	t.Setenv("ADMIN_EMAIL", "admin@example.com")
+	t.Cleanup(func() { _, _, _ = config.Reload() })
+	t.Setenv("FEATURES", "guest_comments=on")
+	t.Setenv("TRUST_PROXY", "true")
+	if _, _, err := config.Reload(); err != nil {
+		t.Fatalf("Reload failed: %v", err)
+	}
+	srv := testutil.NewServer(t)
+	admin := srv.Login(t, "admin@example.com")
+	reader := srv.Login(t, "reader@example.com")
+	ctx := context.Background()
+	user, err := srv.Store.GetUserByEmail(ctx, "reader@example.com")
+	if err != nil {
+		t.Fatalf("GetUserByEmail failed: %v", err)
+	}
+	year := srv.Clock.Now().Year() - 1
+	review := &store.YearReview{UserID: user.ID, Year: year, Token: "shared-review"}
+	if err := srv.Store.SaveYearReview(ctx, review); err != nil {
+		t.Fatalf("SaveYearReview failed: %v", err)
+	}
+	page := "/review/" + review.Token
+
+	visitor := srv.NewClient(t)
+	comment := func(ip string, values url.Values) (int, string) {
+		t.Helper()
+		req, err := http.NewRequest(http.MethodPost, srv.URL+page, strings.NewReader(values.Encode()))
+		if err != nil {
+			t.Fatalf("NewRequest failed: %v", err)
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set("X-Forwarded-For", ip)
+		resp, err := visitor.Do(req)
+		if err != nil {
+			t.Fatalf("POST %s failed: %v", page, err)
+		}
+		body := readBody(t, resp)
+		return resp.StatusCode, resp.Header.Get("Location") + body
+	}
+	view := func() string {
+		t.Helper()
+		resp, err := visitor.Get(srv.URL + page)
+		if err != nil {
+			t.Fatalf("GET %s failed: %v", page, err)
+		}
+		return readBody(t, resp)
+	}
+	post := func(client *http.Client, path string, values url.Values) (int, string) {
+		t.Helper()
+		resp, err := client.PostForm(srv.URL+path, values)
+		if err != nil {
+			t.Fatalf("POST %s failed: %v", path, err)
+		}
+		return resp.StatusCode, readBody(t, resp)
+	}
+
+	// Comments are closed until the owner allows them.
+	if code, _ := comment("192.0.2.1", url.Values{"name": {"Anna"}, "comment": {"Hello"}}); code != http.StatusForbidden {
+		t.Errorf("comment before comments were allowed = %d, want %d", code, http.StatusForbidden)
+	}
+	if body := view(); strings.Contains(body, "guest-comment-form") {
+		t.Errorf("review shows a comment form before comments were allowed:\n%s", body)
+	}
+	values := url.Values{"action": {"comments"}, "enabled": {"true"}, "year": {strconv.Itoa(year)}}
+	if code, body := post(reader, "/stats/review", values); code != http.StatusOK || !strings.Contains(body, "can now comment") {
+		t.Fatalf("allowing comments = %d: %s", code, body)
+	}
+
+	if code, _ := comment("192.0.2.1", url.Values{"name": {"Anna"}, "comment": {"What a year of reading!"}}); code != http.StatusSeeOther {
+		t.Fatalf("comment = %d, want %d", code, http.StatusSeeOther)
+	}
+	// Bots that fill in the hidden field are turned away as if their comment were accepted.
+	if code, _ := comment("192.0.2.2", url.Values{"name": {"Bot"}, "comment": {"Cheap pills"}, "website": {"http://spam.example"}}); code != http.StatusSeeOther {
+		t.Errorf("comment with the honeypot filled in = %d, want %d", code, http.StatusSeeOther)
+	}
+	if code, body := comment("192.0.2.1", url.Values{"name": {"Anna"}, "comment": {" "}}); code != http.StatusBadRequest || !strings.Contains(body, "Write a comment first") {
+		t.Errorf("empty comment = %d: %s", code, body)
+	}
+	if body := view(); !strings.Contains(body, "What a year of reading!") || strings.Contains(body, "Cheap pills") {
+		t.Errorf("review comments = %s, want only Anna's", body)
+	}
+
+	// The admin blocks words and holds new comments for approval.
+	if code, _ := post(admin, "/admin/comments", url.Values{"action": {"block"}, "kind": {"word"}, "value": {"Casino"}}); code != http.StatusSeeOther {
+		t.Fatalf("blocking a word = %d, want %d", code, http.StatusSeeOther)
+	}
+	if code, _ := post(admin, "/admin/comments", url.Values{"action": {"moderation"}, "enabled": {"true"}}); code != http.StatusSeeOther {
+		t.Fatalf("turning on moderation = %d, want %d", code, http.StatusSeeOther)
+	}
+	if code, _ := comment("192.0.2.1", url.Values{"name": {"Anna"}, "comment": {"Visit my casino"}}); code != http.StatusSeeOther {
+		t.Errorf("blocked comment = %d, want %d", code, http.StatusSeeOther)
+	}
+	if code, location := comment("192.0.2.1", url.Values{"name": {"Anna"}, "comment": {"Praying for you."}}); code != http.StatusSeeOther || !strings.Contains(location, "held=1") {
+		t.Errorf("moderated comment = %d %s, want it held", code, location)
+	}
+	// The address has left three comments in ten minutes.
+	if code, _ := comment("192.0.2.1", url.Values{"name": {"Anna"}, "comment": {"One more thing"}}); code != http.StatusTooManyRequests {
+		t.Errorf("fourth comment = %d, want %d", code, http.StatusTooManyRequests)
+	}
+	if body := view(); strings.Contains(body, "Praying for you.") || strings.Contains(body, "casino") {
+		t.Errorf("review shows comments not approved:\n%s", body)
+	}
+
+	pending, err := srv.Store.ListPendingGuestComments(ctx)
+	if err != nil || len(pending) != 1 || pending[0].IP != "192.0.2.1" {
+		t.Fatalf("ListPendingGuestComments = %+v, %v; want the held comment", pending, err)
+	}
+	resp, err := admin.Get(srv.URL + "/admin/comments")
+	if err != nil {
+		t.Fatalf("GET /admin/comments failed: %v", err)
+	}
+	if body := readBody(t, resp); !strings.Contains(body, "Praying for you.") || !strings.Contains(body, "Casino") {
+		t.Errorf("admin comments page does not list the held comment and blocklist:\n%s", body)
+	}
+	if code, _ := post(admin, "/admin/comments", url.Values{"action": {"approve"}, "id": {strconv.FormatInt(pending[0].ID, 10)}}); code != http.StatusSeeOther {
+		t.Fatalf("approving = %d, want %d", code, http.StatusSeeOther)
+	}
+	if body := view(); !strings.Contains(body, "Praying for you.") {
+		t.Errorf("review does not show the approved comment:\n%s", body)
+	}
+
+	// Deleting a comment and blocking its address drops what comes from the address after.
+	if code, _ := post(admin, "/admin/comments", url.Values{"action": {"block-ip"}, "id": {strconv.FormatInt(pending[0].ID, 10)}, "ip": {"192.0.2.3"}}); code != http.StatusSeeOther {
+		t.Fatalf("blocking an address = %d, want %d", code, http.StatusSeeOther)
+	}
+	if code, _ := comment("192.0.2.3", url.Values{"name": {"Eve"}, "comment": {"Sneaky"}}); code != http.StatusSeeOther {
+		t.Errorf("comment from a blocked address = %d, want %d", code, http.StatusSeeOther)
+	}
+	if comments, _ := srv.Store.ListGuestComments(ctx, user.ID, year); len(comments) != 1 || comments[0].Body != "What a year of reading!" {
+		t.Errorf("ListGuestComments = %+v, want only the first comment left", comments)
+	}
+	if pending, _ := srv.Store.ListPendingGuestComments(ctx); len(pending) != 0 {
+		t.Errorf("ListPendingGuestComments = %+v, want the blocked comment dropped", pending)
+	}
+}
+
+func TestIntegration_GuestCommentsIgnoreForwardedFor(t *testing.T) {
+	t.Cleanup(func() { _, _, _ = config.Reload() })
+	t.Setenv("FEATURES", "guest_comments=on")
+	t.Setenv("TRUST_PROXY", "")
+	if _, _, err := config.Reload(); err != nil {
+		t.Fatalf("Reload failed: %v", err)
+	}
+	srv := testutil.NewServer(t)
+	srv.Login(t, "reader@example.com")
+	ctx := context.Background()
+	user, err := srv.Store.GetUserByEmail(ctx, "reader@example.com")
+	if err != nil {
+		t.Fatalf("GetUserByEmail failed: %v", err)
+	}
+	year := srv.Clock.Now().Year() - 1
+	review := &store.YearReview{UserID: user.ID, Year: year, Token: "shared-review"}
+	if err := srv.Store.SaveYearReview(ctx, review); err != nil {
+		t.Fatalf("SaveYearReview failed: %v", err)
+	}
+	if err := srv.Store.SetYearReviewComments(ctx, user.ID, year, true); err != nil {
+		t.Fatalf("SetYearReviewComments failed: %v", err)
+	}
+
+	// Without a proxy in front of the instance the header is the visitor's own, and they could send a
+	// new address with each comment to get past the limit and the blocklist.
+	values := url.Values{"name": {"Anna"}, "comment": {"Hello"}}
+	req, err := http.NewRequest(http.MethodPost, srv.URL+"/review/"+review.Token, strings.NewReader(values.Encode()))
+	if err != nil {
+		t.Fatalf("NewRequest failed: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("X-Forwarded-For", "192.0.2.1")
+	resp, err := srv.NewClient(t).Do(req)
+	if err != nil {
+		t.Fatalf("POST comment failed: %v", err)
+	}
+	_ = readBody(t, resp)
+	if resp.StatusCode != http.StatusSeeOther {
+		t.Fatalf("comment = %d, want %d", resp.StatusCode, http.StatusSeeOther)
+	}
+	comments, err := srv.Store.ListGuestComments(ctx, user.ID, year)
+	if err != nil || len(comments) != 1 {
+		t.Fatalf("ListGuestComments = %+v, %v; want the comment", comments, err)
+	}
+	if comments[0].IP != "127.0.0.1" {
+		t.Errorf("comment IP = %q, want the connection's address rather than the forged header", comments[0].IP)
+	}
+}
+
+func TestIntegration_Mentors(t *testing.T) {
+	srv := testutil.NewServer(t)
+	reader := srv.Login(t, "reader@example.com")
+	mentor := srv.Login(t, "mentor@example.com")
+	ctx := context.Background()
+	user, err := srv.Store.GetUserByEmail(ctx, "reader@example.com")
+	if err != nil {
+		t.Fatalf("GetUserByEmail failed: %v", err)
+	}
+	for date, observation := range map[string]string{"2026-03-07": "No condemnation.", "2026-03-10": "Set free."} {
+		entry := &store.SOAPData{Date: date, Sections: map[string]string{"observation": observation}}
+		if err := srv.Store.SaveSOAPData(ctx, user.ID, entry); err != nil {
+			t.Fatalf("SaveSOAPData failed: %v", err)
+		}
+	}
+	journal := fmt.Sprintf("/mentees/%d", user.ID)
+	post := func(client *http.Client, path string, values url.Values) (int, string) {
+		t.Helper()
+		resp, err := client.PostForm(srv.URL+path, values)
+		if err != nil {
+			t.Fatalf("POST %s failed: %v", path, err)
+		}
+		return resp.StatusCode, readBody(t, resp)
+	}
+	get := func(client *http.Client, path string) (int, string) {
+		t.Helper()
+		resp, err := client.Get(srv.URL + path)
+		if err != nil {
+			t.Fatalf("GET %s failed: %v", path, err)
+		}
+		return resp.StatusCode, readBody(t, resp)
+	}
+
+	if status, _ := get(mentor, journal); status != http.StatusNotFound {
+		t.Errorf("GET %s before sharing = %d, want 404", journal, status)
+	}
+	if _, body := post(reader, "/settings/mentors", url.Values{"action": {"grant"}, "email": {"nobody@example.com"}}); !strings.Contains(body, "no account with that email") {
+		t.Errorf("sharing with an unknown account = %s", body)
+	}
+	grant := url.Values{"action": {"grant"}, "email": {"mentor@example.com"}, "from": {"2026-03-01"}, "to": {"2026-03-08"}}
+	if status, _ := post(reader, "/settings/mentors", grant); status != http.StatusSeeOther {
+		t.Fatalf("POST /settings/mentors = %d, want a redirect", status)
+	}
+	if _, body := get(mentor, "/settings/mentors"); !strings.Contains(body, journal) {
+		t.Errorf("mentor's settings do not link to the shared journal: %s", body)
+	}
+
+	// The mentor reads only the entries in the dates shared, and can comment on them but not others.
+	status, body := get(mentor, journal)
+	if status != http.StatusOK || !strings.Contains(body, "No condemnation.") || strings.Contains(body, "Set free.") {
+		t.Fatalf("GET %s = %d, want only the entry of March 7: %s", journal, status, body)
+	}
+	if status, _ := post(mentor, journal, url.Values{"date": {"2026-03-10"}, "comment": {"Amen."}}); status != http.StatusNotFound {
+		t.Errorf("commenting on an entry not shared = %d, want 404", status)
+	}
+	if status, _ := post(mentor, journal, url.Values{"date": {"2026-03-07"}, "comment": {"Well observed."}}); status != http.StatusSeeOther {
+		t.Fatalf("POST %s = %d, want a redirect", journal, status)
+	}
+	if _, body := get(mentor, journal); !strings.Contains(body, "Well observed.") {
+		t.Errorf("comment missing from the mentor's view: %s", body)
+	}
+	if _, body := get(reader, "/mentor-comments?date=2026-03-07"); !strings.Contains(body, "Well observed.") || !strings.Contains(body, "mentor@example.com") {
+		t.Errorf("reader does not see the comment: %s", body)
+	}
+	emails, err := srv.Store.ListRecentEmails(ctx, 1)
+	if err != nil || len(emails) != 1 || emails[0].Recipient != "reader@example.com" || !strings.Contains(emails[0].Subject, "mentor@example.com commented") {
+		t.Errorf("ListRecentEmails = %+v, %v; want the reader told of the comment", emails, err)
+	}
+
+	// Revoking access hides the journal from the mentor at once, and keeps the comment.
+	mentorUser, err := srv.Store.GetUserByEmail(ctx, "mentor@example.com")
+	if err != nil {
+		t.Fatalf("GetUserByEmail failed: %v", err)
+	}
+	if status, _ := post(reader, "/settings/mentors", url.Values{"action": {"revoke"}, "mentor_id": {strconv.FormatInt(mentorUser.ID, 10)}}); status != http.StatusSeeOther {
+		t.Fatalf("revoking = %d, want a redirect", status)
+	}
+	if status, _ := get(mentor, journal); status != http.StatusNotFound {
+		t.Errorf("GET %s after revoking = %d, want 404", journal, status)
+	}
+	if _, body := get(reader, "/?date=2026-03-07"); !strings.Contains(body, "Comments from your mentors") {
+		t.Errorf("comment gone from the reader's journal after revoking: %s", body)
+	}
+}
+
+func TestIntegration_MentorSeesMenteeSections(t *testing.T) {
+	srv := testutil.NewServer(t)
+	reader := srv.Login(t, "reader@example.com")
+	mentor := srv.Login(t, "mentor@example.com")
+	ctx := context.Background()
+	user, err := srv.Store.GetUserByEmail(ctx, "reader@example.com")
+	if err != nil {
+		t.Fatalf("GetUserByEmail failed: %v", err)
+	}
+
+	// The reader journals in sections of their own, and the mentor in the ACTS sections.
+	prefs, err := srv.Store.GetPreferences(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("GetPreferences failed: %v", err)
+	}
+	prefs.JournalSchema = `[{"key": "gratitude", "name": "Thankful for"}, {"key": "observation", "name": "Noticed"}]`
+	if err := srv.Store.SavePreferences(ctx, user.ID, prefs); err != nil {
+		t.Fatalf("SavePreferences failed: %v", err)
+	}
+	resp, err := mentor.PostForm(srv.URL+"/settings/journal", url.Values{"preset": {"acts"}})
+	if err != nil {
+		t.Fatalf("POST /settings/journal failed: %v", err)
+	}
+	if body := readBody(t, resp); resp.StatusCode != http.StatusOK {
+		t.Fatalf("POST /settings/journal = %d: %s", resp.StatusCode, body)
+	}
+
+	entry := &store.SOAPData{Date: "2026-03-07", Sections: map[string]string{"gratitude": "Freedom.", "observation": "No condemnation."}}
+	if err := srv.Store.SaveSOAPData(ctx, user.ID, entry); err != nil {
+		t.Fatalf("SaveSOAPData failed: %v", err)
+	}
+	grant := url.Values{"action": {"grant"}, "email": {"mentor@example.com"}, "from": {"2026-03-01"}, "to": {"2026-03-08"}}
+	resp, err = reader.PostForm(srv.URL+"/settings/mentors", grant)
+	if err != nil {
+		t.Fatalf("POST /settings/mentors failed: %v", err)
+	}
+	if body := readBody(t, resp); resp.StatusCode != http.StatusSeeOther {
+		t.Fatalf("POST /settings/mentors = %d: %s", resp.StatusCode, body)
+	}
+
+	journal := fmt.Sprintf("/mentees/%d", user.ID)
+	resp, err = mentor.Get(srv.URL + journal)
+	if err != nil {
+		t.Fatalf("GET %s failed: %v", journal, err)
+	}
+	body := readBody(t, resp)
+	thankful, noticed := strings.Index(body, "<h4>Thankful for</h4>"), strings.Index(body, "<h4>Noticed</h4>")
+	if resp.StatusCode != http.StatusOK || thankful < 0 || noticed < thankful {
+		t.Errorf("GET %s = %d, want the entry in the reader's sections and their order: %s", journal, resp.StatusCode, body)
+	}
+}
+
+func TestIntegration_Favorites(t *testing.T) {
+	srv := testutil.NewServer(t)
+	for _, date := range []string{"2026-03-05", "2026-03-06", "2026-03-07"} {
+		srv.SetDailyText(t, date, dailytexts.DailyText{Verses: []string{"Romans 8:1-2"}})
+	}
+	client := testutil.HTMX(srv.Login(t, "reader@example.com"))
+	ctx := context.Background()
+	user, err := srv.Store.GetUserByEmail(ctx, "reader@example.com")
+	if err != nil {
+		t.Fatalf("GetUserByEmail failed: %v", err)
+	}
+
+	post := func(path string, values url.Values) (int, string) {
+		t.Helper()
+		resp, err := client.PostForm(srv.URL+path, values)
+		if err != nil {
+			t.Fatalf("POST %s failed: %v", path, err)
+		}
+		return resp.StatusCode, readBody(t, resp)
+	}
+	get := func(path string) string {
+		t.Helper()
+		resp, err := client.Get(srv.URL + path)
+		if err != nil {
+			t.Fatalf("GET %s failed: %v", path, err)
+		}
+		body := readBody(t, resp)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("GET %s = %d: %s", path, resp.StatusCode, body)
+		}
+		return body
+	}
+
+	if _, body := post("/favorite", url.Values{"date": {"2026-03-05"}, "action": {"add"}}); !strings.Contains(body, "Write something first") {
+		t.Errorf("pinning an unwritten entry = %s, want it refused", body)
+	}
+	for _, e := range []struct{ date, observation string }{{"2026-03-05", "No condemnation."}, {"2026-03-06", "Set free."}} {
+		date := e.date
+		entry := &store.SOAPData{Date: date, Sections: map[string]string{"observation": e.observation}}
+		if err := srv.Store.SaveSOAPData(ctx, user.ID, entry); err != nil {
+			t.Fatalf("SaveSOAPData failed: %v", err)
+		}
+		if status, body := post("/favorite", url.Values{"date": {date}, "action": {"add"}}); status != http.StatusOK || !strings.Contains(body, "Pinned as a favorite") {
+			t.Errorf("POST /favorite for %s = %d: %s", date, status, body)
+		}
+	}
+
+	// Another day's page suggests a favorite, and a favorite's own page shows it pinned.
+	if body := get("/?date=2026-03-07"); !strings.Contains(body, "From your favorites") || !strings.Contains(body, "Pin as a favorite") {
+		t.Errorf("index does not suggest a favorite:\n%s", body)
+	}
+	if body := get("/favorite?date=2026-03-05"); !strings.Contains(body, "Pinned as a favorite") || !strings.Contains(body, "Set free.") {
+		t.Errorf("favorite partial = %s, want it pinned and the other favorite suggested", body)
+	}
+
+	body := get("/favorites")
+	if i, j := strings.Index(body, "No condemnation."), strings.Index(body, "Set free."); i < 0 || j < i {
+		t.Errorf("favorites are not listed in the order pinned:\n%s", body)
+	}
+	if status, _ := post("/favorites", url.Values{"date": {"2026-03-06"}, "action": {"up"}}); status != http.StatusSeeOther {
+		t.Errorf("moving a favorite up = %d", status)
+	}
+	if dates, err := srv.Store.ListFavorites(ctx, user.ID); err != nil || !slices.Equal(dates, []string{"2026-03-06", "2026-03-05"}) {
+		t.Errorf("favorites after moving = %v, %v", dates, err)
+	}
+	post("/favorites", url.Values{"date": {"2026-03-06"}, "action": {"remove"}})
+	if body := get("/favorites"); strings.Contains(body, "Set free.") || !strings.Contains(body, "No condemnation.") {
+		t.Errorf("favorites after unpinning:\n%s", body)
+	}
+}
+
+func TestIntegration_APIResources(t *testing.T) {
+	srv := testutil.NewServer(t)
+	srv.SetDailyText(t, "2026-03-07", dailytexts.DailyText{
+		Verses:         []string{"Romans 8:1-2"},
+		DailyWatchWord: "The LORD is my shepherd. Psalm 23:1",
+		Prayer:         "Lead us.",
+	})
+	srv.ESV.AddPassage("Romans 8:1-2", romans8)
+	client := srv.Login(t, "reader@example.com")
+
+	request := func(method, path, body string) (int, map[string]any) {
+		t.Helper()
+		req, err := http.NewRequest(method, srv.URL+path, strings.NewReader(body))
+		if err != nil {
+			t.Fatalf("creating request: %v", err)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("%s %s failed: %v", method, path, err)
+		}
+		var v map[string]any
+		if err := json.Unmarshal([]byte(readBody(t, resp)), &v); err != nil {
+			t.Fatalf("%s %s = %d, not JSON: %v", method, path, resp.StatusCode, err)
+		}
+		return resp.StatusCode, v
+	}
+	errorMessage := func(v map[string]any) string {
+		e, _ := v["error"].(map[string]any)
+		msg, _ := e["message"].(string)
+		return msg
+	}
+
+	code, v := request(http.MethodGet, "/api/v1/texts/2026-03-07", "")
+	if text, _ := v["text"].(map[string]any); code != http.StatusOK || text["reference"] != "Psalm 23:1" || text["prayer"] != "Lead us." {
+		t.Errorf("GET /api/v1/texts/2026-03-07 = %d, %v; want the day's text", code, v)
+	}
+	if code, v := request(http.MethodGet, "/api/v1/texts/someday", ""); code != http.StatusBadRequest || errorMessage(v) != "Invalid date" {
+		t.Errorf("GET /api/v1/texts/someday = %d, %v; want a 400 error envelope", code, v)
+	}
+
+	code, v = request(http.MethodGet, "/api/v1/passages?ref="+url.QueryEscape("Romans 8:1-2"), "")
+	if passages, _ := v["passages"].([]any); code != http.StatusOK || len(passages) != 1 || !strings.Contains(fmt.Sprint(passages[0]), "no condemnation") {
+		t.Errorf("GET /api/v1/passages = %d, %v; want Romans 8:1-2", code, v)
+	}
+	if code, _ := request(http.MethodGet, "/api/v1/passages?ref=nothing", ""); code != http.StatusBadRequest {
+		t.Errorf("GET /api/v1/passages with an invalid reference = %d, want 400", code)
+	}
+
+	if code, _ := request(http.MethodGet, "/api/v1/entries/2026-03-07", ""); code != http.StatusNotFound {
+		t.Errorf("GET /api/v1/entries/2026-03-07 before writing = %d, want 404", code)
+	}
+	code, v = request(http.MethodPut, "/api/v1/entries/2026-03-07", `{"sections": {"observation": "No condemnation."}, "selectedVerses": ["45008001"], "baseRevision": 0}`)
+	entry, _ := v["entry"].(map[string]any)
+	if code != http.StatusOK || entry["revision"] != 1.0 || fmt.Sprint(entry["selectedVerses"]) != "[45008001]" {
+		t.Fatalf("PUT /api/v1/entries/2026-03-07 = %d, %v; want revision 1", code, v)
+	}
+	if code, v := request(http.MethodPut, "/api/v1/entries/2026-03-07", `{"sections": {"prayer": "Amen."}, "baseRevision": 0}`); code != http.StatusConflict || errorMessage(v) == "" {
+		t.Errorf("PUT /api/v1/entries/2026-03-07 on an old revision = %d, %v; want 409", code, v)
+	}
+	if code, v := request(http.MethodPut, "/api/v1/entries/2026-03-07", `{"sections": {"Not A Key!": "Psst."}}`); code != http.StatusBadRequest || errorMessage(v) != "Invalid section" {
+		t.Errorf("PUT /api/v1/entries/2026-03-07 with an invalid section = %d, %v; want 400", code, v)
+	}
+
+	if code, v := request(http.MethodGet, "/api/v1/entries", ""); code != http.StatusOK || len(v["entries"].([]any)) != 1 || v["hasMore"] != false {
+		t.Errorf("GET /api/v1/entries = %d, %v; want the one entry", code, v)
+	}
+	if code, v := request(http.MethodGet, "/api/v1/search?q=CONDEMNATION", ""); code != http.StatusOK || len(v["entries"].([]any)) != 1 {
+		t.Errorf("GET /api/v1/search = %d, %v; want the entry", code, v)
+	}
+	if code, _ := request(http.MethodGet, "/api/v1/search", ""); code != http.StatusBadRequest {
+		t.Errorf("GET /api/v1/search without a query = %d, want 400", code)
+	}
+	code, v = request(http.MethodGet, "/api/v1/stats", "")
+	if stats, _ := v["stats"].(map[string]any); code != http.StatusOK || stats["windowDays"] != 30.0 {
+		t.Errorf("GET /api/v1/stats = %d, %v; want the stats", code, v)
+	}
+
+	// Browsers preflight calls from other origins without credentials, and are then let read responses.
+	req, err := http.NewRequest(http.MethodOptions, srv.URL+"/api/v1/entries/2026-03-07", nil)
+	if err != nil {
+		t.Fatalf("creating request: %v", err)
+	}
+	req.Header.Set("Origin", "https://app.example.com")
+	req.Header.Set("Access-Control-Request-Method", http.MethodPut)
+	resp, err := srv.Client().Do(req)
+	if err != nil {
+		t.Fatalf("OPTIONS /api/v1/entries/2026-03-07 failed: %v", err)
+	}
+	readBody(t, resp)
+	if resp.StatusCode != http.StatusNoContent || resp.Header.Get("Access-Control-Allow-Origin") != "*" ||
+		!strings.Contains(resp.Header.Get("Access-Control-Allow-Methods"), http.MethodPut) ||
+		!strings.Contains(resp.Header.Get("Access-Control-Allow-Headers"), "Authorization") {
+		t.Errorf("OPTIONS /api/v1/entries/2026-03-07 = %d, %v; want a preflight response", resp.StatusCode, resp.Header)
+	}
+	resp, err = srv.Client().Get(srv.URL + "/api/v1/entries")
+	if err != nil {
+		t.Fatalf("GET /api/v1/entries failed: %v", err)
+	}
+	body := readBody(t, resp)
+	if resp.StatusCode != http.StatusUnauthorized || resp.Header.Get("Access-Control-Allow-Origin") != "*" ||
+		resp.Header.Get("Content-Type") != "application/json" || !strings.Contains(body, `"status":401`) {
+		t.Errorf("GET /api/v1/entries signed out = %d, %s; want a 401 error envelope", resp.StatusCode, body)
+	}
+}
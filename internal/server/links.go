@@ -0,0 +1,49 @@
+package server
+
+import (
+	"context"
+	"log/slog"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+
+	"derrclan.com/moravian-soap/internal/journal"
+	"derrclan.com/moravian-soap/internal/store"
+)
+
+// saveJournalLinks detects links to other entries in a saved journal entry and records them.
+func (s *Server) saveJournalLinks(ctx context.Context, userID int64, soapData *store.SOAPData) {
+	links := journalLinks(soapData)
+	if err := s.store.SaveJournalLinks(ctx, userID, soapData.Date, links); err != nil {
+		slog.Error("failed to save journal links", "date", soapData.Date, "error", err)
+	}
+}
+
+// journalLinks returns the dates of the other entries a journal entry links to.
+func journalLinks(soapData *store.SOAPData) []string {
+	var text strings.Builder
+	for _, key := range slices.Sorted(maps.Keys(soapData.Sections)) {
+		text.WriteString(soapData.Sections[key])
+		text.WriteString("\n")
+	}
+	return journal.FindLinks(soapData.Date, text.String())
+}
+
+// handleLinkedEntries renders the linked entries partial for a date (for HTMX).
+func (s *Server) handleLinkedEntries(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(userContextKey).(*store.User)
+	dateStr := requestDate(r).String()
+
+	linked, err := s.store.GetLinkedEntries(r.Context(), user.ID, dateStr)
+	if err != nil {
+		slog.Error("failed to get linked entries", "date", dateStr, "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	if err := s.tmpl.ExecuteTemplate(w, "linked_entries.gotmpl", map[string]any{"linkedEntries": linked}); err != nil {
+		slog.Error("failed to execute linked entries template", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,196 @@
+package server
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"derrclan.com/moravian-soap/internal/store"
+)
+
+// lockOption is a privacy lock period users can choose.
+type lockOption struct {
+	Minutes int
+	Label   string
+}
+
+// lockOptions are the privacy lock periods users can choose from. Zero minutes turns the lock off.
+var lockOptions = []lockOption{
+	{0, "Never"},
+	{5, "After 5 minutes"},
+	{15, "After 15 minutes"},
+	{30, "After 30 minutes"},
+	{60, "After 1 hour"},
+	{240, "After 4 hours"},
+}
+
+// lockTouchInterval is how out of date a session's recorded activity may be before a request updates
+// it, so that not every request writes to the database. Sessions may lock up to this much early.
+const lockTouchInterval = time.Minute
+
+// lockMiddleware requires users who have turned on the privacy lock to re-authenticate once their session
+// has gone unused for the lock period, even though the session is still valid. Pages redirect to the
+// unlock page; other requests, including those to the API, fail with 401 and an HX-Redirect header so
+// HTMX follows to it.
+func (s *Server) lockMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cookie, err := r.Cookie("session_token")
+		if err != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		user := r.Context().Value(userContextKey).(*store.User)
+
+		prefs, err := s.store.GetPreferences(r.Context(), user.ID)
+		if err != nil {
+			slog.Error("failed to get preferences", "user_id", user.ID, "error", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		if prefs.LockAfterMinutes == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		lastActive, err := s.store.GetSessionActivity(r.Context(), cookie.Value)
+		if err != nil {
+			slog.Error("failed to get session activity", "user_id", user.ID, "error", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		now := s.clock.Now()
+		if !lastActive.IsZero() && now.Sub(lastActive) > time.Duration(prefs.LockAfterMinutes)*time.Minute {
+			unlockURL := "/unlock?next=" + url.QueryEscape(r.URL.RequestURI())
+			if r.Method == http.MethodGet && !isHTMX(r) && !strings.HasPrefix(r.URL.Path, "/api/") {
+				http.Redirect(w, r, unlockURL, http.StatusSeeOther)
+				return
+			}
+			w.Header().Set("HX-Redirect", "/unlock")
+			http.Error(w, "Locked", http.StatusUnauthorized)
+			return
+		}
+
+		if now.Sub(lastActive) > lockTouchInterval {
+			if err := s.store.TouchSession(r.Context(), cookie.Value, now); err != nil {
+				slog.Error("failed to record session activity", "user_id", user.ID, "error", err)
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// lockAfterMinutes returns the user's privacy lock period, or 0 if the lock is off or their preferences
+// cannot be loaded.
+func (s *Server) lockAfterMinutes(ctx context.Context, user *store.User) int {
+	prefs, err := s.store.GetPreferences(ctx, user.ID)
+	if err != nil {
+		slog.Error("failed to get preferences", "user_id", user.ID, "error", err)
+		return 0
+	}
+	return prefs.LockAfterMinutes
+}
+
+// localRedirect returns target if it is a path on this site, and "/" otherwise, so that redirecting to a
+// user-supplied URL cannot send the user elsewhere.
+func localRedirect(target string) string {
+	if !strings.HasPrefix(target, "/") || strings.HasPrefix(target, "//") || strings.HasPrefix(target, "/\\") {
+		return "/"
+	}
+	return target
+}
+
+// handleUnlock asks a user whose session is locked for their password or a passkey, and unlocks the
+// session when the password is correct. Passkey sign-in replaces the session with a new one instead.
+func (s *Server) handleUnlock(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(userContextKey).(*store.User)
+	next := localRedirect(r.FormValue("next"))
+
+	var unlockErr string
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		cookie, err := r.Cookie("session_token")
+		if err != nil {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if _, err := s.authenticateUser(r.Context(), user.Email, r.FormValue("password")); err != nil {
+			slog.Warn("failed to unlock session", "user_id", user.ID, "error", err)
+			unlockErr = "Incorrect password"
+			w.WriteHeader(http.StatusUnauthorized)
+			break
+		}
+		if err := s.store.TouchSession(r.Context(), cookie.Value, s.clock.Now()); err != nil {
+			slog.Error("failed to record session activity", "user_id", user.ID, "error", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		http.Redirect(w, r, next, http.StatusSeeOther)
+		return
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	data := map[string]any{
+		"user":      user,
+		"next":      next,
+		"error":     unlockErr,
+		"CSRFToken": r.Context().Value(csrfContextKey).(string),
+		"Nonce":     r.Context().Value(nonceContextKey).(string),
+	}
+	if err := s.tmpl.ExecuteTemplate(w, "unlock.html", data); err != nil {
+		slog.Error("failed to execute unlock template", "error", err)
+	}
+}
+
+// handleSettingsPrivacy shows and saves the user's privacy lock period.
+func (s *Server) handleSettingsPrivacy(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(userContextKey).(*store.User)
+
+	prefs, err := s.store.GetPreferences(r.Context(), user.ID)
+	if err != nil {
+		slog.Error("failed to get preferences", "user_id", user.ID, "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	var saved bool
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		minutes, err := strconv.Atoi(r.FormValue("lock_after"))
+		if err != nil || !slices.ContainsFunc(lockOptions, func(o lockOption) bool { return o.Minutes == minutes }) {
+			http.Error(w, "Invalid lock period", http.StatusBadRequest)
+			return
+		}
+		prefs.LockAfterMinutes = minutes
+		if err := s.store.SavePreferences(r.Context(), user.ID, prefs); err != nil {
+			slog.Error("failed to save preferences", "user_id", user.ID, "error", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		saved = true
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	data := map[string]any{
+		"user":        user,
+		"prefs":       prefs,
+		"lockOptions": lockOptions,
+		"saved":       saved,
+		"CSRFToken":   r.Context().Value(csrfContextKey).(string),
+		"Nonce":       r.Context().Value(nonceContextKey).(string),
+	}
+	if err := s.tmpl.ExecuteTemplate(w, "settings_privacy.html", data); err != nil {
+		slog.Error("failed to execute settings_privacy template", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
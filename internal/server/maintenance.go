@@ -0,0 +1,133 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"derrclan.com/moravian-soap/internal/maintenance"
+	"derrclan.com/moravian-soap/internal/store"
+)
+
+// maintenanceSettingKey is the instance setting that keeps maintenance mode on across restarts.
+const maintenanceSettingKey = "maintenance"
+
+// drainTimeout is how long turning on maintenance mode waits for running background jobs to finish.
+const drainTimeout = 30 * time.Second
+
+// maintenanceExempt lists the paths, and path prefixes ending in a slash, that stay live in
+// maintenance mode: the health check, the static files and service worker, the offline page, signing
+// in and out, and the admin pages.
+var maintenanceExempt = []string{
+	"/healthz", "/web/", "/sw.js", "/offline", "/login", "/logout", "/passkeys/login/", "/admin/", "/emails/preview/",
+}
+
+// loadMaintenance turns maintenance mode on if it was left on when the server last stopped.
+func (s *Server) loadMaintenance(ctx context.Context) error {
+	settings, err := s.store.GetInstanceSettings(ctx)
+	if err != nil {
+		return fmt.Errorf("getting instance settings: %w", err)
+	}
+	if settings[maintenanceSettingKey] == "on" {
+		slog.Warn("starting in maintenance mode")
+		return maintenance.Enable(ctx)
+	}
+	return nil
+}
+
+// maintenanceMiddleware serves a notice with a 503 to requests for anything but the exempt paths
+// while maintenance mode is on.
+func (s *Server) maintenanceMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !maintenance.Enabled() || isMaintenanceExempt(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Set("Retry-After", "300")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		data := map[string]any{
+			"Nonce": r.Context().Value(nonceContextKey),
+		}
+		if err := s.tmpl.ExecuteTemplate(w, "maintenance.html", data); err != nil {
+			slog.Error("failed to execute maintenance template", "error", err)
+		}
+	})
+}
+
+// isMaintenanceExempt reports whether path stays live in maintenance mode.
+func isMaintenanceExempt(path string) bool {
+	for _, p := range maintenanceExempt {
+		if path == p || (strings.HasSuffix(p, "/") && strings.HasPrefix(path, p)) {
+			return true
+		}
+	}
+	return false
+}
+
+// handleHealthz reports whether the server can reach its database, for load balancers and uptime
+// checks. It answers even in maintenance mode.
+func (s *Server) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	if err := s.db.PingContext(r.Context()); err != nil {
+		slog.Error("health check failed", "error", err)
+		http.Error(w, "database unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	fmt.Fprintln(w, "ok")
+}
+
+// handleAdminMaintenance shows whether maintenance mode is on and lets the admin turn it on or off.
+// Turning it on waits for running background jobs to finish.
+func (s *Server) handleAdminMaintenance(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(userContextKey).(*store.User)
+
+	var success, errMsg string
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		enabled := r.FormValue("enabled") == "true"
+		value := ""
+		if enabled {
+			value = "on"
+		}
+		if err := s.store.SaveInstanceSettings(r.Context(), map[string]string{maintenanceSettingKey: value}); err != nil {
+			slog.Error("failed to save maintenance mode", "error", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		if !enabled {
+			maintenance.Disable()
+			slog.Info("maintenance mode turned off", "user_id", user.ID)
+			success = "Maintenance mode is off, and the site is back for everyone."
+			break
+		}
+		ctx, cancel := context.WithTimeout(r.Context(), drainTimeout)
+		defer cancel()
+		if err := maintenance.Enable(ctx); err != nil {
+			slog.Warn("background jobs still running after turning on maintenance mode", "error", err)
+			errMsg = "Maintenance mode is on, but background jobs are still running. Wait a minute before starting work on the database."
+			break
+		}
+		slog.Info("maintenance mode turned on", "user_id", user.ID)
+		success = "Maintenance mode is on, and no background jobs are running."
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	data := map[string]any{
+		"user":      user,
+		"enabled":   maintenance.Enabled(),
+		"Success":   success,
+		"Error":     errMsg,
+		"CSRFToken": r.Context().Value(csrfContextKey).(string),
+		"Nonce":     r.Context().Value(nonceContextKey).(string),
+	}
+	if err := s.tmpl.ExecuteTemplate(w, "admin_maintenance.html", data); err != nil {
+		slog.Error("failed to execute admin_maintenance template", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
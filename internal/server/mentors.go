@@ -0,0 +1,254 @@
+package server
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"derrclan.com/moravian-soap/internal/civil"
+	"derrclan.com/moravian-soap/internal/email"
+	"derrclan.com/moravian-soap/internal/features"
+	"derrclan.com/moravian-soap/internal/store"
+)
+
+// maxMentorCommentLength is the longest comment a mentor may leave on an entry, in characters.
+const maxMentorCommentLength = 2000
+
+// menteeEntry is an entry shared with a mentor, with the comments left on it.
+type menteeEntry struct {
+	historyEntry
+	Comments []*store.MentorComment
+}
+
+// handleSettingsMentors lists the mentors the user shared their journal with and the journals shared
+// with them (GET), and grants an account read access to the entries between two dates (POST, with an
+// action of "grant") or revokes it (POST, with an action of "revoke"). Granting access to an account
+// that already has it changes the dates shared.
+func (s *Server) handleSettingsMentors(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(userContextKey).(*store.User)
+
+	var errMsg string
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		switch r.FormValue("action") {
+		case "grant":
+			errMsg = s.grantMentor(r, user)
+		case "revoke":
+			mentorID, err := strconv.ParseInt(r.FormValue("mentor_id"), 10, 64)
+			if err != nil {
+				http.Error(w, "Bad request", http.StatusBadRequest)
+				return
+			}
+			if err := s.store.DeleteMentorship(r.Context(), user.ID, mentorID); err != nil {
+				slog.Error("failed to revoke mentor", "user_id", user.ID, "mentor_id", mentorID, "error", err)
+				http.Error(w, "Internal server error", http.StatusInternalServerError)
+				return
+			}
+		default:
+			http.Error(w, "Unknown action", http.StatusBadRequest)
+			return
+		}
+		if errMsg == "" {
+			http.Redirect(w, r, "/settings/mentors", http.StatusSeeOther)
+			return
+		}
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	mentors, err := s.store.ListMentorships(r.Context(), user.ID)
+	if err != nil {
+		slog.Error("failed to list mentors", "user_id", user.ID, "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	mentees, err := s.store.ListMentees(r.Context(), user.ID)
+	if err != nil {
+		slog.Error("failed to list mentees", "user_id", user.ID, "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	data := map[string]any{
+		"user":      user,
+		"mentors":   mentors,
+		"mentees":   mentees,
+		"Error":     errMsg,
+		"CSRFToken": r.Context().Value(csrfContextKey).(string),
+		"Nonce":     r.Context().Value(nonceContextKey).(string),
+	}
+	if err := s.tmpl.ExecuteTemplate(w, "settings_mentors.html", data); err != nil {
+		slog.Error("failed to execute settings_mentors template", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
+
+// grantMentor grants the account with the email in the form read access to the user's entries between
+// the dates in the form, either of which may be blank. It returns a message for the user if the form is
+// invalid.
+func (s *Server) grantMentor(r *http.Request, user *store.User) string {
+	m := &store.Mentorship{UserID: user.ID, From: r.FormValue("from"), To: r.FormValue("to")}
+	for _, d := range []string{m.From, m.To} {
+		if _, err := civil.ParseDate(d); d != "" && err != nil {
+			return "Enter the dates as YYYY-MM-DD"
+		}
+	}
+	if m.From != "" && m.To != "" && m.From > m.To {
+		return "The first date shared must not be after the last"
+	}
+
+	mentor, err := s.store.GetUserByEmail(r.Context(), strings.TrimSpace(r.FormValue("email")))
+	if errors.Is(err, sql.ErrNoRows) {
+		return "There is no account with that email"
+	} else if err != nil {
+		slog.Error("failed to look up mentor", "user_id", user.ID, "error", err)
+		return "Failed to share your journal"
+	}
+	if mentor.ID == user.ID {
+		return "You cannot be your own mentor"
+	}
+	m.MentorID = mentor.ID
+	if err := s.store.SaveMentorship(r.Context(), m); err != nil {
+		slog.Error("failed to grant mentor", "user_id", user.ID, "mentor_id", mentor.ID, "error", err)
+		return "Failed to share your journal"
+	}
+	return ""
+}
+
+// handleMenteeJournal shows the mentor the entries a user shared with them, newest first, with the
+// comments left on each (GET), and adds the mentor's comment to one of the entries (POST), emailing the
+// user about it. Entries are read-only to the mentor. Users who have not shared their journal with the
+// mentor, or have revoked access, are not found.
+func (s *Server) handleMenteeJournal(w http.ResponseWriter, r *http.Request) {
+	mentor := r.Context().Value(userContextKey).(*store.User)
+	userID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+	m, err := s.store.GetMentorship(r.Context(), userID, mentor.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.NotFound(w, r)
+		return
+	} else if err != nil {
+		slog.Error("failed to get mentorship", "user_id", userID, "mentor_id", mentor.ID, "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	entries, err := s.store.ListSOAPData(r.Context(), userID)
+	if err != nil {
+		slog.Error("failed to list shared entries", "user_id", userID, "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	entries = slices.DeleteFunc(entries, func(d *store.SOAPData) bool { return !m.Covers(d.Date) })
+
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		date := r.FormValue("date")
+		comment := strings.TrimSpace(r.FormValue("comment"))
+		switch {
+		case !slices.ContainsFunc(entries, func(d *store.SOAPData) bool { return d.Date == date }):
+			http.NotFound(w, r)
+			return
+		case comment == "":
+			http.Error(w, "Comment is empty", http.StatusBadRequest)
+			return
+		case utf8.RuneCountInString(comment) > maxMentorCommentLength:
+			http.Error(w, "Comment is too long", http.StatusBadRequest)
+			return
+		}
+		c := &store.MentorComment{UserID: userID, Date: date, AuthorID: mentor.ID, Body: comment}
+		if err := s.store.AddMentorComment(r.Context(), c); err != nil {
+			slog.Error("failed to add comment", "user_id", userID, "mentor_id", mentor.ID, "date", date, "error", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		s.notifyMentorComment(r, m, c)
+		http.Redirect(w, r, fmt.Sprintf("/mentees/%d#entry-%s", userID, date), http.StatusSeeOther)
+		return
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// The entries are shown with the sections of the user who wrote them, not the mentor's.
+	schema := s.userJournalSchema(r.Context(), userID)
+	shared := make([]menteeEntry, 0, len(entries))
+	for _, d := range slices.Backward(entries) {
+		comments, err := s.store.ListMentorComments(r.Context(), userID, d.Date)
+		if err != nil {
+			slog.Error("failed to list comments", "user_id", userID, "date", d.Date, "error", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		shared = append(shared, menteeEntry{historyEntry: newHistoryEntry(d, schema), Comments: comments})
+	}
+
+	data := map[string]any{
+		"user":       mentor,
+		"mentorship": m,
+		"entries":    shared,
+		"maxLength":  maxMentorCommentLength,
+		"CSRFToken":  r.Context().Value(csrfContextKey).(string),
+		"Nonce":      r.Context().Value(nonceContextKey).(string),
+	}
+	if err := s.tmpl.ExecuteTemplate(w, "mentee_journal.html", data); err != nil {
+		slog.Error("failed to execute mentee_journal template", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
+
+// notifyMentorComment emails the user who shared their journal in m about the comment c, if email is
+// available to them. A failure to queue the email is logged, since the comment is saved anyway.
+func (s *Server) notifyMentorComment(r *http.Request, m *store.Mentorship, c *store.MentorComment) {
+	owner, err := s.store.GetUserByEmail(r.Context(), m.UserEmail)
+	if err != nil {
+		slog.Error("failed to look up user to notify of comment", "user_id", m.UserID, "error", err)
+		return
+	}
+	if owner.Features, err = s.store.GetFeatureOverrides(r.Context(), owner.ID); err != nil {
+		slog.Error("failed to get feature overrides", "user_id", owner.ID, "error", err)
+		return
+	}
+	if !featureEnabled(owner, features.Email) {
+		return
+	}
+	baseURL := os.Getenv("BASE_URL")
+	if baseURL == "" {
+		baseURL = "http://localhost:8080"
+	}
+	msg := email.MentorCommentEmail(m.MentorEmail, c.Date, c.Body, fmt.Sprintf("%s/?date=%s", baseURL, c.Date))
+	if err := email.Queue(r.Context(), s.store, owner.ID, owner.Email, msg); err != nil {
+		slog.Error("failed to queue comment email", "user_id", owner.ID, "error", err)
+	}
+}
+
+// handleMentorComments renders the partial listing the comments mentors left on the user's entry for a
+// date (for HTMX).
+func (s *Server) handleMentorComments(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(userContextKey).(*store.User)
+	dateStr := requestDate(r).String()
+
+	comments, err := s.store.ListMentorComments(r.Context(), user.ID, dateStr)
+	if err != nil {
+		slog.Error("failed to list comments", "user_id", user.ID, "date", dateStr, "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if err := s.tmpl.ExecuteTemplate(w, "mentor_comments.gotmpl", map[string]any{"mentorComments": comments}); err != nil {
+		slog.Error("failed to execute mentor comments template", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
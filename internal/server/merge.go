@@ -0,0 +1,125 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"derrclan.com/moravian-soap/internal/store"
+)
+
+// mergeSection is a section of a journal entry whose edits conflict, shown in the merge partial.
+type mergeSection struct {
+	Key, Name string
+	// Mine is the user's text, Theirs the text as saved, and Merged the text the merge starts from.
+	Mine, Theirs, Merged string
+}
+
+// Conflicting reports whether the two versions of the section differ.
+func (s mergeSection) Conflicting() bool {
+	return s.Mine != s.Theirs
+}
+
+// renderMerge responds with status and the merge partial for the user's edits of an entry, based on
+// baseRevision, against the entry as it is now saved. Each section's merge starts from the user's text,
+// or the saved text if theirs is empty, and the selected verses of both are kept.
+func (s *Server) renderMerge(w http.ResponseWriter, r *http.Request, mine *store.SOAPData, baseRevision int64, status int) {
+	user := r.Context().Value(userContextKey).(*store.User)
+	theirs, err := s.store.GetSOAPData(r.Context(), user.ID, mine.Date)
+	if err != nil {
+		slog.Error("failed to get SOAP data", "date", mine.Date, "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	var sections []mergeSection
+	for _, sec := range s.journalSchema(r.Context()) {
+		m := mergeSection{Key: sec.Key, Name: sec.Name, Mine: mine.Sections[sec.Key], Theirs: theirs.Sections[sec.Key]}
+		m.Merged = m.Mine
+		if m.Merged == "" {
+			m.Merged = m.Theirs
+		}
+		sections = append(sections, m)
+	}
+	verses := slices.Clone(mine.SelectedVerses)
+	for _, v := range theirs.SelectedVerses {
+		if !slices.Contains(verses, v) {
+			verses = append(verses, v)
+		}
+	}
+	versesJSON, err := json.Marshal(verses)
+	if err != nil {
+		slog.Error("failed to marshal selected verses", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	data := map[string]any{
+		"date":           mine.Date,
+		"baseRevision":   baseRevision,
+		"theirsRevision": theirs.Revision,
+		"sections":       sections,
+		"selectedVerses": string(versesJSON),
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	if err := s.tmpl.ExecuteTemplate(w, "soap_merge.gotmpl", data); err != nil {
+		slog.Error("failed to execute soap merge template", "error", err)
+	}
+}
+
+// handleMergeSOAP saves the user's merge of their conflicting edits with the entry as saved, recording
+// the revisions of both. If the entry was saved yet again meanwhile, the merge partial is shown anew
+// against the latest text; otherwise an entryMerged event tells the page to reload the entry.
+func (s *Server) handleMergeSOAP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	user := r.Context().Value(userContextKey).(*store.User)
+
+	baseRevision, err := strconv.ParseInt(r.FormValue("base_revision"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid revision", http.StatusBadRequest)
+		return
+	}
+	theirsRevision, err := strconv.ParseInt(r.FormValue("theirs_revision"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid revision", http.StatusBadRequest)
+		return
+	}
+	merged := &store.SOAPData{Date: requestDate(r).String(), Sections: map[string]string{}}
+	if err := json.Unmarshal([]byte(r.FormValue("selected_verses")), &merged.SelectedVerses); err != nil {
+		http.Error(w, "Invalid selected verses", http.StatusBadRequest)
+		return
+	}
+	verses, err := s.selectableVerses(r.Context(), user, merged.Date)
+	if err != nil {
+		slog.Error("failed to get selectable verses", "user_id", user.ID, "date", merged.Date, "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if merged.SelectedVerses, err = verses.Select(merged.SelectedVerses); err != nil {
+		http.Error(w, "Invalid selected verses", http.StatusBadRequest)
+		return
+	}
+	for _, sec := range s.journalSchema(r.Context()) {
+		merged.Sections[sec.Key] = r.FormValue("section-" + sec.Key)
+	}
+
+	_, err = s.store.MergeSOAPData(r.Context(), user.ID, merged, baseRevision, theirsRevision)
+	if errors.Is(err, store.ErrConflict) {
+		s.renderMerge(w, r, merged, baseRevision, http.StatusOK)
+		return
+	}
+	if err != nil {
+		slog.Error("failed to merge SOAP data", "date", merged.Date, "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	s.publishEntrySaved(r.Context(), user.ID, nil, merged)
+	w.Header().Set("HX-Trigger", "entryMerged")
+}
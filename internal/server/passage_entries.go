@@ -0,0 +1,273 @@
+package server
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"derrclan.com/moravian-soap/internal/civil"
+	"derrclan.com/moravian-soap/internal/export"
+	"derrclan.com/moravian-soap/internal/journal"
+	"derrclan.com/moravian-soap/internal/store"
+)
+
+// maxReferenceLength limits the length of the reference a passage entry is written on.
+const maxReferenceLength = 100
+
+// validatePassageEntry checks a passage entry from a form or backup before it is saved. Unlike journal
+// entries, passage entries may be dated in years without daily texts.
+func validatePassageEntry(entry *store.PassageEntry) error {
+	if entry.Reference == "" || len(entry.Reference) > maxReferenceLength {
+		return fmt.Errorf("invalid passage reference %q", entry.Reference)
+	}
+	if entry.Date != "" {
+		if _, err := civil.ParseDate(entry.Date); err != nil {
+			return fmt.Errorf("invalid passage entry date %q: %w", entry.Date, err)
+		}
+	}
+	for key := range entry.Sections {
+		if !journal.ValidSectionKey(key) {
+			return fmt.Errorf("invalid section %q in entry on %s", key, entry.Reference)
+		}
+	}
+	return nil
+}
+
+// passageEntryTitle returns the heading of a passage entry: its reference, followed by its date if
+// it has one.
+func passageEntryTitle(entry *store.PassageEntry) string {
+	if entry.Date == "" {
+		return entry.Reference
+	}
+	return fmt.Sprintf("%s (%s)", entry.Reference, entry.Date)
+}
+
+// handlePassageEntries lists the user's passage entries (GET) or starts a new one (POST). A new
+// entry's reference is looked up in the ESV API first, so that only passages that exist are saved,
+// under their canonical reference.
+func (s *Server) handlePassageEntries(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(userContextKey).(*store.User)
+
+	var errMsg string
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		entry := &store.PassageEntry{
+			Reference: strings.TrimSpace(r.FormValue("reference")),
+			Date:      strings.TrimSpace(r.FormValue("date")),
+			Sections:  map[string]string{},
+		}
+		if err := validatePassageEntry(entry); err != nil {
+			errMsg = "Enter a passage reference, such as John 3:16-21, and an optional date"
+			break
+		}
+		passages, err := s.fetchPassagesWithCache(r.Context(), []string{entry.Reference}, s.passageOptions(r.Context()))
+		if err != nil {
+			slog.Error("failed to look up passage for entry", "reference", entry.Reference, "error", err)
+			errMsg = "Failed to look up the passage. Please try again."
+			break
+		}
+		if len(passages.Passages) == 0 {
+			errMsg = fmt.Sprintf("No passage was found for %q", entry.Reference)
+			break
+		}
+		if len(passages.PassageMeta) > 0 && passages.PassageMeta[0].Canonical != "" {
+			entry.Reference = passages.PassageMeta[0].Canonical
+		}
+		if errMsg, err = s.quotaExceeded(r.Context(), user.ID, passageEntrySize(entry)); err != nil {
+			slog.Error("failed to check storage quota", "user_id", user.ID, "error", err)
+			errMsg = "Failed to create the entry"
+		}
+		if errMsg != "" {
+			break
+		}
+		if err := s.store.CreatePassageEntry(r.Context(), user.ID, entry); err != nil {
+			slog.Error("failed to create passage entry", "user_id", user.ID, "error", err)
+			errMsg = "Failed to create the entry"
+			break
+		}
+		http.Redirect(w, r, fmt.Sprintf("/passages/%d", entry.ID), http.StatusSeeOther)
+		return
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	entries, err := s.store.ListPassageEntries(r.Context(), user.ID)
+	if err != nil {
+		slog.Error("failed to list passage entries", "user_id", user.ID, "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	data := map[string]any{
+		"user":      user,
+		"entries":   entries,
+		"Error":     errMsg,
+		"CSRFToken": r.Context().Value(csrfContextKey).(string),
+		"Nonce":     r.Context().Value(nonceContextKey).(string),
+	}
+	if err := s.tmpl.ExecuteTemplate(w, "passages.html", data); err != nil {
+		slog.Error("failed to execute passages template", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
+
+// getPassageEntry returns the passage entry named by the request path, writing an error response and
+// returning nil if there is none.
+func (s *Server) getPassageEntry(w http.ResponseWriter, r *http.Request, user *store.User) *store.PassageEntry {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return nil
+	}
+	entry, err := s.store.GetPassageEntry(r.Context(), user.ID, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.NotFound(w, r)
+		return nil
+	} else if err != nil {
+		slog.Error("failed to get passage entry", "id", id, "user_id", user.ID, "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return nil
+	}
+	return entry
+}
+
+// handlePassageEntry shows a passage entry with its passage (GET), and saves or deletes it (POST).
+func (s *Server) handlePassageEntry(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(userContextKey).(*store.User)
+	entry := s.getPassageEntry(w, r, user)
+	if entry == nil {
+		return
+	}
+	schema := s.journalSchema(r.Context())
+
+	var saved bool
+	var errMsg string
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		if r.FormValue("action") == "delete" {
+			if err := s.store.DeletePassageEntry(r.Context(), user.ID, entry.ID); err != nil {
+				slog.Error("failed to delete passage entry", "id", entry.ID, "user_id", user.ID, "error", err)
+				http.Error(w, "Internal server error", http.StatusInternalServerError)
+				return
+			}
+			http.Redirect(w, r, "/passages", http.StatusSeeOther)
+			return
+		}
+
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, "Bad request", http.StatusBadRequest)
+			return
+		}
+		size := passageEntrySize(entry)
+		entry.Date = strings.TrimSpace(r.PostForm.Get("date"))
+		// Sections missing from the form, such as those of a replaced schema, are kept as they were.
+		for _, e := range schema.Entries(entry.Sections) {
+			if values, ok := r.PostForm[e.Key]; ok {
+				entry.Sections[e.Key] = strings.Join(values, "")
+			}
+		}
+		if err := validatePassageEntry(entry); err != nil {
+			errMsg = "Enter the date as YYYY-MM-DD, or leave it blank"
+			break
+		}
+		var err error
+		if errMsg, err = s.quotaExceeded(r.Context(), user.ID, passageEntrySize(entry)-size); err != nil {
+			slog.Error("failed to check storage quota", "user_id", user.ID, "error", err)
+			errMsg = "Failed to save the entry"
+		}
+		if errMsg != "" {
+			break
+		}
+		if err := s.store.UpdatePassageEntry(r.Context(), user.ID, entry); err != nil {
+			slog.Error("failed to save passage entry", "id", entry.ID, "user_id", user.ID, "error", err)
+			errMsg = "Failed to save the entry"
+			break
+		}
+		saved = true
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	passages, err := s.fetchPassagesWithCache(r.Context(), []string{entry.Reference}, s.passageOptions(r.Context()))
+	if err != nil {
+		slog.Error("failed to fetch passage for entry", "reference", entry.Reference, "error", err)
+	}
+
+	data := map[string]any{
+		"user":      user,
+		"entry":     entry,
+		"title":     passageEntryTitle(entry),
+		"sections":  schema.Entries(entry.Sections),
+		"esvData":   passages,
+		"saved":     saved,
+		"Error":     errMsg,
+		"CSRFToken": r.Context().Value(csrfContextKey).(string),
+		"Nonce":     r.Context().Value(nonceContextKey).(string),
+	}
+	if err := s.tmpl.ExecuteTemplate(w, "passage_entry.html", data); err != nil {
+		slog.Error("failed to execute passage entry template", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
+
+// handlePassageEntryExport downloads a passage entry as HTML or, with format=markdown, Markdown.
+func (s *Server) handlePassageEntryExport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	user := r.Context().Value(userContextKey).(*store.User)
+	entry := s.getPassageEntry(w, r, user)
+	if entry == nil {
+		return
+	}
+
+	passages, err := s.prerenderPassages(r.Context(), [][]string{{entry.Reference}}, s.passageOptions(r.Context()))
+	if err != nil {
+		slog.Error("failed to fetch passage for export", "reference", entry.Reference, "error", err)
+		http.Error(w, fmt.Sprintf("Error loading %s", entry.Reference), http.StatusInternalServerError)
+		return
+	}
+
+	var exporter export.Exporter
+	var filename string
+	if r.URL.Query().Get("format") == "markdown" {
+		exporter, err = export.NewMarkdownExporter()
+		filename = fmt.Sprintf("soap-passage-%d.md", entry.ID)
+	} else {
+		exporter, err = newHTMLExporter()
+		filename = fmt.Sprintf("soap-passage-%d.html", entry.ID)
+	}
+	if err != nil {
+		slog.Error("failed to create exporter", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	// The exporters head an entry with its date; a passage entry is headed by its reference instead.
+	soapData := &store.SOAPData{Date: passageEntryTitle(entry), Sections: entry.Sections, SelectedVerses: []string{}}
+	w.Header().Set("Content-Type", exporter.ContentType())
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+	if err := exporter.Export(r.Context(), w, soapData, s.journalSchema(r.Context()), strings.Join(passages[0].Passages, "\n")); err != nil {
+		slog.Error("failed to export passage entry", "id", entry.ID, "error", err)
+	}
+}
+
+// passageEntrySize returns the space a passage entry takes up in its user's data, as counted against
+// their storage quota.
+func passageEntrySize(entry *store.PassageEntry) int64 {
+	size := len(entry.Reference)
+	if sections, err := json.Marshal(entry.Sections); err == nil {
+		size += len(sections)
+	}
+	return int64(size)
+}
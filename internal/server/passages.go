@@ -0,0 +1,127 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"derrclan.com/moravian-soap/internal/config"
+	"derrclan.com/moravian-soap/internal/esv"
+	"derrclan.com/moravian-soap/internal/esv/cache"
+)
+
+// prerenderFetchInterval spaces the ESV API fetches that fill gaps in a batch, so that a large export
+// does not burst through the API's rate limit.
+const prerenderFetchInterval = 250 * time.Millisecond
+
+// errESVQuotaReached is returned when a batch needs passages from the ESV API but the instance has
+// used its daily quota.
+var errESVQuotaReached = errors.New("ESV daily quota reached")
+
+// maxBatchReferences is the most references joined in one query to the ESV API when prerendering
+// passages that are not cached.
+const maxBatchReferences = 20
+
+// prerenderPassages resolves each set of references to verses rendered with opts, in the order given.
+// Cached sets are read in one query; the rest are fetched from the ESV API joined into batches of up to
+// maxBatchReferences references, a query per batch, spaced by prerenderFetchInterval and stopping once
+// the instance's daily quota is used up.
+func (s *Server) prerenderPassages(ctx context.Context, sets [][]string, opts esv.Options) ([]esv.Response, error) {
+	keys := make([]string, len(sets))
+	for i, references := range sets {
+		keys[i] = cache.Key(references, opts)
+	}
+	cached, err := s.passageCache().GetBatch(ctx, keys)
+	if err != nil {
+		return nil, fmt.Errorf("reading cached passages: %w", err)
+	}
+
+	var missing [][]string
+	pending := make(map[string]bool)
+	for i, references := range sets {
+		if _, ok := cached[keys[i]]; !ok && !pending[keys[i]] {
+			pending[keys[i]] = true
+			missing = append(missing, references)
+		}
+	}
+
+	for batches := 0; len(missing) > 0; batches++ {
+		n, size := 1, len(missing[0])
+		for n < len(missing) && size+len(missing[n]) <= maxBatchReferences {
+			size += len(missing[n])
+			n++
+		}
+		batch := missing[:n]
+		missing = missing[n:]
+
+		if batches > 0 {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(prerenderFetchInterval):
+			}
+		}
+		if fetchesFromESV(opts) {
+			used, err := s.store.GetInstanceESVUsage(ctx, s.clock.Now().UTC().Format(time.DateOnly))
+			if err != nil {
+				return nil, fmt.Errorf("getting instance ESV usage: %w", err)
+			}
+			if used >= config.Current().ESVDailyQuota {
+				return nil, errESVQuotaReached
+			}
+		}
+		responses, err := s.fetchPassageBatch(ctx, batch, opts)
+		if err != nil {
+			return nil, err
+		}
+		for i, references := range batch {
+			cached[cache.Key(references, opts)] = responses[i]
+		}
+	}
+
+	responses := make([]esv.Response, len(sets))
+	for i, key := range keys {
+		responses[i] = cached[key]
+	}
+	return responses, nil
+}
+
+// fetchPassageBatch fetches the sets of references joined in one query, splits the passages back into
+// a response for each set and caches each. If the response cannot be split, each set is fetched on
+// its own instead.
+func (s *Server) fetchPassageBatch(ctx context.Context, sets [][]string, opts esv.Options) ([]esv.Response, error) {
+	if len(sets) > 1 {
+		response, logCalls, err := s.fetchPassages(ctx, slices.Concat(sets...), opts)
+		if err != nil {
+			logCalls(apiCallNotStored)
+			return nil, err
+		}
+		split, err := response.Split(sets)
+		if err == nil {
+			outcome := apiCallStored
+			for i, references := range sets {
+				if err := s.passageCache().Put(ctx, references, opts, split[i]); err != nil {
+					slog.Error("failed to cache passages", "error", err)
+					outcome = apiCallNotStored
+				}
+			}
+			logCalls(outcome)
+			return split, nil
+		}
+		logCalls(apiCallNotStored)
+		slog.Warn("failed to split batched passages, fetching them one set at a time", "error", err)
+	}
+
+	responses := make([]esv.Response, len(sets))
+	for i, references := range sets {
+		response, err := s.fetchPassagesWithCache(ctx, references, opts)
+		if err != nil {
+			return nil, err
+		}
+		responses[i] = response
+	}
+	return responses, nil
+}
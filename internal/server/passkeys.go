@@ -0,0 +1,328 @@
+package server
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"derrclan.com/moravian-soap/internal/store"
+	"derrclan.com/moravian-soap/internal/webauthn"
+)
+
+// passkeyTimeout is how long the user has to complete a passkey ceremony.
+const passkeyTimeout = 5 * time.Minute
+
+// relyingParty returns the WebAuthn relying party for the site, derived from BASE_URL.
+func relyingParty() webauthn.RelyingParty {
+	baseURL := os.Getenv("BASE_URL")
+	if baseURL == "" {
+		baseURL = "http://localhost:8080"
+	}
+	rp := webauthn.RelyingParty{Name: "Daily Reading + SOAP", Origin: strings.TrimSuffix(baseURL, "/")}
+	if u, err := url.Parse(baseURL); err == nil {
+		rp.ID = u.Hostname()
+		rp.Origin = u.Scheme + "://" + u.Host
+	}
+	return rp
+}
+
+// userHandle returns the opaque WebAuthn user handle of a user.
+func userHandle(userID int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(userID, 10)))
+}
+
+// decodeBase64URL decodes the base64url-encoded binary fields sent by the browser.
+func decodeBase64URL(fields ...string) ([][]byte, error) {
+	decoded := make([][]byte, len(fields))
+	for i, f := range fields {
+		b, err := base64.RawURLEncoding.DecodeString(f)
+		if err != nil {
+			return nil, fmt.Errorf("decoding field %d: %w", i, err)
+		}
+		decoded[i] = b
+	}
+	return decoded, nil
+}
+
+// newPasskeyChallenge creates and saves the challenge for a passkey ceremony. A user ID of 0 starts a login.
+func (s *Server) newPasskeyChallenge(r *http.Request, userID int64) (string, error) {
+	challenge, err := webauthn.NewChallenge()
+	if err != nil {
+		return "", fmt.Errorf("creating challenge: %w", err)
+	}
+	if err := s.store.SaveWebAuthnChallenge(r.Context(), challenge, userID, s.clock.Now().Add(passkeyTimeout)); err != nil {
+		return "", fmt.Errorf("saving challenge: %w", err)
+	}
+	return challenge, nil
+}
+
+// consumePasskeyChallenge looks up the challenge the client data was signed for and checks that it
+// was issued to userID and has not expired. Each challenge can only be used once.
+func (s *Server) consumePasskeyChallenge(r *http.Request, clientDataJSON []byte, userID int64) (string, bool) {
+	challenge, err := webauthn.ChallengeOf(clientDataJSON)
+	if err != nil {
+		return "", false
+	}
+	issuedTo, expiresAt, err := s.store.ConsumeWebAuthnChallenge(r.Context(), challenge)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			slog.Error("failed to consume passkey challenge", "error", err)
+		}
+		return "", false
+	}
+	return challenge, issuedTo == userID && s.clock.Now().Before(expiresAt)
+}
+
+// writeJSON writes v as a JSON response.
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		slog.Error("failed to encode JSON response", "error", err)
+	}
+}
+
+// handlePasskeyRegisterBegin returns the options for creating a passkey for the signed-in user.
+func (s *Server) handlePasskeyRegisterBegin(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	user := r.Context().Value(userContextKey).(*store.User)
+
+	challenge, err := s.newPasskeyChallenge(r, user.ID)
+	if err != nil {
+		slog.Error("failed to create passkey challenge", "user_id", user.ID, "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	creds, err := s.store.ListWebAuthnCredentials(r.Context(), user.ID)
+	if err != nil {
+		slog.Error("failed to list passkeys", "user_id", user.ID, "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	exclude := make([]map[string]string, 0, len(creds))
+	for _, c := range creds {
+		exclude = append(exclude, map[string]string{"type": "public-key", "id": base64.RawURLEncoding.EncodeToString(c.CredentialID)})
+	}
+
+	rp := relyingParty()
+	writeJSON(w, map[string]any{
+		"challenge": challenge,
+		"rp":        map[string]string{"id": rp.ID, "name": rp.Name},
+		"user":      map[string]string{"id": userHandle(user.ID), "name": user.Email, "displayName": user.Email},
+		"pubKeyCredParams": []map[string]any{
+			{"type": "public-key", "alg": webauthn.AlgES256},
+			{"type": "public-key", "alg": webauthn.AlgRS256},
+		},
+		"authenticatorSelection": map[string]string{"residentKey": "required", "userVerification": "required"},
+		"attestation":            "none",
+		"excludeCredentials":     exclude,
+		"timeout":                passkeyTimeout.Milliseconds(),
+	})
+}
+
+type passkeyRegistration struct {
+	Name              string `json:"name"`
+	ClientDataJSON    string `json:"clientDataJSON"`
+	AttestationObject string `json:"attestationObject"`
+}
+
+// handlePasskeyRegisterFinish verifies a newly created passkey and saves it for the signed-in user.
+func (s *Server) handlePasskeyRegisterFinish(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	user := r.Context().Value(userContextKey).(*store.User)
+
+	var req passkeyRegistration
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+	fields, err := decodeBase64URL(req.ClientDataJSON, req.AttestationObject)
+	if err != nil {
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+	clientDataJSON, attestationObject := fields[0], fields[1]
+
+	challenge, ok := s.consumePasskeyChallenge(r, clientDataJSON, user.ID)
+	if !ok {
+		http.Error(w, "Passkey registration expired, please try again", http.StatusBadRequest)
+		return
+	}
+	verified, err := relyingParty().VerifyRegistration(challenge, clientDataJSON, attestationObject)
+	if err != nil {
+		slog.Warn("passkey registration failed verification", "user_id", user.ID, "error", err)
+		http.Error(w, "Passkey could not be verified", http.StatusBadRequest)
+		return
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		name = "Passkey"
+	}
+	cred := &store.WebAuthnCredential{
+		UserID:       user.ID,
+		Name:         name,
+		CredentialID: verified.ID,
+		PublicKey:    verified.PublicKey,
+		SignCount:    verified.SignCount,
+	}
+	if err := s.store.CreateWebAuthnCredential(r.Context(), cred); err != nil {
+		slog.Error("failed to save passkey", "user_id", user.ID, "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, map[string]string{"status": "success"})
+}
+
+// handlePasskeyLoginBegin returns the options for signing in with a passkey.
+func (s *Server) handlePasskeyLoginBegin(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	challenge, err := s.newPasskeyChallenge(r, 0)
+	if err != nil {
+		slog.Error("failed to create passkey challenge", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, map[string]any{
+		"challenge":        challenge,
+		"rpId":             relyingParty().ID,
+		"userVerification": "required",
+		"timeout":          passkeyTimeout.Milliseconds(),
+	})
+}
+
+type passkeyAssertion struct {
+	ID                string `json:"id"`
+	ClientDataJSON    string `json:"clientDataJSON"`
+	AuthenticatorData string `json:"authenticatorData"`
+	Signature         string `json:"signature"`
+	UserHandle        string `json:"userHandle"`
+	Timezone          string `json:"timezone"`
+}
+
+// handlePasskeyLoginFinish verifies a passkey assertion and signs the user in.
+func (s *Server) handlePasskeyLoginFinish(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req passkeyAssertion
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+	fields, err := decodeBase64URL(req.ID, req.ClientDataJSON, req.AuthenticatorData, req.Signature)
+	if err != nil {
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+	credentialID, clientDataJSON, authData, signature := fields[0], fields[1], fields[2], fields[3]
+
+	challenge, ok := s.consumePasskeyChallenge(r, clientDataJSON, 0)
+	if !ok {
+		http.Error(w, "Sign in expired, please try again", http.StatusBadRequest)
+		return
+	}
+	cred, err := s.store.GetWebAuthnCredential(r.Context(), credentialID)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			slog.Error("failed to get passkey", "error", err)
+		}
+		http.Error(w, "Unknown passkey", http.StatusUnauthorized)
+		return
+	}
+	if req.UserHandle != "" && req.UserHandle != userHandle(cred.UserID) {
+		http.Error(w, "Unknown passkey", http.StatusUnauthorized)
+		return
+	}
+
+	verified := &webauthn.Credential{ID: cred.CredentialID, PublicKey: cred.PublicKey, SignCount: cred.SignCount}
+	signCount, err := relyingParty().VerifyAssertion(challenge, verified, clientDataJSON, authData, signature)
+	if err != nil {
+		slog.Warn("passkey sign in failed verification", "user_id", cred.UserID, "credential", cred.ID, "error", err)
+		http.Error(w, "Passkey could not be verified", http.StatusUnauthorized)
+		return
+	}
+	if err := s.store.UpdateWebAuthnSignCount(r.Context(), cred.ID, signCount); err != nil {
+		slog.Error("failed to update passkey", "credential", cred.ID, "error", err)
+	}
+
+	if req.Timezone != "" {
+		if err := s.store.UpdateUserTimezone(r.Context(), cred.UserID, req.Timezone); err != nil {
+			slog.Error("failed to update user timezone", "error", err, "user_id", cred.UserID)
+		}
+	}
+
+	sessionToken, err := s.createSession(r.Context(), cred.UserID)
+	if err != nil {
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	s.setSessionCookie(w, r, sessionToken)
+	writeJSON(w, map[string]string{"redirect": "/"})
+}
+
+// handleSettingsPasskeys shows the user's passkeys and removes them.
+func (s *Server) handleSettingsPasskeys(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(userContextKey).(*store.User)
+
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		if r.FormValue("action") != "delete" {
+			http.Error(w, "Bad request", http.StatusBadRequest)
+			return
+		}
+		id, err := strconv.ParseInt(r.FormValue("id"), 10, 64)
+		if err != nil {
+			http.Error(w, "Bad request", http.StatusBadRequest)
+			return
+		}
+		if err := s.store.DeleteWebAuthnCredential(r.Context(), user.ID, id); err != nil {
+			slog.Error("failed to delete passkey", "user_id", user.ID, "credential", id, "error", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		http.Redirect(w, r, "/settings/passkeys", http.StatusSeeOther)
+		return
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	creds, err := s.store.ListWebAuthnCredentials(r.Context(), user.ID)
+	if err != nil {
+		slog.Error("failed to list passkeys", "user_id", user.ID, "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	data := map[string]any{
+		"user":      user,
+		"passkeys":  creds,
+		"CSRFToken": r.Context().Value(csrfContextKey).(string),
+		"Nonce":     r.Context().Value(nonceContextKey).(string),
+	}
+	if err := s.tmpl.ExecuteTemplate(w, "settings_passkeys.html", data); err != nil {
+		slog.Error("failed to execute settings_passkeys template", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
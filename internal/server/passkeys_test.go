@@ -0,0 +1,62 @@
+package server
+
+import (
+	"database/sql"
+	"net/http/httptest"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+
+	"derrclan.com/moravian-soap/internal/clock"
+	"derrclan.com/moravian-soap/internal/store/sqlite"
+)
+
+func TestRelyingParty(t *testing.T) {
+	t.Setenv("BASE_URL", "https://soap.example.com:8443/")
+	rp := relyingParty()
+	if rp.ID != "soap.example.com" || rp.Origin != "https://soap.example.com:8443" {
+		t.Errorf("relyingParty() = %+v", rp)
+	}
+}
+
+func TestConsumePasskeyChallenge(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("failed to open test db: %v", err)
+	}
+	defer db.Close()
+	srv := &Server{db: db, store: sqlite.New(db), clock: clock.Real}
+
+	createChallengesSQL := `
+	CREATE TABLE webauthn_challenges (
+		challenge TEXT PRIMARY KEY,
+		user_id INTEGER NOT NULL DEFAULT 0,
+		expires_at DATETIME NOT NULL
+	);`
+	if _, err := db.Exec(createChallengesSQL); err != nil {
+		t.Fatalf("failed to create table: %v", err)
+	}
+
+	r := httptest.NewRequest("POST", "/passkeys/register/finish", nil)
+	challenge, err := srv.newPasskeyChallenge(r, 1)
+	if err != nil {
+		t.Fatalf("newPasskeyChallenge failed: %v", err)
+	}
+	clientData := []byte(`{"type":"webauthn.create","challenge":"` + challenge + `"}`)
+
+	if _, ok := srv.consumePasskeyChallenge(r, clientData, 2); ok {
+		t.Error("expected challenge issued to another user to be rejected")
+	}
+
+	challenge, err = srv.newPasskeyChallenge(r, 1)
+	if err != nil {
+		t.Fatalf("newPasskeyChallenge failed: %v", err)
+	}
+	clientData = []byte(`{"type":"webauthn.create","challenge":"` + challenge + `"}`)
+	if got, ok := srv.consumePasskeyChallenge(r, clientData, 1); !ok || got != challenge {
+		t.Errorf("consumePasskeyChallenge() = %q, %v; want %q, true", got, ok, challenge)
+	}
+	if _, ok := srv.consumePasskeyChallenge(r, clientData, 1); ok {
+		t.Error("expected challenge to be usable only once")
+	}
+}
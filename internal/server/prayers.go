@@ -0,0 +1,130 @@
+package server
+
+import (
+	"database/sql"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"derrclan.com/moravian-soap/internal/store"
+)
+
+// handlePrayers renders the prayer list partial for a date (GET) or adds a prayer item to it (POST).
+// Forms posted without JavaScript are redirected to the day's journal instead.
+func (s *Server) handlePrayers(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(userContextKey).(*store.User)
+	dateStr := requestDate(r).String()
+
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		text := strings.TrimSpace(r.FormValue("text"))
+		if text == "" {
+			http.Error(w, "Prayer text is required", http.StatusBadRequest)
+			return
+		}
+		item := &store.PrayerItem{
+			UserID:      user.ID,
+			Text:        text,
+			Status:      store.PrayerOpen,
+			CreatedDate: dateStr,
+		}
+		if err := s.store.CreatePrayerItem(r.Context(), item); err != nil {
+			slog.Error("failed to create prayer item", "user_id", user.ID, "error", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if r.Method == http.MethodPost && !isHTMX(r) {
+		redirectToDay(w, r, dateStr)
+		return
+	}
+
+	s.renderPrayerList(w, r, user, dateStr)
+}
+
+// handlePrayerItem updates the text or status of a prayer item and re-renders the prayer list, or sends
+// a browser without JavaScript back to the journal for the date.
+func (s *Server) handlePrayerItem(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	user := r.Context().Value(userContextKey).(*store.User)
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+	dateStr := requestDate(r).String()
+
+	item, err := s.store.GetPrayerItem(r.Context(), user.ID, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.NotFound(w, r)
+		return
+	} else if err != nil {
+		slog.Error("failed to get prayer item", "id", id, "user_id", user.ID, "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	wasOpen := item.Status == store.PrayerOpen
+	item.Text = strings.TrimSpace(r.FormValue("text"))
+	item.Status = r.FormValue("status")
+	switch item.Status {
+	case store.PrayerOpen:
+		item.ResolvedDate = nil
+	case store.PrayerAnswered, store.PrayerDone:
+		// An item is resolved on the day it is closed; editing it later keeps that date.
+		if wasOpen {
+			item.ResolvedDate = &dateStr
+		}
+	default:
+		http.Error(w, "Invalid prayer status", http.StatusBadRequest)
+		return
+	}
+	if item.Text == "" {
+		http.Error(w, "Prayer text is required", http.StatusBadRequest)
+		return
+	}
+
+	if err := s.store.UpdatePrayerItem(r.Context(), item); errors.Is(err, sql.ErrNoRows) {
+		http.NotFound(w, r)
+		return
+	} else if err != nil {
+		slog.Error("failed to update prayer item", "id", id, "user_id", user.ID, "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if !isHTMX(r) {
+		redirectToDay(w, r, dateStr)
+		return
+	}
+
+	s.renderPrayerList(w, r, user, dateStr)
+}
+
+// renderPrayerList renders the prayer list partial with the items visible on a date.
+func (s *Server) renderPrayerList(w http.ResponseWriter, r *http.Request, user *store.User, dateStr string) {
+	items, err := s.store.GetPrayerItems(r.Context(), user.ID, dateStr)
+	if err != nil {
+		slog.Error("failed to get prayer items", "date", dateStr, "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	data := map[string]any{
+		"date":        dateStr,
+		"prayerItems": items,
+		"CSRFToken":   r.Context().Value(csrfContextKey).(string),
+	}
+	if err := s.tmpl.ExecuteTemplate(w, "prayer_list.gotmpl", data); err != nil {
+		slog.Error("failed to execute prayer list template", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
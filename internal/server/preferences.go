@@ -0,0 +1,231 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"derrclan.com/moravian-soap/internal/esv"
+	"derrclan.com/moravian-soap/internal/journal"
+	"derrclan.com/moravian-soap/internal/store"
+)
+
+// passageOptions returns the ESV rendering options preferred by the user in the context, in the
+// translation asked for by the request if it asked for one. Requests without a user, and users whose
+// preferences cannot be loaded, get the defaults.
+func (s *Server) passageOptions(ctx context.Context) esv.Options {
+	opts := s.preferredPassageOptions(ctx)
+	if t := requestTranslation(ctx); t != "" {
+		opts.Translation = t
+	}
+	return opts
+}
+
+// preferredPassageOptions returns the ESV rendering options and translation preferred by the user in
+// the context, or the defaults.
+func (s *Server) preferredPassageOptions(ctx context.Context) esv.Options {
+	user, ok := ctx.Value(userContextKey).(*store.User)
+	if !ok {
+		return esv.DefaultOptions()
+	}
+	prefs, err := s.store.GetPreferences(ctx, user.ID)
+	if err != nil {
+		slog.Error("failed to get preferences", "user_id", user.ID, "error", err)
+		return esv.DefaultOptions()
+	}
+	return esv.Options{
+		IncludeHeadings:       prefs.ESVHeadings,
+		IncludeVerseNumbers:   prefs.ESVVerseNumbers,
+		IncludeShortCopyright: prefs.ESVShortCopyright,
+		IndentPoetry:          prefs.ESVIndentPoetry,
+		RedLetter:             prefs.ESVRedLetter,
+		Translation:           prefs.Translation,
+	}
+}
+
+// journalSchema returns the journal sections of the user in the context. Requests without a user, and
+// users whose schema cannot be loaded, get the default SOAP sections.
+func (s *Server) journalSchema(ctx context.Context) journal.Schema {
+	user, ok := ctx.Value(userContextKey).(*store.User)
+	if !ok {
+		return journal.DefaultSchema()
+	}
+	return s.userJournalSchema(ctx, user.ID)
+}
+
+// userJournalSchema returns the journal sections of the user with userID, who need not be the user in
+// the context, or the default SOAP sections if their schema cannot be loaded.
+func (s *Server) userJournalSchema(ctx context.Context, userID int64) journal.Schema {
+	prefs, err := s.store.GetPreferences(ctx, userID)
+	if err != nil {
+		slog.Error("failed to get preferences", "user_id", userID, "error", err)
+		return journal.DefaultSchema()
+	}
+	schema, err := journal.ParseSchema(prefs.JournalSchema)
+	if err != nil {
+		slog.Error("failed to parse journal schema", "user_id", userID, "error", err)
+		return journal.DefaultSchema()
+	}
+	return schema
+}
+
+// handleSettingsReading shows and saves the user's passage display preferences and, when the form
+// posted is "weeks", the day their weeks start on and how they are numbered.
+func (s *Server) handleSettingsReading(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(userContextKey).(*store.User)
+
+	var saved bool
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		prefs, err := s.store.GetPreferences(r.Context(), user.ID)
+		if err != nil {
+			slog.Error("failed to get preferences", "user_id", user.ID, "error", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		if r.FormValue("form") == "weeks" {
+			switch r.FormValue("week_start") {
+			case "sunday":
+				prefs.WeekStart = time.Sunday
+			case "monday":
+				prefs.WeekStart = time.Monday
+			default:
+				http.Error(w, "Unknown first day of the week", http.StatusBadRequest)
+				return
+			}
+			prefs.ISOWeeks = r.FormValue("iso_weeks") == "on"
+		} else {
+			prefs.ESVHeadings = r.FormValue("headings") == "on"
+			prefs.ESVVerseNumbers = r.FormValue("verse_numbers") == "on"
+			prefs.ESVShortCopyright = r.FormValue("short_copyright") == "on"
+			prefs.ESVIndentPoetry = r.FormValue("indent_poetry") == "on"
+			prefs.ESVRedLetter = r.FormValue("red_letter") == "on"
+			if name := r.FormValue("translation"); name != "" {
+				t, ok := esv.Lookup(name)
+				if !ok {
+					http.Error(w, "Unknown translation", http.StatusBadRequest)
+					return
+				}
+				prefs.Translation = t.Name()
+			}
+		}
+		if err := s.store.SavePreferences(r.Context(), user.ID, prefs); err != nil {
+			slog.Error("failed to save preferences", "user_id", user.ID, "error", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		saved = true
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	prefs, err := s.store.GetPreferences(r.Context(), user.ID)
+	if err != nil {
+		slog.Error("failed to get preferences", "user_id", user.ID, "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	data := map[string]any{
+		"user":         user,
+		"prefs":        prefs,
+		"translations": esv.Translations(),
+		"saved":        saved,
+		"CSRFToken":    r.Context().Value(csrfContextKey).(string),
+		"Nonce":        r.Context().Value(nonceContextKey).(string),
+	}
+	if err := s.tmpl.ExecuteTemplate(w, "settings_reading.html", data); err != nil {
+		slog.Error("failed to execute settings_reading template", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
+
+// handleSettingsJournal shows and saves the sections the user journals in. Users choose one of the
+// preset schemas, or write their own as a JSON list of sections.
+func (s *Server) handleSettingsJournal(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(userContextKey).(*store.User)
+
+	prefs, err := s.store.GetPreferences(r.Context(), user.ID)
+	if err != nil {
+		slog.Error("failed to get preferences", "user_id", user.ID, "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	schema, err := journal.ParseSchema(prefs.JournalSchema)
+	if err != nil {
+		slog.Error("failed to parse journal schema", "user_id", user.ID, "error", err)
+		schema = journal.DefaultSchema()
+	}
+
+	var saved bool
+	var schemaErr string
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		custom := r.FormValue("custom")
+		var chosen journal.Schema
+		if p, ok := journal.Presets[r.FormValue("preset")]; ok {
+			chosen = p
+		} else if strings.TrimSpace(custom) == "" {
+			schemaErr = "A custom template needs at least one section."
+		} else if chosen, err = journal.ParseSchema(custom); err != nil {
+			schemaErr = err.Error()
+		}
+		if schemaErr != "" {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+		if schemaErr == "" {
+			encoded, err := json.Marshal(chosen)
+			if err != nil {
+				slog.Error("failed to encode journal schema", "user_id", user.ID, "error", err)
+				http.Error(w, "Internal server error", http.StatusInternalServerError)
+				return
+			}
+			prefs.JournalSchema = string(encoded)
+			if err := s.store.SavePreferences(r.Context(), user.ID, prefs); err != nil {
+				slog.Error("failed to save preferences", "user_id", user.ID, "error", err)
+				http.Error(w, "Internal server error", http.StatusInternalServerError)
+				return
+			}
+			schema = chosen
+			saved = true
+			s.updateAllCompleteness(r.Context(), user.ID, schema)
+		}
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	custom, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		slog.Error("failed to encode journal schema", "user_id", user.ID, "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	preset := schema.PresetName()
+	if schemaErr != "" {
+		custom = []byte(r.FormValue("custom"))
+		preset = ""
+	}
+
+	data := map[string]any{
+		"user":      user,
+		"schema":    schema,
+		"preset":    preset,
+		"presets":   journal.Presets,
+		"custom":    string(custom),
+		"saved":     saved,
+		"error":     schemaErr,
+		"CSRFToken": r.Context().Value(csrfContextKey).(string),
+		"Nonce":     r.Context().Value(nonceContextKey).(string),
+	}
+	if err := s.tmpl.ExecuteTemplate(w, "settings_journal.html", data); err != nil {
+		slog.Error("failed to execute settings_journal template", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
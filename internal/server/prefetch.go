@@ -0,0 +1,80 @@
+package server
+
+import (
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"derrclan.com/moravian-soap/internal/config"
+	"derrclan.com/moravian-soap/internal/dailytexts"
+	"derrclan.com/moravian-soap/internal/store"
+)
+
+// prefetchQuotaPercent is the share of the instance's daily ESV quota after which prefetching stops,
+// leaving the remainder for pages users actually open.
+const prefetchQuotaPercent = 80
+
+// prefetchThrottle limits how often each user may warm the ESV cache.
+type prefetchThrottle struct {
+	mu   sync.Mutex
+	last map[int64]time.Time
+}
+
+// allow reports whether the user may prefetch at time now given the minimum interval between prefetches,
+// and records the prefetch if so.
+func (p *prefetchThrottle) allow(userID int64, now time.Time, interval time.Duration) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.last == nil {
+		p.last = make(map[int64]time.Time)
+	}
+
+	if last, ok := p.last[userID]; ok && now.Sub(last) < interval {
+		return false
+	}
+
+	// Drop entries that can no longer throttle anyone so the map doesn't grow without bound.
+	for id, last := range p.last {
+		if now.Sub(last) >= interval {
+			delete(p.last, id)
+		}
+	}
+	p.last[userID] = now
+	return true
+}
+
+// handlePrefetch warms the ESV cache for the daily text on a date so that navigating to it is instant.
+// It is triggered when hovering the previous/next day controls and always responds with no content.
+func (s *Server) handlePrefetch(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(userContextKey).(*store.User)
+	dateStr := requestDate(r).String()
+
+	cfg := config.Current()
+	if !s.prefetches.allow(user.ID, s.clock.Now(), cfg.PrefetchInterval) {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	used, err := s.store.GetInstanceESVUsage(r.Context(), s.clock.Now().UTC().Format(time.DateOnly))
+	if err != nil {
+		slog.Error("failed to get instance ESV usage", "error", err)
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if used >= cfg.ESVDailyQuota*prefetchQuotaPercent/100 {
+		slog.Debug("skipping prefetch near ESV quota", "date", dateStr, "used", used)
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	dailyText, err := dailytexts.GetDailyText(dateStr)
+	if err != nil || dailyText == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if _, err := s.fetchPassagesWithCache(r.Context(), dailyText.Verses, s.passageOptions(r.Context())); err != nil {
+		slog.Warn("failed to prefetch verses", "date", dateStr, "error", err)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
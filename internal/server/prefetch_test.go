@@ -0,0 +1,27 @@
+package server
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPrefetchThrottle(t *testing.T) {
+	p := &prefetchThrottle{last: make(map[int64]time.Time)}
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	if !p.allow(1, now, time.Second) {
+		t.Fatal("expected first prefetch to be allowed")
+	}
+	if p.allow(1, now.Add(500*time.Millisecond), time.Second) {
+		t.Error("expected prefetch within interval to be throttled")
+	}
+	if !p.allow(2, now.Add(500*time.Millisecond), time.Second) {
+		t.Error("expected other users to be unaffected")
+	}
+	if !p.allow(1, now.Add(1500*time.Millisecond), time.Second) {
+		t.Error("expected prefetch after interval to be allowed")
+	}
+	if _, ok := p.last[2]; ok {
+		t.Error("expected expired entries to be dropped")
+	}
+}
@@ -0,0 +1,109 @@
+package server
+
+import (
+	"log/slog"
+	"net/http"
+
+	"derrclan.com/moravian-soap/internal/psalter"
+	"derrclan.com/moravian-soap/internal/store"
+)
+
+// handlePsalter renders the psalter partial for a date (GET) or marks the date's portion as read (POST).
+func (s *Server) handlePsalter(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(userContextKey).(*store.User)
+	dateStr := requestDate(r).String()
+
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		readings, err := s.store.GetPsalterReadings(r.Context(), user.ID)
+		if err != nil {
+			slog.Error("failed to get psalter readings", "user_id", user.ID, "error", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		portion, _ := psalter.PortionOn(dateStr, readings)
+		if err := s.store.SavePsalterReading(r.Context(), user.ID, &store.PsalterReading{Date: dateStr, Portion: portion}); err != nil {
+			slog.Error("failed to save psalter reading", "user_id", user.ID, "date", dateStr, "error", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	s.renderPsalter(w, r, user, dateStr)
+}
+
+// handlePsalterMode starts or stops psalter mode for the user and re-renders the psalter partial.
+func (s *Server) handlePsalterMode(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	user := r.Context().Value(userContextKey).(*store.User)
+	dateStr := requestDate(r).String()
+
+	var err error
+	switch r.FormValue("action") {
+	case "start":
+		err = s.store.StartPsalter(r.Context(), user.ID)
+	case "stop":
+		err = s.store.StopPsalter(r.Context(), user.ID)
+	default:
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		slog.Error("failed to change psalter mode", "user_id", user.ID, "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	s.renderPsalter(w, r, user, dateStr)
+}
+
+// renderPsalter renders the psalter partial: the portion for the date and the user's progress through
+// the Psalms, or an invitation to start psalter mode.
+func (s *Server) renderPsalter(w http.ResponseWriter, r *http.Request, user *store.User, dateStr string) {
+	enabled, err := s.store.IsPsalterReader(r.Context(), user.ID)
+	if err != nil {
+		slog.Error("failed to check psalter mode", "user_id", user.ID, "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	data := map[string]any{
+		"date":    dateStr,
+		"enabled": enabled,
+	}
+
+	if enabled {
+		readings, err := s.store.GetPsalterReadings(r.Context(), user.ID)
+		if err != nil {
+			slog.Error("failed to get psalter readings", "user_id", user.ID, "error", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		index, read := psalter.PortionOn(dateStr, readings)
+		portion := psalter.Get(index)
+
+		passage, err := s.fetchPassagesWithCache(r.Context(), []string{portion.Reference}, s.passageOptions(r.Context()))
+		if err != nil {
+			slog.Error("failed to fetch psalter portion", "reference", portion.Reference, "error", err)
+			http.Error(w, "Error loading psalm", http.StatusInternalServerError)
+			return
+		}
+
+		data["portion"] = portion
+		data["read"] = read
+		data["esvData"] = passage
+		data["psalmsRead"] = psalter.PsalmsRead(readings)
+		data["totalPsalms"] = psalter.TotalPsalms
+	}
+
+	if err := s.tmpl.ExecuteTemplate(w, "psalter.gotmpl", data); err != nil {
+		slog.Error("failed to execute psalter template", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
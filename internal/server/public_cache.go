@@ -0,0 +1,102 @@
+package server
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"derrclan.com/moravian-soap/internal/config"
+)
+
+// assetMaxAge is how long a static asset at a versioned URL may be kept. Its URL changes with it.
+const assetMaxAge = 365 * 24 * time.Hour
+
+// urlVersion returns the version of content at path for the "v" query parameter of its URL: a MAC of
+// both with the cache signing key, so that the URL changes whenever the content does.
+func urlVersion(path string, digest [sha256.Size]byte) string {
+	mac := hmac.New(sha256.New, []byte(config.Current().CacheSigningKey))
+	mac.Write([]byte(path))
+	mac.Write([]byte{0})
+	mac.Write(digest[:])
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)[:12])
+}
+
+// setPublicCache lets browsers and shared caches, such as a CDN or Caddy in front of the instance, keep
+// the response to a public request for content, with Cache-Control for browsers and Surrogate-Control
+// for the shared caches. A request for the version of content in its URL (see urlVersion) may be kept
+// for maxAge without revalidation; one for any other version, which only an old page or a forger asks
+// for, must not be kept; and one without a version may be kept for the configured PublicCacheTTL.
+func setPublicCache(w http.ResponseWriter, r *http.Request, digest [sha256.Size]byte, maxAge time.Duration) {
+	h := w.Header()
+	// Shared caches will not keep a response that sets a cookie, and public responses need none.
+	h.Del("Set-Cookie")
+	v := r.URL.Query().Get("v")
+	switch {
+	case v == "":
+		maxAge = config.Current().PublicCacheTTL
+		if maxAge <= 0 {
+			h.Set("Cache-Control", "public, no-cache")
+			h.Set("Surrogate-Control", "no-store")
+			return
+		}
+		h.Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(maxAge.Seconds())))
+	case hmac.Equal([]byte(v), []byte(urlVersion(r.URL.Path, digest))):
+		h.Set("Cache-Control", fmt.Sprintf("public, max-age=%d, immutable", int(maxAge.Seconds())))
+	default:
+		h.Set("Cache-Control", "no-store")
+		h.Set("Surrogate-Control", "no-store")
+		return
+	}
+	h.Set("Surrogate-Control", fmt.Sprintf("max-age=%d", int(maxAge.Seconds())))
+}
+
+// assetDigests memoizes the digests of the embedded static assets, by name, which never change while
+// the server runs.
+var assetDigests sync.Map
+
+// assetDigest returns the digest of the embedded static asset name, or false if there is none.
+func assetDigest(name string) ([sha256.Size]byte, bool) {
+	if d, ok := assetDigests.Load(name); ok {
+		return d.([sha256.Size]byte), true
+	}
+	content, err := fs.ReadFile(web, "web/"+name)
+	if err != nil {
+		return [sha256.Size]byte{}, false
+	}
+	d := sha256.Sum256(content)
+	assetDigests.Store(name, d)
+	return d, true
+}
+
+// assetURL returns the versioned URL of the embedded static asset name, e.g. "style.css", which may be
+// cached for a year, or its plain URL if there is no such asset.
+func assetURL(name string) string {
+	path := "/web/" + name
+	d, ok := assetDigest(name)
+	if !ok {
+		return path
+	}
+	return path + "?v=" + urlVersion(path, d)
+}
+
+// serveAssets serves the embedded static assets, in fsys, under /web/, letting public caches keep them.
+func serveAssets(fsys fs.FS) http.Handler {
+	files := http.StripPrefix("/web/", http.FileServer(http.FS(fsys)))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := strings.TrimPrefix(r.URL.Path, "/web/")
+		if d, ok := assetDigest(name); ok {
+			setPublicCache(w, r, d, assetMaxAge)
+		}
+		// Browsers expect the web app manifest with its own type, which Go does not know.
+		if strings.HasSuffix(name, ".webmanifest") {
+			w.Header().Set("Content-Type", "application/manifest+json")
+		}
+		files.ServeHTTP(w, r)
+	})
+}
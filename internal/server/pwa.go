@@ -0,0 +1,66 @@
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"net/http"
+)
+
+// shellAssets are the static assets of the app shell, which the service worker keeps so that the app
+// opens without a connection.
+var shellAssets = []string{"style.css", "app.js", "logic.js", "htmx.min.js", "favicon.png", "bible.svg", "manifest.webmanifest"}
+
+// handleServiceWorker serves the service worker, web/sw.js, from the root so that it controls the
+// whole app. It is prefixed with the URLs of the app shell and a version that changes with any of them
+// or with the worker, so that browsers install the new worker, and it with the new shell, on the next
+// visit after a deploy.
+func (s *Server) handleServiceWorker(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	script, err := fs.ReadFile(web, "web/sw.js")
+	if err != nil {
+		slog.Error("failed to read service worker", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	version := sha256.New()
+	version.Write(script)
+	shell := []string{"/offline"}
+	for _, name := range shellAssets {
+		d, _ := assetDigest(name)
+		version.Write(d[:])
+		shell = append(shell, assetURL(name))
+	}
+	// app.js imports logic.js by its plain URL.
+	shell = append(shell, "/web/logic.js")
+	urls, err := json.Marshal(shell)
+	if err != nil {
+		slog.Error("failed to encode app shell", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/javascript; charset=utf-8")
+	// Browsers check for a new worker at least daily anyway; no-cache makes every check see a deploy.
+	w.Header().Set("Cache-Control", "no-cache")
+	_, _ = fmt.Fprintf(w, "const VERSION = %q;\nconst SHELL = %s;\n\n", hex.EncodeToString(version.Sum(nil)[:8]), urls)
+	_, _ = w.Write(script)
+}
+
+// handleOffline renders the page the service worker shows for a page it does not have while offline.
+func (s *Server) handleOffline(w http.ResponseWriter, r *http.Request) {
+	data := map[string]any{
+		"Nonce": r.Context().Value(nonceContextKey),
+	}
+	if err := s.tmpl.ExecuteTemplate(w, "offline.html", data); err != nil {
+		slog.Error("failed to execute offline template", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,112 @@
+package server
+
+import (
+	"database/sql"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"derrclan.com/moravian-soap/internal/store"
+)
+
+// quietTimeDuration is how long a quiet time session lasts.
+const quietTimeDuration = 15 * time.Minute
+
+// quietTimeGrace is how early before its timer runs out by the server's clock a session may be
+// completed, allowing for the page's timer running slightly ahead.
+const quietTimeGrace = 5 * time.Second
+
+// handleQuietTime renders the quiet time partial for a date (GET), or starts, completes or stops a
+// session (POST, with an action of "start", "complete" or "stop"). The server keeps the timer, so a
+// session carries on when the page is reloaded, and it is only completed once its time has passed.
+// Without JavaScript, the browser is redirected to the journal once the action is taken.
+func (s *Server) handleQuietTime(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(userContextKey).(*store.User)
+	dateStr := requestDate(r).String()
+
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		now := s.clock.Now()
+		q, err := s.getQuietTime(r, user, dateStr)
+		if err != nil {
+			slog.Error("failed to get quiet time", "user_id", user.ID, "date", dateStr, "error", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		running := q != nil && q.Running(now)
+
+		switch r.FormValue("action") {
+		case "start":
+			if running {
+				break
+			}
+			q = &store.QuietTime{UserID: user.ID, Date: dateStr, StartedAt: now, Duration: quietTimeDuration}
+			err = s.store.StartQuietTime(r.Context(), q)
+		case "complete":
+			if q == nil || q.EndedAt != nil || now.Before(q.EndsAt().Add(-quietTimeGrace)) {
+				break
+			}
+			err = s.store.EndQuietTime(r.Context(), user.ID, q.ID, now, true)
+		case "stop":
+			if !running {
+				break
+			}
+			err = s.store.EndQuietTime(r.Context(), user.ID, q.ID, now, false)
+		default:
+			http.Error(w, "Invalid action", http.StatusBadRequest)
+			return
+		}
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			slog.Error("failed to update quiet time", "user_id", user.ID, "date", dateStr, "error", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if r.Method == http.MethodPost && !isHTMX(r) {
+		redirectToDay(w, r, dateStr)
+		return
+	}
+
+	q, err := s.getQuietTime(r, user, dateStr)
+	if err != nil {
+		slog.Error("failed to get quiet time", "user_id", user.ID, "date", dateStr, "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	data := map[string]any{"date": dateStr, "CSRFToken": r.Context().Value(csrfContextKey).(string)}
+	s.addQuietTime(data, q)
+	if err := s.tmpl.ExecuteTemplate(w, "quiet_time.gotmpl", data); err != nil {
+		slog.Error("failed to execute quiet time template", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
+
+// getQuietTime returns the user's latest quiet time session for a date, or nil if they have not started
+// one.
+func (s *Server) getQuietTime(r *http.Request, user *store.User, dateStr string) (*store.QuietTime, error) {
+	q, err := s.store.GetQuietTime(r.Context(), user.ID, dateStr)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return q, err
+}
+
+// addQuietTime adds the state of a date's quiet time session to the data of a template that includes
+// quiet_time.gotmpl. The page counts down the seconds remaining, rather than to the end time, so that
+// its clock need not agree with the server's.
+func (s *Server) addQuietTime(data map[string]any, q *store.QuietTime) {
+	now := s.clock.Now()
+	data["quietTimeMinutes"] = int(quietTimeDuration / time.Minute)
+	if q == nil {
+		return
+	}
+	if q.Running(now) {
+		data["quietTimeRemaining"] = int(q.EndsAt().Sub(now).Round(time.Second) / time.Second)
+	}
+	data["quietTimeCompleted"] = q.Completed
+}
@@ -0,0 +1,33 @@
+package server
+
+import "derrclan.com/moravian-soap/internal/esv"
+
+// passageLength is how long a passage, or the whole day's reading, takes to read.
+type passageLength struct {
+	Words   int
+	Minutes int
+}
+
+// newPassageLength estimates the reading time of a number of words.
+func newPassageLength(words int) passageLength {
+	return passageLength{Words: words, Minutes: esv.ReadingMinutes(words)}
+}
+
+// readingLengths returns the length of each passage of a day's reading and of the reading as a whole.
+// The total is estimated from the total word count rather than by adding up rounded minutes. Word
+// counts are taken from the response; passages cached before they were counted are counted here.
+func readingLengths(resp esv.Response) ([]passageLength, passageLength) {
+	lengths := make([]passageLength, len(resp.Passages))
+	total := 0
+	for i, p := range resp.Passages {
+		var words int
+		if len(resp.WordCounts) == len(resp.Passages) {
+			words = resp.WordCounts[i]
+		} else {
+			words = esv.WordCount(p)
+		}
+		lengths[i] = newPassageLength(words)
+		total += words
+	}
+	return lengths, newPassageLength(total)
+}
@@ -0,0 +1,70 @@
+package server
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"derrclan.com/moravian-soap/internal/dailytexts"
+	"derrclan.com/moravian-soap/internal/esv"
+	"derrclan.com/moravian-soap/internal/store"
+)
+
+// relatedChapter is a chapter covered by a journal entry and the other dates the user journaled on it.
+type relatedChapter struct {
+	Name  string
+	Dates []string
+}
+
+// saveJournalChapters records the chapters covered by a saved journal entry: those of the day's
+// readings and of the verses the user selected.
+func (s *Server) saveJournalChapters(ctx context.Context, userID int64, soapData *store.SOAPData) {
+	if err := s.store.SaveJournalChapters(ctx, userID, soapData.Date, s.journalChapters(ctx, soapData)); err != nil {
+		slog.Error("failed to save journal chapters", "date", soapData.Date, "error", err)
+	}
+}
+
+// journalChapters returns the chapters covered by a journal entry.
+func (s *Server) journalChapters(ctx context.Context, soapData *store.SOAPData) []store.Chapter {
+	// The day's passages have almost always been cached by reading them; saving never waits on the ESV API.
+	var meta []esv.PassageMeta
+	if dailyText, err := dailytexts.GetDailyText(soapData.Date); err == nil && dailyText != nil {
+		if response, ok := s.cachedPassages(ctx, dailyText.Verses, s.passageOptions(ctx)); ok {
+			meta = response.PassageMeta
+		}
+	}
+
+	var chapters []store.Chapter
+	for _, c := range esv.Chapters(meta, soapData.SelectedVerses) {
+		chapters = append(chapters, store.Chapter{Book: c.Book, Chapter: c.Chapter})
+	}
+	return chapters
+}
+
+// relatedChapters names the chapters of related entries for display.
+func relatedChapters(related []*store.RelatedEntry) []relatedChapter {
+	chapters := make([]relatedChapter, 0, len(related))
+	for _, r := range related {
+		name := esv.Chapter{Book: r.Chapter.Book, Chapter: r.Chapter.Chapter}.String()
+		chapters = append(chapters, relatedChapter{Name: name, Dates: r.Dates})
+	}
+	return chapters
+}
+
+// handleRelatedEntries renders the related entries partial for a date (for HTMX).
+func (s *Server) handleRelatedEntries(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(userContextKey).(*store.User)
+	dateStr := requestDate(r).String()
+
+	related, err := s.store.GetRelatedEntries(r.Context(), user.ID, dateStr)
+	if err != nil {
+		slog.Error("failed to get related entries", "date", dateStr, "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	if err := s.tmpl.ExecuteTemplate(w, "related_entries.gotmpl", map[string]any{"relatedEntries": relatedChapters(related)}); err != nil {
+		slog.Error("failed to execute related entries template", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,138 @@
+package server
+
+import (
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"derrclan.com/moravian-soap/internal/store"
+)
+
+// reminderOption is a number of days users can choose for inactivity reminders.
+type reminderOption struct {
+	Days  int
+	Label string
+}
+
+// inactivityOptions are how long users can choose to go without journaling before they are reminded.
+// Zero days turns inactivity reminders off.
+var inactivityOptions = []reminderOption{
+	{0, "Never"},
+	{2, "After 2 days"},
+	{3, "After 3 days"},
+	{5, "After 5 days"},
+	{7, "After a week"},
+	{14, "After two weeks"},
+}
+
+// cooldownOptions are how long users can choose to wait between inactivity reminders.
+var cooldownOptions = []reminderOption{
+	{1, "Every day"},
+	{3, "Every 3 days"},
+	{7, "Once a week"},
+	{14, "Every two weeks"},
+	{30, "Once a month"},
+}
+
+// handleSettingsReminders shows and saves when the user is emailed a reminder after they stop journaling,
+// and the time of day they are emailed the day's texts. Choosing a time subscribes the user to the daily
+// email, and clearing it unsubscribes them.
+func (s *Server) handleSettingsReminders(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(userContextKey).(*store.User)
+
+	prefs, err := s.store.GetPreferences(r.Context(), user.ID)
+	if err != nil {
+		slog.Error("failed to get preferences", "user_id", user.ID, "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	var saved bool
+	var notice string
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		switch r.FormValue("action") {
+		case "daily_email":
+			sendTime := r.FormValue("daily_email_time")
+			if sendTime != "" && !validDailyEmailTime(sendTime) {
+				http.Error(w, "Invalid send time", http.StatusBadRequest)
+				return
+			}
+			timezone := r.FormValue("timezone")
+			if !validTimezone(timezone) {
+				http.Error(w, "Invalid timezone", http.StatusBadRequest)
+				return
+			}
+			if timezone != user.Timezone {
+				if err := s.store.UpdateUserTimezone(r.Context(), user.ID, timezone); err != nil {
+					slog.Error("failed to update timezone", "user_id", user.ID, "error", err)
+					http.Error(w, "Internal server error", http.StatusInternalServerError)
+					return
+				}
+				user.Timezone = timezone
+			}
+			prefs.DailyEmailTime = sendTime
+			var sub *store.EmailSubscription
+			if sendTime == "" {
+				sub, err = s.unsubscribeDailyEmail(r.Context(), user.ID)
+			} else {
+				sub, err = s.subscribeDailyEmail(r.Context(), user)
+			}
+			if err != nil {
+				slog.Error("failed to update daily email subscription", "user_id", user.ID, "error", err)
+				http.Error(w, "Internal server error", http.StatusInternalServerError)
+				return
+			}
+			if sub != nil && sub.Status == store.SubscriptionPending {
+				notice = "Check your email for a link to confirm the daily email. It is not sent until you do."
+			}
+		default:
+			days, err := strconv.Atoi(r.FormValue("inactive_days"))
+			if err != nil || !slices.ContainsFunc(inactivityOptions, func(o reminderOption) bool { return o.Days == days }) {
+				http.Error(w, "Invalid reminder period", http.StatusBadRequest)
+				return
+			}
+			cooldown, err := strconv.Atoi(r.FormValue("cooldown_days"))
+			if err != nil || !slices.ContainsFunc(cooldownOptions, func(o reminderOption) bool { return o.Days == cooldown }) {
+				http.Error(w, "Invalid reminder period", http.StatusBadRequest)
+				return
+			}
+			prefs.InactivityReminderDays = days
+			prefs.InactivityCooldownDays = cooldown
+		}
+		if err := s.store.SavePreferences(r.Context(), user.ID, prefs); err != nil {
+			slog.Error("failed to save preferences", "user_id", user.ID, "error", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		saved = true
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	dailyEmail, err := s.dailyEmailSubscription(r.Context(), user.ID)
+	if err != nil {
+		slog.Error("failed to get daily email subscription", "user_id", user.ID, "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	data := map[string]any{
+		"user":              user,
+		"prefs":             prefs,
+		"dailyEmail":        dailyEmail,
+		"notice":            notice,
+		"inactivityOptions": inactivityOptions,
+		"cooldownOptions":   cooldownOptions,
+		"saved":             saved,
+		"CSRFToken":         r.Context().Value(csrfContextKey).(string),
+		"Nonce":             r.Context().Value(nonceContextKey).(string),
+	}
+	if err := s.tmpl.ExecuteTemplate(w, "settings_reminders.html", data); err != nil {
+		slog.Error("failed to execute settings_reminders template", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,100 @@
+package server
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"derrclan.com/moravian-soap/internal/store"
+)
+
+// Save states of a journal section, as reported by handleSaveStatus.
+const (
+	// sectionSaved is a section saved at or before the editor's revision, so the editor has it.
+	sectionSaved = "saved"
+	// sectionChanged is a section saved since the editor's revision, such as from another device.
+	sectionChanged = "changed"
+	// sectionEmpty is a section with nothing saved in it.
+	sectionEmpty = "empty"
+)
+
+// sectionStatus is the save state of one section of a journal entry.
+type sectionStatus struct {
+	Section string `json:"section"`
+	Name    string `json:"name"`
+	State   string `json:"state"`
+	// Revision is the entry's revision that last changed the section, and SavedAt when it was saved;
+	// both are left out of empty sections.
+	Revision int64      `json:"revision,omitempty"`
+	SavedAt  *time.Time `json:"savedAt,omitempty"`
+}
+
+// saveStatus is the save state of a journal entry, section by section.
+type saveStatus struct {
+	Date string `json:"date"`
+	// Revision is the entry's current revision, zero if it has never been saved.
+	Revision int64           `json:"revision"`
+	Sections []sectionStatus `json:"sections"`
+}
+
+// handleSaveStatus reports when each section of the user's entry for a date was last saved, so that the
+// editor can show which sections are saved and how long ago. The editor passes the revision its edits
+// are based on as the revision parameter; sections saved since then are reported as changed. Edits not
+// yet sent are known only to the editor.
+func (s *Server) handleSaveStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	user := r.Context().Value(userContextKey).(*store.User)
+	dateStr := requestDate(r).String()
+	var base int64
+	if v := r.URL.Query().Get("revision"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || n < 0 {
+			http.Error(w, "Invalid revision", http.StatusBadRequest)
+			return
+		}
+		base = n
+	}
+
+	soapData, err := s.store.GetSOAPData(r.Context(), user.ID, dateStr)
+	if err != nil {
+		slog.Error("failed to get SOAP data", "date", dateStr, "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	saves, err := s.store.GetSectionSaves(r.Context(), user.ID, dateStr)
+	if err != nil {
+		slog.Error("failed to get section saves", "date", dateStr, "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	bySection := make(map[string]*store.SectionSave, len(saves))
+	for _, save := range saves {
+		bySection[save.Section] = save
+	}
+
+	status := saveStatus{Date: dateStr, Revision: soapData.Revision}
+	for _, e := range s.journalSchema(r.Context()).Entries(soapData.Sections) {
+		sec := sectionStatus{Section: e.Key, Name: e.Name, State: sectionEmpty}
+		if save, ok := bySection[e.Key]; ok {
+			sec.State, sec.Revision = sectionSaved, save.Revision
+			if base > 0 && save.Revision > base {
+				sec.State = sectionChanged
+			}
+			if !save.SavedAt.IsZero() {
+				savedAt := save.SavedAt.UTC()
+				sec.SavedAt = &savedAt
+			}
+		}
+		status.Sections = append(status.Sections, sec)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(status); err != nil {
+		slog.Error("failed to encode save status", "error", err)
+	}
+}
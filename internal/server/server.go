@@ -14,31 +14,87 @@ import (
 	"html/template"
 	"io/fs"
 	"log/slog"
+	"mime"
 	"net/http"
-	"net/url"
 	"os"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 	_ "time/tzdata" // Initialize timezone data
 
 	"derrclan.com/moravian-soap/internal/auth"
+	"derrclan.com/moravian-soap/internal/clock"
+	"derrclan.com/moravian-soap/internal/commentary"
+	"derrclan.com/moravian-soap/internal/config"
+	"derrclan.com/moravian-soap/internal/dailyemail"
+	"derrclan.com/moravian-soap/internal/dailypost"
 	"derrclan.com/moravian-soap/internal/dailytexts"
 	"derrclan.com/moravian-soap/internal/email"
 	"derrclan.com/moravian-soap/internal/esv"
+	"derrclan.com/moravian-soap/internal/esv/cache"
+	"derrclan.com/moravian-soap/internal/events"
 	"derrclan.com/moravian-soap/internal/export"
 	"derrclan.com/moravian-soap/internal/expunger"
+	"derrclan.com/moravian-soap/internal/features"
+	"derrclan.com/moravian-soap/internal/journal"
+	"derrclan.com/moravian-soap/internal/metrics"
+	"derrclan.com/moravian-soap/internal/middleware"
 	"derrclan.com/moravian-soap/internal/migrations"
+	"derrclan.com/moravian-soap/internal/reminders"
 	"derrclan.com/moravian-soap/internal/store"
 	"derrclan.com/moravian-soap/internal/store/sqlite"
-
-	_ "github.com/mattn/go-sqlite3" // SQLite driver
+	"derrclan.com/moravian-soap/internal/tts"
+	"derrclan.com/moravian-soap/internal/verifier"
+	"derrclan.com/moravian-soap/internal/weather"
+	"derrclan.com/moravian-soap/internal/yearbook"
+	"derrclan.com/moravian-soap/internal/yearreview"
+	"golang.org/x/sync/singleflight"
 )
 
-var (
-	tmpl     *template.Template
-	db       *sql.DB
-	appStore store.Store
-)
+// Server is the application: its database, its templates and the HTTP handlers that use them. Its
+// handlers are methods, so that each server serves from its own database.
+type Server struct {
+	db      *sql.DB
+	store   store.Store
+	clock   clock.Clock
+	tmpl    *template.Template
+	handler http.Handler
+	// speech reads entries aloud. It is nil if no text-to-speech backend is configured.
+	speech *tts.Cache
+	// weather looks up the weather that entries are stamped with.
+	weather *weather.Client
+	// commentary supplies the commentary shown with the days' readings. It is nil if the deployment
+	// supplies none.
+	commentary commentary.Commentary
+	// guestLimiter limits how often visitors comment on shared years in review.
+	guestLimiter commentLimiter
+	// snapshots keep the days rendered in each session, to render them again from memory.
+	snapshots daySnapshots
+	// prefetches limits how often each user warms the ESV cache.
+	prefetches prefetchThrottle
+	// passageFetches deduplicates concurrent ESV API fetches of the same passages.
+	passageFetches singleflight.Group
+	// dailyReadings coalesces concurrent loads of the same reading, such as a family's devices all
+	// opening the day's page at once, so that they share one read of the cache or fetch from the ESV
+	// API, and one count of the passages' words.
+	dailyReadings singleflight.Group
+	// instanceCSS holds the stylesheet of the instance's theme, emitted into every page by head.gotmpl.
+	instanceCSS atomic.Pointer[string]
+	// events announces what happens in the app to the subsystems that act on it; see subscribe.
+	events *events.Bus
+}
+
+// Config configures a Server.
+type Config struct {
+	// DBPath is the SQLite database, which is created if it does not exist and migrated to the latest
+	// schema.
+	DBPath string
+	// Clock tells the time to handlers and background services. It defaults to the real clock.
+	Clock clock.Clock
+	// Options customize the server for a deployment.
+	Options []Option
+}
 
 //go:embed web
 var web embed.FS
@@ -49,58 +105,126 @@ const (
 	userContextKey  contextKey = "user"
 	csrfContextKey  contextKey = "csrf_token"
 	nonceContextKey contextKey = "nonce"
+	dateContextKey  contextKey = "date"
+	// translationContextKey holds the translation asked for by the request; see translationMiddleware.
+	translationContextKey contextKey = "translation"
 )
 
-func init() {
-	// Parse templates with function map for safe HTML rendering
-	funcMap := template.FuncMap{
-		"safeHTML": func(s string) template.HTML {
-			return template.HTML(s) // #nosec G203
-		},
-		"toJSON": func(v any) (template.JS, error) {
-			b, err := json.Marshal(v)
-			if err != nil {
-				return "", fmt.Errorf("marshaling JSON: %w", err)
-			}
-			return template.JS(b), nil // #nosec G203
-		},
-	}
-	var err error
-	tmpl, err = template.New("").Funcs(funcMap).ParseFS(web, "web/*.html", "web/*.gotmpl")
-	if err != nil {
-		slog.Error("failed to parse template", "error", err)
-		// Create a minimal template to prevent nil pointer errors
-		tmpl = template.Must(template.New("error").Parse("<html><body><h1>Template Error</h1></body></html>"))
-	}
-}
-
-// Muxer returns the HTTP handler for the application.
-func Muxer() http.Handler {
+// routes returns the HTTP handler for the application.
+func (s *Server) routes() http.Handler {
 	mux := http.NewServeMux()
 
 	// Public routes
-	mux.HandleFunc("/login", handleLogin)
-	mux.HandleFunc("/register", handleRegister)
-	mux.HandleFunc("/confirm", handleConfirm)
-	mux.HandleFunc("/forgot-password", handleForgotPassword)
-	mux.HandleFunc("/reset-password", handleResetPassword)
-	mux.HandleFunc("/logout", handleLogout)
-
-	// Protected routes
-	mux.HandleFunc("/", authMiddleware(handleIndex))
-	mux.HandleFunc("/reading", authMiddleware(handleReading))
-	mux.HandleFunc("/soap", authMiddleware(handleSOAP))
-	mux.HandleFunc("/export", authMiddleware(handleExport))
+	public := middleware.NewGroup(mux)
+	public.HandleFunc("/login", s.handleLogin)
+	public.HandleFunc("/register", s.handleRegister)
+	public.HandleFunc("/confirm", s.handleConfirm)
+	public.HandleFunc("/forgot-password", s.handleForgotPassword)
+	public.HandleFunc("/reset-password", s.handleResetPassword)
+	public.HandleFunc("/logout", handleLogout)
+	public.With(requireFeature(features.Passkeys)).HandleFunc("/passkeys/login/begin", s.handlePasskeyLoginBegin)
+	public.With(requireFeature(features.Passkeys)).HandleFunc("/passkeys/login/finish", s.handlePasskeyLoginFinish)
+	public.HandleFunc("/webhooks/mailgun", s.handleMailgunWebhook)
+	public.HandleFunc("/healthz", s.handleHealthz)
+	public.HandleFunc("/sw.js", s.handleServiceWorker)
+	public.HandleFunc("/offline", s.handleOffline)
+	public.With(requireFeature(features.Email)).HandleFunc("/subscriptions/confirm", s.handleSubscriptionConfirm)
+	public.With(requireFeature(features.Email)).HandleFunc("/subscriptions/unsubscribe", s.handleSubscriptionUnsubscribe)
+	public.With(requireFeature(features.Stats)).HandleFunc("/review/{token}", s.handleSharedYearReview)
+	public.With(requireFeature(features.Stats)).HandleFunc("/review/{token}/card.png", s.handleYearReviewCard)
+
+	// Protected routes, which lock after a period of inactivity for users who turn on the privacy lock
+	unlocked := middleware.NewGroup(mux, s.authMiddleware, s.invalidateSnapshotsMiddleware)
+	unlocked.HandleFunc("/unlock", s.handleUnlock)
+	site := unlocked.With(s.lockMiddleware)
+	dated := site.With(s.dateMiddleware)
+	dated.HandleFunc("/", s.handleIndex)
+	dated.HandleFunc("/reading", s.handleReading)
+	dated.HandleFunc("/soap", s.handleSOAP)
+	dated.HandleFunc("/soap-form", s.handleSOAPForm)
+	dated.HandleFunc("/soap/merge", s.handleMergeSOAP)
+	dated.HandleFunc("/soap/status", s.handleSaveStatus)
+	site.HandleFunc("/notes/verse", s.handleVerseNote)
+	site.HandleFunc("/export", s.handleExport)
+	site.HandleFunc("/backup", s.handleBackup)
+	site.HandleFunc("/backup/yearbook", s.handleYearbook)
+	site.HandleFunc("/backup/yearbook/{year}", s.handleYearbookPage)
+	site.HandleFunc("/passages", s.handlePassageEntries)
+	site.HandleFunc("/passages/{id}", s.handlePassageEntry)
+	site.HandleFunc("/passages/{id}/export", s.handlePassageEntryExport)
+	site.With(requireFeature(features.API)).HandleFunc("/settings/api", s.handleSettingsAPI)
+	site.HandleFunc("/settings/reading", s.handleSettingsReading)
+	site.HandleFunc("/settings/journal", s.handleSettingsJournal)
+	site.With(requireFeature(features.Passkeys)).HandleFunc("/settings/passkeys", s.handleSettingsPasskeys)
+	site.HandleFunc("/settings/privacy", s.handleSettingsPrivacy)
+	site.With(requireFeature(features.Email)).HandleFunc("/settings/reminders", s.handleSettingsReminders)
+	site.HandleFunc("/settings/backup", s.handleSettingsBackup)
+	site.HandleFunc("/settings/backup/highlights", s.handleImportHighlights)
+	site.With(requireFeature(features.Stats)).HandleFunc("/stats", s.handleStats)
+	site.With(requireFeature(features.Stats)).HandleFunc("/stats/review", s.handleYearReview)
+	site.With(requireFeature(features.Concordance)).HandleFunc("/concordance", s.handleConcordance)
+	site.HandleFunc("/journal", s.handleJournalHistory)
+	site.HandleFunc("/favorites", s.handleFavorites)
+	site.With(requireFeature(features.Mentors)).HandleFunc("/settings/mentors", s.handleSettingsMentors)
+	site.With(requireFeature(features.Mentors)).HandleFunc("/mentees/{id}", s.handleMenteeJournal)
+	site.HandleFunc("/whats-new", s.handleWhatsNew)
+	site.HandleFunc("/announcements/{id}/dismiss", s.handleDismissAnnouncement)
+	site.With(requireFeature(features.Passkeys)).HandleFunc("/passkeys/register/begin", s.handlePasskeyRegisterBegin)
+	site.With(requireFeature(features.Passkeys)).HandleFunc("/passkeys/register/finish", s.handlePasskeyRegisterFinish)
+	dated.With(requireFeature(features.Prayers)).HandleFunc("/prayers", s.handlePrayers)
+	dated.With(requireFeature(features.Prayers)).HandleFunc("/prayers/{id}", s.handlePrayerItem)
+	dated.HandleFunc("/links", s.handleLinkedEntries)
+	dated.HandleFunc("/related", s.handleRelatedEntries)
+	dated.HandleFunc("/favorite", s.handleFavorite)
+	dated.With(requireFeature(features.Mentors)).HandleFunc("/mentor-comments", s.handleMentorComments)
+	dated.HandleFunc("/prefetch", s.handlePrefetch)
+	dated.With(requireFeature(features.Speech)).HandleFunc("/audio/entry", s.handleEntryAudio)
+	dated.With(requireFeature(features.Speech)).HandleFunc("/audio/week", s.handleWeekAudio)
+	dated.With(requireFeature(features.Psalter)).HandleFunc("/psalter", s.handlePsalter)
+	dated.With(requireFeature(features.Psalter)).HandleFunc("/psalter/mode", s.handlePsalterMode)
+	dated.With(requireFeature(features.Devotions)).HandleFunc("/devotions", s.handleDevotions)
+	dated.With(requireFeature(features.QuietTime)).HandleFunc("/quiet-time", s.handleQuietTime)
+	dated.With(requireFeature(features.Stamps)).HandleFunc("/stamp", s.handleStamp)
+
+	// API routes
+	api := middleware.NewGroup(mux, apiMiddleware, s.apiAuthMiddleware, s.invalidateSnapshotsMiddleware, requireFeature(features.API))
+	api.HandleFunc("/api/v1/week", s.handleAPIWeek)
+	api.HandleFunc("/api/v1/votd", s.handleAPIVotd)
+	api.HandleFunc("/api/v1/watchwords", s.handleAPIWatchwords)
+	api.HandleFunc("/api/v1/watchwords.txt", s.handleAPIWatchwordsText)
+	api.HandleFunc("/api/v1/export", s.handleAPIExport)
+	api.HandleFunc("/api/v1/import", s.handleAPIImport)
+	api.HandleFunc("/api/v1/texts/{date}", s.handleAPIText)
+	api.HandleFunc("/api/v1/passages", s.handleAPIPassages)
+	api.HandleFunc("/api/v1/entries", s.handleAPIEntries)
+	api.HandleFunc("/api/v1/entries/{date}", s.handleAPIEntry)
+	api.HandleFunc("/api/v1/search", s.handleAPISearch)
+	api.With(requireFeature(features.Stats)).HandleFunc("/api/v1/stats", s.handleAPIStats)
+	api.With(requireFeature(features.Email)).HandleFunc("/api/v1/subscriptions", s.handleAPISubscriptions)
+
+	// Admin routes
+	admin := site.With(adminMiddleware)
+	admin.HandleFunc("/emails/preview/{template}", s.handleEmailPreview)
+	admin.HandleFunc("/admin/reload-config", s.handleReloadConfig)
+	admin.HandleFunc("/admin/emails", s.handleAdminEmails)
+	admin.HandleFunc("/admin/api-log", s.handleAdminAPILog)
+	admin.HandleFunc("/admin/posts", s.handleAdminDailyPosts)
+	admin.HandleFunc("/admin/theme", s.handleAdminTheme)
+	admin.HandleFunc("/admin/theme/preview", s.handleAdminThemePreview)
+	admin.HandleFunc("/admin/maintenance", s.handleAdminMaintenance)
+	admin.HandleFunc("/admin/features", s.handleAdminFeatures)
+	admin.HandleFunc("/admin/comments", s.handleAdminComments)
+	admin.HandleFunc("/admin/storage", s.handleAdminStorage)
 
 	// Create a subdirectory filesystem for the web directory
 	webFS, err := fs.Sub(web, "web")
 	if err != nil {
 		slog.Error("failed to create web subdirectory filesystem", "error", err)
 	} else {
-		mux.Handle("/web/", http.StripPrefix("/web/", http.FileServer(http.FS(webFS))))
+		public.Handle("/web/", serveAssets(webFS))
 	}
 
-	return securityMiddleware(csrfMiddleware(mux))
+	return middleware.Chain(securityMiddleware, csrfMiddleware, s.maintenanceMiddleware, translationMiddleware)(mux)
 }
 
 func securityMiddleware(next http.Handler) http.Handler {
@@ -138,7 +262,11 @@ func csrfMiddleware(next http.Handler) http.Handler {
 			token = cookie.Value
 		}
 
-		if r.Method == http.MethodPost {
+		// API requests authenticated with a personal API token carry no ambient credentials, and webhooks
+		// are authenticated by their signatures.
+		exempt := (strings.HasPrefix(r.URL.Path, "/api/") && bearerToken(r) != "") || strings.HasPrefix(r.URL.Path, "/webhooks/")
+		safe := r.Method == http.MethodGet || r.Method == http.MethodHead || r.Method == http.MethodOptions
+		if !safe && !exempt {
 			requestToken := r.Header.Get("X-CSRF-Token")
 			if requestToken == "" {
 				requestToken = r.FormValue("csrf_token")
@@ -165,9 +293,10 @@ func generateRandomString(n int) string {
 	return base64.URLEncoding.EncodeToString(b)
 }
 
-// authMiddleware checks for a valid session cookie and sets the user in the context.
-func authMiddleware(next http.HandlerFunc) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
+// authMiddleware checks for a valid session cookie and sets the user in the context. Personal API
+// tokens are accepted only on the API routes, by apiAuthMiddleware.
+func (s *Server) authMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		cookie, err := r.Cookie("session_token")
 		if err != nil {
 			if r.URL.Path == "/" {
@@ -178,7 +307,7 @@ func authMiddleware(next http.HandlerFunc) http.HandlerFunc {
 			return
 		}
 
-		user, err := appStore.GetUserFromSession(r.Context(), cookie.Value)
+		user, err := s.store.GetUserFromSession(r.Context(), cookie.Value)
 		if err != nil {
 			// Invalid session
 			http.SetCookie(w, &http.Cookie{
@@ -199,11 +328,11 @@ func authMiddleware(next http.HandlerFunc) http.HandlerFunc {
 		}
 
 		ctx := context.WithValue(r.Context(), userContextKey, user)
-		next(w, r.WithContext(ctx))
-	}
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
 }
 
-func handleLogin(w http.ResponseWriter, r *http.Request) {
+func (s *Server) handleLogin(w http.ResponseWriter, r *http.Request) {
 	csrfToken := r.Context().Value(csrfContextKey).(string)
 	nonce := r.Context().Value(nonceContextKey).(string)
 	if r.Method == http.MethodGet {
@@ -212,7 +341,7 @@ func handleLogin(w http.ResponseWriter, r *http.Request) {
 			"CSRFToken": csrfToken,
 			"Nonce":     nonce,
 		}
-		if err := tmpl.ExecuteTemplate(w, "login.html", data); err != nil {
+		if err := s.tmpl.ExecuteTemplate(w, "login.html", data); err != nil {
 			slog.Error("failed to execute login template", "error", err)
 		}
 		return
@@ -223,7 +352,7 @@ func handleLogin(w http.ResponseWriter, r *http.Request) {
 		password := r.FormValue("password")
 		timezone := r.FormValue("timezone")
 
-		user, err := authenticateUser(r.Context(), email, password)
+		user, err := s.authenticateUser(r.Context(), email, password)
 		if err != nil {
 			slog.Error("authenticating user", "email", email, "error", err)
 			data := map[string]any{
@@ -233,7 +362,7 @@ func handleLogin(w http.ResponseWriter, r *http.Request) {
 				"CSRFToken": csrfToken,
 				"Nonce":     nonce,
 			}
-			if err := tmpl.ExecuteTemplate(w, "login.html", data); err != nil {
+			if err := s.tmpl.ExecuteTemplate(w, "login.html", data); err != nil {
 				slog.Error("failed to execute login template", "error", err)
 			}
 			return
@@ -241,32 +370,36 @@ func handleLogin(w http.ResponseWriter, r *http.Request) {
 
 		// Update timezone if provided
 		if timezone != "" {
-			if err := appStore.UpdateUserTimezone(r.Context(), user.ID, timezone); err != nil {
+			if err := s.store.UpdateUserTimezone(r.Context(), user.ID, timezone); err != nil {
 				slog.Error("failed to update user timezone", "error", err, "user_id", user.ID)
 			}
 		}
 
-		sessionToken, err := createSession(r.Context(), user.ID)
+		sessionToken, err := s.createSession(r.Context(), user.ID)
 		if err != nil {
 			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
 			return
 		}
 
-		http.SetCookie(w, &http.Cookie{
-			Name:     "session_token",
-			Value:    sessionToken,
-			Path:     "/",
-			HttpOnly: true,
-			Secure:   r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https",
-			SameSite: http.SameSiteLaxMode,
-			Expires:  time.Now().Add(24 * time.Hour * 30), // 30 days
-		})
-
+		s.setSessionCookie(w, r, sessionToken)
 		http.Redirect(w, r, "/", http.StatusFound)
 	}
 }
 
-func handleRegister(w http.ResponseWriter, r *http.Request) {
+// setSessionCookie sets the cookie that keeps the user signed in.
+func (s *Server) setSessionCookie(w http.ResponseWriter, r *http.Request, sessionToken string) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     "session_token",
+		Value:    sessionToken,
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https",
+		SameSite: http.SameSiteLaxMode,
+		Expires:  s.clock.Now().Add(24 * time.Hour * 30), // 30 days
+	})
+}
+
+func (s *Server) handleRegister(w http.ResponseWriter, r *http.Request) {
 	csrfToken := r.Context().Value(csrfContextKey).(string)
 	nonce := r.Context().Value(nonceContextKey).(string)
 	if r.Method == http.MethodGet {
@@ -275,7 +408,7 @@ func handleRegister(w http.ResponseWriter, r *http.Request) {
 			"CSRFToken": csrfToken,
 			"Nonce":     nonce,
 		}
-		if err := tmpl.ExecuteTemplate(w, "login.html", data); err != nil {
+		if err := s.tmpl.ExecuteTemplate(w, "login.html", data); err != nil {
 			slog.Error("failed to execute register template", "error", err)
 		}
 		return
@@ -294,7 +427,7 @@ func handleRegister(w http.ResponseWriter, r *http.Request) {
 				"CSRFToken": csrfToken,
 				"Nonce":     nonce,
 			}
-			if err := tmpl.ExecuteTemplate(w, "login.html", data); err != nil {
+			if err := s.tmpl.ExecuteTemplate(w, "login.html", data); err != nil {
 				slog.Error("failed to execute register template", "error", err)
 			}
 			return
@@ -309,7 +442,7 @@ func handleRegister(w http.ResponseWriter, r *http.Request) {
 		}
 		token := base64.URLEncoding.EncodeToString(tokenBytes)
 
-		if err := createUser(r.Context(), emailStr, password, token, timezone); err != nil {
+		if err := s.createUser(r.Context(), emailStr, password, token, timezone); err != nil {
 			slog.Error("failed to create user", "error", err)
 			data := map[string]any{
 				"IsLogin":   false,
@@ -318,25 +451,25 @@ func handleRegister(w http.ResponseWriter, r *http.Request) {
 				"CSRFToken": csrfToken,
 				"Nonce":     nonce,
 			}
-			if err := tmpl.ExecuteTemplate(w, "login.html", data); err != nil {
+			if err := s.tmpl.ExecuteTemplate(w, "login.html", data); err != nil {
 				slog.Error("failed to execute register template", "error", err)
 			}
 			return
 		}
 
-		// Send welcome email
+		// Queue welcome email
 		baseURL := os.Getenv("BASE_URL")
 		if baseURL == "" {
 			baseURL = "http://localhost:8080"
 		}
 		confirmationURL := fmt.Sprintf("%s/confirm?token=%s", baseURL, token)
 
-		client, err := email.GetClient()
+		user, err := s.store.GetUserByEmail(r.Context(), emailStr)
 		if err == nil {
-			err = client.SendWelcomeEmail(r.Context(), emailStr, confirmationURL)
+			err = email.Queue(r.Context(), s.store, user.ID, emailStr, email.WelcomeEmail(confirmationURL))
 		}
 		if err != nil {
-			slog.Error("failed to send welcome email", "error", err)
+			slog.Error("failed to queue welcome email", "error", err)
 			// User created but email failed. They can't login.
 			// Ideally we'd rollback or have a "resend" option.
 			// For now, show error.
@@ -347,11 +480,12 @@ func handleRegister(w http.ResponseWriter, r *http.Request) {
 				"CSRFToken": csrfToken,
 				"Nonce":     nonce,
 			}
-			if err := tmpl.ExecuteTemplate(w, "login.html", data); err != nil {
+			if err := s.tmpl.ExecuteTemplate(w, "login.html", data); err != nil {
 				slog.Error("failed to execute register template", "error", err)
 			}
 			return
 		}
+		s.events.Publish(r.Context(), events.Event{Kind: events.UserRegistered, At: s.clock.Now(), UserID: user.ID, Email: emailStr})
 
 		// Show success message
 		data := map[string]any{
@@ -360,13 +494,13 @@ func handleRegister(w http.ResponseWriter, r *http.Request) {
 			"CSRFToken": csrfToken,
 			"Nonce":     nonce,
 		}
-		if err := tmpl.ExecuteTemplate(w, "login.html", data); err != nil {
+		if err := s.tmpl.ExecuteTemplate(w, "login.html", data); err != nil {
 			slog.Error("failed to execute login template", "error", err)
 		}
 	}
 }
 
-func handleConfirm(w http.ResponseWriter, r *http.Request) {
+func (s *Server) handleConfirm(w http.ResponseWriter, r *http.Request) {
 	csrfToken := r.Context().Value(csrfContextKey).(string)
 	nonce := r.Context().Value(nonceContextKey).(string)
 	token := r.URL.Query().Get("token")
@@ -375,7 +509,7 @@ func handleConfirm(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	userID, emailStr, err := appStore.ConfirmUser(r.Context(), token)
+	userID, emailStr, err := s.store.ConfirmUser(r.Context(), token)
 	if err != nil {
 		slog.Error("failed to verify user", "error", err)
 		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
@@ -389,7 +523,7 @@ func handleConfirm(w http.ResponseWriter, r *http.Request) {
 			"CSRFToken": csrfToken,
 			"Nonce":     nonce,
 		}
-		if err := tmpl.ExecuteTemplate(w, "login.html", data); err != nil {
+		if err := s.tmpl.ExecuteTemplate(w, "login.html", data); err != nil {
 			slog.Error("failed to execute login template", "error", err)
 		}
 		return
@@ -398,16 +532,7 @@ func handleConfirm(w http.ResponseWriter, r *http.Request) {
 	// Notify admin
 	adminEmail := os.Getenv("ADMIN_EMAIL")
 	if adminEmail != "" {
-		notification := &store.QueuedEmail{
-			UserID:        userID,
-			Recipient:     adminEmail,
-			Subject:       "New User Registration: " + emailStr,
-			BodyHTML:      "A new user has registered and verified their account: " + emailStr,
-			Status:        "pending",
-			Attempts:      0,
-			NextAttemptAt: time.Now(),
-		}
-		if err := appStore.QueueEmail(r.Context(), notification); err != nil {
+		if err := email.Queue(r.Context(), s.store, userID, adminEmail, email.AdminNotificationEmail(emailStr)); err != nil {
 			slog.Error("failed to queue admin notification email", "error", err, "admin_email", adminEmail, "user_email", emailStr)
 		}
 	}
@@ -418,16 +543,16 @@ func handleConfirm(w http.ResponseWriter, r *http.Request) {
 		"CSRFToken": csrfToken,
 		"Nonce":     nonce,
 	}
-	if err := tmpl.ExecuteTemplate(w, "login.html", data); err != nil {
+	if err := s.tmpl.ExecuteTemplate(w, "login.html", data); err != nil {
 		slog.Error("failed to execute login template", "error", err)
 	}
 }
 
-func handleForgotPassword(w http.ResponseWriter, r *http.Request) {
+func (s *Server) handleForgotPassword(w http.ResponseWriter, r *http.Request) {
 	csrfToken := r.Context().Value(csrfContextKey).(string)
 	nonce := r.Context().Value(nonceContextKey).(string)
 	if r.Method == http.MethodGet {
-		if err := tmpl.ExecuteTemplate(w, "forgot_password.html", map[string]any{"CSRFToken": csrfToken, "Nonce": nonce}); err != nil {
+		if err := s.tmpl.ExecuteTemplate(w, "forgot_password.html", map[string]any{"CSRFToken": csrfToken, "Nonce": nonce}); err != nil {
 			slog.Error("failed to execute forgot_password template", "error", err)
 		}
 		return
@@ -441,14 +566,14 @@ func handleForgotPassword(w http.ResponseWriter, r *http.Request) {
 				"CSRFToken": csrfToken,
 				"Nonce":     nonce,
 			}
-			if err := tmpl.ExecuteTemplate(w, "forgot_password.html", data); err != nil {
+			if err := s.tmpl.ExecuteTemplate(w, "forgot_password.html", data); err != nil {
 				slog.Error("failed to execute forgot_password template", "error", err)
 			}
 			return
 		}
 
 		// Check if user exists (generic success message regardless)
-		user, err := appStore.GetUserByEmail(r.Context(), emailStr)
+		user, err := s.store.GetUserByEmail(r.Context(), emailStr)
 		if errors.Is(err, sql.ErrNoRows) {
 			// User not found - pretend we sent it
 			data := map[string]any{
@@ -456,7 +581,7 @@ func handleForgotPassword(w http.ResponseWriter, r *http.Request) {
 				"CSRFToken": csrfToken,
 				"Nonce":     nonce,
 			}
-			if err := tmpl.ExecuteTemplate(w, "forgot_password.html", data); err != nil {
+			if err := s.tmpl.ExecuteTemplate(w, "forgot_password.html", data); err != nil {
 				slog.Error("failed to execute forgot_password template", "error", err)
 			}
 			return
@@ -474,37 +599,33 @@ func handleForgotPassword(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 		token := base64.URLEncoding.EncodeToString(tokenBytes)
-		expiresAt := time.Now().Add(1 * time.Hour)
+		expiresAt := s.clock.Now().Add(1 * time.Hour)
 
 		// Save token
-		err = appStore.CreatePasswordResetToken(r.Context(), token, user.ID, expiresAt)
+		err = s.store.CreatePasswordResetToken(r.Context(), token, user.ID, expiresAt)
 		if err != nil {
 			slog.Error("failed to save reset token", "error", err)
 			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
 			return
 		}
 
-		// Send email
+		// Queue email
 		baseURL := os.Getenv("BASE_URL")
 		if baseURL == "" {
 			baseURL = "http://localhost:8080"
 		}
 		resetURL := fmt.Sprintf("%s/reset-password?token=%s", baseURL, token)
 
-		client, err := email.GetClient()
-		if err == nil {
-			err = client.SendPasswordResetEmail(r.Context(), emailStr, resetURL)
-		}
-		if err != nil {
-			slog.Error("failed to send password reset email", "error", err)
-			// Log the link for dev/debug if email fails
+		if err := email.Queue(r.Context(), s.store, user.ID, emailStr, email.PasswordResetEmail(resetURL)); err != nil {
+			slog.Error("failed to queue password reset email", "error", err)
+			// Log the link for dev/debug if queuing fails
 			slog.Debug("Password reset link", "url", resetURL, "email", emailStr)
 			data := map[string]any{
 				"Error":     "Failed to send email. Please try again later.",
 				"CSRFToken": csrfToken,
 				"Nonce":     nonce,
 			}
-			if err := tmpl.ExecuteTemplate(w, "forgot_password.html", data); err != nil {
+			if err := s.tmpl.ExecuteTemplate(w, "forgot_password.html", data); err != nil {
 				slog.Error("failed to execute forgot_password template", "error", err)
 			}
 			return
@@ -515,13 +636,13 @@ func handleForgotPassword(w http.ResponseWriter, r *http.Request) {
 			"CSRFToken": csrfToken,
 			"Nonce":     nonce,
 		}
-		if err := tmpl.ExecuteTemplate(w, "forgot_password.html", data); err != nil {
+		if err := s.tmpl.ExecuteTemplate(w, "forgot_password.html", data); err != nil {
 			slog.Error("failed to execute forgot_password template", "error", err)
 		}
 	}
 }
 
-func handleResetPassword(w http.ResponseWriter, r *http.Request) {
+func (s *Server) handleResetPassword(w http.ResponseWriter, r *http.Request) {
 	csrfToken := r.Context().Value(csrfContextKey).(string)
 	nonce := r.Context().Value(nonceContextKey).(string)
 	if r.Method == http.MethodGet {
@@ -532,7 +653,7 @@ func handleResetPassword(w http.ResponseWriter, r *http.Request) {
 		}
 
 		// Validate token
-		_, expiresAt, err := appStore.GetPasswordResetToken(r.Context(), token)
+		_, expiresAt, err := s.store.GetPasswordResetToken(r.Context(), token)
 		if err != nil {
 			data := map[string]any{
 				"Error":     "Invalid or expired password reset link.",
@@ -540,19 +661,19 @@ func handleResetPassword(w http.ResponseWriter, r *http.Request) {
 				"Nonce":     nonce,
 			}
 			// Just render login with error if token invalid
-			if err := tmpl.ExecuteTemplate(w, "login.html", data); err != nil {
+			if err := s.tmpl.ExecuteTemplate(w, "login.html", data); err != nil {
 				slog.Error("failed to execute login template", "error", err)
 			}
 			return
 		}
 
-		if time.Now().After(expiresAt) {
+		if s.clock.Now().After(expiresAt) {
 			data := map[string]any{
 				"Error":     "Password reset link has expired.",
 				"CSRFToken": csrfToken,
 				"Nonce":     nonce,
 			}
-			if err := tmpl.ExecuteTemplate(w, "login.html", data); err != nil {
+			if err := s.tmpl.ExecuteTemplate(w, "login.html", data); err != nil {
 				slog.Error("failed to execute login template", "error", err)
 			}
 			return
@@ -563,7 +684,7 @@ func handleResetPassword(w http.ResponseWriter, r *http.Request) {
 			"CSRFToken": csrfToken,
 			"Nonce":     nonce,
 		}
-		if err := tmpl.ExecuteTemplate(w, "reset_password.html", data); err != nil {
+		if err := s.tmpl.ExecuteTemplate(w, "reset_password.html", data); err != nil {
 			slog.Error("failed to execute reset_password template", "error", err)
 		}
 		return
@@ -579,14 +700,14 @@ func handleResetPassword(w http.ResponseWriter, r *http.Request) {
 		}
 
 		// Validate token again
-		userID, expiresAt, err := appStore.GetPasswordResetToken(r.Context(), token)
-		if err != nil || time.Now().After(expiresAt) {
+		userID, expiresAt, err := s.store.GetPasswordResetToken(r.Context(), token)
+		if err != nil || s.clock.Now().After(expiresAt) {
 			data := map[string]any{
 				"Error":     "Invalid or expired password reset link.",
 				"CSRFToken": csrfToken,
 				"Nonce":     nonce,
 			}
-			if err := tmpl.ExecuteTemplate(w, "login.html", data); err != nil {
+			if err := s.tmpl.ExecuteTemplate(w, "login.html", data); err != nil {
 				slog.Error("failed to execute login template", "error", err)
 			}
 			return
@@ -600,7 +721,7 @@ func handleResetPassword(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		err = appStore.UpdateUserPassword(r.Context(), userID, hashedPassword)
+		err = s.store.UpdateUserPassword(r.Context(), userID, hashedPassword)
 		if err != nil {
 			slog.Error("failed to update password", "error", err)
 			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
@@ -608,7 +729,7 @@ func handleResetPassword(w http.ResponseWriter, r *http.Request) {
 		}
 
 		// Delete used token
-		err = appStore.DeletePasswordResetToken(r.Context(), token)
+		err = s.store.DeletePasswordResetToken(r.Context(), token)
 		if err != nil {
 			slog.Error("failed to delete password reset token", "error", err)
 		}
@@ -619,13 +740,16 @@ func handleResetPassword(w http.ResponseWriter, r *http.Request) {
 			"CSRFToken": csrfToken,
 			"Nonce":     nonce,
 		}
-		if err := tmpl.ExecuteTemplate(w, "login.html", data); err != nil {
+		if err := s.tmpl.ExecuteTemplate(w, "login.html", data); err != nil {
 			slog.Error("failed to execute login template", "error", err)
 		}
 	}
 }
 
 func handleLogout(w http.ResponseWriter, r *http.Request) {
+	// Drop what the service worker keeps for offline use, so that the journal cannot be read from the
+	// device once signed out.
+	w.Header().Set("Clear-Site-Data", `"cache", "storage"`)
 	http.SetCookie(w, &http.Cookie{
 		Name:     "session_token",
 		Value:    "",
@@ -638,7 +762,8 @@ func handleLogout(w http.ResponseWriter, r *http.Request) {
 	http.Redirect(w, r, "/login", http.StatusFound)
 }
 
-func handleIndex(w http.ResponseWriter, r *http.Request) {
+// handleIndex renders the reading and journal for today, or for the day given by the "date" parameter.
+func (s *Server) handleIndex(w http.ResponseWriter, r *http.Request) {
 	user := r.Context().Value(userContextKey).(*store.User)
 
 	// Only handle root path
@@ -647,64 +772,62 @@ func handleIndex(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Get current date in YYYY-MM-DD format based on user location
-	loc, err := time.LoadLocation(user.Timezone)
-	if err != nil {
-		slog.Error("failed to load user location", "timezone", user.Timezone, "error", err)
-		loc = time.UTC
-	}
-	today := time.Now().In(loc).Format(time.DateOnly)
+	dateStr := requestDate(r).String()
 
-	// Get today's data (will load year file if needed)
-	dailyText, err := dailytexts.GetDailyText(today)
-	if err != nil {
-		slog.Error("failed to get daily text", "date", today, "error", err)
-		http.Error(w, fmt.Sprintf("Error loading data for date: %s", today), http.StatusInternalServerError)
-		return
-	}
-
-	if dailyText == nil {
-		slog.Warn("no data found for date", "date", today)
-		http.Error(w, fmt.Sprintf("No data found for date: %s", today), http.StatusNotFound)
+	// Get the day's reading and the user's entry, from the session's snapshot of the day if they have
+	// changed nothing since it was rendered
+	day, ok := s.loadDay(w, r, user, dateStr)
+	if !ok {
 		return
 	}
+	reading, soapData := day.reading, day.entry
 
-	// Fetch verse content from ESV API (using cache)
-	verseContents, err := fetchPassagesWithCache(r.Context(), dailyText.Verses)
+	quietTime, err := s.getQuietTime(r, user, dateStr)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Error loading verses for %s", today), http.StatusInternalServerError)
-		return
-	}
-
-	// Load existing SOAP data from database
-	soapData, err := appStore.GetSOAPData(r.Context(), user.ID, today)
-	if err != nil {
-		slog.Warn("failed to load SOAP data", "date", today, "error", err)
-		// Continue with empty values if there's an error
-		soapData = &store.SOAPData{
-			Date:           today,
-			Observation:    "",
-			Application:    "",
-			Prayer:         "",
-			SelectedVerses: []string{},
-		}
+		slog.Warn("failed to load quiet time", "date", dateStr, "error", err)
 	}
 
 	// Prepare template data
 	data := map[string]any{
-		"esvData":        verseContents,
-		"date":           today,
-		"observation":    soapData.Observation,
-		"application":    soapData.Application,
-		"prayer":         soapData.Prayer,
-		"selectedVerses": soapData.SelectedVerses,
-		"user":           user,
-		"CSRFToken":      r.Context().Value(csrfContextKey).(string),
-		"Nonce":          r.Context().Value(nonceContextKey).(string),
+		"esvData":           day.passages,
+		"passagesPending":   !reading.loaded,
+		"plainReferences":   reading.references,
+		"readingLengths":    reading.lengths,
+		"readingTotal":      reading.total,
+		"doctrinal":         reading.doctrinal,
+		"dailyPrayer":       reading.prayer,
+		"commentary":        reading.commentary,
+		"prayerItems":       day.prayerItems,
+		"linkedEntries":     day.linkedEntries,
+		"relatedEntries":    relatedChapters(day.relatedEntries),
+		"date":              dateStr,
+		"sections":          s.journalSchema(r.Context()).Entries(soapData.Sections),
+		"selectedVerses":    soapData.SelectedVerses,
+		"revision":          soapData.Revision,
+		"selectedReference": esv.FormatReferences(soapData.SelectedVerses),
+		"stamp":             soapData.Stamp(),
+		"user":              user,
+		"CSRFToken":         r.Context().Value(csrfContextKey).(string),
+		"Nonce":             r.Context().Value(nonceContextKey).(string),
+		"lockAfterMinutes":  s.lockAfterMinutes(r.Context(), user),
+		"weekDates":         s.weekDates(r.Context(), user),
+		"translation":       requestTranslation(r.Context()),
+	}
+	if err := s.addFavorite(r.Context(), data, user.ID, dateStr); err != nil {
+		slog.Warn("failed to load favorites", "date", dateStr, "error", err)
+	}
+	if featureEnabled(user, features.Mentors) {
+		if data["mentorComments"], err = s.store.ListMentorComments(r.Context(), user.ID, dateStr); err != nil {
+			slog.Warn("failed to load mentor comments", "date", dateStr, "error", err)
+		}
 	}
+	addDayNav(data, dateStr)
+	s.addWeeklyWatchword(r.Context(), data, user.ID, requestDate(r))
+	s.addQuietTime(data, quietTime)
+	addAnnouncement(data, s.unreadAnnouncements(r.Context(), user))
 
 	// Execute template
-	if err := tmpl.ExecuteTemplate(w, "index.html", data); err != nil {
+	if err := s.tmpl.ExecuteTemplate(w, "index.html", data); err != nil {
 		slog.Error("failed to execute template", "error", err)
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
@@ -713,122 +836,170 @@ func handleIndex(w http.ResponseWriter, r *http.Request) {
 
 // handleReading handles requests for the verses partial template (for HTMX).
 // Accepts a "date" query parameter (YYYY-MM-DD format). Defaults to today if not provided.
-func handleReading(w http.ResponseWriter, r *http.Request) {
-	// Get date from query parameter, default to today
-	dateStr := r.URL.Query().Get("date")
-	if dateStr == "" {
-		// Use user's timezone for default date
-		loc := time.UTC
-		if user, ok := r.Context().Value(userContextKey).(*store.User); ok {
-			if l, err := time.LoadLocation(user.Timezone); err == nil {
-				loc = l
-			}
-		}
-		dateStr = time.Now().In(loc).Format(time.DateOnly)
-	}
+func (s *Server) handleReading(w http.ResponseWriter, r *http.Request) {
+	dateStr := requestDate(r).String()
 
-	// Get daily text for the requested date
-	dailyText, err := dailytexts.GetDailyText(dateStr)
-	if err != nil {
-		slog.Error("failed to get daily text", "date", dateStr, "error", err)
-		http.Error(w, fmt.Sprintf("Error loading data for date: %s", dateStr), http.StatusInternalServerError)
-		return
-	}
-
-	if dailyText == nil {
-		slog.Warn("no data found for date", "date", dateStr)
-		http.Error(w, fmt.Sprintf("No data found for date: %s", dateStr), http.StatusNotFound)
-		return
-	}
-
-	// Fetch verse content from ESV API (using cache)
-	verseContents, err := fetchPassagesWithCache(r.Context(), dailyText.Verses)
-	if err != nil {
-		http.Error(w, fmt.Sprintf("Error fetching verses for %s", dateStr), http.StatusInternalServerError)
+	reading, ok := s.dailyReadingOrError(w, r, dateStr)
+	if !ok {
 		return
 	}
+	user := r.Context().Value(userContextKey).(*store.User)
+	verseContents := s.markHighlights(r.Context(), user.ID, s.markVerseNotes(r.Context(), user.ID, reading.passages))
 
 	// Prepare template data
 	data := map[string]any{
-		"esvData": verseContents,
-		"date":    dateStr,
-	}
+		"user":            user,
+		"esvData":         verseContents,
+		"passagesPending": !reading.loaded,
+		"plainReferences": reading.references,
+		"readingLengths":  reading.lengths,
+		"readingTotal":    reading.total,
+		"doctrinal":       reading.doctrinal,
+		"dailyPrayer":     reading.prayer,
+		"commentary":      reading.commentary,
+		"date":            dateStr,
+		"translation":     requestTranslation(r.Context()),
+	}
+	addDayNav(data, dateStr)
+	s.addWeeklyWatchword(r.Context(), data, user.ID, requestDate(r))
 
 	// Execute only the verses template
-	if err := tmpl.ExecuteTemplate(w, "verses.gotmpl", data); err != nil {
+	if err := s.tmpl.ExecuteTemplate(w, "verses.gotmpl", data); err != nil {
 		slog.Error("failed to execute verses template", "error", err)
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
 }
 
-// InitDB initializes the SQLite database and applies migrations.
-func InitDB(ctx context.Context) error {
-	dbPath := os.Getenv("DB_PATH")
-	if dbPath == "" {
-		dbPath = "/data/app.db"
+// New parses the templates, opens and migrates the database and loads the daily texts, then starts the
+// background services, which run until ctx is done. It returns an error if any of it fails, in which
+// case nothing is left running.
+func New(ctx context.Context, cfg Config) (*Server, error) {
+	s := &Server{clock: cfg.Clock, weather: weather.FromEnv(), events: events.NewBus()}
+	if s.clock == nil {
+		s.clock = clock.Real
 	}
 
-	// Parse the DSN to safely append query parameters
-	u, err := url.Parse(dbPath)
+	var err error
+	s.tmpl, err = s.parseTemplates(cfg.Options...)
 	if err != nil {
-		return fmt.Errorf("failed to parse database path: %w", err)
+		return nil, err
+	}
+	s.commentary = applyOptions(cfg.Options).commentary
+	s.speech, err = newSpeechCache(cfg.DBPath)
+	if err != nil {
+		return nil, err
 	}
 
-	q := u.Query()
-	q.Set("_foreign_keys", "on")
-	u.RawQuery = q.Encode()
-
-	db, err = sql.Open("sqlite3", u.String())
+	s.db, err = sqlite.Open(cfg.DBPath)
 	if err != nil {
-		return fmt.Errorf("failed to open database at %s: %w", dbPath, err)
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	if err := s.load(ctx, cfg.DBPath); err != nil {
+		_ = s.db.Close()
+		return nil, err
 	}
+	s.handler = s.routes()
+	s.subscribe()
+
+	s.start(ctx)
+	return s, nil
+}
 
-	// Run migrations
-	if err := migrations.Run(ctx, db); err != nil {
+// load applies migrations to the database and loads what the server keeps in memory.
+func (s *Server) load(ctx context.Context, dbPath string) error {
+	if err := migrations.Run(ctx, s.db, migrations.WithBackup(dbPath)); err != nil {
 		return fmt.Errorf("failed to run migrations: %w", err)
 	}
-
 	slog.Info("database initialized successfully")
+	s.store = sqlite.New(s.db)
+
+	if err := s.loadTheme(ctx); err != nil {
+		return fmt.Errorf("failed to load theme: %w", err)
+	}
+	if err := s.loadMaintenance(ctx); err != nil {
+		return fmt.Errorf("failed to load maintenance mode: %w", err)
+	}
+
+	dailytexts.Preload(s.clock.Now().Year())
 
-	// Initialize the store
-	appStore = sqlite.New(db)
+	if err := s.indexReadings(ctx); err != nil {
+		slog.Error("failed to index daily readings", "error", err)
+	}
+	return nil
+}
 
+// start starts the background services, which run until ctx is done.
+func (s *Server) start(ctx context.Context) {
 	// Start the cache expunger service
-	expunger.Start(ctx, appStore)
+	expunger.Start(ctx, s.store, s.clock, s.events)
+
+	// Start posting the daily watchword to Discord and Slack
+	dailypost.Start(ctx, s.store, s.clock)
+
+	// Start reminding users who have stopped journaling
+	reminders.Start(ctx, s.store, s.clock)
+
+	// Start emailing subscribers the day's texts each morning
+	dailyemail.Start(ctx, s.store, s.clock)
+
+	// Start sending the admin a weekly report on the instance
+	metrics.Start(ctx, s.store, s.clock)
+
+	// Start making the years in review that users share once their year is over
+	yearreview.Start(ctx, s.store, s.clock)
+
+	// Start making the printable yearbooks users ask for
+	yearbook.Start(ctx, s.store)
+
+	// Start pruning the audio of entries read aloud that is no longer listened to
+	s.startSpeechPruner(ctx)
+
+	// Start checking a few cached passages a day against the API's current text
+	verifier.Start(ctx, s.store, s.clock, s.fetchToVerify)
 
 	// Start email background worker
 	emailClient, err := email.GetClient()
 	if err == nil {
-		go email.StartWorker(ctx, appStore, emailClient)
+		go email.StartWorker(ctx, s.store, emailClient)
 	} else {
 		slog.Warn("email worker not started due to missing configuration", "error", err)
 	}
+}
 
-	return nil
+// ServeHTTP serves the application.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.handler.ServeHTTP(w, r)
+}
+
+// Close closes the database. The background services must have been stopped by cancelling the context
+// New was called with.
+func (s *Server) Close() error {
+	return s.db.Close()
 }
 
 // handleSOAP handles GET and POST requests for SOAP data.
-func handleSOAP(w http.ResponseWriter, r *http.Request) {
+func (s *Server) handleSOAP(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
 	case http.MethodGet:
-		handleGetSOAP(w, r)
+		s.handleGetSOAP(w, r)
 	case http.MethodPost:
-		handlePostSOAP(w, r)
+		if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == "application/x-www-form-urlencoded" {
+			s.handlePostSOAPForm(w, r)
+			return
+		}
+		s.handlePostSOAP(w, r)
 	default:
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 	}
 }
 
 // handleGetSOAP retrieves SOAP data for a given date.
-func handleGetSOAP(w http.ResponseWriter, r *http.Request) {
+func (s *Server) handleGetSOAP(w http.ResponseWriter, r *http.Request) {
 	user := r.Context().Value(userContextKey).(*store.User)
-	dateStr := r.URL.Query().Get("date")
-	if dateStr == "" {
-		dateStr = time.Now().Format(time.DateOnly)
-	}
+	dateStr := requestDate(r).String()
 
-	soapData, err := appStore.GetSOAPData(r.Context(), user.ID, dateStr)
+	soapData, err := s.store.GetSOAPData(r.Context(), user.ID, dateStr)
 	if err != nil {
 		slog.Error("failed to get SOAP data", "date", dateStr, "error", err)
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
@@ -843,18 +1014,72 @@ func handleGetSOAP(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-// handlePostSOAP saves SOAP data.
-func handlePostSOAP(w http.ResponseWriter, r *http.Request) {
+// handleSOAPForm handles requests for the journal sections partial template (for HTMX), holding the
+// user's entry for the "date" query parameter. Defaults to today if not provided.
+func (s *Server) handleSOAPForm(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	user := r.Context().Value(userContextKey).(*store.User)
+	dateStr := requestDate(r).String()
+
+	soapData, err := s.store.GetSOAPData(r.Context(), user.ID, dateStr)
+	if err != nil {
+		slog.Error("failed to get SOAP data", "date", dateStr, "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	data := map[string]any{
+		"date":           dateStr,
+		"sections":       s.journalSchema(r.Context()).Entries(soapData.Sections),
+		"selectedVerses": soapData.SelectedVerses,
+		"revision":       soapData.Revision,
+	}
+	if err := s.tmpl.ExecuteTemplate(w, "soap_form.gotmpl", data); err != nil {
+		slog.Error("failed to execute SOAP form template", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
+
+// soapSave is a journal entry saved by the editor, with the revision its edits were based on. A save
+// without a base revision overwrites the entry whatever its revision.
+type soapSave struct {
+	store.SOAPData
+	BaseRevision *int64 `json:"baseRevision"`
+}
+
+// handlePostSOAP saves SOAP data with saveEntry, and returns the selection of verses saved. A save
+// based on a revision that is no longer current fails with 409 and the merge partial, for the user to
+// reconcile their edits with the entry as saved.
+func (s *Server) handlePostSOAP(w http.ResponseWriter, r *http.Request) {
 	user := r.Context().Value(userContextKey).(*store.User)
 
-	var soapData store.SOAPData
-	if err := json.NewDecoder(r.Body).Decode(&soapData); err != nil {
+	var save soapSave
+	if err := json.NewDecoder(r.Body).Decode(&save); err != nil {
 		slog.Error("failed to decode SOAP data", "error", err)
 		http.Error(w, "Bad request", http.StatusBadRequest)
 		return
 	}
-
-	if err := appStore.SaveSOAPData(r.Context(), user.ID, &soapData); err != nil {
+	soapData := &save.SOAPData
+	revision, err := s.saveEntry(r.Context(), user, &save)
+	var refused *entrySaveError
+	switch {
+	case errors.As(err, &refused) && refused.status == http.StatusInsufficientStorage:
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(refused.status)
+		if err := json.NewEncoder(w).Encode(map[string]any{"error": refused.msg, "overQuota": true}); err != nil {
+			slog.Error("failed to encode error response", "error", err)
+		}
+		return
+	case errors.As(err, &refused):
+		http.Error(w, refused.msg, refused.status)
+		return
+	case errors.Is(err, store.ErrConflict):
+		s.renderMerge(w, r, soapData, *save.BaseRevision, http.StatusConflict)
+		return
+	case err != nil:
 		slog.Error("failed to save SOAP data", "error", err)
 		w.Header().Set("Content-Type", "application/json")
 		if err := json.NewEncoder(w).Encode(map[string]string{"error": "Failed to save data"}); err != nil {
@@ -863,37 +1088,156 @@ func handlePostSOAP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	result := map[string]any{"status": "success", "selectedVerses": soapData.SelectedVerses}
+	if save.BaseRevision != nil {
+		result["revision"] = revision
+	}
 	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(map[string]string{"status": "success"}); err != nil {
+	if err := json.NewEncoder(w).Encode(result); err != nil {
 		slog.Error("failed to encode success response", "error", err)
 	}
 }
 
+// handlePostSOAPForm saves an entry submitted by the editor's form without JavaScript, and redirects
+// back to it. Only the sections of the user's journal schema and those already in the entry are read
+// from the form; the selected verses are given as a reference in "verses", e.g. "Romans 8:1-2, 28".
+func (s *Server) handlePostSOAPForm(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(userContextKey).(*store.User)
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid form", http.StatusBadRequest)
+		return
+	}
+	date, err := parseDate(r.PostFormValue("date"))
+	if err != nil {
+		http.Error(w, "Invalid date", http.StatusBadRequest)
+		return
+	}
+	saved, err := s.store.GetSOAPData(r.Context(), user.ID, date.String())
+	if err != nil {
+		slog.Error("failed to load SOAP data", "user_id", user.ID, "date", date, "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	save := soapSave{SOAPData: store.SOAPData{Date: date.String(), Sections: map[string]string{}, SelectedVerses: []string{}}}
+	for _, entry := range s.journalSchema(r.Context()).Entries(saved.Sections) {
+		if values, ok := r.PostForm[entry.Key]; ok {
+			save.Sections[entry.Key] = values[0]
+		}
+	}
+	if ref := strings.TrimSpace(r.PostFormValue("verses")); ref != "" {
+		if save.SelectedVerses, err = esv.ParseVerseIDs(ref); err != nil {
+			http.Error(w, "Invalid selected verses: "+ref, http.StatusBadRequest)
+			return
+		}
+	}
+
+	_, err = s.saveEntry(r.Context(), user, &save)
+	var refused *entrySaveError
+	if errors.As(err, &refused) {
+		http.Error(w, refused.msg, refused.status)
+		return
+	} else if err != nil {
+		slog.Error("failed to save SOAP data", "user_id", user.ID, "date", date, "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	redirectToDay(w, r, date.String())
+}
+
+// entrySaveError is a save of a journal entry refused by saveEntry, with the status to respond with
+// and a message for the user.
+type entrySaveError struct {
+	status int
+	msg    string
+}
+
+func (e *entrySaveError) Error() string { return e.msg }
+
+// saveEntry saves the user's journal entry, for the editor and the API. Selected verses are saved by
+// their canonical IDs, leaving out any that are not in the day's passages. It returns an
+// *entrySaveError if the entry is invalid or would take the user over their storage quota, and
+// store.ErrConflict if it is based on a revision that is no longer current. The revision saved is only
+// returned for a save with a base revision.
+func (s *Server) saveEntry(ctx context.Context, user *store.User, save *soapSave) (int64, error) {
+	soapData := &save.SOAPData
+	if _, err := parseDate(soapData.Date); err != nil {
+		return 0, &entrySaveError{http.StatusBadRequest, "Invalid date"}
+	}
+	for key := range soapData.Sections {
+		if !journal.ValidSectionKey(key) {
+			return 0, &entrySaveError{http.StatusBadRequest, "Invalid section"}
+		}
+	}
+	verses, err := s.selectableVerses(ctx, user, soapData.Date)
+	if err != nil {
+		return 0, fmt.Errorf("getting selectable verses: %w", err)
+	}
+	if soapData.SelectedVerses, err = verses.Select(soapData.SelectedVerses); err != nil {
+		return 0, &entrySaveError{http.StatusBadRequest, "Invalid selected verses"}
+	}
+	saved, err := s.store.GetSOAPData(ctx, user.ID, soapData.Date)
+	if err != nil {
+		return 0, fmt.Errorf("loading SOAP data: %w", err)
+	}
+	if msg, err := s.quotaExceeded(ctx, user.ID, entryGrowth(saved, soapData)); err != nil {
+		return 0, fmt.Errorf("checking storage quota: %w", err)
+	} else if msg != "" {
+		return 0, &entrySaveError{http.StatusInsufficientStorage, msg}
+	}
+
+	var revision int64
+	if save.BaseRevision != nil {
+		revision, err = s.store.SaveSOAPRevision(ctx, user.ID, soapData, *save.BaseRevision)
+	} else {
+		err = s.store.SaveSOAPData(ctx, user.ID, soapData)
+	}
+	if err != nil {
+		return 0, err
+	}
+	s.publishEntrySaved(ctx, user.ID, saved, soapData)
+	return revision, nil
+}
+
 type exportRequest struct {
 	Date       string   `json:"date"`
-	Format     string   `json:"format"`     // html or markdown
+	Format     string   `json:"format"`     // html or markdown; json or csv for several entries
 	Method     string   `json:"method"`     // download or email
 	Recipients []string `json:"recipients"` // only for method=email
+	// Without a date, every entry from From to To, inclusive, is exported. Either may be left out.
+	From string `json:"from"`
+	To   string `json:"to"`
+	// Types limits an export of several entries to those of the given export entry types.
+	Types []string `json:"types"`
+	// Scripture is whether the passages are exported with the entries, which they are if it is unset.
+	Scripture *bool `json:"scripture"`
 }
 
 // handleExport handles SOAP journal export requests.
-func handleExport(w http.ResponseWriter, r *http.Request) {
+func (s *Server) handleExport(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	var req exportRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	req, err := decodeExportRequest(r)
+	if err != nil {
 		slog.Error("failed to decode export request", "error", err)
 		http.Error(w, "Bad request", http.StatusBadRequest)
 		return
 	}
-
 	user := r.Context().Value(userContextKey).(*store.User)
+	if req.Date == "" {
+		s.exportEntries(w, r, user, req)
+		return
+	}
+	if _, err := parseDate(req.Date); err != nil {
+		http.Error(w, "Invalid date", http.StatusBadRequest)
+		return
+	}
 
-	// Fetch SOAP data via appStore.GetSOAPData(r.Context(), user.ID, req.Date)
-	soapData, err := appStore.GetSOAPData(r.Context(), user.ID, req.Date)
+	// Fetch SOAP data via s.store.GetSOAPData(r.Context(), user.ID, req.Date)
+	soapData, err := s.store.GetSOAPData(r.Context(), user.ID, req.Date)
 	if err != nil {
 		slog.Error("failed to get SOAP data for export", "date", req.Date, "error", err)
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
@@ -919,15 +1263,17 @@ func handleExport(w http.ResponseWriter, r *http.Request) {
 	if len(soapData.SelectedVerses) > 0 {
 		references = []string{esv.FormatReferences(soapData.SelectedVerses)}
 	}
-	verseContents, err := fetchPassagesWithCache(r.Context(), references)
-	if err != nil {
-		slog.Error("failed to fetch verses for export", "date", req.Date, "error", err)
-		http.Error(w, fmt.Sprintf("Error loading verses for %s", req.Date), http.StatusInternalServerError)
-		return
+	var scriptureHTML string
+	if req.Scripture == nil || *req.Scripture {
+		verseContents, err := s.prerenderPassages(r.Context(), [][]string{references}, s.passageOptions(r.Context()))
+		if err != nil {
+			slog.Error("failed to fetch verses for export", "date", req.Date, "error", err)
+			http.Error(w, fmt.Sprintf("Error loading verses for %s", req.Date), http.StatusInternalServerError)
+			return
+		}
+		scriptureHTML = strings.Join(verseContents[0].Passages, "\n")
 	}
 
-	scriptureHTML := strings.Join(verseContents.Passages, "\n")
-
 	// Email Logic:
 	if req.Method == "email" {
 		// Only allow format: html
@@ -940,7 +1286,7 @@ func handleExport(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		exporter, err := export.NewHTMLExporter()
+		exporter, err := newHTMLExporter()
 		if err != nil {
 			slog.Error("failed to create HTML exporter", "error", err)
 			http.Error(w, "Internal server error", http.StatusInternalServerError)
@@ -948,14 +1294,14 @@ func handleExport(w http.ResponseWriter, r *http.Request) {
 		}
 
 		var buf bytes.Buffer
-		if err := exporter.Export(r.Context(), &buf, soapData, scriptureHTML); err != nil {
+		if err := exporter.Export(r.Context(), &buf, soapData, s.journalSchema(r.Context()), scriptureHTML); err != nil {
 			slog.Error("failed to export HTML for email", "error", err)
 			http.Error(w, "Internal server error", http.StatusInternalServerError)
 			return
 		}
 
-		// Call email.QueueExportEmail(r.Context(), appStore, user, req.Date, req.Recipients, htmlContent)
-		if err := email.QueueExportEmail(r.Context(), appStore, user, req.Date, req.Recipients, buf.String()); err != nil {
+		// Call email.QueueExportEmail(r.Context(), s.store, user, req.Date, req.Recipients, htmlContent)
+		if err := email.QueueExportEmail(r.Context(), s.store, user, req.Date, req.Recipients, buf.String()); err != nil {
 			slog.Error("failed to queue export email", "error", err)
 			http.Error(w, "Internal server error", http.StatusInternalServerError)
 			return
@@ -977,7 +1323,7 @@ func handleExport(w http.ResponseWriter, r *http.Request) {
 		exporter, err = export.NewMarkdownExporter()
 		filename = fmt.Sprintf("soap-%s.md", req.Date)
 	} else {
-		exporter, err = export.NewHTMLExporter()
+		exporter, err = newHTMLExporter()
 		filename = fmt.Sprintf("soap-%s.html", req.Date)
 	}
 
@@ -992,7 +1338,7 @@ func handleExport(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
 
 	// Write generated content to w
-	if err := exporter.Export(r.Context(), w, soapData, scriptureHTML); err != nil {
+	if err := exporter.Export(r.Context(), w, soapData, s.journalSchema(r.Context()), scriptureHTML); err != nil {
 		slog.Error("failed to export content for download", "error", err)
 		// Note: headers already sent, can't change status code easily
 	}
@@ -1000,20 +1346,20 @@ func handleExport(w http.ResponseWriter, r *http.Request) {
 
 // User registration and authentication helpers
 
-func createUser(ctx context.Context, email, password, token, timezone string) error {
+func (s *Server) createUser(ctx context.Context, email, password, token, timezone string) error {
 	hashedPassword, err := auth.HashPassword(password)
 	if err != nil {
 		return fmt.Errorf("hashing password: %w", err)
 	}
 
-	if err := appStore.CreateUser(ctx, email, hashedPassword, token, timezone); err != nil {
+	if err := s.store.CreateUser(ctx, email, hashedPassword, token, timezone); err != nil {
 		return fmt.Errorf("creating user in store: %w", err)
 	}
 	return nil
 }
 
-func authenticateUser(ctx context.Context, email, password string) (*store.User, error) {
-	id, passwordHash, isVerified, timezone, err := appStore.GetAuthUser(ctx, email)
+func (s *Server) authenticateUser(ctx context.Context, email, password string) (*store.User, error) {
+	id, passwordHash, isVerified, timezone, err := s.store.GetAuthUser(ctx, email)
 	if err != nil {
 		return nil, fmt.Errorf("authenticating user %q: %w", email, err)
 	}
@@ -1026,7 +1372,7 @@ func authenticateUser(ctx context.Context, email, password string) (*store.User,
 	if needsUpgrade {
 		newHash, err := auth.HashPassword(password)
 		if err == nil {
-			err = appStore.UpdateUserPasswordHash(ctx, id, newHash)
+			err = s.store.UpdateUserPasswordHash(ctx, id, newHash)
 			if err != nil {
 				slog.Error("failed to migrate password hash", "user_id", id, "error", err)
 			}
@@ -1042,7 +1388,7 @@ func authenticateUser(ctx context.Context, email, password string) (*store.User,
 	return &store.User{ID: id, Email: email, IsVerified: isVerified, Timezone: timezone}, nil
 }
 
-func createSession(ctx context.Context, userID int64) (string, error) {
+func (s *Server) createSession(ctx context.Context, userID int64) (string, error) {
 	b := make([]byte, 32)
 	if _, err := rand.Read(b); err != nil {
 		return "", fmt.Errorf("generating session token: %w", err)
@@ -1050,58 +1396,152 @@ func createSession(ctx context.Context, userID int64) (string, error) {
 	token := base64.URLEncoding.EncodeToString(b)
 
 	// Clean up expired sessions first.
-	if err := appStore.DeleteExpiredSessions(ctx); err != nil {
+	if err := s.store.DeleteExpiredSessions(ctx); err != nil {
 		slog.Error("failed to cleanup expired sessions", "error", err)
 	}
 
-	expiresAt := time.Now().Add(24 * time.Hour * 30) // 30 days
-	err := appStore.CreateSession(ctx, token, userID, expiresAt)
+	expiresAt := s.clock.Now().Add(24 * time.Hour * 30) // 30 days
+	err := s.store.CreateSession(ctx, token, userID, expiresAt)
 	if err != nil {
 		return "", fmt.Errorf("saving session for user %d: %w", userID, err)
 	}
 	return token, nil
 }
 
-// fetchPassagesWithCache fetches verses from the cache or the ESV API.
-func fetchPassagesWithCache(ctx context.Context, references []string) (esv.Response, error) {
-	key := strings.Join(references, ";")
-	var response esv.Response
+// passageCache returns the cache of ESV passages, limited by the current configuration.
+func (s *Server) passageCache() *cache.Cache {
+	cfg := config.Current()
+	return cache.New(s.store, cache.Options{TTL: cfg.ESVCacheTTL, MaxEntries: cfg.ESVCacheMaxEntries})
+}
 
-	// 1. Check cache
-	content, err := appStore.GetCachedESV(ctx, key)
-	if err == nil {
-		// Cache hit
-		if err := json.Unmarshal([]byte(content), &response); err != nil {
-			// If unmarshal fails, log it and fall back to fetch
-			slog.Error("failed to unmarshal cached ESV response", "error", err)
-		} else {
-			slog.Debug("cache hit for verses", "reference", key)
-			return response, nil
+// cachedPassages returns verses rendered with opts from the cache, without calling the ESV API. It
+// reports false if they are not cached.
+func (s *Server) cachedPassages(ctx context.Context, references []string, opts esv.Options) (esv.Response, bool) {
+	response, err := s.passageCache().Get(ctx, references, opts)
+	if err != nil {
+		if !errors.Is(err, cache.ErrMiss) {
+			slog.Error("failed to read cached passages", "error", err)
 		}
-	} else if !errors.Is(err, sql.ErrNoRows) {
-		// Log DB error but proceed to fetch
-		slog.Error("failed to query esv_cache", "error", err)
+		return response, false
 	}
+	slog.Debug("cache hit for verses", "reference", cache.Key(references, opts))
+	return response, true
+}
 
-	// 2. Fetch from API
-	response, err = esv.FetchPassages(ctx, references)
-	if err != nil {
-		return response, fmt.Errorf("fetching passages %v from ESV: %w", references, err)
+// fetchPassagesWithCache fetches verses rendered with opts from the cache or the ESV API.
+func (s *Server) fetchPassagesWithCache(ctx context.Context, references []string, opts esv.Options) (esv.Response, error) {
+	if response, ok := s.cachedPassages(ctx, references, opts); ok {
+		return response, nil
 	}
 
-	// 3. Save to cache
-	responseBytes, err := json.Marshal(response)
+	response, logCalls, err := s.fetchPassages(ctx, references, opts)
 	if err != nil {
-		slog.Error("failed to marshal ESV response for cache", "error", err)
-		return response, nil // Return successful fetch even if cache save fails
+		logCalls(apiCallNotStored)
+		return response, err
 	}
 
-	err = appStore.SaveCachedESV(ctx, key, string(responseBytes))
-	if err != nil {
-		slog.Error("failed to save to esv_cache", "error", err)
+	// A successful fetch is returned even if it cannot be cached.
+	if err := s.passageCache().Put(ctx, references, opts, response); err != nil {
+		slog.Error("failed to cache passages", "error", err)
+		logCalls(apiCallNotStored)
 	} else {
-		slog.Debug("saved verses to cache", "reference", key)
+		slog.Debug("saved verses to cache", "reference", cache.Key(references, opts))
+		logCalls(apiCallStored)
 	}
-
 	return response, nil
 }
+
+// fetchPassages fetches verses rendered with opts from the translation's API, without the cache, and
+// counts the fetch against the ESV quota if it succeeds. The caller logs the API calls made with
+// logCalls, once it knows what became of the passages.
+func (s *Server) fetchPassages(ctx context.Context, references []string, opts esv.Options) (response esv.Response, logCalls func(outcome string), err error) {
+	var mu sync.Mutex
+	var calls []esv.Call
+	logCalls = func(outcome string) { s.logAPICalls(ctx, calls, outcome) }
+	opts.VerifyTransform = config.Current().ESVVerifyTransform
+	response, err = esv.FetchPassages(esv.WithCallObserver(ctx, func(c esv.Call) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls = append(calls, c)
+	}), references, opts)
+	if err != nil {
+		return response, logCalls, fmt.Errorf("fetching passages %v from ESV: %w", references, err)
+	}
+	if fetchesFromESV(opts) {
+		s.recordESVUsage(ctx)
+	}
+	return response, logCalls, nil
+}
+
+// What became of the passages fetched by a call to a translation's API, as logged.
+const (
+	apiCallStored    = "stored"
+	apiCallNotStored = "not stored"
+	apiCallVerified  = "verified"
+)
+
+// fetchToVerify fetches verses rendered with opts from the translation's API for the cache verifier,
+// which compares them with the cache and refreshes them itself.
+func (s *Server) fetchToVerify(ctx context.Context, references []string, opts esv.Options) (esv.Response, error) {
+	response, logCalls, err := s.fetchPassages(ctx, references, opts)
+	logCalls(apiCallVerified)
+	return response, err
+}
+
+// apiLogSize is the number of calls to translations' APIs kept in the log for the admin.
+const apiLogSize = 1000
+
+// logAPICalls logs calls to translations' APIs, with what became of the passages they fetched, to slog
+// and to the API log the admin troubleshoots with.
+func (s *Server) logAPICalls(ctx context.Context, calls []esv.Call, outcome string) {
+	for _, c := range calls {
+		slog.Info("translation API call", "translation", c.Translation, "references", c.References, "url", c.URL,
+			"status", c.Status, "bytes", c.Bytes, "latency", c.Latency, "retries", c.Retries, "cache", outcome, "error", c.Err)
+		call := &store.APICall{
+			At:          s.clock.Now(),
+			Translation: c.Translation,
+			References:  c.References,
+			URL:         c.URL,
+			Status:      c.Status,
+			Bytes:       c.Bytes,
+			Latency:     c.Latency,
+			Retries:     c.Retries,
+			Cache:       outcome,
+			Error:       c.Err,
+		}
+		if err := s.store.RecordAPICall(ctx, call, apiLogSize); err != nil {
+			slog.Error("failed to record API call", "error", err)
+		}
+	}
+}
+
+// esvBudgetShare is the share of the page budget that a page waits for passages from the ESV API,
+// leaving the rest for loading the journal and rendering.
+const esvBudgetShare = 3 / 4.0
+
+// fetchPassagesWithinBudget fetches verses rendered with opts from the cache or the ESV API, waiting
+// for the API at most esvBudgetShare of the page budget. It reports false if the passages are not ready
+// in time; the fetch carries on in the background and caches them for a later request.
+func (s *Server) fetchPassagesWithinBudget(ctx context.Context, references []string, opts esv.Options) (esv.Response, bool, error) {
+	if response, ok := s.cachedPassages(ctx, references, opts); ok {
+		return response, true, nil
+	}
+
+	budget := time.Duration(float64(config.Current().PageBudget) * esvBudgetShare)
+	ctx, cancel := context.WithTimeout(ctx, budget)
+	defer cancel()
+	fetched := s.passageFetches.DoChan(cache.Key(references, opts), func() (any, error) {
+		// The fetch must outlive the request so that its result is cached; the ESV client bounds it.
+		return s.fetchPassagesWithCache(context.WithoutCancel(ctx), references, opts)
+	})
+	select {
+	case res := <-fetched:
+		if res.Err != nil {
+			return esv.Response{}, false, res.Err
+		}
+		return res.Val.(esv.Response), true, nil
+	case <-ctx.Done():
+		slog.Warn("ESV fetch exceeded page budget", "references", references, "budget", budget)
+		return esv.Response{}, false, nil
+	}
+}
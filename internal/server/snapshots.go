@@ -0,0 +1,191 @@
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"derrclan.com/moravian-soap/internal/esv"
+	"derrclan.com/moravian-soap/internal/store"
+)
+
+// Limits on the day snapshots kept in memory. A snapshot is used for at most snapshotMaxAge, so that
+// passages refreshed in the cache reach sessions that keep returning to a day.
+const (
+	snapshotMaxAge = 10 * time.Minute
+	maxSnapshots   = 1000
+)
+
+// daySnapshot is what was loaded to render a day's page for a session: the day's reading, its passages
+// marked with the user's highlights and verse notes, and the user's entry and what hangs off it.
+type daySnapshot struct {
+	userID     int64
+	generation uint64
+	at         time.Time
+
+	reading        *dailyReading
+	passages       esv.Response
+	entry          *store.SOAPData
+	prayerItems    []*store.PrayerItem
+	linkedEntries  *store.LinkedEntries
+	relatedEntries []*store.RelatedEntry
+}
+
+// daySnapshots keeps the snapshots of the days rendered in each session, so that going back to a day
+// renders it from memory after a single check that the user has changed nothing since: each change a
+// user makes moves them to a new generation, and snapshots of an earlier one are stale.
+type daySnapshots struct {
+	mu          sync.Mutex
+	generations map[int64]uint64
+	snapshots   map[string]*daySnapshot
+}
+
+// generation returns the user's current generation. It must be read before loading what a snapshot
+// holds, so that a change made meanwhile leaves the snapshot stale.
+func (d *daySnapshots) generation(userID int64) uint64 {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.generations[userID]
+}
+
+// get returns the snapshot kept under key if it is of the user's current generation and no older than
+// snapshotMaxAge at now, or nil.
+func (d *daySnapshots) get(key string, userID int64, now time.Time) *daySnapshot {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	snap := d.snapshots[key]
+	if snap == nil {
+		return nil
+	}
+	if snap.userID != userID || snap.generation != d.generations[userID] || now.Sub(snap.at) >= snapshotMaxAge {
+		delete(d.snapshots, key)
+		return nil
+	}
+	return snap
+}
+
+// put keeps snap under key, unless the user has changed something since the generation it was loaded
+// at. Once maxSnapshots are kept, expired snapshots are dropped, and then, if there are still too many,
+// the oldest.
+func (d *daySnapshots) put(key string, snap *daySnapshot) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if snap.generation != d.generations[snap.userID] {
+		return
+	}
+	if d.snapshots == nil {
+		d.snapshots = make(map[string]*daySnapshot)
+	}
+	if _, ok := d.snapshots[key]; !ok && len(d.snapshots) >= maxSnapshots {
+		var oldest string
+		for k, s := range d.snapshots {
+			if snap.at.Sub(s.at) >= snapshotMaxAge {
+				delete(d.snapshots, k)
+			} else if oldest == "" || s.at.Before(d.snapshots[oldest].at) {
+				oldest = k
+			}
+		}
+		if len(d.snapshots) >= maxSnapshots {
+			delete(d.snapshots, oldest)
+		}
+	}
+	d.snapshots[key] = snap
+}
+
+// invalidate moves the user to a new generation, dropping the snapshots of every session they have.
+func (d *daySnapshots) invalidate(userID int64) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.generations == nil {
+		d.generations = make(map[int64]uint64)
+	}
+	d.generations[userID]++
+	for key, snap := range d.snapshots {
+		if snap.userID == userID {
+			delete(d.snapshots, key)
+		}
+	}
+}
+
+// snapshotKey returns the key of the request's session's snapshot of date, or "" if the request has
+// no session. The session token is hashed so that it is not kept in memory any longer than it has to.
+func snapshotKey(r *http.Request, date string) string {
+	cookie, err := r.Cookie("session_token")
+	if err != nil || cookie.Value == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(cookie.Value))
+	return hex.EncodeToString(sum[:16]) + "|" + date + "|" + requestTranslation(r.Context())
+}
+
+// invalidateSnapshotsMiddleware moves the signed-in user to a new generation of day snapshots after any
+// request that may change what they hold, which is any request that is not a GET or HEAD.
+func (s *Server) invalidateSnapshotsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r)
+		if r.Method == http.MethodGet || r.Method == http.MethodHead {
+			return
+		}
+		if user, ok := r.Context().Value(userContextKey).(*store.User); ok {
+			s.snapshots.invalidate(user.ID)
+		}
+	})
+}
+
+// loadDay returns what is needed to render date's page for the user: the session's snapshot of it, if
+// it is still fresh, or else everything loaded afresh, which is then kept as the session's snapshot if
+// all of it loaded. It responds with an error and reports false if the day's reading cannot be loaded.
+func (s *Server) loadDay(w http.ResponseWriter, r *http.Request, user *store.User, date string) (*daySnapshot, bool) {
+	ctx := r.Context()
+	key := snapshotKey(r, date)
+	if key != "" {
+		if snap := s.snapshots.get(key, user.ID, s.clock.Now()); snap != nil {
+			return snap, true
+		}
+	}
+
+	snap := &daySnapshot{userID: user.ID, generation: s.snapshots.generation(user.ID), at: s.clock.Now()}
+	reading, ok := s.dailyReadingOrError(w, r, date)
+	if !ok {
+		return nil, false
+	}
+	snap.reading = reading
+	snap.passages = s.markHighlights(ctx, user.ID, s.markVerseNotes(ctx, user.ID, reading.passages))
+	// A reading still loading, or listing its references while the ESV API is unavailable, is loaded
+	// again next time.
+	complete := reading.loaded && reading.references == nil
+
+	entry, err := s.store.GetSOAPData(ctx, user.ID, date)
+	if err != nil {
+		slog.Warn("failed to load SOAP data", "date", date, "error", err)
+		// Continue with empty values if there's an error
+		entry = &store.SOAPData{
+			Date:           date,
+			Sections:       map[string]string{},
+			SelectedVerses: []string{},
+		}
+		complete = false
+	}
+	snap.entry = entry
+
+	if snap.prayerItems, err = s.store.GetPrayerItems(ctx, user.ID, date); err != nil {
+		slog.Warn("failed to load prayer items", "date", date, "error", err)
+		complete = false
+	}
+	if snap.linkedEntries, err = s.store.GetLinkedEntries(ctx, user.ID, date); err != nil {
+		slog.Warn("failed to load linked entries", "date", date, "error", err)
+		complete = false
+	}
+	if snap.relatedEntries, err = s.store.GetRelatedEntries(ctx, user.ID, date); err != nil {
+		slog.Warn("failed to load related entries", "date", date, "error", err)
+		complete = false
+	}
+
+	if key != "" && complete {
+		s.snapshots.put(key, snap)
+	}
+	return snap, true
+}
@@ -0,0 +1,73 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"derrclan.com/moravian-soap/internal/clock"
+	"derrclan.com/moravian-soap/internal/events"
+)
+
+func TestDaySnapshots(t *testing.T) {
+	var d daySnapshots
+	now := time.Date(2026, 3, 7, 8, 0, 0, 0, time.UTC)
+
+	d.put("a|2026-03-07|", &daySnapshot{userID: 1, generation: d.generation(1), at: now})
+	if d.get("a|2026-03-07|", 1, now.Add(time.Minute)) == nil {
+		t.Fatal("fresh snapshot was not kept")
+	}
+	if d.get("a|2026-03-07|", 2, now) != nil {
+		t.Error("another user's snapshot was returned")
+	}
+	if d.get("a|2026-03-07|", 1, now) != nil {
+		t.Error("snapshot was returned after being found under another user")
+	}
+
+	d.put("a|2026-03-07|", &daySnapshot{userID: 1, generation: d.generation(1), at: now})
+	if d.get("a|2026-03-07|", 1, now.Add(snapshotMaxAge)) != nil {
+		t.Error("expired snapshot was returned")
+	}
+
+	// A change by the user leaves the snapshots of each of their sessions stale, including one still
+	// being loaded when it was made, but not another user's.
+	d.put("a|2026-03-07|", &daySnapshot{userID: 1, generation: d.generation(1), at: now})
+	d.put("b|2026-03-07|", &daySnapshot{userID: 2, generation: d.generation(2), at: now})
+	loading := &daySnapshot{userID: 1, generation: d.generation(1), at: now}
+	d.invalidate(1)
+	d.put("c|2026-03-07|", loading)
+	for _, key := range []string{"a|2026-03-07|", "c|2026-03-07|"} {
+		if d.get(key, 1, now) != nil {
+			t.Errorf("snapshot %s was returned after the user changed something", key)
+		}
+	}
+	if d.get("b|2026-03-07|", 2, now) == nil {
+		t.Error("another user's snapshot was dropped")
+	}
+}
+
+func TestDaySnapshots_Limit(t *testing.T) {
+	var d daySnapshots
+	now := time.Date(2026, 3, 7, 8, 0, 0, 0, time.UTC)
+	for i := range maxSnapshots + 1 {
+		d.put(fmt.Sprint(i), &daySnapshot{userID: 1, at: now.Add(time.Duration(i) * time.Millisecond)})
+	}
+	if len(d.snapshots) != maxSnapshots {
+		t.Errorf("%d snapshots kept, want %d", len(d.snapshots), maxSnapshots)
+	}
+	if d.snapshots["0"] != nil || d.snapshots[fmt.Sprint(maxSnapshots)] == nil {
+		t.Error("the oldest snapshot was not the one dropped")
+	}
+}
+
+func TestDaySnapshots_EntryEvents(t *testing.T) {
+	srv := &Server{clock: clock.Real, events: events.NewBus()}
+	srv.subscribe()
+	srv.snapshots.put("a|2026-03-07|", &daySnapshot{userID: 1, at: time.Now()})
+
+	srv.events.Publish(context.Background(), events.Event{Kind: events.EntryDeleted, UserID: 1, Date: "2026-03-07"})
+	if srv.snapshots.get("a|2026-03-07|", 1, time.Now()) != nil {
+		t.Error("snapshot was returned after the user's entry was deleted")
+	}
+}
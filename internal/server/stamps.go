@@ -0,0 +1,108 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"derrclan.com/moravian-soap/internal/store"
+	"derrclan.com/moravian-soap/internal/weather"
+)
+
+// maxStampLocation is the longest place name an entry can be stamped with, in characters.
+const maxStampLocation = 100
+
+// handleStamp renders the stamp partial for a date's entry (GET), or stamps the entry with where it is
+// being written and the weather there now (POST, with a location the user typed or coordinates from the
+// browser, or both) or removes its stamp (POST, with an action of "clear"). Coordinates are rounded to
+// about 10 km before they are recorded or sent to the weather service.
+func (s *Server) handleStamp(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(userContextKey).(*store.User)
+	dateStr := requestDate(r).String()
+
+	var message string
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		var location, conditions string
+		if r.FormValue("action") != "clear" {
+			var err error
+			location, conditions, err = s.stamp(r.Context(), r.FormValue("location"), r.FormValue("latitude"), r.FormValue("longitude"))
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			if conditions == "" {
+				message = "The weather could not be found, so only the place was noted."
+			}
+		}
+		err := s.store.SaveEntryStamp(r.Context(), user.ID, dateStr, location, conditions)
+		if errors.Is(err, sql.ErrNoRows) {
+			message = "Write something first, then note where you are."
+		} else if err != nil {
+			slog.Error("failed to save entry stamp", "user_id", user.ID, "date", dateStr, "error", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	soapData, err := s.store.GetSOAPData(r.Context(), user.ID, dateStr)
+	if err != nil {
+		slog.Error("failed to get SOAP data", "date", dateStr, "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	data := map[string]any{"date": dateStr, "stamp": soapData.Stamp(), "stampMessage": message}
+	if err := s.tmpl.ExecuteTemplate(w, "entry_stamp.gotmpl", data); err != nil {
+		slog.Error("failed to execute entry stamp template", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
+
+// stamp returns the place and the weather there now to stamp an entry with, from the location the user
+// typed and the coordinates from their browser, either of which may be empty. Without a name, the place
+// is its rounded coordinates; without coordinates, the place is looked up by name. The weather is empty
+// if it cannot be found.
+func (s *Server) stamp(ctx context.Context, location, latitude, longitude string) (string, string, error) {
+	location = strings.TrimSpace(location)
+	if utf8.RuneCountInString(location) > maxStampLocation {
+		return "", "", fmt.Errorf("the place must be at most %d characters", maxStampLocation)
+	}
+
+	var place weather.Place
+	if latitude != "" || longitude != "" {
+		lat, latErr := strconv.ParseFloat(latitude, 64)
+		lon, lonErr := strconv.ParseFloat(longitude, 64)
+		if latErr != nil || lonErr != nil || lat < -90 || lat > 90 || lon < -180 || lon > 180 {
+			return "", "", errors.New("invalid coordinates")
+		}
+		place = weather.Place{Latitude: weather.Coarse(lat), Longitude: weather.Coarse(lon)}
+		if location == "" {
+			location = fmt.Sprintf("%.1f, %.1f", place.Latitude, place.Longitude)
+		}
+	} else if location != "" {
+		var err error
+		if place, err = s.weather.Lookup(ctx, location); err != nil {
+			slog.Warn("failed to look up place for stamp", "error", err)
+			return location, "", nil
+		}
+	} else {
+		return "", "", errors.New("say where you are or use your location")
+	}
+
+	conditions, err := s.weather.Current(ctx, weather.Coarse(place.Latitude), weather.Coarse(place.Longitude))
+	if err != nil {
+		slog.Warn("failed to get weather for stamp", "error", err)
+		return location, "", nil
+	}
+	return location, conditions.String(), nil
+}
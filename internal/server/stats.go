@@ -0,0 +1,142 @@
+package server
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"derrclan.com/moravian-soap/internal/civil"
+	"derrclan.com/moravian-soap/internal/dailytexts"
+	"derrclan.com/moravian-soap/internal/pace"
+	"derrclan.com/moravian-soap/internal/store"
+)
+
+// statsWindowDays is the number of recent days summarized alongside the all-time journaling stats.
+const statsWindowDays = 30
+
+// Catch-up plans spread missed days over defaultCatchUpWeeks, or as many weeks up to maxCatchUpWeeks as
+// the user chooses.
+const (
+	defaultCatchUpWeeks = 4
+	maxCatchUpWeeks     = 12
+)
+
+// handleStats renders the stats dashboard.
+func (s *Server) handleStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	user := r.Context().Value(userContextKey).(*store.User)
+	today := s.userToday(user)
+	since := today.AddDays(-(statsWindowDays - 1)).String()
+
+	allTime, err := s.store.GetJournalingStats(r.Context(), user.ID, "")
+	if err != nil {
+		slog.Error("failed to get journaling stats", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	recent, err := s.store.GetJournalingStats(r.Context(), user.ID, since)
+	if err != nil {
+		slog.Error("failed to get journaling stats", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	recentCompleteness, err := s.store.CountEntryCompleteness(r.Context(), user.ID, since)
+	if err != nil {
+		slog.Error("failed to count entry completeness", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	allTimeCompleteness, err := s.store.CountEntryCompleteness(r.Context(), user.ID, "")
+	if err != nil {
+		slog.Error("failed to count entry completeness", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	recentQuietTime, err := s.store.GetQuietTimeStats(r.Context(), user.ID, since)
+	if err != nil {
+		slog.Error("failed to get quiet time stats", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	allTimeQuietTime, err := s.store.GetQuietTimeStats(r.Context(), user.ID, "")
+	if err != nil {
+		slog.Error("failed to get quiet time stats", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	weeks, err := strconv.Atoi(r.URL.Query().Get("weeks"))
+	if err != nil || weeks < 1 || weeks > maxCatchUpWeeks {
+		weeks = defaultCatchUpWeeks
+	}
+	var projection *pace.Projection
+	var catchUp []pace.Week
+	if dailytexts.HasYear(today.Year) {
+		yearStart := civil.Date{Year: today.Year, Month: 1, Day: 1}
+		completeness, err := s.store.GetEntryCompleteness(r.Context(), user.ID, yearStart.String(), today.String())
+		if err != nil {
+			slog.Error("failed to get entry completeness", "error", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		p := pace.Project(today, completeness)
+		projection = &p
+		catchUp = pace.CatchUp(today, p.Missed, weeks)
+	}
+
+	data := map[string]any{
+		"user":                user,
+		"windowDays":          statsWindowDays,
+		"recent":              journalingSummary(recent),
+		"allTime":             journalingSummary(allTime),
+		"recentCompleteness":  recentCompleteness,
+		"allTimeCompleteness": allTimeCompleteness,
+		"recentQuietTime":     quietTimeSummary(recentQuietTime),
+		"allTimeQuietTime":    quietTimeSummary(allTimeQuietTime),
+		"pace":                projection,
+		"catchUp":             catchUp,
+		"catchUpWeeks":        weeks,
+		"maxCatchUpWeeks":     maxCatchUpWeeks,
+		"CSRFToken":           r.Context().Value(csrfContextKey).(string),
+		"Nonce":               r.Context().Value(nonceContextKey).(string),
+	}
+	if err := s.tmpl.ExecuteTemplate(w, "stats.html", data); err != nil {
+		slog.Error("failed to execute stats template", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
+
+// journalingSummary formats journaling stats for display.
+func journalingSummary(stats *store.JournalingStats) map[string]any {
+	return map[string]any{
+		"Entries": stats.Entries,
+		"Average": formatMinutes(stats.Average()),
+		"Total":   formatMinutes(stats.Total),
+		"Longest": formatMinutes(stats.Longest),
+	}
+}
+
+// quietTimeSummary formats quiet time stats for display.
+func quietTimeSummary(stats *store.QuietTimeStats) map[string]any {
+	return map[string]any{
+		"Sessions": stats.Sessions,
+		"Total":    formatMinutes(stats.Total),
+	}
+}
+
+// formatMinutes formats d as a whole number of minutes, or hours and minutes once it reaches an hour.
+func formatMinutes(d time.Duration) string {
+	minutes := int(d.Round(time.Minute) / time.Minute)
+	if minutes < 60 {
+		return fmt.Sprintf("%d min", minutes)
+	}
+	return fmt.Sprintf("%d h %d min", minutes/60, minutes%60)
+}
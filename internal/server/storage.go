@@ -0,0 +1,211 @@
+package server
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"derrclan.com/moravian-soap/internal/store"
+)
+
+// storageQuotaSettingKey is the instance setting that holds the most space, in megabytes, each user's
+// data may take up, unless the admin gives them their own quota. It is blank or 0 for no limit.
+const storageQuotaSettingKey = "storage_quota_mb"
+
+// megabyte is the unit quotas are set in.
+const megabyte = 1 << 20
+
+// formatBytes formats a number of bytes for people, e.g. "512 B", "1.5 KB" or "20.0 MB".
+func formatBytes(n int64) string {
+	switch {
+	case n < 1<<10:
+		return fmt.Sprintf("%d B", n)
+	case n < megabyte:
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	default:
+		return fmt.Sprintf("%.1f MB", float64(n)/megabyte)
+	}
+}
+
+// instanceStorageQuota returns the instance's limit on each user's data, in bytes, or 0 for none.
+func (s *Server) instanceStorageQuota(ctx context.Context) (int64, error) {
+	settings, err := s.store.GetInstanceSettings(ctx)
+	if err != nil {
+		return 0, err
+	}
+	mb, _ := strconv.ParseInt(settings[storageQuotaSettingKey], 10, 64)
+	return max(mb, 0) * megabyte, nil
+}
+
+// storageQuota returns the limit on the data of the user with usage, in bytes, or 0 for none: their own
+// quota, if the admin gave them one, or else the instance's.
+func (s *Server) storageQuota(ctx context.Context, usage *store.StorageUsage) (int64, error) {
+	if usage.Quota > 0 {
+		return usage.Quota, nil
+	}
+	return s.instanceStorageQuota(ctx)
+}
+
+// quotaExceeded returns a message for the user if adding grow bytes to their data would take it over
+// their quota. Saves that do not grow the user's data are always allowed, so that they can shorten or
+// remove what they wrote once over their quota.
+func (s *Server) quotaExceeded(ctx context.Context, userID, grow int64) (string, error) {
+	if grow <= 0 {
+		return "", nil
+	}
+	usage, err := s.store.GetStorageUsage(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+	quota, err := s.storageQuota(ctx, usage)
+	if err != nil {
+		return "", err
+	}
+	if quota == 0 || usage.Total()+grow <= quota {
+		return "", nil
+	}
+	slog.Warn("storage quota exceeded", "user_id", userID, "usage", usage.Total(), "grow", grow, "quota", quota)
+	return fmt.Sprintf("Your account is using %s of the %s of storage it is allowed, so this cannot be saved. "+
+		"Shorten or delete some of what you have written, or ask the administrator for more room.",
+		formatBytes(usage.Total()), formatBytes(quota)), nil
+}
+
+// entryGrowth returns how many bytes saving updated over the entry as saved, old, adds to the user's
+// data. Only the sections in updated are saved, so the others are left out.
+func entryGrowth(old, updated *store.SOAPData) int64 {
+	var grow int64
+	for key, content := range updated.Sections {
+		grow += int64(len(content) - len(old.Sections[key]))
+	}
+	oldVerses, _ := json.Marshal(old.SelectedVerses)
+	newVerses, _ := json.Marshal(updated.SelectedVerses)
+	return grow + int64(len(newVerses)-len(oldVerses))
+}
+
+// storageRow is a user's line in the admin's storage table.
+type storageRow struct {
+	*store.StorageUsage
+	Total, Entries, PassageEntries, Notes, Yearbooks string
+	// QuotaMB is the user's own quota in megabytes, or 0 if the instance's applies.
+	QuotaMB int64
+	// Percent is how much of their quota the user has used, or -1 if they have none.
+	Percent int64
+}
+
+// handleAdminStorage shows the admin the space each user's data takes up against their quota (GET), and
+// sets the instance's quota (POST, with an action of "default"), sets a user's own quota or removes it
+// ("quota"), or deletes a user's yearbooks, which they can ask for again ("delete-yearbooks").
+func (s *Server) handleAdminStorage(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(userContextKey).(*store.User)
+
+	var errMsg string
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		id, _ := strconv.ParseInt(r.FormValue("id"), 10, 64)
+		quota, ok := parseQuotaMB(r.FormValue("quota"))
+		var err error
+		switch r.FormValue("action") {
+		case "default":
+			if !ok {
+				errMsg = badQuotaMessage
+				break
+			}
+			value := ""
+			if quota > 0 {
+				value = strconv.FormatInt(quota, 10)
+			}
+			err = s.store.SaveInstanceSettings(r.Context(), map[string]string{storageQuotaSettingKey: value})
+		case "quota":
+			if !ok {
+				errMsg = badQuotaMessage
+				break
+			}
+			err = s.store.SetStorageQuota(r.Context(), id, quota*megabyte)
+		case "delete-yearbooks":
+			err = s.store.DeleteYearbooks(r.Context(), id)
+		default:
+			http.Error(w, "Unknown action", http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			slog.Error("failed to update storage", "action", r.FormValue("action"), "user_id", id, "error", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		if errMsg == "" {
+			slog.Info("storage updated", "admin_id", user.ID, "action", r.FormValue("action"), "user_id", id)
+			http.Redirect(w, r, "/admin/storage", http.StatusSeeOther)
+			return
+		}
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	usage, err := s.store.ListStorageUsage(r.Context())
+	if err != nil {
+		slog.Error("failed to list storage usage", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	instanceQuota, err := s.instanceStorageQuota(r.Context())
+	if err != nil {
+		slog.Error("failed to get instance settings", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	var total int64
+	rows := make([]storageRow, 0, len(usage))
+	for _, u := range usage {
+		total += u.Total()
+		row := storageRow{
+			StorageUsage:   u,
+			Total:          formatBytes(u.Total()),
+			Entries:        formatBytes(u.Entries),
+			PassageEntries: formatBytes(u.PassageEntries),
+			Notes:          formatBytes(u.Notes),
+			Yearbooks:      formatBytes(u.Yearbooks),
+			QuotaMB:        u.Quota / megabyte,
+			Percent:        -1,
+		}
+		if quota := cmp.Or(u.Quota, instanceQuota); quota > 0 {
+			row.Percent = u.Total() * 100 / quota
+		}
+		rows = append(rows, row)
+	}
+
+	data := map[string]any{
+		"user":      user,
+		"usage":     rows,
+		"total":     formatBytes(total),
+		"quotaMB":   instanceQuota / megabyte,
+		"Error":     errMsg,
+		"CSRFToken": r.Context().Value(csrfContextKey).(string),
+		"Nonce":     r.Context().Value(nonceContextKey).(string),
+	}
+	if err := s.tmpl.ExecuteTemplate(w, "admin_storage.html", data); err != nil {
+		slog.Error("failed to execute admin_storage template", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
+
+// badQuotaMessage is shown to the admin for a quota parseQuotaMB does not accept.
+const badQuotaMessage = "Enter the quota as a whole number of megabytes, or leave it blank for no limit."
+
+// parseQuotaMB parses a quota in megabytes as entered by the admin, and reports whether it is valid. A
+// blank quota is 0, for none.
+func parseQuotaMB(s string) (int64, bool) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, true
+	}
+	mb, err := strconv.ParseInt(s, 10, 64)
+	return mb, err == nil && mb >= 0
+}
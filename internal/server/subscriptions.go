@@ -0,0 +1,276 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"derrclan.com/moravian-soap/internal/email"
+	"derrclan.com/moravian-soap/internal/store"
+)
+
+// errSubscriptionCancelled is returned when confirming a subscription that was unsubscribed from after
+// the confirmation was sent.
+var errSubscriptionCancelled = errors.New("subscription was cancelled")
+
+// subscriptionResponse is an email subscription as reported by the API.
+type subscriptionResponse struct {
+	List   string `json:"list"`
+	Status string `json:"status"`
+	// Time is when the daily email is sent, as HH:MM in Timezone.
+	Time     string `json:"time,omitempty"`
+	Timezone string `json:"timezone"`
+}
+
+// subscriptionRequest subscribes to an email list through the API. Timezone is optional, and keeps the
+// user's timezone if empty.
+type subscriptionRequest struct {
+	List     string `json:"list"`
+	Time     string `json:"time"`
+	Timezone string `json:"timezone"`
+}
+
+// dailyEmailSubscription returns the user's subscription to the daily email, or nil if they have never
+// subscribed.
+func (s *Server) dailyEmailSubscription(ctx context.Context, userID int64) (*store.EmailSubscription, error) {
+	subs, err := s.store.ListEmailSubscriptions(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, sub := range subs {
+		if sub.List == store.DailyEmailList {
+			return sub, nil
+		}
+	}
+	return nil, nil
+}
+
+// subscribeDailyEmail subscribes the user to the daily email. A new subscription, or one the user had
+// unsubscribed from, is pending until they confirm it from the link emailed to them, which is sent again
+// if they subscribe while it is still pending. An active subscription is left as it is.
+func (s *Server) subscribeDailyEmail(ctx context.Context, user *store.User) (*store.EmailSubscription, error) {
+	sub, err := s.dailyEmailSubscription(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if sub != nil && sub.Status == store.SubscriptionActive {
+		return sub, nil
+	}
+	if sub == nil || sub.Status == store.SubscriptionUnsubscribed {
+		sub = &store.EmailSubscription{UserID: user.ID, List: store.DailyEmailList, Token: generateRandomString(32)}
+	}
+	sub.Status = store.SubscriptionPending
+	if err := s.store.SaveEmailSubscription(ctx, sub); err != nil {
+		return nil, err
+	}
+
+	baseURL := os.Getenv("BASE_URL")
+	if baseURL == "" {
+		baseURL = "http://localhost:8080"
+	}
+	confirmURL := fmt.Sprintf("%s/subscriptions/confirm?token=%s", baseURL, url.QueryEscape(sub.Token))
+	if err := email.Queue(ctx, s.store, user.ID, user.Email, email.SubscriptionConfirmationEmail(confirmURL)); err != nil {
+		return nil, fmt.Errorf("queueing subscription confirmation: %w", err)
+	}
+	return sub, nil
+}
+
+// unsubscribeDailyEmail unsubscribes the user from the daily email. It returns nil if they have never
+// subscribed.
+func (s *Server) unsubscribeDailyEmail(ctx context.Context, userID int64) (*store.EmailSubscription, error) {
+	sub, err := s.dailyEmailSubscription(ctx, userID)
+	if err != nil || sub == nil || sub.Status == store.SubscriptionUnsubscribed {
+		return sub, err
+	}
+	sub.Status = store.SubscriptionUnsubscribed
+	if err := s.store.SaveEmailSubscription(ctx, sub); err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
+
+// validDailyEmailTime reports whether t is a time of day the daily email can be sent at, as HH:MM.
+func validDailyEmailTime(t string) bool {
+	_, err := time.Parse("15:04", t)
+	return err == nil
+}
+
+// validTimezone reports whether tz names a timezone a user can choose.
+func validTimezone(tz string) bool {
+	_, err := time.LoadLocation(tz)
+	return err == nil && tz != "" && tz != "Local"
+}
+
+// handleAPISubscriptions lists the user's email subscriptions (GET), subscribes them to a list (POST),
+// or unsubscribes them from the list named by the list parameter (DELETE). A new subscription is
+// pending, with a 202, until it is confirmed from the link emailed to the user.
+func (s *Server) handleAPISubscriptions(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(userContextKey).(*store.User)
+
+	switch r.Method {
+	case http.MethodGet:
+		subs, err := s.store.ListEmailSubscriptions(r.Context(), user.ID)
+		if err != nil {
+			slog.Error("failed to list email subscriptions", "user_id", user.ID, "error", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		prefs, err := s.store.GetPreferences(r.Context(), user.ID)
+		if err != nil {
+			slog.Error("failed to get preferences", "user_id", user.ID, "error", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		resp := []subscriptionResponse{}
+		for _, sub := range subs {
+			resp = append(resp, subscriptionResponse{List: sub.List, Status: sub.Status, Time: prefs.DailyEmailTime, Timezone: user.Timezone})
+		}
+		writeJSON(w, resp)
+	case http.MethodPost:
+		var req subscriptionRequest
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<10)).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		if req.List != store.DailyEmailList {
+			http.Error(w, "Unknown list", http.StatusBadRequest)
+			return
+		}
+		if !validDailyEmailTime(req.Time) {
+			http.Error(w, "Invalid send time", http.StatusBadRequest)
+			return
+		}
+		if req.Timezone != "" && req.Timezone != user.Timezone {
+			if !validTimezone(req.Timezone) {
+				http.Error(w, "Invalid timezone", http.StatusBadRequest)
+				return
+			}
+			if err := s.store.UpdateUserTimezone(r.Context(), user.ID, req.Timezone); err != nil {
+				slog.Error("failed to update timezone", "user_id", user.ID, "error", err)
+				http.Error(w, "Internal server error", http.StatusInternalServerError)
+				return
+			}
+			user.Timezone = req.Timezone
+		}
+		prefs, err := s.store.GetPreferences(r.Context(), user.ID)
+		if err == nil {
+			prefs.DailyEmailTime = req.Time
+			err = s.store.SavePreferences(r.Context(), user.ID, prefs)
+		}
+		if err != nil {
+			slog.Error("failed to save daily email time", "user_id", user.ID, "error", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		sub, err := s.subscribeDailyEmail(r.Context(), user)
+		if err != nil {
+			slog.Error("failed to subscribe to the daily email", "user_id", user.ID, "error", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		if sub.Status == store.SubscriptionPending {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusAccepted)
+		}
+		writeJSON(w, subscriptionResponse{List: sub.List, Status: sub.Status, Time: req.Time, Timezone: user.Timezone})
+	case http.MethodDelete:
+		if r.URL.Query().Get("list") != store.DailyEmailList {
+			http.Error(w, "Unknown list", http.StatusBadRequest)
+			return
+		}
+		sub, err := s.unsubscribeDailyEmail(r.Context(), user.ID)
+		if err != nil {
+			slog.Error("failed to unsubscribe from the daily email", "user_id", user.ID, "error", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		if sub == nil {
+			http.NotFound(w, r)
+			return
+		}
+		writeJSON(w, subscriptionResponse{List: sub.List, Status: sub.Status, Timezone: user.Timezone})
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleSubscriptionConfirm confirms the subscription with the token in the link emailed to the user.
+// The link shows a button that confirms it, so that mail scanners following links do not.
+func (s *Server) handleSubscriptionConfirm(w http.ResponseWriter, r *http.Request) {
+	s.handleSubscriptionLink(w, r, "confirm", func(sub *store.EmailSubscription) (string, error) {
+		switch sub.Status {
+		case store.SubscriptionActive:
+			return "Your daily email is already confirmed.", nil
+		case store.SubscriptionUnsubscribed:
+			return "", errSubscriptionCancelled
+		}
+		sub.Status = store.SubscriptionActive
+		if err := s.store.SaveEmailSubscription(r.Context(), sub); err != nil {
+			return "", err
+		}
+		slog.Info("daily email subscription confirmed", "user_id", sub.UserID)
+		return "Your daily email is confirmed. The first one arrives at the time you chose.", nil
+	})
+}
+
+// handleSubscriptionUnsubscribe unsubscribes from the subscription with the token in the link in each
+// daily email, without signing in.
+func (s *Server) handleSubscriptionUnsubscribe(w http.ResponseWriter, r *http.Request) {
+	s.handleSubscriptionLink(w, r, "unsubscribe", func(sub *store.EmailSubscription) (string, error) {
+		if sub.Status != store.SubscriptionUnsubscribed {
+			sub.Status = store.SubscriptionUnsubscribed
+			if err := s.store.SaveEmailSubscription(r.Context(), sub); err != nil {
+				return "", err
+			}
+			slog.Info("unsubscribed from the daily email", "user_id", sub.UserID)
+		}
+		return "You are unsubscribed from the daily email.", nil
+	})
+}
+
+// handleSubscriptionLink shows the page of an emailed subscription link with the given action, and
+// applies the action to the subscription when its button is pressed. apply returns the message to
+// show.
+func (s *Server) handleSubscriptionLink(w http.ResponseWriter, r *http.Request, action string, apply func(*store.EmailSubscription) (string, error)) {
+	data := map[string]any{
+		"action":    action,
+		"token":     r.FormValue("token"),
+		"CSRFToken": r.Context().Value(csrfContextKey).(string),
+		"Nonce":     r.Context().Value(nonceContextKey).(string),
+	}
+	sub, err := s.store.GetEmailSubscriptionByToken(r.Context(), r.FormValue("token"))
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		w.WriteHeader(http.StatusNotFound)
+		data["Error"] = "This link is invalid or has expired."
+	case err != nil:
+		slog.Error("failed to get email subscription", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	case r.Method == http.MethodPost:
+		msg, err := apply(sub)
+		if errors.Is(err, errSubscriptionCancelled) {
+			data["Error"] = "You unsubscribed from the daily email after this link was sent. Subscribe again from your reminder settings."
+			break
+		}
+		if err != nil {
+			slog.Error("failed to update email subscription", "user_id", sub.UserID, "action", action, "error", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		data["Success"] = msg
+	case r.Method != http.MethodGet:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if err := s.tmpl.ExecuteTemplate(w, "subscription.html", data); err != nil {
+		slog.Error("failed to execute subscription template", "error", err)
+	}
+}
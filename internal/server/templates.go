@@ -0,0 +1,136 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"maps"
+	"slices"
+	"strings"
+
+	"derrclan.com/moravian-soap/internal/commentary"
+)
+
+// builtinTemplateFuncs are the functions predefined by html/template, which cannot be replaced.
+var builtinTemplateFuncs = []string{
+	"and", "call", "eq", "ge", "gt", "html", "index", "js", "le", "len", "lt", "ne", "not", "or",
+	"print", "printf", "println", "slice", "urlquery",
+}
+
+// templateFuncs returns the functions available to the embedded templates.
+func (s *Server) templateFuncs() template.FuncMap {
+	return template.FuncMap{
+		"safeHTML": func(s string) template.HTML {
+			return template.HTML(s) // #nosec G203
+		},
+		"toJSON": func(v any) (template.JS, error) {
+			b, err := json.Marshal(v)
+			if err != nil {
+				return "", fmt.Errorf("marshaling JSON: %w", err)
+			}
+			return template.JS(b), nil // #nosec G203
+		},
+		"themeCSS": s.themeCSS,
+		"feature":  featureFunc,
+		"asset":    assetURL,
+	}
+}
+
+// Option customizes a Server for a deployment.
+type Option func(*options)
+
+type options struct {
+	funcs      []template.FuncMap
+	partials   []partialSource
+	commentary commentary.Commentary
+}
+
+// applyOptions returns the options set by opts.
+func applyOptions(opts []Option) options {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// partialSource is a set of template files added with WithPartials.
+type partialSource struct {
+	fsys     fs.FS
+	patterns []string
+}
+
+// WithTemplateFuncs makes funcs available to every template, including partials added with
+// WithPartials. A function may not have the name of a predefined or embedded function, or of a
+// function added by another option.
+func WithTemplateFuncs(funcs template.FuncMap) Option {
+	return func(o *options) {
+		o.funcs = append(o.funcs, maps.Clone(funcs))
+	}
+}
+
+// WithPartials adds the templates in the files of fsys matching patterns. Each file defines a template
+// named after it, such as footer.gotmpl, and may define others with {{define}}. A partial replaces the
+// embedded partial with its name, so a deployment can supply its own footer, but it may not replace a
+// page or a template added by another file.
+func WithPartials(fsys fs.FS, patterns ...string) Option {
+	return func(o *options) {
+		o.partials = append(o.partials, partialSource{fsys: fsys, patterns: patterns})
+	}
+}
+
+// WithCommentary shows the commentary c supplies on each day's reading below its passages. If it is
+// given more than once, the last is used.
+func WithCommentary(c commentary.Commentary) Option {
+	return func(o *options) {
+		o.commentary = c
+	}
+}
+
+// parseTemplates parses the embedded templates along with the functions and partials added by opts.
+func (s *Server) parseTemplates(opts ...Option) (*template.Template, error) {
+	o := applyOptions(opts)
+
+	funcs := s.templateFuncs()
+	for _, added := range o.funcs {
+		for name, fn := range added {
+			if slices.Contains(builtinTemplateFuncs, name) || funcs[name] != nil {
+				return nil, fmt.Errorf("template function %q is already defined", name)
+			}
+			funcs[name] = fn
+		}
+	}
+
+	t, err := template.New("").Funcs(funcs).ParseFS(web, "web/*.html", "web/*.gotmpl")
+	if err != nil {
+		return nil, fmt.Errorf("parsing embedded templates: %w", err)
+	}
+
+	// Partials are parsed on their own first, so that the templates they define can be checked
+	// before they replace any embedded ones.
+	defined := map[string]bool{}
+	for _, src := range o.partials {
+		partials, err := template.New("").Funcs(funcs).ParseFS(src.fsys, src.patterns...)
+		if err != nil {
+			return nil, fmt.Errorf("parsing partials: %w", err)
+		}
+		for _, p := range partials.Templates() {
+			name := p.Name()
+			if name == "" || p.Tree == nil {
+				continue
+			}
+			if defined[name] {
+				return nil, fmt.Errorf("partial %q is defined more than once", name)
+			}
+			if strings.HasSuffix(name, ".html") && t.Lookup(name) != nil {
+				return nil, fmt.Errorf("partial %q would replace a page", name)
+			}
+			defined[name] = true
+			if _, err := t.AddParseTree(name, p.Tree); err != nil {
+				return nil, fmt.Errorf("adding partial %q: %w", name, err)
+			}
+		}
+	}
+	return t, nil
+}
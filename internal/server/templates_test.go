@@ -0,0 +1,50 @@
+package server
+
+import (
+	"html/template"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestParseTemplates(t *testing.T) {
+	partials := fstest.MapFS{
+		"footer.gotmpl": {Data: []byte(`<footer>{{shout "St. Luke's"}}</footer>`)},
+	}
+	tmpl, err := new(Server).parseTemplates(
+		WithTemplateFuncs(template.FuncMap{"shout": strings.ToUpper}),
+		WithPartials(partials, "*.gotmpl"),
+	)
+	if err != nil {
+		t.Fatalf("parseTemplates failed: %v", err)
+	}
+	var b strings.Builder
+	if err := tmpl.ExecuteTemplate(&b, "footer.gotmpl", nil); err != nil {
+		t.Fatalf("executing footer failed: %v", err)
+	}
+	if got, want := b.String(), "<footer>ST. LUKE&#39;S</footer>"; got != want {
+		t.Errorf("footer = %q, want %q", got, want)
+	}
+}
+
+func TestParseTemplates_Conflicts(t *testing.T) {
+	tests := map[string][]Option{
+		"predefined function": {WithTemplateFuncs(template.FuncMap{"len": strings.ToUpper})},
+		"embedded function":   {WithTemplateFuncs(template.FuncMap{"toJSON": strings.ToUpper})},
+		"function added twice": {
+			WithTemplateFuncs(template.FuncMap{"shout": strings.ToUpper}),
+			WithTemplateFuncs(template.FuncMap{"shout": strings.ToLower}),
+		},
+		"page": {WithPartials(fstest.MapFS{"index.html": {Data: []byte("hijacked")}}, "*.html")},
+		"partial added twice": {
+			WithPartials(fstest.MapFS{"footer.gotmpl": {Data: []byte("one")}}, "*.gotmpl"),
+			WithPartials(fstest.MapFS{"extra.gotmpl": {Data: []byte(`{{define "footer.gotmpl"}}two{{end}}`)}}, "*.gotmpl"),
+		},
+		"unknown function": {WithPartials(fstest.MapFS{"footer.gotmpl": {Data: []byte("{{shout .}}")}}, "*.gotmpl")},
+	}
+	for name, opts := range tests {
+		if _, err := new(Server).parseTemplates(opts...); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"derrclan.com/moravian-soap/internal/store"
+	"derrclan.com/moravian-soap/internal/theme"
+)
+
+// Keys of the instance settings that hold the theme.
+const (
+	themeSettingKey     = "theme"
+	customCSSSettingKey = "custom_css"
+)
+
+// themeCSS returns the instance's stylesheet for the page head.
+func (s *Server) themeCSS() template.CSS {
+	if css := s.instanceCSS.Load(); css != nil {
+		return template.CSS(*css) // #nosec G203 -- custom CSS is checked by theme.ValidateCustomCSS
+	}
+	return ""
+}
+
+// loadTheme reads the instance's theme from the store into s.instanceCSS.
+func (s *Server) loadTheme(ctx context.Context) error {
+	settings, err := s.store.GetInstanceSettings(ctx)
+	if err != nil {
+		return fmt.Errorf("getting instance settings: %w", err)
+	}
+	css := theme.Stylesheet(settings[themeSettingKey], settings[customCSSSettingKey])
+	s.instanceCSS.Store(&css)
+	return nil
+}
+
+// handleAdminTheme lets the admin choose the instance's theme and add custom CSS.
+func (s *Server) handleAdminTheme(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(userContextKey).(*store.User)
+
+	var success, errMsg string
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		name := r.FormValue("theme")
+		customCSS := strings.TrimSpace(r.FormValue("custom_css"))
+		if _, ok := theme.Get(name); !ok {
+			http.Error(w, "Bad request", http.StatusBadRequest)
+			return
+		}
+		if err := theme.ValidateCustomCSS(customCSS); err != nil {
+			errMsg = "Could not save the theme: " + err.Error() + "."
+			break
+		}
+		settings := map[string]string{themeSettingKey: name, customCSSSettingKey: customCSS}
+		if err := s.store.SaveInstanceSettings(r.Context(), settings); err != nil {
+			slog.Error("failed to save theme", "error", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		css := theme.Stylesheet(name, customCSS)
+		s.instanceCSS.Store(&css)
+		success = "The theme has been saved."
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	settings, err := s.store.GetInstanceSettings(r.Context())
+	if err != nil {
+		slog.Error("failed to get instance settings", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	current := settings[themeSettingKey]
+	if _, ok := theme.Get(current); !ok {
+		current = theme.Default
+	}
+
+	data := map[string]any{
+		"user":      user,
+		"themes":    theme.List(),
+		"current":   current,
+		"customCSS": settings[customCSSSettingKey],
+		"Success":   success,
+		"Error":     errMsg,
+		"CSRFToken": r.Context().Value(csrfContextKey).(string),
+		"Nonce":     r.Context().Value(nonceContextKey).(string),
+	}
+	if err := s.tmpl.ExecuteTemplate(w, "admin_theme.html", data); err != nil {
+		slog.Error("failed to execute admin_theme template", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
+
+// handleAdminThemePreview renders the stylesheet of the theme and custom CSS in the admin's form, without
+// saving them, so the admin page can show how they look.
+func (s *Server) handleAdminThemePreview(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	customCSS := strings.TrimSpace(r.FormValue("custom_css"))
+	if theme.ValidateCustomCSS(customCSS) != nil {
+		// Preview the theme alone until the custom CSS is valid.
+		customCSS = ""
+	}
+	css := template.CSS(theme.Stylesheet(r.FormValue("theme"), customCSS)) // #nosec G203 -- checked above
+	if err := s.tmpl.ExecuteTemplate(w, "theme_css.gotmpl", css); err != nil {
+		slog.Error("failed to execute theme_css template", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,41 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"derrclan.com/moravian-soap/internal/esv"
+)
+
+// translationMiddleware reads the translation parameter, with which a request may ask for passages in
+// a translation other than the user's preferred one. Unknown translations are rejected.
+func translationMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := r.URL.Query().Get("translation")
+		if name == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		t, ok := esv.Lookup(name)
+		if !ok {
+			http.Error(w, "Unknown translation", http.StatusBadRequest)
+			return
+		}
+		ctx := context.WithValue(r.Context(), translationContextKey, t.Name())
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// requestTranslation returns the name of the translation the request asked for with its translation
+// parameter, or "" if it did not ask for one.
+func requestTranslation(ctx context.Context) string {
+	name, _ := ctx.Value(translationContextKey).(string)
+	return name
+}
+
+// fetchesFromESV reports whether passages rendered with opts come from the ESV API, and so count
+// towards its daily quota.
+func fetchesFromESV(opts esv.Options) bool {
+	return opts.Translation == "" || strings.EqualFold(opts.Translation, esv.DefaultTranslation)
+}
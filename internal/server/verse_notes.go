@@ -0,0 +1,154 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+
+	"derrclan.com/moravian-soap/internal/esv"
+	"derrclan.com/moravian-soap/internal/store"
+)
+
+// maxVerseNoteLength is the longest verse note, in characters. Verse notes are meant to be short;
+// longer thoughts belong in the journal entry.
+const maxVerseNoteLength = 500
+
+// verseRefRE matches an 8-digit verse reference, e.g. "43003016" for John 3:16.
+var verseRefRE = regexp.MustCompile(`^\d{8}$`)
+
+// handleVerseNote renders the popover for viewing and editing the user's note on a verse (GET), or
+// saves the note and re-renders the popover (POST). Saving an empty note removes it. Saves trigger a
+// verseNoteSaved event so the page can show or hide the verse's note marker.
+func (s *Server) handleVerseNote(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(userContextKey).(*store.User)
+	ref := r.FormValue("ref")
+	if !verseRefRE.MatchString(ref) {
+		http.Error(w, "Invalid verse", http.StatusBadRequest)
+		return
+	}
+
+	var note, errMsg string
+	var saved bool
+	switch r.Method {
+	case http.MethodGet:
+		notes, err := s.store.GetVerseNotes(r.Context(), user.ID, []string{ref})
+		if err != nil {
+			slog.Error("failed to get verse note", "user_id", user.ID, "ref", ref, "error", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		if len(notes) > 0 {
+			note = notes[0].Note
+		}
+	case http.MethodPost:
+		note = strings.TrimSpace(r.FormValue("note"))
+		if utf8.RuneCountInString(note) > maxVerseNoteLength {
+			http.Error(w, "Note is too long", http.StatusBadRequest)
+			return
+		}
+		notes, err := s.store.GetVerseNotes(r.Context(), user.ID, []string{ref})
+		if err != nil {
+			slog.Error("failed to get verse note", "user_id", user.ID, "ref", ref, "error", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		grow := int64(len(note))
+		if len(notes) > 0 {
+			grow -= int64(len(notes[0].Note))
+		}
+		if errMsg, err = s.quotaExceeded(r.Context(), user.ID, grow); err != nil {
+			slog.Error("failed to check storage quota", "user_id", user.ID, "error", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		if errMsg != "" {
+			break
+		}
+		if err := s.store.SaveVerseNote(r.Context(), user.ID, ref, note); err != nil {
+			slog.Error("failed to save verse note", "user_id", user.ID, "ref", ref, "error", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		saved = true
+		trigger, _ := json.Marshal(map[string]any{"verseNoteSaved": map[string]any{"ref": ref, "hasNote": note != ""}})
+		w.Header().Set("HX-Trigger", string(trigger))
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	data := map[string]any{
+		"ref":       ref,
+		"reference": esv.FormatReferences([]string{ref}),
+		"note":      note,
+		"saved":     saved,
+		"error":     errMsg,
+		"maxLength": maxVerseNoteLength,
+	}
+	if err := s.tmpl.ExecuteTemplate(w, "verse_note.gotmpl", data); err != nil {
+		slog.Error("failed to execute verse_note template", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
+
+// markVerseNotes returns the passages with a marker after each verse the user has a note on, which
+// opens the note in a popover. Passages are returned unmarked if the notes cannot be loaded.
+func (s *Server) markVerseNotes(ctx context.Context, userID int64, resp esv.Response) esv.Response {
+	var refs []string
+	for _, p := range resp.Passages {
+		refs = append(refs, esv.VerseRefs(p)...)
+	}
+	notes, err := s.store.GetVerseNotes(ctx, userID, refs)
+	if err != nil {
+		slog.Error("failed to get verse notes", "user_id", userID, "error", err)
+		return resp
+	}
+	if len(notes) == 0 {
+		return resp
+	}
+	noted := make([]string, len(notes))
+	for i, n := range notes {
+		noted[i] = n.Ref
+	}
+
+	// The passages may be shared with other requests, so they are marked in a copy.
+	marked := make([]string, len(resp.Passages))
+	for i, p := range resp.Passages {
+		m, err := esv.AppendToVerses(p, noted, verseNoteMarker)
+		if err != nil {
+			slog.Error("failed to mark verse notes", "user_id", userID, "error", err)
+			return resp
+		}
+		marked[i] = m
+	}
+	resp.Passages = marked
+	return resp
+}
+
+// verseNoteMarker returns the button shown after a verse with a note. app.js builds the same button
+// when a note is added without reloading the passage.
+func verseNoteMarker(ref string) *html.Node {
+	button := &html.Node{
+		Type:     html.ElementNode,
+		Data:     "button",
+		DataAtom: atom.Button,
+		Attr: []html.Attribute{
+			{Key: "type", Val: "button"},
+			{Key: "class", Val: "verse-note-marker"},
+			{Key: "data-note-ref", Val: ref},
+			{Key: "popovertarget", Val: "verse-note"},
+			{Key: "hx-get", Val: "/notes/verse?ref=" + ref},
+			{Key: "hx-target", Val: "#verse-note"},
+			{Key: "aria-label", Val: "Note on " + esv.FormatReferences([]string{ref})},
+		},
+	}
+	button.AppendChild(&html.Node{Type: html.TextNode, Data: "✎"})
+	return button
+}
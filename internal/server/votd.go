@@ -0,0 +1,127 @@
+package server
+
+import (
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"derrclan.com/moravian-soap/internal/dailytexts"
+	"derrclan.com/moravian-soap/internal/esv"
+	"derrclan.com/moravian-soap/internal/htmltext"
+	"derrclan.com/moravian-soap/internal/store"
+)
+
+// votdOptions renders the verse of the day as a bare quotation, without headings, verse numbers or
+// copyright notes, which are reported separately.
+var votdOptions = esv.Options{}
+
+// votd is the verse of the day returned by the API. All fields are always present so displays can
+// rely on the schema; text fields are empty when there is nothing to show.
+type votd struct {
+	Date     string `json:"date"`
+	Timezone string `json:"timezone"`
+	// Reference is the citation of the watchword, e.g. "Psalm 115:2-3" or "Psalm 34:8; 1 Peter 2:3".
+	Reference string `json:"reference"`
+	// References are each of the Bible references in Reference.
+	References []string `json:"references"`
+	// Watchword is the watchword as printed in the Daily Texts, including its reference.
+	Watchword string `json:"watchword"`
+	// Text and HTML are the ESV text of all the references as one block, as plain text and as rendered
+	// by the ESV API.
+	Text string `json:"text"`
+	HTML string `json:"html"`
+	// Passages are the ESV text of each reference, so that verses chosen in the block can be told apart
+	// by the reference they belong to.
+	Passages    []votdPassage   `json:"passages"`
+	Attribution votdAttribution `json:"attribution"`
+}
+
+// votdPassage is the ESV text of one of the references of the verse of the day.
+type votdPassage struct {
+	Reference string `json:"reference"`
+	Text      string `json:"text"`
+	HTML      string `json:"html"`
+}
+
+// votdAttribution holds the credits that must be shown with the verse of the day.
+type votdAttribution struct {
+	Watchword string `json:"watchword"`
+	Text      string `json:"text"`
+}
+
+// handleAPIVotd returns today's watchword as a verse of the day. "Today" is in the user's timezone, or in
+// the timezone given by the "tz" query parameter (an IANA name such as "Europe/Berlin"), so a display can
+// be pinned to where it hangs. The ESV text comes from the passage cache.
+func (s *Server) handleAPIVotd(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	user := r.Context().Value(userContextKey).(*store.User)
+
+	tz := user.Timezone
+	if q := r.URL.Query().Get("tz"); q != "" {
+		// "Local" would be the server's timezone, which means nothing to the client.
+		if _, err := time.LoadLocation(q); err != nil || q == "Local" {
+			http.Error(w, "Invalid timezone", http.StatusBadRequest)
+			return
+		}
+		tz = q
+	}
+	loc, err := time.LoadLocation(tz)
+	if err != nil || tz == "" {
+		tz, loc = "UTC", time.UTC
+	}
+	today := s.todayIn(loc)
+
+	text, err := dailytexts.GetDailyText(today.String())
+	if err != nil {
+		slog.Error("failed to get daily text", "date", today, "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if text == nil {
+		http.Error(w, "No daily text for today", http.StatusNotFound)
+		return
+	}
+
+	v := votd{
+		Date:        today.String(),
+		Timezone:    tz,
+		Reference:   text.WatchwordReference(),
+		References:  text.WatchwordReferences(),
+		Watchword:   text.DailyWatchWord,
+		Passages:    []votdPassage{},
+		Attribution: votdAttribution{Watchword: dailytexts.Attribution},
+	}
+	if v.References == nil {
+		v.References = []string{}
+	}
+	if len(v.References) > 0 {
+		// The references are fetched together but kept distinct, a passage each.
+		resp, err := s.fetchPassagesWithCache(r.Context(), v.References, votdOptions)
+		if err != nil {
+			// The watchword is still worth showing without the ESV text.
+			slog.Warn("failed to fetch verse of the day", "reference", v.Reference, "error", err)
+		} else if len(resp.Passages) > 0 {
+			v.HTML = strings.Join(resp.Passages, "\n")
+			v.Attribution.Text = resp.Copyright
+			var texts []string
+			for i, passage := range resp.Passages {
+				t, err := htmltext.ToText(passage)
+				if err != nil {
+					slog.Warn("failed to convert verse of the day to text", "reference", v.Reference, "error", err)
+					break
+				}
+				texts = append(texts, t)
+				if len(resp.Passages) == len(v.References) {
+					v.Passages = append(v.Passages, votdPassage{Reference: v.References[i], Text: t, HTML: passage})
+				}
+			}
+			v.Text = strings.Join(texts, "\n\n")
+		}
+	}
+
+	writeCacheableJSON(w, r, v)
+}
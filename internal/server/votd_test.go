@@ -0,0 +1,126 @@
+package server_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"derrclan.com/moravian-soap/internal/dailytexts"
+	"derrclan.com/moravian-soap/internal/testutil"
+)
+
+func TestAPIVotd(t *testing.T) {
+	srv := testutil.NewServer(t)
+	client := srv.Login(t, "mirror@example.com")
+
+	// These timezones are 25 hours apart, so they are never on the same date.
+	user, err := srv.Store.GetUserByEmail(context.Background(), "mirror@example.com")
+	if err != nil {
+		t.Fatalf("GetUserByEmail failed: %v", err)
+	}
+	if err := srv.Store.UpdateUserTimezone(context.Background(), user.ID, "Pacific/Pago_Pago"); err != nil {
+		t.Fatalf("UpdateUserTimezone failed: %v", err)
+	}
+	dateIn := func(tz string) string {
+		loc, err := time.LoadLocation(tz)
+		if err != nil {
+			t.Fatalf("loading %s: %v", tz, err)
+		}
+		return time.Now().In(loc).Format(time.DateOnly)
+	}
+	srv.SetDailyText(t, dateIn("Pacific/Pago_Pago"), dailytexts.DailyText{DailyWatchWord: "The LORD is my shepherd; I shall not want. Psalm 23:1"})
+	srv.SetDailyText(t, dateIn("Pacific/Kiritimati"), dailytexts.DailyText{DailyWatchWord: "Jesus wept. John 11:35 NIV"})
+	srv.ESV.AddPassage("Psalm 23:1", testutil.Passage{HTML: `<p><b class="verse-num" id="v19023001-1">1&nbsp;</b>The LORD is my shepherd; I shall not want.</p>`})
+
+	get := func(query string) (*http.Response, map[string]any) {
+		t.Helper()
+		resp, err := client.Get(srv.URL + "/api/v1/votd" + query)
+		if err != nil {
+			t.Fatalf("GET /api/v1/votd%s failed: %v", query, err)
+		}
+		defer resp.Body.Close()
+		var body map[string]any
+		if resp.StatusCode == http.StatusOK {
+			if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+				t.Fatalf("decoding response: %v", err)
+			}
+		}
+		return resp, body
+	}
+
+	resp, v := get("")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want 200", resp.StatusCode)
+	}
+	want := map[string]string{
+		"date":      dateIn("Pacific/Pago_Pago"),
+		"timezone":  "Pacific/Pago_Pago",
+		"reference": "Psalm 23:1",
+		"text":      "The LORD is my shepherd; I shall not want.",
+	}
+	for k, w := range want {
+		if v[k] != w {
+			t.Errorf("%s = %q, want %q", k, v[k], w)
+		}
+	}
+	if attribution, _ := v["attribution"].(map[string]any); attribution["text"] != "ESV" || attribution["watchword"] == "" {
+		t.Errorf("unexpected attribution: %v", v["attribution"])
+	}
+
+	_, v = get("?tz=Pacific/Kiritimati")
+	if v["date"] != dateIn("Pacific/Kiritimati") || v["reference"] != "John 11:35" || v["timezone"] != "Pacific/Kiritimati" {
+		t.Errorf("pinned timezone ignored: %v", v)
+	}
+
+	if resp, _ := get("?tz=Not/AZone"); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("invalid timezone: status = %d, want 400", resp.StatusCode)
+	}
+
+	// Only the two distinct references were fetched; the repeat request was served from the cache.
+	get("")
+	if n := len(srv.ESV.Queries()); n != 2 {
+		t.Errorf("ESV queried %d times, want 2", n)
+	}
+}
+
+func TestAPIVotd_MultipleReferences(t *testing.T) {
+	srv := testutil.NewServer(t)
+	client := srv.Login(t, "mirror@example.com")
+	today := time.Now().UTC().Format(time.DateOnly)
+	srv.SetDailyText(t, today, dailytexts.DailyText{DailyWatchWord: "Taste and see that the LORD is good. Psalm 34:8; 1 Peter 2:3"})
+	srv.ESV.AddPassage("Psalm 34:8", testutil.Passage{HTML: `<p><b class="verse-num" id="v19034008-1">8&nbsp;</b>Oh, taste and see that the LORD is good!</p>`})
+	srv.ESV.AddPassage("1 Peter 2:3", testutil.Passage{HTML: `<p><b class="verse-num" id="v60002003-1">3&nbsp;</b>if indeed you have tasted that the Lord is good.</p>`})
+
+	resp, err := client.Get(srv.URL + "/api/v1/votd?tz=UTC")
+	if err != nil {
+		t.Fatalf("GET /api/v1/votd failed: %v", err)
+	}
+	defer resp.Body.Close()
+	var v struct {
+		Reference  string
+		References []string
+		Text       string
+		Passages   []struct{ Reference, Text, HTML string }
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&v); err != nil {
+		t.Fatalf("decoding response: %v", err)
+	}
+
+	if v.Reference != "Psalm 34:8; 1 Peter 2:3" || !slices.Equal(v.References, []string{"Psalm 34:8", "1 Peter 2:3"}) {
+		t.Errorf("reference = %q, references = %q", v.Reference, v.References)
+	}
+	if !strings.Contains(v.Text, "taste and see") || !strings.Contains(v.Text, "tasted that the Lord") {
+		t.Errorf("text = %q, want both passages in one block", v.Text)
+	}
+	if len(v.Passages) != 2 || v.Passages[0].Reference != "Psalm 34:8" || !strings.Contains(v.Passages[0].HTML, `data-ref="19034008"`) ||
+		v.Passages[1].Reference != "1 Peter 2:3" || !strings.Contains(v.Passages[1].HTML, `data-ref="60002003"`) {
+		t.Errorf("passages = %+v, want each verse under its own reference", v.Passages)
+	}
+	if queries := srv.ESV.Queries(); !slices.Equal(queries, []string{"Psalm 34:8;1 Peter 2:3"}) {
+		t.Errorf("ESV queries = %q, want the references fetched together", queries)
+	}
+}
@@ -1,4 +1,4 @@
-import { formatVerseReference, parseVerseId } from './logic.js';
+import { formatCountdown, formatSaveStatus, formatVerseReference, parseVerseId, sectionName } from './logic.js';
 
 const timezone = Intl.DateTimeFormat().resolvedOptions().timeZone;
 
@@ -14,12 +14,12 @@ if (authForm) {
 
 // Get data from the page (set by inline script in HTML)
 let currentDate = window.SOAP_DATA?.date || '';
-const observationField = document.getElementById('observation');
-const applicationField = document.getElementById('application');
-const prayerField = document.getElementById('prayer');
+const journalSections = document.getElementById('journal-sections');
 const saveStatus = document.getElementById('saveStatus');
+const sectionSaveStatus = document.getElementById('sectionSaveStatus');
 const selectedVersesReference = document.getElementById('selectedVersesReference');
 const datePicker = document.getElementById('date-picker');
+const verseNoteBtn = document.getElementById('verse-note-btn');
 
 // Export Modal Elements
 const shareBtn = document.getElementById('share-btn');
@@ -33,6 +33,22 @@ const recipientsInput = document.getElementById('export-recipients');
 let saveTimeout = null;
 const SAVE_DELAY = 1000; // 1 second after last change
 
+// The revision each loaded entry was last saved at, by date, which the next save of it is based on.
+// Saves are sent one at a time so that none is based on a revision another is about to replace.
+const revisions = {};
+let saveInFlight = false;
+let pendingSave = null;
+
+// The sections of the current entry with edits not yet saved, and the save state of each section as
+// last reported by the server, for the per-section save status.
+const unsavedSections = new Set();
+let sectionSaves = [];
+
+// Merge dialog, shown when a save conflicts with the entry as saved elsewhere
+const mergeModal = document.getElementById('merge-modal');
+const mergeContent = document.getElementById('merge-content');
+let merging = false;
+
 // Get verse info from a verse element
 function getVerseInfo(element) {
     // 1. Check for data-ref on the element itself or ancestors
@@ -98,6 +114,49 @@ function updateVerseReference() {
         selectedVersesReference.textContent = '';
         selectedVersesReference.style.display = 'none';
     }
+    updateVerseNoteButton();
+}
+
+// Offer a note on the most recently selected verse
+function updateVerseNoteButton() {
+    if (!verseNoteBtn) return;
+    const ref = selectedVerseIds[selectedVerseIds.length - 1];
+    verseNoteBtn.hidden = !ref;
+    if (ref) {
+        verseNoteBtn.dataset.noteRef = ref;
+        verseNoteBtn.textContent = `Note on ${formatVerseReference([ref])}`;
+    }
+}
+
+// Create the marker shown after a verse with a note, like the ones rendered by the server
+function createVerseNoteMarker(ref) {
+    const marker = document.createElement('button');
+    marker.type = 'button';
+    marker.className = 'verse-note-marker';
+    marker.dataset.noteRef = ref;
+    marker.setAttribute('popovertarget', 'verse-note');
+    marker.setAttribute('hx-get', `/notes/verse?ref=${ref}`);
+    marker.setAttribute('hx-target', '#verse-note');
+    marker.setAttribute('aria-label', `Note on ${formatVerseReference([ref])}`);
+    marker.textContent = '✎';
+    return marker;
+}
+
+// Show or hide a verse's note marker after its note is saved
+function updateVerseNoteMarker(ref, hasNote) {
+    const markers = document.querySelectorAll(`.verse-note-marker[data-note-ref="${ref}"]`);
+    if (!hasNote) {
+        markers.forEach(el => el.remove());
+        return;
+    }
+    if (markers.length > 0) return;
+    const span = Array.from(document.querySelectorAll(`.verse-content span.verse[data-ref="${ref}"]`))
+        .find(el => !el.closest('.verse-num'));
+    if (span) {
+        const marker = createVerseNoteMarker(ref);
+        span.appendChild(marker);
+        htmx.process(marker);
+    }
 }
 
 // Toggle verse selection
@@ -158,8 +217,8 @@ function handleVerseClick(e) {
         return;
     }
 
-    // Prevent selection when clicking headers or extra_text
-    if (e.target.closest('h1, h2, h3, h4, h5, h6, .extra_text')) {
+    // Prevent selection when clicking headers, extra_text or verse note markers
+    if (e.target.closest('h1, h2, h3, h4, h5, h6, .extra_text, .verse-note-marker')) {
         return;
     }
 
@@ -175,6 +234,15 @@ function init() {
     document.body.addEventListener('click', handleVerseClick);
     refreshHighlights();
 
+    if (verseNoteBtn) {
+        verseNoteBtn.addEventListener('click', () => {
+            htmx.ajax('GET', `/notes/verse?ref=${verseNoteBtn.dataset.noteRef}`, { target: '#verse-note' });
+        });
+    }
+    document.body.addEventListener('verseNoteSaved', (evt) => {
+        updateVerseNoteMarker(evt.detail.ref, evt.detail.hasNote);
+    });
+
     // Export Modal listeners
     if (shareBtn && exportModal) {
         shareBtn.addEventListener('click', () => {
@@ -327,9 +395,45 @@ if (document.readyState === 'loading') {
 document.body.addEventListener('htmx:afterSwap', function (evt) {
     if (evt.target.classList.contains('verses-section')) {
         refreshHighlights();
+
+        // Follow the reading to its date when the previous/next day controls moved it
+        const date = evt.target.querySelector('.daily-reading')?.dataset.date;
+        if (date && date !== currentDate) {
+            if (datePicker) datePicker.value = date;
+            switchDate(date);
+        }
     }
 });
 
+// Count down a running quiet time session, keeping the page in focus mode until it ends. The server
+// keeps the timer; when the countdown runs out the page asks it to complete the session.
+let quietTimeInterval = null;
+function startQuietTime() {
+    const quietTime = document.getElementById('quiet-time');
+    // Each render of the partial is counted down once, from when it arrived
+    if (!quietTime || quietTime.dataset.counting) return;
+    quietTime.dataset.counting = 'true';
+    clearInterval(quietTimeInterval);
+    const remaining = Number(quietTime?.dataset.remaining || 0);
+    document.body.classList.toggle('focus-mode', remaining > 0);
+    if (remaining <= 0) return;
+
+    const display = quietTime.querySelector('.quiet-time-remaining');
+    const endsAt = Date.now() + remaining * 1000;
+    const tick = () => {
+        const left = (endsAt - Date.now()) / 1000;
+        display.textContent = formatCountdown(left);
+        if (left <= 0) {
+            clearInterval(quietTimeInterval);
+            htmx.trigger(quietTime.querySelector('.quiet-time-complete'), 'submit');
+        }
+    };
+    tick();
+    quietTimeInterval = setInterval(tick, 1000);
+}
+startQuietTime();
+document.body.addEventListener('htmx:afterSettle', startQuietTime);
+
 // Configure HTMX to include CSRF token
 document.body.addEventListener('htmx:configRequest', (event) => {
     if (window.SOAP_DATA?.csrfToken) {
@@ -337,42 +441,123 @@ document.body.addEventListener('htmx:configRequest', (event) => {
     }
 });
 
+// Switch the journal to another date, saving the entry for the current one first
+function switchDate(newDate) {
+    if (newDate === currentDate) return;
+
+    // Only save if we have a valid current date
+    if (currentDate) {
+        saveData(true);
+    }
+    currentDate = newDate;
+    loadDataForDate(newDate);
+}
+
 // Handle date changes
 if (datePicker) {
-    datePicker.addEventListener('change', function (e) {
-        const newDate = datePicker.value;
-        if (newDate === currentDate) return;
-
-        // 1. Save data for the OLD date (currentDate)
-        // Only save if we have a valid current date
-        if (currentDate) {
-            saveData(true);
-        }
+    datePicker.addEventListener('change', () => switchDate(datePicker.value));
+}
+
+// The editor and date forms are for browsers without JavaScript; here entries save as they are written
+// and the date switches as it is picked
+['soap-form', 'date-form'].forEach(id => {
+    document.getElementById(id)?.addEventListener('submit', (e) => e.preventDefault());
+});
+
+// All journal section fields, in the user's schema order followed by any extra sections
+function sectionFields() {
+    return journalSections ? Array.from(journalSections.querySelectorAll('[data-section]')) : [];
+}
+
+// Show the sections of a loaded entry. Sections from outside the user's schema, such as those
+// written under an earlier template, get their own field so they are not hidden.
+function showSections(sections) {
+    if (!journalSections) return;
+    journalSections.querySelectorAll('[data-extra]').forEach(field => field.remove());
+
+    const fields = sectionFields();
+    const shown = new Set(fields.map(field => field.dataset.section));
+    fields.forEach(field => {
+        field.value = sections[field.dataset.section] || '';
+    });
 
-        // 2. Update current date
-        currentDate = newDate;
+    Object.keys(sections).sort().forEach(key => {
+        if (shown.has(key) || !sections[key]) return;
+        const wrapper = document.createElement('div');
+        wrapper.className = 'soap-field';
+        wrapper.setAttribute('data-extra', '');
+        const label = document.createElement('label');
+        label.htmlFor = `section-${key}`;
+        label.textContent = sectionName(key);
+        const textarea = document.createElement('textarea');
+        textarea.id = `section-${key}`;
+        textarea.name = key;
+        textarea.rows = 6;
+        textarea.dir = 'auto';
+        textarea.dataset.section = key;
+        textarea.value = sections[key];
+        wrapper.append(label, textarea);
+        journalSections.appendChild(wrapper);
+    });
+}
 
-        // 3. Load data for the NEW date
-        loadDataForDate(newDate);
+// Follow the server to the unlock page when the privacy lock has locked the session
+function checkLocked(response) {
+    const redirect = response.headers.get('HX-Redirect');
+    if (response.status === 401 && redirect) {
+        window.location.href = redirect;
+        throw new Error('Journal locked');
+    }
+    return response;
+}
+
+// Reload the page once the privacy lock period passes without any activity, so the server can send it
+// to the unlock page rather than leaving the journal on screen
+const lockAfterMinutes = window.SOAP_DATA?.lockAfterMinutes || 0;
+if (lockAfterMinutes > 0) {
+    let lockTimeout;
+    const resetLockTimeout = () => {
+        clearTimeout(lockTimeout);
+        lockTimeout = setTimeout(() => window.location.reload(), lockAfterMinutes * 60 * 1000 + 5000);
+    };
+    ['keydown', 'pointerdown', 'scroll'].forEach(type => {
+        document.addEventListener(type, resetLockTimeout, { passive: true });
     });
+    resetLockTimeout();
 }
 
+// Load the journal form for dateStr, rendered by the server with the entry's sections, in place of the
+// current one
 function loadDataForDate(dateStr) {
     // Show loading state?
-    if (observationField) observationField.value = 'Loading...';
-    if (applicationField) applicationField.value = 'Loading...';
-    if (prayerField) prayerField.value = 'Loading...';
-
-    fetch(`/soap?date=${dateStr}`)
-        .then(response => response.json())
-        .then(data => {
-            // Update fields
-            if (observationField) observationField.value = data.observation || '';
-            if (applicationField) applicationField.value = data.application || '';
-            if (prayerField) prayerField.value = data.prayer || '';
+    sectionFields().forEach(field => {
+        field.value = 'Loading...';
+    });
+
+    fetch(`/soap-form?date=${dateStr}`)
+        .then(checkLocked)
+        .then(response => response.ok ? response.text() : Promise.reject(new Error(response.statusText)))
+        .then(html => {
+            const template = document.createElement('template');
+            template.innerHTML = html;
+            const form = template.content.getElementById('journal-sections');
+            if (!form || !journalSections) throw new Error('No journal form');
+            const data = {
+                date: form.dataset.date,
+                selectedVerses: JSON.parse(form.dataset.selectedVerses || '[]'),
+                revision: Number(form.dataset.revision || 0),
+            };
+
+            // Swap in the fields, keeping the container and the listeners on it
+            journalSections.replaceChildren(...form.childNodes);
 
             // Update selected verses
-            selectedVerseIds = data.selectedVerses || [];
+            selectedVerseIds = data.selectedVerses;
+
+            // A save of this tab's may have been answered with a later revision already
+            revisions[data.date] = Math.max(revisions[data.date] ?? 0, data.revision);
+            unsavedSections.clear();
+            refreshSectionSaveStatus(data.date);
 
             // Update current date from server response (source of truth)
             if (data.date) {
@@ -385,33 +570,101 @@ function loadDataForDate(dateStr) {
 
             // Refresh highlights
             refreshHighlights();
+
+            // Refresh the prayer list, which carries open items forward from earlier days
+            if (window.htmx && document.getElementById('prayer-list')) {
+                htmx.ajax('GET', `/prayers?date=${currentDate}`, { target: '#prayer-list', swap: 'outerHTML' });
+            }
+            refreshLinkedEntries();
+            if (window.htmx && document.getElementById('quiet-time')) {
+                htmx.ajax('GET', `/quiet-time?date=${currentDate}`, { target: '#quiet-time', swap: 'outerHTML' });
+            }
+            if (window.htmx && document.getElementById('entry-stamp')) {
+                htmx.ajax('GET', `/stamp?date=${currentDate}`, { target: '#entry-stamp', swap: 'outerHTML' });
+            }
+            if (window.htmx && document.getElementById('favorite-entry')) {
+                htmx.ajax('GET', `/favorite?date=${currentDate}`, { target: '#favorite-entry', swap: 'outerHTML' });
+            }
+            if (window.htmx && document.getElementById('mentor-comments')) {
+                htmx.ajax('GET', `/mentor-comments?date=${currentDate}`, { target: '#mentor-comments', swap: 'outerHTML' });
+            }
         })
         .catch(err => {
             console.error('Failed to load data', err);
-            if (observationField) observationField.value = '';
-            if (applicationField) applicationField.value = '';
-            if (prayerField) prayerField.value = '';
+            showSections({});
         });
 }
 
+// Refresh the links to and from the current entry, and the entries on the same chapters
+function refreshLinkedEntries() {
+    if (window.htmx && document.getElementById('linked-entries')) {
+        htmx.ajax('GET', `/links?date=${currentDate}`, { target: '#linked-entries', swap: 'outerHTML' });
+    }
+    if (window.htmx && document.getElementById('related-entries')) {
+        htmx.ajax('GET', `/related?date=${currentDate}`, { target: '#related-entries', swap: 'outerHTML' });
+    }
+}
+
+// Navigate to a linked entry by changing the date picker
+document.body.addEventListener('click', (e) => {
+    const link = e.target.closest('.linked-entry');
+    if (!link || !datePicker) return;
+    e.preventDefault();
+    datePicker.value = link.dataset.date;
+    datePicker.dispatchEvent(new Event('change', { bubbles: true }));
+});
+
+// Note where the entry is being written from the browser's location, of which only about 10 km of
+// precision is sent
+document.body.addEventListener('click', (e) => {
+    const button = e.target.closest('.entry-stamp-locate');
+    if (!button || !navigator.geolocation) return;
+    const form = button.closest('form');
+    button.disabled = true;
+    navigator.geolocation.getCurrentPosition(
+        (position) => {
+            form.elements.latitude.value = position.coords.latitude.toFixed(1);
+            form.elements.longitude.value = position.coords.longitude.toFixed(1);
+            form.requestSubmit();
+        },
+        () => {
+            button.textContent = 'Location unavailable';
+        },
+        { enableHighAccuracy: false, maximumAge: 10 * 60 * 1000, timeout: 10000 }
+    );
+});
+
 function saveData(immediate = false) {
-    // Guard against saving with empty date
-    if (!currentDate || !observationField) {
+    // Guard against saving with empty date, or over the entry while its conflict is being merged
+    const fields = sectionFields();
+    if (!currentDate || fields.length === 0 || merging) {
         return;
     }
 
+    const sections = {};
+    fields.forEach(field => {
+        sections[field.dataset.section] = field.value;
+    });
     const dataToSave = {
         date: currentDate,
-        observation: observationField.value,
-        application: applicationField.value,
-        prayer: prayerField.value,
-        selectedVerses: selectedVerseIds
+        sections,
+        selectedVerses: [...selectedVerseIds]
     };
 
     if (immediate) {
         if (saveTimeout) clearTimeout(saveTimeout);
     }
 
+    // Send the latest edits once the save in flight is answered
+    if (saveInFlight) {
+        pendingSave = dataToSave;
+        return;
+    }
+    sendSave(dataToSave);
+}
+
+function sendSave(dataToSave) {
+    saveInFlight = true;
     if (saveStatus) {
         saveStatus.textContent = 'Saving...';
         saveStatus.className = 'save-status saving';
@@ -423,16 +676,55 @@ function saveData(immediate = false) {
             'Content-Type': 'application/json',
             'X-CSRF-Token': window.SOAP_DATA?.csrfToken
         },
-        body: JSON.stringify(dataToSave)
+        body: JSON.stringify({ ...dataToSave, baseRevision: revisions[dataToSave.date] })
     })
-        .then(response => response.json())
+        .then(checkLocked)
+        .then(response => {
+            if (response.status === 409) {
+                return response.text().then(html => {
+                    showMerge(html);
+                    return { conflict: true };
+                });
+            }
+            return response.json();
+        })
         .then(result => {
-            if (result.error) {
+            if (result.conflict) {
+                if (saveStatus) {
+                    saveStatus.textContent = 'Changed elsewhere';
+                    saveStatus.className = 'save-status error';
+                }
+            } else if (result.queued) {
+                // Offline: the service worker sends the save once the server can be reached
+                if (saveStatus) {
+                    saveStatus.textContent = 'Saved on this device';
+                    saveStatus.className = 'save-status saving';
+                }
+            } else if (result.error) {
                 if (saveStatus) {
-                    saveStatus.textContent = 'Error saving';
+                    // Being over the storage quota is not fixed by trying again, so say why
+                    saveStatus.textContent = result.overQuota ? result.error : 'Error saving';
                     saveStatus.className = 'save-status error';
                 }
             } else {
+                if (result.revision !== undefined) {
+                    revisions[dataToSave.date] = result.revision;
+                }
+                refreshLinkedEntries();
+                // Edits made while the save was in flight are still unsaved
+                if (dataToSave.date === currentDate) {
+                    // Adopt the selection as saved, unless it changed while the save was in flight
+                    if (result.selectedVerses && selectedVerseIds.join() === dataToSave.selectedVerses.join()) {
+                        selectedVerseIds = result.selectedVerses;
+                        refreshHighlights();
+                    }
+                    sectionFields().forEach(field => {
+                        if (field.value === dataToSave.sections[field.dataset.section]) {
+                            unsavedSections.delete(field.dataset.section);
+                        }
+                    });
+                    refreshSectionSaveStatus(dataToSave.date);
+                }
                 if (saveStatus) {
                     saveStatus.textContent = 'Saved';
                     saveStatus.className = 'save-status saved';
@@ -452,9 +744,59 @@ function saveData(immediate = false) {
                 saveStatus.className = 'save-status error';
             }
             console.error('Error:', error);
+        })
+        .finally(() => {
+            saveInFlight = false;
+            const next = pendingSave;
+            pendingSave = null;
+            if (next && !merging) sendSave(next);
         });
 }
 
+// Show the server's merge of a conflicting save, holding further saves until it is resolved
+function showMerge(html) {
+    if (!mergeModal) return;
+    merging = true;
+    pendingSave = null;
+    if (saveTimeout) clearTimeout(saveTimeout);
+    mergeContent.innerHTML = html;
+    if (window.htmx) htmx.process(mergeContent);
+    mergeModal.showModal();
+}
+
+if (mergeModal) {
+    // The conflict must be merged before the entry can be saved again
+    mergeModal.addEventListener('cancel', (e) => e.preventDefault());
+}
+
+document.body.addEventListener('entryMerged', () => {
+    merging = false;
+    if (mergeModal) mergeModal.close();
+    loadDataForDate(currentDate);
+});
+
+// Fetch the save state of each section of the entry for dateStr and show it
+function refreshSectionSaveStatus(dateStr) {
+    if (!sectionSaveStatus) return;
+    fetch(`/soap/status?date=${dateStr}&revision=${revisions[dateStr] ?? 0}`)
+        .then(response => response.ok ? response.json() : Promise.reject(new Error(response.statusText)))
+        .then(status => {
+            if (status.date !== currentDate) return;
+            sectionSaves = status.sections || [];
+            renderSectionSaveStatus();
+        })
+        .catch(err => console.error('Failed to load save status', err));
+}
+
+function renderSectionSaveStatus() {
+    if (sectionSaveStatus) {
+        sectionSaveStatus.textContent = formatSaveStatus(sectionSaves, unsavedSections, new Date());
+    }
+}
+
+// Keep the times since each section was saved current
+setInterval(renderSectionSaveStatus, 15000);
+
 function scheduleSave() {
     if (saveTimeout) {
         clearTimeout(saveTimeout);
@@ -462,6 +804,67 @@ function scheduleSave() {
     saveTimeout = setTimeout(saveData, SAVE_DELAY);
 }
 
-if (observationField) observationField.addEventListener('input', scheduleSave);
-if (applicationField) applicationField.addEventListener('input', scheduleSave);
-if (prayerField) prayerField.addEventListener('input', scheduleSave);
+// Listen on the container so fields added for extra sections are saved too
+if (journalSections) {
+    journalSections.addEventListener('input', (e) => {
+        if (e.target.matches('[data-section]')) {
+            if (!unsavedSections.has(e.target.dataset.section)) {
+                unsavedSections.add(e.target.dataset.section);
+                renderSectionSaveStatus();
+            }
+            scheduleSave();
+        }
+    });
+}
+
+// Keep the app usable offline: the service worker keeps the app shell and this week's days, and holds
+// saves made offline until the server can be reached. It is kept off while the privacy lock is on, as
+// what it keeps could be read without unlocking.
+if ('serviceWorker' in navigator && window.SOAP_DATA) {
+    if (lockAfterMinutes > 0) {
+        navigator.serviceWorker.getRegistrations()
+            .then(registrations => registrations.forEach(registration => registration.unregister()));
+        if (window.caches) {
+            caches.keys().then(keys => keys.forEach(key => caches.delete(key)));
+        }
+    } else {
+        navigator.serviceWorker.register('/sw.js')
+            .then(() => navigator.serviceWorker.ready)
+            .then(registration => {
+                const weekDates = window.SOAP_DATA.weekDates || [];
+                // The week is fetched for keeping once a day, as the first page of the day is opened
+                const precached = `${weekDates[0]}|${new Date().toDateString()}`;
+                if (weekDates.length > 0 && localStorage.getItem('precachedWeek') !== precached) {
+                    registration.active.postMessage({ type: 'precache', dates: weekDates });
+                    localStorage.setItem('precachedWeek', precached);
+                }
+                registration.active.postMessage({ type: 'replay', csrfToken: window.SOAP_DATA.csrfToken });
+            })
+            .catch(err => console.error('Failed to register service worker', err));
+
+        window.addEventListener('online', () => {
+            navigator.serviceWorker.controller?.postMessage({ type: 'replay', csrfToken: window.SOAP_DATA.csrfToken });
+        });
+
+        navigator.serviceWorker.addEventListener('message', (event) => {
+            const message = event.data || {};
+            if (message.type === 'save-replayed') {
+                if (message.revision !== undefined) {
+                    revisions[message.date] = Math.max(revisions[message.date] ?? 0, message.revision);
+                }
+                if (message.date === currentDate) {
+                    refreshSectionSaveStatus(message.date);
+                    if (saveStatus) {
+                        saveStatus.textContent = 'Saved';
+                        saveStatus.className = 'save-status saved';
+                    }
+                }
+            } else if (message.type === 'save-conflict') {
+                showMerge(message.html);
+            } else if (message.type === 'save-failed' && saveStatus) {
+                saveStatus.textContent = `Not saved (${message.date}): ${message.error}`;
+                saveStatus.className = 'save-status error';
+            }
+        });
+    }
+}
@@ -12,7 +12,7 @@ async function loadApp(window) {
   // Wrap in a function to pass window as global
   const fn = new Function(
     "window", "document", "Intl", "fetch", "Node", "setTimeout", "clearTimeout",
-    "formatVerseReference", "parseVerseId",
+    "formatCountdown", "formatVerseReference", "parseVerseId", "sectionName",
     code
   );
   fn(
@@ -23,8 +23,10 @@ async function loadApp(window) {
     window.Node,
     window.setTimeout,
     window.clearTimeout,
+    logic.formatCountdown,
     logic.formatVerseReference,
-    logic.parseVerseId
+    logic.parseVerseId,
+    logic.sectionName
   );
 }
 
@@ -43,9 +45,11 @@ Deno.test("verse highlighting - valid highlighting", { sanitizeOps: false, sanit
           </div>
         </div>
         <div id="selectedVersesReference"></div>
-        <textarea id="observation"></textarea>
-        <textarea id="application"></textarea>
-        <textarea id="prayer"></textarea>
+        <div id="journal-sections">
+          <textarea id="section-observation" data-section="observation"></textarea>
+          <textarea id="section-application" data-section="application"></textarea>
+          <textarea id="section-prayer" data-section="prayer"></textarea>
+        </div>
         <div id="saveStatus"></div>
       </body>
     </html>
@@ -65,7 +69,7 @@ Deno.test("verse highlighting - valid highlighting", { sanitizeOps: false, sanit
       resolvedOptions: () => ({ timeZone: "UTC" })
     })
   };
-  window.fetch = () => Promise.resolve({ json: () => Promise.resolve({}) });
+  window.fetch = () => Promise.resolve({ status: 200, headers: new Headers(), json: () => Promise.resolve({}) });
 
   // Load app.js
   await loadApp(window);
@@ -113,9 +117,11 @@ Deno.test("export modal - method change logic", { sanitizeOps: false, sanitizeRe
             <button type="submit">Export</button>
           </form>
         </dialog>
-        <textarea id="observation"></textarea>
-        <textarea id="application"></textarea>
-        <textarea id="prayer"></textarea>
+        <div id="journal-sections">
+          <textarea id="section-observation" data-section="observation"></textarea>
+          <textarea id="section-application" data-section="application"></textarea>
+          <textarea id="section-prayer" data-section="prayer"></textarea>
+        </div>
         <div id="saveStatus"></div>
         <div id="selectedVersesReference"></div>
       </body>
@@ -136,7 +142,7 @@ Deno.test("export modal - method change logic", { sanitizeOps: false, sanitizeRe
       resolvedOptions: () => ({ timeZone: "UTC" })
     })
   };
-  window.fetch = () => Promise.resolve({ json: () => Promise.resolve({}) });
+  window.fetch = () => Promise.resolve({ status: 200, headers: new Headers(), json: () => Promise.resolve({}) });
 
   // Load app.js
   await loadApp(window);
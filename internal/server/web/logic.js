@@ -95,3 +95,97 @@ export function formatVerseReference(verseIds) {
 
     return references.join('; ');
 }
+
+/**
+ * Shift a date by a number of days (e.g., "2025-12-31" + 1 = "2026-01-01")
+ * @param {string} dateStr - date in YYYY-MM-DD format
+ * @param {number} days
+ * @returns {string}
+ */
+export function shiftDate(dateStr, days) {
+    const date = new Date(`${dateStr}T00:00:00Z`);
+    date.setUTCDate(date.getUTCDate() + days);
+    return date.toISOString().slice(0, 10);
+}
+
+/**
+ * Name a journal section by its key (e.g., "my_notes" = "My notes"), for sections the page was not
+ * rendered with
+ * @param {string} key
+ * @returns {string}
+ */
+export function sectionName(key) {
+    return key.charAt(0).toUpperCase() + key.slice(1).replaceAll('_', ' ');
+}
+
+/**
+ * Format a number of seconds as a countdown (e.g., 905 = "15:05")
+ * @param {number} seconds
+ * @returns {string}
+ */
+export function formatCountdown(seconds) {
+    const s = Math.max(0, Math.ceil(seconds));
+    return `${Math.floor(s / 60)}:${String(s % 60).padStart(2, '0')}`;
+}
+
+/**
+ * Format how long ago something happened (e.g., 12 = "12s ago", 7200 = "2h ago")
+ * @param {number} seconds
+ * @returns {string}
+ */
+export function formatAgo(seconds) {
+    const s = Math.max(0, Math.floor(seconds));
+    if (s < 5) return 'just now';
+    if (s < 60) return `${s}s ago`;
+    if (s < 3600) return `${Math.floor(s / 60)}m ago`;
+    if (s < 86400) return `${Math.floor(s / 3600)}h ago`;
+    return `${Math.floor(s / 86400)}d ago`;
+}
+
+/**
+ * Summarize the save state of an entry's sections (e.g., "Observation saved 12s ago · Prayer unsaved")
+ * from the sections reported by /soap/status and the keys of those with edits not yet saved. Empty
+ * sections without edits are left out.
+ * @param {{section: string, name: string, state: string, savedAt?: string}[]} sections
+ * @param {Set<string>} unsaved
+ * @param {Date} now
+ * @returns {string}
+ */
+export function formatSaveStatus(sections, unsaved, now) {
+    const parts = [];
+    for (const sec of sections) {
+        if (unsaved.has(sec.section)) {
+            parts.push(`${sec.name} unsaved`);
+        } else if (sec.state === 'changed') {
+            parts.push(`${sec.name} changed elsewhere`);
+        } else if (sec.state === 'saved' && sec.savedAt) {
+            parts.push(`${sec.name} saved ${formatAgo((now - new Date(sec.savedAt)) / 1000)}`);
+        }
+    }
+    return parts.join(' · ');
+}
+
+/**
+ * Encode bytes as unpadded base64url, the encoding WebAuthn uses for binary fields
+ * @param {ArrayBuffer|Uint8Array} buffer
+ * @returns {string}
+ */
+export function bufferToBase64url(buffer) {
+    const bytes = new Uint8Array(buffer);
+    let binary = '';
+    for (const b of bytes) {
+        binary += String.fromCharCode(b);
+    }
+    return btoa(binary).replace(/\+/g, '-').replace(/\//g, '_').replace(/=+$/, '');
+}
+
+/**
+ * Decode unpadded base64url into bytes
+ * @param {string} str
+ * @returns {Uint8Array}
+ */
+export function base64urlToBuffer(str) {
+    const base64 = str.replace(/-/g, '+').replace(/_/g, '/');
+    const binary = atob(base64.padEnd(base64.length + (4 - base64.length % 4) % 4, '='));
+    return Uint8Array.from(binary, c => c.charCodeAt(0));
+}
@@ -1,5 +1,5 @@
 import { assertEquals } from "https://deno.land/std@0.224.0/assert/mod.ts";
-import { formatVerseReference, parseVerseId } from "./logic.js";
+import { base64urlToBuffer, bufferToBase64url, formatAgo, formatCountdown, formatSaveStatus, formatVerseReference, parseVerseId, sectionName, shiftDate } from "./logic.js";
 
 Deno.test("parseVerseId - correctly parses a valid ID", () => {
     const result = parseVerseId("23063008");
@@ -25,3 +25,46 @@ Deno.test("formatVerseReference - handles multiple chapters", () => {
     const result = formatVerseReference(input);
     assertEquals(result, "Isaiah 63:8; Isaiah 64:1");
 });
+
+Deno.test("shiftDate - crosses month and year boundaries", () => {
+    assertEquals(shiftDate("2025-12-31", 1), "2026-01-01");
+    assertEquals(shiftDate("2024-03-01", -1), "2024-02-29");
+});
+
+Deno.test("sectionName - capitalizes and spaces section keys", () => {
+    assertEquals(sectionName("observation"), "Observation");
+    assertEquals(sectionName("my_prayer_list"), "My prayer list");
+});
+
+Deno.test("formatCountdown - pads seconds and stops at zero", () => {
+    assertEquals(formatCountdown(900), "15:00");
+    assertEquals(formatCountdown(65.2), "1:06");
+    assertEquals(formatCountdown(-3), "0:00");
+});
+
+Deno.test("formatAgo - rounds down to the largest unit", () => {
+    assertEquals(formatAgo(2), "just now");
+    assertEquals(formatAgo(12.8), "12s ago");
+    assertEquals(formatAgo(125), "2m ago");
+    assertEquals(formatAgo(7200), "2h ago");
+    assertEquals(formatAgo(3 * 86400), "3d ago");
+});
+
+Deno.test("formatSaveStatus - puts unsaved edits before the server's state", () => {
+    const sections = [
+        { section: "observation", name: "Observation", state: "saved", savedAt: "2026-03-07T08:00:00Z" },
+        { section: "application", name: "Application", state: "empty" },
+        { section: "prayer", name: "Prayer", state: "saved", savedAt: "2026-03-07T07:00:00Z" },
+        { section: "thoughts", name: "Thoughts", state: "changed", savedAt: "2026-03-07T08:00:10Z" },
+    ];
+    const now = new Date("2026-03-07T08:00:12Z");
+    assertEquals(formatSaveStatus(sections, new Set(["prayer"]), now), "Observation saved 12s ago · Prayer unsaved · Thoughts changed elsewhere");
+    assertEquals(formatSaveStatus(sections, new Set(["application"]), now), "Observation saved 12s ago · Application unsaved · Prayer saved 1h ago · Thoughts changed elsewhere");
+});
+
+Deno.test("base64url - round trips bytes without padding", () => {
+    const bytes = new Uint8Array([251, 255, 0, 1, 62]);
+    const encoded = bufferToBase64url(bytes);
+    assertEquals(encoded, "-_8AAT4");
+    assertEquals(Array.from(base64urlToBuffer(encoded)), Array.from(bytes));
+});
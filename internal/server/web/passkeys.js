@@ -0,0 +1,91 @@
+import { base64urlToBuffer, bufferToBase64url } from './logic.js';
+
+const csrfToken = document.querySelector('input[name="csrf_token"]')?.value;
+const errorBox = document.getElementById('passkey-error');
+
+function showError(message) {
+    if (!errorBox) return;
+    errorBox.textContent = message;
+    errorBox.hidden = false;
+}
+
+async function postJSON(url, body) {
+    const response = await fetch(url, {
+        method: 'POST',
+        headers: {
+            'Content-Type': 'application/json',
+            'X-CSRF-Token': csrfToken
+        },
+        body: body ? JSON.stringify(body) : undefined
+    });
+    if (!response.ok) {
+        throw new Error((await response.text()).trim() || 'Request failed');
+    }
+    return response.json();
+}
+
+// Sign in with a passkey from the login page
+const passkeyLogin = document.getElementById('passkey-login');
+const passkeyLoginBtn = document.getElementById('passkey-login-btn');
+if (passkeyLogin && passkeyLoginBtn && window.PublicKeyCredential) {
+    passkeyLogin.hidden = false;
+    passkeyLoginBtn.addEventListener('click', async () => {
+        try {
+            const options = await postJSON('/passkeys/login/begin');
+            const credential = await navigator.credentials.get({
+                publicKey: {
+                    challenge: base64urlToBuffer(options.challenge),
+                    rpId: options.rpId,
+                    userVerification: options.userVerification,
+                    timeout: options.timeout
+                }
+            });
+            const result = await postJSON('/passkeys/login/finish', {
+                id: bufferToBase64url(credential.rawId),
+                clientDataJSON: bufferToBase64url(credential.response.clientDataJSON),
+                authenticatorData: bufferToBase64url(credential.response.authenticatorData),
+                signature: bufferToBase64url(credential.response.signature),
+                userHandle: credential.response.userHandle ? bufferToBase64url(credential.response.userHandle) : '',
+                timezone: Intl.DateTimeFormat().resolvedOptions().timeZone
+            });
+            window.location.href = passkeyLogin.dataset.next || result.redirect;
+        } catch (err) {
+            if (err.name === 'NotAllowedError') return; // cancelled by the user
+            console.error('Passkey sign in failed', err);
+            showError(err.message || 'Passkey sign in failed');
+        }
+    });
+}
+
+// Add a passkey from the settings page
+const registerForm = document.getElementById('passkey-register-form');
+if (registerForm) {
+    if (!window.PublicKeyCredential) {
+        registerForm.hidden = true;
+        showError('This browser does not support passkeys.');
+    }
+    registerForm.addEventListener('submit', async (e) => {
+        e.preventDefault();
+        try {
+            const options = await postJSON('/passkeys/register/begin');
+            const credential = await navigator.credentials.create({
+                publicKey: {
+                    ...options,
+                    challenge: base64urlToBuffer(options.challenge),
+                    user: { ...options.user, id: base64urlToBuffer(options.user.id) },
+                    excludeCredentials: options.excludeCredentials.map(c => ({ ...c, id: base64urlToBuffer(c.id) }))
+                }
+            });
+            await postJSON('/passkeys/register/finish', {
+                name: registerForm.elements.name.value,
+                clientDataJSON: bufferToBase64url(credential.response.clientDataJSON),
+                attestationObject: bufferToBase64url(credential.response.attestationObject)
+            });
+            window.location.reload();
+        } catch (err) {
+            if (err.name === 'NotAllowedError') return;
+            console.error('Passkey registration failed', err);
+            showError(err.message || 'Passkey registration failed');
+        }
+    });
+}
@@ -0,0 +1,260 @@
+// The service worker, which keeps the app usable offline. The server serves it from /sw.js prefixed
+// with VERSION, which changes whenever the app shell does, and SHELL, the URLs of the app shell.
+
+const SHELL_CACHE = `shell-${VERSION}`;
+const PAGES_CACHE = 'pages';
+
+// The journal page and the partials it loads days with, which are kept for the days of this week and
+// for each day opened
+const PAGE_PATHS = ['/', '/reading', '/soap-form'];
+
+// Saves made offline, one for each day, kept until they can be sent
+const QUEUE_DB = 'soap-offline';
+const QUEUE_STORE = 'saves';
+const REPLAY_TAG = 'replay-saves';
+
+self.addEventListener('install', event => {
+    event.waitUntil(caches.open(SHELL_CACHE)
+        .then(cache => cache.addAll(SHELL))
+        .then(() => self.skipWaiting()));
+});
+
+self.addEventListener('activate', event => {
+    event.waitUntil(caches.keys()
+        .then(keys => Promise.all(keys
+            .filter(key => key.startsWith('shell-') && key !== SHELL_CACHE)
+            .map(key => caches.delete(key))))
+        .then(() => self.clients.claim()));
+});
+
+self.addEventListener('fetch', event => {
+    const request = event.request;
+    const url = new URL(request.url);
+    if (url.origin !== self.location.origin) return;
+
+    if (request.method === 'POST' && url.pathname === '/soap' &&
+        (request.headers.get('Content-Type') || '').startsWith('application/json')) {
+        event.respondWith(saveOrQueue(request));
+        return;
+    }
+    if (request.method !== 'GET') return;
+
+    if (url.pathname.startsWith('/web/')) {
+        event.respondWith(caches.match(request).then(cached => cached || fetch(request)));
+    } else if (PAGE_PATHS.includes(url.pathname)) {
+        event.respondWith(networkFirst(request));
+    } else if (request.mode === 'navigate') {
+        event.respondWith(fetch(request).catch(() => offlinePage()));
+    }
+});
+
+self.addEventListener('message', event => {
+    const message = event.data || {};
+    if (message.type === 'precache') {
+        event.waitUntil(precacheWeek(message.dates || []));
+    } else if (message.type === 'replay') {
+        event.waitUntil(replaySaves(message.csrfToken));
+    }
+});
+
+self.addEventListener('sync', event => {
+    if (event.tag === REPLAY_TAG) {
+        event.waitUntil(replaySaves());
+    }
+});
+
+// The server is down for maintenance when it answers 503, which is treated like being offline
+function unavailable(response) {
+    return response.status === 503;
+}
+
+function offlinePage() {
+    return caches.match('/offline').then(cached => cached || Response.error());
+}
+
+// Fetch a page or partial, keeping a copy for when the server cannot be reached, or answer with the
+// copy kept, or the offline page, if it cannot
+async function networkFirst(request) {
+    let response;
+    try {
+        response = await fetch(request);
+    } catch (err) {
+        response = null;
+    }
+    if (response && !unavailable(response)) {
+        // A redirect is to the login or unlock page, which must not stand in for the journal
+        if (response.ok && !response.redirected) {
+            const cache = await caches.open(PAGES_CACHE);
+            await cache.put(request, response.clone());
+        }
+        return response;
+    }
+    const cached = await caches.match(request);
+    if (cached) return cached;
+    if (request.mode === 'navigate') return response || offlinePage();
+    return response || Response.error();
+}
+
+// Keep the journal page and partials of each of dates, the days of this week, and of today, dropping
+// those of days before the week
+async function precacheWeek(dates) {
+    if (dates.length === 0) return;
+    const cache = await caches.open(PAGES_CACHE);
+    for (const request of await cache.keys()) {
+        const date = new URL(request.url).searchParams.get('date');
+        if (date && date < dates[0]) {
+            await cache.delete(request);
+        }
+    }
+    const urls = ['/', ...dates.flatMap(date => PAGE_PATHS.map(path => `${path}?date=${date}`))];
+    await Promise.all(urls.map(async url => {
+        try {
+            const response = await fetch(url);
+            if (response.ok && !response.redirected) {
+                await cache.put(url, response);
+            }
+        } catch (err) {
+            // Offline: keep what was kept before
+        }
+    }));
+}
+
+// Send a save to the server, or queue it to be sent once it can be reached, answering 202 with
+// {"queued": true}
+async function saveOrQueue(request) {
+    const save = await request.clone().json();
+    try {
+        const response = await fetch(request);
+        if (!unavailable(response)) return response;
+    } catch (err) {
+        // Offline: queue the save below
+    }
+    await queueSave(save, request.headers.get('X-CSRF-Token'));
+    if (self.registration.sync) {
+        self.registration.sync.register(REPLAY_TAG).catch(() => {});
+    }
+    return new Response(JSON.stringify({ queued: true }), {
+        status: 202,
+        headers: { 'Content-Type': 'application/json' },
+    });
+}
+
+function openQueue() {
+    return new Promise((resolve, reject) => {
+        const open = indexedDB.open(QUEUE_DB, 1);
+        open.onupgradeneeded = () => open.result.createObjectStore(QUEUE_STORE, { keyPath: 'date' });
+        open.onsuccess = () => resolve(open.result);
+        open.onerror = () => reject(open.error);
+    });
+}
+
+// Run fn on the queue's store in a transaction, resolving with what it sets the result to once the
+// transaction completes
+async function withQueue(mode, fn) {
+    const db = await openQueue();
+    try {
+        return await new Promise((resolve, reject) => {
+            const tx = db.transaction(QUEUE_STORE, mode);
+            let result;
+            fn(tx.objectStore(QUEUE_STORE), value => { result = value; });
+            tx.oncomplete = () => resolve(result);
+            tx.onerror = () => reject(tx.error);
+        });
+    } finally {
+        db.close();
+    }
+}
+
+// Queue a save made offline. Saves of the same day are merged into one as the server would apply them
+// in turn: the sections of later saves replace those of earlier ones and the selected verses are the
+// latest, while the revision it is based on stays that of the first, which was the last seen online.
+function queueSave(save, csrfToken) {
+    return withQueue('readwrite', store => {
+        store.get(save.date).onsuccess = event => {
+            const queued = event.target.result;
+            if (queued) {
+                save = {
+                    ...save,
+                    sections: { ...queued.save.sections, ...save.sections },
+                    baseRevision: queued.save.baseRevision,
+                };
+            }
+            store.put({ date: save.date, save, csrfToken, queuedAt: Date.now() });
+        };
+    });
+}
+
+// Remove the queued save of date, unless it was queued again since queuedAt
+function unqueue(date, queuedAt) {
+    return withQueue('readwrite', store => {
+        store.get(date).onsuccess = event => {
+            if (event.target.result?.queuedAt === queuedAt) {
+                store.delete(date);
+            }
+        };
+    });
+}
+
+function queuedSaves() {
+    return withQueue('readonly', (store, setResult) => {
+        store.getAll().onsuccess = event => {
+            setResult(event.target.result.sort((a, b) => a.queuedAt - b.queuedAt));
+        };
+    });
+}
+
+async function notifyClients(message) {
+    for (const client of await self.clients.matchAll({ type: 'window' })) {
+        client.postMessage(message);
+    }
+}
+
+// Send the saves queued offline, oldest first, with csrfToken if given, which is the token of the page
+// asking, or else the token each was queued with. Sending stops, to be tried again later, when the
+// server cannot be reached or the journal is locked. A save that conflicts with the entry as saved
+// elsewhere is handed to an open page to merge, as a save made online would be, or kept until one is.
+let replaying = null;
+
+function replaySaves(csrfToken) {
+    if (!replaying) {
+        replaying = sendQueued(csrfToken).finally(() => {
+            replaying = null;
+        });
+    }
+    return replaying;
+}
+
+async function sendQueued(csrfToken) {
+    for (const queued of await queuedSaves()) {
+        let response;
+        try {
+            response = await fetch('/soap', {
+                method: 'POST',
+                headers: {
+                    'Content-Type': 'application/json',
+                    'X-CSRF-Token': csrfToken || queued.csrfToken,
+                },
+                body: JSON.stringify(queued.save),
+            });
+        } catch (err) {
+            return;
+        }
+        if (unavailable(response) || response.status === 401 || response.status === 403) {
+            return;
+        }
+
+        const date = queued.save.date;
+        if (response.status === 409) {
+            const pages = await self.clients.matchAll({ type: 'window' });
+            if (pages.length === 0) return;
+            pages[0].postMessage({ type: 'save-conflict', date, html: await response.text() });
+        } else if (response.ok) {
+            const result = await response.json();
+            await notifyClients({ type: 'save-replayed', date, revision: result.revision });
+        } else {
+            const result = await response.json().catch(() => ({}));
+            await notifyClients({ type: 'save-failed', date, error: result.error || response.statusText });
+        }
+        await unqueue(date, queued.queuedAt);
+    }
+}
@@ -16,6 +16,8 @@ type Response struct {
 	// other fields ignored for this test
 }
 
+func (r Response) Direction(int) string { return "ltr" }
+
 func TestVersesTemplate(t *testing.T) {
 	// Mock data
 	data := map[string]any{
@@ -30,6 +32,7 @@ func TestVersesTemplate(t *testing.T) {
 		"safeHTML": func(s string) template.HTML {
 			return template.HTML(s) // #nosec G203
 		},
+		"feature": func(string, any) bool { return true },
 	}
 
 	// Read the actual template file
@@ -0,0 +1,54 @@
+package server
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+
+	"derrclan.com/moravian-soap/internal/email"
+)
+
+// maxWebhookBytes caps the size of webhook request bodies.
+const maxWebhookBytes = 1 << 20
+
+// handleMailgunWebhook records the addresses reported by Mailgun as hard bounces or spam complaints in
+// the suppression list, so no more email is sent to them. Requests must be signed with the webhook
+// signing key in MAILGUN_WEBHOOK_SIGNING_KEY.
+func (s *Server) handleMailgunWebhook(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	signingKey := os.Getenv("MAILGUN_WEBHOOK_SIGNING_KEY")
+	if signingKey == "" {
+		http.NotFound(w, r)
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookBytes))
+	if err != nil {
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+	event, err := email.ParseWebhook(body, signingKey, s.clock.Now())
+	if errors.Is(err, email.ErrInvalidWebhookSignature) {
+		slog.Warn("rejected Mailgun webhook", "error", err)
+		http.Error(w, "Invalid signature", http.StatusUnauthorized)
+		return
+	} else if err != nil {
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+
+	if reason := event.SuppressionReason(); reason != "" && event.Recipient != "" {
+		if err := s.store.SuppressEmail(r.Context(), event.Recipient, reason); err != nil {
+			slog.Error("failed to suppress email address", "recipient", event.Recipient, "error", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		slog.Info("suppressed email address", "recipient", event.Recipient, "reason", reason)
+	}
+	w.WriteHeader(http.StatusOK)
+}
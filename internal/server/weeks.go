@@ -0,0 +1,57 @@
+package server
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+
+	"derrclan.com/moravian-soap/internal/civil"
+	"derrclan.com/moravian-soap/internal/dailytexts"
+	"derrclan.com/moravian-soap/internal/store"
+)
+
+// userWeeks returns how the user groups days into weeks and numbers them. Users whose preferences
+// cannot be loaded get weeks starting on Sunday.
+func (s *Server) userWeeks(ctx context.Context, userID int64) civil.Weeks {
+	prefs, err := s.store.GetPreferences(ctx, userID)
+	if err != nil {
+		slog.Error("failed to get preferences", "user_id", userID, "error", err)
+		return civil.Weeks{}
+	}
+	return prefs.Weeks()
+}
+
+// weekDates returns the dates of the user's current week, which the service worker keeps for offline
+// use.
+func (s *Server) weekDates(ctx context.Context, user *store.User) []string {
+	start := s.userWeeks(ctx, user.ID).Start(s.userToday(user))
+	dates := make([]string, 7)
+	for i := range dates {
+		dates[i] = start.AddDays(i).String()
+	}
+	return dates
+}
+
+// weeklyWatchword is the watchword for the week shown beside a day's reading.
+type weeklyWatchword struct {
+	// Week is the number of the user's week holding the day.
+	Week int
+	Text string
+}
+
+// addWeeklyWatchword adds to data the watchword for the week in force on date, which is given on the
+// Sunday on or before it, numbered as the user numbers weeks.
+func (s *Server) addWeeklyWatchword(ctx context.Context, data map[string]any, userID int64, date civil.Date) {
+	for d := date; d.After(date.AddDays(-7)); d = d.AddDays(-1) {
+		text, err := dailytexts.GetDailyText(d.String())
+		if err != nil || text == nil || text.WeeklyWatchword == "" {
+			continue
+		}
+		_, week := s.userWeeks(ctx, userID).Number(date)
+		data["weeklyWatchword"] = weeklyWatchword{
+			Week: week,
+			Text: strings.TrimPrefix(text.WeeklyWatchword, "Watchword for the week — "),
+		}
+		return
+	}
+}
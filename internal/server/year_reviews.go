@@ -0,0 +1,301 @@
+package server
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"derrclan.com/moravian-soap/internal/config"
+	"derrclan.com/moravian-soap/internal/esv"
+	"derrclan.com/moravian-soap/internal/features"
+	"derrclan.com/moravian-soap/internal/store"
+	"derrclan.com/moravian-soap/internal/yearreview"
+)
+
+// maxReviewVerses is the most highlighted verses a year in review can include.
+const maxReviewVerses = 10
+
+// handleYearReview shows the user a preview of their year in review and lets them share it at a public
+// link with the highlighted verses they choose (POST, with an action of "share"), or stop sharing it
+// (POST, with an action of "stop"), and lets visitors comment on it or not (POST, with an action of
+// "comments"). The year is given by the year parameter, and is the current one
+// by default. A review of a year that is over is made at once; otherwise the year-end job makes it.
+func (s *Server) handleYearReview(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(userContextKey).(*store.User)
+	today := s.userToday(user)
+	year := today.Year
+	if y, err := strconv.Atoi(r.FormValue("year")); err == nil && y > 0 && y <= today.Year {
+		year = y
+	}
+
+	candidates, err := s.highlightedVerses(r, user.ID, year)
+	if err != nil {
+		slog.Error("failed to list highlighted verses", "user_id", user.ID, "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	review, err := s.store.GetYearReview(r.Context(), user.ID, year)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		slog.Error("failed to get year review", "user_id", user.ID, "year", year, "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	var success, errMsg string
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		switch r.FormValue("action") {
+		case "share":
+			verses := r.Form["verses"]
+			if len(verses) > maxReviewVerses {
+				errMsg = "Choose at most " + strconv.Itoa(maxReviewVerses) + " verses."
+				break
+			}
+			if slices.ContainsFunc(verses, func(v string) bool { return !slices.Contains(candidates, v) }) {
+				http.Error(w, "Unknown verse", http.StatusBadRequest)
+				return
+			}
+			if review == nil {
+				review = &store.YearReview{UserID: user.ID, Year: year, Token: generateRandomString(32)}
+			}
+			review.Verses = verses
+			if err := s.store.SaveYearReview(r.Context(), review); err != nil {
+				slog.Error("failed to save year review", "user_id", user.ID, "year", year, "error", err)
+				http.Error(w, "Internal server error", http.StatusInternalServerError)
+				return
+			}
+			success = "Your year in review will be shared once the year is over."
+			if year < today.Year {
+				if err := yearreview.Make(r.Context(), s.store, review); err != nil {
+					slog.Error("failed to make year review", "user_id", user.ID, "year", year, "error", err)
+					http.Error(w, "Internal server error", http.StatusInternalServerError)
+					return
+				}
+				success = "Your year in review is shared."
+			}
+		case "stop":
+			if err := s.store.DeleteYearReview(r.Context(), user.ID, year); err != nil {
+				slog.Error("failed to delete year review", "user_id", user.ID, "year", year, "error", err)
+				http.Error(w, "Internal server error", http.StatusInternalServerError)
+				return
+			}
+			review = nil
+			success = "Your year in review is no longer shared."
+		case "comments":
+			if review == nil || !featureEnabled(user, features.GuestComments) {
+				http.Error(w, "Bad request", http.StatusBadRequest)
+				return
+			}
+			review.CommentsEnabled = r.FormValue("enabled") == "true"
+			if err := s.store.SetYearReviewComments(r.Context(), user.ID, year, review.CommentsEnabled); err != nil {
+				slog.Error("failed to save year review comments", "user_id", user.ID, "year", year, "error", err)
+				http.Error(w, "Internal server error", http.StatusInternalServerError)
+				return
+			}
+			success = "Visitors can no longer comment on your year in review."
+			if review.CommentsEnabled {
+				success = "Visitors can now comment on your year in review."
+			}
+		default:
+			http.Error(w, "Unknown action", http.StatusBadRequest)
+			return
+		}
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	summary, err := yearreview.Summarize(r.Context(), s.store, user.ID, year)
+	if err != nil {
+		slog.Error("failed to summarize year", "user_id", user.ID, "year", year, "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	var verses []reviewVerse
+	for _, ref := range candidates {
+		verses = append(verses, reviewVerse{Reference: ref, Included: review != nil && slices.Contains(review.Verses, ref)})
+	}
+	data := map[string]any{
+		"user":      user,
+		"year":      year,
+		"prevYear":  year - 1,
+		"summary":   summary,
+		"verses":    verses,
+		"maxVerses": maxReviewVerses,
+		"review":    review,
+		"Success":   success,
+		"Error":     errMsg,
+		"CSRFToken": r.Context().Value(csrfContextKey).(string),
+		"Nonce":     r.Context().Value(nonceContextKey).(string),
+	}
+	if year < today.Year {
+		data["nextYear"] = year + 1
+	}
+	if review != nil {
+		data["shareURL"] = reviewURL(review.Token)
+	}
+	if err := s.tmpl.ExecuteTemplate(w, "year_review_settings.html", data); err != nil {
+		slog.Error("failed to execute year review settings template", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
+
+// reviewVerse is a reference to verses the user highlighted, and whether their year in review
+// includes it.
+type reviewVerse struct {
+	Reference string
+	Included  bool
+}
+
+// highlightedVerses returns the references of the verses the user highlighted in their entries of year,
+// in the order of the entries and without duplicates.
+func (s *Server) highlightedVerses(r *http.Request, userID int64, year int) ([]string, error) {
+	entries, err := s.store.ListSOAPData(r.Context(), userID)
+	if err != nil {
+		return nil, err
+	}
+	prefix := strconv.Itoa(year) + "-"
+	var refs []string
+	for _, e := range entries {
+		if !strings.HasPrefix(e.Date, prefix) || len(e.SelectedVerses) == 0 {
+			continue
+		}
+		if ref := esv.FormatReferences(e.SelectedVerses); ref != "" && !slices.Contains(refs, ref) {
+			refs = append(refs, ref)
+		}
+	}
+	return refs, nil
+}
+
+// reviewURL returns the public address of the year in review with token.
+func reviewURL(token string) string {
+	baseURL := os.Getenv("BASE_URL")
+	if baseURL == "" {
+		baseURL = "http://localhost:8080"
+	}
+	return strings.TrimSuffix(baseURL, "/") + "/review/" + token
+}
+
+// sharedYearReview returns the year in review with the token in the request's path, or nil after
+// responding with a 404 if there is none or its owner no longer has the stats available.
+func (s *Server) sharedYearReview(w http.ResponseWriter, r *http.Request) *store.YearReview {
+	review, err := s.store.GetYearReviewByToken(r.Context(), r.PathValue("token"))
+	if errors.Is(err, sql.ErrNoRows) {
+		http.NotFound(w, r)
+		return nil
+	} else if err != nil {
+		slog.Error("failed to get year review", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return nil
+	}
+	overrides, err := s.store.GetFeatureOverrides(r.Context(), review.UserID)
+	if err != nil {
+		slog.Error("failed to get feature overrides", "user_id", review.UserID, "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return nil
+	}
+	if !config.Current().Features.Enabled(features.Stats, overrides) {
+		http.NotFound(w, r)
+		return nil
+	}
+	return review
+}
+
+// handleSharedYearReview renders the public page of a shared year in review, which names nobody. Until
+// the review is made, the page says when it will be. If its owner allows comments, the page lists them
+// and visitors may leave one (POST).
+func (s *Server) handleSharedYearReview(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	review := s.sharedYearReview(w, r)
+	if review == nil {
+		return
+	}
+	open, err := s.guestCommentsOpen(r.Context(), review)
+	if err != nil {
+		slog.Error("failed to check guest comments", "user_id", review.UserID, "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	var errMsg string
+	var status int
+	if r.Method == http.MethodPost {
+		if !open {
+			http.Error(w, "Comments are closed", http.StatusForbidden)
+			return
+		}
+		var redirect string
+		if redirect, errMsg, status = s.addGuestComment(r, review); errMsg == "" {
+			http.Redirect(w, r, redirect, http.StatusSeeOther)
+			return
+		}
+	}
+
+	url := reviewURL(review.Token)
+	// The card's URL changes with it, so that caches in front of the instance never show an old card.
+	cardURL := url + "/card.png"
+	if review.Card != nil {
+		cardURL += "?v=" + urlVersion("/review/"+review.Token+"/card.png", sha256.Sum256(review.Card))
+	}
+	data := map[string]any{
+		"review":    review,
+		"url":       url,
+		"cardURL":   cardURL,
+		"Error":     errMsg,
+		"CSRFToken": r.Context().Value(csrfContextKey).(string),
+		"Nonce":     r.Context().Value(nonceContextKey).(string),
+	}
+	if open {
+		comments, err := s.store.ListGuestComments(r.Context(), review.UserID, review.Year)
+		if err != nil {
+			slog.Error("failed to list guest comments", "user_id", review.UserID, "year", review.Year, "error", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		data["comments"] = comments
+		data["commentsOpen"] = true
+		data["held"] = r.URL.Query().Get("held") == "1"
+		data["name"] = r.PostFormValue("name")
+		data["comment"] = r.PostFormValue("comment")
+		data["maxLength"] = maxGuestCommentLength
+		data["maxNameLength"] = maxGuestNameLength
+	}
+	if errMsg != "" {
+		w.WriteHeader(status)
+	}
+	if err := s.tmpl.ExecuteTemplate(w, "year_review.html", data); err != nil {
+		slog.Error("failed to execute year review template", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
+
+// handleYearReviewCard serves the card image of a shared year in review, for previews of links to it.
+func (s *Server) handleYearReviewCard(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	review := s.sharedYearReview(w, r)
+	if review == nil {
+		return
+	}
+	if review.Card == nil {
+		http.NotFound(w, r)
+		return
+	}
+	// The card of a review that stops being shared must not outlive the public cache TTL anywhere, so
+	// even a request for its current version may only be kept that long.
+	w.Header().Set("Content-Type", "image/png")
+	setPublicCache(w, r, sha256.Sum256(review.Card), config.Current().PublicCacheTTL)
+	_, _ = w.Write(review.Card)
+}
@@ -0,0 +1,96 @@
+package server
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"derrclan.com/moravian-soap/internal/civil"
+	"derrclan.com/moravian-soap/internal/store"
+)
+
+// handleYearbook asks for a printable yearbook of the year in the form to be made (POST). The
+// background job makes it within a minute or so, and the backup page links to it once it is ready.
+func (s *Server) handleYearbook(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	user := r.Context().Value(userContextKey).(*store.User)
+	year, err := strconv.Atoi(r.FormValue("year"))
+	if err != nil || year < 1 || year > s.userToday(user).Year {
+		http.Error(w, "Invalid year", http.StatusBadRequest)
+		return
+	}
+	// A yearbook's size is not known until it is made, so none is made for a user already at their quota.
+	if msg, err := s.quotaExceeded(r.Context(), user.ID, 1); err != nil {
+		slog.Error("failed to check storage quota", "user_id", user.ID, "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	} else if msg != "" {
+		http.Error(w, msg, http.StatusInsufficientStorage)
+		return
+	}
+	if err := s.store.QueueYearbook(r.Context(), user.ID, year); err != nil {
+		slog.Error("failed to queue yearbook", "user_id", user.ID, "year", year, "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	http.Redirect(w, r, "/settings/backup#yearbooks", http.StatusSeeOther)
+}
+
+// handleYearbookPage serves the user's yearbook of the year in the path, ready to print, once it is
+// made.
+func (s *Server) handleYearbookPage(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	user := r.Context().Value(userContextKey).(*store.User)
+	year, err := strconv.Atoi(r.PathValue("year"))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	y, err := s.store.GetYearbook(r.Context(), user.ID, year)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.NotFound(w, r)
+		return
+	} else if err != nil {
+		slog.Error("failed to get yearbook", "user_id", user.ID, "year", year, "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if y.Status != store.YearbookReady {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=yearbook-%d.html", year))
+	_, _ = w.Write(y.HTML)
+}
+
+// journaledYears returns the years the user wrote entries in, latest first.
+func (s *Server) journaledYears(r *http.Request, userID int64) ([]int, error) {
+	entries, err := s.store.ListSOAPData(r.Context(), userID)
+	if err != nil {
+		return nil, err
+	}
+	var years []int
+	for _, e := range entries {
+		date, err := civil.ParseDate(e.Date)
+		if err != nil {
+			continue
+		}
+		if !slices.Contains(years, date.Year) {
+			years = append(years, date.Year)
+		}
+	}
+	slices.Sort(years)
+	slices.Reverse(years)
+	return years, nil
+}
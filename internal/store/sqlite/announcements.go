@@ -0,0 +1,37 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+)
+
+// DismissAnnouncement records that a user has dismissed an announcement. Dismissing it again has no effect.
+func (s *Store) DismissAnnouncement(ctx context.Context, userID int64, announcementID string) error {
+	_, err := s.db.ExecContext(ctx, "INSERT OR IGNORE INTO announcement_dismissals (user_id, announcement_id) VALUES (?, ?)", userID, announcementID)
+	if err != nil {
+		return fmt.Errorf("dismissing announcement %q for user %d: %w", announcementID, userID, err)
+	}
+	return nil
+}
+
+// GetDismissedAnnouncements retrieves the IDs of the announcements a user has dismissed.
+func (s *Store) GetDismissedAnnouncements(ctx context.Context, userID int64) ([]string, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT announcement_id FROM announcement_dismissals WHERE user_id = ?", userID)
+	if err != nil {
+		return nil, fmt.Errorf("querying dismissed announcements for user %d: %w", userID, err)
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("scanning dismissed announcement: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return ids, nil
+}
@@ -0,0 +1,35 @@
+package sqlite
+
+import (
+	"context"
+	"slices"
+	"testing"
+)
+
+func TestStore_Announcements(t *testing.T) {
+	db := setupTestDB(t)
+	s := New(db)
+	ctx := context.Background()
+
+	if ids, err := s.GetDismissedAnnouncements(ctx, 1); err != nil || len(ids) != 0 {
+		t.Fatalf("GetDismissedAnnouncements() = %v, %v; want none", ids, err)
+	}
+
+	for _, id := range []string{"passkeys", "psalter", "passkeys"} {
+		if err := s.DismissAnnouncement(ctx, 1, id); err != nil {
+			t.Fatalf("DismissAnnouncement(%q) failed: %v", id, err)
+		}
+	}
+	if err := s.DismissAnnouncement(ctx, 2, "prayer-list"); err != nil {
+		t.Fatalf("DismissAnnouncement for another user failed: %v", err)
+	}
+
+	ids, err := s.GetDismissedAnnouncements(ctx, 1)
+	if err != nil {
+		t.Fatalf("GetDismissedAnnouncements failed: %v", err)
+	}
+	slices.Sort(ids)
+	if want := []string{"passkeys", "psalter"}; !slices.Equal(ids, want) {
+		t.Errorf("GetDismissedAnnouncements() = %v, want %v", ids, want)
+	}
+}
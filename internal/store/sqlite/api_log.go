@@ -0,0 +1,71 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"derrclan.com/moravian-soap/internal/store"
+)
+
+// referencesSeparator joins the references of a call in the api_log table.
+const referencesSeparator = "; "
+
+// RecordAPICall logs a call to a translation's API and deletes all but the newest keep calls.
+func (s *Store) RecordAPICall(ctx context.Context, call *store.APICall, keep int) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	query := `
+		INSERT INTO api_log (at, translation, refs, url, status, bytes, latency_ms, retries, cache, error)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	res, err := tx.ExecContext(ctx, query, call.At.UTC(), call.Translation, strings.Join(call.References, referencesSeparator),
+		call.URL, call.Status, call.Bytes, call.Latency.Milliseconds(), call.Retries, call.Cache, call.Error)
+	if err != nil {
+		return fmt.Errorf("inserting API call: %w", err)
+	}
+	if call.ID, err = res.LastInsertId(); err != nil {
+		return fmt.Errorf("getting API call id: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM api_log WHERE id <= ?`, call.ID-int64(keep)); err != nil {
+		return fmt.Errorf("trimming API log: %w", err)
+	}
+	return tx.Commit()
+}
+
+// ListAPICalls returns the most recent calls to translations' APIs, newest first.
+func (s *Store) ListAPICalls(ctx context.Context, limit int) ([]*store.APICall, error) {
+	query := `
+		SELECT id, at, translation, refs, url, status, bytes, latency_ms, retries, cache, error
+		FROM api_log
+		ORDER BY id DESC
+		LIMIT ?
+	`
+	rows, err := s.db.QueryContext(ctx, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("querying API log: %w", err)
+	}
+	defer rows.Close()
+
+	var calls []*store.APICall
+	for rows.Next() {
+		var c store.APICall
+		var refs string
+		var latencyMS int64
+		if err := rows.Scan(&c.ID, &c.At, &c.Translation, &refs, &c.URL, &c.Status, &c.Bytes, &latencyMS, &c.Retries, &c.Cache, &c.Error); err != nil {
+			return nil, fmt.Errorf("scanning API call: %w", err)
+		}
+		c.References = strings.Split(refs, referencesSeparator)
+		c.Latency = time.Duration(latencyMS) * time.Millisecond
+		calls = append(calls, &c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return calls, nil
+}
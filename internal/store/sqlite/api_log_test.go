@@ -0,0 +1,50 @@
+package sqlite
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"derrclan.com/moravian-soap/internal/store"
+)
+
+func TestStore_APILog(t *testing.T) {
+	db := setupTestDB(t)
+	s := New(db)
+	ctx := context.Background()
+
+	at := time.Date(2026, time.March, 7, 8, 0, 0, 0, time.UTC)
+	for i := range 5 {
+		call := &store.APICall{
+			At:          at.Add(time.Duration(i) * time.Minute),
+			Translation: "ESV",
+			References:  []string{"Psalm 23", "John 10:11"},
+			URL:         "https://api.esv.org/v3/passage/html/?q=Psalm+23",
+			Status:      200,
+			Bytes:       1000 + i,
+			Latency:     150 * time.Millisecond,
+			Retries:     i % 2,
+			Cache:       "stored",
+		}
+		if err := s.RecordAPICall(ctx, call, 3); err != nil {
+			t.Fatalf("RecordAPICall failed: %v", err)
+		}
+	}
+
+	calls, err := s.ListAPICalls(ctx, 10)
+	if err != nil {
+		t.Fatalf("ListAPICalls failed: %v", err)
+	}
+	if len(calls) != 3 {
+		t.Fatalf("ListAPICalls returned %d calls, want the newest 3", len(calls))
+	}
+	newest := calls[0]
+	if newest.Bytes != 1004 || !newest.At.Equal(at.Add(4*time.Minute)) || newest.Latency != 150*time.Millisecond ||
+		!slices.Equal(newest.References, []string{"Psalm 23", "John 10:11"}) || newest.Cache != "stored" {
+		t.Errorf("newest call = %+v", newest)
+	}
+	if calls[2].Bytes != 1002 {
+		t.Errorf("oldest call kept = %+v, want the third recorded", calls[2])
+	}
+}
@@ -0,0 +1,130 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"derrclan.com/moravian-soap/internal/store"
+)
+
+// CreateAPIToken saves a new personal API token and returns its ID.
+func (s *Store) CreateAPIToken(ctx context.Context, userID int64, name, tokenHash string) (int64, error) {
+	res, err := s.db.ExecContext(ctx, "INSERT INTO api_tokens (user_id, name, token_hash) VALUES (?, ?, ?)", userID, name, tokenHash)
+	if err != nil {
+		return 0, fmt.Errorf("saving API token for user %d: %w", userID, err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("getting last insert id: %w", err)
+	}
+	return id, nil
+}
+
+// ListAPITokens retrieves all API tokens belonging to a user, newest first.
+func (s *Store) ListAPITokens(ctx context.Context, userID int64) ([]*store.APIToken, error) {
+	query := `
+		SELECT id, user_id, name, request_count, last_used_at, created_at
+		FROM api_tokens
+		WHERE user_id = ?
+		ORDER BY created_at DESC, id DESC
+	`
+	rows, err := s.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("querying API tokens for user %d: %w", userID, err)
+	}
+	defer rows.Close()
+
+	var tokens []*store.APIToken
+	for rows.Next() {
+		var t store.APIToken
+		if err := rows.Scan(&t.ID, &t.UserID, &t.Name, &t.RequestCount, &t.LastUsedAt, &t.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scanning API token: %w", err)
+		}
+		tokens = append(tokens, &t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return tokens, nil
+}
+
+// DeleteAPIToken revokes one of a user's API tokens.
+func (s *Store) DeleteAPIToken(ctx context.Context, userID, tokenID int64) error {
+	_, err := s.db.ExecContext(ctx, "DELETE FROM api_tokens WHERE id = ? AND user_id = ?", tokenID, userID)
+	if err != nil {
+		return fmt.Errorf("deleting API token %d: %w", tokenID, err)
+	}
+	return nil
+}
+
+// AuthenticateAPIToken retrieves the user owning the token with the given hash, with the features turned
+// on or off for them, and records the use of the token.
+func (s *Store) AuthenticateAPIToken(ctx context.Context, tokenHash string) (*store.User, error) {
+	var user store.User
+	query := `
+		UPDATE api_tokens
+		SET request_count = request_count + 1, last_used_at = ?
+		WHERE token_hash = ?
+		RETURNING user_id`
+	err := s.db.QueryRowContext(ctx, query, time.Now().UTC(), tokenHash).Scan(&user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("authenticating API token: %w", err)
+	}
+
+	err = s.db.QueryRowContext(ctx, "SELECT email, is_verified, timezone FROM users WHERE id = ?", user.ID).Scan(&user.Email, &user.IsVerified, &user.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("getting user %d for API token: %w", user.ID, err)
+	}
+	if user.Features, err = s.GetFeatureOverrides(ctx, user.ID); err != nil {
+		return nil, fmt.Errorf("getting user %d for API token: %w", user.ID, err)
+	}
+	return &user, nil
+}
+
+// RecordESVUsage adds to the number of ESV API requests attributed to a user on the given day.
+func (s *Store) RecordESVUsage(ctx context.Context, userID int64, day string, requests int) error {
+	query := `
+		INSERT INTO esv_usage (user_id, day, requests)
+		VALUES (?, ?, ?)
+		ON CONFLICT(user_id, day) DO UPDATE SET requests = requests + excluded.requests
+	`
+	_, err := s.db.ExecContext(ctx, query, userID, day, requests)
+	if err != nil {
+		return fmt.Errorf("recording ESV usage for user %d: %w", userID, err)
+	}
+	return nil
+}
+
+// GetESVUsage retrieves the daily ESV API requests attributed to a user on or after the given day, newest first.
+func (s *Store) GetESVUsage(ctx context.Context, userID int64, since string) ([]*store.ESVUsage, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT day, requests FROM esv_usage WHERE user_id = ? AND day >= ? ORDER BY day DESC", userID, since)
+	if err != nil {
+		return nil, fmt.Errorf("querying ESV usage for user %d: %w", userID, err)
+	}
+	defer rows.Close()
+
+	var usage []*store.ESVUsage
+	for rows.Next() {
+		var u store.ESVUsage
+		if err := rows.Scan(&u.Day, &u.Requests); err != nil {
+			return nil, fmt.Errorf("scanning ESV usage: %w", err)
+		}
+		usage = append(usage, &u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return usage, nil
+}
+
+// GetInstanceESVUsage retrieves the total number of ESV API requests made by the instance on the given day.
+func (s *Store) GetInstanceESVUsage(ctx context.Context, day string) (int, error) {
+	var total sql.NullInt64
+	err := s.db.QueryRowContext(ctx, "SELECT SUM(requests) FROM esv_usage WHERE day = ?", day).Scan(&total)
+	if err != nil {
+		return 0, fmt.Errorf("summing ESV usage for %s: %w", day, err)
+	}
+	return int(total.Int64), nil
+}
@@ -0,0 +1,113 @@
+package sqlite
+
+import (
+	"context"
+	"testing"
+)
+
+func TestStore_APITokenOperations(t *testing.T) {
+	db := setupTestDB(t)
+	s := New(db)
+	ctx := context.Background()
+
+	_, _ = db.Exec("INSERT INTO users (id, email, password_hash, is_verified, timezone) VALUES (1, 'a@example.com', 'h', 1, 'America/Chicago')")
+
+	id, err := s.CreateAPIToken(ctx, 1, "widget", "hash-1")
+	if err != nil {
+		t.Fatalf("CreateAPIToken failed: %v", err)
+	}
+
+	t.Run("Authenticate records usage", func(t *testing.T) {
+		for range 2 {
+			user, err := s.AuthenticateAPIToken(ctx, "hash-1")
+			if err != nil {
+				t.Fatalf("AuthenticateAPIToken failed: %v", err)
+			}
+			if user.ID != 1 || user.Email != "a@example.com" || user.Timezone != "America/Chicago" {
+				t.Errorf("unexpected user: %+v", user)
+			}
+		}
+
+		tokens, err := s.ListAPITokens(ctx, 1)
+		if err != nil {
+			t.Fatalf("ListAPITokens failed: %v", err)
+		}
+		if len(tokens) != 1 {
+			t.Fatalf("expected 1 token, got %d", len(tokens))
+		}
+		if tokens[0].RequestCount != 2 {
+			t.Errorf("expected 2 requests, got %d", tokens[0].RequestCount)
+		}
+		if tokens[0].LastUsedAt == nil {
+			t.Error("expected last_used_at to be set")
+		}
+	})
+
+	t.Run("Unknown token", func(t *testing.T) {
+		if _, err := s.AuthenticateAPIToken(ctx, "missing"); err == nil {
+			t.Error("expected error for unknown token")
+		}
+	})
+
+	t.Run("Revoke only own token", func(t *testing.T) {
+		if err := s.DeleteAPIToken(ctx, 2, id); err != nil {
+			t.Fatalf("DeleteAPIToken failed: %v", err)
+		}
+		if _, err := s.AuthenticateAPIToken(ctx, "hash-1"); err != nil {
+			t.Errorf("token revoked by another user: %v", err)
+		}
+
+		if err := s.DeleteAPIToken(ctx, 1, id); err != nil {
+			t.Fatalf("DeleteAPIToken failed: %v", err)
+		}
+		if _, err := s.AuthenticateAPIToken(ctx, "hash-1"); err == nil {
+			t.Error("expected error for revoked token")
+		}
+	})
+}
+
+func TestStore_ESVUsage(t *testing.T) {
+	db := setupTestDB(t)
+	s := New(db)
+	ctx := context.Background()
+
+	records := []struct {
+		userID int64
+		day    string
+	}{
+		{1, "2026-10-01"},
+		{1, "2026-10-02"},
+		{1, "2026-10-02"},
+		{2, "2026-10-02"},
+		{0, "2026-10-02"},
+	}
+	for _, r := range records {
+		if err := s.RecordESVUsage(ctx, r.userID, r.day, 1); err != nil {
+			t.Fatalf("RecordESVUsage failed: %v", err)
+		}
+	}
+
+	usage, err := s.GetESVUsage(ctx, 1, "2026-10-02")
+	if err != nil {
+		t.Fatalf("GetESVUsage failed: %v", err)
+	}
+	if len(usage) != 1 || usage[0].Day != "2026-10-02" || usage[0].Requests != 2 {
+		t.Errorf("unexpected usage: %+v", usage)
+	}
+
+	total, err := s.GetInstanceESVUsage(ctx, "2026-10-02")
+	if err != nil {
+		t.Fatalf("GetInstanceESVUsage failed: %v", err)
+	}
+	if total != 4 {
+		t.Errorf("expected 4 instance requests, got %d", total)
+	}
+
+	total, err = s.GetInstanceESVUsage(ctx, "2026-01-01")
+	if err != nil {
+		t.Fatalf("GetInstanceESVUsage failed: %v", err)
+	}
+	if total != 0 {
+		t.Errorf("expected 0 instance requests, got %d", total)
+	}
+}
@@ -0,0 +1,72 @@
+package sqlite
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"derrclan.com/moravian-soap/internal/store"
+)
+
+// RecordCacheVerification logs a check of cached passages and deletes all but the newest keep checks.
+func (s *Store) RecordCacheVerification(ctx context.Context, v *store.CacheVerification, keep int) error {
+	changed := v.Changed
+	if changed == nil {
+		changed = []string{}
+	}
+	changedJSON, err := json.Marshal(changed)
+	if err != nil {
+		return fmt.Errorf("marshaling changed keys: %w", err)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	query := `INSERT INTO cache_verifications (verified_at, checked, failed, changed) VALUES (?, ?, ?, ?)`
+	res, err := tx.ExecContext(ctx, query, v.At.UTC(), v.Checked, v.Failed, string(changedJSON))
+	if err != nil {
+		return fmt.Errorf("inserting cache verification: %w", err)
+	}
+	if v.ID, err = res.LastInsertId(); err != nil {
+		return fmt.Errorf("getting cache verification id: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM cache_verifications WHERE id <= ?`, v.ID-int64(keep)); err != nil {
+		return fmt.Errorf("trimming cache verifications: %w", err)
+	}
+	return tx.Commit()
+}
+
+// ListCacheVerifications returns the most recent checks of cached passages, newest first.
+func (s *Store) ListCacheVerifications(ctx context.Context, limit int) ([]*store.CacheVerification, error) {
+	query := `
+		SELECT id, verified_at, checked, failed, changed
+		FROM cache_verifications
+		ORDER BY id DESC
+		LIMIT ?
+	`
+	rows, err := s.db.QueryContext(ctx, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("querying cache verifications: %w", err)
+	}
+	defer rows.Close()
+
+	var verifications []*store.CacheVerification
+	for rows.Next() {
+		var v store.CacheVerification
+		var changed string
+		if err := rows.Scan(&v.ID, &v.At, &v.Checked, &v.Failed, &changed); err != nil {
+			return nil, fmt.Errorf("scanning cache verification: %w", err)
+		}
+		if err := json.Unmarshal([]byte(changed), &v.Changed); err != nil {
+			return nil, fmt.Errorf("unmarshaling changed keys of cache verification %d: %w", v.ID, err)
+		}
+		verifications = append(verifications, &v)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return verifications, nil
+}
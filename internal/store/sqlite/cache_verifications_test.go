@@ -0,0 +1,84 @@
+package sqlite
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"derrclan.com/moravian-soap/internal/store"
+)
+
+func TestStore_CacheVerifications(t *testing.T) {
+	db := setupTestDB(t)
+	s := New(db)
+	ctx := context.Background()
+
+	at := time.Date(2026, time.March, 7, 3, 0, 0, 0, time.UTC)
+	for i := range 4 {
+		v := &store.CacheVerification{At: at.AddDate(0, 0, i), Checked: 5, Failed: i % 2}
+		if i == 3 {
+			v.Changed = []string{"John 3:16;Psalm 23|1110", "Romans 8:28"}
+		}
+		if err := s.RecordCacheVerification(ctx, v, 2); err != nil {
+			t.Fatalf("RecordCacheVerification failed: %v", err)
+		}
+	}
+
+	verifications, err := s.ListCacheVerifications(ctx, 10)
+	if err != nil {
+		t.Fatalf("ListCacheVerifications failed: %v", err)
+	}
+	if len(verifications) != 2 {
+		t.Fatalf("ListCacheVerifications returned %d checks, want the newest 2", len(verifications))
+	}
+	newest := verifications[0]
+	if !newest.At.Equal(at.AddDate(0, 0, 3)) || newest.Checked != 5 || newest.Failed != 1 ||
+		!slices.Equal(newest.Changed, []string{"John 3:16;Psalm 23|1110", "Romans 8:28"}) {
+		t.Errorf("newest check = %+v", newest)
+	}
+	if older := verifications[1]; older.Failed != 0 || len(older.Changed) != 0 {
+		t.Errorf("older check = %+v, want none failed or changed", older)
+	}
+}
+
+func TestStore_SampleCachedESV(t *testing.T) {
+	db := setupTestDB(t)
+	s := New(db)
+	ctx := context.Background()
+
+	for _, key := range []string{"John 1:1", "John 1:2", "John 1:3"} {
+		if err := s.SaveCachedESV(ctx, key, `{"query":"`+key+`"}`); err != nil {
+			t.Fatalf("SaveCachedESV(%s) failed: %v", key, err)
+		}
+	}
+	_, err := db.Exec(`INSERT INTO esv_cache (reference, content, created_at) VALUES ('Genesis 1:1', '{}', datetime('now', '-2 days'))`)
+	if err != nil {
+		t.Fatalf("failed to insert expired entry: %v", err)
+	}
+	// Sampling must not count as reading the entries.
+	if _, err := db.Exec(`UPDATE esv_cache SET last_accessed = NULL`); err != nil {
+		t.Fatalf("failed to clear access times: %v", err)
+	}
+
+	sample, err := s.SampleCachedESV(ctx, 24*time.Hour, 2)
+	if err != nil {
+		t.Fatalf("SampleCachedESV failed: %v", err)
+	}
+	if len(sample) != 2 {
+		t.Errorf("SampleCachedESV returned %d entries, want 2", len(sample))
+	}
+	if all, err := s.SampleCachedESV(ctx, 24*time.Hour, 10); err != nil || len(all) != 3 {
+		t.Errorf("SampleCachedESV(10) = %v, %v; want the 3 unexpired entries", all, err)
+	} else if all["John 1:2"] != `{"query":"John 1:2"}` {
+		t.Errorf("sampled content of John 1:2 = %q", all["John 1:2"])
+	}
+
+	var touched int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM esv_cache WHERE last_accessed IS NOT NULL`).Scan(&touched); err != nil {
+		t.Fatalf("failed to count touched entries: %v", err)
+	}
+	if touched != 0 {
+		t.Errorf("sampling marked %d entries as used, want 0", touched)
+	}
+}
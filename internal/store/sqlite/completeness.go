@@ -0,0 +1,72 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+)
+
+// SaveEntryCompleteness records how much of a user's journal entry for a date has been written. It does
+// nothing if there is no entry for the date.
+func (s *Store) SaveEntryCompleteness(ctx context.Context, userID int64, dateStr, completeness string) error {
+	query := `UPDATE journal SET completeness = ? WHERE user_id = ? AND date = ?`
+	if _, err := s.db.ExecContext(ctx, query, completeness, userID, dateStr); err != nil {
+		return fmt.Errorf("saving completeness of %s: %w", dateStr, err)
+	}
+	return nil
+}
+
+// GetEntryCompleteness retrieves the completeness of a user's journal entries between from and to
+// (inclusive), by date. Dates without an entry, or whose entry is empty, are left out.
+func (s *Store) GetEntryCompleteness(ctx context.Context, userID int64, from, to string) (map[string]string, error) {
+	query := `
+		SELECT date, completeness FROM journal
+		WHERE user_id = ? AND date BETWEEN ? AND ? AND completeness != 'none'
+	`
+	rows, err := s.db.QueryContext(ctx, query, userID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("querying entry completeness: %w", err)
+	}
+	defer rows.Close()
+
+	completeness := make(map[string]string)
+	for rows.Next() {
+		var date, c string
+		if err := rows.Scan(&date, &c); err != nil {
+			return nil, fmt.Errorf("scanning entry completeness: %w", err)
+		}
+		completeness[date] = c
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return completeness, nil
+}
+
+// CountEntryCompleteness counts a user's journal entries dated on or after since by their completeness.
+// Empty entries are not counted.
+func (s *Store) CountEntryCompleteness(ctx context.Context, userID int64, since string) (map[string]int, error) {
+	query := `
+		SELECT completeness, COUNT(*) FROM journal
+		WHERE user_id = ? AND date >= ? AND completeness != 'none'
+		GROUP BY completeness
+	`
+	rows, err := s.db.QueryContext(ctx, query, userID, since)
+	if err != nil {
+		return nil, fmt.Errorf("counting entry completeness: %w", err)
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var c string
+		var n int
+		if err := rows.Scan(&c, &n); err != nil {
+			return nil, fmt.Errorf("scanning entry completeness count: %w", err)
+		}
+		counts[c] = n
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return counts, nil
+}
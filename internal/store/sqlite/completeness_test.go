@@ -0,0 +1,58 @@
+package sqlite
+
+import (
+	"context"
+	"maps"
+	"testing"
+
+	"derrclan.com/moravian-soap/internal/store"
+)
+
+func TestStore_EntryCompleteness(t *testing.T) {
+	db := setupTestDB(t)
+	s := New(db)
+	ctx := context.Background()
+
+	for date, completeness := range map[string]string{
+		"2026-02-14": "complete",
+		"2026-02-15": "partial",
+		"2026-02-16": "complete",
+		"2026-02-17": "none",
+		"2026-02-18": "draft",
+	} {
+		if err := s.SaveSOAPData(ctx, 1, &store.SOAPData{Date: date, Sections: map[string]string{}}); err != nil {
+			t.Fatalf("SaveSOAPData failed: %v", err)
+		}
+		if err := s.SaveEntryCompleteness(ctx, 1, date, completeness); err != nil {
+			t.Fatalf("SaveEntryCompleteness failed: %v", err)
+		}
+	}
+	// An entry that does not exist is not created.
+	if err := s.SaveEntryCompleteness(ctx, 1, "2026-02-19", "complete"); err != nil {
+		t.Fatalf("SaveEntryCompleteness failed: %v", err)
+	}
+
+	got, err := s.GetEntryCompleteness(ctx, 1, "2026-02-15", "2026-02-19")
+	if err != nil {
+		t.Fatalf("GetEntryCompleteness failed: %v", err)
+	}
+	want := map[string]string{"2026-02-15": "partial", "2026-02-16": "complete", "2026-02-18": "draft"}
+	if !maps.Equal(got, want) {
+		t.Errorf("GetEntryCompleteness = %v, want %v", got, want)
+	}
+
+	counts, err := s.CountEntryCompleteness(ctx, 1, "")
+	if err != nil {
+		t.Fatalf("CountEntryCompleteness failed: %v", err)
+	}
+	if want := map[string]int{"complete": 2, "partial": 1, "draft": 1}; !maps.Equal(counts, want) {
+		t.Errorf("CountEntryCompleteness = %v, want %v", counts, want)
+	}
+	counts, err = s.CountEntryCompleteness(ctx, 1, "2026-02-16")
+	if err != nil {
+		t.Fatalf("CountEntryCompleteness failed: %v", err)
+	}
+	if want := map[string]int{"complete": 1, "draft": 1}; !maps.Equal(counts, want) {
+		t.Errorf("CountEntryCompleteness since 2026-02-16 = %v, want %v", counts, want)
+	}
+}
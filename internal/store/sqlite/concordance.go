@@ -0,0 +1,80 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+
+	"derrclan.com/moravian-soap/internal/store"
+)
+
+// SaveReadingChapters replaces the index of the chapters in the daily readings of a year with
+// readings, keyed by date.
+func (s *Store) SaveReadingChapters(ctx context.Context, year int, readings map[string][]store.Chapter) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM reading_chapters WHERE date LIKE ?", fmt.Sprintf("%04d-%%", year)); err != nil {
+		return fmt.Errorf("deleting reading chapters for %d: %w", year, err)
+	}
+	stmt, err := tx.PrepareContext(ctx, "INSERT OR IGNORE INTO reading_chapters (date, book, chapter) VALUES (?, ?, ?)")
+	if err != nil {
+		return fmt.Errorf("preparing reading chapter insert: %w", err)
+	}
+	defer stmt.Close()
+	for date, chapters := range readings {
+		for _, c := range chapters {
+			if _, err := stmt.ExecContext(ctx, date, c.Book, c.Chapter); err != nil {
+				return fmt.Errorf("saving reading chapter %d:%d for %s: %w", c.Book, c.Chapter, date, err)
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("committing reading chapters: %w", err)
+	}
+	return nil
+}
+
+// GetConcordance retrieves every chapter in the daily readings from from to to, inclusive, and in the
+// user's journal entries, with the dates it appeared on and whether the user journaled on each.
+func (s *Store) GetConcordance(ctx context.Context, userID int64, from, to string) ([]*store.ConcordanceChapter, error) {
+	query := `
+		SELECT k.book, k.chapter, k.date,
+			EXISTS (
+				SELECT 1 FROM journal_sections s
+				WHERE s.user_id = ? AND s.date = k.date AND s.content != ''
+			)
+		FROM (
+			SELECT book, chapter, date FROM reading_chapters WHERE date BETWEEN ? AND ?
+			UNION
+			SELECT book, chapter, date FROM journal_chapters WHERE user_id = ?
+		) k
+		ORDER BY k.book, k.chapter, k.date
+	`
+	rows, err := s.db.QueryContext(ctx, query, userID, from, to, userID)
+	if err != nil {
+		return nil, fmt.Errorf("querying concordance: %w", err)
+	}
+	defer rows.Close()
+
+	var concordance []*store.ConcordanceChapter
+	for rows.Next() {
+		var c store.Chapter
+		var d store.ConcordanceDate
+		if err := rows.Scan(&c.Book, &c.Chapter, &d.Date, &d.Journaled); err != nil {
+			return nil, fmt.Errorf("scanning concordance: %w", err)
+		}
+		if n := len(concordance); n > 0 && concordance[n-1].Chapter == c {
+			concordance[n-1].Dates = append(concordance[n-1].Dates, d)
+			continue
+		}
+		concordance = append(concordance, &store.ConcordanceChapter{Chapter: c, Dates: []store.ConcordanceDate{d}})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return concordance, nil
+}
@@ -0,0 +1,76 @@
+package sqlite
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"derrclan.com/moravian-soap/internal/store"
+)
+
+func TestStore_Concordance(t *testing.T) {
+	db := setupTestDB(t)
+	s := New(db)
+	ctx := context.Background()
+
+	psalm1 := store.Chapter{Book: 19, Chapter: 1}
+	genesis1 := store.Chapter{Book: 1, Chapter: 1}
+	romans8 := store.Chapter{Book: 45, Chapter: 8}
+
+	err := s.SaveReadingChapters(ctx, 2026, map[string][]store.Chapter{
+		"2026-01-01": {psalm1, genesis1},
+		"2026-01-02": {genesis1},
+		"2026-01-03": {psalm1},
+	})
+	if err != nil {
+		t.Fatalf("SaveReadingChapters failed: %v", err)
+	}
+	// Another year's readings are kept when a year is indexed again.
+	if err := s.SaveReadingChapters(ctx, 2025, map[string][]store.Chapter{"2025-12-31": {psalm1}}); err != nil {
+		t.Fatalf("SaveReadingChapters failed: %v", err)
+	}
+	// An entry on a chapter outside the readings, and one by someone else.
+	if err := s.SaveSOAPData(ctx, 1, &store.SOAPData{Date: "2026-01-02", Sections: map[string]string{"observation": "In the beginning."}}); err != nil {
+		t.Fatalf("SaveSOAPData failed: %v", err)
+	}
+	if err := s.SaveJournalChapters(ctx, 1, "2026-01-02", []store.Chapter{genesis1, romans8}); err != nil {
+		t.Fatalf("SaveJournalChapters failed: %v", err)
+	}
+	if err := s.SaveSOAPData(ctx, 2, &store.SOAPData{Date: "2026-01-01", Sections: map[string]string{"observation": "Blessed."}}); err != nil {
+		t.Fatalf("SaveSOAPData failed: %v", err)
+	}
+
+	got, err := s.GetConcordance(ctx, 1, "2026-01-01", "2026-01-02")
+	if err != nil {
+		t.Fatalf("GetConcordance failed: %v", err)
+	}
+	want := []*store.ConcordanceChapter{
+		{Chapter: genesis1, Dates: []store.ConcordanceDate{{Date: "2026-01-01"}, {Date: "2026-01-02", Journaled: true}}},
+		{Chapter: psalm1, Dates: []store.ConcordanceDate{{Date: "2026-01-01"}}},
+		{Chapter: romans8, Dates: []store.ConcordanceDate{{Date: "2026-01-02", Journaled: true}}},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("GetConcordance returned %d chapters, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i].Chapter != want[i].Chapter || !slices.Equal(got[i].Dates, want[i].Dates) {
+			t.Errorf("chapter %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+
+	// Indexing a year again replaces its readings. Chapters of entries are included whatever their date.
+	if err := s.SaveReadingChapters(ctx, 2026, map[string][]store.Chapter{"2026-01-01": {romans8}}); err != nil {
+		t.Fatalf("SaveReadingChapters failed: %v", err)
+	}
+	got, err = s.GetConcordance(ctx, 1, "2025-12-31", "2026-01-01")
+	if err != nil {
+		t.Fatalf("GetConcordance failed: %v", err)
+	}
+	var chapters []store.Chapter
+	for _, c := range got {
+		chapters = append(chapters, c.Chapter)
+	}
+	if !slices.Equal(chapters, []store.Chapter{genesis1, psalm1, romans8}) || len(got[2].Dates) != 2 {
+		t.Errorf("concordance after reindexing has chapters %v, want Genesis 1, Psalm 1 and Romans 8 on two dates", chapters)
+	}
+}
@@ -0,0 +1,52 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+
+	"derrclan.com/moravian-soap/internal/store"
+)
+
+// ListDailyEmailSubscribers retrieves the verified users with a confirmed subscription to the daily
+// email and a time to be sent it, with the date of the last one they were sent.
+func (s *Store) ListDailyEmailSubscribers(ctx context.Context) ([]*store.DailyEmailSubscriber, error) {
+	query := `
+		SELECT u.id, u.email, u.timezone, sub.token, p.daily_email_time, COALESCE(d.last_sent_date, '')
+		FROM users u
+		JOIN user_preferences p ON p.user_id = u.id
+		JOIN email_subscriptions sub ON sub.user_id = u.id AND sub.list = ? AND sub.status = ?
+		LEFT JOIN daily_emails d ON d.user_id = u.id
+		WHERE u.is_verified = 1 AND p.daily_email_time != ''
+		ORDER BY u.id
+	`
+	rows, err := s.db.QueryContext(ctx, query, store.DailyEmailList, store.SubscriptionActive)
+	if err != nil {
+		return nil, fmt.Errorf("querying daily email subscribers: %w", err)
+	}
+	defer rows.Close()
+
+	var subscribers []*store.DailyEmailSubscriber
+	for rows.Next() {
+		var sub store.DailyEmailSubscriber
+		if err := rows.Scan(&sub.UserID, &sub.Email, &sub.Timezone, &sub.Token, &sub.SendTime, &sub.LastSentDate); err != nil {
+			return nil, fmt.Errorf("scanning daily email subscriber: %w", err)
+		}
+		subscribers = append(subscribers, &sub)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return subscribers, nil
+}
+
+// MarkDailyEmailSent records that a user was sent the daily email for a date.
+func (s *Store) MarkDailyEmailSent(ctx context.Context, userID int64, date string) error {
+	query := `
+		INSERT INTO daily_emails (user_id, last_sent_date) VALUES (?, ?)
+		ON CONFLICT(user_id) DO UPDATE SET last_sent_date = excluded.last_sent_date
+	`
+	if _, err := s.db.ExecContext(ctx, query, userID, date); err != nil {
+		return fmt.Errorf("marking daily email sent to user %d: %w", userID, err)
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package sqlite
+
+import (
+	"context"
+	"testing"
+
+	"derrclan.com/moravian-soap/internal/store"
+)
+
+func TestStore_DailyEmailSubscribers(t *testing.T) {
+	db := setupTestDB(t)
+	s := New(db)
+	ctx := context.Background()
+
+	for _, stmt := range []string{
+		`INSERT INTO users (id, email, password_hash, is_verified, timezone) VALUES
+			(1, 'morning@example.com', 'x', 1, 'America/Chicago'),
+			(2, 'off@example.com', 'x', 1, 'UTC'),
+			(3, 'unverified@example.com', 'x', 0, 'UTC'),
+			(4, 'pending@example.com', 'x', 1, 'UTC'),
+			(5, 'unsubscribed@example.com', 'x', 1, 'UTC')`,
+		`INSERT INTO user_preferences (user_id, daily_email_time) VALUES (1, '06:30'), (2, ''), (3, '07:00'), (4, '07:00'), (5, '07:00')`,
+		`INSERT INTO email_subscriptions (user_id, list, status, token) VALUES
+			(1, 'daily', 'active', 'token-1'),
+			(2, 'daily', 'active', 'token-2'),
+			(3, 'daily', 'active', 'token-3'),
+			(4, 'daily', 'pending', 'token-4'),
+			(5, 'daily', 'unsubscribed', 'token-5')`,
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("failed to insert test data: %v", err)
+		}
+	}
+
+	subscribers, err := s.ListDailyEmailSubscribers(ctx)
+	if err != nil {
+		t.Fatalf("ListDailyEmailSubscribers failed: %v", err)
+	}
+	want := store.DailyEmailSubscriber{UserID: 1, Email: "morning@example.com", Timezone: "America/Chicago", Token: "token-1", SendTime: "06:30"}
+	if len(subscribers) != 1 || *subscribers[0] != want {
+		t.Fatalf("ListDailyEmailSubscribers = %+v, want only %+v", subscribers, want)
+	}
+
+	for _, date := range []string{"2026-03-05", "2026-03-06"} {
+		if err := s.MarkDailyEmailSent(ctx, 1, date); err != nil {
+			t.Fatalf("MarkDailyEmailSent failed: %v", err)
+		}
+	}
+	subscribers, err = s.ListDailyEmailSubscribers(ctx)
+	if err != nil {
+		t.Fatalf("ListDailyEmailSubscribers failed: %v", err)
+	}
+	if got := subscribers[0].LastSentDate; got != "2026-03-06" {
+		t.Errorf("LastSentDate = %q, want 2026-03-06", got)
+	}
+}
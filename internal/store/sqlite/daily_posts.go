@@ -0,0 +1,71 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+
+	"derrclan.com/moravian-soap/internal/store"
+)
+
+// CreateDailyPostChannel saves a new daily post channel and sets its ID.
+func (s *Store) CreateDailyPostChannel(ctx context.Context, ch *store.DailyPostChannel) error {
+	query := `
+		INSERT INTO daily_post_channels (name, kind, webhook_url, template, post_time, timezone)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`
+	res, err := s.db.ExecContext(ctx, query, ch.Name, ch.Kind, ch.WebhookURL, ch.Template, ch.PostTime, ch.Timezone)
+	if err != nil {
+		return fmt.Errorf("saving daily post channel %q: %w", ch.Name, err)
+	}
+	ch.ID, err = res.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("getting last insert id: %w", err)
+	}
+	return nil
+}
+
+// ListDailyPostChannels retrieves every daily post channel, oldest first.
+func (s *Store) ListDailyPostChannels(ctx context.Context) ([]*store.DailyPostChannel, error) {
+	query := `
+		SELECT id, name, kind, webhook_url, template, post_time, timezone, last_posted_date, created_at
+		FROM daily_post_channels
+		ORDER BY id
+	`
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("querying daily post channels: %w", err)
+	}
+	defer rows.Close()
+
+	var channels []*store.DailyPostChannel
+	for rows.Next() {
+		var ch store.DailyPostChannel
+		err := rows.Scan(&ch.ID, &ch.Name, &ch.Kind, &ch.WebhookURL, &ch.Template, &ch.PostTime, &ch.Timezone,
+			&ch.LastPostedDate, &ch.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("scanning daily post channel: %w", err)
+		}
+		channels = append(channels, &ch)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return channels, nil
+}
+
+// MarkDailyPostSent records that a channel's post for date (YYYY-MM-DD) has been sent.
+func (s *Store) MarkDailyPostSent(ctx context.Context, id int64, date string) error {
+	_, err := s.db.ExecContext(ctx, "UPDATE daily_post_channels SET last_posted_date = ? WHERE id = ?", date, id)
+	if err != nil {
+		return fmt.Errorf("marking daily post %d sent: %w", id, err)
+	}
+	return nil
+}
+
+// DeleteDailyPostChannel removes a daily post channel.
+func (s *Store) DeleteDailyPostChannel(ctx context.Context, id int64) error {
+	if _, err := s.db.ExecContext(ctx, "DELETE FROM daily_post_channels WHERE id = ?", id); err != nil {
+		return fmt.Errorf("deleting daily post channel %d: %w", id, err)
+	}
+	return nil
+}
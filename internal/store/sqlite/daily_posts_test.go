@@ -0,0 +1,50 @@
+package sqlite
+
+import (
+	"context"
+	"testing"
+
+	"derrclan.com/moravian-soap/internal/store"
+)
+
+func TestStore_DailyPostChannels(t *testing.T) {
+	db := setupTestDB(t)
+	s := New(db)
+	ctx := context.Background()
+
+	ch := &store.DailyPostChannel{
+		Name:       "#devotions",
+		Kind:       "discord",
+		WebhookURL: "https://discord.com/api/webhooks/1/token",
+		PostTime:   "06:30",
+		Timezone:   "America/New_York",
+	}
+	if err := s.CreateDailyPostChannel(ctx, ch); err != nil {
+		t.Fatalf("CreateDailyPostChannel failed: %v", err)
+	}
+	if ch.ID == 0 {
+		t.Fatal("expected the channel ID to be set")
+	}
+
+	if err := s.MarkDailyPostSent(ctx, ch.ID, "2026-10-16"); err != nil {
+		t.Fatalf("MarkDailyPostSent failed: %v", err)
+	}
+	channels, err := s.ListDailyPostChannels(ctx)
+	if err != nil {
+		t.Fatalf("ListDailyPostChannels failed: %v", err)
+	}
+	if len(channels) != 1 {
+		t.Fatalf("expected 1 channel, got %d", len(channels))
+	}
+	got := channels[0]
+	if got.Name != ch.Name || got.PostTime != "06:30" || got.Timezone != "America/New_York" || got.LastPostedDate != "2026-10-16" {
+		t.Errorf("unexpected channel: %+v", got)
+	}
+
+	if err := s.DeleteDailyPostChannel(ctx, ch.ID); err != nil {
+		t.Fatalf("DeleteDailyPostChannel failed: %v", err)
+	}
+	if channels, _ := s.ListDailyPostChannels(ctx); len(channels) != 0 {
+		t.Errorf("expected no channels after deleting, got %d", len(channels))
+	}
+}
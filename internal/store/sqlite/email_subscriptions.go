@@ -0,0 +1,63 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"derrclan.com/moravian-soap/internal/store"
+)
+
+// ListEmailSubscriptions retrieves a user's email subscriptions, ordered by list.
+func (s *Store) ListEmailSubscriptions(ctx context.Context, userID int64) ([]*store.EmailSubscription, error) {
+	query := `
+		SELECT user_id, list, status, token, updated_at
+		FROM email_subscriptions
+		WHERE user_id = ?
+		ORDER BY list
+	`
+	rows, err := s.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("querying email subscriptions for user %d: %w", userID, err)
+	}
+	defer rows.Close()
+
+	var subs []*store.EmailSubscription
+	for rows.Next() {
+		var sub store.EmailSubscription
+		if err := rows.Scan(&sub.UserID, &sub.List, &sub.Status, &sub.Token, &sub.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("scanning email subscription: %w", err)
+		}
+		subs = append(subs, &sub)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return subs, nil
+}
+
+// GetEmailSubscriptionByToken retrieves the subscription with the given token. It returns sql.ErrNoRows
+// if there is none.
+func (s *Store) GetEmailSubscriptionByToken(ctx context.Context, token string) (*store.EmailSubscription, error) {
+	var sub store.EmailSubscription
+	query := `SELECT user_id, list, status, token, updated_at FROM email_subscriptions WHERE token = ?`
+	err := s.db.QueryRowContext(ctx, query, token).Scan(&sub.UserID, &sub.List, &sub.Status, &sub.Token, &sub.UpdatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("getting email subscription: %w", err)
+	}
+	return &sub, nil
+}
+
+// SaveEmailSubscription creates or updates a user's subscription to a list, and sets its UpdatedAt.
+func (s *Store) SaveEmailSubscription(ctx context.Context, sub *store.EmailSubscription) error {
+	sub.UpdatedAt = time.Now().UTC()
+	query := `
+		INSERT INTO email_subscriptions (user_id, list, status, token, updated_at) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(user_id, list) DO UPDATE SET
+			status = excluded.status, token = excluded.token, updated_at = excluded.updated_at
+	`
+	if _, err := s.db.ExecContext(ctx, query, sub.UserID, sub.List, sub.Status, sub.Token, sub.UpdatedAt); err != nil {
+		return fmt.Errorf("saving %s subscription for user %d: %w", sub.List, sub.UserID, err)
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"derrclan.com/moravian-soap/internal/store"
+)
+
+func TestStore_EmailSubscriptions(t *testing.T) {
+	db := setupTestDB(t)
+	s := New(db)
+	ctx := context.Background()
+
+	if _, err := db.Exec(`INSERT INTO users (id, email, password_hash) VALUES (1, 'reader@example.com', 'x')`); err != nil {
+		t.Fatalf("failed to insert user: %v", err)
+	}
+
+	sub := &store.EmailSubscription{UserID: 1, List: store.DailyEmailList, Status: store.SubscriptionPending, Token: "first"}
+	if err := s.SaveEmailSubscription(ctx, sub); err != nil {
+		t.Fatalf("SaveEmailSubscription failed: %v", err)
+	}
+	sub.Status, sub.Token = store.SubscriptionActive, "second"
+	if err := s.SaveEmailSubscription(ctx, sub); err != nil {
+		t.Fatalf("SaveEmailSubscription failed: %v", err)
+	}
+
+	subs, err := s.ListEmailSubscriptions(ctx, 1)
+	if err != nil {
+		t.Fatalf("ListEmailSubscriptions failed: %v", err)
+	}
+	if len(subs) != 1 || subs[0].Status != store.SubscriptionActive || subs[0].Token != "second" {
+		t.Fatalf("ListEmailSubscriptions = %+v, want one active subscription", subs)
+	}
+
+	got, err := s.GetEmailSubscriptionByToken(ctx, "second")
+	if err != nil {
+		t.Fatalf("GetEmailSubscriptionByToken failed: %v", err)
+	}
+	if got.UserID != 1 || got.List != store.DailyEmailList {
+		t.Errorf("GetEmailSubscriptionByToken = %+v, want the user's daily subscription", got)
+	}
+	if _, err := s.GetEmailSubscriptionByToken(ctx, "first"); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("GetEmailSubscriptionByToken with a replaced token: error = %v, want sql.ErrNoRows", err)
+	}
+}
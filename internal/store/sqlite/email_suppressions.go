@@ -0,0 +1,62 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+
+	"derrclan.com/moravian-soap/internal/store"
+)
+
+// SuppressEmail stops emails to an address, recording why. Suppressing an address again updates the
+// reason.
+func (s *Store) SuppressEmail(ctx context.Context, address, reason string) error {
+	query := `
+		INSERT INTO email_suppressions (address, reason) VALUES (?, ?)
+		ON CONFLICT(address) DO UPDATE SET reason = excluded.reason
+	`
+	if _, err := s.db.ExecContext(ctx, query, address, reason); err != nil {
+		return fmt.Errorf("suppressing email to %q: %w", address, err)
+	}
+	return nil
+}
+
+// UnsuppressEmail allows emails to an address again.
+func (s *Store) UnsuppressEmail(ctx context.Context, address string) error {
+	if _, err := s.db.ExecContext(ctx, "DELETE FROM email_suppressions WHERE address = ?", address); err != nil {
+		return fmt.Errorf("unsuppressing email to %q: %w", address, err)
+	}
+	return nil
+}
+
+// IsEmailSuppressed reports whether emails to an address are suppressed. Addresses are compared
+// case-insensitively.
+func (s *Store) IsEmailSuppressed(ctx context.Context, address string) (bool, error) {
+	var suppressed bool
+	err := s.db.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM email_suppressions WHERE address = ?)", address).Scan(&suppressed)
+	if err != nil {
+		return false, fmt.Errorf("checking suppression of %q: %w", address, err)
+	}
+	return suppressed, nil
+}
+
+// ListEmailSuppressions returns every suppressed address, most recently suppressed first.
+func (s *Store) ListEmailSuppressions(ctx context.Context) ([]*store.EmailSuppression, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT address, reason, created_at FROM email_suppressions ORDER BY created_at DESC, address")
+	if err != nil {
+		return nil, fmt.Errorf("querying email suppressions: %w", err)
+	}
+	defer rows.Close()
+
+	var suppressions []*store.EmailSuppression
+	for rows.Next() {
+		var sup store.EmailSuppression
+		if err := rows.Scan(&sup.Address, &sup.Reason, &sup.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scanning email suppression: %w", err)
+		}
+		suppressions = append(suppressions, &sup)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterating email suppressions: %w", err)
+	}
+	return suppressions, nil
+}
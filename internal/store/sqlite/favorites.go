@@ -0,0 +1,111 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// ListFavorites returns the dates of a user's favorite journal entries, in the order they arranged them.
+func (s *Store) ListFavorites(ctx context.Context, userID int64) ([]string, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT date FROM journal_favorites WHERE user_id = ? ORDER BY position, date`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("querying favorites for user %d: %w", userID, err)
+	}
+	defer rows.Close()
+
+	var dates []string
+	for rows.Next() {
+		var date string
+		if err := rows.Scan(&date); err != nil {
+			return nil, fmt.Errorf("scanning favorite: %w", err)
+		}
+		dates = append(dates, date)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return dates, nil
+}
+
+// SaveFavorite pins a user's journal entry as a favorite, after their other favorites, or unpins it. It
+// returns sql.ErrNoRows if there is no entry on the date to pin.
+func (s *Store) SaveFavorite(ctx context.Context, userID int64, dateStr string, favorite bool) error {
+	if !favorite {
+		if _, err := s.db.ExecContext(ctx, `DELETE FROM journal_favorites WHERE user_id = ? AND date = ?`, userID, dateStr); err != nil {
+			return fmt.Errorf("unpinning %s: %w", dateStr, err)
+		}
+		return nil
+	}
+	query := `
+		INSERT INTO journal_favorites (user_id, date, position)
+		SELECT user_id, date, (SELECT COALESCE(MAX(position), 0) + 1 FROM journal_favorites WHERE user_id = ?)
+		FROM journal
+		WHERE user_id = ? AND date = ?
+		ON CONFLICT(user_id, date) DO NOTHING
+	`
+	if _, err := s.db.ExecContext(ctx, query, userID, userID, dateStr); err != nil {
+		return fmt.Errorf("pinning %s: %w", dateStr, err)
+	}
+	var exists bool
+	err := s.db.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM journal_favorites WHERE user_id = ? AND date = ?)`, userID, dateStr).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("checking favorite %s: %w", dateStr, err)
+	}
+	if !exists {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// MoveFavorite moves a user's favorite entry by places in their order of favorites: earlier if by is
+// negative and later if it is positive, stopping at either end. Moving an entry that is not a favorite
+// does nothing.
+func (s *Store) MoveFavorite(ctx context.Context, userID int64, dateStr string, by int) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	rows, err := tx.QueryContext(ctx, `SELECT date FROM journal_favorites WHERE user_id = ? ORDER BY position, date`, userID)
+	if err != nil {
+		return fmt.Errorf("querying favorites for user %d: %w", userID, err)
+	}
+	var dates []string
+	from := -1
+	for rows.Next() {
+		var date string
+		if err := rows.Scan(&date); err != nil {
+			rows.Close()
+			return fmt.Errorf("scanning favorite: %w", err)
+		}
+		if date == dateStr {
+			from = len(dates)
+		}
+		dates = append(dates, date)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("rows error: %w", err)
+	}
+	if from < 0 {
+		return nil
+	}
+
+	to := min(max(from+by, 0), len(dates)-1)
+	for i := from; i != to; {
+		next := i + 1
+		if to < from {
+			next = i - 1
+		}
+		dates[i], dates[next] = dates[next], dates[i]
+		i = next
+	}
+	for i, date := range dates {
+		if _, err := tx.ExecContext(ctx, `UPDATE journal_favorites SET position = ? WHERE user_id = ? AND date = ?`, i+1, userID, date); err != nil {
+			return fmt.Errorf("moving favorite %s: %w", date, err)
+		}
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,68 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"slices"
+	"testing"
+
+	"derrclan.com/moravian-soap/internal/store"
+)
+
+func TestStore_Favorites(t *testing.T) {
+	db := setupTestDB(t)
+	s := New(db)
+	ctx := context.Background()
+
+	dates := []string{"2026-01-01", "2026-01-02", "2026-01-03"}
+	for _, date := range dates {
+		if err := s.SaveSOAPData(ctx, 1, &store.SOAPData{Date: date, Sections: map[string]string{"observation": "Noted."}}); err != nil {
+			t.Fatalf("SaveSOAPData failed: %v", err)
+		}
+	}
+	for _, date := range []string{"2026-01-03", "2026-01-01", "2026-01-02", "2026-01-03"} {
+		if err := s.SaveFavorite(ctx, 1, date, true); err != nil {
+			t.Fatalf("SaveFavorite(%s) failed: %v", date, err)
+		}
+	}
+	if err := s.SaveFavorite(ctx, 1, "2026-02-01", true); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("SaveFavorite of a day without an entry = %v, want sql.ErrNoRows", err)
+	}
+	if err := s.SaveFavorite(ctx, 2, "2026-01-01", true); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("SaveFavorite of another user's entry = %v, want sql.ErrNoRows", err)
+	}
+
+	check := func(want ...string) {
+		t.Helper()
+		got, err := s.ListFavorites(ctx, 1)
+		if err != nil {
+			t.Fatalf("ListFavorites failed: %v", err)
+		}
+		if !slices.Equal(got, want) {
+			t.Errorf("favorites = %v, want %v", got, want)
+		}
+	}
+	// Pinning a favorite again leaves it where it was.
+	check("2026-01-03", "2026-01-01", "2026-01-02")
+
+	if err := s.MoveFavorite(ctx, 1, "2026-01-02", -1); err != nil {
+		t.Fatalf("MoveFavorite failed: %v", err)
+	}
+	check("2026-01-03", "2026-01-02", "2026-01-01")
+	if err := s.MoveFavorite(ctx, 1, "2026-01-03", 5); err != nil {
+		t.Fatalf("MoveFavorite failed: %v", err)
+	}
+	check("2026-01-02", "2026-01-01", "2026-01-03")
+	if err := s.MoveFavorite(ctx, 1, "2026-02-01", -1); err != nil {
+		t.Fatalf("MoveFavorite of an entry that is not a favorite failed: %v", err)
+	}
+
+	if err := s.SaveFavorite(ctx, 1, "2026-01-01", false); err != nil {
+		t.Fatalf("SaveFavorite to unpin failed: %v", err)
+	}
+	check("2026-01-02", "2026-01-03")
+	if favorites, err := s.ListFavorites(ctx, 2); err != nil || favorites != nil {
+		t.Errorf("ListFavorites of another user = %v, %v; want none", favorites, err)
+	}
+}
@@ -0,0 +1,80 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+
+	"derrclan.com/moravian-soap/internal/store"
+)
+
+// GetFeatureOverrides retrieves the features turned on or off for a user, by name.
+func (s *Store) GetFeatureOverrides(ctx context.Context, userID int64) (map[string]bool, error) {
+	rows, err := s.queryCached(ctx, "SELECT feature, enabled FROM feature_overrides WHERE user_id = ?", userID)
+	if err != nil {
+		return nil, fmt.Errorf("querying feature overrides: %w", err)
+	}
+	defer rows.Close()
+
+	overrides := make(map[string]bool)
+	for rows.Next() {
+		var feature string
+		var enabled bool
+		if err := rows.Scan(&feature, &enabled); err != nil {
+			return nil, fmt.Errorf("scanning feature override: %w", err)
+		}
+		overrides[feature] = enabled
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return overrides, nil
+}
+
+// ListFeatureOverrides retrieves the features turned on or off for every user, ordered by the user's
+// email and then the feature.
+func (s *Store) ListFeatureOverrides(ctx context.Context) ([]*store.FeatureOverride, error) {
+	query := `
+		SELECT f.user_id, u.email, f.feature, f.enabled
+		FROM feature_overrides f
+		JOIN users u ON u.id = f.user_id
+		ORDER BY u.email, f.feature
+	`
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("querying feature overrides: %w", err)
+	}
+	defer rows.Close()
+
+	var overrides []*store.FeatureOverride
+	for rows.Next() {
+		var o store.FeatureOverride
+		if err := rows.Scan(&o.UserID, &o.Email, &o.Feature, &o.Enabled); err != nil {
+			return nil, fmt.Errorf("scanning feature override: %w", err)
+		}
+		overrides = append(overrides, &o)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return overrides, nil
+}
+
+// SaveFeatureOverride turns a feature on or off for a user.
+func (s *Store) SaveFeatureOverride(ctx context.Context, userID int64, feature string, enabled bool) error {
+	query := `
+		INSERT INTO feature_overrides (user_id, feature, enabled) VALUES (?, ?, ?)
+		ON CONFLICT(user_id, feature) DO UPDATE SET enabled = excluded.enabled
+	`
+	if _, err := s.db.ExecContext(ctx, query, userID, feature, enabled); err != nil {
+		return fmt.Errorf("saving feature override: %w", err)
+	}
+	return nil
+}
+
+// DeleteFeatureOverride returns a feature to the instance's mode for a user.
+func (s *Store) DeleteFeatureOverride(ctx context.Context, userID int64, feature string) error {
+	if _, err := s.db.ExecContext(ctx, "DELETE FROM feature_overrides WHERE user_id = ? AND feature = ?", userID, feature); err != nil {
+		return fmt.Errorf("deleting feature override: %w", err)
+	}
+	return nil
+}
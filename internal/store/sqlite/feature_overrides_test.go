@@ -0,0 +1,64 @@
+package sqlite
+
+import (
+	"context"
+	"maps"
+	"testing"
+	"time"
+
+	"derrclan.com/moravian-soap/internal/store"
+)
+
+func TestStore_FeatureOverrides(t *testing.T) {
+	db := setupTestDB(t)
+	s := New(db)
+	ctx := context.Background()
+
+	if _, err := db.Exec(`INSERT INTO users (id, email, password_hash, is_verified, timezone) VALUES
+		(1, 'beta@example.com', 'x', 1, 'UTC'),
+		(2, 'alpha@example.com', 'x', 1, 'UTC')`); err != nil {
+		t.Fatalf("failed to insert users: %v", err)
+	}
+	if _, err := db.Exec(`INSERT INTO sessions (token, user_id, expires_at) VALUES ('token-1', 1, ?)`, time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("failed to insert session: %v", err)
+	}
+
+	for _, o := range []store.FeatureOverride{
+		{UserID: 1, Feature: "psalter", Enabled: true},
+		{UserID: 1, Feature: "stats", Enabled: true},
+		{UserID: 1, Feature: "stats", Enabled: false},
+		{UserID: 2, Feature: "api", Enabled: false},
+	} {
+		if err := s.SaveFeatureOverride(ctx, o.UserID, o.Feature, o.Enabled); err != nil {
+			t.Fatalf("SaveFeatureOverride failed: %v", err)
+		}
+	}
+
+	user, err := s.GetUserFromSession(ctx, "token-1")
+	if err != nil {
+		t.Fatalf("GetUserFromSession failed: %v", err)
+	}
+	if want := map[string]bool{"psalter": true, "stats": false}; !maps.Equal(user.Features, want) {
+		t.Errorf("user.Features = %v, want %v", user.Features, want)
+	}
+
+	if err := s.DeleteFeatureOverride(ctx, 1, "psalter"); err != nil {
+		t.Fatalf("DeleteFeatureOverride failed: %v", err)
+	}
+	overrides, err := s.ListFeatureOverrides(ctx)
+	if err != nil {
+		t.Fatalf("ListFeatureOverrides failed: %v", err)
+	}
+	want := []store.FeatureOverride{
+		{UserID: 2, Email: "alpha@example.com", Feature: "api", Enabled: false},
+		{UserID: 1, Email: "beta@example.com", Feature: "stats", Enabled: false},
+	}
+	if len(overrides) != len(want) {
+		t.Fatalf("ListFeatureOverrides = %+v, want %+v", overrides, want)
+	}
+	for i := range want {
+		if *overrides[i] != want[i] {
+			t.Errorf("override %d = %+v, want %+v", i, *overrides[i], want[i])
+		}
+	}
+}
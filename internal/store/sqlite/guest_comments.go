@@ -0,0 +1,115 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+
+	"derrclan.com/moravian-soap/internal/store"
+)
+
+// guestCommentColumns are the columns scanned by listGuestComments.
+const guestCommentColumns = `id, user_id, year, name, body, ip, approved, created_at`
+
+// AddGuestComment saves a visitor's comment on a year in review, setting its ID and creation time.
+func (s *Store) AddGuestComment(ctx context.Context, c *store.GuestComment) error {
+	query := `INSERT INTO guest_comments (user_id, year, name, body, ip, approved) VALUES (?, ?, ?, ?, ?, ?) RETURNING id, created_at`
+	if err := s.db.QueryRowContext(ctx, query, c.UserID, c.Year, c.Name, c.Body, c.IP, c.Approved).Scan(&c.ID, &c.CreatedAt); err != nil {
+		return fmt.Errorf("adding guest comment on %d review for user %d: %w", c.Year, c.UserID, err)
+	}
+	return nil
+}
+
+// ListGuestComments returns the approved comments on a user's year in review, oldest first.
+func (s *Store) ListGuestComments(ctx context.Context, userID int64, year int) ([]*store.GuestComment, error) {
+	return s.listGuestComments(ctx, `WHERE user_id = ? AND year = ? AND approved ORDER BY created_at, id`, userID, year)
+}
+
+// ListPendingGuestComments returns the comments waiting for the admin's approval, oldest first.
+func (s *Store) ListPendingGuestComments(ctx context.Context) ([]*store.GuestComment, error) {
+	return s.listGuestComments(ctx, `WHERE NOT approved ORDER BY created_at, id`)
+}
+
+// ListRecentGuestComments returns the newest approved comments on all years in review, at most limit,
+// newest first.
+func (s *Store) ListRecentGuestComments(ctx context.Context, limit int) ([]*store.GuestComment, error) {
+	return s.listGuestComments(ctx, `WHERE approved ORDER BY created_at DESC, id DESC LIMIT ?`, limit)
+}
+
+// listGuestComments returns the guest comments selected by the clauses following FROM.
+func (s *Store) listGuestComments(ctx context.Context, clauses string, args ...any) ([]*store.GuestComment, error) {
+	query := `SELECT ` + guestCommentColumns + ` FROM guest_comments ` + clauses
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("querying guest comments: %w", err)
+	}
+	defer rows.Close()
+
+	var comments []*store.GuestComment
+	for rows.Next() {
+		var c store.GuestComment
+		if err := rows.Scan(&c.ID, &c.UserID, &c.Year, &c.Name, &c.Body, &c.IP, &c.Approved, &c.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scanning guest comment: %w", err)
+		}
+		comments = append(comments, &c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return comments, nil
+}
+
+// ApproveGuestComment shows a comment that was waiting for approval.
+func (s *Store) ApproveGuestComment(ctx context.Context, id int64) error {
+	if _, err := s.db.ExecContext(ctx, `UPDATE guest_comments SET approved = 1 WHERE id = ?`, id); err != nil {
+		return fmt.Errorf("approving guest comment %d: %w", id, err)
+	}
+	return nil
+}
+
+// DeleteGuestComment deletes a comment, approved or not.
+func (s *Store) DeleteGuestComment(ctx context.Context, id int64) error {
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM guest_comments WHERE id = ?`, id); err != nil {
+		return fmt.Errorf("deleting guest comment %d: %w", id, err)
+	}
+	return nil
+}
+
+// BlockCommentValue adds an address or word, by kind, to the blocklist of guest comments. Blocking a
+// value already blocked does nothing.
+func (s *Store) BlockCommentValue(ctx context.Context, kind, value string) error {
+	query := `INSERT INTO comment_blocklist (kind, value) VALUES (?, ?) ON CONFLICT(kind, value) DO NOTHING`
+	if _, err := s.db.ExecContext(ctx, query, kind, value); err != nil {
+		return fmt.Errorf("blocking %s %q: %w", kind, value, err)
+	}
+	return nil
+}
+
+// ListBlockedValues returns the blocklist of guest comments, by kind and then value.
+func (s *Store) ListBlockedValues(ctx context.Context) ([]*store.BlockedValue, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT id, kind, value, created_at FROM comment_blocklist ORDER BY kind, value`)
+	if err != nil {
+		return nil, fmt.Errorf("querying comment blocklist: %w", err)
+	}
+	defer rows.Close()
+
+	var blocked []*store.BlockedValue
+	for rows.Next() {
+		var b store.BlockedValue
+		if err := rows.Scan(&b.ID, &b.Kind, &b.Value, &b.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scanning blocked value: %w", err)
+		}
+		blocked = append(blocked, &b)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return blocked, nil
+}
+
+// DeleteBlockedValue removes an address or word from the blocklist of guest comments.
+func (s *Store) DeleteBlockedValue(ctx context.Context, id int64) error {
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM comment_blocklist WHERE id = ?`, id); err != nil {
+		return fmt.Errorf("deleting blocked value %d: %w", id, err)
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package sqlite
+
+import (
+	"context"
+	"testing"
+
+	"derrclan.com/moravian-soap/internal/store"
+)
+
+func TestStore_GuestComments(t *testing.T) {
+	db := setupTestDB(t)
+	s := New(db)
+	ctx := context.Background()
+	if _, err := db.Exec(`INSERT INTO users (id, email, password_hash) VALUES (1, 'reader@example.com', 'x')`); err != nil {
+		t.Fatalf("inserting user: %v", err)
+	}
+	if err := s.SaveYearReview(ctx, &store.YearReview{UserID: 1, Year: 2025, Token: "share"}); err != nil {
+		t.Fatalf("SaveYearReview failed: %v", err)
+	}
+
+	if review, err := s.GetYearReview(ctx, 1, 2025); err != nil || review.CommentsEnabled {
+		t.Fatalf("GetYearReview = %+v, %v; want comments off by default", review, err)
+	}
+	if err := s.SetYearReviewComments(ctx, 1, 2025, true); err != nil {
+		t.Fatalf("SetYearReviewComments failed: %v", err)
+	}
+	if review, err := s.GetYearReviewByToken(ctx, "share"); err != nil || !review.CommentsEnabled {
+		t.Fatalf("GetYearReviewByToken = %+v, %v; want comments on", review, err)
+	}
+
+	shown := &store.GuestComment{UserID: 1, Year: 2025, Name: "Anna", Body: "What a year.", IP: "192.0.2.1", Approved: true}
+	held := &store.GuestComment{UserID: 1, Year: 2025, Name: "Spam", Body: "Buy now", IP: "192.0.2.2"}
+	for _, c := range []*store.GuestComment{shown, held} {
+		if err := s.AddGuestComment(ctx, c); err != nil {
+			t.Fatalf("AddGuestComment failed: %v", err)
+		}
+		if c.ID == 0 || c.CreatedAt.IsZero() {
+			t.Errorf("AddGuestComment left the comment without an ID or time: %+v", c)
+		}
+	}
+	comments, err := s.ListGuestComments(ctx, 1, 2025)
+	if err != nil || len(comments) != 1 || comments[0].Name != "Anna" || comments[0].IP != "192.0.2.1" {
+		t.Fatalf("ListGuestComments = %+v, %v; want only the approved comment", comments, err)
+	}
+	pending, err := s.ListPendingGuestComments(ctx)
+	if err != nil || len(pending) != 1 || pending[0].ID != held.ID {
+		t.Fatalf("ListPendingGuestComments = %+v, %v; want the held comment", pending, err)
+	}
+	if err := s.ApproveGuestComment(ctx, held.ID); err != nil {
+		t.Fatalf("ApproveGuestComment failed: %v", err)
+	}
+	if comments, _ := s.ListGuestComments(ctx, 1, 2025); len(comments) != 2 {
+		t.Errorf("ListGuestComments = %+v after approval, want both comments", comments)
+	}
+	recent, err := s.ListRecentGuestComments(ctx, 1)
+	if err != nil || len(recent) != 1 || recent[0].ID != held.ID {
+		t.Errorf("ListRecentGuestComments(1) = %+v, %v; want the newest comment", recent, err)
+	}
+	if err := s.DeleteGuestComment(ctx, held.ID); err != nil {
+		t.Fatalf("DeleteGuestComment failed: %v", err)
+	}
+	if comments, _ := s.ListGuestComments(ctx, 1, 2025); len(comments) != 1 {
+		t.Errorf("ListGuestComments = %+v after deletion, want one comment", comments)
+	}
+
+	for _, b := range []struct{ kind, value string }{{store.BlockWord, "casino"}, {store.BlockIP, "192.0.2.2"}, {store.BlockWord, "casino"}} {
+		if err := s.BlockCommentValue(ctx, b.kind, b.value); err != nil {
+			t.Fatalf("BlockCommentValue(%s, %s) failed: %v", b.kind, b.value, err)
+		}
+	}
+	blocked, err := s.ListBlockedValues(ctx)
+	if err != nil || len(blocked) != 2 || blocked[0].Kind != store.BlockIP || blocked[1].Value != "casino" {
+		t.Fatalf("ListBlockedValues = %+v, %v; want the address, then the word once", blocked, err)
+	}
+	if err := s.DeleteBlockedValue(ctx, blocked[0].ID); err != nil {
+		t.Fatalf("DeleteBlockedValue failed: %v", err)
+	}
+	if blocked, _ := s.ListBlockedValues(ctx); len(blocked) != 1 {
+		t.Errorf("ListBlockedValues = %+v after deletion, want one value", blocked)
+	}
+}
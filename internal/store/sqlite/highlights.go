@@ -0,0 +1,81 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"derrclan.com/moravian-soap/internal/store"
+)
+
+// GetHighlights retrieves a user's highlights of the verses in refs, in no particular order. Verses that
+// are not highlighted are left out.
+func (s *Store) GetHighlights(ctx context.Context, userID int64, refs []string) ([]*store.Highlight, error) {
+	if len(refs) == 0 {
+		return nil, nil
+	}
+	args := []any{userID}
+	for _, ref := range refs {
+		args = append(args, ref)
+	}
+	query := `
+		SELECT verse_ref, source, highlighted_on
+		FROM highlights
+		WHERE user_id = ? AND verse_ref IN (?` + strings.Repeat(", ?", len(refs)-1) + `)
+	`
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("querying highlights for user %d: %w", userID, err)
+	}
+	defer rows.Close()
+
+	var highlights []*store.Highlight
+	for rows.Next() {
+		var h store.Highlight
+		if err := rows.Scan(&h.Ref, &h.Source, &h.Date); err != nil {
+			return nil, fmt.Errorf("scanning highlight: %w", err)
+		}
+		highlights = append(highlights, &h)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return highlights, nil
+}
+
+// ImportHighlights adds highlights to a user's, in one transaction, and returns how many were added.
+// Verses the user already highlighted or selected in a journal entry are skipped, so importing the
+// same highlights again adds nothing.
+func (s *Store) ImportHighlights(ctx context.Context, userID int64, highlights []*store.Highlight) (int, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	query := `
+		INSERT INTO highlights (user_id, verse_ref, source, highlighted_on)
+		SELECT ?, ?, ?, ?
+		WHERE NOT EXISTS (
+			SELECT 1 FROM journal j, json_each(j.selected_verses) v
+			WHERE j.user_id = ? AND json_valid(j.selected_verses) AND CAST(v.value AS TEXT) = ?
+		)
+		ON CONFLICT(user_id, verse_ref) DO NOTHING
+	`
+	added := 0
+	for _, h := range highlights {
+		res, err := tx.ExecContext(ctx, query, userID, h.Ref, h.Source, h.Date, userID, h.Ref)
+		if err != nil {
+			return 0, fmt.Errorf("importing highlight of %s: %w", h.Ref, err)
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return 0, fmt.Errorf("importing highlight of %s: %w", h.Ref, err)
+		}
+		added += int(n)
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("committing highlights: %w", err)
+	}
+	return added, nil
+}
@@ -0,0 +1,47 @@
+package sqlite
+
+import (
+	"context"
+	"testing"
+
+	"derrclan.com/moravian-soap/internal/store"
+)
+
+func TestStore_ImportHighlights(t *testing.T) {
+	db := setupTestDB(t)
+	s := New(db)
+	ctx := context.Background()
+
+	if _, err := db.Exec(`INSERT INTO journal (user_id, date, selected_verses) VALUES (1, '2026-03-07', '["43003016"]')`); err != nil {
+		t.Fatalf("inserting entry: %v", err)
+	}
+	highlights := []*store.Highlight{
+		{Ref: "43003016", Source: store.HighlightSourceESVOrg, Date: "2025-12-24"},
+		{Ref: "43003017", Source: store.HighlightSourceESVOrg, Date: "2025-12-24"},
+		{Ref: "19023001", Source: store.HighlightSourceESVOrg},
+	}
+	added, err := s.ImportHighlights(ctx, 1, highlights)
+	if err != nil {
+		t.Fatalf("ImportHighlights failed: %v", err)
+	}
+	if added != 2 {
+		t.Errorf("ImportHighlights added %d, want 2 with the selected verse skipped", added)
+	}
+	if added, err := s.ImportHighlights(ctx, 1, highlights); err != nil || added != 0 {
+		t.Errorf("importing again added %d, %v; want 0", added, err)
+	}
+	if added, err := s.ImportHighlights(ctx, 2, highlights[:1]); err != nil || added != 1 {
+		t.Errorf("another user's import added %d, %v; want 1", added, err)
+	}
+
+	got, err := s.GetHighlights(ctx, 1, []string{"43003016", "43003017"})
+	if err != nil {
+		t.Fatalf("GetHighlights failed: %v", err)
+	}
+	if len(got) != 1 || got[0].Ref != "43003017" || got[0].Source != store.HighlightSourceESVOrg || got[0].Date != "2025-12-24" {
+		t.Errorf("GetHighlights = %+v, want the imported highlight of John 3:17", got)
+	}
+	if got, err := s.GetHighlights(ctx, 1, nil); err != nil || got != nil {
+		t.Errorf("GetHighlights(nil) = %v, %v; want none", got, err)
+	}
+}
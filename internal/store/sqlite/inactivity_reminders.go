@@ -0,0 +1,63 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"derrclan.com/moravian-soap/internal/store"
+)
+
+// ListInactiveUsers retrieves the verified users with inactivity reminders on who, at now, have not saved
+// a journal entry for their chosen number of days and have not been reminded within their cool-down.
+// Users who have never saved an entry are not reminded.
+func (s *Store) ListInactiveUsers(ctx context.Context, now time.Time) ([]*store.InactiveUser, error) {
+	query := `
+		SELECT u.id, u.email, u.timezone, MAX(j.timestamp)
+		FROM users u
+		JOIN user_preferences p ON p.user_id = u.id
+		JOIN journal j ON j.user_id = u.id
+		LEFT JOIN inactivity_reminders r ON r.user_id = u.id
+		WHERE u.is_verified = 1 AND p.inactivity_reminder_days > 0
+			AND (r.sent_at IS NULL OR r.sent_at <= datetime(?, '-' || p.inactivity_cooldown_days || ' days'))
+		GROUP BY u.id
+		HAVING MAX(j.timestamp) <= datetime(?, '-' || p.inactivity_reminder_days || ' days')
+		ORDER BY u.id
+	`
+	at := now.UTC().Format(time.DateTime)
+	rows, err := s.db.QueryContext(ctx, query, at, at)
+	if err != nil {
+		return nil, fmt.Errorf("querying inactive users: %w", err)
+	}
+	defer rows.Close()
+
+	var users []*store.InactiveUser
+	for rows.Next() {
+		var u store.InactiveUser
+		var lastSaved string
+		if err := rows.Scan(&u.UserID, &u.Email, &u.Timezone, &lastSaved); err != nil {
+			return nil, fmt.Errorf("scanning inactive user: %w", err)
+		}
+		if u.LastSavedAt, err = time.Parse(time.DateTime, lastSaved); err != nil {
+			return nil, fmt.Errorf("parsing last save of user %d: %w", u.UserID, err)
+		}
+		users = append(users, &u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return users, nil
+}
+
+// MarkInactivityReminderSent records that a user was sent an inactivity reminder at a time, starting
+// their cool-down.
+func (s *Store) MarkInactivityReminderSent(ctx context.Context, userID int64, at time.Time) error {
+	query := `
+		INSERT INTO inactivity_reminders (user_id, sent_at) VALUES (?, ?)
+		ON CONFLICT(user_id) DO UPDATE SET sent_at = excluded.sent_at
+	`
+	if _, err := s.db.ExecContext(ctx, query, userID, at.UTC().Format(time.DateTime)); err != nil {
+		return fmt.Errorf("marking inactivity reminder sent to user %d: %w", userID, err)
+	}
+	return nil
+}
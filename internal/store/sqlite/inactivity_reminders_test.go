@@ -0,0 +1,75 @@
+package sqlite
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"derrclan.com/moravian-soap/internal/store"
+)
+
+func TestStore_ListInactiveUsers(t *testing.T) {
+	db := setupTestDB(t)
+	s := New(db)
+	ctx := context.Background()
+
+	for _, stmt := range []string{
+		`INSERT INTO users (id, email, password_hash, is_verified, timezone) VALUES
+			(1, 'idle@example.com', 'x', 1, 'America/Chicago'),
+			(2, 'busy@example.com', 'x', 1, 'UTC'),
+			(3, 'off@example.com', 'x', 1, 'UTC'),
+			(4, 'unverified@example.com', 'x', 0, 'UTC'),
+			(5, 'new@example.com', 'x', 1, 'UTC')`,
+		`INSERT INTO user_preferences (user_id, inactivity_reminder_days, inactivity_cooldown_days) VALUES
+			(1, 3, 7), (2, 3, 7), (3, 0, 7), (4, 3, 7), (5, 3, 7)`,
+		`INSERT INTO journal (user_id, date, timestamp) VALUES
+			(1, '2026-03-01', '2026-03-01 12:00:00'),
+			(1, '2026-03-02', '2026-03-02 12:00:00'),
+			(2, '2026-03-01', '2026-03-01 12:00:00'),
+			(2, '2026-03-05', '2026-03-05 12:00:00'),
+			(3, '2026-03-01', '2026-03-01 12:00:00'),
+			(4, '2026-03-01', '2026-03-01 12:00:00')`,
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("failed to insert test data: %v", err)
+		}
+	}
+
+	ids := func(now time.Time) []int64 {
+		t.Helper()
+		users, err := s.ListInactiveUsers(ctx, now)
+		if err != nil {
+			t.Fatalf("ListInactiveUsers failed: %v", err)
+		}
+		var ids []int64
+		for _, u := range users {
+			ids = append(ids, u.UserID)
+		}
+		return ids
+	}
+
+	// Three days after user 1's last save, but not yet for user 2.
+	now := time.Date(2026, time.March, 5, 12, 0, 0, 0, time.UTC)
+	users, err := s.ListInactiveUsers(ctx, now)
+	if err != nil {
+		t.Fatalf("ListInactiveUsers failed: %v", err)
+	}
+	want := store.InactiveUser{UserID: 1, Email: "idle@example.com", Timezone: "America/Chicago", LastSavedAt: time.Date(2026, time.March, 2, 12, 0, 0, 0, time.UTC)}
+	if len(users) != 1 || *users[0] != want {
+		t.Fatalf("ListInactiveUsers = %+v, want only %+v", users, want)
+	}
+	if got := ids(now.Add(-time.Second)); len(got) != 0 {
+		t.Errorf("ListInactiveUsers a second early = %v, want none", got)
+	}
+
+	// After a reminder, user 1 waits out the cool-down.
+	if err := s.MarkInactivityReminderSent(ctx, 1, now); err != nil {
+		t.Fatalf("MarkInactivityReminderSent failed: %v", err)
+	}
+	if got := ids(now.AddDate(0, 0, 6)); len(got) != 1 || got[0] != 2 {
+		t.Errorf("ListInactiveUsers during the cool-down = %v, want [2]", got)
+	}
+	if got := ids(now.AddDate(0, 0, 7)); len(got) != 2 || got[0] != 1 {
+		t.Errorf("ListInactiveUsers after the cool-down = %v, want [1 2]", got)
+	}
+}
@@ -0,0 +1,52 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+)
+
+// GetInstanceSettings retrieves the settings the administrator has saved for the instance, by key.
+func (s *Store) GetInstanceSettings(ctx context.Context) (map[string]string, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT key, value FROM instance_settings")
+	if err != nil {
+		return nil, fmt.Errorf("querying instance settings: %w", err)
+	}
+	defer rows.Close()
+
+	settings := make(map[string]string)
+	for rows.Next() {
+		var key, value string
+		if err := rows.Scan(&key, &value); err != nil {
+			return nil, fmt.Errorf("scanning instance setting: %w", err)
+		}
+		settings[key] = value
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return settings, nil
+}
+
+// SaveInstanceSettings saves instance settings in a single transaction, leaving settings with other keys
+// unchanged.
+func (s *Store) SaveInstanceSettings(ctx context.Context, settings map[string]string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	query := `
+		INSERT INTO instance_settings (key, value) VALUES (?, ?)
+		ON CONFLICT(key) DO UPDATE SET value = excluded.value, updated_at = CURRENT_TIMESTAMP
+	`
+	for key, value := range settings {
+		if _, err := tx.ExecContext(ctx, query, key, value); err != nil {
+			return fmt.Errorf("saving instance setting %s: %w", key, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("committing instance settings: %w", err)
+	}
+	return nil
+}
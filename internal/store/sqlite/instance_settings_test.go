@@ -0,0 +1,33 @@
+package sqlite
+
+import (
+	"context"
+	"maps"
+	"testing"
+)
+
+func TestStore_InstanceSettings(t *testing.T) {
+	db := setupTestDB(t)
+	s := New(db)
+	ctx := context.Background()
+
+	settings, err := s.GetInstanceSettings(ctx)
+	if err != nil || len(settings) != 0 {
+		t.Fatalf("GetInstanceSettings = %v, %v; want no settings", settings, err)
+	}
+
+	if err := s.SaveInstanceSettings(ctx, map[string]string{"theme": "evergreen", "custom_css": ""}); err != nil {
+		t.Fatalf("SaveInstanceSettings failed: %v", err)
+	}
+	if err := s.SaveInstanceSettings(ctx, map[string]string{"theme": "sunrise"}); err != nil {
+		t.Fatalf("SaveInstanceSettings update failed: %v", err)
+	}
+
+	settings, err = s.GetInstanceSettings(ctx)
+	if err != nil {
+		t.Fatalf("GetInstanceSettings failed: %v", err)
+	}
+	if want := map[string]string{"theme": "sunrise", "custom_css": ""}; !maps.Equal(settings, want) {
+		t.Errorf("GetInstanceSettings = %v, want %v", settings, want)
+	}
+}
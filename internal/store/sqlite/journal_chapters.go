@@ -0,0 +1,84 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"derrclan.com/moravian-soap/internal/store"
+)
+
+// SaveJournalChapters replaces the chapters covered by a user's journal entry.
+func (s *Store) SaveJournalChapters(ctx context.Context, userID int64, dateStr string, chapters []store.Chapter) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if err := saveJournalChapters(ctx, tx, userID, dateStr, chapters); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("committing journal chapters: %w", err)
+	}
+	return nil
+}
+
+// saveJournalChapters replaces the chapters covered by a user's journal entry within tx.
+func saveJournalChapters(ctx context.Context, tx *sql.Tx, userID int64, dateStr string, chapters []store.Chapter) error {
+	if _, err := tx.ExecContext(ctx, "DELETE FROM journal_chapters WHERE user_id = ? AND date = ?", userID, dateStr); err != nil {
+		return fmt.Errorf("deleting journal chapters for %s: %w", dateStr, err)
+	}
+	for _, c := range chapters {
+		_, err := tx.ExecContext(ctx,
+			"INSERT OR IGNORE INTO journal_chapters (user_id, date, book, chapter) VALUES (?, ?, ?, ?)",
+			userID, dateStr, c.Book, c.Chapter,
+		)
+		if err != nil {
+			return fmt.Errorf("saving journal chapter %d:%d for %s: %w", c.Book, c.Chapter, dateStr, err)
+		}
+	}
+	return nil
+}
+
+// GetRelatedEntries retrieves, for each chapter covered by a user's journal entry, the other dates on
+// which the user journaled on that chapter, newest first. Chapters without other entries are omitted.
+func (s *Store) GetRelatedEntries(ctx context.Context, userID int64, dateStr string) ([]*store.RelatedEntry, error) {
+	query := `
+		SELECT o.book, o.chapter, o.date
+		FROM journal_chapters c
+		JOIN journal_chapters o
+			ON o.user_id = c.user_id AND o.book = c.book AND o.chapter = c.chapter AND o.date != c.date
+		WHERE c.user_id = ? AND c.date = ?
+			AND EXISTS (
+				SELECT 1 FROM journal_sections s
+				WHERE s.user_id = o.user_id AND s.date = o.date AND s.content != ''
+			)
+		ORDER BY o.book, o.chapter, o.date DESC
+	`
+	rows, err := s.db.QueryContext(ctx, query, userID, dateStr)
+	if err != nil {
+		return nil, fmt.Errorf("querying related entries for %s: %w", dateStr, err)
+	}
+	defer rows.Close()
+
+	var related []*store.RelatedEntry
+	for rows.Next() {
+		var c store.Chapter
+		var d string
+		if err := rows.Scan(&c.Book, &c.Chapter, &d); err != nil {
+			return nil, fmt.Errorf("scanning related entry: %w", err)
+		}
+		if n := len(related); n > 0 && related[n-1].Chapter == c {
+			related[n-1].Dates = append(related[n-1].Dates, d)
+			continue
+		}
+		related = append(related, &store.RelatedEntry{Chapter: c, Dates: []string{d}})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return related, nil
+}
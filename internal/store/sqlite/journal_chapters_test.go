@@ -0,0 +1,69 @@
+package sqlite
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"derrclan.com/moravian-soap/internal/store"
+)
+
+func TestStore_RelatedEntries(t *testing.T) {
+	db := setupTestDB(t)
+	s := New(db)
+	ctx := context.Background()
+
+	romans8 := store.Chapter{Book: 45, Chapter: 8}
+	psalm23 := store.Chapter{Book: 19, Chapter: 23}
+	acts9 := store.Chapter{Book: 44, Chapter: 9}
+
+	entries := []struct {
+		userID   int64
+		date     string
+		text     string
+		chapters []store.Chapter
+	}{
+		{1, "2025-06-01", "today", []store.Chapter{psalm23, romans8}},
+		{1, "2025-03-03", "older", []store.Chapter{romans8, acts9}},
+		{1, "2025-05-01", "newer", []store.Chapter{romans8, psalm23}},
+		{1, "2025-04-01", "", []store.Chapter{romans8}},
+		{2, "2025-02-02", "someone else", []store.Chapter{romans8}},
+	}
+	for _, e := range entries {
+		if err := s.SaveSOAPData(ctx, e.userID, &store.SOAPData{Date: e.date, Sections: map[string]string{"observation": e.text}}); err != nil {
+			t.Fatalf("SaveSOAPData failed: %v", err)
+		}
+		if err := s.SaveJournalChapters(ctx, e.userID, e.date, e.chapters); err != nil {
+			t.Fatalf("SaveJournalChapters failed: %v", err)
+		}
+	}
+
+	t.Run("Related by chapter", func(t *testing.T) {
+		got, err := s.GetRelatedEntries(ctx, 1, "2025-06-01")
+		if err != nil {
+			t.Fatalf("GetRelatedEntries failed: %v", err)
+		}
+		if len(got) != 2 {
+			t.Fatalf("expected 2 related chapters, got %d", len(got))
+		}
+		if got[0].Chapter != psalm23 || !slices.Equal(got[0].Dates, []string{"2025-05-01"}) {
+			t.Errorf("unexpected first related entry: %+v", got[0])
+		}
+		if got[1].Chapter != romans8 || !slices.Equal(got[1].Dates, []string{"2025-05-01", "2025-03-03"}) {
+			t.Errorf("unexpected second related entry: %+v", got[1])
+		}
+	})
+
+	t.Run("Saving replaces chapters", func(t *testing.T) {
+		if err := s.SaveJournalChapters(ctx, 1, "2025-05-01", []store.Chapter{acts9}); err != nil {
+			t.Fatalf("SaveJournalChapters failed: %v", err)
+		}
+		got, err := s.GetRelatedEntries(ctx, 1, "2025-06-01")
+		if err != nil {
+			t.Fatalf("GetRelatedEntries failed: %v", err)
+		}
+		if len(got) != 1 || got[0].Chapter != romans8 || !slices.Equal(got[0].Dates, []string{"2025-03-03"}) {
+			t.Errorf("unexpected related entries after replacing: %+v", got)
+		}
+	})
+}
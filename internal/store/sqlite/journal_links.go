@@ -0,0 +1,86 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"derrclan.com/moravian-soap/internal/store"
+)
+
+// SaveJournalLinks replaces the links from a user's journal entry to other entries.
+func (s *Store) SaveJournalLinks(ctx context.Context, userID int64, sourceDate string, targetDates []string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if err := saveJournalLinks(ctx, tx, userID, sourceDate, targetDates); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("committing journal links: %w", err)
+	}
+	return nil
+}
+
+// saveJournalLinks replaces the links from a user's journal entry within tx.
+func saveJournalLinks(ctx context.Context, tx *sql.Tx, userID int64, sourceDate string, targetDates []string) error {
+	if _, err := tx.ExecContext(ctx, "DELETE FROM journal_links WHERE user_id = ? AND source_date = ?", userID, sourceDate); err != nil {
+		return fmt.Errorf("deleting journal links for %s: %w", sourceDate, err)
+	}
+	for _, target := range targetDates {
+		_, err := tx.ExecContext(ctx,
+			"INSERT OR IGNORE INTO journal_links (user_id, source_date, target_date) VALUES (?, ?, ?)",
+			userID, sourceDate, target,
+		)
+		if err != nil {
+			return fmt.Errorf("saving journal link %s -> %s: %w", sourceDate, target, err)
+		}
+	}
+	return nil
+}
+
+// GetLinkedEntries retrieves the dates of the entries a user's journal entry links to and the entries
+// that link back to it, oldest first.
+func (s *Store) GetLinkedEntries(ctx context.Context, userID int64, dateStr string) (*store.LinkedEntries, error) {
+	links, err := s.queryDates(ctx,
+		"SELECT target_date FROM journal_links WHERE user_id = ? AND source_date = ? ORDER BY target_date",
+		userID, dateStr,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("querying links from %s: %w", dateStr, err)
+	}
+	backlinks, err := s.queryDates(ctx,
+		"SELECT source_date FROM journal_links WHERE user_id = ? AND target_date = ? ORDER BY source_date",
+		userID, dateStr,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("querying backlinks to %s: %w", dateStr, err)
+	}
+	return &store.LinkedEntries{Links: links, Backlinks: backlinks}, nil
+}
+
+// queryDates runs a query selecting a single date column and returns the results.
+func (s *Store) queryDates(ctx context.Context, query string, args ...any) ([]string, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("querying dates: %w", err)
+	}
+	defer rows.Close()
+
+	dates := []string{}
+	for rows.Next() {
+		var d string
+		if err := rows.Scan(&d); err != nil {
+			return nil, fmt.Errorf("scanning date: %w", err)
+		}
+		dates = append(dates, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return dates, nil
+}
@@ -0,0 +1,54 @@
+package sqlite
+
+import (
+	"context"
+	"slices"
+	"testing"
+)
+
+func TestStore_JournalLinks(t *testing.T) {
+	db := setupTestDB(t)
+	s := New(db)
+	ctx := context.Background()
+
+	if err := s.SaveJournalLinks(ctx, 1, "2025-06-01", []string{"2025-03-03", "2025-05-01"}); err != nil {
+		t.Fatalf("SaveJournalLinks failed: %v", err)
+	}
+	if err := s.SaveJournalLinks(ctx, 1, "2025-06-02", []string{"2025-03-03"}); err != nil {
+		t.Fatalf("SaveJournalLinks failed: %v", err)
+	}
+	if err := s.SaveJournalLinks(ctx, 2, "2025-06-03", []string{"2025-03-03"}); err != nil {
+		t.Fatalf("SaveJournalLinks failed: %v", err)
+	}
+
+	t.Run("Links and backlinks", func(t *testing.T) {
+		got, err := s.GetLinkedEntries(ctx, 1, "2025-06-01")
+		if err != nil {
+			t.Fatalf("GetLinkedEntries failed: %v", err)
+		}
+		if !slices.Equal(got.Links, []string{"2025-03-03", "2025-05-01"}) {
+			t.Errorf("unexpected links: %v", got.Links)
+		}
+
+		got, err = s.GetLinkedEntries(ctx, 1, "2025-03-03")
+		if err != nil {
+			t.Fatalf("GetLinkedEntries failed: %v", err)
+		}
+		if !slices.Equal(got.Backlinks, []string{"2025-06-01", "2025-06-02"}) {
+			t.Errorf("unexpected backlinks: %v", got.Backlinks)
+		}
+	})
+
+	t.Run("Saving replaces links", func(t *testing.T) {
+		if err := s.SaveJournalLinks(ctx, 1, "2025-06-01", nil); err != nil {
+			t.Fatalf("SaveJournalLinks failed: %v", err)
+		}
+		got, err := s.GetLinkedEntries(ctx, 1, "2025-06-01")
+		if err != nil {
+			t.Fatalf("GetLinkedEntries failed: %v", err)
+		}
+		if len(got.Links) != 0 {
+			t.Errorf("expected links to be removed, got %v", got.Links)
+		}
+	})
+}
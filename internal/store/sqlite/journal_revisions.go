@@ -0,0 +1,95 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"derrclan.com/moravian-soap/internal/store"
+)
+
+// SaveSOAPRevision saves a user's journal entry if its current revision is still baseRevision, the one
+// the user's edits were based on, and returns the entry's new revision. It returns store.ErrConflict,
+// saving nothing, if the entry has been saved since.
+func (s *Store) SaveSOAPRevision(ctx context.Context, userID int64, soapData *store.SOAPData, baseRevision int64) (int64, error) {
+	return s.saveRevision(ctx, userID, soapData, baseRevision, nil)
+}
+
+// MergeSOAPData saves a user's merge of their conflicting edits, based on baseRevision, with the entry
+// as saved at theirsRevision, and records the merge with both revisions. It returns the entry's new
+// revision, or store.ErrConflict if the entry has been saved again since theirsRevision.
+func (s *Store) MergeSOAPData(ctx context.Context, userID int64, soapData *store.SOAPData, baseRevision, theirsRevision int64) (int64, error) {
+	return s.saveRevision(ctx, userID, soapData, theirsRevision, &baseRevision)
+}
+
+// saveRevision saves a journal entry whose current revision is expected, recording a merge of the
+// edits based on mergedBase if it is not nil.
+func (s *Store) saveRevision(ctx context.Context, userID int64, soapData *store.SOAPData, expected int64, mergedBase *int64) (int64, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var current int64
+	err = tx.QueryRowContext(ctx, `SELECT revision FROM journal WHERE user_id = ? AND date = ?`, userID, soapData.Date).Scan(&current)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return 0, fmt.Errorf("getting revision of %s: %w", soapData.Date, err)
+	}
+	if current != expected {
+		return 0, store.ErrConflict
+	}
+
+	if err := saveSOAPData(ctx, tx, userID, soapData); err != nil {
+		return 0, err
+	}
+	if err := recordEditing(ctx, tx, userID, soapData.Date); err != nil {
+		return 0, err
+	}
+	if mergedBase != nil {
+		query := `
+			INSERT INTO journal_merges (user_id, date, base_revision, theirs_revision, merged_revision)
+			VALUES (?, ?, ?, ?, ?)
+		`
+		if _, err := tx.ExecContext(ctx, query, userID, soapData.Date, *mergedBase, expected, expected+1); err != nil {
+			return 0, fmt.Errorf("recording merge of %s: %w", soapData.Date, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("committing SOAP data: %w", err)
+	}
+	return expected + 1, nil
+}
+
+// GetSectionSaves returns when each section written in a user's entry on dateStr last changed, by
+// section key.
+func (s *Store) GetSectionSaves(ctx context.Context, userID int64, dateStr string) ([]*store.SectionSave, error) {
+	query := `
+		SELECT section, revision, saved_at
+		FROM journal_sections
+		WHERE user_id = ? AND date = ?
+		ORDER BY section
+	`
+	rows, err := s.db.QueryContext(ctx, query, userID, dateStr)
+	if err != nil {
+		return nil, fmt.Errorf("querying section saves of %s: %w", dateStr, err)
+	}
+	defer rows.Close()
+
+	var saves []*store.SectionSave
+	for rows.Next() {
+		var save store.SectionSave
+		var savedAt sql.NullTime
+		if err := rows.Scan(&save.Section, &save.Revision, &savedAt); err != nil {
+			return nil, fmt.Errorf("scanning section save: %w", err)
+		}
+		save.SavedAt = savedAt.Time
+		saves = append(saves, &save)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return saves, nil
+}
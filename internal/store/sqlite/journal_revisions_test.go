@@ -0,0 +1,88 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"derrclan.com/moravian-soap/internal/store"
+)
+
+func TestStore_SaveSOAPRevision(t *testing.T) {
+	db := setupTestDB(t)
+	s := New(db)
+	ctx := context.Background()
+	entry := func(text string) *store.SOAPData {
+		return &store.SOAPData{Date: "2026-03-07", Sections: map[string]string{"observation": text}}
+	}
+
+	rev, err := s.SaveSOAPRevision(ctx, 1, entry("first"), 0)
+	if err != nil || rev != 1 {
+		t.Fatalf("SaveSOAPRevision of a new entry = %d, %v; want 1, nil", rev, err)
+	}
+	if err := s.SaveSOAPData(ctx, 1, entry("from another tab")); err != nil {
+		t.Fatalf("SaveSOAPData failed: %v", err)
+	}
+	if _, err := s.SaveSOAPRevision(ctx, 1, entry("stale"), 1); !errors.Is(err, store.ErrConflict) {
+		t.Fatalf("SaveSOAPRevision of a stale revision error = %v, want ErrConflict", err)
+	}
+
+	got, err := s.GetSOAPData(ctx, 1, "2026-03-07")
+	if err != nil {
+		t.Fatalf("GetSOAPData failed: %v", err)
+	}
+	if got.Revision != 2 || got.Sections["observation"] != "from another tab" {
+		t.Errorf("GetSOAPData = revision %d, %q; want 2, the other tab's text", got.Revision, got.Sections["observation"])
+	}
+
+	// Merging requires the revision merged with to still be current.
+	if _, err := s.MergeSOAPData(ctx, 1, entry("merged"), 1, 1); !errors.Is(err, store.ErrConflict) {
+		t.Fatalf("MergeSOAPData with an old revision error = %v, want ErrConflict", err)
+	}
+	rev, err = s.MergeSOAPData(ctx, 1, entry("merged"), 1, 2)
+	if err != nil || rev != 3 {
+		t.Fatalf("MergeSOAPData = %d, %v; want 3, nil", rev, err)
+	}
+	var base, theirs, merged int64
+	err = db.QueryRow(`SELECT base_revision, theirs_revision, merged_revision FROM journal_merges WHERE user_id = 1 AND date = '2026-03-07'`).Scan(&base, &theirs, &merged)
+	if err != nil {
+		t.Fatalf("failed to query merge: %v", err)
+	}
+	if base != 1 || theirs != 2 || merged != 3 {
+		t.Errorf("merge recorded revisions %d, %d, %d; want 1, 2, 3", base, theirs, merged)
+	}
+}
+
+func TestStore_GetSectionSaves(t *testing.T) {
+	db := setupTestDB(t)
+	s := New(db)
+	ctx := context.Background()
+
+	save := func(sections map[string]string) {
+		t.Helper()
+		if err := s.SaveSOAPData(ctx, 1, &store.SOAPData{Date: "2026-03-07", Sections: sections}); err != nil {
+			t.Fatalf("SaveSOAPData failed: %v", err)
+		}
+	}
+	save(map[string]string{"observation": "Loved", "prayer": "Thanks"})
+	save(map[string]string{"observation": "Loved", "prayer": "Thanks be to God"})
+	save(map[string]string{"observation": "Loved", "prayer": "Thanks be to God", "application": ""})
+
+	saves, err := s.GetSectionSaves(ctx, 1, "2026-03-07")
+	if err != nil {
+		t.Fatalf("GetSectionSaves failed: %v", err)
+	}
+	if len(saves) != 2 {
+		t.Fatalf("GetSectionSaves = %+v, want the two sections written", saves)
+	}
+	// Sections are saved whole with every save, but only change when their content does.
+	if saves[0].Section != "observation" || saves[0].Revision != 1 || saves[0].SavedAt.IsZero() {
+		t.Errorf("observation save = %+v, want revision 1", saves[0])
+	}
+	if saves[1].Section != "prayer" || saves[1].Revision != 2 {
+		t.Errorf("prayer save = %+v, want revision 2", saves[1])
+	}
+	if saves, err := s.GetSectionSaves(ctx, 1, "2026-03-08"); err != nil || len(saves) != 0 {
+		t.Errorf("GetSectionSaves of an unwritten day = %+v, %v; want none", saves, err)
+	}
+}
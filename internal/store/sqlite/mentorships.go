@@ -0,0 +1,122 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+
+	"derrclan.com/moravian-soap/internal/store"
+)
+
+// mentorshipColumns are the columns scanned by scanMentorships, from mentorships m joined with the users
+// u who granted access and mentors t.
+const mentorshipColumns = `m.user_id, u.email, m.mentor_id, t.email, m.from_date, m.to_date, m.created_at`
+
+// SaveMentorship grants a mentor read access to a user's entries between m.From and m.To, replacing the
+// dates of any access already granted.
+func (s *Store) SaveMentorship(ctx context.Context, m *store.Mentorship) error {
+	query := `
+		INSERT INTO mentorships (user_id, mentor_id, from_date, to_date) VALUES (?, ?, ?, ?)
+		ON CONFLICT(user_id, mentor_id) DO UPDATE SET from_date = excluded.from_date, to_date = excluded.to_date
+	`
+	if _, err := s.db.ExecContext(ctx, query, m.UserID, m.MentorID, m.From, m.To); err != nil {
+		return fmt.Errorf("saving mentorship of user %d: %w", m.UserID, err)
+	}
+	return nil
+}
+
+// DeleteMentorship revokes a mentor's access to a user's entries. The mentor's comments are kept.
+func (s *Store) DeleteMentorship(ctx context.Context, userID, mentorID int64) error {
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM mentorships WHERE user_id = ? AND mentor_id = ?`, userID, mentorID); err != nil {
+		return fmt.Errorf("deleting mentorship of user %d: %w", userID, err)
+	}
+	return nil
+}
+
+// GetMentorship returns the access a user granted a mentor, or an error wrapping sql.ErrNoRows if they
+// granted none.
+func (s *Store) GetMentorship(ctx context.Context, userID, mentorID int64) (*store.Mentorship, error) {
+	query := `
+		SELECT ` + mentorshipColumns + `
+		FROM mentorships m JOIN users u ON u.id = m.user_id JOIN users t ON t.id = m.mentor_id
+		WHERE m.user_id = ? AND m.mentor_id = ?
+	`
+	var m store.Mentorship
+	err := s.db.QueryRowContext(ctx, query, userID, mentorID).Scan(&m.UserID, &m.UserEmail, &m.MentorID, &m.MentorEmail, &m.From, &m.To, &m.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("getting mentorship of user %d: %w", userID, err)
+	}
+	return &m, nil
+}
+
+// ListMentorships returns the mentors a user granted access to, by email.
+func (s *Store) ListMentorships(ctx context.Context, userID int64) ([]*store.Mentorship, error) {
+	return s.listMentorships(ctx, `m.user_id = ? ORDER BY t.email`, userID)
+}
+
+// ListMentees returns the access users granted a mentor, by the users' email.
+func (s *Store) ListMentees(ctx context.Context, mentorID int64) ([]*store.Mentorship, error) {
+	return s.listMentorships(ctx, `m.mentor_id = ? ORDER BY u.email`, mentorID)
+}
+
+// listMentorships returns the mentorships matching where, which also orders them.
+func (s *Store) listMentorships(ctx context.Context, where string, id int64) ([]*store.Mentorship, error) {
+	query := `
+		SELECT ` + mentorshipColumns + `
+		FROM mentorships m JOIN users u ON u.id = m.user_id JOIN users t ON t.id = m.mentor_id
+		WHERE ` + where
+	rows, err := s.db.QueryContext(ctx, query, id)
+	if err != nil {
+		return nil, fmt.Errorf("querying mentorships: %w", err)
+	}
+	defer rows.Close()
+
+	var mentorships []*store.Mentorship
+	for rows.Next() {
+		var m store.Mentorship
+		if err := rows.Scan(&m.UserID, &m.UserEmail, &m.MentorID, &m.MentorEmail, &m.From, &m.To, &m.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scanning mentorship: %w", err)
+		}
+		mentorships = append(mentorships, &m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return mentorships, nil
+}
+
+// AddMentorComment saves a mentor's comment on a user's entry, setting its ID and creation time.
+func (s *Store) AddMentorComment(ctx context.Context, c *store.MentorComment) error {
+	query := `INSERT INTO mentor_comments (user_id, date, author_id, body) VALUES (?, ?, ?, ?) RETURNING id, created_at`
+	if err := s.db.QueryRowContext(ctx, query, c.UserID, c.Date, c.AuthorID, c.Body).Scan(&c.ID, &c.CreatedAt); err != nil {
+		return fmt.Errorf("adding comment on %s: %w", c.Date, err)
+	}
+	return nil
+}
+
+// ListMentorComments returns the comments mentors left on a user's entry on dateStr, oldest first.
+func (s *Store) ListMentorComments(ctx context.Context, userID int64, dateStr string) ([]*store.MentorComment, error) {
+	query := `
+		SELECT c.id, c.user_id, c.date, c.author_id, u.email, c.body, c.created_at
+		FROM mentor_comments c JOIN users u ON u.id = c.author_id
+		WHERE c.user_id = ? AND c.date = ?
+		ORDER BY c.created_at, c.id
+	`
+	rows, err := s.db.QueryContext(ctx, query, userID, dateStr)
+	if err != nil {
+		return nil, fmt.Errorf("querying comments on %s: %w", dateStr, err)
+	}
+	defer rows.Close()
+
+	var comments []*store.MentorComment
+	for rows.Next() {
+		var c store.MentorComment
+		if err := rows.Scan(&c.ID, &c.UserID, &c.Date, &c.AuthorID, &c.AuthorEmail, &c.Body, &c.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scanning comment: %w", err)
+		}
+		comments = append(comments, &c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return comments, nil
+}
@@ -0,0 +1,68 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"derrclan.com/moravian-soap/internal/store"
+)
+
+func TestStore_Mentorships(t *testing.T) {
+	db := setupTestDB(t)
+	s := New(db)
+	ctx := context.Background()
+	if _, err := db.Exec(`INSERT INTO users (id, email, password_hash) VALUES
+		(1, 'reader@example.com', 'x'), (2, 'mentor@example.com', 'x'), (3, 'pastor@example.com', 'x')`); err != nil {
+		t.Fatalf("inserting users: %v", err)
+	}
+
+	if err := s.SaveMentorship(ctx, &store.Mentorship{UserID: 1, MentorID: 3, From: "2026-01-01"}); err != nil {
+		t.Fatalf("SaveMentorship failed: %v", err)
+	}
+	if err := s.SaveMentorship(ctx, &store.Mentorship{UserID: 1, MentorID: 2}); err != nil {
+		t.Fatalf("SaveMentorship failed: %v", err)
+	}
+	// Granting access again replaces its dates.
+	if err := s.SaveMentorship(ctx, &store.Mentorship{UserID: 1, MentorID: 2, From: "2026-03-01", To: "2026-03-31"}); err != nil {
+		t.Fatalf("SaveMentorship update failed: %v", err)
+	}
+
+	mentors, err := s.ListMentorships(ctx, 1)
+	if err != nil {
+		t.Fatalf("ListMentorships failed: %v", err)
+	}
+	if len(mentors) != 2 || mentors[0].MentorEmail != "mentor@example.com" || mentors[0].From != "2026-03-01" || mentors[0].To != "2026-03-31" ||
+		mentors[1].MentorEmail != "pastor@example.com" {
+		t.Fatalf("ListMentorships = %+v", mentors)
+	}
+	mentees, err := s.ListMentees(ctx, 2)
+	if err != nil || len(mentees) != 1 || mentees[0].UserEmail != "reader@example.com" {
+		t.Fatalf("ListMentees = %+v, %v; want the reader", mentees, err)
+	}
+	m, err := s.GetMentorship(ctx, 1, 2)
+	if err != nil || !m.Covers("2026-03-07") || m.Covers("2026-04-01") || m.Covers("2026-02-28") {
+		t.Fatalf("GetMentorship = %+v, %v; want March shared", m, err)
+	}
+
+	c := &store.MentorComment{UserID: 1, Date: "2026-03-07", AuthorID: 2, Body: "Well observed."}
+	if err := s.AddMentorComment(ctx, c); err != nil {
+		t.Fatalf("AddMentorComment failed: %v", err)
+	}
+	if c.ID == 0 || c.CreatedAt.IsZero() {
+		t.Errorf("AddMentorComment left the comment without an ID or time: %+v", c)
+	}
+
+	// Revoking access keeps the comments already left.
+	if err := s.DeleteMentorship(ctx, 1, 2); err != nil {
+		t.Fatalf("DeleteMentorship failed: %v", err)
+	}
+	if _, err := s.GetMentorship(ctx, 1, 2); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("GetMentorship after revoking = %v, want sql.ErrNoRows", err)
+	}
+	comments, err := s.ListMentorComments(ctx, 1, "2026-03-07")
+	if err != nil || len(comments) != 1 || comments[0].AuthorEmail != "mentor@example.com" || comments[0].Body != "Well observed." {
+		t.Errorf("ListMentorComments = %+v, %v; want the mentor's comment", comments, err)
+	}
+}
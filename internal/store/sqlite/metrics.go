@@ -0,0 +1,37 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"derrclan.com/moravian-soap/internal/store"
+)
+
+// GetInstanceMetrics retrieves the size of the database and the ESV cache, the ESV API requests made
+// and the emails given up on since a time, and the emails waiting to be sent.
+func (s *Store) GetInstanceMetrics(ctx context.Context, since time.Time) (*store.InstanceMetrics, error) {
+	var m store.InstanceMetrics
+	err := s.db.QueryRowContext(ctx, `
+		SELECT page_count * page_size FROM pragma_page_count(), pragma_page_size()
+	`).Scan(&m.DatabaseBytes)
+	if err != nil {
+		return nil, fmt.Errorf("querying database size: %w", err)
+	}
+
+	query := `
+		SELECT
+			(SELECT COUNT(*) FROM esv_cache),
+			(SELECT COALESCE(SUM(size_bytes), 0) FROM esv_cache),
+			(SELECT COALESCE(SUM(requests), 0) FROM esv_usage WHERE day >= ?),
+			(SELECT COUNT(*) FROM queued_emails WHERE status = 'failed' AND last_attempt_at >= ?),
+			(SELECT COUNT(*) FROM queued_emails WHERE status = 'pending')
+	`
+	at := since.UTC()
+	err = s.db.QueryRowContext(ctx, query, at.Format(time.DateOnly), at.Format(time.DateTime)).Scan(
+		&m.CacheEntries, &m.CacheBytes, &m.ESVRequests, &m.EmailsFailed, &m.EmailsPending)
+	if err != nil {
+		return nil, fmt.Errorf("querying instance metrics: %w", err)
+	}
+	return &m, nil
+}
@@ -0,0 +1,46 @@
+package sqlite
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestStore_GetInstanceMetrics(t *testing.T) {
+	db := setupTestDB(t)
+	s := New(db)
+	ctx := context.Background()
+	since := time.Now().UTC().Add(-7 * 24 * time.Hour)
+
+	setup := []string{
+		`INSERT INTO esv_cache (reference, content, size_bytes) VALUES ('John 1:1', '{}', 100), ('John 1:2', '{}', 50)`,
+		`INSERT INTO esv_usage (user_id, day, requests) VALUES (1, date('now'), 3), (2, date('now', '-1 day'), 2), (1, date('now', '-30 days'), 10)`,
+		`INSERT INTO queued_emails (user_id, recipient, subject, body_html, status, last_attempt_at) VALUES
+			(1, 'a@example.com', 's', 'b', 'failed', datetime('now', '-1 day')),
+			(1, 'a@example.com', 's', 'b', 'failed', datetime('now', '-30 days')),
+			(1, 'a@example.com', 's', 'b', 'pending', NULL),
+			(1, 'a@example.com', 's', 'b', 'sent', datetime('now'))`,
+	}
+	for _, query := range setup {
+		if _, err := db.Exec(query); err != nil {
+			t.Fatalf("setup failed: %v", err)
+		}
+	}
+
+	m, err := s.GetInstanceMetrics(ctx, since)
+	if err != nil {
+		t.Fatalf("GetInstanceMetrics failed: %v", err)
+	}
+	if m.DatabaseBytes <= 0 {
+		t.Errorf("DatabaseBytes = %d, want the size of the database", m.DatabaseBytes)
+	}
+	if m.CacheEntries != 2 || m.CacheBytes != 150 {
+		t.Errorf("cache = %d entries, %d bytes; want 2, 150", m.CacheEntries, m.CacheBytes)
+	}
+	if m.ESVRequests != 5 {
+		t.Errorf("ESVRequests = %d, want 5", m.ESVRequests)
+	}
+	if m.EmailsFailed != 1 || m.EmailsPending != 1 {
+		t.Errorf("emails = %d failed, %d pending; want 1, 1", m.EmailsFailed, m.EmailsPending)
+	}
+}
@@ -0,0 +1,45 @@
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+	"net/url"
+	"runtime"
+
+	_ "github.com/mattn/go-sqlite3" // SQLite driver
+)
+
+// busyTimeout is how long, in milliseconds, a connection waits for a lock held by another connection.
+const busyTimeout = 5000
+
+// Open opens the SQLite database at path, configured for use from many goroutines.
+//
+// The database uses write-ahead logging so that readers never block the writer. SQLite only allows
+// one writer at a time, so transactions begin with BEGIN IMMEDIATE to take the write lock up front
+// instead of failing when a read lock cannot be upgraded, and connections wait up to busyTimeout
+// for the lock instead of returning "database is locked".
+func Open(path string) (*sql.DB, error) {
+	u, err := url.Parse(path)
+	if err != nil {
+		return nil, fmt.Errorf("parsing database path: %w", err)
+	}
+
+	q := u.Query()
+	q.Set("_foreign_keys", "on")
+	q.Set("_journal_mode", "WAL")
+	q.Set("_busy_timeout", fmt.Sprint(busyTimeout))
+	q.Set("_txlock", "immediate")
+	u.RawQuery = q.Encode()
+
+	db, err := sql.Open("sqlite3", u.String())
+	if err != nil {
+		return nil, fmt.Errorf("opening database at %s: %w", path, err)
+	}
+
+	// Writers queue on the SQLite lock, so extra connections only help concurrent readers.
+	conns := max(4, runtime.NumCPU())
+	db.SetMaxOpenConns(conns)
+	db.SetMaxIdleConns(conns)
+
+	return db, nil
+}
@@ -0,0 +1,69 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"derrclan.com/moravian-soap/internal/migrations"
+	"derrclan.com/moravian-soap/internal/store"
+)
+
+func TestOpen_ConcurrentWrites(t *testing.T) {
+	ctx := context.Background()
+	db, err := Open(filepath.Join(t.TempDir(), "app.db"))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+	if err := migrations.Run(ctx, db); err != nil {
+		t.Fatalf("failed to run migrations: %v", err)
+	}
+
+	s := New(db)
+	const users = 4
+	for i := range users {
+		if err := s.CreateUser(ctx, fmt.Sprintf("user%d@example.com", i), "hash", fmt.Sprintf("token%d", i), "UTC"); err != nil {
+			t.Fatalf("CreateUser failed: %v", err)
+		}
+	}
+
+	const writers, saves = 16, 25
+	var wg sync.WaitGroup
+	errs := make(chan error, writers*saves*3)
+	for w := range writers {
+		wg.Go(func() {
+			userID := int64(w%users + 1)
+			for i := range saves {
+				date := fmt.Sprintf("2026-01-%02d", i+1)
+				soap := &store.SOAPData{Date: date, Sections: map[string]string{"observation": fmt.Sprintf("writer %d", w)}}
+				if err := s.SaveSOAPData(ctx, userID, soap); err != nil {
+					errs <- err
+				}
+				if err := s.SaveJournalLinks(ctx, userID, date, []string{"2025-12-31"}); err != nil {
+					errs <- err
+				}
+				if _, err := s.GetSOAPData(ctx, userID, date); err != nil {
+					errs <- err
+				}
+			}
+		})
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Errorf("concurrent access failed: %v", err)
+	}
+
+	for userID := int64(1); userID <= users; userID++ {
+		dates, err := s.GetJournaledDates(ctx, userID, "2026-01-01", "2026-01-31")
+		if err != nil {
+			t.Fatalf("GetJournaledDates failed: %v", err)
+		}
+		if len(dates) != saves {
+			t.Errorf("user %d has %d journaled dates, want %d", userID, len(dates), saves)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"derrclan.com/moravian-soap/internal/store"
+)
+
+// passageEntryColumns are the columns scanned by scanPassageEntry.
+const passageEntryColumns = "id, reference, date, sections, created_at, updated_at"
+
+// scanPassageEntry scans a row of passageEntryColumns.
+func scanPassageEntry(row interface{ Scan(...any) error }) (*store.PassageEntry, error) {
+	var entry store.PassageEntry
+	var date sql.NullString
+	var sections string
+	if err := row.Scan(&entry.ID, &entry.Reference, &date, &sections, &entry.CreatedAt, &entry.UpdatedAt); err != nil {
+		return nil, err
+	}
+	entry.Date = date.String
+	if err := json.Unmarshal([]byte(sections), &entry.Sections); err != nil {
+		return nil, fmt.Errorf("decoding sections of passage entry %d: %w", entry.ID, err)
+	}
+	if entry.Sections == nil {
+		entry.Sections = map[string]string{}
+	}
+	return &entry, nil
+}
+
+// nullDate returns dateStr for storing in a nullable date column, with "" stored as NULL.
+func nullDate(dateStr string) sql.NullString {
+	return sql.NullString{String: dateStr, Valid: dateStr != ""}
+}
+
+// CreatePassageEntry saves a new passage entry for a user and sets its ID and timestamps.
+func (s *Store) CreatePassageEntry(ctx context.Context, userID int64, entry *store.PassageEntry) error {
+	sections, err := json.Marshal(entry.Sections)
+	if err != nil {
+		return fmt.Errorf("encoding passage entry sections: %w", err)
+	}
+	err = s.db.QueryRowContext(ctx,
+		"INSERT INTO passage_entries (user_id, reference, date, sections) VALUES (?, ?, ?, ?) RETURNING id, created_at, updated_at",
+		userID, entry.Reference, nullDate(entry.Date), string(sections),
+	).Scan(&entry.ID, &entry.CreatedAt, &entry.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("inserting passage entry: %w", err)
+	}
+	return nil
+}
+
+// GetPassageEntry retrieves one of a user's passage entries. It returns sql.ErrNoRows if the user has
+// no entry with that ID.
+func (s *Store) GetPassageEntry(ctx context.Context, userID, id int64) (*store.PassageEntry, error) {
+	row := s.db.QueryRowContext(ctx,
+		"SELECT "+passageEntryColumns+" FROM passage_entries WHERE id = ? AND user_id = ?", id, userID)
+	entry, err := scanPassageEntry(row)
+	if err != nil {
+		return nil, fmt.Errorf("getting passage entry %d: %w", id, err)
+	}
+	return entry, nil
+}
+
+// ListPassageEntries retrieves all of a user's passage entries, newest first.
+func (s *Store) ListPassageEntries(ctx context.Context, userID int64) ([]*store.PassageEntry, error) {
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT "+passageEntryColumns+" FROM passage_entries WHERE user_id = ? ORDER BY created_at DESC, id DESC", userID)
+	if err != nil {
+		return nil, fmt.Errorf("querying passage entries for user %d: %w", userID, err)
+	}
+	defer rows.Close()
+
+	var entries []*store.PassageEntry
+	for rows.Next() {
+		entry, err := scanPassageEntry(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scanning passage entry: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return entries, nil
+}
+
+// UpdatePassageEntry saves the date and sections of one of a user's passage entries. It returns
+// sql.ErrNoRows if the user has no entry with that ID.
+func (s *Store) UpdatePassageEntry(ctx context.Context, userID int64, entry *store.PassageEntry) error {
+	sections, err := json.Marshal(entry.Sections)
+	if err != nil {
+		return fmt.Errorf("encoding passage entry sections: %w", err)
+	}
+	res, err := s.db.ExecContext(ctx,
+		"UPDATE passage_entries SET date = ?, sections = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ? AND user_id = ?",
+		nullDate(entry.Date), string(sections), entry.ID, userID,
+	)
+	if err != nil {
+		return fmt.Errorf("updating passage entry %d: %w", entry.ID, err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("getting rows affected: %w", err)
+	}
+	if n == 0 {
+		return fmt.Errorf("updating passage entry %d: %w", entry.ID, sql.ErrNoRows)
+	}
+	return nil
+}
+
+// DeletePassageEntry deletes one of a user's passage entries. It returns sql.ErrNoRows if the user
+// has no entry with that ID.
+func (s *Store) DeletePassageEntry(ctx context.Context, userID, id int64) error {
+	res, err := s.db.ExecContext(ctx, "DELETE FROM passage_entries WHERE id = ? AND user_id = ?", id, userID)
+	if err != nil {
+		return fmt.Errorf("deleting passage entry %d: %w", id, err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("getting rows affected: %w", err)
+	}
+	if n == 0 {
+		return fmt.Errorf("deleting passage entry %d: %w", id, sql.ErrNoRows)
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"derrclan.com/moravian-soap/internal/store"
+)
+
+func TestStore_PassageEntries(t *testing.T) {
+	db := setupTestDB(t)
+	s := New(db)
+	ctx := context.Background()
+
+	sermon := &store.PassageEntry{Reference: "John 3:16-21", Sections: map[string]string{"observation": "God so loved"}}
+	if err := s.CreatePassageEntry(ctx, 1, sermon); err != nil {
+		t.Fatalf("CreatePassageEntry failed: %v", err)
+	}
+	if sermon.ID == 0 || sermon.CreatedAt.IsZero() {
+		t.Errorf("expected the ID and creation time to be set, got %+v", sermon)
+	}
+	dated := &store.PassageEntry{Reference: "Psalm 23", Date: "2026-10-11", Sections: map[string]string{}}
+	if err := s.CreatePassageEntry(ctx, 1, dated); err != nil {
+		t.Fatalf("CreatePassageEntry failed: %v", err)
+	}
+
+	got, err := s.GetPassageEntry(ctx, 1, sermon.ID)
+	if err != nil {
+		t.Fatalf("GetPassageEntry failed: %v", err)
+	}
+	if got.Reference != "John 3:16-21" || got.Date != "" || got.Sections["observation"] != "God so loved" {
+		t.Errorf("GetPassageEntry = %+v, want the undated sermon entry", got)
+	}
+	if _, err := s.GetPassageEntry(ctx, 2, sermon.ID); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("GetPassageEntry for another user: error = %v, want sql.ErrNoRows", err)
+	}
+
+	got.Date = "2026-10-12"
+	got.Sections["prayer"] = "Amen"
+	if err := s.UpdatePassageEntry(ctx, 1, got); err != nil {
+		t.Fatalf("UpdatePassageEntry failed: %v", err)
+	}
+	if err := s.UpdatePassageEntry(ctx, 2, got); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("UpdatePassageEntry for another user: error = %v, want sql.ErrNoRows", err)
+	}
+
+	list, err := s.ListPassageEntries(ctx, 1)
+	if err != nil {
+		t.Fatalf("ListPassageEntries failed: %v", err)
+	}
+	if len(list) != 2 || list[0].ID != dated.ID || list[1].Date != "2026-10-12" || list[1].Sections["prayer"] != "Amen" {
+		t.Errorf("ListPassageEntries = %+v, want both entries newest first with the update saved", list)
+	}
+
+	if err := s.DeletePassageEntry(ctx, 2, sermon.ID); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("DeletePassageEntry for another user: error = %v, want sql.ErrNoRows", err)
+	}
+	if err := s.DeletePassageEntry(ctx, 1, sermon.ID); err != nil {
+		t.Fatalf("DeletePassageEntry failed: %v", err)
+	}
+	if list, _ := s.ListPassageEntries(ctx, 1); len(list) != 1 {
+		t.Errorf("expected one entry left after deleting, got %d", len(list))
+	}
+}
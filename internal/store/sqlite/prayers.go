@@ -0,0 +1,116 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"derrclan.com/moravian-soap/internal/store"
+)
+
+// GetPrayerItems retrieves the prayer items visible on a date: every item created on or before the
+// date that was still open at that point. Open items are listed first, oldest first.
+func (s *Store) GetPrayerItems(ctx context.Context, userID int64, dateStr string) ([]*store.PrayerItem, error) {
+	query := `
+		SELECT id, user_id, text, status, created_date, resolved_date
+		FROM prayer_items
+		WHERE user_id = ? AND created_date <= ? AND (resolved_date IS NULL OR resolved_date >= ?)
+		ORDER BY status != 'open', created_date ASC, id ASC
+	`
+	rows, err := s.db.QueryContext(ctx, query, userID, dateStr, dateStr)
+	if err != nil {
+		return nil, fmt.Errorf("querying prayer items for %s: %w", dateStr, err)
+	}
+	defer rows.Close()
+
+	var items []*store.PrayerItem
+	for rows.Next() {
+		var item store.PrayerItem
+		if err := rows.Scan(&item.ID, &item.UserID, &item.Text, &item.Status, &item.CreatedDate, &item.ResolvedDate); err != nil {
+			return nil, fmt.Errorf("scanning prayer item: %w", err)
+		}
+		items = append(items, &item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return items, nil
+}
+
+// GetPrayerItem retrieves one of a user's prayer items. It returns sql.ErrNoRows if the user has no
+// item with that ID.
+func (s *Store) GetPrayerItem(ctx context.Context, userID, id int64) (*store.PrayerItem, error) {
+	var item store.PrayerItem
+	err := s.db.QueryRowContext(ctx,
+		"SELECT id, user_id, text, status, created_date, resolved_date FROM prayer_items WHERE id = ? AND user_id = ?",
+		id, userID,
+	).Scan(&item.ID, &item.UserID, &item.Text, &item.Status, &item.CreatedDate, &item.ResolvedDate)
+	if err != nil {
+		return nil, fmt.Errorf("getting prayer item %d: %w", id, err)
+	}
+	return &item, nil
+}
+
+// ListPrayerItems retrieves all of a user's prayer items, in the order they were created.
+func (s *Store) ListPrayerItems(ctx context.Context, userID int64) ([]*store.PrayerItem, error) {
+	query := `
+		SELECT id, user_id, text, status, created_date, resolved_date
+		FROM prayer_items
+		WHERE user_id = ?
+		ORDER BY created_date ASC, id ASC
+	`
+	rows, err := s.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("querying prayer items for user %d: %w", userID, err)
+	}
+	defer rows.Close()
+
+	var items []*store.PrayerItem
+	for rows.Next() {
+		var item store.PrayerItem
+		if err := rows.Scan(&item.ID, &item.UserID, &item.Text, &item.Status, &item.CreatedDate, &item.ResolvedDate); err != nil {
+			return nil, fmt.Errorf("scanning prayer item: %w", err)
+		}
+		items = append(items, &item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return items, nil
+}
+
+// CreatePrayerItem inserts a new prayer item and sets its ID.
+func (s *Store) CreatePrayerItem(ctx context.Context, item *store.PrayerItem) error {
+	res, err := s.db.ExecContext(ctx,
+		"INSERT INTO prayer_items (user_id, text, status, created_date, resolved_date) VALUES (?, ?, ?, ?, ?)",
+		item.UserID, item.Text, item.Status, item.CreatedDate, item.ResolvedDate,
+	)
+	if err != nil {
+		return fmt.Errorf("inserting prayer item: %w", err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("getting last insert id: %w", err)
+	}
+	item.ID = id
+	return nil
+}
+
+// UpdatePrayerItem updates the text and status of a user's prayer item.
+func (s *Store) UpdatePrayerItem(ctx context.Context, item *store.PrayerItem) error {
+	res, err := s.db.ExecContext(ctx,
+		"UPDATE prayer_items SET text = ?, status = ?, resolved_date = ? WHERE id = ? AND user_id = ?",
+		item.Text, item.Status, item.ResolvedDate, item.ID, item.UserID,
+	)
+	if err != nil {
+		return fmt.Errorf("updating prayer item %d: %w", item.ID, err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("getting rows affected: %w", err)
+	}
+	if n == 0 {
+		return fmt.Errorf("updating prayer item %d: %w", item.ID, sql.ErrNoRows)
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"derrclan.com/moravian-soap/internal/store"
+)
+
+func TestStore_PrayerItems(t *testing.T) {
+	db := setupTestDB(t)
+	s := New(db)
+	ctx := context.Background()
+
+	item := &store.PrayerItem{UserID: 1, Text: "Healing for Mom", Status: store.PrayerOpen, CreatedDate: "2026-01-05"}
+	if err := s.CreatePrayerItem(ctx, item); err != nil {
+		t.Fatalf("CreatePrayerItem failed: %v", err)
+	}
+	if item.ID == 0 {
+		t.Fatal("expected ID to be set")
+	}
+	other := &store.PrayerItem{UserID: 2, Text: "Someone else's", Status: store.PrayerOpen, CreatedDate: "2026-01-01"}
+	if err := s.CreatePrayerItem(ctx, other); err != nil {
+		t.Fatalf("CreatePrayerItem failed: %v", err)
+	}
+
+	countOn := func(date string) int {
+		t.Helper()
+		items, err := s.GetPrayerItems(ctx, 1, date)
+		if err != nil {
+			t.Fatalf("GetPrayerItems(%s) failed: %v", date, err)
+		}
+		return len(items)
+	}
+
+	t.Run("Carried forward while open", func(t *testing.T) {
+		if n := countOn("2026-01-04"); n != 0 {
+			t.Errorf("expected no items before creation, got %d", n)
+		}
+		for _, date := range []string{"2026-01-05", "2026-01-06", "2026-02-01"} {
+			if n := countOn(date); n != 1 {
+				t.Errorf("expected 1 item on %s, got %d", date, n)
+			}
+		}
+	})
+
+	t.Run("Resolved items stop carrying forward", func(t *testing.T) {
+		resolved := "2026-01-10"
+		item.Status = store.PrayerAnswered
+		item.ResolvedDate = &resolved
+		if err := s.UpdatePrayerItem(ctx, item); err != nil {
+			t.Fatalf("UpdatePrayerItem failed: %v", err)
+		}
+
+		if n := countOn("2026-01-08"); n != 1 {
+			t.Errorf("expected item before resolution, got %d", n)
+		}
+		items, err := s.GetPrayerItems(ctx, 1, resolved)
+		if err != nil {
+			t.Fatalf("GetPrayerItems failed: %v", err)
+		}
+		if len(items) != 1 || items[0].Status != store.PrayerAnswered {
+			t.Errorf("expected answered item on resolution date, got %+v", items)
+		}
+		if n := countOn("2026-01-11"); n != 0 {
+			t.Errorf("expected no items after resolution, got %d", n)
+		}
+	})
+
+	t.Run("Reopened items carry forward again", func(t *testing.T) {
+		item.Status = store.PrayerOpen
+		item.ResolvedDate = nil
+		if err := s.UpdatePrayerItem(ctx, item); err != nil {
+			t.Fatalf("UpdatePrayerItem failed: %v", err)
+		}
+		if n := countOn("2026-01-11"); n != 1 {
+			t.Errorf("expected reopened item, got %d", n)
+		}
+	})
+
+	t.Run("Cannot update another user's item", func(t *testing.T) {
+		other.UserID = 1
+		err := s.UpdatePrayerItem(ctx, other)
+		if !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("expected sql.ErrNoRows, got %v", err)
+		}
+	})
+
+	t.Run("Lists all of a user's items", func(t *testing.T) {
+		resolved := "2026-01-02"
+		old := &store.PrayerItem{UserID: 1, Text: "Older", Status: store.PrayerDone, CreatedDate: "2026-01-01", ResolvedDate: &resolved}
+		if err := s.CreatePrayerItem(ctx, old); err != nil {
+			t.Fatalf("CreatePrayerItem failed: %v", err)
+		}
+		items, err := s.ListPrayerItems(ctx, 1)
+		if err != nil {
+			t.Fatalf("ListPrayerItems failed: %v", err)
+		}
+		if len(items) != 2 || items[0].ID != old.ID || items[1].ID != item.ID {
+			t.Errorf("expected the resolved and open items in creation order, got %v", items)
+		}
+	})
+}
+
+func TestStore_GetPrayerItem(t *testing.T) {
+	db := setupTestDB(t)
+	s := New(db)
+	ctx := context.Background()
+
+	item := &store.PrayerItem{UserID: 1, Text: "Wisdom", Status: store.PrayerOpen, CreatedDate: "2026-01-05"}
+	if err := s.CreatePrayerItem(ctx, item); err != nil {
+		t.Fatalf("CreatePrayerItem failed: %v", err)
+	}
+	got, err := s.GetPrayerItem(ctx, 1, item.ID)
+	if err != nil {
+		t.Fatalf("GetPrayerItem failed: %v", err)
+	}
+	if got.Text != "Wisdom" || got.CreatedDate != "2026-01-05" {
+		t.Errorf("unexpected item: %+v", got)
+	}
+	if _, err := s.GetPrayerItem(ctx, 2, item.ID); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("expected sql.ErrNoRows for another user's item, got %v", err)
+	}
+}
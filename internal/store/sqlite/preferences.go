@@ -0,0 +1,58 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"derrclan.com/moravian-soap/internal/store"
+)
+
+// GetPreferences retrieves a user's preferences, or the defaults if the user has not saved any.
+func (s *Store) GetPreferences(ctx context.Context, userID int64) (*store.Preferences, error) {
+	query := `
+		SELECT esv_headings, esv_verse_numbers, esv_short_copyright, esv_indent_poetry, esv_red_letter, journal_schema, lock_after_minutes, devotions_audience,
+			inactivity_reminder_days, inactivity_cooldown_days, translation, daily_email_time, week_start, iso_weeks
+		FROM user_preferences
+		WHERE user_id = ?
+	`
+	var p store.Preferences
+	err := s.db.QueryRowContext(ctx, query, userID).Scan(&p.ESVHeadings, &p.ESVVerseNumbers, &p.ESVShortCopyright, &p.ESVIndentPoetry, &p.ESVRedLetter, &p.JournalSchema, &p.LockAfterMinutes, &p.DevotionsAudience, &p.InactivityReminderDays, &p.InactivityCooldownDays, &p.Translation, &p.DailyEmailTime, &p.WeekStart, &p.ISOWeeks)
+	if errors.Is(err, sql.ErrNoRows) {
+		return store.DefaultPreferences(), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("querying preferences for user %d: %w", userID, err)
+	}
+	return &p, nil
+}
+
+// SavePreferences inserts or replaces a user's preferences.
+func (s *Store) SavePreferences(ctx context.Context, userID int64, prefs *store.Preferences) error {
+	query := `
+		INSERT INTO user_preferences (user_id, esv_headings, esv_verse_numbers, esv_short_copyright, esv_indent_poetry, esv_red_letter, journal_schema, lock_after_minutes, devotions_audience,
+			inactivity_reminder_days, inactivity_cooldown_days, translation, daily_email_time, week_start, iso_weeks)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(user_id) DO UPDATE SET
+			esv_headings = excluded.esv_headings,
+			esv_verse_numbers = excluded.esv_verse_numbers,
+			esv_short_copyright = excluded.esv_short_copyright,
+			esv_indent_poetry = excluded.esv_indent_poetry,
+			esv_red_letter = excluded.esv_red_letter,
+			journal_schema = excluded.journal_schema,
+			lock_after_minutes = excluded.lock_after_minutes,
+			devotions_audience = excluded.devotions_audience,
+			inactivity_reminder_days = excluded.inactivity_reminder_days,
+			inactivity_cooldown_days = excluded.inactivity_cooldown_days,
+			translation = excluded.translation,
+			daily_email_time = excluded.daily_email_time,
+			week_start = excluded.week_start,
+			iso_weeks = excluded.iso_weeks
+	`
+	_, err := s.db.ExecContext(ctx, query, userID, prefs.ESVHeadings, prefs.ESVVerseNumbers, prefs.ESVShortCopyright, prefs.ESVIndentPoetry, prefs.ESVRedLetter, prefs.JournalSchema, prefs.LockAfterMinutes, prefs.DevotionsAudience, prefs.InactivityReminderDays, prefs.InactivityCooldownDays, prefs.Translation, prefs.DailyEmailTime, prefs.WeekStart, prefs.ISOWeeks)
+	if err != nil {
+		return fmt.Errorf("saving preferences for user %d: %w", userID, err)
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package sqlite
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"derrclan.com/moravian-soap/internal/store"
+)
+
+func TestStore_Preferences(t *testing.T) {
+	db := setupTestDB(t)
+	s := New(db)
+	ctx := context.Background()
+
+	got, err := s.GetPreferences(ctx, 1)
+	if err != nil {
+		t.Fatalf("GetPreferences failed: %v", err)
+	}
+	if *got != *store.DefaultPreferences() {
+		t.Errorf("GetPreferences() = %+v, want defaults", got)
+	}
+
+	for _, want := range []store.Preferences{
+		{ESVHeadings: false, ESVVerseNumbers: false, ESVShortCopyright: true, ESVIndentPoetry: false, Translation: "ESV"},
+		{ESVHeadings: true, ESVVerseNumbers: false, ESVShortCopyright: false, ESVIndentPoetry: true, ESVRedLetter: true, JournalSchema: `[{"key":"notes","name":"Notes"}]`, LockAfterMinutes: 15, DevotionsAudience: "kids", Translation: "WEB", WeekStart: time.Monday, ISOWeeks: true},
+	} {
+		if err := s.SavePreferences(ctx, 1, &want); err != nil {
+			t.Fatalf("SavePreferences failed: %v", err)
+		}
+		got, err := s.GetPreferences(ctx, 1)
+		if err != nil {
+			t.Fatalf("GetPreferences failed: %v", err)
+		}
+		if *got != want {
+			t.Errorf("GetPreferences() = %+v, want %+v", got, want)
+		}
+	}
+
+	other, err := s.GetPreferences(ctx, 2)
+	if err != nil {
+		t.Fatalf("GetPreferences failed: %v", err)
+	}
+	if *other != *store.DefaultPreferences() {
+		t.Errorf("GetPreferences() for another user = %+v, want defaults", other)
+	}
+}
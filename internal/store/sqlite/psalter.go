@@ -0,0 +1,73 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+
+	"derrclan.com/moravian-soap/internal/store"
+)
+
+// StartPsalter enrolls a user in psalter mode. Enrolling a user already in psalter mode does nothing.
+func (s *Store) StartPsalter(ctx context.Context, userID int64) error {
+	_, err := s.db.ExecContext(ctx, "INSERT OR IGNORE INTO psalter_readers (user_id) VALUES (?)", userID)
+	if err != nil {
+		return fmt.Errorf("starting psalter for user %d: %w", userID, err)
+	}
+	return nil
+}
+
+// StopPsalter takes a user out of psalter mode. Their readings are kept so they can pick up where they left off.
+func (s *Store) StopPsalter(ctx context.Context, userID int64) error {
+	_, err := s.db.ExecContext(ctx, "DELETE FROM psalter_readers WHERE user_id = ?", userID)
+	if err != nil {
+		return fmt.Errorf("stopping psalter for user %d: %w", userID, err)
+	}
+	return nil
+}
+
+// IsPsalterReader reports whether a user is in psalter mode.
+func (s *Store) IsPsalterReader(ctx context.Context, userID int64) (bool, error) {
+	var n int
+	err := s.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM psalter_readers WHERE user_id = ?", userID).Scan(&n)
+	if err != nil {
+		return false, fmt.Errorf("checking psalter mode for user %d: %w", userID, err)
+	}
+	return n > 0, nil
+}
+
+// GetPsalterReadings retrieves all of a user's psalter readings, oldest first.
+func (s *Store) GetPsalterReadings(ctx context.Context, userID int64) ([]*store.PsalterReading, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT date, portion FROM psalter_readings WHERE user_id = ? ORDER BY date", userID)
+	if err != nil {
+		return nil, fmt.Errorf("querying psalter readings for user %d: %w", userID, err)
+	}
+	defer rows.Close()
+
+	var readings []*store.PsalterReading
+	for rows.Next() {
+		var r store.PsalterReading
+		if err := rows.Scan(&r.Date, &r.Portion); err != nil {
+			return nil, fmt.Errorf("scanning psalter reading: %w", err)
+		}
+		readings = append(readings, &r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return readings, nil
+}
+
+// SavePsalterReading records the psalter portion a user read on a date, replacing any other portion
+// recorded for that date.
+func (s *Store) SavePsalterReading(ctx context.Context, userID int64, reading *store.PsalterReading) error {
+	query := `
+		INSERT INTO psalter_readings (user_id, date, portion)
+		VALUES (?, ?, ?)
+		ON CONFLICT(user_id, date) DO UPDATE SET portion = excluded.portion
+	`
+	_, err := s.db.ExecContext(ctx, query, userID, reading.Date, reading.Portion)
+	if err != nil {
+		return fmt.Errorf("saving psalter reading for %s: %w", reading.Date, err)
+	}
+	return nil
+}
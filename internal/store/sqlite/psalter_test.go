@@ -0,0 +1,58 @@
+package sqlite
+
+import (
+	"context"
+	"testing"
+
+	"derrclan.com/moravian-soap/internal/store"
+)
+
+func TestStore_Psalter(t *testing.T) {
+	db := setupTestDB(t)
+	s := New(db)
+	ctx := context.Background()
+
+	t.Run("Mode", func(t *testing.T) {
+		if ok, err := s.IsPsalterReader(ctx, 1); err != nil || ok {
+			t.Fatalf("IsPsalterReader() = %v, %v; want false", ok, err)
+		}
+		if err := s.StartPsalter(ctx, 1); err != nil {
+			t.Fatalf("StartPsalter failed: %v", err)
+		}
+		if err := s.StartPsalter(ctx, 1); err != nil {
+			t.Fatalf("StartPsalter again failed: %v", err)
+		}
+		if ok, err := s.IsPsalterReader(ctx, 1); err != nil || !ok {
+			t.Fatalf("IsPsalterReader() = %v, %v; want true", ok, err)
+		}
+		if err := s.StopPsalter(ctx, 1); err != nil {
+			t.Fatalf("StopPsalter failed: %v", err)
+		}
+		if ok, err := s.IsPsalterReader(ctx, 1); err != nil || ok {
+			t.Fatalf("IsPsalterReader() = %v, %v; want false after stopping", ok, err)
+		}
+	})
+
+	t.Run("Readings", func(t *testing.T) {
+		for _, r := range []*store.PsalterReading{
+			{Date: "2026-01-02", Portion: 1},
+			{Date: "2026-01-01", Portion: 0},
+			{Date: "2026-01-02", Portion: 2},
+		} {
+			if err := s.SavePsalterReading(ctx, 1, r); err != nil {
+				t.Fatalf("SavePsalterReading failed: %v", err)
+			}
+		}
+
+		readings, err := s.GetPsalterReadings(ctx, 1)
+		if err != nil {
+			t.Fatalf("GetPsalterReadings failed: %v", err)
+		}
+		if len(readings) != 2 {
+			t.Fatalf("expected 2 readings, got %d", len(readings))
+		}
+		if readings[0].Date != "2026-01-01" || readings[1].Portion != 2 {
+			t.Errorf("unexpected readings: %+v, %+v", readings[0], readings[1])
+		}
+	})
+}
@@ -0,0 +1,83 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"derrclan.com/moravian-soap/internal/store"
+)
+
+// StartQuietTime records the start of a quiet time session and sets its ID.
+func (s *Store) StartQuietTime(ctx context.Context, q *store.QuietTime) error {
+	query := `INSERT INTO quiet_times (user_id, date, started_at, duration_seconds) VALUES (?, ?, ?, ?)`
+	res, err := s.db.ExecContext(ctx, query, q.UserID, q.Date, q.StartedAt.UTC(), int(q.Duration.Seconds()))
+	if err != nil {
+		return fmt.Errorf("starting quiet time for %s: %w", q.Date, err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("getting last insert id: %w", err)
+	}
+	q.ID = id
+	return nil
+}
+
+// GetQuietTime retrieves the user's latest quiet time session for a date, or sql.ErrNoRows if they have
+// not started one.
+func (s *Store) GetQuietTime(ctx context.Context, userID int64, dateStr string) (*store.QuietTime, error) {
+	query := `
+		SELECT id, user_id, date, started_at, duration_seconds, ended_at, completed
+		FROM quiet_times
+		WHERE user_id = ? AND date = ?
+		ORDER BY started_at DESC, id DESC
+		LIMIT 1
+	`
+	q := &store.QuietTime{}
+	var seconds int64
+	var endedAt sql.NullTime
+	err := s.db.QueryRowContext(ctx, query, userID, dateStr).Scan(&q.ID, &q.UserID, &q.Date, &q.StartedAt, &seconds, &endedAt, &q.Completed)
+	if err != nil {
+		return nil, fmt.Errorf("getting quiet time for %s: %w", dateStr, err)
+	}
+	q.Duration = time.Duration(seconds) * time.Second
+	if endedAt.Valid {
+		q.EndedAt = &endedAt.Time
+	}
+	return q, nil
+}
+
+// EndQuietTime ends a running quiet time session of the user's, marking whether it was completed. It
+// returns sql.ErrNoRows if there is no such session or it has already ended.
+func (s *Store) EndQuietTime(ctx context.Context, userID, id int64, endedAt time.Time, completed bool) error {
+	query := `UPDATE quiet_times SET ended_at = ?, completed = ? WHERE id = ? AND user_id = ? AND ended_at IS NULL`
+	res, err := s.db.ExecContext(ctx, query, endedAt.UTC(), completed, id, userID)
+	if err != nil {
+		return fmt.Errorf("ending quiet time %d: %w", id, err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("getting rows affected: %w", err)
+	}
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// GetQuietTimeStats summarizes the user's completed quiet time sessions for dates on or after since.
+func (s *Store) GetQuietTimeStats(ctx context.Context, userID int64, since string) (*store.QuietTimeStats, error) {
+	query := `
+		SELECT COUNT(*), COALESCE(SUM(duration_seconds), 0)
+		FROM quiet_times
+		WHERE user_id = ? AND date >= ? AND completed
+	`
+	var stats store.QuietTimeStats
+	var total int64
+	if err := s.db.QueryRowContext(ctx, query, userID, since).Scan(&stats.Sessions, &total); err != nil {
+		return nil, fmt.Errorf("getting quiet time stats for user %d: %w", userID, err)
+	}
+	stats.Total = time.Duration(total) * time.Second
+	return &stats, nil
+}
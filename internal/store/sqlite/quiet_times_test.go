@@ -0,0 +1,89 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"derrclan.com/moravian-soap/internal/store"
+)
+
+func TestStore_QuietTimes(t *testing.T) {
+	db := setupTestDB(t)
+	s := New(db)
+	ctx := context.Background()
+	start := time.Date(2026, 10, 16, 6, 30, 0, 0, time.UTC)
+
+	if _, err := s.GetQuietTime(ctx, 1, "2026-10-16"); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("GetQuietTime before starting error = %v, want sql.ErrNoRows", err)
+	}
+
+	// A session stopped early, then one run to completion, on the same day.
+	stopped := &store.QuietTime{UserID: 1, Date: "2026-10-16", StartedAt: start, Duration: 15 * time.Minute}
+	if err := s.StartQuietTime(ctx, stopped); err != nil {
+		t.Fatalf("StartQuietTime failed: %v", err)
+	}
+	if err := s.EndQuietTime(ctx, 1, stopped.ID, start.Add(5*time.Minute), false); err != nil {
+		t.Fatalf("EndQuietTime failed: %v", err)
+	}
+	completed := &store.QuietTime{UserID: 1, Date: "2026-10-16", StartedAt: start.Add(10 * time.Minute), Duration: 15 * time.Minute}
+	if err := s.StartQuietTime(ctx, completed); err != nil {
+		t.Fatalf("StartQuietTime failed: %v", err)
+	}
+
+	got, err := s.GetQuietTime(ctx, 1, "2026-10-16")
+	if err != nil {
+		t.Fatalf("GetQuietTime failed: %v", err)
+	}
+	if got.ID != completed.ID || !got.StartedAt.Equal(completed.StartedAt) || got.Duration != 15*time.Minute || got.EndedAt != nil {
+		t.Errorf("GetQuietTime = %+v, want the running session %+v", got, completed)
+	}
+	if !got.Running(start.Add(20*time.Minute)) || got.Running(start.Add(25*time.Minute)) {
+		t.Errorf("session should run until %s", got.EndsAt())
+	}
+
+	// Only the owner can end a session, and only once.
+	if err := s.EndQuietTime(ctx, 2, completed.ID, start.Add(25*time.Minute), true); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("EndQuietTime by another user error = %v, want sql.ErrNoRows", err)
+	}
+	if err := s.EndQuietTime(ctx, 1, completed.ID, start.Add(25*time.Minute), true); err != nil {
+		t.Fatalf("EndQuietTime failed: %v", err)
+	}
+	if err := s.EndQuietTime(ctx, 1, completed.ID, start.Add(26*time.Minute), true); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("EndQuietTime of an ended session error = %v, want sql.ErrNoRows", err)
+	}
+	got, err = s.GetQuietTime(ctx, 1, "2026-10-16")
+	if err != nil {
+		t.Fatalf("GetQuietTime failed: %v", err)
+	}
+	if !got.Completed || got.EndedAt == nil || !got.EndedAt.Equal(start.Add(25*time.Minute)) {
+		t.Errorf("GetQuietTime after completing = %+v, want completed at %s", got, start.Add(25*time.Minute))
+	}
+
+	// An earlier completed session counts toward all-time stats only.
+	earlier := &store.QuietTime{UserID: 1, Date: "2026-09-01", StartedAt: start.AddDate(0, -1, 0), Duration: 10 * time.Minute}
+	if err := s.StartQuietTime(ctx, earlier); err != nil {
+		t.Fatalf("StartQuietTime failed: %v", err)
+	}
+	if err := s.EndQuietTime(ctx, 1, earlier.ID, earlier.EndsAt(), true); err != nil {
+		t.Fatalf("EndQuietTime failed: %v", err)
+	}
+
+	for _, tt := range []struct {
+		since string
+		want  store.QuietTimeStats
+	}{
+		{"", store.QuietTimeStats{Sessions: 2, Total: 25 * time.Minute}},
+		{"2026-10-01", store.QuietTimeStats{Sessions: 1, Total: 15 * time.Minute}},
+	} {
+		stats, err := s.GetQuietTimeStats(ctx, 1, tt.since)
+		if err != nil {
+			t.Fatalf("GetQuietTimeStats failed: %v", err)
+		}
+		if *stats != tt.want {
+			t.Errorf("GetQuietTimeStats(since %q) = %+v, want %+v", tt.since, *stats, tt.want)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"derrclan.com/moravian-soap/internal/store"
+)
+
+// RestoreJournal saves journal entries, passage entries and prayer items restored from a backup in a
+// single transaction, so a failure leaves the account as it was. Passage entries that match one
+// already in the account by reference and creation time, and prayer items that match one by text and
+// creation date, are updated rather than added again, and their IDs are set.
+func (s *Store) RestoreJournal(ctx context.Context, userID int64, entries []*store.RestoredEntry, passages []*store.PassageEntry, items []*store.PrayerItem) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	for _, e := range entries {
+		if err := saveSOAPData(ctx, tx, userID, e.Entry); err != nil {
+			return err
+		}
+		if err := saveJournalLinks(ctx, tx, userID, e.Entry.Date, e.Links); err != nil {
+			return err
+		}
+		if err := saveJournalChapters(ctx, tx, userID, e.Entry.Date, e.Chapters); err != nil {
+			return err
+		}
+		if e.Entry.Location != "" || e.Entry.Weather != "" {
+			if err := saveEntryStamp(ctx, tx, userID, e.Entry.Date, e.Entry.Location, e.Entry.Weather); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, entry := range passages {
+		if err := restorePassageEntry(ctx, tx, userID, entry); err != nil {
+			return err
+		}
+	}
+
+	for _, item := range items {
+		var id int64
+		err := tx.QueryRowContext(ctx,
+			"SELECT id FROM prayer_items WHERE user_id = ? AND text = ? AND created_date = ? ORDER BY id LIMIT 1",
+			userID, item.Text, item.CreatedDate,
+		).Scan(&id)
+		switch {
+		case err == nil:
+			if _, err := tx.ExecContext(ctx,
+				"UPDATE prayer_items SET status = ?, resolved_date = ? WHERE id = ?",
+				item.Status, item.ResolvedDate, id,
+			); err != nil {
+				return fmt.Errorf("updating prayer item %d: %w", id, err)
+			}
+		case errors.Is(err, sql.ErrNoRows):
+			res, err := tx.ExecContext(ctx,
+				"INSERT INTO prayer_items (user_id, text, status, created_date, resolved_date) VALUES (?, ?, ?, ?, ?)",
+				userID, item.Text, item.Status, item.CreatedDate, item.ResolvedDate,
+			)
+			if err != nil {
+				return fmt.Errorf("inserting prayer item: %w", err)
+			}
+			if id, err = res.LastInsertId(); err != nil {
+				return fmt.Errorf("getting last insert id: %w", err)
+			}
+		default:
+			return fmt.Errorf("finding prayer item: %w", err)
+		}
+		item.ID = id
+		item.UserID = userID
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("committing restored journal: %w", err)
+	}
+	return nil
+}
+
+// restorePassageEntry saves a passage entry restored from a backup within tx.
+func restorePassageEntry(ctx context.Context, tx *sql.Tx, userID int64, entry *store.PassageEntry) error {
+	sections, err := json.Marshal(entry.Sections)
+	if err != nil {
+		return fmt.Errorf("encoding passage entry sections: %w", err)
+	}
+	createdAt := entry.CreatedAt.UTC().Format(time.DateTime)
+	updatedAt := entry.UpdatedAt.UTC().Format(time.DateTime)
+
+	var id int64
+	err = tx.QueryRowContext(ctx,
+		"SELECT id FROM passage_entries WHERE user_id = ? AND reference = ? AND created_at = ? ORDER BY id LIMIT 1",
+		userID, entry.Reference, createdAt,
+	).Scan(&id)
+	switch {
+	case err == nil:
+		if _, err := tx.ExecContext(ctx,
+			"UPDATE passage_entries SET date = ?, sections = ?, updated_at = ? WHERE id = ?",
+			nullDate(entry.Date), string(sections), updatedAt, id,
+		); err != nil {
+			return fmt.Errorf("updating passage entry %d: %w", id, err)
+		}
+	case errors.Is(err, sql.ErrNoRows):
+		res, err := tx.ExecContext(ctx,
+			"INSERT INTO passage_entries (user_id, reference, date, sections, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?)",
+			userID, entry.Reference, nullDate(entry.Date), string(sections), createdAt, updatedAt,
+		)
+		if err != nil {
+			return fmt.Errorf("inserting passage entry: %w", err)
+		}
+		if id, err = res.LastInsertId(); err != nil {
+			return fmt.Errorf("getting last insert id: %w", err)
+		}
+	default:
+		return fmt.Errorf("finding passage entry: %w", err)
+	}
+	entry.ID = id
+	return nil
+}
@@ -0,0 +1,86 @@
+package sqlite
+
+import (
+	"context"
+	"testing"
+
+	"derrclan.com/moravian-soap/internal/store"
+)
+
+func TestStore_RestoreJournal(t *testing.T) {
+	db := setupTestDB(t)
+	s := New(db)
+	ctx := context.Background()
+
+	existing := &store.PrayerItem{UserID: 1, Text: "Wisdom", Status: store.PrayerOpen, CreatedDate: "2026-01-05"}
+	if err := s.CreatePrayerItem(ctx, existing); err != nil {
+		t.Fatalf("CreatePrayerItem failed: %v", err)
+	}
+
+	resolved := "2026-01-10"
+	entries := []*store.RestoredEntry{{
+		Entry:    &store.SOAPData{Date: "2026-01-06", Sections: map[string]string{"observation": "see 2026-01-05"}, SelectedVerses: []string{}},
+		Links:    []string{"2026-01-05"},
+		Chapters: []store.Chapter{{Book: 45, Chapter: 8}},
+	}}
+	items := []*store.PrayerItem{
+		{Text: "Wisdom", Status: store.PrayerAnswered, CreatedDate: "2026-01-05", ResolvedDate: &resolved},
+		{Text: "Healing", Status: store.PrayerOpen, CreatedDate: "2026-01-06"},
+	}
+	sermon := &store.PassageEntry{Reference: "John 3:16", Sections: map[string]string{"observation": "first draft"}}
+	if err := s.CreatePassageEntry(ctx, 1, sermon); err != nil {
+		t.Fatalf("CreatePassageEntry failed: %v", err)
+	}
+	passages := []*store.PassageEntry{
+		{Reference: "John 3:16", Sections: map[string]string{"observation": "restored"}, CreatedAt: sermon.CreatedAt, UpdatedAt: sermon.CreatedAt},
+		{Reference: "Psalm 23", Date: "2026-01-04", Sections: map[string]string{}, CreatedAt: sermon.CreatedAt, UpdatedAt: sermon.CreatedAt},
+	}
+	if err := s.RestoreJournal(ctx, 1, entries, passages, items); err != nil {
+		t.Fatalf("RestoreJournal failed: %v", err)
+	}
+
+	got, err := s.ListPrayerItems(ctx, 1)
+	if err != nil {
+		t.Fatalf("ListPrayerItems failed: %v", err)
+	}
+	if len(got) != 2 || got[0].ID != existing.ID || got[0].Status != store.PrayerAnswered {
+		t.Errorf("expected the existing item to be updated and one added, got %v", got)
+	}
+	restored, err := s.ListPassageEntries(ctx, 1)
+	if err != nil {
+		t.Fatalf("ListPassageEntries failed: %v", err)
+	}
+	if len(restored) != 2 || passages[0].ID != sermon.ID {
+		t.Errorf("expected the existing passage entry to be updated and one added, got %v", restored)
+	}
+	if got, _ := s.GetPassageEntry(ctx, 1, sermon.ID); got == nil || got.Sections["observation"] != "restored" {
+		t.Errorf("existing passage entry = %+v, want its sections restored", got)
+	}
+	linked, err := s.GetLinkedEntries(ctx, 1, "2026-01-06")
+	if err != nil {
+		t.Fatalf("GetLinkedEntries failed: %v", err)
+	}
+	if len(linked.Links) != 1 || linked.Links[0] != "2026-01-05" {
+		t.Errorf("links = %v, want [2026-01-05]", linked.Links)
+	}
+
+	t.Run("Failure leaves the account unchanged", func(t *testing.T) {
+		bad := []*store.RestoredEntry{
+			{Entry: &store.SOAPData{Date: "2026-02-01", Sections: map[string]string{"prayer": "kept?"}}},
+			{Entry: &store.SOAPData{Date: "2026-02-02", Sections: map[string]string{"prayer": "fails"}}},
+		}
+		if _, err := db.Exec("CREATE TRIGGER fail_restore BEFORE INSERT ON journal_sections WHEN NEW.content = 'fails' BEGIN SELECT RAISE(ABORT, 'boom'); END"); err != nil {
+			t.Fatalf("creating trigger: %v", err)
+		}
+		if err := s.RestoreJournal(ctx, 1, bad, nil, nil); err == nil {
+			t.Fatal("expected RestoreJournal to fail")
+		}
+		entries, err := s.ListSOAPData(ctx, 1)
+		if err != nil {
+			t.Fatalf("ListSOAPData failed: %v", err)
+		}
+		if len(entries) != 1 {
+			t.Errorf("expected only the earlier restored entry, got %d entries", len(entries))
+		}
+	})
+}
@@ -7,6 +7,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"log/slog"
+	"strings"
 	"time"
 
 	"derrclan.com/moravian-soap/internal/store"
@@ -15,14 +16,17 @@ import (
 // Store implements the store.Store interface using SQLite.
 type Store struct {
 	db *sql.DB
+	// stmts holds the prepared statements of hot queries, or is nil to run every query unprepared.
+	stmts *stmtCache
 }
 
 // New creates a new SQLite store.
 func New(db *sql.DB) *Store {
-	return &Store{db: db}
+	return &Store{db: db, stmts: &stmtCache{}}
 }
 
-// GetUserFromSession retrieves a user associated with a given session token.
+// GetUserFromSession retrieves a user associated with a given session token, with the features turned
+// on or off for them.
 func (s *Store) GetUserFromSession(ctx context.Context, token string) (*store.User, error) {
 	var user store.User
 	var expiresAt time.Time
@@ -33,7 +37,7 @@ func (s *Store) GetUserFromSession(ctx context.Context, token string) (*store.Us
 		JOIN users u ON s.user_id = u.id
 		WHERE s.token = ?`
 
-	err := s.db.QueryRowContext(ctx, query, token).Scan(&user.ID, &user.Email, &user.IsVerified, &user.Timezone, &expiresAt)
+	err := s.queryRowCached(ctx, query, token).Scan(&user.ID, &user.Email, &user.IsVerified, &user.Timezone, &expiresAt)
 	if err != nil {
 		return nil, fmt.Errorf("getting user from session: %w", err)
 	}
@@ -42,20 +46,21 @@ func (s *Store) GetUserFromSession(ctx context.Context, token string) (*store.Us
 		return nil, fmt.Errorf("session expired")
 	}
 
+	if user.Features, err = s.GetFeatureOverrides(ctx, user.ID); err != nil {
+		return nil, fmt.Errorf("getting user from session: %w", err)
+	}
 	return &user, nil
 }
 
 // GetSOAPData retrieves SOAP data from the database for a given user and date.
 func (s *Store) GetSOAPData(ctx context.Context, userID int64, dateStr string) (*store.SOAPData, error) {
-	var soapData store.SOAPData
-	var selectedVersesJSON sql.NullString
-	soapData.Date = dateStr
+	soapData := store.SOAPData{Date: dateStr, Sections: map[string]string{}, SelectedVerses: []string{}}
 
-	query := `SELECT observation, application, prayer, selected_verses FROM journal WHERE user_id = ? AND date = ?`
-	err := s.db.QueryRowContext(ctx, query, userID, dateStr).Scan(&soapData.Observation, &soapData.Application, &soapData.Prayer, &selectedVersesJSON)
+	var selectedVersesJSON sql.NullString
+	query := `SELECT selected_verses, revision, location, weather FROM journal WHERE user_id = ? AND date = ?`
+	err := s.queryRowCached(ctx, query, userID, dateStr).Scan(&selectedVersesJSON, &soapData.Revision, &soapData.Location, &soapData.Weather)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			soapData.SelectedVerses = []string{}
 			return &soapData, nil
 		}
 		return nil, fmt.Errorf("retrieving SOAP journal data: %w", err)
@@ -66,36 +71,352 @@ func (s *Store) GetSOAPData(ctx context.Context, userID int64, dateStr string) (
 			slog.Error("failed to unmarshal (JSON) selected verses", "error", err, "userID", userID, "verses", selectedVersesJSON.String)
 			soapData.SelectedVerses = []string{}
 		}
-	} else {
-		soapData.SelectedVerses = []string{}
+	}
+
+	rows, err := s.queryCached(ctx, `SELECT section, content FROM journal_sections WHERE user_id = ? AND date = ?`, userID, dateStr)
+	if err != nil {
+		return nil, fmt.Errorf("retrieving journal sections: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var section, content string
+		if err := rows.Scan(&section, &content); err != nil {
+			return nil, fmt.Errorf("scanning journal section: %w", err)
+		}
+		soapData.Sections[section] = content
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
 	}
 	return &soapData, nil
 }
 
-// SaveSOAPData saves SOAP data to the database.
+// ListSOAPData retrieves all of a user's journal entries, oldest first.
+func (s *Store) ListSOAPData(ctx context.Context, userID int64) ([]*store.SOAPData, error) {
+	rows, err := s.queryCached(ctx, `SELECT date, selected_verses, location, weather FROM journal WHERE user_id = ? ORDER BY date`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("querying journal entries for user %d: %w", userID, err)
+	}
+	defer rows.Close()
+
+	var entries []*store.SOAPData
+	byDate := make(map[string]*store.SOAPData)
+	for rows.Next() {
+		entry := store.SOAPData{Sections: map[string]string{}, SelectedVerses: []string{}}
+		var selectedVersesJSON sql.NullString
+		if err := rows.Scan(&entry.Date, &selectedVersesJSON, &entry.Location, &entry.Weather); err != nil {
+			return nil, fmt.Errorf("scanning journal entry: %w", err)
+		}
+		if selectedVersesJSON.Valid && selectedVersesJSON.String != "" {
+			if err := json.Unmarshal([]byte(selectedVersesJSON.String), &entry.SelectedVerses); err != nil {
+				slog.Error("failed to unmarshal (JSON) selected verses", "error", err, "userID", userID, "verses", selectedVersesJSON.String)
+			}
+			if entry.SelectedVerses == nil {
+				entry.SelectedVerses = []string{}
+			}
+		}
+		entries = append(entries, &entry)
+		byDate[entry.Date] = &entry
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	sectionRows, err := s.queryCached(ctx, `SELECT date, section, content FROM journal_sections WHERE user_id = ?`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("retrieving journal sections: %w", err)
+	}
+	defer sectionRows.Close()
+	for sectionRows.Next() {
+		var date, section, content string
+		if err := sectionRows.Scan(&date, &section, &content); err != nil {
+			return nil, fmt.Errorf("scanning journal section: %w", err)
+		}
+		if entry, ok := byDate[date]; ok {
+			entry.Sections[section] = content
+		}
+	}
+	if err := sectionRows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return entries, nil
+}
+
+// SaveSOAPData saves SOAP data to the database. Only the sections present in soapData.Sections are
+// written; sections saved empty are removed and sections not present are left as they were.
 func (s *Store) SaveSOAPData(ctx context.Context, userID int64, soapData *store.SOAPData) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if err := saveSOAPData(ctx, tx, userID, soapData); err != nil {
+		return err
+	}
+	if err := recordEditing(ctx, tx, userID, soapData.Date); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("committing SOAP data: %w", err)
+	}
+	return nil
+}
+
+// SaveEntryStamp records where a user's journal entry was written and the weather there, replacing any
+// stamp it had. It returns sql.ErrNoRows if the entry has not been saved.
+func (s *Store) SaveEntryStamp(ctx context.Context, userID int64, dateStr, location, weather string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if err := saveEntryStamp(ctx, tx, userID, dateStr, location, weather); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("committing stamp: %w", err)
+	}
+	return nil
+}
+
+// saveEntryStamp records the stamp of a user's journal entry within tx.
+func saveEntryStamp(ctx context.Context, tx *sql.Tx, userID int64, dateStr, location, weather string) error {
+	res, err := tx.ExecContext(ctx, `UPDATE journal SET location = ?, weather = ? WHERE user_id = ? AND date = ?`, location, weather, userID, dateStr)
+	if err != nil {
+		return fmt.Errorf("saving stamp of %s: %w", dateStr, err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("saving stamp of %s: %w", dateStr, err)
+	}
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// editingIdleGap is the longest pause between saves of an entry that still counts as time spent writing
+// it. The editor saves a second after each change, so longer pauses mean the user stepped away.
+const editingIdleGap = 5 * time.Minute
+
+// recordEditing records a save of a user's journal entry within tx: the first save marks when the entry
+// was started, the latest when it was finished, and the time between saves no more than editingIdleGap
+// apart adds up to the time spent writing it.
+func recordEditing(ctx context.Context, tx *sql.Tx, userID int64, dateStr string) error {
+	// The right-hand sides all see the row as it was before the update.
+	query := `
+		UPDATE journal SET
+			started_at = COALESCE(started_at, CURRENT_TIMESTAMP),
+			editing_seconds = editing_seconds + CASE
+				WHEN finished_at IS NOT NULL
+					AND strftime('%s', 'now') - strftime('%s', finished_at) BETWEEN 0 AND ?
+				THEN strftime('%s', 'now') - strftime('%s', finished_at)
+				ELSE 0
+			END,
+			finished_at = CURRENT_TIMESTAMP
+		WHERE user_id = ? AND date = ?
+	`
+	if _, err := tx.ExecContext(ctx, query, int(editingIdleGap.Seconds()), userID, dateStr); err != nil {
+		return fmt.Errorf("recording editing time for %s: %w", dateStr, err)
+	}
+	return nil
+}
+
+// GetJournalingStats summarizes the time a user has spent writing the entries saved since a date.
+// Entries saved before editing time was recorded are not counted.
+func (s *Store) GetJournalingStats(ctx context.Context, userID int64, since string) (*store.JournalingStats, error) {
+	query := `
+		SELECT COUNT(*), COALESCE(SUM(editing_seconds), 0), COALESCE(MAX(editing_seconds), 0)
+		FROM journal
+		WHERE user_id = ? AND date >= ? AND started_at IS NOT NULL
+	`
+	var stats store.JournalingStats
+	var total, longest int64
+	if err := s.db.QueryRowContext(ctx, query, userID, since).Scan(&stats.Entries, &total, &longest); err != nil {
+		return nil, fmt.Errorf("getting journaling stats for user %d: %w", userID, err)
+	}
+	stats.Total = time.Duration(total) * time.Second
+	stats.Longest = time.Duration(longest) * time.Second
+	return &stats, nil
+}
+
+// saveSOAPData saves SOAP data within tx.
+func saveSOAPData(ctx context.Context, tx *sql.Tx, userID int64, soapData *store.SOAPData) error {
 	selectedVersesJSON, err := json.Marshal(soapData.SelectedVerses)
 	if err != nil {
 		return fmt.Errorf("JSON marshaling selected verses: %w", err)
 	}
 
 	query := `
-		INSERT INTO journal (user_id, date, observation, application, prayer, selected_verses)
-		VALUES (?, ?, ?, ?, ?, ?)
+		INSERT INTO journal (user_id, date, selected_verses, revision)
+		VALUES (?, ?, ?, 1)
 		ON CONFLICT(user_id, date) DO UPDATE SET
-			observation = excluded.observation,
-			application = excluded.application,
-			prayer = excluded.prayer,
 			selected_verses = excluded.selected_verses,
-			timestamp = CURRENT_TIMESTAMP
+			timestamp = CURRENT_TIMESTAMP,
+			revision = revision + 1
+		RETURNING revision
 	`
-	_, err = s.db.ExecContext(ctx, query, userID, soapData.Date, soapData.Observation, soapData.Application, soapData.Prayer, selectedVersesJSON)
-	if err != nil {
+	var revision int64
+	if err := tx.QueryRowContext(ctx, query, userID, soapData.Date, selectedVersesJSON).Scan(&revision); err != nil {
 		return fmt.Errorf("saving SOAP data: %w", err)
 	}
+
+	for section, content := range soapData.Sections {
+		if content == "" {
+			_, err = tx.ExecContext(ctx, `DELETE FROM journal_sections WHERE user_id = ? AND date = ? AND section = ?`, userID, soapData.Date, section)
+		} else {
+			// A section saved unchanged keeps the time and revision it last changed at.
+			_, err = tx.ExecContext(ctx, `
+				INSERT INTO journal_sections (user_id, date, section, content, saved_at, revision)
+				VALUES (?, ?, ?, ?, CURRENT_TIMESTAMP, ?)
+				ON CONFLICT(user_id, date, section) DO UPDATE SET
+					content = excluded.content,
+					saved_at = CASE WHEN content = excluded.content THEN saved_at ELSE excluded.saved_at END,
+					revision = CASE WHEN content = excluded.content THEN revision ELSE excluded.revision END
+			`, userID, soapData.Date, section, content, revision)
+		}
+		if err != nil {
+			return fmt.Errorf("saving journal section %q: %w", section, err)
+		}
+	}
 	return nil
 }
 
+// ListRecentSOAPData retrieves a page of a user's journal entries with anything written in them, newest
+// first, skipping the offset newest.
+func (s *Store) ListRecentSOAPData(ctx context.Context, userID int64, limit, offset int) ([]*store.SOAPData, error) {
+	query := `
+		SELECT j.date, j.selected_verses, j.revision, j.location, j.weather FROM journal j
+		WHERE j.user_id = ? AND EXISTS (
+			SELECT 1 FROM journal_sections s WHERE s.user_id = j.user_id AND s.date = j.date AND s.content != ''
+		)
+		ORDER BY j.date DESC
+		LIMIT ? OFFSET ?
+	`
+	rows, err := s.db.QueryContext(ctx, query, userID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("querying recent journal entries for user %d: %w", userID, err)
+	}
+	defer rows.Close()
+
+	var entries []*store.SOAPData
+	byDate := make(map[string]*store.SOAPData)
+	for rows.Next() {
+		entry := store.SOAPData{Sections: map[string]string{}, SelectedVerses: []string{}}
+		var selectedVersesJSON sql.NullString
+		if err := rows.Scan(&entry.Date, &selectedVersesJSON, &entry.Revision, &entry.Location, &entry.Weather); err != nil {
+			return nil, fmt.Errorf("scanning journal entry: %w", err)
+		}
+		if selectedVersesJSON.Valid && selectedVersesJSON.String != "" {
+			if err := json.Unmarshal([]byte(selectedVersesJSON.String), &entry.SelectedVerses); err != nil {
+				slog.Error("failed to unmarshal (JSON) selected verses", "error", err, "userID", userID, "verses", selectedVersesJSON.String)
+			}
+			if entry.SelectedVerses == nil {
+				entry.SelectedVerses = []string{}
+			}
+		}
+		entries = append(entries, &entry)
+		byDate[entry.Date] = &entry
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	if len(entries) == 0 {
+		return entries, nil
+	}
+
+	// The page's entries are the user's only entries between its oldest and newest dates.
+	sectionRows, err := s.db.QueryContext(ctx, `
+		SELECT date, section, content FROM journal_sections WHERE user_id = ? AND date BETWEEN ? AND ?
+	`, userID, entries[len(entries)-1].Date, entries[0].Date)
+	if err != nil {
+		return nil, fmt.Errorf("retrieving journal sections: %w", err)
+	}
+	defer sectionRows.Close()
+	for sectionRows.Next() {
+		var date, section, content string
+		if err := sectionRows.Scan(&date, &section, &content); err != nil {
+			return nil, fmt.Errorf("scanning journal section: %w", err)
+		}
+		if entry, ok := byDate[date]; ok {
+			entry.Sections[section] = content
+		}
+	}
+	if err := sectionRows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return entries, nil
+}
+
+// SearchSOAPData retrieves up to limit of a user's journal entries with a section containing query,
+// ignoring ASCII case, newest first.
+func (s *Store) SearchSOAPData(ctx context.Context, userID int64, query string, limit int) ([]*store.SOAPData, error) {
+	escaped := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(query)
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT DISTINCT date FROM journal_sections
+		WHERE user_id = ? AND content LIKE ? ESCAPE '\'
+		ORDER BY date DESC
+		LIMIT ?
+	`, userID, "%"+escaped+"%", limit)
+	if err != nil {
+		return nil, fmt.Errorf("searching journal entries for user %d: %w", userID, err)
+	}
+	var dates []string
+	for rows.Next() {
+		var d string
+		if err := rows.Scan(&d); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("scanning journal date: %w", err)
+		}
+		dates = append(dates, d)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	entries := make([]*store.SOAPData, 0, len(dates))
+	for _, d := range dates {
+		entry, err := s.GetSOAPData(ctx, userID, d)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// GetJournaledDates retrieves the dates between from and to (inclusive) on which a user wrote a journal entry.
+func (s *Store) GetJournaledDates(ctx context.Context, userID int64, from, to string) ([]string, error) {
+	query := `
+		SELECT DISTINCT date FROM journal_sections
+		WHERE user_id = ? AND date BETWEEN ? AND ? AND content != ''
+		ORDER BY date
+	`
+	rows, err := s.db.QueryContext(ctx, query, userID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("querying journaled dates: %w", err)
+	}
+	defer rows.Close()
+
+	var dates []string
+	for rows.Next() {
+		var d string
+		if err := rows.Scan(&d); err != nil {
+			return nil, fmt.Errorf("scanning journaled date: %w", err)
+		}
+		dates = append(dates, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return dates, nil
+}
+
 // CreateUser inserts a new user into the database.
 func (s *Store) CreateUser(ctx context.Context, email, passwordHash, token, timezone string) error {
 	if timezone == "" {
@@ -199,13 +520,33 @@ func (s *Store) UpdateUserPasswordHash(ctx context.Context, userID int64, newHas
 
 // CreateSession creates a new session token.
 func (s *Store) CreateSession(ctx context.Context, token string, userID int64, expiresAt time.Time) error {
-	_, err := s.db.ExecContext(ctx, "INSERT INTO sessions (token, user_id, expires_at) VALUES (?, ?, ?)", token, userID, expiresAt)
+	_, err := s.db.ExecContext(ctx, "INSERT INTO sessions (token, user_id, expires_at, last_active_at) VALUES (?, ?, ?, ?)", token, userID, expiresAt, time.Now())
 	if err != nil {
 		return fmt.Errorf("saving session for user %d: %w", userID, err)
 	}
 	return nil
 }
 
+// GetSessionActivity retrieves when a session was last used. It returns the zero time for sessions
+// whose activity has not been recorded.
+func (s *Store) GetSessionActivity(ctx context.Context, token string) (time.Time, error) {
+	var lastActive sql.NullTime
+	err := s.db.QueryRowContext(ctx, "SELECT last_active_at FROM sessions WHERE token = ?", token).Scan(&lastActive)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("getting session activity: %w", err)
+	}
+	return lastActive.Time, nil
+}
+
+// TouchSession records that a session was used at the given time.
+func (s *Store) TouchSession(ctx context.Context, token string, at time.Time) error {
+	_, err := s.db.ExecContext(ctx, "UPDATE sessions SET last_active_at = ? WHERE token = ?", at, token)
+	if err != nil {
+		return fmt.Errorf("recording session activity: %w", err)
+	}
+	return nil
+}
+
 // DeleteExpiredSessions removes expired session tokens.
 func (s *Store) DeleteExpiredSessions(ctx context.Context) error {
 	_, err := s.db.ExecContext(ctx, "DELETE FROM sessions WHERE expires_at < ?", time.Now())
@@ -215,13 +556,70 @@ func (s *Store) DeleteExpiredSessions(ctx context.Context) error {
 	return nil
 }
 
-// ExpungeCache removes old and excess entries from the esv_cache table.
-func (s *Store) ExpungeCache(ctx context.Context, olderThan time.Duration, keepMax int) error {
+// EvictCache removes the least recently used entries from the esv_cache table until their total size is
+// at most maxBytes, removing no more than batchSize entries per call. It returns the number of entries
+// removed; when that is batchSize the cache may still be over the limit.
+func (s *Store) EvictCache(ctx context.Context, maxBytes int64, batchSize int) (int, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var total int64
+	if err := tx.QueryRowContext(ctx, "SELECT COALESCE(SUM(size_bytes), 0) FROM esv_cache").Scan(&total); err != nil {
+		return 0, fmt.Errorf("summing ESV cache size: %w", err)
+	}
+	excess := total - maxBytes
+	if excess <= 0 {
+		return 0, nil
+	}
+
+	query := `
+		SELECT reference, size_bytes
+		FROM esv_cache
+		ORDER BY COALESCE(last_accessed, created_at) ASC, reference
+		LIMIT ?
+	`
+	rows, err := tx.QueryContext(ctx, query, batchSize)
+	if err != nil {
+		return 0, fmt.Errorf("listing least recently used ESV cache entries: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var refs []string
+	for excess > 0 && rows.Next() {
+		var ref string
+		var size int64
+		if err := rows.Scan(&ref, &size); err != nil {
+			return 0, fmt.Errorf("scanning ESV cache entry: %w", err)
+		}
+		refs = append(refs, ref)
+		excess -= size
+	}
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("rows error: %w", err)
+	}
+	_ = rows.Close()
+
+	for _, ref := range refs {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM esv_cache WHERE reference = ?", ref); err != nil {
+			return 0, fmt.Errorf("evicting ESV cache entry (key=%s): %w", ref, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("committing ESV cache eviction: %w", err)
+	}
+	return len(refs), nil
+}
+
+// ExpungeCache removes the entries created before createdBefore and, beyond keepMax entries, the least
+// recently used ones from the esv_cache table.
+func (s *Store) ExpungeCache(ctx context.Context, createdBefore time.Time, keepMax int) error {
 	// The terms of use for api.esv.org requires keeping no more than 500 passages and for none for longer than 30 days.
 
 	// Time-based purge
-	cutoff := time.Now().Add(-olderThan)
-	_, err := s.db.ExecContext(ctx, "DELETE FROM esv_cache WHERE created_at < ?", cutoff)
+	_, err := s.db.ExecContext(ctx, "DELETE FROM esv_cache WHERE created_at < ?", createdBefore)
 	if err != nil {
 		return fmt.Errorf("purging old ESV cache entries: %w", err)
 	}
@@ -240,7 +638,7 @@ func (s *Store) ExpungeCache(ctx context.Context, olderThan time.Duration, keepM
 			WHERE reference IN (
 				SELECT reference
 				FROM esv_cache
-				ORDER BY created_at ASC
+				ORDER BY COALESCE(last_accessed, created_at) ASC
 				LIMIT ?
 			)
 		`
@@ -253,19 +651,144 @@ func (s *Store) ExpungeCache(ctx context.Context, olderThan time.Duration, keepM
 	return nil
 }
 
-// GetCachedESV retrieves a cached ESV response.
-func (s *Store) GetCachedESV(ctx context.Context, key string) (string, error) {
+// cacheTouchInterval is how out of date a cache entry's last access time may be before reading it
+// updates it, so that reading cached passages does not take the write lock on every page view.
+// Eviction only needs to tell entries apart by hours.
+const cacheTouchInterval = time.Hour
+
+// ageModifier returns the SQLite date modifier that goes back age from now.
+func ageModifier(age time.Duration) string {
+	return fmt.Sprintf("-%d seconds", int(age.Seconds()))
+}
+
+// DeleteCachedESV removes the entries with keys from the esv_cache table.
+func (s *Store) DeleteCachedESV(ctx context.Context, keys []string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	args := make([]any, len(keys))
+	for i, key := range keys {
+		args[i] = key
+	}
+	query := `DELETE FROM esv_cache WHERE reference IN (?` + strings.Repeat(", ?", len(keys)-1) + `)`
+	if _, err := s.db.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("deleting ESV cache entries: %w", err)
+	}
+	return nil
+}
+
+// DeleteCachedESVVariants deletes the cached passages with the key base under any rendering options.
+func (s *Store) DeleteCachedESVVariants(ctx context.Context, base string) error {
+	query := `DELETE FROM esv_cache WHERE reference = ? OR substr(reference, 1, ?) = ?`
+	if _, err := s.db.ExecContext(ctx, query, base, len(base)+1, base+"|"); err != nil {
+		return fmt.Errorf("deleting ESV cache entries (key=%s): %w", base, err)
+	}
+	return nil
+}
+
+// GetCachedESV retrieves a cached ESV response no older than maxAge and marks it as recently used.
+func (s *Store) GetCachedESV(ctx context.Context, key string, maxAge time.Duration) (string, error) {
 	var content string
-	err := s.db.QueryRowContext(ctx, "SELECT content FROM esv_cache WHERE reference = ?", key).Scan(&content)
-	if err != nil {
+	var stale bool
+	query := `
+		SELECT content, COALESCE(last_accessed < datetime('now', ?), 1) FROM esv_cache
+		WHERE reference = ? AND created_at > datetime('now', ?)
+	`
+	since := ageModifier(cacheTouchInterval)
+	if err := s.queryRowCached(ctx, query, since, key, ageModifier(maxAge)).Scan(&content, &stale); err != nil {
 		return "", fmt.Errorf("getting cached ESV content (key=%s): %w", key, err)
 	}
+	if stale {
+		if _, err := s.execCached(ctx, "UPDATE esv_cache SET last_accessed = CURRENT_TIMESTAMP WHERE reference = ?", key); err != nil {
+			slog.Error("failed to mark ESV cache entry as used", "key", key, "error", err)
+		}
+	}
 	return content, nil
 }
 
+// GetCachedESVBatch retrieves the cached content no older than maxAge of each of keys that is in the
+// cache, by key, in one query. Like GetCachedESV, it updates the access times of entries not read within
+// cacheTouchInterval.
+func (s *Store) GetCachedESVBatch(ctx context.Context, keys []string, maxAge time.Duration) (map[string]string, error) {
+	contents := make(map[string]string, len(keys))
+	if len(keys) == 0 {
+		return contents, nil
+	}
+	args := []any{ageModifier(cacheTouchInterval), ageModifier(maxAge)}
+	for _, key := range keys {
+		args = append(args, key)
+	}
+
+	query := `
+		SELECT reference, content, COALESCE(last_accessed < datetime('now', ?), 1) FROM esv_cache
+		WHERE created_at > datetime('now', ?) AND reference IN (?` + strings.Repeat(", ?", len(keys)-1) + `)`
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("getting cached ESV content: %w", err)
+	}
+	defer rows.Close()
+
+	var stale []any
+	for rows.Next() {
+		var key, content string
+		var isStale bool
+		if err := rows.Scan(&key, &content, &isStale); err != nil {
+			return nil, fmt.Errorf("scanning cached ESV content: %w", err)
+		}
+		contents[key] = content
+		if isStale {
+			stale = append(stale, key)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	if len(stale) > 0 {
+		query := `UPDATE esv_cache SET last_accessed = CURRENT_TIMESTAMP WHERE reference IN (?` + strings.Repeat(", ?", len(stale)-1) + `)`
+		if _, err := s.db.ExecContext(ctx, query, stale...); err != nil {
+			slog.Error("failed to mark ESV cache entries as used", "count", len(stale), "error", err)
+		}
+	}
+	return contents, nil
+}
+
+// SampleCachedESV returns up to n entries no older than maxAge from the esv_cache table, by key, chosen
+// at random. Unlike GetCachedESV it leaves their access times alone, as reading a sample is not a use.
+func (s *Store) SampleCachedESV(ctx context.Context, maxAge time.Duration, n int) (map[string]string, error) {
+	query := `
+		SELECT reference, content FROM esv_cache
+		WHERE created_at > datetime('now', ?)
+		ORDER BY RANDOM()
+		LIMIT ?
+	`
+	rows, err := s.db.QueryContext(ctx, query, ageModifier(maxAge), n)
+	if err != nil {
+		return nil, fmt.Errorf("sampling cached ESV content: %w", err)
+	}
+	defer rows.Close()
+
+	contents := make(map[string]string, n)
+	for rows.Next() {
+		var key, content string
+		if err := rows.Scan(&key, &content); err != nil {
+			return nil, fmt.Errorf("scanning cached ESV content: %w", err)
+		}
+		contents[key] = content
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return contents, nil
+}
+
 // SaveCachedESV saves an ESV response to the cache.
 func (s *Store) SaveCachedESV(ctx context.Context, key string, content string) error {
-	_, err := s.db.ExecContext(ctx, "INSERT OR REPLACE INTO esv_cache (reference, content) VALUES (?, ?)", key, content)
+	query := `
+		INSERT OR REPLACE INTO esv_cache (reference, content, size_bytes, last_accessed)
+		VALUES (?, ?, ?, CURRENT_TIMESTAMP)
+	`
+	_, err := s.execCached(ctx, query, key, content, len(content))
 	if err != nil {
 		return fmt.Errorf("saving to ESV cache (key=%s): %w", key, err)
 	}
@@ -356,3 +879,44 @@ func (s *Store) MarkEmailSent(ctx context.Context, id int64) error {
 	}
 	return nil
 }
+
+// MarkEmailSuppressed marks a queued email as not sent because its recipient is suppressed.
+func (s *Store) MarkEmailSuppressed(ctx context.Context, id int64) error {
+	_, err := s.db.ExecContext(ctx, "UPDATE queued_emails SET status = 'suppressed' WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("marking email as suppressed (id=%d): %w", id, err)
+	}
+	return nil
+}
+
+// ListRecentEmails returns the most recently queued emails, newest first, without their bodies.
+func (s *Store) ListRecentEmails(ctx context.Context, limit int) ([]*store.QueuedEmail, error) {
+	query := `
+		SELECT id, user_id, recipient, subject, status, attempts, last_attempt_at, next_attempt_at
+		FROM queued_emails
+		ORDER BY id DESC
+		LIMIT ?
+	`
+	rows, err := s.db.QueryContext(ctx, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("querying recent emails: %w", err)
+	}
+	defer rows.Close()
+
+	var emails []*store.QueuedEmail
+	for rows.Next() {
+		var email store.QueuedEmail
+		err := rows.Scan(
+			&email.ID, &email.UserID, &email.Recipient, &email.Subject,
+			&email.Status, &email.Attempts, &email.LastAttemptAt, &email.NextAttemptAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("scanning queued email: %w", err)
+		}
+		emails = append(emails, &email)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return emails, nil
+}
@@ -4,6 +4,9 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
+	"maps"
+	"slices"
 	"testing"
 	"time"
 
@@ -30,23 +33,40 @@ func setupTestDB(t *testing.T) *sql.DB {
 		token TEXT PRIMARY KEY,
 		user_id INTEGER NOT NULL,
 		expires_at DATETIME NOT NULL,
+		last_active_at DATETIME,
 		FOREIGN KEY(user_id) REFERENCES users(id)
 	);
 	CREATE TABLE journal (
 		user_id INTEGER NOT NULL,
 		date TEXT NOT NULL,
-		observation TEXT NOT NULL,
-		application TEXT NOT NULL,
-		prayer TEXT NOT NULL,
 		selected_verses TEXT,
 		timestamp DATETIME DEFAULT CURRENT_TIMESTAMP,
+		started_at DATETIME,
+		finished_at DATETIME,
+		editing_seconds INTEGER NOT NULL DEFAULT 0,
+		completeness TEXT NOT NULL DEFAULT 'none',
+		revision INTEGER NOT NULL DEFAULT 0,
+		location TEXT NOT NULL DEFAULT '',
+		weather TEXT NOT NULL DEFAULT '',
 		PRIMARY KEY (user_id, date),
 		FOREIGN KEY(user_id) REFERENCES users(id)
 	);
+	CREATE TABLE journal_sections (
+		user_id INTEGER NOT NULL,
+		date TEXT NOT NULL,
+		section TEXT NOT NULL,
+		content TEXT NOT NULL,
+		saved_at DATETIME,
+		revision INTEGER NOT NULL DEFAULT 0,
+		PRIMARY KEY (user_id, date, section),
+		FOREIGN KEY(user_id) REFERENCES users(id)
+	);
 	CREATE TABLE esv_cache (
 		reference TEXT PRIMARY KEY,
 		content TEXT NOT NULL,
-		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		size_bytes INTEGER NOT NULL DEFAULT 0,
+		last_accessed DATETIME
 	);
 	CREATE TABLE password_reset_tokens (
 		token TEXT PRIMARY KEY,
@@ -68,6 +88,268 @@ func setupTestDB(t *testing.T) *sql.DB {
 		FOREIGN KEY (user_id) REFERENCES users(id)
 	);
 	CREATE INDEX idx_queued_emails_status_next_attempt ON queued_emails(status, next_attempt_at);
+	CREATE TABLE email_suppressions (
+		address TEXT PRIMARY KEY COLLATE NOCASE,
+		reason TEXT NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+	CREATE TABLE daily_post_channels (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL,
+		kind TEXT NOT NULL,
+		webhook_url TEXT NOT NULL,
+		template TEXT NOT NULL DEFAULT '',
+		post_time TEXT NOT NULL DEFAULT '07:00',
+		timezone TEXT NOT NULL DEFAULT 'UTC',
+		last_posted_date TEXT NOT NULL DEFAULT '',
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+	CREATE TABLE api_tokens (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id INTEGER NOT NULL,
+		name TEXT NOT NULL,
+		token_hash TEXT UNIQUE NOT NULL,
+		request_count INTEGER NOT NULL DEFAULT 0,
+		last_used_at DATETIME,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (user_id) REFERENCES users(id)
+	);
+	CREATE TABLE esv_usage (
+		user_id INTEGER NOT NULL,
+		day TEXT NOT NULL,
+		requests INTEGER NOT NULL DEFAULT 0,
+		PRIMARY KEY (user_id, day)
+	);
+	CREATE TABLE prayer_items (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id INTEGER NOT NULL,
+		text TEXT NOT NULL,
+		status TEXT NOT NULL DEFAULT 'open',
+		created_date TEXT NOT NULL,
+		resolved_date TEXT
+	);
+	CREATE TABLE journal_links (
+		user_id INTEGER NOT NULL,
+		source_date TEXT NOT NULL,
+		target_date TEXT NOT NULL,
+		PRIMARY KEY (user_id, source_date, target_date)
+	);
+	CREATE TABLE psalter_readers (
+		user_id INTEGER PRIMARY KEY
+	);
+	CREATE TABLE psalter_readings (
+		user_id INTEGER NOT NULL,
+		date TEXT NOT NULL,
+		portion INTEGER NOT NULL,
+		PRIMARY KEY (user_id, date)
+	);
+	CREATE TABLE journal_chapters (
+		user_id INTEGER NOT NULL,
+		date TEXT NOT NULL,
+		book INTEGER NOT NULL,
+		chapter INTEGER NOT NULL,
+		PRIMARY KEY (user_id, date, book, chapter)
+	);
+	CREATE INDEX idx_journal_chapters_chapter ON journal_chapters(user_id, book, chapter, date);
+	CREATE TABLE webauthn_credentials (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id INTEGER NOT NULL,
+		name TEXT NOT NULL,
+		credential_id BLOB UNIQUE NOT NULL,
+		public_key BLOB NOT NULL,
+		sign_count INTEGER NOT NULL DEFAULT 0,
+		last_used_at DATETIME,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+	CREATE TABLE webauthn_challenges (
+		challenge TEXT PRIMARY KEY,
+		user_id INTEGER NOT NULL DEFAULT 0,
+		expires_at DATETIME NOT NULL
+	);
+	CREATE TABLE user_preferences (
+		user_id INTEGER PRIMARY KEY,
+		esv_headings INTEGER NOT NULL DEFAULT 1,
+		esv_verse_numbers INTEGER NOT NULL DEFAULT 1,
+		esv_short_copyright INTEGER NOT NULL DEFAULT 1,
+		esv_indent_poetry INTEGER NOT NULL DEFAULT 1,
+		esv_red_letter INTEGER NOT NULL DEFAULT 0,
+		journal_schema TEXT NOT NULL DEFAULT '',
+		lock_after_minutes INTEGER NOT NULL DEFAULT 0,
+		devotions_audience TEXT NOT NULL DEFAULT '',
+		inactivity_reminder_days INTEGER NOT NULL DEFAULT 0,
+		inactivity_cooldown_days INTEGER NOT NULL DEFAULT 7,
+		translation TEXT NOT NULL DEFAULT 'ESV',
+		daily_email_time TEXT NOT NULL DEFAULT '',
+		week_start INTEGER NOT NULL DEFAULT 0,
+		iso_weeks INTEGER NOT NULL DEFAULT 0
+	);
+	CREATE TABLE inactivity_reminders (
+		user_id INTEGER PRIMARY KEY,
+		sent_at DATETIME NOT NULL
+	);
+	CREATE TABLE daily_emails (
+		user_id INTEGER PRIMARY KEY,
+		last_sent_date TEXT NOT NULL
+	);
+	CREATE TABLE email_subscriptions (
+		user_id INTEGER NOT NULL,
+		list TEXT NOT NULL,
+		status TEXT NOT NULL,
+		token TEXT NOT NULL UNIQUE,
+		updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (user_id, list)
+	);
+	CREATE TABLE feature_overrides (
+		user_id INTEGER NOT NULL,
+		feature TEXT NOT NULL,
+		enabled BOOLEAN NOT NULL,
+		PRIMARY KEY (user_id, feature)
+	);
+	CREATE TABLE verse_notes (
+		user_id INTEGER NOT NULL,
+		verse_ref TEXT NOT NULL,
+		note TEXT NOT NULL,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (user_id, verse_ref)
+	);
+	CREATE TABLE instance_settings (
+		key TEXT PRIMARY KEY,
+		value TEXT NOT NULL,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+	CREATE TABLE announcement_dismissals (
+		user_id INTEGER NOT NULL,
+		announcement_id TEXT NOT NULL,
+		dismissed_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (user_id, announcement_id)
+	);
+	CREATE TABLE journal_merges (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id INTEGER NOT NULL,
+		date TEXT NOT NULL,
+		base_revision INTEGER NOT NULL,
+		theirs_revision INTEGER NOT NULL,
+		merged_revision INTEGER NOT NULL,
+		merged_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+	CREATE TABLE quiet_times (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id INTEGER NOT NULL,
+		date TEXT NOT NULL,
+		started_at DATETIME NOT NULL,
+		duration_seconds INTEGER NOT NULL,
+		ended_at DATETIME,
+		completed BOOLEAN NOT NULL DEFAULT 0
+	);
+	CREATE TABLE reading_chapters (
+		date TEXT NOT NULL,
+		book INTEGER NOT NULL,
+		chapter INTEGER NOT NULL,
+		PRIMARY KEY (date, book, chapter)
+	);
+	CREATE TABLE passage_entries (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id INTEGER NOT NULL,
+		reference TEXT NOT NULL,
+		date TEXT,
+		sections TEXT NOT NULL DEFAULT '{}',
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+	CREATE TABLE year_reviews (
+		user_id INTEGER NOT NULL,
+		year INTEGER NOT NULL,
+		token TEXT NOT NULL UNIQUE,
+		verses TEXT NOT NULL DEFAULT '[]',
+		summary TEXT,
+		card BLOB,
+		updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		comments_enabled INTEGER NOT NULL DEFAULT 0,
+		PRIMARY KEY (user_id, year)
+	);
+	CREATE TABLE guest_comments (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id INTEGER NOT NULL,
+		year INTEGER NOT NULL,
+		name TEXT NOT NULL,
+		body TEXT NOT NULL,
+		ip TEXT NOT NULL,
+		approved INTEGER NOT NULL DEFAULT 1,
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+	CREATE TABLE yearbooks (
+		user_id INTEGER NOT NULL,
+		year INTEGER NOT NULL,
+		status TEXT NOT NULL DEFAULT 'queued',
+		html BLOB,
+		error TEXT NOT NULL DEFAULT '',
+		requested_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		made_at DATETIME,
+		PRIMARY KEY (user_id, year)
+	);
+	CREATE TABLE comment_blocklist (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		kind TEXT NOT NULL,
+		value TEXT NOT NULL,
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE (kind, value)
+	);
+	CREATE TABLE journal_favorites (
+		user_id INTEGER NOT NULL,
+		date TEXT NOT NULL,
+		position INTEGER NOT NULL,
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (user_id, date)
+	);
+	CREATE TABLE highlights (
+		user_id INTEGER NOT NULL,
+		verse_ref TEXT NOT NULL,
+		source TEXT NOT NULL,
+		highlighted_on TEXT NOT NULL DEFAULT '',
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (user_id, verse_ref)
+	);
+	CREATE TABLE mentorships (
+		user_id INTEGER NOT NULL,
+		mentor_id INTEGER NOT NULL,
+		from_date TEXT NOT NULL DEFAULT '',
+		to_date TEXT NOT NULL DEFAULT '',
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (user_id, mentor_id)
+	);
+	CREATE TABLE mentor_comments (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id INTEGER NOT NULL,
+		date TEXT NOT NULL,
+		author_id INTEGER NOT NULL,
+		body TEXT NOT NULL,
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+	CREATE TABLE api_log (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		at DATETIME NOT NULL,
+		translation TEXT NOT NULL,
+		refs TEXT NOT NULL,
+		url TEXT NOT NULL,
+		status INTEGER NOT NULL,
+		bytes INTEGER NOT NULL,
+		latency_ms INTEGER NOT NULL,
+		retries INTEGER NOT NULL,
+		cache TEXT NOT NULL,
+		error TEXT NOT NULL DEFAULT ''
+	);
+	CREATE TABLE cache_verifications (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		verified_at DATETIME NOT NULL,
+		checked INTEGER NOT NULL,
+		failed INTEGER NOT NULL,
+		changed TEXT NOT NULL DEFAULT '[]'
+	);
+	CREATE TABLE storage_quotas (
+		user_id INTEGER PRIMARY KEY,
+		quota_bytes INTEGER NOT NULL,
+		updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
 	`
 	if _, err := db.Exec(schema); err != nil {
 		t.Fatalf("failed to create schema: %v", err)
@@ -134,19 +416,26 @@ func TestStore_GetSOAPData(t *testing.T) {
 	date := "2026-02-18"
 	selectedVerses := []string{"Gen 1:1", "Gen 1:2"}
 	versesJSON, _ := json.Marshal(selectedVerses)
-	_, err = db.Exec("INSERT INTO journal (user_id, date, observation, application, prayer, selected_verses) VALUES (1, ?, 'obs', 'app', 'pry', ?)", date, string(versesJSON))
+	_, err = db.Exec("INSERT INTO journal (user_id, date, selected_verses) VALUES (1, ?, ?)", date, string(versesJSON))
 	if err != nil {
 		t.Fatalf("failed to insert journal entry: %v", err)
 	}
+	_, err = db.Exec("INSERT INTO journal_sections (user_id, date, section, content) VALUES (1, ?, 'observation', 'obs'), (1, ?, 'prayer', 'pry')", date, date)
+	if err != nil {
+		t.Fatalf("failed to insert journal sections: %v", err)
+	}
 
 	t.Run("Existing SOAP data", func(t *testing.T) {
 		data, err := s.GetSOAPData(ctx, 1, date)
 		if err != nil {
 			t.Errorf("expected no error, got %v", err)
 		}
-		if data.Observation != "obs" {
+		if data.Sections["observation"] != "obs" || data.Sections["prayer"] != "pry" || len(data.Sections) != 2 {
 			t.Errorf("unexpected soap data: %+v", data)
 		}
+		if !slices.Equal(data.SelectedVerses, selectedVerses) {
+			t.Errorf("unexpected selected verses: %v", data.SelectedVerses)
+		}
 	})
 
 	t.Run("Non-existent SOAP data", func(t *testing.T) {
@@ -154,7 +443,7 @@ func TestStore_GetSOAPData(t *testing.T) {
 		if err != nil {
 			t.Errorf("expected no error, got %v", err)
 		}
-		if data.Observation != "" {
+		if len(data.Sections) != 0 || data.SelectedVerses == nil {
 			t.Errorf("expected empty soap data, got %+v", data)
 		}
 	})
@@ -172,9 +461,7 @@ func TestStore_SaveSOAPData(t *testing.T) {
 
 	soapData := &store.SOAPData{
 		Date:           "2026-02-18",
-		Observation:    "new-obs",
-		Application:    "new-app",
-		Prayer:         "new-pry",
+		Sections:       map[string]string{"observation": "new-obs", "application": "new-app", "prayer": "new-pry"},
 		SelectedVerses: []string{"John 3:16"},
 	}
 
@@ -183,12 +470,204 @@ func TestStore_SaveSOAPData(t *testing.T) {
 		t.Errorf("expected no error, got %v", err)
 	}
 
-	// Verify update
-	soapData.Observation = "updated-obs"
+	// Verify update: changed sections are replaced, emptied sections removed and omitted sections kept.
+	soapData.Sections = map[string]string{"observation": "updated-obs", "application": "", "adoration": "praise"}
 	err = s.SaveSOAPData(ctx, 1, soapData)
 	if err != nil {
 		t.Errorf("expected no error on update, got %v", err)
 	}
+
+	got, err := s.GetSOAPData(ctx, 1, "2026-02-18")
+	if err != nil {
+		t.Fatalf("GetSOAPData failed: %v", err)
+	}
+	want := map[string]string{"observation": "updated-obs", "prayer": "new-pry", "adoration": "praise"}
+	if !maps.Equal(got.Sections, want) {
+		t.Errorf("sections = %v, want %v", got.Sections, want)
+	}
+}
+
+func TestStore_SaveEntryStamp(t *testing.T) {
+	db := setupTestDB(t)
+	s := New(db)
+	ctx := context.Background()
+
+	if err := s.SaveEntryStamp(ctx, 1, "2026-02-18", "Bethlehem, PA", "Clear sky, 3°C (37°F)"); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("SaveEntryStamp of an unsaved entry = %v, want sql.ErrNoRows", err)
+	}
+	entry := &store.SOAPData{Date: "2026-02-18", Sections: map[string]string{"observation": "obs"}}
+	if err := s.SaveSOAPData(ctx, 1, entry); err != nil {
+		t.Fatalf("SaveSOAPData failed: %v", err)
+	}
+	if err := s.SaveEntryStamp(ctx, 1, "2026-02-18", "Bethlehem, PA", "Clear sky, 3°C (37°F)"); err != nil {
+		t.Fatalf("SaveEntryStamp failed: %v", err)
+	}
+
+	// Saving the entry again keeps its stamp.
+	entry.Sections["observation"] = "edited"
+	if err := s.SaveSOAPData(ctx, 1, entry); err != nil {
+		t.Fatalf("SaveSOAPData failed: %v", err)
+	}
+	got, err := s.GetSOAPData(ctx, 1, "2026-02-18")
+	if err != nil {
+		t.Fatalf("GetSOAPData failed: %v", err)
+	}
+	if want := "Bethlehem, PA · Clear sky, 3°C (37°F)"; got.Stamp() != want {
+		t.Errorf("stamp = %q, want %q", got.Stamp(), want)
+	}
+	recent, err := s.ListRecentSOAPData(ctx, 1, 10, 0)
+	if err != nil || len(recent) != 1 || recent[0].Location != "Bethlehem, PA" {
+		t.Errorf("ListRecentSOAPData = %v, %v; want the stamped entry", recent, err)
+	}
+}
+
+func TestStore_ListSOAPData(t *testing.T) {
+	db := setupTestDB(t)
+	s := New(db)
+	ctx := context.Background()
+
+	for _, entry := range []*store.SOAPData{
+		{Date: "2026-02-18", Sections: map[string]string{"observation": "later"}, SelectedVerses: []string{"John 3:16"}},
+		{Date: "2026-02-17", Sections: map[string]string{"prayer": "earlier", "adoration": "praise"}},
+	} {
+		if err := s.SaveSOAPData(ctx, 1, entry); err != nil {
+			t.Fatalf("SaveSOAPData failed: %v", err)
+		}
+	}
+	if err := s.SaveSOAPData(ctx, 2, &store.SOAPData{Date: "2026-02-16", Sections: map[string]string{"prayer": "other"}}); err != nil {
+		t.Fatalf("SaveSOAPData failed: %v", err)
+	}
+
+	entries, err := s.ListSOAPData(ctx, 1)
+	if err != nil {
+		t.Fatalf("ListSOAPData failed: %v", err)
+	}
+	if len(entries) != 2 || entries[0].Date != "2026-02-17" || entries[1].Date != "2026-02-18" {
+		t.Fatalf("entries = %v, want 2026-02-17 and 2026-02-18", entries)
+	}
+	if want := map[string]string{"prayer": "earlier", "adoration": "praise"}; !maps.Equal(entries[0].Sections, want) {
+		t.Errorf("sections = %v, want %v", entries[0].Sections, want)
+	}
+	if !slices.Equal(entries[1].SelectedVerses, []string{"John 3:16"}) {
+		t.Errorf("selected verses = %v, want [John 3:16]", entries[1].SelectedVerses)
+	}
+}
+
+func TestStore_ListRecentSOAPData(t *testing.T) {
+	db := setupTestDB(t)
+	s := New(db)
+	ctx := context.Background()
+
+	for _, entry := range []*store.SOAPData{
+		{Date: "2026-02-15", Sections: map[string]string{"observation": "oldest"}},
+		{Date: "2026-02-16", Sections: map[string]string{"observation": "older"}},
+		{Date: "2026-02-17", Sections: map[string]string{"observation": ""}, SelectedVerses: []string{"John 3:16"}},
+		{Date: "2026-02-18", Sections: map[string]string{"observation": "newest", "prayer": "amen"}},
+	} {
+		if err := s.SaveSOAPData(ctx, 1, entry); err != nil {
+			t.Fatalf("SaveSOAPData failed: %v", err)
+		}
+	}
+	if err := s.SaveSOAPData(ctx, 2, &store.SOAPData{Date: "2026-02-19", Sections: map[string]string{"prayer": "other"}}); err != nil {
+		t.Fatalf("SaveSOAPData failed: %v", err)
+	}
+
+	dates := func(entries []*store.SOAPData) []string {
+		var d []string
+		for _, e := range entries {
+			d = append(d, e.Date)
+		}
+		return d
+	}
+	// An entry with nothing written in it is left out.
+	page, err := s.ListRecentSOAPData(ctx, 1, 2, 0)
+	if err != nil {
+		t.Fatalf("ListRecentSOAPData failed: %v", err)
+	}
+	if got := dates(page); !slices.Equal(got, []string{"2026-02-18", "2026-02-16"}) {
+		t.Fatalf("first page = %v, want 2026-02-18, 2026-02-16", got)
+	}
+	if want := map[string]string{"observation": "newest", "prayer": "amen"}; !maps.Equal(page[0].Sections, want) {
+		t.Errorf("sections = %v, want %v", page[0].Sections, want)
+	}
+
+	page, err = s.ListRecentSOAPData(ctx, 1, 2, 2)
+	if err != nil {
+		t.Fatalf("ListRecentSOAPData failed: %v", err)
+	}
+	if got := dates(page); !slices.Equal(got, []string{"2026-02-15"}) {
+		t.Errorf("second page = %v, want 2026-02-15", got)
+	}
+}
+
+func TestStore_SearchSOAPData(t *testing.T) {
+	db := setupTestDB(t)
+	s := New(db)
+	ctx := context.Background()
+
+	for _, entry := range []*store.SOAPData{
+		{Date: "2026-03-01", Sections: map[string]string{"observation": "Grace upon grace"}},
+		{Date: "2026-03-02", Sections: map[string]string{"prayer": "for more GRACE"}},
+		{Date: "2026-03-03", Sections: map[string]string{"application": "100% sure"}},
+		{Date: "2026-03-04", Sections: map[string]string{"application": "1000 sure"}},
+	} {
+		if err := s.SaveSOAPData(ctx, 1, entry); err != nil {
+			t.Fatalf("SaveSOAPData failed: %v", err)
+		}
+	}
+	if err := s.SaveSOAPData(ctx, 2, &store.SOAPData{Date: "2026-03-05", Sections: map[string]string{"prayer": "grace"}}); err != nil {
+		t.Fatalf("SaveSOAPData failed: %v", err)
+	}
+
+	tests := []struct {
+		query string
+		limit int
+		want  []string
+	}{
+		{"grace", 10, []string{"2026-03-02", "2026-03-01"}},
+		{"grace", 1, []string{"2026-03-02"}},
+		{"100%", 10, []string{"2026-03-03"}},
+		{"mercy", 10, nil},
+	}
+	for _, tt := range tests {
+		entries, err := s.SearchSOAPData(ctx, 1, tt.query, tt.limit)
+		if err != nil {
+			t.Fatalf("SearchSOAPData(%q) failed: %v", tt.query, err)
+		}
+		var got []string
+		for _, e := range entries {
+			got = append(got, e.Date)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("SearchSOAPData(%q, %d) = %v, want %v", tt.query, tt.limit, got, tt.want)
+		}
+	}
+}
+
+func TestStore_GetJournaledDates(t *testing.T) {
+	db := setupTestDB(t)
+	s := New(db)
+	ctx := context.Background()
+
+	for _, d := range []*store.SOAPData{
+		{Date: "2026-02-15", Sections: map[string]string{"observation": "before range"}},
+		{Date: "2026-02-16", Sections: map[string]string{"prayer": "in range"}},
+		{Date: "2026-02-17", Sections: map[string]string{"observation": ""}},
+		{Date: "2026-02-18", Sections: map[string]string{"application": "in range", "prayer": "twice"}},
+		{Date: "2026-02-23", Sections: map[string]string{"observation": "after range"}},
+	} {
+		if err := s.SaveSOAPData(ctx, 1, d); err != nil {
+			t.Fatalf("SaveSOAPData failed: %v", err)
+		}
+	}
+
+	dates, err := s.GetJournaledDates(ctx, 1, "2026-02-16", "2026-02-22")
+	if err != nil {
+		t.Fatalf("GetJournaledDates failed: %v", err)
+	}
+	if !slices.Equal(dates, []string{"2026-02-16", "2026-02-18"}) {
+		t.Errorf("unexpected dates: %v", dates)
+	}
 }
 
 func TestStore_UserOperations(t *testing.T) {
@@ -278,6 +757,32 @@ func TestStore_SessionOperations(t *testing.T) {
 			t.Error("expected token-expired to be gone")
 		}
 	})
+
+	t.Run("Session activity", func(t *testing.T) {
+		before := time.Now().Add(-time.Second)
+		if err := s.CreateSession(ctx, "token-2", 1, time.Now().Add(time.Hour)); err != nil {
+			t.Fatalf("CreateSession failed: %v", err)
+		}
+		lastActive, err := s.GetSessionActivity(ctx, "token-2")
+		if err != nil {
+			t.Fatalf("GetSessionActivity failed: %v", err)
+		}
+		if lastActive.Before(before) {
+			t.Errorf("new session last active at %v, want after %v", lastActive, before)
+		}
+
+		at := time.Now().Add(-time.Hour).Round(time.Second)
+		if err := s.TouchSession(ctx, "token-2", at); err != nil {
+			t.Fatalf("TouchSession failed: %v", err)
+		}
+		if lastActive, err := s.GetSessionActivity(ctx, "token-2"); err != nil || !lastActive.Equal(at) {
+			t.Errorf("GetSessionActivity() = %v, %v; want %v", lastActive, err, at)
+		}
+
+		if _, err := s.GetSessionActivity(ctx, "no-such-token"); err == nil {
+			t.Error("expected error for unknown session")
+		}
+	})
 }
 
 func TestStore_PasswordResetOperations(t *testing.T) {
@@ -369,13 +874,181 @@ func TestStore_ESVCache(t *testing.T) {
 	if err != nil {
 		t.Errorf("expected no error, got %v", err)
 	}
-	content, err := s.GetCachedESV(ctx, "John 1:1")
+	content, err := s.GetCachedESV(ctx, "John 1:1", time.Hour)
 	if err != nil {
 		t.Errorf("expected no error, got %v", err)
 	}
 	if content != "In the beginning..." {
 		t.Errorf("expected content, got %s", content)
 	}
+
+	var size int
+	if err := db.QueryRow("SELECT size_bytes FROM esv_cache WHERE reference = 'John 1:1'").Scan(&size); err != nil {
+		t.Fatalf("failed to query size: %v", err)
+	}
+	if size != len("In the beginning...") {
+		t.Errorf("expected size %d, got %d", len("In the beginning..."), size)
+	}
+}
+
+func TestStore_EvictCache(t *testing.T) {
+	db := setupTestDB(t)
+	s := New(db)
+	ctx := context.Background()
+
+	// Three 100-byte entries, least recently used first.
+	for _, e := range []struct{ ref, ago string }{{"a", "-3 hours"}, {"b", "-2 hours"}, {"c", "-1 hours"}} {
+		_, err := db.Exec(`INSERT INTO esv_cache (reference, content, size_bytes, last_accessed) VALUES (?, 'x', 100, datetime('now', ?))`, e.ref, e.ago)
+		if err != nil {
+			t.Fatalf("failed to insert cache entry: %v", err)
+		}
+	}
+	// Reading "a" makes it the most recently used.
+	if _, err := s.GetCachedESV(ctx, "a", time.Hour); err != nil {
+		t.Fatalf("GetCachedESV failed: %v", err)
+	}
+
+	n, err := s.EvictCache(ctx, 150, 10)
+	if err != nil {
+		t.Fatalf("EvictCache failed: %v", err)
+	}
+	if n != 2 {
+		t.Errorf("expected 2 entries evicted, got %d", n)
+	}
+	var remaining string
+	if err := db.QueryRow("SELECT group_concat(reference) FROM esv_cache").Scan(&remaining); err != nil {
+		t.Fatalf("failed to query remaining entries: %v", err)
+	}
+	if remaining != "a" {
+		t.Errorf("expected only the recently read entry to remain, got %q", remaining)
+	}
+
+	if n, err := s.EvictCache(ctx, 150, 10); err != nil || n != 0 {
+		t.Errorf("EvictCache under the limit = %d, %v; want 0, nil", n, err)
+	}
+}
+
+func TestStore_GetCachedESV_ThrottlesAccessTime(t *testing.T) {
+	db := setupTestDB(t)
+	s := New(db)
+	ctx := context.Background()
+
+	for _, e := range []struct{ ref, ago string }{{"recent", "-10 minutes"}, {"stale", "-2 hours"}} {
+		_, err := db.Exec(`INSERT INTO esv_cache (reference, content, size_bytes, last_accessed) VALUES (?, 'x', 1, datetime('now', ?))`, e.ref, e.ago)
+		if err != nil {
+			t.Fatalf("failed to insert cache entry: %v", err)
+		}
+	}
+	if _, err := db.Exec(`INSERT INTO esv_cache (reference, content, size_bytes) VALUES ('never', 'x', 1)`); err != nil {
+		t.Fatalf("failed to insert cache entry: %v", err)
+	}
+
+	for _, ref := range []string{"recent", "stale", "never"} {
+		if _, err := s.GetCachedESV(ctx, ref, time.Hour); err != nil {
+			t.Fatalf("GetCachedESV(%s) failed: %v", ref, err)
+		}
+	}
+
+	var touched string
+	if err := db.QueryRow(`SELECT group_concat(reference) FROM (SELECT reference FROM esv_cache WHERE last_accessed > datetime('now', '-1 minute') ORDER BY reference)`).Scan(&touched); err != nil {
+		t.Fatalf("failed to query touched entries: %v", err)
+	}
+	if touched != "never,stale" {
+		t.Errorf("entries with updated access times = %q, want only those not accessed within the hour", touched)
+	}
+}
+
+func TestStore_GetCachedESVBatch(t *testing.T) {
+	db := setupTestDB(t)
+	s := New(db)
+	ctx := context.Background()
+
+	for _, e := range []struct{ ref, content, ago string }{{"recent", "r", "-10 minutes"}, {"stale", "s", "-2 hours"}} {
+		_, err := db.Exec(`INSERT INTO esv_cache (reference, content, size_bytes, last_accessed) VALUES (?, ?, 1, datetime('now', ?))`, e.ref, e.content, e.ago)
+		if err != nil {
+			t.Fatalf("failed to insert cache entry: %v", err)
+		}
+	}
+
+	got, err := s.GetCachedESVBatch(ctx, []string{"recent", "stale", "missing"}, time.Hour)
+	if err != nil {
+		t.Fatalf("GetCachedESVBatch failed: %v", err)
+	}
+	if len(got) != 2 || got["recent"] != "r" || got["stale"] != "s" {
+		t.Errorf("GetCachedESVBatch = %v, want only the two cached entries", got)
+	}
+
+	var touched string
+	if err := db.QueryRow(`SELECT group_concat(reference) FROM esv_cache WHERE last_accessed > datetime('now', '-1 minute')`).Scan(&touched); err != nil {
+		t.Fatalf("failed to query touched entries: %v", err)
+	}
+	if touched != "stale" {
+		t.Errorf("entries with updated access times = %q, want only the stale one", touched)
+	}
+
+	if got, err := s.GetCachedESVBatch(ctx, nil, time.Hour); err != nil || len(got) != 0 {
+		t.Errorf("GetCachedESVBatch(nil) = %v, %v; want empty, nil", got, err)
+	}
+}
+
+func TestStore_ESVCache_MaxAgeAndDelete(t *testing.T) {
+	db := setupTestDB(t)
+	s := New(db)
+	ctx := context.Background()
+
+	for _, e := range []struct{ ref, ago string }{{"fresh", "-1 hours"}, {"old", "-3 days"}} {
+		_, err := db.Exec(`INSERT INTO esv_cache (reference, content, size_bytes, created_at) VALUES (?, 'x', 1, datetime('now', ?))`, e.ref, e.ago)
+		if err != nil {
+			t.Fatalf("failed to insert cache entry: %v", err)
+		}
+	}
+
+	if _, err := s.GetCachedESV(ctx, "fresh", 24*time.Hour); err != nil {
+		t.Errorf("GetCachedESV(fresh) failed: %v", err)
+	}
+	if _, err := s.GetCachedESV(ctx, "old", 24*time.Hour); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("GetCachedESV(old) error = %v, want sql.ErrNoRows", err)
+	}
+	got, err := s.GetCachedESVBatch(ctx, []string{"fresh", "old"}, 24*time.Hour)
+	if err != nil {
+		t.Fatalf("GetCachedESVBatch failed: %v", err)
+	}
+	if len(got) != 1 || got["fresh"] != "x" {
+		t.Errorf("GetCachedESVBatch = %v, want only the fresh entry", got)
+	}
+
+	if err := s.DeleteCachedESV(ctx, []string{"fresh", "missing"}); err != nil {
+		t.Fatalf("DeleteCachedESV failed: %v", err)
+	}
+	var remaining string
+	if err := db.QueryRow("SELECT group_concat(reference) FROM esv_cache").Scan(&remaining); err != nil {
+		t.Fatalf("failed to query remaining entries: %v", err)
+	}
+	if remaining != "old" {
+		t.Errorf("entries after DeleteCachedESV = %q, want old", remaining)
+	}
+}
+
+func TestStore_DeleteCachedESVVariants(t *testing.T) {
+	db := setupTestDB(t)
+	s := New(db)
+	ctx := context.Background()
+
+	for _, ref := range []string{"John 3:16", "John 3:16|WEB", "John 3:16;Psalm 23", "John 3:16-17|WEB", "Psalm 23"} {
+		if err := s.SaveCachedESV(ctx, ref, "x"); err != nil {
+			t.Fatalf("SaveCachedESV(%q) failed: %v", ref, err)
+		}
+	}
+	if err := s.DeleteCachedESVVariants(ctx, "John 3:16"); err != nil {
+		t.Fatalf("DeleteCachedESVVariants failed: %v", err)
+	}
+	var remaining string
+	if err := db.QueryRow("SELECT group_concat(reference, ',') FROM (SELECT reference FROM esv_cache ORDER BY reference)").Scan(&remaining); err != nil {
+		t.Fatalf("failed to query remaining entries: %v", err)
+	}
+	if want := "John 3:16-17|WEB,John 3:16;Psalm 23,Psalm 23"; remaining != want {
+		t.Errorf("entries after DeleteCachedESVVariants = %q, want %q", remaining, want)
+	}
 }
 
 func TestStore_QueueEmail(t *testing.T) {
@@ -501,3 +1174,132 @@ func TestStore_MarkEmailSent(t *testing.T) {
 		t.Error("expected last_attempt_at to be set")
 	}
 }
+
+func TestStore_EmailSuppressions(t *testing.T) {
+	db := setupTestDB(t)
+	s := New(db)
+	ctx := context.Background()
+
+	if err := s.SuppressEmail(ctx, "Gone@Example.com", "bounce"); err != nil {
+		t.Fatalf("SuppressEmail failed: %v", err)
+	}
+	if err := s.SuppressEmail(ctx, "gone@example.com", "complaint"); err != nil {
+		t.Fatalf("SuppressEmail again failed: %v", err)
+	}
+
+	suppressed, err := s.IsEmailSuppressed(ctx, "GONE@example.com")
+	if err != nil {
+		t.Fatalf("IsEmailSuppressed failed: %v", err)
+	}
+	if !suppressed {
+		t.Error("expected address to be suppressed regardless of case")
+	}
+
+	suppressions, err := s.ListEmailSuppressions(ctx)
+	if err != nil {
+		t.Fatalf("ListEmailSuppressions failed: %v", err)
+	}
+	if len(suppressions) != 1 || suppressions[0].Reason != "complaint" {
+		t.Fatalf("expected one suppression with the latest reason, got %+v", suppressions)
+	}
+
+	if err := s.UnsuppressEmail(ctx, "gone@example.com"); err != nil {
+		t.Fatalf("UnsuppressEmail failed: %v", err)
+	}
+	if suppressed, _ := s.IsEmailSuppressed(ctx, "gone@example.com"); suppressed {
+		t.Error("expected address to be allowed after unsuppressing")
+	}
+}
+
+func TestStore_RecentEmails(t *testing.T) {
+	db := setupTestDB(t)
+	s := New(db)
+	ctx := context.Background()
+
+	_, _ = db.Exec("INSERT INTO users (id, email, password_hash) VALUES (1, 'u@example.com', 'h')")
+	for id := 1; id <= 3; id++ {
+		_, _ = db.Exec("INSERT INTO queued_emails (id, user_id, recipient, subject, body_html) VALUES (?, 1, 'u@example.com', 'subject', 'body')", id)
+	}
+
+	if err := s.MarkEmailSuppressed(ctx, 2); err != nil {
+		t.Fatalf("MarkEmailSuppressed failed: %v", err)
+	}
+
+	emails, err := s.ListRecentEmails(ctx, 2)
+	if err != nil {
+		t.Fatalf("ListRecentEmails failed: %v", err)
+	}
+	if len(emails) != 2 || emails[0].ID != 3 || emails[1].ID != 2 {
+		t.Fatalf("expected the two newest emails, got %+v", emails)
+	}
+	if emails[1].Status != "suppressed" {
+		t.Errorf("expected status 'suppressed', got %s", emails[1].Status)
+	}
+	if emails[0].BodyHTML != "" {
+		t.Error("expected bodies to be left out of the listing")
+	}
+}
+
+func TestStore_JournalingStats(t *testing.T) {
+	db := setupTestDB(t)
+	s := New(db)
+	ctx := context.Background()
+
+	_, _ = db.Exec("INSERT INTO users (id, email, password_hash) VALUES (1, 'u@example.com', 'h')")
+
+	save := func(date string) {
+		t.Helper()
+		data := &store.SOAPData{Date: date, Sections: map[string]string{"observation": "o"}, SelectedVerses: []string{}}
+		if err := s.SaveSOAPData(ctx, 1, data); err != nil {
+			t.Fatalf("SaveSOAPData(%s) failed: %v", date, err)
+		}
+	}
+	// backdate moves an entry's last save into the past, as if the user had paused for that long.
+	backdate := func(date, ago string) {
+		t.Helper()
+		if _, err := db.Exec(`UPDATE journal SET finished_at = datetime('now', ?) WHERE user_id = 1 AND date = ?`, ago, date); err != nil {
+			t.Fatalf("failed to backdate %s: %v", date, err)
+		}
+	}
+
+	save("2026-03-01")
+	backdate("2026-03-01", "-120 seconds")
+	save("2026-03-01")
+	backdate("2026-03-01", "-60 seconds")
+	save("2026-03-01")
+
+	save("2026-03-02")
+	backdate("2026-03-02", "-240 seconds")
+	save("2026-03-02")
+	// A pause longer than editingIdleGap is time away from the entry and is not counted.
+	backdate("2026-03-02", "-1 hour")
+	save("2026-03-02")
+
+	// Entries saved before timing was recorded have no start time and are left out.
+	if _, err := db.Exec(`INSERT INTO journal (user_id, date) VALUES (1, '2026-02-01')`); err != nil {
+		t.Fatalf("failed to insert untimed entry: %v", err)
+	}
+
+	stats, err := s.GetJournalingStats(ctx, 1, "")
+	if err != nil {
+		t.Fatalf("GetJournalingStats failed: %v", err)
+	}
+	// Allow a second of slack per save for the clock ticking during the test.
+	if stats.Entries != 2 || stats.Total < 7*time.Minute || stats.Total > 7*time.Minute+5*time.Second {
+		t.Errorf("stats = %+v, want 2 entries totaling 7m", stats)
+	}
+	if stats.Longest < 4*time.Minute || stats.Longest > 4*time.Minute+5*time.Second {
+		t.Errorf("Longest = %v, want 4m", stats.Longest)
+	}
+	if avg := stats.Average(); avg != stats.Total/2 {
+		t.Errorf("Average = %v, want %v", avg, stats.Total/2)
+	}
+
+	recent, err := s.GetJournalingStats(ctx, 1, "2026-03-02")
+	if err != nil {
+		t.Fatalf("GetJournalingStats failed: %v", err)
+	}
+	if recent.Entries != 1 || recent.Longest != stats.Longest {
+		t.Errorf("recent stats = %+v, want only the 2026-03-02 entry", recent)
+	}
+}
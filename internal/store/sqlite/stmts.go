@@ -0,0 +1,74 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+)
+
+// stmtCache holds prepared statements for the queries run on every page view, keyed by their SQL, so
+// that SQLite parses and plans each of them once rather than on every request. The statements are
+// prepared on the database rather than a connection; database/sql prepares them again on each
+// connection that runs them. Like the database, they stay open for the life of the process.
+type stmtCache struct {
+	mu    sync.RWMutex
+	stmts map[string]*sql.Stmt
+}
+
+// stmt returns the prepared statement for query, preparing it on first use.
+func (c *stmtCache) stmt(ctx context.Context, db *sql.DB, query string) (*sql.Stmt, error) {
+	c.mu.RLock()
+	stmt, ok := c.stmts[query]
+	c.mu.RUnlock()
+	if ok {
+		return stmt, nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if stmt, ok := c.stmts[query]; ok {
+		return stmt, nil
+	}
+	// The statement outlives the request that prepares it, so it must not be closed when ctx is.
+	stmt, err := db.PrepareContext(context.WithoutCancel(ctx), query)
+	if err != nil {
+		return nil, err
+	}
+	if c.stmts == nil {
+		c.stmts = make(map[string]*sql.Stmt)
+	}
+	c.stmts[query] = stmt
+	return stmt, nil
+}
+
+// queryRowCached runs a hot query expected to return at most one row with a prepared statement. A
+// store without a statement cache, or a query that cannot be prepared, runs it unprepared, which
+// reports any error in the query.
+func (s *Store) queryRowCached(ctx context.Context, query string, args ...any) *sql.Row {
+	if s.stmts != nil {
+		if stmt, err := s.stmts.stmt(ctx, s.db, query); err == nil {
+			return stmt.QueryRowContext(ctx, args...)
+		}
+	}
+	return s.db.QueryRowContext(ctx, query, args...)
+}
+
+// queryCached runs a hot query with a prepared statement, like queryRowCached.
+func (s *Store) queryCached(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	if s.stmts != nil {
+		if stmt, err := s.stmts.stmt(ctx, s.db, query); err == nil {
+			return stmt.QueryContext(ctx, args...)
+		}
+	}
+	return s.db.QueryContext(ctx, query, args...)
+}
+
+// execCached runs a hot statement with a prepared statement, like queryRowCached.
+func (s *Store) execCached(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	if s.stmts != nil {
+		if stmt, err := s.stmts.stmt(ctx, s.db, query); err == nil {
+			return stmt.ExecContext(ctx, args...)
+		}
+	}
+	return s.db.ExecContext(ctx, query, args...)
+}
@@ -0,0 +1,103 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"derrclan.com/moravian-soap/internal/migrations"
+	"derrclan.com/moravian-soap/internal/store"
+)
+
+// benchmarkStores returns a store with prepared statements and one without, on a migrated database
+// file that is shared by several connections as in production, with a user who has a month of
+// journal entries and a cached passage.
+func benchmarkStores(b *testing.B) map[string]*Store {
+	b.Helper()
+	ctx := context.Background()
+	db, err := Open(filepath.Join(b.TempDir(), "bench.db"))
+	if err != nil {
+		b.Fatalf("Open failed: %v", err)
+	}
+	b.Cleanup(func() { _ = db.Close() })
+	if err := migrations.Run(ctx, db); err != nil {
+		b.Fatalf("migrations failed: %v", err)
+	}
+
+	s := New(db)
+	if err := s.CreateUser(ctx, "reader@example.com", "hash", "token", "UTC"); err != nil {
+		b.Fatalf("CreateUser failed: %v", err)
+	}
+	for day := 1; day <= 30; day++ {
+		entry := &store.SOAPData{
+			Date:           fmt.Sprintf("2026-04-%02d", day),
+			Sections:       map[string]string{"observation": "Grace upon grace.", "application": "Listen.", "prayer": "Amen."},
+			SelectedVerses: []string{"43001001"},
+		}
+		if err := s.SaveSOAPData(ctx, 1, entry); err != nil {
+			b.Fatalf("SaveSOAPData failed: %v", err)
+		}
+	}
+	if err := s.SaveCachedESV(ctx, "John 1:1", "<p>In the beginning was the Word.</p>"); err != nil {
+		b.Fatalf("SaveCachedESV failed: %v", err)
+	}
+	return map[string]*Store{"unprepared": {db: db}, "prepared": s}
+}
+
+// benchmarkQuery runs query in parallel on the store with and without prepared statements.
+func benchmarkQuery(b *testing.B, query func(ctx context.Context, s *Store) error) {
+	stores := benchmarkStores(b)
+	for _, name := range []string{"unprepared", "prepared"} {
+		s := stores[name]
+		b.Run(name, func(b *testing.B) {
+			ctx := context.Background()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					if err := query(ctx, s); err != nil {
+						b.Error(err)
+						return
+					}
+				}
+			})
+		})
+	}
+}
+
+func BenchmarkGetUserFromSession(b *testing.B) {
+	benchmarkQuery(b, func(ctx context.Context, s *Store) error {
+		_, err := s.GetUserFromSession(ctx, "no-such-token")
+		if err == nil {
+			return fmt.Errorf("found a session that does not exist")
+		}
+		return nil
+	})
+}
+
+func BenchmarkGetSOAPData(b *testing.B) {
+	benchmarkQuery(b, func(ctx context.Context, s *Store) error {
+		_, err := s.GetSOAPData(ctx, 1, "2026-04-15")
+		return err
+	})
+}
+
+func BenchmarkListSOAPData(b *testing.B) {
+	benchmarkQuery(b, func(ctx context.Context, s *Store) error {
+		_, err := s.ListSOAPData(ctx, 1)
+		return err
+	})
+}
+
+func BenchmarkGetCachedESV(b *testing.B) {
+	benchmarkQuery(b, func(ctx context.Context, s *Store) error {
+		_, err := s.GetCachedESV(ctx, "John 1:1", time.Hour)
+		return err
+	})
+}
+
+func BenchmarkSaveCachedESV(b *testing.B) {
+	benchmarkQuery(b, func(ctx context.Context, s *Store) error {
+		return s.SaveCachedESV(ctx, "John 1:2", "<p>He was in the beginning with God.</p>")
+	})
+}
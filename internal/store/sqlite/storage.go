@@ -0,0 +1,79 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+
+	"derrclan.com/moravian-soap/internal/store"
+)
+
+// storageUsageQuery selects the space each user's data takes up, counting the bytes of its text, with
+// the user's own quota. Text is cast to a blob so that its length is in bytes rather than characters.
+const storageUsageQuery = `
+	SELECT u.id, u.email,
+		COALESCE((SELECT SUM(LENGTH(CAST(content AS BLOB))) FROM journal_sections WHERE user_id = u.id), 0)
+			+ COALESCE((SELECT SUM(LENGTH(CAST(COALESCE(selected_verses, '') AS BLOB)) + LENGTH(CAST(location AS BLOB)) + LENGTH(CAST(weather AS BLOB)))
+				FROM journal WHERE user_id = u.id), 0) AS entries,
+		COALESCE((SELECT SUM(LENGTH(CAST(reference AS BLOB)) + LENGTH(CAST(sections AS BLOB))) FROM passage_entries WHERE user_id = u.id), 0) AS passage_entries,
+		COALESCE((SELECT SUM(LENGTH(CAST(note AS BLOB))) FROM verse_notes WHERE user_id = u.id), 0)
+			+ COALESCE((SELECT SUM(LENGTH(CAST(text AS BLOB))) FROM prayer_items WHERE user_id = u.id), 0) AS notes,
+		COALESCE((SELECT SUM(LENGTH(html)) FROM yearbooks WHERE user_id = u.id), 0) AS yearbooks,
+		COALESCE(q.quota_bytes, 0)
+	FROM users u
+	LEFT JOIN storage_quotas q ON q.user_id = u.id
+`
+
+// scanStorageUsage scans a row selected by storageUsageQuery.
+func scanStorageUsage(row interface{ Scan(...any) error }) (*store.StorageUsage, error) {
+	var u store.StorageUsage
+	if err := row.Scan(&u.UserID, &u.Email, &u.Entries, &u.PassageEntries, &u.Notes, &u.Yearbooks, &u.Quota); err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+// GetStorageUsage retrieves the space a user's data takes up. It returns sql.ErrNoRows if there is no
+// such user.
+func (s *Store) GetStorageUsage(ctx context.Context, userID int64) (*store.StorageUsage, error) {
+	u, err := scanStorageUsage(s.db.QueryRowContext(ctx, storageUsageQuery+` WHERE u.id = ?`, userID))
+	if err != nil {
+		return nil, fmt.Errorf("getting storage usage of user %d: %w", userID, err)
+	}
+	return u, nil
+}
+
+// ListStorageUsage retrieves the space each user's data takes up, the most first.
+func (s *Store) ListStorageUsage(ctx context.Context) ([]*store.StorageUsage, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT * FROM (`+storageUsageQuery+`) ORDER BY entries + passage_entries + notes + yearbooks DESC, email`)
+	if err != nil {
+		return nil, fmt.Errorf("listing storage usage: %w", err)
+	}
+	defer rows.Close()
+
+	var usage []*store.StorageUsage
+	for rows.Next() {
+		u, err := scanStorageUsage(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scanning storage usage: %w", err)
+		}
+		usage = append(usage, u)
+	}
+	return usage, rows.Err()
+}
+
+// SetStorageQuota sets a user's own limit on their data, in bytes, or removes it if quota is 0.
+func (s *Store) SetStorageQuota(ctx context.Context, userID int64, quota int64) error {
+	var err error
+	if quota == 0 {
+		_, err = s.db.ExecContext(ctx, `DELETE FROM storage_quotas WHERE user_id = ?`, userID)
+	} else {
+		_, err = s.db.ExecContext(ctx, `
+			INSERT INTO storage_quotas (user_id, quota_bytes, updated_at) VALUES (?, ?, CURRENT_TIMESTAMP)
+			ON CONFLICT(user_id) DO UPDATE SET quota_bytes = excluded.quota_bytes, updated_at = excluded.updated_at
+		`, userID, quota)
+	}
+	if err != nil {
+		return fmt.Errorf("setting storage quota of user %d: %w", userID, err)
+	}
+	return nil
+}
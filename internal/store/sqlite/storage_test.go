@@ -0,0 +1,67 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"derrclan.com/moravian-soap/internal/store"
+)
+
+func TestStore_StorageUsage(t *testing.T) {
+	db := setupTestDB(t)
+	s := New(db)
+	ctx := context.Background()
+	if _, err := db.Exec(`INSERT INTO users (id, email, password_hash) VALUES (1, 'reader@example.com', 'x'), (2, 'writer@example.com', 'x')`); err != nil {
+		t.Fatalf("inserting users: %v", err)
+	}
+
+	// "é" is two bytes, so usage is counted in bytes rather than characters.
+	entry := &store.SOAPData{Date: "2026-03-07", Sections: map[string]string{"observation": "Café"}, SelectedVerses: []string{"45008001"}}
+	if err := s.SaveSOAPData(ctx, 2, entry); err != nil {
+		t.Fatalf("SaveSOAPData failed: %v", err)
+	}
+	if err := s.SaveVerseNote(ctx, 2, "45008001", "Free."); err != nil {
+		t.Fatalf("SaveVerseNote failed: %v", err)
+	}
+	if err := s.CreatePrayerItem(ctx, &store.PrayerItem{UserID: 2, Text: "Rain", Status: store.PrayerOpen, CreatedDate: "2026-03-07"}); err != nil {
+		t.Fatalf("CreatePrayerItem failed: %v", err)
+	}
+	if err := s.QueueYearbook(ctx, 2, 2025); err != nil {
+		t.Fatalf("QueueYearbook failed: %v", err)
+	}
+	if err := s.SaveYearbook(ctx, 2, 2025, make([]byte, 100), ""); err != nil {
+		t.Fatalf("SaveYearbook failed: %v", err)
+	}
+
+	u, err := s.GetStorageUsage(ctx, 2)
+	if err != nil {
+		t.Fatalf("GetStorageUsage failed: %v", err)
+	}
+	want := store.StorageUsage{UserID: 2, Email: "writer@example.com", Entries: int64(len("Café") + len(`["45008001"]`)), Notes: 9, Yearbooks: 100}
+	if *u != want {
+		t.Errorf("GetStorageUsage = %+v, want %+v", *u, want)
+	}
+	if _, err := s.GetStorageUsage(ctx, 3); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("GetStorageUsage of no user: err = %v, want sql.ErrNoRows", err)
+	}
+
+	if err := s.SetStorageQuota(ctx, 2, 1<<20); err != nil {
+		t.Fatalf("SetStorageQuota failed: %v", err)
+	}
+	usage, err := s.ListStorageUsage(ctx)
+	if err != nil || len(usage) != 2 || usage[0].UserID != 2 || usage[0].Quota != 1<<20 || usage[1].Total() != 0 {
+		t.Fatalf("ListStorageUsage = %+v, %v; want the writer first, with their quota", usage, err)
+	}
+
+	if err := s.DeleteYearbooks(ctx, 2); err != nil {
+		t.Fatalf("DeleteYearbooks failed: %v", err)
+	}
+	if err := s.SetStorageQuota(ctx, 2, 0); err != nil {
+		t.Fatalf("SetStorageQuota(0) failed: %v", err)
+	}
+	if u, err := s.GetStorageUsage(ctx, 2); err != nil || u.Yearbooks != 0 || u.Quota != 0 {
+		t.Errorf("after cleaning up, GetStorageUsage = %+v, %v; want no yearbooks or quota", u, err)
+	}
+}
@@ -0,0 +1,63 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"derrclan.com/moravian-soap/internal/store"
+)
+
+// GetVerseNotes retrieves a user's notes on the verses in refs, in no particular order. Verses without
+// a note are left out.
+func (s *Store) GetVerseNotes(ctx context.Context, userID int64, refs []string) ([]*store.VerseNote, error) {
+	if len(refs) == 0 {
+		return nil, nil
+	}
+	args := []any{userID}
+	for _, ref := range refs {
+		args = append(args, ref)
+	}
+	query := `
+		SELECT verse_ref, note, updated_at
+		FROM verse_notes
+		WHERE user_id = ? AND verse_ref IN (?` + strings.Repeat(", ?", len(refs)-1) + `)
+	`
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("querying verse notes for user %d: %w", userID, err)
+	}
+	defer rows.Close()
+
+	var notes []*store.VerseNote
+	for rows.Next() {
+		var n store.VerseNote
+		if err := rows.Scan(&n.Ref, &n.Note, &n.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("scanning verse note: %w", err)
+		}
+		notes = append(notes, &n)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return notes, nil
+}
+
+// SaveVerseNote sets a user's note on a verse. An empty note removes it.
+func (s *Store) SaveVerseNote(ctx context.Context, userID int64, ref, note string) error {
+	if note == "" {
+		_, err := s.db.ExecContext(ctx, "DELETE FROM verse_notes WHERE user_id = ? AND verse_ref = ?", userID, ref)
+		if err != nil {
+			return fmt.Errorf("deleting note on verse %s: %w", ref, err)
+		}
+		return nil
+	}
+	query := `
+		INSERT INTO verse_notes (user_id, verse_ref, note) VALUES (?, ?, ?)
+		ON CONFLICT(user_id, verse_ref) DO UPDATE SET note = excluded.note, updated_at = CURRENT_TIMESTAMP
+	`
+	if _, err := s.db.ExecContext(ctx, query, userID, ref, note); err != nil {
+		return fmt.Errorf("saving note on verse %s: %w", ref, err)
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package sqlite
+
+import (
+	"context"
+	"testing"
+)
+
+func TestStore_VerseNotes(t *testing.T) {
+	db := setupTestDB(t)
+	s := New(db)
+	ctx := context.Background()
+
+	if err := s.SaveVerseNote(ctx, 1, "43003016", "For God so loved"); err != nil {
+		t.Fatalf("SaveVerseNote failed: %v", err)
+	}
+	if err := s.SaveVerseNote(ctx, 1, "43003016", "The gospel in a verse"); err != nil {
+		t.Fatalf("SaveVerseNote update failed: %v", err)
+	}
+	if err := s.SaveVerseNote(ctx, 2, "43003017", "Another user's note"); err != nil {
+		t.Fatalf("SaveVerseNote failed: %v", err)
+	}
+
+	notes, err := s.GetVerseNotes(ctx, 1, []string{"43003016", "43003017"})
+	if err != nil {
+		t.Fatalf("GetVerseNotes failed: %v", err)
+	}
+	if len(notes) != 1 || notes[0].Ref != "43003016" || notes[0].Note != "The gospel in a verse" {
+		t.Fatalf("expected only the user's updated note, got %+v", notes)
+	}
+
+	if err := s.SaveVerseNote(ctx, 1, "43003016", ""); err != nil {
+		t.Fatalf("SaveVerseNote to clear failed: %v", err)
+	}
+	if notes, _ := s.GetVerseNotes(ctx, 1, []string{"43003016"}); len(notes) != 0 {
+		t.Errorf("expected the note to be removed, got %+v", notes)
+	}
+	if notes, err := s.GetVerseNotes(ctx, 1, nil); err != nil || notes != nil {
+		t.Errorf("GetVerseNotes(nil) = %v, %v; want no notes", notes, err)
+	}
+}
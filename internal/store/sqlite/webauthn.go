@@ -0,0 +1,111 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"derrclan.com/moravian-soap/internal/store"
+)
+
+// SaveWebAuthnChallenge saves the challenge of a passkey ceremony in progress. A user ID of 0 marks a
+// login ceremony, where the user is not known until the passkey is used. Expired challenges are removed.
+func (s *Store) SaveWebAuthnChallenge(ctx context.Context, challenge string, userID int64, expiresAt time.Time) error {
+	if _, err := s.db.ExecContext(ctx, "DELETE FROM webauthn_challenges WHERE expires_at < ?", time.Now()); err != nil {
+		return fmt.Errorf("cleaning up expired WebAuthn challenges: %w", err)
+	}
+	_, err := s.db.ExecContext(ctx, "INSERT INTO webauthn_challenges (challenge, user_id, expires_at) VALUES (?, ?, ?)", challenge, userID, expiresAt)
+	if err != nil {
+		return fmt.Errorf("saving WebAuthn challenge: %w", err)
+	}
+	return nil
+}
+
+// ConsumeWebAuthnChallenge deletes a passkey ceremony challenge and returns the user it was issued to
+// and when it expires. Each challenge can only be used once.
+func (s *Store) ConsumeWebAuthnChallenge(ctx context.Context, challenge string) (userID int64, expiresAt time.Time, err error) {
+	err = s.db.QueryRowContext(ctx, "DELETE FROM webauthn_challenges WHERE challenge = ? RETURNING user_id, expires_at", challenge).Scan(&userID, &expiresAt)
+	if err != nil {
+		return 0, time.Time{}, fmt.Errorf("consuming WebAuthn challenge: %w", err)
+	}
+	return userID, expiresAt, nil
+}
+
+// CreateWebAuthnCredential saves a newly registered passkey and sets its ID.
+func (s *Store) CreateWebAuthnCredential(ctx context.Context, cred *store.WebAuthnCredential) error {
+	query := `
+		INSERT INTO webauthn_credentials (user_id, name, credential_id, public_key, sign_count)
+		VALUES (?, ?, ?, ?, ?)
+	`
+	res, err := s.db.ExecContext(ctx, query, cred.UserID, cred.Name, cred.CredentialID, cred.PublicKey, cred.SignCount)
+	if err != nil {
+		return fmt.Errorf("saving WebAuthn credential for user %d: %w", cred.UserID, err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("getting last insert id: %w", err)
+	}
+	cred.ID = id
+	return nil
+}
+
+// GetWebAuthnCredential retrieves a passkey by the credential ID chosen by the authenticator.
+func (s *Store) GetWebAuthnCredential(ctx context.Context, credentialID []byte) (*store.WebAuthnCredential, error) {
+	query := `
+		SELECT id, user_id, name, credential_id, public_key, sign_count, last_used_at, created_at
+		FROM webauthn_credentials
+		WHERE credential_id = ?
+	`
+	var c store.WebAuthnCredential
+	err := s.db.QueryRowContext(ctx, query, credentialID).Scan(&c.ID, &c.UserID, &c.Name, &c.CredentialID, &c.PublicKey, &c.SignCount, &c.LastUsedAt, &c.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("getting WebAuthn credential: %w", err)
+	}
+	return &c, nil
+}
+
+// ListWebAuthnCredentials retrieves all passkeys belonging to a user, newest first.
+func (s *Store) ListWebAuthnCredentials(ctx context.Context, userID int64) ([]*store.WebAuthnCredential, error) {
+	query := `
+		SELECT id, user_id, name, credential_id, public_key, sign_count, last_used_at, created_at
+		FROM webauthn_credentials
+		WHERE user_id = ?
+		ORDER BY created_at DESC, id DESC
+	`
+	rows, err := s.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("querying WebAuthn credentials for user %d: %w", userID, err)
+	}
+	defer rows.Close()
+
+	var creds []*store.WebAuthnCredential
+	for rows.Next() {
+		var c store.WebAuthnCredential
+		if err := rows.Scan(&c.ID, &c.UserID, &c.Name, &c.CredentialID, &c.PublicKey, &c.SignCount, &c.LastUsedAt, &c.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scanning WebAuthn credential: %w", err)
+		}
+		creds = append(creds, &c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return creds, nil
+}
+
+// UpdateWebAuthnSignCount records a successful login with a passkey.
+func (s *Store) UpdateWebAuthnSignCount(ctx context.Context, id int64, signCount uint32) error {
+	_, err := s.db.ExecContext(ctx, "UPDATE webauthn_credentials SET sign_count = ?, last_used_at = ? WHERE id = ?", signCount, time.Now().UTC(), id)
+	if err != nil {
+		return fmt.Errorf("updating WebAuthn credential %d: %w", id, err)
+	}
+	return nil
+}
+
+// DeleteWebAuthnCredential removes one of a user's passkeys.
+func (s *Store) DeleteWebAuthnCredential(ctx context.Context, userID, id int64) error {
+	_, err := s.db.ExecContext(ctx, "DELETE FROM webauthn_credentials WHERE id = ? AND user_id = ?", id, userID)
+	if err != nil {
+		return fmt.Errorf("deleting WebAuthn credential %d: %w", id, err)
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"derrclan.com/moravian-soap/internal/store"
+)
+
+func TestStore_WebAuthnChallenges(t *testing.T) {
+	db := setupTestDB(t)
+	s := New(db)
+	ctx := context.Background()
+
+	expiresAt := time.Now().Add(5 * time.Minute).UTC().Truncate(time.Second)
+	if err := s.SaveWebAuthnChallenge(ctx, "challenge", 7, expiresAt); err != nil {
+		t.Fatalf("SaveWebAuthnChallenge failed: %v", err)
+	}
+	if err := s.SaveWebAuthnChallenge(ctx, "expired", 0, time.Now().Add(-time.Minute)); err != nil {
+		t.Fatalf("SaveWebAuthnChallenge failed: %v", err)
+	}
+
+	userID, gotExpiry, err := s.ConsumeWebAuthnChallenge(ctx, "challenge")
+	if err != nil {
+		t.Fatalf("ConsumeWebAuthnChallenge failed: %v", err)
+	}
+	if userID != 7 || !gotExpiry.Equal(expiresAt) {
+		t.Errorf("ConsumeWebAuthnChallenge() = %d, %v; want 7, %v", userID, gotExpiry, expiresAt)
+	}
+	if _, _, err := s.ConsumeWebAuthnChallenge(ctx, "challenge"); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("expected sql.ErrNoRows consuming a challenge twice, got %v", err)
+	}
+
+	// Saving another challenge cleans up the expired one.
+	if err := s.SaveWebAuthnChallenge(ctx, "another", 0, expiresAt); err != nil {
+		t.Fatalf("SaveWebAuthnChallenge failed: %v", err)
+	}
+	if _, _, err := s.ConsumeWebAuthnChallenge(ctx, "expired"); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("expected expired challenge to be removed, got %v", err)
+	}
+}
+
+func TestStore_WebAuthnCredentials(t *testing.T) {
+	db := setupTestDB(t)
+	s := New(db)
+	ctx := context.Background()
+
+	phone := &store.WebAuthnCredential{UserID: 1, Name: "Phone", CredentialID: []byte{1, 2, 3}, PublicKey: []byte("key1")}
+	laptop := &store.WebAuthnCredential{UserID: 1, Name: "Laptop", CredentialID: []byte{4, 5, 6}, PublicKey: []byte("key2"), SignCount: 3}
+	for _, c := range []*store.WebAuthnCredential{phone, laptop} {
+		if err := s.CreateWebAuthnCredential(ctx, c); err != nil {
+			t.Fatalf("CreateWebAuthnCredential failed: %v", err)
+		}
+	}
+	if err := s.CreateWebAuthnCredential(ctx, &store.WebAuthnCredential{UserID: 2, Name: "Dup", CredentialID: []byte{1, 2, 3}, PublicKey: []byte("k")}); err == nil {
+		t.Error("expected error registering a duplicate credential ID")
+	}
+
+	got, err := s.GetWebAuthnCredential(ctx, []byte{4, 5, 6})
+	if err != nil {
+		t.Fatalf("GetWebAuthnCredential failed: %v", err)
+	}
+	if got.ID != laptop.ID || got.Name != "Laptop" || got.SignCount != 3 || string(got.PublicKey) != "key2" || got.LastUsedAt != nil {
+		t.Errorf("unexpected credential: %+v", got)
+	}
+
+	if err := s.UpdateWebAuthnSignCount(ctx, laptop.ID, 4); err != nil {
+		t.Fatalf("UpdateWebAuthnSignCount failed: %v", err)
+	}
+	got, err = s.GetWebAuthnCredential(ctx, []byte{4, 5, 6})
+	if err != nil {
+		t.Fatalf("GetWebAuthnCredential failed: %v", err)
+	}
+	if got.SignCount != 4 || got.LastUsedAt == nil {
+		t.Errorf("expected updated sign count and last use, got %+v", got)
+	}
+
+	if err := s.DeleteWebAuthnCredential(ctx, 2, phone.ID); err != nil {
+		t.Fatalf("DeleteWebAuthnCredential failed: %v", err)
+	}
+	creds, err := s.ListWebAuthnCredentials(ctx, 1)
+	if err != nil {
+		t.Fatalf("ListWebAuthnCredentials failed: %v", err)
+	}
+	if len(creds) != 2 {
+		t.Fatalf("expected another user's delete to be ignored, got %d credentials", len(creds))
+	}
+
+	if err := s.DeleteWebAuthnCredential(ctx, 1, phone.ID); err != nil {
+		t.Fatalf("DeleteWebAuthnCredential failed: %v", err)
+	}
+	creds, err = s.ListWebAuthnCredentials(ctx, 1)
+	if err != nil {
+		t.Fatalf("ListWebAuthnCredentials failed: %v", err)
+	}
+	if len(creds) != 1 || creds[0].ID != laptop.ID {
+		t.Errorf("unexpected credentials after delete: %+v", creds)
+	}
+}
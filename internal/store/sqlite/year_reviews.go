@@ -0,0 +1,137 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"derrclan.com/moravian-soap/internal/store"
+)
+
+// yearReviewColumns are the columns scanned by scanYearReview.
+const yearReviewColumns = `user_id, year, token, verses, summary, card, comments_enabled`
+
+// scanYearReview scans a year in review selected with yearReviewColumns.
+func scanYearReview(row interface{ Scan(...any) error }, review *store.YearReview, extra ...any) error {
+	var verses string
+	var summary sql.NullString
+	if err := row.Scan(append([]any{&review.UserID, &review.Year, &review.Token, &verses, &summary, &review.Card, &review.CommentsEnabled}, extra...)...); err != nil {
+		return err
+	}
+	if err := json.Unmarshal([]byte(verses), &review.Verses); err != nil {
+		return fmt.Errorf("decoding verses: %w", err)
+	}
+	if summary.Valid {
+		review.Summary = &store.YearSummary{}
+		if err := json.Unmarshal([]byte(summary.String), review.Summary); err != nil {
+			return fmt.Errorf("decoding summary: %w", err)
+		}
+	}
+	return nil
+}
+
+// GetYearReview retrieves a user's year in review of a year. It returns sql.ErrNoRows if the user has
+// not opted in to one.
+func (s *Store) GetYearReview(ctx context.Context, userID int64, year int) (*store.YearReview, error) {
+	var review store.YearReview
+	query := `SELECT ` + yearReviewColumns + ` FROM year_reviews WHERE user_id = ? AND year = ?`
+	if err := scanYearReview(s.db.QueryRowContext(ctx, query, userID, year), &review); err != nil {
+		return nil, fmt.Errorf("getting %d review for user %d: %w", year, userID, err)
+	}
+	return &review, nil
+}
+
+// GetYearReviewByToken retrieves the year in review with the given token. It returns sql.ErrNoRows if
+// there is none.
+func (s *Store) GetYearReviewByToken(ctx context.Context, token string) (*store.YearReview, error) {
+	var review store.YearReview
+	query := `SELECT ` + yearReviewColumns + ` FROM year_reviews WHERE token = ?`
+	if err := scanYearReview(s.db.QueryRowContext(ctx, query, token), &review); err != nil {
+		return nil, fmt.Errorf("getting year review: %w", err)
+	}
+	return &review, nil
+}
+
+// ListPendingYearReviews retrieves the years in review that have not been made, with their users'
+// timezones, oldest year first.
+func (s *Store) ListPendingYearReviews(ctx context.Context) ([]*store.YearReview, error) {
+	query := `
+		SELECT r.user_id, r.year, r.token, r.verses, r.summary, r.card, r.comments_enabled, u.timezone
+		FROM year_reviews r
+		JOIN users u ON u.id = r.user_id
+		WHERE r.summary IS NULL
+		ORDER BY r.year, r.user_id
+	`
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("querying pending year reviews: %w", err)
+	}
+	defer rows.Close()
+
+	var reviews []*store.YearReview
+	for rows.Next() {
+		var review store.YearReview
+		if err := scanYearReview(rows, &review, &review.Timezone); err != nil {
+			return nil, fmt.Errorf("scanning year review: %w", err)
+		}
+		reviews = append(reviews, &review)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return reviews, nil
+}
+
+// SaveYearReview creates or updates a user's year in review with its token and verses, and clears its
+// summary and card until it is made again.
+func (s *Store) SaveYearReview(ctx context.Context, review *store.YearReview) error {
+	verses, err := json.Marshal(review.Verses)
+	if err != nil {
+		return fmt.Errorf("encoding verses: %w", err)
+	}
+	if review.Verses == nil {
+		verses = []byte("[]")
+	}
+	query := `
+		INSERT INTO year_reviews (user_id, year, token, verses, updated_at) VALUES (?, ?, ?, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT(user_id, year) DO UPDATE SET
+			token = excluded.token, verses = excluded.verses, summary = NULL, card = NULL,
+			updated_at = excluded.updated_at
+	`
+	if _, err := s.db.ExecContext(ctx, query, review.UserID, review.Year, review.Token, string(verses)); err != nil {
+		return fmt.Errorf("saving %d review for user %d: %w", review.Year, review.UserID, err)
+	}
+	review.Summary, review.Card = nil, nil
+	return nil
+}
+
+// SaveYearReviewSummary records the summary and card image made for a user's year in review.
+func (s *Store) SaveYearReviewSummary(ctx context.Context, userID int64, year int, summary *store.YearSummary, card []byte) error {
+	data, err := json.Marshal(summary)
+	if err != nil {
+		return fmt.Errorf("encoding summary: %w", err)
+	}
+	query := `UPDATE year_reviews SET summary = ?, card = ?, updated_at = CURRENT_TIMESTAMP WHERE user_id = ? AND year = ?`
+	if _, err := s.db.ExecContext(ctx, query, string(data), card, userID, year); err != nil {
+		return fmt.Errorf("saving %d review summary for user %d: %w", year, userID, err)
+	}
+	return nil
+}
+
+// SetYearReviewComments sets whether visitors may comment on a user's year in review of a year.
+func (s *Store) SetYearReviewComments(ctx context.Context, userID int64, year int, enabled bool) error {
+	query := `UPDATE year_reviews SET comments_enabled = ? WHERE user_id = ? AND year = ?`
+	if _, err := s.db.ExecContext(ctx, query, enabled, userID, year); err != nil {
+		return fmt.Errorf("setting comments on %d review for user %d: %w", year, userID, err)
+	}
+	return nil
+}
+
+// DeleteYearReview stops sharing a user's year in review of a year.
+func (s *Store) DeleteYearReview(ctx context.Context, userID int64, year int) error {
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM year_reviews WHERE user_id = ? AND year = ?`, userID, year); err != nil {
+		return fmt.Errorf("deleting %d review for user %d: %w", year, userID, err)
+	}
+	return nil
+}
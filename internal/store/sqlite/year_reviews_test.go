@@ -0,0 +1,69 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"slices"
+	"testing"
+
+	"derrclan.com/moravian-soap/internal/store"
+)
+
+func TestStore_YearReviews(t *testing.T) {
+	db := setupTestDB(t)
+	s := New(db)
+	ctx := context.Background()
+
+	if _, err := db.Exec(`INSERT INTO users (id, email, password_hash, timezone) VALUES (1, 'reader@example.com', 'x', 'America/New_York')`); err != nil {
+		t.Fatalf("failed to insert user: %v", err)
+	}
+
+	review := &store.YearReview{UserID: 1, Year: 2026, Token: "share", Verses: []string{"Psalm 23:1"}}
+	if err := s.SaveYearReview(ctx, review); err != nil {
+		t.Fatalf("SaveYearReview failed: %v", err)
+	}
+	pending, err := s.ListPendingYearReviews(ctx)
+	if err != nil {
+		t.Fatalf("ListPendingYearReviews failed: %v", err)
+	}
+	if len(pending) != 1 || pending[0].Token != "share" || pending[0].Timezone != "America/New_York" || pending[0].Summary != nil {
+		t.Fatalf("ListPendingYearReviews = %+v, want the review not yet made", pending)
+	}
+
+	summary := &store.YearSummary{Journaled: []string{"2026-01-01"}, TopBooks: []string{"Psalms"}}
+	if err := s.SaveYearReviewSummary(ctx, 1, 2026, summary, []byte("png")); err != nil {
+		t.Fatalf("SaveYearReviewSummary failed: %v", err)
+	}
+	got, err := s.GetYearReviewByToken(ctx, "share")
+	if err != nil {
+		t.Fatalf("GetYearReviewByToken failed: %v", err)
+	}
+	if got.UserID != 1 || got.Year != 2026 || !slices.Equal(got.Verses, review.Verses) ||
+		got.Summary == nil || !slices.Equal(got.Summary.TopBooks, summary.TopBooks) || string(got.Card) != "png" {
+		t.Errorf("GetYearReviewByToken = %+v, want the made review", got)
+	}
+	if pending, _ := s.ListPendingYearReviews(ctx); len(pending) != 0 {
+		t.Errorf("ListPendingYearReviews = %+v after the review was made, want none", pending)
+	}
+
+	// Changing the verses clears the summary until the review is made again.
+	review.Verses = nil
+	if err := s.SaveYearReview(ctx, review); err != nil {
+		t.Fatalf("SaveYearReview failed: %v", err)
+	}
+	got, err = s.GetYearReview(ctx, 1, 2026)
+	if err != nil {
+		t.Fatalf("GetYearReview failed: %v", err)
+	}
+	if len(got.Verses) != 0 || got.Summary != nil || got.Card != nil {
+		t.Errorf("GetYearReview = %+v after changing the verses, want no verses or summary", got)
+	}
+
+	if err := s.DeleteYearReview(ctx, 1, 2026); err != nil {
+		t.Fatalf("DeleteYearReview failed: %v", err)
+	}
+	if _, err := s.GetYearReviewByToken(ctx, "share"); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("GetYearReviewByToken after deleting: error = %v, want sql.ErrNoRows", err)
+	}
+}
@@ -0,0 +1,103 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"derrclan.com/moravian-soap/internal/store"
+)
+
+// yearbookColumns are the columns scanned by scanYearbook, which leave out the page.
+const yearbookColumns = `user_id, year, status, error, requested_at, made_at`
+
+// scanYearbook scans a yearbook selected with yearbookColumns.
+func scanYearbook(row interface{ Scan(...any) error }, y *store.Yearbook, extra ...any) error {
+	var madeAt sql.NullTime
+	if err := row.Scan(append([]any{&y.UserID, &y.Year, &y.Status, &y.Error, &y.RequestedAt, &madeAt}, extra...)...); err != nil {
+		return err
+	}
+	if madeAt.Valid {
+		y.MadeAt = &madeAt.Time
+	}
+	return nil
+}
+
+// QueueYearbook asks for a user's yearbook of a year to be made, clearing the one made before, if any,
+// until it is made again.
+func (s *Store) QueueYearbook(ctx context.Context, userID int64, year int) error {
+	query := `
+		INSERT INTO yearbooks (user_id, year, status, requested_at) VALUES (?, ?, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT(user_id, year) DO UPDATE SET
+			status = excluded.status, html = NULL, error = '', requested_at = excluded.requested_at, made_at = NULL
+	`
+	if _, err := s.db.ExecContext(ctx, query, userID, year, store.YearbookQueued); err != nil {
+		return fmt.Errorf("queuing %d yearbook for user %d: %w", year, userID, err)
+	}
+	return nil
+}
+
+// GetYearbook retrieves a user's yearbook of a year with its page. It returns sql.ErrNoRows if the user
+// has not asked for one.
+func (s *Store) GetYearbook(ctx context.Context, userID int64, year int) (*store.Yearbook, error) {
+	var y store.Yearbook
+	query := `SELECT ` + yearbookColumns + `, html FROM yearbooks WHERE user_id = ? AND year = ?`
+	if err := scanYearbook(s.db.QueryRowContext(ctx, query, userID, year), &y, &y.HTML); err != nil {
+		return nil, fmt.Errorf("getting %d yearbook for user %d: %w", year, userID, err)
+	}
+	return &y, nil
+}
+
+// ListYearbooks retrieves the yearbooks a user asked for, without their pages, the latest year first.
+func (s *Store) ListYearbooks(ctx context.Context, userID int64) ([]*store.Yearbook, error) {
+	return s.listYearbooks(ctx, `WHERE user_id = ? ORDER BY year DESC`, userID)
+}
+
+// ListQueuedYearbooks retrieves the yearbooks waiting to be made, the first asked for first.
+func (s *Store) ListQueuedYearbooks(ctx context.Context) ([]*store.Yearbook, error) {
+	return s.listYearbooks(ctx, `WHERE status = ? ORDER BY requested_at, user_id, year`, store.YearbookQueued)
+}
+
+// listYearbooks retrieves the yearbooks selected by the clauses following FROM.
+func (s *Store) listYearbooks(ctx context.Context, clauses string, args ...any) ([]*store.Yearbook, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT `+yearbookColumns+` FROM yearbooks `+clauses, args...)
+	if err != nil {
+		return nil, fmt.Errorf("querying yearbooks: %w", err)
+	}
+	defer rows.Close()
+
+	var yearbooks []*store.Yearbook
+	for rows.Next() {
+		var y store.Yearbook
+		if err := scanYearbook(rows, &y); err != nil {
+			return nil, fmt.Errorf("scanning yearbook: %w", err)
+		}
+		yearbooks = append(yearbooks, &y)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return yearbooks, nil
+}
+
+// SaveYearbook records the page of a yearbook that was made or, if errMsg is not empty, why it could
+// not be.
+func (s *Store) SaveYearbook(ctx context.Context, userID int64, year int, html []byte, errMsg string) error {
+	status := store.YearbookReady
+	if errMsg != "" {
+		status, html = store.YearbookFailed, nil
+	}
+	query := `UPDATE yearbooks SET status = ?, html = ?, error = ?, made_at = CURRENT_TIMESTAMP WHERE user_id = ? AND year = ?`
+	if _, err := s.db.ExecContext(ctx, query, status, html, errMsg, userID, year); err != nil {
+		return fmt.Errorf("saving %d yearbook for user %d: %w", year, userID, err)
+	}
+	return nil
+}
+
+// DeleteYearbooks deletes all of a user's yearbooks, which they can ask for again.
+func (s *Store) DeleteYearbooks(ctx context.Context, userID int64) error {
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM yearbooks WHERE user_id = ?`, userID); err != nil {
+		return fmt.Errorf("deleting yearbooks of user %d: %w", userID, err)
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"derrclan.com/moravian-soap/internal/store"
+)
+
+func TestStore_Yearbooks(t *testing.T) {
+	db := setupTestDB(t)
+	s := New(db)
+	ctx := context.Background()
+	if _, err := db.Exec(`INSERT INTO users (id, email, password_hash) VALUES (1, 'reader@example.com', 'x')`); err != nil {
+		t.Fatalf("inserting user: %v", err)
+	}
+
+	if _, err := s.GetYearbook(ctx, 1, 2025); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("GetYearbook before one was asked for: err = %v, want sql.ErrNoRows", err)
+	}
+	for _, year := range []int{2024, 2025} {
+		if err := s.QueueYearbook(ctx, 1, year); err != nil {
+			t.Fatalf("QueueYearbook(%d) failed: %v", year, err)
+		}
+	}
+	queued, err := s.ListQueuedYearbooks(ctx)
+	if err != nil || len(queued) != 2 || queued[0].Year != 2024 || queued[0].Status != store.YearbookQueued {
+		t.Fatalf("ListQueuedYearbooks = %+v, %v; want both years, 2024 first", queued, err)
+	}
+
+	if err := s.SaveYearbook(ctx, 1, 2025, []byte("<html>"), ""); err != nil {
+		t.Fatalf("SaveYearbook failed: %v", err)
+	}
+	if err := s.SaveYearbook(ctx, 1, 2024, []byte("<html>"), "no entries"); err != nil {
+		t.Fatalf("SaveYearbook failed: %v", err)
+	}
+	y, err := s.GetYearbook(ctx, 1, 2025)
+	if err != nil || y.Status != store.YearbookReady || string(y.HTML) != "<html>" || y.MadeAt == nil {
+		t.Fatalf("GetYearbook = %+v, %v; want it ready", y, err)
+	}
+	list, err := s.ListYearbooks(ctx, 1)
+	if err != nil || len(list) != 2 || list[0].Year != 2025 || list[0].HTML != nil ||
+		list[1].Status != store.YearbookFailed || list[1].Error != "no entries" {
+		t.Fatalf("ListYearbooks = %+v, %v; want 2025 ready, then 2024 failed", list, err)
+	}
+	if queued, _ := s.ListQueuedYearbooks(ctx); len(queued) != 0 {
+		t.Errorf("ListQueuedYearbooks = %+v after both were made, want none", queued)
+	}
+
+	// Asking again clears the page until it is made again.
+	if err := s.QueueYearbook(ctx, 1, 2025); err != nil {
+		t.Fatalf("QueueYearbook failed: %v", err)
+	}
+	if y, err := s.GetYearbook(ctx, 1, 2025); err != nil || y.Status != store.YearbookQueued || y.HTML != nil || y.MadeAt != nil {
+		t.Errorf("GetYearbook after asking again = %+v, %v; want it queued", y, err)
+	}
+}
@@ -3,7 +3,11 @@ package store
 
 import (
 	"context"
+	"errors"
+	"strings"
 	"time"
+
+	"derrclan.com/moravian-soap/internal/civil"
 )
 
 // User represents a system user.
@@ -12,6 +16,9 @@ type User struct {
 	Email      string
 	IsVerified bool
 	Timezone   string
+	// Features turns optional features on or off for the user, by name. It is only loaded for the
+	// signed-in user.
+	Features map[string]bool
 }
 
 // QueuedEmail represents an email message in the delivery queue.
@@ -27,37 +34,702 @@ type QueuedEmail struct {
 	NextAttemptAt time.Time
 }
 
+// EmailSuppression is an address that emails are no longer sent to, because mail to it bounced or its
+// owner reported it as spam.
+type EmailSuppression struct {
+	Address   string
+	Reason    string
+	CreatedAt time.Time
+}
+
+// DailyPostChannel is a Discord or Slack channel that the daily watchword is posted to through an
+// incoming webhook.
+type DailyPostChannel struct {
+	ID         int64
+	Name       string
+	Kind       string // "discord" or "slack"
+	WebhookURL string
+	// Template is the text/template the post is rendered with, or "" for the default of the kind.
+	Template string
+	// PostTime is the time of day, as HH:MM in Timezone, from which the day's post is sent.
+	PostTime string
+	Timezone string
+	// LastPostedDate is the date (YYYY-MM-DD) of the last post sent, or "" if none has been sent.
+	LastPostedDate string
+	CreatedAt      time.Time
+}
+
+// VerseNote is a short note a user has attached to a single verse, independent of their journal entries.
+type VerseNote struct {
+	// Ref is the 8-digit reference of the verse, e.g. "43003016".
+	Ref       string
+	Note      string
+	UpdatedAt time.Time
+}
+
+// HighlightSourceESVOrg is the source of highlights imported from an ESV.org account.
+const HighlightSourceESVOrg = "esv.org"
+
+// Highlight is a verse a user highlighted outside the journal, such as on ESV.org.
+type Highlight struct {
+	// Ref is the 8-digit reference of the verse, e.g. "43003016".
+	Ref string
+	// Source is where the verse was highlighted, e.g. HighlightSourceESVOrg.
+	Source string
+	// Date is the day (YYYY-MM-DD) the verse was highlighted, or "" if the source does not say.
+	Date string
+}
+
+// Mentorship is read access to a user's journal entries granted to a mentor, who may comment on the
+// entries they can read but not change them.
+type Mentorship struct {
+	UserID int64
+	// UserEmail is the email of the user who granted access.
+	UserEmail   string
+	MentorID    int64
+	MentorEmail string
+	// From and To are the first and last dates (YYYY-MM-DD) of the entries shared. Either is "" to share
+	// every entry before or after the other.
+	From      string
+	To        string
+	CreatedAt time.Time
+}
+
+// Covers reports whether the mentorship shares the entry on dateStr.
+func (m *Mentorship) Covers(dateStr string) bool {
+	return (m.From == "" || dateStr >= m.From) && (m.To == "" || dateStr <= m.To)
+}
+
+// MentorComment is a comment a mentor left on a user's journal entry.
+type MentorComment struct {
+	ID int64
+	// UserID and Date identify the entry commented on.
+	UserID      int64
+	Date        string
+	AuthorID    int64
+	AuthorEmail string
+	Body        string
+	CreatedAt   time.Time
+}
+
+// APIToken represents a personal API token. Only a hash of the token secret is stored.
+type APIToken struct {
+	ID           int64
+	UserID       int64
+	Name         string
+	RequestCount int64
+	LastUsedAt   *time.Time
+	CreatedAt    time.Time
+}
+
+// WebAuthnCredential represents a passkey registered by a user.
+type WebAuthnCredential struct {
+	ID           int64
+	UserID       int64
+	Name         string
+	CredentialID []byte
+	// PublicKey is the credential's public key in PKIX, ASN.1 DER form.
+	PublicKey  []byte
+	SignCount  uint32
+	LastUsedAt *time.Time
+	CreatedAt  time.Time
+}
+
+// ESVUsage represents the number of ESV API requests attributed to a user on a given day.
+type ESVUsage struct {
+	Day      string
+	Requests int
+}
+
+// InstanceMetrics is the state of the instance's database reported in the admin's weekly metrics email.
+type InstanceMetrics struct {
+	// DatabaseBytes is the size of the database file.
+	DatabaseBytes int64
+	// CacheEntries and CacheBytes are the number and total size of cached ESV passages.
+	CacheEntries int
+	CacheBytes   int64
+	// ESVRequests is the number of ESV API requests made by the instance since the reporting period began.
+	ESVRequests int
+	// EmailsFailed is the number of queued emails that were given up on since the reporting period began.
+	EmailsFailed int
+	// EmailsPending is the number of queued emails waiting to be sent.
+	EmailsPending int
+}
+
+// Prayer item statuses.
+const (
+	PrayerOpen     = "open"
+	PrayerAnswered = "answered"
+	PrayerDone     = "done"
+)
+
+// PrayerItem represents a single prayer request that is carried forward from day to day until resolved.
+type PrayerItem struct {
+	ID           int64   `json:"id"`
+	UserID       int64   `json:"-"`
+	Text         string  `json:"text"`
+	Status       string  `json:"status"`
+	CreatedDate  string  `json:"createdDate"`
+	ResolvedDate *string `json:"resolvedDate,omitempty"`
+}
+
+// PsalterReading records that a user read a psalter portion on a date.
+type PsalterReading struct {
+	Date    string
+	Portion int
+}
+
+// Preferences holds a user's display preferences.
+type Preferences struct {
+	ESVHeadings       bool
+	ESVVerseNumbers   bool
+	ESVShortCopyright bool
+	ESVIndentPoetry   bool
+	// ESVRedLetter shows the words of Christ in red.
+	ESVRedLetter bool
+	// JournalSchema is the JSON-encoded list of sections the user journals in, or "" for the default.
+	JournalSchema string
+	// LockAfterMinutes is how long a session may go unused before the user must re-authenticate to see
+	// their journal again, or 0 if the privacy lock is off.
+	LockAfterMinutes int
+	// DevotionsAudience is who family devotions questions are shown for ("kids" or "family"), or "" if
+	// family devotions mode is off.
+	DevotionsAudience string
+	// InactivityReminderDays is how many days without a saved entry it takes for the user to be emailed
+	// a reminder, or 0 if inactivity reminders are off.
+	InactivityReminderDays int
+	// InactivityCooldownDays is the fewest days between two inactivity reminders.
+	InactivityCooldownDays int
+	// Translation is the name of the Bible translation passages are read in.
+	Translation string
+	// DailyEmailTime is the time of day, as HH:MM in the user's timezone, at which they are emailed the
+	// day's texts, or "" if they are not.
+	DailyEmailTime string
+	// WeekStart is the day the user's weeks start on in calendars, weekly digests and the week API.
+	WeekStart time.Weekday
+	// ISOWeeks numbers weeks as ISO 8601 does, which starts them on Monday whatever WeekStart is.
+	ISOWeeks bool
+}
+
+// Weeks returns how the user groups days into weeks and numbers them.
+func (p *Preferences) Weeks() civil.Weeks {
+	return civil.Weeks{First: p.WeekStart, ISO: p.ISOWeeks}
+}
+
+// DefaultPreferences returns the preferences of a user who has not changed any.
+func DefaultPreferences() *Preferences {
+	return &Preferences{
+		ESVHeadings:            true,
+		ESVVerseNumbers:        true,
+		ESVShortCopyright:      true,
+		ESVIndentPoetry:        true,
+		InactivityCooldownDays: 7,
+		Translation:            "ESV",
+	}
+}
+
+// InactiveUser is a user due an inactivity reminder.
+type InactiveUser struct {
+	UserID   int64
+	Email    string
+	Timezone string
+	// LastSavedAt is when the user last saved a journal entry.
+	LastSavedAt time.Time
+}
+
+// DailyEmailSubscriber is a user who is emailed the day's texts.
+type DailyEmailSubscriber struct {
+	UserID   int64
+	Email    string
+	Timezone string
+	// Token is the token of the user's subscription, with which the email links to unsubscribing.
+	Token string
+	// SendTime is the time of day, as HH:MM in Timezone, at which the email is sent.
+	SendTime string
+	// LastSentDate is the date of the last email sent to the user, or "" if none has been.
+	LastSentDate string
+}
+
+// DailyEmailList is the email list of the daily email.
+const DailyEmailList = "daily"
+
+// The states of an email subscription.
+const (
+	SubscriptionPending      = "pending"
+	SubscriptionActive       = "active"
+	SubscriptionUnsubscribed = "unsubscribed"
+)
+
+// EmailSubscription is a user's subscription to an email list. It is pending until the user confirms
+// it from an emailed link, and can be unsubscribed from with its token from the link in each email.
+type EmailSubscription struct {
+	UserID    int64
+	List      string
+	Status    string
+	Token     string
+	UpdatedAt time.Time
+}
+
+// FeatureOverride turns an optional feature on or off for a user.
+type FeatureOverride struct {
+	UserID  int64
+	Email   string
+	Feature string
+	Enabled bool
+}
+
+// SectionSave is when a section of a journal entry was last changed.
+type SectionSave struct {
+	Section string
+	// Revision is the entry's revision that last changed the section.
+	Revision int64
+	SavedAt  time.Time
+}
+
 // SOAPData represents the SOAP journal entry.
 type SOAPData struct {
-	Date           string   `json:"date"`
-	Observation    string   `json:"observation"`
-	Application    string   `json:"application"`
-	Prayer         string   `json:"prayer"`
-	SelectedVerses []string `json:"selectedVerses"`
+	Date string `json:"date"`
+	// Sections maps section keys, such as "observation", to what was written in them.
+	Sections       map[string]string `json:"sections"`
+	SelectedVerses []string          `json:"selectedVerses"`
+	// Revision counts the saves of the entry; it is zero for an entry never saved.
+	Revision int64 `json:"revision,omitempty"`
+	// Location and Weather are where the entry was written and the weather there at the time, if the
+	// user stamped it. Saving the entry leaves them as they were; they are set by SaveEntryStamp.
+	Location string `json:"location,omitempty"`
+	Weather  string `json:"weather,omitempty"`
 }
 
-// Store defines the interface for database operations.
-type Store interface {
+// Stamp describes where the entry was written and the weather, e.g. "Bethlehem, PA · Light rain,
+// 12°C (54°F)", or is empty if it was not stamped.
+func (d *SOAPData) Stamp() string {
+	var parts []string
+	for _, part := range []string{d.Location, d.Weather} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, " · ")
+}
+
+// ErrConflict is returned when saving a journal entry based on a revision that is no longer current.
+var ErrConflict = errors.New("journal entry changed since it was loaded")
+
+// PassageEntry is a journal entry on a passage of the user's choosing rather than a day's texts,
+// such as a sermon text.
+type PassageEntry struct {
+	ID        int64  `json:"-"`
+	Reference string `json:"reference"`
+	// Date is the day the entry is about, or "" if it is not tied to one.
+	Date string `json:"date,omitempty"`
+	// Sections maps section keys, such as "observation", to what was written in them.
+	Sections  map[string]string `json:"sections"`
+	CreatedAt time.Time         `json:"createdAt"`
+	UpdatedAt time.Time         `json:"updatedAt"`
+}
+
+// JournalingStats summarizes the time a user spent writing journal entries.
+type JournalingStats struct {
+	Entries int
+	Total   time.Duration
+	Longest time.Duration
+}
+
+// Average returns the average time spent writing an entry.
+func (s *JournalingStats) Average() time.Duration {
+	if s.Entries == 0 {
+		return 0
+	}
+	return s.Total / time.Duration(s.Entries)
+}
+
+// QuietTime is a timed session of sitting with a day's reading, started from its journal entry.
+type QuietTime struct {
+	ID        int64
+	UserID    int64
+	Date      string
+	StartedAt time.Time
+	Duration  time.Duration
+	// EndedAt is when the session ended, or nil while it runs.
+	EndedAt *time.Time
+	// Completed reports whether the session ran its full duration rather than being stopped early.
+	Completed bool
+}
+
+// EndsAt returns when the session's timer runs out.
+func (q *QuietTime) EndsAt() time.Time {
+	return q.StartedAt.Add(q.Duration)
+}
+
+// Running reports whether the session has neither ended nor run out of time at now.
+func (q *QuietTime) Running(now time.Time) bool {
+	return q.EndedAt == nil && now.Before(q.EndsAt())
+}
+
+// QuietTimeStats summarizes a user's completed quiet time sessions.
+type QuietTimeStats struct {
+	Sessions int
+	Total    time.Duration
+}
+
+// LinkedEntries holds the dates of journal entries linked to and from an entry.
+type LinkedEntries struct {
+	Links     []string `json:"links"`
+	Backlinks []string `json:"backlinks"`
+}
+
+// Chapter identifies a chapter of the Bible by book and chapter number.
+type Chapter struct {
+	Book    int
+	Chapter int
+}
+
+// RestoredEntry is a journal entry restored from a backup, together with the links and chapters
+// derived from it.
+type RestoredEntry struct {
+	Entry    *SOAPData
+	Links    []string
+	Chapters []Chapter
+}
+
+// RelatedEntry holds the dates of other journal entries that covered the same chapter.
+type RelatedEntry struct {
+	Chapter Chapter
+	Dates   []string
+}
+
+// ConcordanceChapter is a chapter in a user's daily readings or journal entries, with the dates it
+// appeared on, oldest first.
+type ConcordanceChapter struct {
+	Chapter Chapter
+	Dates   []ConcordanceDate
+}
+
+// ConcordanceDate is a date on which a chapter appeared and whether the user journaled that day.
+type ConcordanceDate struct {
+	Date      string
+	Journaled bool
+}
+
+// YearReview is a user's opt-in to share a year of their journaling on a public page found by its
+// token. The page shows nothing that names the user, only a summary of their year and the verses they
+// chose to include. The summary and the card image shown in previews of links to the page are made
+// once the year is over, and again whenever the user changes what the review includes.
+type YearReview struct {
+	UserID int64
+	Year   int
+	Token  string
+	// Verses are the references of the highlighted verses the user chose to include.
+	Verses []string
+	// Summary and Card, a PNG image, are nil until the review is made.
+	Summary *YearSummary
+	Card    []byte
+	// CommentsEnabled is whether visitors may comment on the review's page.
+	CommentsEnabled bool
+	// Timezone is the user's timezone, in which their year ends. It is only set by
+	// ListPendingYearReviews.
+	Timezone string
+}
+
+// YearSummary summarizes a year of a user's journaling for their year in review.
+type YearSummary struct {
+	// Journaled are the dates the user wrote a journal entry on, in order.
+	Journaled []string `json:"journaled"`
+	// TopBooks are the names of the books the user journaled on the most days, most first.
+	TopBooks []string `json:"topBooks"`
+}
+
+// The states of a yearbook.
+const (
+	YearbookQueued = "queued"
+	YearbookReady  = "ready"
+	YearbookFailed = "failed"
+)
+
+// Yearbook is a printable book of a year of a user's journal, made by a background job after the user
+// asks for it.
+type Yearbook struct {
+	UserID int64
+	Year   int
+	// Status is YearbookQueued until the yearbook is made, then YearbookReady, or YearbookFailed with
+	// the reason in Error.
+	Status string
+	// HTML is the yearbook's page, ready to print. It is only set by GetYearbook, once the yearbook is
+	// ready.
+	HTML        []byte
+	Error       string
+	RequestedAt time.Time
+	MadeAt      *time.Time
+}
+
+// StorageUsage is the space a user's data takes up, in bytes of text, by the kind of data.
+type StorageUsage struct {
+	UserID int64
+	Email  string
+	// Entries is the journal entries: their sections, selected verses and stamps.
+	Entries        int64
+	PassageEntries int64
+	// Notes is the verse notes and prayer items.
+	Notes     int64
+	Yearbooks int64
+	// Quota is the user's own limit on their data, in bytes, or 0 if the instance's limit applies.
+	Quota int64
+}
+
+// Total returns the space all of the user's data takes up.
+func (u *StorageUsage) Total() int64 {
+	return u.Entries + u.PassageEntries + u.Notes + u.Yearbooks
+}
+
+// GuestComment is a comment a visitor left on a shared year in review.
+type GuestComment struct {
+	ID int64
+	// UserID and Year identify the year in review commented on.
+	UserID int64
+	Year   int
+	// Name is the name the visitor gave.
+	Name string
+	Body string
+	// IP is the address the comment came from, kept so the admin can block it.
+	IP string
+	// Approved is whether the comment is shown. Comments left while the admin moderates them are not
+	// approved until the admin approves them.
+	Approved  bool
+	CreatedAt time.Time
+}
+
+// The kinds of entries in the blocklist of guest comments.
+const (
+	// BlockIP blocks comments from an address.
+	BlockIP = "ip"
+	// BlockWord blocks comments containing a word or phrase, ignoring case.
+	BlockWord = "word"
+)
+
+// BlockedValue is an address or word the admin blocked from guest comments.
+type BlockedValue struct {
+	ID        int64
+	Kind      string
+	Value     string
+	CreatedAt time.Time
+}
+
+// APICall is a request made to a translation's API, kept in a rolling log for the admin to troubleshoot
+// with.
+type APICall struct {
+	ID          int64
+	At          time.Time
+	Translation string
+	References  []string
+	// URL is the address requested, with the API key redacted.
+	URL     string
+	Status  int
+	Bytes   int
+	Latency time.Duration
+	Retries int
+	// Cache is what became of the passages fetched: "stored" in the cache, "not stored" if the call
+	// failed or they could not be cached, or "verified" if they were fetched to check the cache against.
+	Cache string
+	Error string
+}
+
+// CacheVerification is a check of a sample of cached passages against the text the API now returns.
+type CacheVerification struct {
+	ID      int64
+	At      time.Time
+	Checked int
+	// Failed is the number of passages that could not be fetched to check.
+	Failed int
+	// Changed are the cache keys of the passages whose text had changed, which were refreshed.
+	Changed []string
+}
+
+// UserStore keeps accounts and how their users sign in: passwords, sessions, passkeys and API tokens.
+type UserStore interface {
+	AuthenticateAPIToken(ctx context.Context, tokenHash string) (*User, error)
 	ConfirmUser(ctx context.Context, token string) (userID int64, email string, err error) // returns userID, email
+	ConsumeWebAuthnChallenge(ctx context.Context, challenge string) (userID int64, expiresAt time.Time, err error)
+	CreateAPIToken(ctx context.Context, userID int64, name, tokenHash string) (int64, error)
 	CreatePasswordResetToken(ctx context.Context, token string, userID int64, expiresAt time.Time) error
 	CreateSession(ctx context.Context, token string, userID int64, expiresAt time.Time) error
 	CreateUser(ctx context.Context, email, passwordHash, token, timezone string) error
+	CreateWebAuthnCredential(ctx context.Context, cred *WebAuthnCredential) error
+	DeleteAPIToken(ctx context.Context, userID, tokenID int64) error
 	DeleteExpiredSessions(ctx context.Context) error
 	DeletePasswordResetToken(ctx context.Context, token string) error
-	ExpungeCache(ctx context.Context, olderThan time.Duration, keepMax int) error
+	DeleteWebAuthnCredential(ctx context.Context, userID, id int64) error
 	GetAuthUser(ctx context.Context, email string) (id int64, passwordHash string, isVerified bool, timezone string, err error)
-	GetCachedESV(ctx context.Context, key string) (string, error)
 	GetPasswordResetToken(ctx context.Context, token string) (int64, time.Time, error) // returns userID, expiresAt
-	GetPendingEmails(ctx context.Context, limit int) ([]*QueuedEmail, error)
-	GetSOAPData(ctx context.Context, userID int64, dateStr string) (*SOAPData, error)
+	GetPreferences(ctx context.Context, userID int64) (*Preferences, error)
+	GetSessionActivity(ctx context.Context, token string) (time.Time, error)
 	GetUserByEmail(ctx context.Context, email string) (*User, error)
 	GetUserFromSession(ctx context.Context, token string) (*User, error)
-	MarkEmailSent(ctx context.Context, id int64) error
-	QueueEmail(ctx context.Context, email *QueuedEmail) error
-	SaveCachedESV(ctx context.Context, key string, content string) error
-	SaveSOAPData(ctx context.Context, userID int64, soapData *SOAPData) error
-	UpdateEmailStatus(ctx context.Context, id int64, status string, nextAttempt *time.Time) error
+	GetWebAuthnCredential(ctx context.Context, credentialID []byte) (*WebAuthnCredential, error)
+	ListAPITokens(ctx context.Context, userID int64) ([]*APIToken, error)
+	ListWebAuthnCredentials(ctx context.Context, userID int64) ([]*WebAuthnCredential, error)
+	SavePreferences(ctx context.Context, userID int64, prefs *Preferences) error
+	SaveWebAuthnChallenge(ctx context.Context, challenge string, userID int64, expiresAt time.Time) error
+	TouchSession(ctx context.Context, token string, at time.Time) error
 	UpdateUserPassword(ctx context.Context, userID int64, passwordHash string) error
 	UpdateUserPasswordHash(ctx context.Context, userID int64, newHash string) error
 	UpdateUserTimezone(ctx context.Context, userID int64, timezone string) error
+	UpdateWebAuthnSignCount(ctx context.Context, id int64, signCount uint32) error
+}
+
+// JournalStore keeps users' journal entries and what is recorded about them.
+type JournalStore interface {
+	CountEntryCompleteness(ctx context.Context, userID int64, since string) (map[string]int, error)
+	GetEntryCompleteness(ctx context.Context, userID int64, from, to string) (map[string]string, error)
+	GetJournaledDates(ctx context.Context, userID int64, from, to string) ([]string, error)
+	GetJournalingStats(ctx context.Context, userID int64, since string) (*JournalingStats, error)
+	GetLinkedEntries(ctx context.Context, userID int64, dateStr string) (*LinkedEntries, error)
+	GetRelatedEntries(ctx context.Context, userID int64, dateStr string) ([]*RelatedEntry, error)
+	GetSOAPData(ctx context.Context, userID int64, dateStr string) (*SOAPData, error)
+	GetSectionSaves(ctx context.Context, userID int64, dateStr string) ([]*SectionSave, error)
+	ListFavorites(ctx context.Context, userID int64) ([]string, error)
+	ListRecentSOAPData(ctx context.Context, userID int64, limit, offset int) ([]*SOAPData, error)
+	ListSOAPData(ctx context.Context, userID int64) ([]*SOAPData, error)
+	MergeSOAPData(ctx context.Context, userID int64, soapData *SOAPData, baseRevision, theirsRevision int64) (int64, error)
+	MoveFavorite(ctx context.Context, userID int64, dateStr string, by int) error
+	RestoreJournal(ctx context.Context, userID int64, entries []*RestoredEntry, passages []*PassageEntry, items []*PrayerItem) error
+	SaveEntryCompleteness(ctx context.Context, userID int64, dateStr, completeness string) error
+	SaveEntryStamp(ctx context.Context, userID int64, dateStr, location, weather string) error
+	SaveFavorite(ctx context.Context, userID int64, dateStr string, favorite bool) error
+	SaveJournalChapters(ctx context.Context, userID int64, dateStr string, chapters []Chapter) error
+	SaveJournalLinks(ctx context.Context, userID int64, sourceDate string, targetDates []string) error
+	SaveSOAPData(ctx context.Context, userID int64, soapData *SOAPData) error
+	SaveSOAPRevision(ctx context.Context, userID int64, soapData *SOAPData, baseRevision int64) (int64, error)
+	SearchSOAPData(ctx context.Context, userID int64, query string, limit int) ([]*SOAPData, error)
+}
+
+// CacheStore caches passages fetched from the ESV API.
+type CacheStore interface {
+	DeleteCachedESV(ctx context.Context, keys []string) error
+	// DeleteCachedESVVariants deletes the cached passages with the key base, however they were
+	// rendered: those keyed by base alone or followed by "|" and the options they were rendered with.
+	DeleteCachedESVVariants(ctx context.Context, base string) error
+	EvictCache(ctx context.Context, maxBytes int64, batchSize int) (int, error)
+	ExpungeCache(ctx context.Context, createdBefore time.Time, keepMax int) error
+	GetCachedESV(ctx context.Context, key string, maxAge time.Duration) (string, error)
+	GetCachedESVBatch(ctx context.Context, keys []string, maxAge time.Duration) (map[string]string, error)
+	// ListCacheVerifications returns the most recent checks of cached passages, newest first.
+	ListCacheVerifications(ctx context.Context, limit int) ([]*CacheVerification, error)
+	// RecordCacheVerification logs a check of cached passages, keeping only the newest keep checks.
+	RecordCacheVerification(ctx context.Context, v *CacheVerification, keep int) error
+	// SampleCachedESV returns up to n cached passages no older than maxAge, by key, chosen at random
+	// and without marking them as used.
+	SampleCachedESV(ctx context.Context, maxAge time.Duration, n int) (map[string]string, error)
+	SaveCachedESV(ctx context.Context, key string, content string) error
+}
+
+// Store is the application's data store. Each backend implements all of it; the parts are split out
+// so that code needing only one can say so.
+type Store interface {
+	UserStore
+	JournalStore
+	CacheStore
+
+	AddGuestComment(ctx context.Context, c *GuestComment) error
+	AddMentorComment(ctx context.Context, c *MentorComment) error
+	ApproveGuestComment(ctx context.Context, id int64) error
+	BlockCommentValue(ctx context.Context, kind, value string) error
+	CreateDailyPostChannel(ctx context.Context, ch *DailyPostChannel) error
+	CreatePrayerItem(ctx context.Context, item *PrayerItem) error
+	CreatePassageEntry(ctx context.Context, userID int64, entry *PassageEntry) error
+	DeleteDailyPostChannel(ctx context.Context, id int64) error
+	DeleteBlockedValue(ctx context.Context, id int64) error
+	DeleteFeatureOverride(ctx context.Context, userID int64, feature string) error
+	DeleteGuestComment(ctx context.Context, id int64) error
+	DeleteMentorship(ctx context.Context, userID, mentorID int64) error
+	DeletePassageEntry(ctx context.Context, userID, id int64) error
+	// DeleteYearbooks deletes all of a user's yearbooks, which they can ask for again.
+	DeleteYearbooks(ctx context.Context, userID int64) error
+	DeleteYearReview(ctx context.Context, userID int64, year int) error
+	DismissAnnouncement(ctx context.Context, userID int64, announcementID string) error
+	EndQuietTime(ctx context.Context, userID, id int64, endedAt time.Time, completed bool) error
+	GetConcordance(ctx context.Context, userID int64, from, to string) ([]*ConcordanceChapter, error)
+	GetDismissedAnnouncements(ctx context.Context, userID int64) ([]string, error)
+	GetESVUsage(ctx context.Context, userID int64, since string) ([]*ESVUsage, error)
+	GetInstanceESVUsage(ctx context.Context, day string) (int, error)
+	GetInstanceMetrics(ctx context.Context, since time.Time) (*InstanceMetrics, error)
+	GetEmailSubscriptionByToken(ctx context.Context, token string) (*EmailSubscription, error)
+	GetFeatureOverrides(ctx context.Context, userID int64) (map[string]bool, error)
+	GetHighlights(ctx context.Context, userID int64, refs []string) ([]*Highlight, error)
+	GetInstanceSettings(ctx context.Context) (map[string]string, error)
+	GetMentorship(ctx context.Context, userID, mentorID int64) (*Mentorship, error)
+	GetPassageEntry(ctx context.Context, userID, id int64) (*PassageEntry, error)
+	GetPendingEmails(ctx context.Context, limit int) ([]*QueuedEmail, error)
+	GetPrayerItem(ctx context.Context, userID, id int64) (*PrayerItem, error)
+	GetPrayerItems(ctx context.Context, userID int64, dateStr string) ([]*PrayerItem, error)
+	GetPsalterReadings(ctx context.Context, userID int64) ([]*PsalterReading, error)
+	GetQuietTime(ctx context.Context, userID int64, dateStr string) (*QuietTime, error)
+	GetQuietTimeStats(ctx context.Context, userID int64, since string) (*QuietTimeStats, error)
+	GetStorageUsage(ctx context.Context, userID int64) (*StorageUsage, error)
+	GetVerseNotes(ctx context.Context, userID int64, refs []string) ([]*VerseNote, error)
+	GetYearbook(ctx context.Context, userID int64, year int) (*Yearbook, error)
+	GetYearReview(ctx context.Context, userID int64, year int) (*YearReview, error)
+	GetYearReviewByToken(ctx context.Context, token string) (*YearReview, error)
+	ImportHighlights(ctx context.Context, userID int64, highlights []*Highlight) (int, error)
+	IsEmailSuppressed(ctx context.Context, address string) (bool, error)
+	IsPsalterReader(ctx context.Context, userID int64) (bool, error)
+	ListAPICalls(ctx context.Context, limit int) ([]*APICall, error)
+	ListBlockedValues(ctx context.Context) ([]*BlockedValue, error)
+	ListDailyPostChannels(ctx context.Context) ([]*DailyPostChannel, error)
+	ListEmailSubscriptions(ctx context.Context, userID int64) ([]*EmailSubscription, error)
+	ListEmailSuppressions(ctx context.Context) ([]*EmailSuppression, error)
+	ListFeatureOverrides(ctx context.Context) ([]*FeatureOverride, error)
+	// ListGuestComments lists the approved comments on a user's year in review, oldest first.
+	ListGuestComments(ctx context.Context, userID int64, year int) ([]*GuestComment, error)
+	ListDailyEmailSubscribers(ctx context.Context) ([]*DailyEmailSubscriber, error)
+	ListInactiveUsers(ctx context.Context, now time.Time) ([]*InactiveUser, error)
+	ListMentees(ctx context.Context, mentorID int64) ([]*Mentorship, error)
+	ListMentorComments(ctx context.Context, userID int64, dateStr string) ([]*MentorComment, error)
+	ListMentorships(ctx context.Context, userID int64) ([]*Mentorship, error)
+	ListPassageEntries(ctx context.Context, userID int64) ([]*PassageEntry, error)
+	ListPendingGuestComments(ctx context.Context) ([]*GuestComment, error)
+	ListPendingYearReviews(ctx context.Context) ([]*YearReview, error)
+	// ListQueuedYearbooks lists the yearbooks waiting to be made, the first asked for first.
+	ListQueuedYearbooks(ctx context.Context) ([]*Yearbook, error)
+	ListRecentGuestComments(ctx context.Context, limit int) ([]*GuestComment, error)
+	ListPrayerItems(ctx context.Context, userID int64) ([]*PrayerItem, error)
+	ListRecentEmails(ctx context.Context, limit int) ([]*QueuedEmail, error)
+	// ListStorageUsage lists the space each user's data takes up, the most first.
+	ListStorageUsage(ctx context.Context) ([]*StorageUsage, error)
+	ListYearbooks(ctx context.Context, userID int64) ([]*Yearbook, error)
+	MarkDailyEmailSent(ctx context.Context, userID int64, date string) error
+	MarkDailyPostSent(ctx context.Context, id int64, date string) error
+	MarkInactivityReminderSent(ctx context.Context, userID int64, at time.Time) error
+	MarkEmailSent(ctx context.Context, id int64) error
+	MarkEmailSuppressed(ctx context.Context, id int64) error
+	QueueEmail(ctx context.Context, email *QueuedEmail) error
+	// QueueYearbook asks for a user's yearbook of a year to be made, or made again.
+	QueueYearbook(ctx context.Context, userID int64, year int) error
+	// RecordAPICall logs a call to a translation's API, keeping only the newest keep calls.
+	RecordAPICall(ctx context.Context, call *APICall, keep int) error
+	RecordESVUsage(ctx context.Context, userID int64, day string, requests int) error
+	SaveEmailSubscription(ctx context.Context, sub *EmailSubscription) error
+	SaveFeatureOverride(ctx context.Context, userID int64, feature string, enabled bool) error
+	SaveInstanceSettings(ctx context.Context, settings map[string]string) error
+	SaveMentorship(ctx context.Context, m *Mentorship) error
+	SavePsalterReading(ctx context.Context, userID int64, reading *PsalterReading) error
+	SaveReadingChapters(ctx context.Context, year int, readings map[string][]Chapter) error
+	SaveVerseNote(ctx context.Context, userID int64, ref, note string) error
+	// SaveYearbook records the page of a yearbook that was made, or, if errMsg is not empty, why it
+	// could not be.
+	SaveYearbook(ctx context.Context, userID int64, year int, html []byte, errMsg string) error
+	// SaveYearReview creates or updates a user's year in review with its token and verses, and clears
+	// its summary and card until it is made again by SaveYearReviewSummary.
+	SaveYearReview(ctx context.Context, review *YearReview) error
+	SaveYearReviewSummary(ctx context.Context, userID int64, year int, summary *YearSummary, card []byte) error
+	// SetStorageQuota sets a user's own limit on their data, in bytes, or removes it if quota is 0.
+	SetStorageQuota(ctx context.Context, userID int64, quota int64) error
+	SetYearReviewComments(ctx context.Context, userID int64, year int, enabled bool) error
+	StartPsalter(ctx context.Context, userID int64) error
+	StartQuietTime(ctx context.Context, q *QuietTime) error
+	StopPsalter(ctx context.Context, userID int64) error
+	SuppressEmail(ctx context.Context, address, reason string) error
+	UnsuppressEmail(ctx context.Context, address string) error
+	UpdateEmailStatus(ctx context.Context, id int64, status string, nextAttempt *time.Time) error
+	UpdatePassageEntry(ctx context.Context, userID int64, entry *PassageEntry) error
+	UpdatePrayerItem(ctx context.Context, item *PrayerItem) error
 }
@@ -0,0 +1,27 @@
+package testutil
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"derrclan.com/moravian-soap/internal/migrations"
+	"derrclan.com/moravian-soap/internal/store/sqlite"
+)
+
+// NewDB returns a migrated SQLite database in a temporary directory. It is opened the same way as the
+// production database and closed when the test ends.
+func NewDB(t testing.TB) *sql.DB {
+	t.Helper()
+	db, err := sqlite.Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("opening test database: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	if err := migrations.Run(context.Background(), db); err != nil {
+		t.Fatalf("migrating test database: %v", err)
+	}
+	return db
+}
@@ -0,0 +1,120 @@
+// Package testutil provides helpers for black-box tests of the application: a fake ESV API, temporary
+// SQLite databases and a running instance of the full server.
+package testutil
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"derrclan.com/moravian-soap/internal/esv"
+)
+
+// Passage is a fixture served by FakeESV for a reference.
+type Passage struct {
+	// HTML is the passage as rendered by the ESV API, before the client's transform.
+	HTML string
+	// Meta describes the passage. Its Canonical field defaults to the reference.
+	Meta esv.PassageMeta
+}
+
+// FakeESV is an httptest server that implements the ESV passage HTML endpoint. It serves fixture
+// passages keyed by reference, and a placeholder passage for references without a fixture.
+type FakeESV struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	passages map[string]Passage
+	queries  []string
+	status   int
+	delay    time.Duration
+}
+
+// NewFakeESV starts a fake ESV API that is closed when the test ends.
+func NewFakeESV(t testing.TB) *FakeESV {
+	t.Helper()
+	f := &FakeESV{passages: make(map[string]Passage)}
+	f.Server = httptest.NewServer(http.HandlerFunc(f.handle))
+	t.Cleanup(f.Close)
+	return f
+}
+
+// AddPassage serves p for the reference, exactly as it appears in the query.
+func (f *FakeESV) AddPassage(reference string, p Passage) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if p.Meta.Canonical == "" {
+		p.Meta.Canonical = reference
+	}
+	f.passages[reference] = p
+}
+
+// FailWith makes the fake respond to every request with the HTTP status, or serve passages again
+// if status is 0.
+func (f *FakeESV) FailWith(status int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.status = status
+}
+
+// Delay makes the fake wait for d before responding to each request, like a slow ESV API.
+func (f *FakeESV) Delay(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.delay = d
+}
+
+// Queries returns the queries (the "q" parameter) of the requests received so far.
+func (f *FakeESV) Queries() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.queries...)
+}
+
+func (f *FakeESV) handle(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "Token ") {
+		http.Error(w, `{"detail":"Authentication credentials were not provided."}`, http.StatusUnauthorized)
+		return
+	}
+
+	query := r.URL.Query().Get("q")
+	f.mu.Lock()
+	f.queries = append(f.queries, query)
+	status, delay := f.status, f.delay
+	resp := esv.Response{Query: query, Copyright: "ESV"}
+	for _, ref := range strings.Split(query, ";") {
+		p, ok := f.passages[ref]
+		if !ok {
+			p = placeholderPassage(ref)
+		}
+		resp.PassageMeta = append(resp.PassageMeta, p.Meta)
+		resp.Passages = append(resp.Passages, p.HTML)
+	}
+	f.mu.Unlock()
+
+	time.Sleep(delay)
+	if status != 0 {
+		http.Error(w, http.StatusText(status), status)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// placeholderPassage returns a passage for a reference without a fixture, so any daily reading can
+// be rendered.
+func placeholderPassage(reference string) Passage {
+	ref := html.EscapeString(reference)
+	return Passage{
+		HTML: fmt.Sprintf(`<h2 class="extra_text">%s</h2><p class="starts-chapter">Text of %s.</p>`, ref, ref),
+		Meta: esv.PassageMeta{Canonical: reference},
+	}
+}
@@ -0,0 +1,49 @@
+package testutil_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"derrclan.com/moravian-soap/internal/esv"
+	"derrclan.com/moravian-soap/internal/testutil"
+)
+
+func TestFakeESV(t *testing.T) {
+	fake := testutil.NewFakeESV(t)
+	t.Setenv("ESV_API_URL", fake.URL)
+	t.Setenv("ESV_API_KEY", "test-key")
+	fake.AddPassage("John 3:16", testutil.Passage{
+		HTML: `<p><b class="verse-num" id="v43003016-1">16&nbsp;</b>For God so loved the world</p>`,
+	})
+
+	resp, err := esv.FetchPassages(context.Background(), []string{"John 3:16", "Jude 1"}, esv.DefaultOptions())
+	if err != nil {
+		t.Fatalf("FetchPassages failed: %v", err)
+	}
+	if len(resp.Passages) != 2 || len(resp.PassageMeta) != 2 {
+		t.Fatalf("expected 2 passages, got %+v", resp)
+	}
+	if !strings.Contains(resp.Passages[0], `data-ref="43003016"`) {
+		t.Errorf("fixture passage not served: %s", resp.Passages[0])
+	}
+	if !strings.Contains(resp.Passages[1], "Text of Jude 1.") || resp.PassageMeta[1].Canonical != "Jude 1" {
+		t.Errorf("unexpected placeholder passage: %s", resp.Passages[1])
+	}
+	if q := fake.Queries(); len(q) != 1 || q[0] != "John 3:16;Jude 1" {
+		t.Errorf("Queries() = %q", q)
+	}
+
+	fake.FailWith(500)
+	if _, err := esv.FetchPassages(context.Background(), []string{"John 3:16"}, esv.DefaultOptions()); err == nil {
+		t.Error("expected error when the fake fails")
+	}
+}
+
+func TestNewDB(t *testing.T) {
+	db := testutil.NewDB(t)
+	var n int
+	if err := db.QueryRow("SELECT COUNT(*) FROM journal_sections").Scan(&n); err != nil {
+		t.Errorf("database not migrated: %v", err)
+	}
+}
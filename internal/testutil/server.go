@@ -0,0 +1,187 @@
+package testutil
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"derrclan.com/moravian-soap/internal/auth"
+	"derrclan.com/moravian-soap/internal/clock"
+	"derrclan.com/moravian-soap/internal/dailytexts"
+	"derrclan.com/moravian-soap/internal/server"
+	"derrclan.com/moravian-soap/internal/store"
+	"derrclan.com/moravian-soap/internal/store/sqlite"
+)
+
+// csrfToken is the CSRF token clients of a test server present in both the cookie and the header.
+const csrfToken = "test-csrf-token"
+
+// Server is a running instance of the full application, backed by a temporary database and a fake
+// ESV API. The daily texts and configuration are process-wide, so tests using it must not run in parallel.
+type Server struct {
+	*httptest.Server
+
+	// ESV is the fake ESV API the server fetches passages from.
+	ESV *FakeESV
+	// Clock is the server's clock. It starts at the real time and only moves when it is told to.
+	Clock *clock.Fake
+	// Store is a separate connection to the server's database, for arranging and inspecting data.
+	Store store.Store
+
+	textsDir string
+}
+
+// NewServer starts the application, customized by opts, with a fresh database and a fake ESV API.
+// Everything is shut down when the test ends.
+func NewServer(t *testing.T, opts ...server.Option) *Server {
+	t.Helper()
+	s := &Server{ESV: NewFakeESV(t), Clock: clock.NewFake(time.Now()), textsDir: t.TempDir()}
+
+	dbPath := filepath.Join(t.TempDir(), "app.db")
+	t.Setenv("DB_PATH", dbPath)
+	t.Setenv("ESV_API_URL", s.ESV.URL)
+	t.Setenv("ESV_API_KEY", "test-key")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	app, err := server.New(ctx, server.Config{DBPath: dbPath, Clock: s.Clock, Options: opts})
+	if err != nil {
+		t.Fatalf("creating server: %v", err)
+	}
+	t.Cleanup(func() {
+		cancel()
+		_ = app.Close()
+	})
+
+	db, err := sqlite.Open(dbPath)
+	if err != nil {
+		t.Fatalf("opening server database: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	s.Store = sqlite.New(db)
+
+	s.Server = httptest.NewServer(app)
+	t.Cleanup(s.Close)
+	t.Cleanup(func() { dailytexts.SetDir("") })
+	return s
+}
+
+// SetDailyText makes the server use text as the daily text for date, in place of the embedded daily
+// texts. Other dates of the same year have no daily text until they are set as well.
+func (s *Server) SetDailyText(t *testing.T, date string, text dailytexts.DailyText) {
+	t.Helper()
+	path := filepath.Join(s.textsDir, date[:4]+".json")
+
+	year := dailytexts.Year{}
+	if data, err := os.ReadFile(path); err == nil {
+		if err := json.Unmarshal(data, &year); err != nil {
+			t.Fatalf("decoding %s: %v", path, err)
+		}
+	}
+	year[date] = text
+
+	data, err := json.Marshal(year)
+	if err != nil {
+		t.Fatalf("encoding daily texts: %v", err)
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatalf("writing %s: %v", path, err)
+	}
+	// Clear the loaded year data so the file is read again.
+	dailytexts.SetDir("")
+	dailytexts.SetDir(s.textsDir)
+}
+
+// NewClient returns a client for the server that keeps cookies, does not follow redirects and sends
+// a valid CSRF token with every request that changes something.
+func (s *Server) NewClient(t *testing.T) *http.Client {
+	t.Helper()
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		t.Fatalf("creating cookie jar: %v", err)
+	}
+	u, err := url.Parse(s.URL)
+	if err != nil {
+		t.Fatalf("parsing server URL: %v", err)
+	}
+	jar.SetCookies(u, []*http.Cookie{{Name: "csrf_token", Value: csrfToken, Path: "/"}})
+
+	return &http.Client{
+		Jar:       jar,
+		Transport: csrfTransport{s.Client().Transport},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// Password is the password of the users created by Login.
+const Password = "correct horse battery staple"
+
+// Login creates a confirmed user with the email address and returns a client signed in as them.
+func (s *Server) Login(t *testing.T, email string) *http.Client {
+	t.Helper()
+	ctx := context.Background()
+
+	hash, err := auth.HashPassword(Password)
+	if err != nil {
+		t.Fatalf("hashing password: %v", err)
+	}
+	token := "confirm-" + email
+	if err := s.Store.CreateUser(ctx, email, hash, token, "UTC"); err != nil {
+		t.Fatalf("creating user %s: %v", email, err)
+	}
+	if _, _, err := s.Store.ConfirmUser(ctx, token); err != nil {
+		t.Fatalf("confirming user %s: %v", email, err)
+	}
+
+	c := s.NewClient(t)
+	resp, err := c.PostForm(s.URL+"/login", url.Values{"email": {email}, "password": {Password}, "timezone": {"UTC"}})
+	if err != nil {
+		t.Fatalf("signing in as %s: %v", email, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusFound {
+		t.Fatalf("signing in as %s: status %d", email, resp.StatusCode)
+	}
+	return c
+}
+
+// csrfTransport adds the CSRF token header to requests other than GET, HEAD and OPTIONS.
+type csrfTransport struct {
+	base http.RoundTripper
+}
+
+func (c csrfTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead && r.Method != http.MethodOptions {
+		r = r.Clone(r.Context())
+		r.Header.Set("X-CSRF-Token", csrfToken)
+	}
+	return c.base.RoundTrip(r)
+}
+
+// HTMX returns a copy of c that sends requests as HTMX does, so that handlers of partials answer with
+// the partial rather than redirecting as they do for forms submitted without JavaScript.
+func HTMX(c *http.Client) *http.Client {
+	htmx := *c
+	htmx.Transport = htmxTransport{c.Transport}
+	return &htmx
+}
+
+// htmxTransport marks requests as sent by HTMX.
+type htmxTransport struct {
+	base http.RoundTripper
+}
+
+func (h htmxTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	r = r.Clone(r.Context())
+	r.Header.Set("HX-Request", "true")
+	return h.base.RoundTrip(r)
+}
@@ -0,0 +1,121 @@
+// Package theme provides the color themes an instance can be branded with. Themes are stylesheets in
+// the themes directory that override the CSS custom properties of style.css; the administrator picks
+// one and may add CSS of their own.
+package theme
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Default is the theme of an instance that has not chosen one.
+const Default = "default"
+
+// MaxCustomCSS is the size limit of an instance's custom CSS, in bytes.
+const MaxCustomCSS = 32 << 10
+
+//go:embed themes/*.css
+var files embed.FS
+
+// Theme is a color theme.
+type Theme struct {
+	// Name identifies the theme; it is the name of its file without the extension.
+	Name string
+	// Label is the theme's display name and Description says what it looks like, both taken from the
+	// comment on the first line of its file ("/* Label: description */").
+	Label       string
+	Description string
+	CSS         string
+}
+
+// headerRE matches the comment that starts a theme file.
+var headerRE = regexp.MustCompile(`^/\*\s*([^:]+):\s*(.*?)\s*\*/`)
+
+// themes holds the embedded themes, the default theme first.
+var themes = func() []Theme {
+	ts, err := load()
+	if err != nil {
+		panic(fmt.Sprintf("themes: %v", err))
+	}
+	return ts
+}()
+
+// load reads and validates the theme files, ordering them for display.
+func load() ([]Theme, error) {
+	entries, err := files.ReadDir("themes")
+	if err != nil {
+		return nil, fmt.Errorf("reading themes: %w", err)
+	}
+	var themes []Theme
+	for _, e := range entries {
+		b, err := files.ReadFile(path.Join("themes", e.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("reading theme %s: %w", e.Name(), err)
+		}
+		m := headerRE.FindStringSubmatch(string(b))
+		if m == nil {
+			return nil, fmt.Errorf("theme %s does not start with a /* Label: description */ comment", e.Name())
+		}
+		themes = append(themes, Theme{
+			Name:        strings.TrimSuffix(e.Name(), ".css"),
+			Label:       m[1],
+			Description: m[2],
+			CSS:         string(b),
+		})
+	}
+	// The default theme comes first, the others in alphabetical order.
+	slices.SortFunc(themes, func(a, b Theme) int {
+		switch {
+		case a.Name == Default:
+			return -1
+		case b.Name == Default:
+			return 1
+		}
+		return strings.Compare(a.Label, b.Label)
+	})
+	return themes, nil
+}
+
+// List returns the available themes, the default theme first.
+func List() []Theme {
+	return slices.Clone(themes)
+}
+
+// Get returns the theme with the given name.
+func Get(name string) (Theme, bool) {
+	i := slices.IndexFunc(themes, func(t Theme) bool { return t.Name == name })
+	if i < 0 {
+		return Theme{}, false
+	}
+	return themes[i], true
+}
+
+// ValidateCustomCSS checks that custom CSS is small enough and cannot close the style element it is
+// emitted in.
+func ValidateCustomCSS(css string) error {
+	if len(css) > MaxCustomCSS {
+		return fmt.Errorf("custom CSS is %d bytes, more than the limit of %d", len(css), MaxCustomCSS)
+	}
+	if strings.Contains(css, "<") {
+		return errors.New("custom CSS must not contain \"<\"")
+	}
+	return nil
+}
+
+// Stylesheet returns the CSS of an instance: the named theme, or the default theme if there is no such
+// theme, followed by the custom CSS.
+func Stylesheet(name, customCSS string) string {
+	t, ok := Get(name)
+	if !ok {
+		t, _ = Get(Default)
+	}
+	if customCSS == "" {
+		return t.CSS
+	}
+	return t.CSS + "\n" + customCSS + "\n"
+}
@@ -0,0 +1,45 @@
+package theme
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestList(t *testing.T) {
+	themes := List()
+	if len(themes) < 2 || themes[0].Name != Default {
+		t.Fatalf("expected the default theme first, got %+v", themes)
+	}
+	for _, th := range themes {
+		if th.Label == "" || th.Description == "" {
+			t.Errorf("theme %s has no label or description", th.Name)
+		}
+	}
+	if th, ok := Get("moravian-star"); !ok || th.Label != "Moravian Star" || !strings.Contains(th.CSS, "--primary-color") {
+		t.Errorf("Get(moravian-star) = %+v, %v", th, ok)
+	}
+}
+
+func TestStylesheet(t *testing.T) {
+	def, _ := Get(Default)
+	if got := Stylesheet("no-such-theme", ""); got != def.CSS {
+		t.Errorf("unknown theme should fall back to the default, got %q", got)
+	}
+	evergreen, _ := Get("evergreen")
+	got := Stylesheet("evergreen", ".header-title { font-variant: small-caps; }")
+	if !strings.HasPrefix(got, evergreen.CSS) || !strings.Contains(got, "small-caps") {
+		t.Errorf("expected the theme followed by the custom CSS, got %q", got)
+	}
+}
+
+func TestValidateCustomCSS(t *testing.T) {
+	if err := ValidateCustomCSS(":root { --primary-color: #123456; }"); err != nil {
+		t.Errorf("valid CSS rejected: %v", err)
+	}
+	if err := ValidateCustomCSS("</style><script>alert(1)</script>"); err == nil {
+		t.Error("expected CSS that closes the style element to be rejected")
+	}
+	if err := ValidateCustomCSS(strings.Repeat("a", MaxCustomCSS+1)); err == nil {
+		t.Error("expected oversized CSS to be rejected")
+	}
+}
@@ -0,0 +1,114 @@
+package tts
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// maxAPIInput is the most characters the speech API reads in one request. Longer texts are read in
+// parts, whose MP3 audio plays back to back when concatenated.
+const maxAPIInput = 4096
+
+// API synthesizes speech with a cloud text-to-speech API compatible with OpenAI's speech endpoint.
+type API struct {
+	// URL is the speech endpoint, Key the bearer token it is called with, and Model and Voice the
+	// model and voice it reads with.
+	URL, Key, Model, Voice string
+}
+
+// Name returns "api" with the model and voice.
+func (a *API) Name() string {
+	return "api:" + a.Model + ":" + a.Voice
+}
+
+// Format returns MP3, which the API is asked for.
+func (a *API) Format() Format {
+	return MP3
+}
+
+// Synthesize reads text with the API, in parts if it is too long for one request, and writes the audio
+// to w.
+func (a *API) Synthesize(ctx context.Context, text string, w io.Writer) error {
+	client := &http.Client{Timeout: 60 * time.Second}
+	for _, part := range splitText(text, maxAPIInput) {
+		if err := a.synthesize(ctx, client, part, w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// synthesize reads one part of a text with the API.
+func (a *API) synthesize(ctx context.Context, client *http.Client, text string, w io.Writer) error {
+	body, err := json.Marshal(map[string]string{
+		"model":           a.Model,
+		"voice":           a.Voice,
+		"input":           text,
+		"response_format": "mp3",
+	})
+	if err != nil {
+		return fmt.Errorf("encoding speech request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+a.Key)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to synthesize speech: %w", err)
+	}
+	defer func() {
+		if cerr := resp.Body.Close(); cerr != nil {
+			slog.Error("failed to close response body", "error", cerr)
+		}
+	}()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("speech API returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	if _, err := io.Copy(w, resp.Body); err != nil {
+		return fmt.Errorf("reading speech: %w", err)
+	}
+	return nil
+}
+
+// splitText splits text into parts of at most limit bytes, between paragraphs where it can, else between
+// sentences or words.
+func splitText(text string, limit int) []string {
+	var parts []string
+	for len(text) > limit {
+		cut := -1
+		for _, sep := range []string{"\n\n", "\n", ". ", " "} {
+			if i := strings.LastIndex(text[:limit], sep); i > 0 {
+				cut = i + len(sep)
+				break
+			}
+		}
+		if cut < 0 {
+			// A single word longer than a part is cut where it must be, on a rune boundary.
+			cut = limit
+			for cut > 0 && !utf8.RuneStart(text[cut]) {
+				cut--
+			}
+		}
+		if part := strings.TrimSpace(text[:cut]); part != "" {
+			parts = append(parts, part)
+		}
+		text = text[cut:]
+	}
+	if text = strings.TrimSpace(text); text != "" {
+		parts = append(parts, text)
+	}
+	return parts
+}
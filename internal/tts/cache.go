@@ -0,0 +1,163 @@
+package tts
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// Cache synthesizes speech with a backend and keeps the audio in a directory, in files named by a hash
+// of the backend and the text, so that a text is read again only once it has changed. Each user's audio
+// is kept in a directory of their own, so that it can be removed with their entries.
+type Cache struct {
+	// MaxAge is how long audio is kept after it was last used, and MaxBytes the most the audio may take
+	// in all. Prune removes audio beyond either limit. Zero means no limit.
+	MaxAge   time.Duration
+	MaxBytes int64
+
+	dir     string
+	backend Backend
+	// syntheses coalesces concurrent requests for the audio of the same text.
+	syntheses singleflight.Group
+}
+
+// NewCache returns a cache of the speech synthesized by backend in dir, which is created if it does not
+// exist. The audio is of private journal entries, so only the server's user may read it.
+func NewCache(dir string, backend Backend) (*Cache, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("creating speech cache: %w", err)
+	}
+	return &Cache{dir: dir, backend: backend}, nil
+}
+
+// Format returns the format of the cached audio.
+func (c *Cache) Format() Format {
+	return c.backend.Format()
+}
+
+// userDir returns the directory of the audio of the user with the given ID.
+func (c *Cache) userDir(userID int64) string {
+	return filepath.Join(c.dir, strconv.FormatInt(userID, 10))
+}
+
+// Open opens the audio of text read aloud for the user with the given ID, synthesizing it first if it
+// is not cached.
+func (c *Cache) Open(ctx context.Context, userID int64, text string) (*os.File, error) {
+	sum := sha256.Sum256([]byte(c.backend.Name() + "\x00" + text))
+	path := filepath.Join(c.userDir(userID), hex.EncodeToString(sum[:])+c.backend.Format().Ext)
+
+	f, err := os.Open(path) // #nosec G304 -- the name is a hash
+	if err == nil {
+		// Mark the audio as used, so that Prune keeps what is still listened to.
+		now := time.Now()
+		_ = os.Chtimes(path, now, now)
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return f, err
+	}
+	// The speech is synthesized even if the request that asked for it first is cancelled, for the
+	// others waiting on it.
+	_, err, _ = c.syntheses.Do(path, func() (any, error) {
+		return nil, c.synthesize(context.WithoutCancel(ctx), text, path)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return os.Open(path) // #nosec G304 -- the name is a hash
+}
+
+// synthesize writes the audio of text to path, by way of a temporary file so that a failed synthesis
+// is never cached.
+func (c *Cache) synthesize(ctx context.Context, text, path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("creating speech cache: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".synthesizing-*")
+	if err != nil {
+		return fmt.Errorf("creating speech file: %w", err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	if err := c.backend.Synthesize(ctx, text, tmp); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("writing speech file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("caching speech: %w", err)
+	}
+	return nil
+}
+
+// RemoveUser removes the audio of the user with the given ID, which must not outlive their entries.
+func (c *Cache) RemoveUser(userID int64) error {
+	if err := os.RemoveAll(c.userDir(userID)); err != nil {
+		return fmt.Errorf("removing speech of user %d: %w", userID, err)
+	}
+	return nil
+}
+
+// Prune removes the audio last used more than MaxAge before now, then the least recently used audio
+// until what is left takes at most MaxBytes. It returns the number of files removed.
+func (c *Cache) Prune(now time.Time) (int, error) {
+	maxAge, maxBytes := c.MaxAge, c.MaxBytes
+	type cached struct {
+		path string
+		size int64
+		used time.Time
+	}
+	var files []cached
+	err := filepath.WalkDir(c.dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		info, err := d.Info()
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		// Audio still being synthesized is left alone unless it was abandoned long ago.
+		if strings.HasPrefix(d.Name(), ".") && (maxAge <= 0 || now.Sub(info.ModTime()) < maxAge) {
+			return nil
+		}
+		files = append(files, cached{path: path, size: info.Size(), used: info.ModTime()})
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("listing cached speech: %w", err)
+	}
+	slices.SortFunc(files, func(a, b cached) int { return a.used.Compare(b.used) })
+
+	var total int64
+	for _, f := range files {
+		total += f.size
+	}
+	removed := 0
+	for _, f := range files {
+		expired := maxAge > 0 && now.Sub(f.used) > maxAge
+		if !expired && (maxBytes <= 0 || total <= maxBytes) {
+			break
+		}
+		if err := os.Remove(f.path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return removed, fmt.Errorf("removing cached speech: %w", err)
+		}
+		total -= f.size
+		removed++
+	}
+	return removed, nil
+}
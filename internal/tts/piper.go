@@ -0,0 +1,58 @@
+package tts
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Piper synthesizes speech with a local piper binary (https://github.com/rhasspy/piper), so that
+// entries never leave the instance.
+type Piper struct {
+	// Path is the piper binary, looked up on the PATH if it has no slash.
+	Path string
+	// Model is the .onnx voice model.
+	Model string
+}
+
+// Name returns "piper" and the voice model.
+func (p *Piper) Name() string {
+	return "piper:" + filepath.Base(p.Model)
+}
+
+// Format returns WAV, which piper writes.
+func (p *Piper) Format() Format {
+	return WAV
+}
+
+// Synthesize runs piper on text, which it reads a line at a time, and copies the audio it writes to w.
+func (p *Piper) Synthesize(ctx context.Context, text string, w io.Writer) error {
+	dir, err := os.MkdirTemp("", "piper-")
+	if err != nil {
+		return fmt.Errorf("creating piper output: %w", err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+	output := filepath.Join(dir, "speech.wav")
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, p.Path, "--model", p.Model, "--output_file", output) // #nosec G204 -- the binary and model are set by the instance
+	cmd.Stdin = strings.NewReader(text)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("running piper: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	f, err := os.Open(output) // #nosec G304 -- the file is in a directory created above
+	if err != nil {
+		return fmt.Errorf("reading piper output: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+	if _, err := io.Copy(w, f); err != nil {
+		return fmt.Errorf("reading piper output: %w", err)
+	}
+	return nil
+}
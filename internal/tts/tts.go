@@ -0,0 +1,88 @@
+// Package tts reads text aloud, so that users can listen back to their journal entries. Speech is
+// synthesized by a backend chosen by the instance, either a local piper binary or a cloud
+// text-to-speech API, and cached on disk so that each text is synthesized only once.
+package tts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Format is an audio format produced by a backend.
+type Format struct {
+	// Ext is the extension of files in the format, such as ".wav".
+	Ext string
+	// ContentType is the MIME type audio in the format is served with.
+	ContentType string
+}
+
+// The formats produced by the backends.
+var (
+	WAV = Format{Ext: ".wav", ContentType: "audio/wav"}
+	MP3 = Format{Ext: ".mp3", ContentType: "audio/mpeg"}
+)
+
+// Backend synthesizes speech from text.
+type Backend interface {
+	// Name identifies the backend and its voice. It is part of the key audio is cached by, so that
+	// changing the voice reads texts again.
+	Name() string
+	// Format is the format of the audio the backend writes.
+	Format() Format
+	// Synthesize writes the speech of text to w.
+	Synthesize(ctx context.Context, text string, w io.Writer) error
+}
+
+// Default settings of the backends.
+const (
+	DefaultPiperPath = "piper"
+	DefaultAPIURL    = "https://api.openai.com/v1/audio/speech"
+	DefaultAPIModel  = "tts-1"
+	DefaultAPIVoice  = "alloy"
+)
+
+// FromEnv returns the backend chosen by TTS_BACKEND, or nil if it is unset and entries cannot be
+// listened to. The piper backend runs the binary at PIPER_PATH with the voice model at PIPER_MODEL.
+// The api backend posts to an OpenAI-compatible speech endpoint at TTS_API_URL with the key in
+// TTS_API_KEY, the model in TTS_MODEL and the voice in TTS_VOICE.
+func FromEnv() (Backend, error) {
+	switch name := strings.ToLower(os.Getenv("TTS_BACKEND")); name {
+	case "":
+		return nil, nil
+	case "piper":
+		p := &Piper{Path: os.Getenv("PIPER_PATH"), Model: os.Getenv("PIPER_MODEL")}
+		if p.Path == "" {
+			p.Path = DefaultPiperPath
+		}
+		if p.Model == "" {
+			return nil, errors.New("PIPER_MODEL: must be set to a piper voice model to use the piper backend")
+		}
+		return p, nil
+	case "api":
+		a := &API{
+			URL:   os.Getenv("TTS_API_URL"),
+			Key:   os.Getenv("TTS_API_KEY"),
+			Model: os.Getenv("TTS_MODEL"),
+			Voice: os.Getenv("TTS_VOICE"),
+		}
+		if a.URL == "" {
+			a.URL = DefaultAPIURL
+		}
+		if a.Model == "" {
+			a.Model = DefaultAPIModel
+		}
+		if a.Voice == "" {
+			a.Voice = DefaultAPIVoice
+		}
+		if a.Key == "" {
+			return nil, errors.New("TTS_API_KEY: must be set to use the api backend")
+		}
+		return a, nil
+	default:
+		return nil, fmt.Errorf("TTS_BACKEND: must be piper or api, got %q", name)
+	}
+}
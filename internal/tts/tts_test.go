@@ -0,0 +1,272 @@
+package tts_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"derrclan.com/moravian-soap/internal/tts"
+)
+
+func TestFromEnv(t *testing.T) {
+	tests := []struct {
+		name     string
+		env      map[string]string
+		wantName string
+		wantErr  bool
+	}{
+		{name: "unset", env: map[string]string{}},
+		{name: "piper", env: map[string]string{"TTS_BACKEND": "piper", "PIPER_MODEL": "/voices/en_US-amy.onnx"}, wantName: "piper:en_US-amy.onnx"},
+		{name: "piper without a model", env: map[string]string{"TTS_BACKEND": "piper"}, wantErr: true},
+		{name: "api", env: map[string]string{"TTS_BACKEND": "API", "TTS_API_KEY": "key"}, wantName: "api:tts-1:alloy"},
+		{name: "api with a voice", env: map[string]string{"TTS_BACKEND": "api", "TTS_API_KEY": "key", "TTS_VOICE": "nova"}, wantName: "api:tts-1:nova"},
+		{name: "api without a key", env: map[string]string{"TTS_BACKEND": "api"}, wantErr: true},
+		{name: "unknown", env: map[string]string{"TTS_BACKEND": "festival"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, key := range []string{"TTS_BACKEND", "PIPER_PATH", "PIPER_MODEL", "TTS_API_URL", "TTS_API_KEY", "TTS_MODEL", "TTS_VOICE"} {
+				t.Setenv(key, tt.env[key])
+			}
+			backend, err := tts.FromEnv()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FromEnv() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			var name string
+			if backend != nil {
+				name = backend.Name()
+			}
+			if name != tt.wantName {
+				t.Errorf("FromEnv() = %q, want %q", name, tt.wantName)
+			}
+		})
+	}
+}
+
+func TestAPISynthesize(t *testing.T) {
+	var inputs []string
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer key" {
+			t.Errorf("Authorization = %q, want the bearer key", got)
+		}
+		var req map[string]string
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decoding request: %v", err)
+		}
+		if req["voice"] != "nova" || req["response_format"] != "mp3" {
+			t.Errorf("request = %v, want the nova voice as mp3", req)
+		}
+		inputs = append(inputs, req["input"])
+		_, _ = io.WriteString(w, "["+req["input"][:5]+"]")
+	}))
+	t.Cleanup(api.Close)
+
+	// The text is too long for one request, so it is read a paragraph at a time.
+	paragraphs := []string{strings.Repeat("Alpha ", 500), strings.Repeat("Bravo ", 500), strings.Repeat("Charlie ", 100)}
+	text := strings.Join(paragraphs, "\n\n")
+	a := &tts.API{URL: api.URL, Key: "key", Model: "tts-1", Voice: "nova"}
+	var out bytes.Buffer
+	if err := a.Synthesize(context.Background(), text, &out); err != nil {
+		t.Fatalf("Synthesize failed: %v", err)
+	}
+	if len(inputs) != 2 || !strings.HasPrefix(inputs[1], "Bravo") {
+		t.Fatalf("read in %d parts, want 2 split between paragraphs", len(inputs))
+	}
+	for _, in := range inputs {
+		if len(in) > 4096 {
+			t.Errorf("part of %d bytes is longer than the API reads", len(in))
+		}
+	}
+	if got := out.String(); got != "[Alpha][Bravo]" {
+		t.Errorf("audio = %q, want the parts' audio in order", got)
+	}
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "quota exceeded", http.StatusTooManyRequests)
+	}))
+	t.Cleanup(failing.Close)
+	a.URL = failing.URL
+	if err := a.Synthesize(context.Background(), "Hello", io.Discard); err == nil || !strings.Contains(err.Error(), "quota exceeded") {
+		t.Errorf("Synthesize with a failing API = %v, want its error", err)
+	}
+}
+
+func TestPiperSynthesize(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake piper is a shell script")
+	}
+	// The fake piper writes the model and the text it reads to its output file.
+	script := filepath.Join(t.TempDir(), "piper")
+	fake := "#!/bin/sh\nmodel=$2\nout=$4\n{ echo \"$model\"; cat; } > \"$out\"\n"
+	if err := os.WriteFile(script, []byte(fake), 0o700); err != nil {
+		t.Fatalf("writing fake piper: %v", err)
+	}
+
+	p := &tts.Piper{Path: script, Model: "amy.onnx"}
+	var out bytes.Buffer
+	if err := p.Synthesize(context.Background(), "In the beginning.", &out); err != nil {
+		t.Fatalf("Synthesize failed: %v", err)
+	}
+	if got, want := out.String(), "amy.onnx\nIn the beginning."; got != want {
+		t.Errorf("audio = %q, want %q", got, want)
+	}
+
+	p.Path = filepath.Join(t.TempDir(), "missing")
+	if err := p.Synthesize(context.Background(), "Hello", io.Discard); err == nil {
+		t.Error("Synthesize with a missing binary succeeded, want an error")
+	}
+}
+
+// fakeBackend reads text aloud as the text itself, counting the texts it reads.
+type fakeBackend struct {
+	reads atomic.Int32
+	err   error
+}
+
+func (b *fakeBackend) Name() string       { return "fake" }
+func (b *fakeBackend) Format() tts.Format { return tts.MP3 }
+
+func (b *fakeBackend) Synthesize(_ context.Context, text string, w io.Writer) error {
+	b.reads.Add(1)
+	if b.err != nil {
+		_, _ = io.WriteString(w, "partial")
+		return b.err
+	}
+	_, err := io.WriteString(w, text)
+	return err
+}
+
+func TestCache(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "tts")
+	backend := &fakeBackend{}
+	cache, err := tts.NewCache(dir, backend)
+	if err != nil {
+		t.Fatalf("NewCache failed: %v", err)
+	}
+
+	read := func(text string) (string, error) {
+		t.Helper()
+		f, err := cache.Open(context.Background(), 1, text)
+		if err != nil {
+			return "", err
+		}
+		defer f.Close()
+		b, err := io.ReadAll(f)
+		return string(b), err
+	}
+	for range 2 {
+		if got, err := read("Observation."); err != nil || got != "Observation." {
+			t.Fatalf("Open = %q, %v; want the audio of the text", got, err)
+		}
+	}
+	if n := backend.reads.Load(); n != 1 {
+		t.Errorf("text read %d times, want once and then from the cache", n)
+	}
+	if _, err := read("Application."); err != nil || backend.reads.Load() != 2 {
+		t.Errorf("Open of another text = %v after %d reads, want it read", err, backend.reads.Load())
+	}
+
+	// A failed read is not cached.
+	backend.err = errors.New("backend down")
+	if _, err := read("Prayer."); err == nil {
+		t.Fatal("Open with a failing backend succeeded")
+	}
+	backend.err = nil
+	if got, err := read("Prayer."); err != nil || got != "Prayer." {
+		t.Errorf("Open after the backend recovered = %q, %v; want the audio of the text", got, err)
+	}
+	entries, err := os.ReadDir(filepath.Join(dir, "1"))
+	if err != nil || len(entries) != 3 {
+		t.Errorf("cache holds %d files, %v; want the 3 texts read", len(entries), err)
+	}
+
+	// Each user's audio is their own, and is removed with them.
+	if f, err := cache.Open(context.Background(), 2, "Prayer."); err != nil {
+		t.Fatalf("Open for another user failed: %v", err)
+	} else {
+		f.Close()
+	}
+	if n := backend.reads.Load(); n != 5 {
+		t.Errorf("text read %d times, want it read again for another user", n)
+	}
+	if err := cache.RemoveUser(1); err != nil {
+		t.Fatalf("RemoveUser failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "1")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("audio of a removed user left behind: %v", err)
+	}
+	if entries, err := os.ReadDir(filepath.Join(dir, "2")); err != nil || len(entries) != 1 {
+		t.Errorf("audio of another user = %d files, %v; want it kept", len(entries), err)
+	}
+}
+
+func TestCache_Prune(t *testing.T) {
+	dir := t.TempDir()
+	cache, err := tts.NewCache(dir, &fakeBackend{})
+	if err != nil {
+		t.Fatalf("NewCache failed: %v", err)
+	}
+	now := time.Now()
+	// Each text is read aloud and then last used the given number of days ago.
+	paths := map[string]string{}
+	for text, days := range map[string]int{"Scripture.": 40, "Observation.": 10, "Application.": 5, "Prayer.": 1} {
+		f, err := cache.Open(context.Background(), 1, text)
+		if err != nil {
+			t.Fatalf("Open failed: %v", err)
+		}
+		f.Close()
+		used := now.AddDate(0, 0, -days)
+		if err := os.Chtimes(f.Name(), used, used); err != nil {
+			t.Fatalf("Chtimes failed: %v", err)
+		}
+		paths[text] = f.Name()
+	}
+	cached := func() []string {
+		var texts []string
+		for text, path := range paths {
+			if _, err := os.Stat(path); err == nil {
+				texts = append(texts, text)
+			}
+		}
+		slices.Sort(texts)
+		return texts
+	}
+
+	if n, err := cache.Prune(now); err != nil || n != 0 || len(cached()) != 4 {
+		t.Errorf("Prune without limits = %d, %v, leaving %v; want nothing removed", n, err, cached())
+	}
+
+	cache.MaxAge = 28 * 24 * time.Hour
+	if n, err := cache.Prune(now); err != nil || n != 1 || slices.Contains(cached(), "Scripture.") {
+		t.Errorf("Prune by age = %d, %v, leaving %v; want the audio unused for 40 days removed", n, err, cached())
+	}
+
+	// The least recently used audio goes first, until the rest fits.
+	cache.MaxBytes = int64(len("Application.") + len("Prayer."))
+	if n, err := cache.Prune(now); err != nil || n != 1 || !slices.Equal(cached(), []string{"Application.", "Prayer."}) {
+		t.Errorf("Prune by size = %d, %v, leaving %v; want the least recently used removed", n, err, cached())
+	}
+
+	// Listening to audio again keeps it.
+	f, err := cache.Open(context.Background(), 1, "Application.")
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	f.Close()
+	cache.MaxBytes = int64(len("Application."))
+	if n, err := cache.Prune(now.Add(time.Minute)); err != nil || n != 1 || !slices.Equal(cached(), []string{"Application."}) {
+		t.Errorf("Prune after a listen = %d, %v, leaving %v; want the audio listened to kept", n, err, cached())
+	}
+}
@@ -0,0 +1,106 @@
+// Package verifier provides a background service that checks a small sample of cached passages against
+// the text the API now returns each day, so that passages corrected upstream are not served stale until
+// their cache entries expire.
+package verifier
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"derrclan.com/moravian-soap/internal/clock"
+	"derrclan.com/moravian-soap/internal/config"
+	"derrclan.com/moravian-soap/internal/esv"
+	"derrclan.com/moravian-soap/internal/esv/cache"
+	"derrclan.com/moravian-soap/internal/maintenance"
+	"derrclan.com/moravian-soap/internal/store"
+)
+
+// SampleSize is the number of cached passages checked each day. It is kept small so that checking
+// costs few of the API's daily requests.
+const SampleSize = 5
+
+// keep is the number of checks kept for the admin, about three months of them.
+const keep = 90
+
+// Fetcher fetches passages from the API, bypassing the cache.
+type Fetcher func(ctx context.Context, references []string, opts esv.Options) (esv.Response, error)
+
+// Start initializes the cache verifier service. It checks a sample of the cached passages every 24
+// hours, the first a day after it starts so that restarts do not spend API requests. Checks are timed
+// with clk.
+func Start(ctx context.Context, s store.CacheStore, clk clock.Clock, fetch Fetcher) {
+	go func() {
+		ticker := time.NewTicker(24 * time.Hour)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				slog.Debug("starting scheduled cache verification")
+				maintenance.Run(func() {
+					if _, err := Verify(ctx, s, fetch, clk.Now(), SampleSize); err != nil {
+						slog.Error("failed to verify cache", "error", err)
+					}
+				})
+			case <-ctx.Done():
+				slog.Info("stopping cache verifier service")
+				return
+			}
+		}
+	}()
+}
+
+// Verify fetches up to n cached passages chosen at random again, refreshes those whose text has
+// changed, and records the check at now. Passages that cannot be fetched are counted as failed; once
+// the API is unavailable the rest are left unchecked.
+func Verify(ctx context.Context, s store.CacheStore, fetch Fetcher, now time.Time, n int) (*store.CacheVerification, error) {
+	cfg := config.Current()
+	c := cache.New(s, cache.Options{TTL: cfg.ESVCacheTTL, MaxEntries: cfg.ESVCacheMaxEntries})
+	sample, err := c.Sample(ctx, n)
+	if err != nil {
+		return nil, fmt.Errorf("sampling cached passages: %w", err)
+	}
+
+	v := &store.CacheVerification{At: now}
+	keys := make([]string, 0, len(sample))
+	for key := range sample {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	for _, key := range keys {
+		refs, opts, err := cache.ParseKey(key)
+		if err != nil {
+			slog.Error("failed to parse cache key", "key", key, "error", err)
+			v.Failed++
+			continue
+		}
+		fresh, err := fetch(ctx, refs, opts)
+		if errors.Is(err, esv.ErrUnavailable) {
+			slog.Warn("stopping cache verification, API unavailable", "key", key)
+			break
+		}
+		if err != nil {
+			slog.Error("failed to fetch passages to verify", "key", key, "error", err)
+			v.Failed++
+			continue
+		}
+		v.Checked++
+		if cached := sample[key]; slices.Equal(cached.Passages, fresh.Passages) && cached.Copyright == fresh.Copyright {
+			continue
+		}
+		v.Changed = append(v.Changed, key)
+		if err := c.Put(ctx, refs, opts, fresh); err != nil {
+			slog.Error("failed to refresh changed passages", "key", key, "error", err)
+		}
+	}
+
+	if err := s.RecordCacheVerification(ctx, v, keep); err != nil {
+		return v, fmt.Errorf("recording cache verification: %w", err)
+	}
+	slog.Info("verified cached passages", "checked", v.Checked, "changed", len(v.Changed), "failed", v.Failed)
+	return v, nil
+}
@@ -0,0 +1,130 @@
+package verifier
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+
+	"derrclan.com/moravian-soap/internal/esv"
+	"derrclan.com/moravian-soap/internal/esv/cache"
+	"derrclan.com/moravian-soap/internal/store/sqlite"
+)
+
+func setupTestDB(t *testing.T) *sql.DB {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("failed to open test db: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	query := `
+	CREATE TABLE esv_cache (
+		reference TEXT PRIMARY KEY,
+		content TEXT NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		size_bytes INTEGER NOT NULL DEFAULT 0,
+		last_accessed DATETIME
+	);
+	CREATE TABLE cache_verifications (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		verified_at DATETIME NOT NULL,
+		checked INTEGER NOT NULL,
+		failed INTEGER NOT NULL,
+		changed TEXT NOT NULL DEFAULT '[]'
+	);`
+	if _, err := db.Exec(query); err != nil {
+		t.Fatalf("failed to create tables: %v", err)
+	}
+	return db
+}
+
+// passage returns the response the API gives for refs, with text as each passage's text.
+func passage(refs []string, text string) esv.Response {
+	passages := make([]string, len(refs))
+	for i, ref := range refs {
+		passages[i] = "<p>" + ref + " " + text + "</p>"
+	}
+	return esv.Response{Query: strings.Join(refs, "; "), Passages: passages, Copyright: "(ESV)"}
+}
+
+func TestVerify(t *testing.T) {
+	db := setupTestDB(t)
+	s := sqlite.New(db)
+	ctx := context.Background()
+	c := cache.New(s, cache.Options{TTL: time.Hour, MaxEntries: 10})
+
+	for _, refs := range [][]string{{"John 1:1"}, {"Psalm 23"}, {"Romans 8:28"}} {
+		if err := c.Put(ctx, refs, esv.DefaultOptions(), passage(refs, "as cached")); err != nil {
+			t.Fatalf("Put(%v) failed: %v", refs, err)
+		}
+	}
+
+	fetch := func(ctx context.Context, refs []string, opts esv.Options) (esv.Response, error) {
+		switch refs[0] {
+		case "Psalm 23":
+			return passage(refs, "as corrected"), nil
+		case "Romans 8:28":
+			return esv.Response{}, errors.New("bad gateway")
+		}
+		return passage(refs, "as cached"), nil
+	}
+	now := time.Date(2026, time.March, 7, 3, 0, 0, 0, time.UTC)
+	v, err := Verify(ctx, s, fetch, now, 10)
+	if err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+	if v.Checked != 2 || v.Failed != 1 || !slices.Equal(v.Changed, []string{"Psalm 23"}) {
+		t.Errorf("Verify = %+v, want 2 checked, 1 failed and Psalm 23 changed", v)
+	}
+
+	got, err := c.Get(ctx, []string{"Psalm 23"}, esv.DefaultOptions())
+	if err != nil {
+		t.Fatalf("Get(Psalm 23) failed: %v", err)
+	}
+	if !slices.Equal(got.Passages, passage([]string{"Psalm 23"}, "as corrected").Passages) {
+		t.Errorf("changed passage was not refreshed: %v", got.Passages)
+	}
+
+	verifications, err := s.ListCacheVerifications(ctx, 10)
+	if err != nil {
+		t.Fatalf("ListCacheVerifications failed: %v", err)
+	}
+	if len(verifications) != 1 || !verifications[0].At.Equal(now) || verifications[0].Checked != 2 {
+		t.Errorf("recorded checks = %+v, want this one", verifications)
+	}
+}
+
+func TestVerify_Unavailable(t *testing.T) {
+	db := setupTestDB(t)
+	s := sqlite.New(db)
+	ctx := context.Background()
+	c := cache.New(s, cache.Options{TTL: time.Hour, MaxEntries: 10})
+
+	for _, refs := range [][]string{{"John 1:1"}, {"Psalm 23"}} {
+		if err := c.Put(ctx, refs, esv.DefaultOptions(), passage(refs, "as cached")); err != nil {
+			t.Fatalf("Put(%v) failed: %v", refs, err)
+		}
+	}
+
+	calls := 0
+	fetch := func(ctx context.Context, refs []string, opts esv.Options) (esv.Response, error) {
+		calls++
+		return esv.Response{}, esv.ErrUnavailable
+	}
+	v, err := Verify(ctx, s, fetch, time.Now(), 10)
+	if err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("fetched %d times, want verification to stop after the API was unavailable", calls)
+	}
+	if v.Checked != 0 || v.Failed != 0 || len(v.Changed) != 0 {
+		t.Errorf("Verify = %+v, want nothing checked", v)
+	}
+}
@@ -0,0 +1,226 @@
+// Package weather looks up the weather at a place, so that journal entries can be stamped with the
+// conditions they were written in. It uses Open-Meteo (https://open-meteo.com), which needs no key.
+package weather
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultForecastURL and DefaultGeocodingURL are the Open-Meteo endpoints. They are overridden by the
+// WEATHER_API_URL and GEOCODING_API_URL environment variables, e.g. to point tests at a fake server.
+const (
+	DefaultForecastURL  = "https://api.open-meteo.com/v1/forecast"
+	DefaultGeocodingURL = "https://geocoding-api.open-meteo.com/v1/search"
+)
+
+// ErrUnknownPlace is returned when looking up a place that cannot be found.
+var ErrUnknownPlace = errors.New("unknown place")
+
+// Client looks up places and their weather.
+type Client struct {
+	ForecastURL  string
+	GeocodingURL string
+	HTTPClient   *http.Client
+}
+
+// FromEnv returns a client of the Open-Meteo endpoints, or of those set by WEATHER_API_URL and
+// GEOCODING_API_URL.
+func FromEnv() *Client {
+	return &Client{
+		ForecastURL:  cmp.Or(os.Getenv("WEATHER_API_URL"), DefaultForecastURL),
+		GeocodingURL: cmp.Or(os.Getenv("GEOCODING_API_URL"), DefaultGeocodingURL),
+		HTTPClient:   &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+// Coarse rounds a coordinate to a tenth of a degree, about 10 km, which is as precisely as a place is
+// recorded.
+func Coarse(degrees float64) float64 {
+	return math.Round(degrees*10) / 10
+}
+
+// Place is a named place and its coordinates.
+type Place struct {
+	Name      string
+	Latitude  float64
+	Longitude float64
+}
+
+// Lookup finds the place best matching name, e.g. "Bethlehem, PA". Only the part of name before the
+// first comma is searched for, and the rest picks among the places found: the first whose region or
+// country it names, by code or name, is chosen, then the first whose region or country name it begins,
+// or else the most populous.
+func (c *Client) Lookup(ctx context.Context, name string) (Place, error) {
+	city, region, _ := strings.Cut(name, ",")
+	city, region = strings.TrimSpace(city), strings.ToLower(strings.TrimSpace(region))
+	params := url.Values{"name": {city}, "count": {"10"}, "format": {"json"}}
+
+	var resp struct {
+		Results []geocodingResult `json:"results"`
+	}
+	if err := c.get(ctx, c.GeocodingURL, params, &resp); err != nil {
+		return Place{}, fmt.Errorf("looking up %q: %w", name, err)
+	}
+	if len(resp.Results) == 0 {
+		return Place{}, fmt.Errorf("looking up %q: %w", name, ErrUnknownPlace)
+	}
+	best := resp.Results[0]
+	if region != "" {
+		for _, match := range []func(r geocodingResult) bool{
+			func(r geocodingResult) bool { return r.named(region, strings.EqualFold) },
+			func(r geocodingResult) bool { return r.named(region, hasFoldedPrefix) },
+		} {
+			if i := slices.IndexFunc(resp.Results, match); i >= 0 {
+				best = resp.Results[i]
+				break
+			}
+		}
+	}
+	return Place{Name: best.Name, Latitude: best.Latitude, Longitude: best.Longitude}, nil
+}
+
+// geocodingResult is a place found by the geocoding API, most populous first.
+type geocodingResult struct {
+	Name        string  `json:"name"`
+	Latitude    float64 `json:"latitude"`
+	Longitude   float64 `json:"longitude"`
+	Admin1      string  `json:"admin1"`
+	Admin1Code  string  `json:"admin1_code"`
+	Country     string  `json:"country"`
+	CountryCode string  `json:"country_code"`
+}
+
+// named reports whether match accepts the place's region or country, by code or name, for region.
+// Codes are only matched exactly.
+func (r geocodingResult) named(region string, match func(s, region string) bool) bool {
+	for _, code := range []string{r.Admin1Code, r.CountryCode} {
+		if code != "" && strings.EqualFold(code, region) {
+			return true
+		}
+	}
+	for _, name := range []string{r.Admin1, r.Country} {
+		if name != "" && match(name, region) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasFoldedPrefix reports whether s begins with prefix, ignoring case.
+func hasFoldedPrefix(s, prefix string) bool {
+	return len(s) >= len(prefix) && strings.EqualFold(s[:len(prefix)], prefix)
+}
+
+// Conditions is the weather at a place at a moment.
+type Conditions struct {
+	// Code is the WMO weather interpretation code, e.g. 61 for light rain.
+	Code int
+	// Celsius is the air temperature.
+	Celsius float64
+}
+
+// String describes the conditions, e.g. "Light rain, 12°C (54°F)".
+func (c Conditions) String() string {
+	fahrenheit := c.Celsius*9/5 + 32
+	return fmt.Sprintf("%s, %.0f°C (%.0f°F)", c.Description(), c.Celsius, fahrenheit)
+}
+
+// Description describes the weather of the conditions' code, e.g. "Light rain".
+func (c Conditions) Description() string {
+	if d, ok := descriptions[c.Code]; ok {
+		return d
+	}
+	return "Weather code " + strconv.Itoa(c.Code)
+}
+
+// descriptions describe the WMO weather interpretation codes that Open-Meteo reports.
+var descriptions = map[int]string{
+	0:  "Clear sky",
+	1:  "Mainly clear",
+	2:  "Partly cloudy",
+	3:  "Overcast",
+	45: "Fog",
+	48: "Freezing fog",
+	51: "Light drizzle",
+	53: "Drizzle",
+	55: "Heavy drizzle",
+	56: "Light freezing drizzle",
+	57: "Freezing drizzle",
+	61: "Light rain",
+	63: "Rain",
+	65: "Heavy rain",
+	66: "Light freezing rain",
+	67: "Freezing rain",
+	71: "Light snow",
+	73: "Snow",
+	75: "Heavy snow",
+	77: "Snow grains",
+	80: "Light showers",
+	81: "Showers",
+	82: "Heavy showers",
+	85: "Light snow showers",
+	86: "Snow showers",
+	95: "Thunderstorm",
+	96: "Thunderstorm with hail",
+	99: "Thunderstorm with heavy hail",
+}
+
+// Current returns the weather now at the coordinates.
+func (c *Client) Current(ctx context.Context, latitude, longitude float64) (Conditions, error) {
+	params := url.Values{
+		"latitude":  {strconv.FormatFloat(latitude, 'f', -1, 64)},
+		"longitude": {strconv.FormatFloat(longitude, 'f', -1, 64)},
+		"current":   {"temperature_2m,weather_code"},
+	}
+	var resp struct {
+		Current *struct {
+			Temperature float64 `json:"temperature_2m"`
+			WeatherCode int     `json:"weather_code"`
+		} `json:"current"`
+	}
+	if err := c.get(ctx, c.ForecastURL, params, &resp); err != nil {
+		return Conditions{}, fmt.Errorf("getting weather: %w", err)
+	}
+	if resp.Current == nil {
+		return Conditions{}, errors.New("getting weather: no current conditions in response")
+	}
+	return Conditions{Code: resp.Current.WeatherCode, Celsius: resp.Current.Temperature}, nil
+}
+
+// get requests endpoint with params and decodes its JSON response into v.
+func (c *Client) get(ctx context.Context, endpoint string, params url.Values, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint+"?"+params.Encode(), nil)
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+	client := c.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("decoding response: %w", err)
+	}
+	return nil
+}
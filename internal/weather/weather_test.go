@@ -0,0 +1,76 @@
+package weather_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"derrclan.com/moravian-soap/internal/weather"
+)
+
+func TestLookup(t *testing.T) {
+	geocoding := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch strings.ToLower(r.URL.Query().Get("name")) {
+		case "bethlehem":
+			_, _ = w.Write([]byte(`{"results": [
+				{"name": "Bethlehem", "latitude": 31.7, "longitude": 35.2, "country": "Palestine", "country_code": "PS"},
+				{"name": "Bethlehem", "latitude": 40.6259, "longitude": -75.3705, "admin1": "Pennsylvania", "admin1_code": "PA", "country": "United States", "country_code": "US"}
+			]}`))
+		default:
+			_, _ = w.Write([]byte(`{}`))
+		}
+	}))
+	t.Cleanup(geocoding.Close)
+	c := &weather.Client{GeocodingURL: geocoding.URL}
+
+	tests := []struct {
+		name          string
+		wantLatitude  float64
+		wantLongitude float64
+	}{
+		{"Bethlehem", 31.7, 35.2},
+		{"Bethlehem, PA", 40.6259, -75.3705},
+		{"bethlehem, pennsylvania", 40.6259, -75.3705},
+		{"Bethlehem, Narnia", 31.7, 35.2},
+	}
+	for _, tt := range tests {
+		place, err := c.Lookup(context.Background(), tt.name)
+		if err != nil {
+			t.Errorf("Lookup(%q) failed: %v", tt.name, err)
+			continue
+		}
+		if place.Latitude != tt.wantLatitude || place.Longitude != tt.wantLongitude {
+			t.Errorf("Lookup(%q) = %+v, want %v, %v", tt.name, place, tt.wantLatitude, tt.wantLongitude)
+		}
+	}
+	if _, err := c.Lookup(context.Background(), "Nowhere"); !errors.Is(err, weather.ErrUnknownPlace) {
+		t.Errorf("Lookup of an unknown place = %v, want ErrUnknownPlace", err)
+	}
+}
+
+func TestCurrent(t *testing.T) {
+	forecast := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("latitude") != "40.6" || q.Get("longitude") != "-75.4" {
+			http.Error(w, "unexpected coordinates", http.StatusBadRequest)
+			return
+		}
+		_, _ = w.Write([]byte(`{"current": {"time": "2026-03-07T07:00", "temperature_2m": 12.2, "weather_code": 61}}`))
+	}))
+	t.Cleanup(forecast.Close)
+	c := &weather.Client{ForecastURL: forecast.URL}
+
+	conditions, err := c.Current(context.Background(), weather.Coarse(40.6259), weather.Coarse(-75.3705))
+	if err != nil {
+		t.Fatalf("Current failed: %v", err)
+	}
+	if got, want := conditions.String(), "Light rain, 12°C (54°F)"; got != want {
+		t.Errorf("conditions = %q, want %q", got, want)
+	}
+	if _, err := c.Current(context.Background(), 0, 0); err == nil {
+		t.Error("Current with a failing API succeeded, want an error")
+	}
+}
@@ -0,0 +1,333 @@
+// Package webauthn implements the relying party side of WebAuthn passkey registration and login.
+//
+// Only what passkeys need is supported: ES256 and RS256 credentials, and the "none" and "packed"
+// attestation formats. Attestation certificates are checked for a valid signature but are not
+// chained to a vendor root, since the server does not restrict which authenticators may be used.
+package webauthn
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// COSE algorithm identifiers of the supported credential types.
+const (
+	AlgES256 = -7
+	AlgRS256 = -257
+)
+
+// Authenticator data flags.
+const (
+	flagUserPresent  = 0x01
+	flagUserVerified = 0x04
+	flagAttestedData = 0x40
+)
+
+var (
+	// ErrChallengeMismatch is returned when the client data was not signed for the expected challenge.
+	ErrChallengeMismatch = errors.New("webauthn: challenge mismatch")
+	// ErrOriginMismatch is returned when the ceremony took place on another origin.
+	ErrOriginMismatch = errors.New("webauthn: origin mismatch")
+	// ErrInvalidSignature is returned when an assertion or attestation signature does not verify.
+	ErrInvalidSignature = errors.New("webauthn: invalid signature")
+	// ErrSignCountRegressed is returned when the signature counter did not increase, which
+	// suggests the credential was cloned.
+	ErrSignCountRegressed = errors.New("webauthn: signature counter did not increase")
+	// ErrUserNotVerified is returned when the authenticator did not verify the user with biometrics or a PIN.
+	ErrUserNotVerified = errors.New("webauthn: user not verified")
+)
+
+// RelyingParty identifies the site credentials are registered with.
+type RelyingParty struct {
+	// ID is the registrable domain the credentials are scoped to, e.g. "soap.example.com".
+	ID string
+	// Name is shown to the user by the authenticator.
+	Name string
+	// Origin is the web origin ceremonies must take place on, e.g. "https://soap.example.com".
+	Origin string
+}
+
+// Credential is a verified public key credential.
+type Credential struct {
+	ID []byte
+	// PublicKey is the credential's public key in PKIX, ASN.1 DER form.
+	PublicKey []byte
+	SignCount uint32
+}
+
+// NewChallenge returns a random challenge, base64url-encoded as it appears in client data.
+func NewChallenge() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("reading random challenge: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// clientData is the subset of CollectedClientData that is verified.
+type clientData struct {
+	Type      string `json:"type"`
+	Challenge string `json:"challenge"`
+	Origin    string `json:"origin"`
+}
+
+// ChallengeOf returns the challenge a client data JSON was created for, so the server can look up
+// the ceremony it belongs to.
+func ChallengeOf(clientDataJSON []byte) (string, error) {
+	var cd clientData
+	if err := json.Unmarshal(clientDataJSON, &cd); err != nil {
+		return "", fmt.Errorf("webauthn: decoding client data: %w", err)
+	}
+	return cd.Challenge, nil
+}
+
+// verifyClientData checks the type, challenge and origin of the client data.
+func (rp RelyingParty) verifyClientData(clientDataJSON []byte, ceremony, challenge string) error {
+	var cd clientData
+	if err := json.Unmarshal(clientDataJSON, &cd); err != nil {
+		return fmt.Errorf("webauthn: decoding client data: %w", err)
+	}
+	if cd.Type != ceremony {
+		return fmt.Errorf("webauthn: client data type %q, want %q", cd.Type, ceremony)
+	}
+	if subtle.ConstantTimeCompare([]byte(cd.Challenge), []byte(challenge)) != 1 {
+		return ErrChallengeMismatch
+	}
+	if cd.Origin != rp.Origin {
+		return fmt.Errorf("%w: %q", ErrOriginMismatch, cd.Origin)
+	}
+	return nil
+}
+
+// authenticatorData is the parsed form of the authenticator data.
+type authenticatorData struct {
+	rpIDHash     []byte
+	flags        byte
+	signCount    uint32
+	credentialID []byte
+	publicKey    map[any]any
+}
+
+// parseAuthenticatorData parses authenticator data, including the attested credential data if present.
+func parseAuthenticatorData(data []byte) (*authenticatorData, error) {
+	if len(data) < 37 {
+		return nil, errors.New("webauthn: authenticator data too short")
+	}
+	ad := &authenticatorData{
+		rpIDHash:  data[:32],
+		flags:     data[32],
+		signCount: binary.BigEndian.Uint32(data[33:37]),
+	}
+	if ad.flags&flagAttestedData == 0 {
+		return ad, nil
+	}
+
+	rest := data[37:]
+	if len(rest) < 18 {
+		return nil, errors.New("webauthn: attested credential data too short")
+	}
+	idLen := int(binary.BigEndian.Uint16(rest[16:18]))
+	rest = rest[18:]
+	if len(rest) < idLen {
+		return nil, errors.New("webauthn: credential ID truncated")
+	}
+	ad.credentialID = rest[:idLen]
+
+	key, _, err := decodeCBOR(rest[idLen:])
+	if err != nil {
+		return nil, fmt.Errorf("webauthn: decoding credential public key: %w", err)
+	}
+	m, ok := key.(map[any]any)
+	if !ok {
+		return nil, errors.New("webauthn: credential public key is not a map")
+	}
+	ad.publicKey = m
+	return ad, nil
+}
+
+// verify checks that the authenticator data belongs to the relying party and that the user was present
+// and verified, by biometrics or a PIN, so that a passkey is never just something the user has.
+func (ad *authenticatorData) verify(rp RelyingParty) error {
+	rpIDHash := sha256.Sum256([]byte(rp.ID))
+	if !bytes.Equal(ad.rpIDHash, rpIDHash[:]) {
+		return errors.New("webauthn: relying party ID mismatch")
+	}
+	if ad.flags&flagUserPresent == 0 {
+		return errors.New("webauthn: user not present")
+	}
+	if ad.flags&flagUserVerified == 0 {
+		return ErrUserNotVerified
+	}
+	return nil
+}
+
+// parseCOSEKey converts a COSE_Key to a public key and its algorithm.
+func parseCOSEKey(m map[any]any) (crypto.PublicKey, int64, error) {
+	kty, _ := m[int64(1)].(int64)
+	alg, _ := m[int64(3)].(int64)
+	switch {
+	case kty == 2 && alg == AlgES256:
+		crv, _ := m[int64(-1)].(int64)
+		x, _ := m[int64(-2)].([]byte)
+		y, _ := m[int64(-3)].([]byte)
+		if crv != 1 || len(x) != 32 || len(y) != 32 {
+			return nil, 0, errors.New("webauthn: invalid P-256 key")
+		}
+		key := &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if _, err := key.ECDH(); err != nil {
+			return nil, 0, fmt.Errorf("webauthn: invalid P-256 point: %w", err)
+		}
+		return key, alg, nil
+	case kty == 3 && alg == AlgRS256:
+		n, _ := m[int64(-1)].([]byte)
+		e, _ := m[int64(-2)].([]byte)
+		if len(n) < 256 || len(e) == 0 || len(e) > 4 {
+			return nil, 0, errors.New("webauthn: invalid RSA key")
+		}
+		exp := 0
+		for _, b := range e {
+			exp = exp<<8 | int(b)
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: exp}, alg, nil
+	default:
+		return nil, 0, fmt.Errorf("webauthn: unsupported key type %d with algorithm %d", kty, alg)
+	}
+}
+
+// verifySignature checks a signature over data made with the private key of pub.
+func verifySignature(pub crypto.PublicKey, data, sig []byte) error {
+	digest := sha256.Sum256(data)
+	switch k := pub.(type) {
+	case *ecdsa.PublicKey:
+		if !ecdsa.VerifyASN1(k, digest[:], sig) {
+			return ErrInvalidSignature
+		}
+		return nil
+	case *rsa.PublicKey:
+		if err := rsa.VerifyPKCS1v15(k, crypto.SHA256, digest[:], sig); err != nil {
+			return ErrInvalidSignature
+		}
+		return nil
+	default:
+		return fmt.Errorf("webauthn: unsupported public key type %T", pub)
+	}
+}
+
+// VerifyRegistration verifies the response to a credential creation request made with challenge and
+// returns the new credential.
+func (rp RelyingParty) VerifyRegistration(challenge string, clientDataJSON, attestationObject []byte) (*Credential, error) {
+	if err := rp.verifyClientData(clientDataJSON, "webauthn.create", challenge); err != nil {
+		return nil, err
+	}
+
+	obj, _, err := decodeCBOR(attestationObject)
+	if err != nil {
+		return nil, fmt.Errorf("webauthn: decoding attestation object: %w", err)
+	}
+	att, ok := obj.(map[any]any)
+	if !ok {
+		return nil, errors.New("webauthn: attestation object is not a map")
+	}
+	format, _ := att["fmt"].(string)
+	attStmt, _ := att["attStmt"].(map[any]any)
+	rawAuthData, _ := att["authData"].([]byte)
+
+	ad, err := parseAuthenticatorData(rawAuthData)
+	if err != nil {
+		return nil, err
+	}
+	if err := ad.verify(rp); err != nil {
+		return nil, err
+	}
+	if ad.publicKey == nil {
+		return nil, errors.New("webauthn: no attested credential data")
+	}
+	pub, alg, err := parseCOSEKey(ad.publicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	signed := append(bytes.Clone(rawAuthData), clientDataHash[:]...)
+	switch format {
+	case "none":
+	case "packed":
+		if err := verifyPacked(attStmt, signed, pub, alg); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("webauthn: unsupported attestation format %q", format)
+	}
+
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return nil, fmt.Errorf("webauthn: encoding public key: %w", err)
+	}
+	return &Credential{ID: bytes.Clone(ad.credentialID), PublicKey: der, SignCount: ad.signCount}, nil
+}
+
+// verifyPacked verifies a "packed" attestation statement, either self attestation signed by the
+// credential itself or full attestation signed by the certificate in x5c.
+func verifyPacked(attStmt map[any]any, signed []byte, credKey crypto.PublicKey, credAlg int64) error {
+	alg, _ := attStmt["alg"].(int64)
+	sig, _ := attStmt["sig"].([]byte)
+	x5c, _ := attStmt["x5c"].([]any)
+	if len(x5c) == 0 {
+		if alg != credAlg {
+			return errors.New("webauthn: self attestation algorithm mismatch")
+		}
+		return verifySignature(credKey, signed, sig)
+	}
+
+	der, _ := x5c[0].([]byte)
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return fmt.Errorf("webauthn: parsing attestation certificate: %w", err)
+	}
+	return verifySignature(cert.PublicKey, signed, sig)
+}
+
+// VerifyAssertion verifies the response to a credential request made with challenge, signed by cred.
+// It returns the new signature counter to store for the credential.
+func (rp RelyingParty) VerifyAssertion(challenge string, cred *Credential, clientDataJSON, rawAuthData, signature []byte) (uint32, error) {
+	if err := rp.verifyClientData(clientDataJSON, "webauthn.get", challenge); err != nil {
+		return 0, err
+	}
+
+	ad, err := parseAuthenticatorData(rawAuthData)
+	if err != nil {
+		return 0, err
+	}
+	if err := ad.verify(rp); err != nil {
+		return 0, err
+	}
+
+	pub, err := x509.ParsePKIXPublicKey(cred.PublicKey)
+	if err != nil {
+		return 0, fmt.Errorf("webauthn: parsing stored public key: %w", err)
+	}
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	signed := append(bytes.Clone(rawAuthData), clientDataHash[:]...)
+	if err := verifySignature(pub, signed, signature); err != nil {
+		return 0, err
+	}
+
+	// Authenticators that do not count signatures, such as synced passkeys, always report zero.
+	if (ad.signCount != 0 || cred.SignCount != 0) && ad.signCount <= cred.SignCount {
+		return 0, ErrSignCountRegressed
+	}
+	return ad.signCount, nil
+}
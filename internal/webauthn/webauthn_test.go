@@ -0,0 +1,299 @@
+package webauthn
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+// cborPair is a map entry for encodeCBOR; maps are encoded in the given order.
+type cborPair struct {
+	key, value any
+}
+
+// encodeCBOR encodes the subset of CBOR produced by authenticators, for building test fixtures.
+func encodeCBOR(v any) []byte {
+	head := func(major byte, n uint64) []byte {
+		switch {
+		case n < 24:
+			return []byte{major<<5 | byte(n)}
+		case n < 1<<8:
+			return []byte{major<<5 | 24, byte(n)}
+		default:
+			return binary.BigEndian.AppendUint16([]byte{major<<5 | 25}, uint16(n))
+		}
+	}
+	switch v := v.(type) {
+	case int:
+		if v < 0 {
+			return head(1, uint64(-1-v))
+		}
+		return head(0, uint64(v))
+	case []byte:
+		return append(head(2, uint64(len(v))), v...)
+	case string:
+		return append(head(3, uint64(len(v))), v...)
+	case []any:
+		b := head(4, uint64(len(v)))
+		for _, item := range v {
+			b = append(b, encodeCBOR(item)...)
+		}
+		return b
+	case []cborPair:
+		b := head(5, uint64(len(v)))
+		for _, p := range v {
+			b = append(b, encodeCBOR(p.key)...)
+			b = append(b, encodeCBOR(p.value)...)
+		}
+		return b
+	default:
+		panic("unsupported type")
+	}
+}
+
+// authenticator is a software authenticator holding a single P-256 credential.
+type authenticator struct {
+	key       *ecdsa.PrivateKey
+	id        []byte
+	signCount uint32
+	// unverified leaves the user verified flag clear, as a security key without a PIN does.
+	unverified bool
+}
+
+func newAuthenticator(t *testing.T) *authenticator {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generating key: %v", err)
+	}
+	return &authenticator{key: key, id: []byte("credential-1")}
+}
+
+func (a *authenticator) authData(rpID string, attested bool) []byte {
+	rpIDHash := sha256.Sum256([]byte(rpID))
+	flags := byte(flagUserPresent | flagUserVerified)
+	if a.unverified {
+		flags &^= flagUserVerified
+	}
+	if attested {
+		flags |= flagAttestedData
+	}
+	data := append(rpIDHash[:], flags)
+	data = binary.BigEndian.AppendUint32(data, a.signCount)
+	if attested {
+		data = append(data, make([]byte, 16)...) // AAGUID
+		data = binary.BigEndian.AppendUint16(data, uint16(len(a.id)))
+		data = append(data, a.id...)
+		data = append(data, encodeCBOR([]cborPair{
+			{1, 2},
+			{3, AlgES256},
+			{-1, 1},
+			{-2, a.key.X.FillBytes(make([]byte, 32))},
+			{-3, a.key.Y.FillBytes(make([]byte, 32))},
+		})...)
+	}
+	return data
+}
+
+func (a *authenticator) sign(t *testing.T, authData, clientDataJSON []byte) []byte {
+	t.Helper()
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	digest := sha256.Sum256(append(authData, clientDataHash[:]...))
+	sig, err := ecdsa.SignASN1(rand.Reader, a.key, digest[:])
+	if err != nil {
+		t.Fatalf("signing: %v", err)
+	}
+	return sig
+}
+
+func clientDataJSON(t *testing.T, ceremony, challenge, origin string) []byte {
+	t.Helper()
+	b, err := json.Marshal(map[string]any{"type": ceremony, "challenge": challenge, "origin": origin, "crossOrigin": false})
+	if err != nil {
+		t.Fatalf("marshaling client data: %v", err)
+	}
+	return b
+}
+
+var testRP = RelyingParty{ID: "soap.example.com", Name: "Daily SOAP", Origin: "https://soap.example.com"}
+
+func TestVerifyRegistration(t *testing.T) {
+	a := newAuthenticator(t)
+	challenge, err := NewChallenge()
+	if err != nil {
+		t.Fatalf("NewChallenge failed: %v", err)
+	}
+	authData := a.authData(testRP.ID, true)
+
+	t.Run("None attestation", func(t *testing.T) {
+		cd := clientDataJSON(t, "webauthn.create", challenge, testRP.Origin)
+		att := encodeCBOR([]cborPair{{"fmt", "none"}, {"attStmt", []cborPair{}}, {"authData", authData}})
+		cred, err := testRP.VerifyRegistration(challenge, cd, att)
+		if err != nil {
+			t.Fatalf("VerifyRegistration failed: %v", err)
+		}
+		if string(cred.ID) != string(a.id) {
+			t.Errorf("credential ID = %q, want %q", cred.ID, a.id)
+		}
+		if got, err := ChallengeOf(cd); err != nil || got != challenge {
+			t.Errorf("ChallengeOf() = %q, %v; want %q", got, err, challenge)
+		}
+	})
+
+	t.Run("Packed self attestation", func(t *testing.T) {
+		cd := clientDataJSON(t, "webauthn.create", challenge, testRP.Origin)
+		attStmt := []cborPair{{"alg", AlgES256}, {"sig", a.sign(t, authData, cd)}}
+		att := encodeCBOR([]cborPair{{"fmt", "packed"}, {"attStmt", attStmt}, {"authData", authData}})
+		if _, err := testRP.VerifyRegistration(challenge, cd, att); err != nil {
+			t.Fatalf("VerifyRegistration failed: %v", err)
+		}
+
+		other := newAuthenticator(t)
+		attStmt = []cborPair{{"alg", AlgES256}, {"sig", other.sign(t, authData, cd)}}
+		att = encodeCBOR([]cborPair{{"fmt", "packed"}, {"attStmt", attStmt}, {"authData", authData}})
+		if _, err := testRP.VerifyRegistration(challenge, cd, att); !errors.Is(err, ErrInvalidSignature) {
+			t.Errorf("expected ErrInvalidSignature, got %v", err)
+		}
+	})
+
+	t.Run("Rejected ceremonies", func(t *testing.T) {
+		att := encodeCBOR([]cborPair{{"fmt", "none"}, {"attStmt", []cborPair{}}, {"authData", authData}})
+		tests := []struct {
+			name string
+			rp   RelyingParty
+			cd   []byte
+		}{
+			{"wrong type", testRP, clientDataJSON(t, "webauthn.get", challenge, testRP.Origin)},
+			{"wrong challenge", testRP, clientDataJSON(t, "webauthn.create", "other", testRP.Origin)},
+			{"wrong origin", testRP, clientDataJSON(t, "webauthn.create", challenge, "https://evil.example.com")},
+			{"wrong relying party", RelyingParty{ID: "evil.example.com", Origin: testRP.Origin}, clientDataJSON(t, "webauthn.create", challenge, testRP.Origin)},
+		}
+		for _, tt := range tests {
+			if _, err := tt.rp.VerifyRegistration(challenge, tt.cd, att); err == nil {
+				t.Errorf("%s: expected error", tt.name)
+			}
+		}
+	})
+
+	t.Run("User not verified", func(t *testing.T) {
+		a.unverified = true
+		defer func() { a.unverified = false }()
+		cd := clientDataJSON(t, "webauthn.create", challenge, testRP.Origin)
+		att := encodeCBOR([]cborPair{{"fmt", "none"}, {"attStmt", []cborPair{}}, {"authData", a.authData(testRP.ID, true)}})
+		if _, err := testRP.VerifyRegistration(challenge, cd, att); !errors.Is(err, ErrUserNotVerified) {
+			t.Errorf("expected ErrUserNotVerified, got %v", err)
+		}
+	})
+
+	t.Run("Unsupported format", func(t *testing.T) {
+		cd := clientDataJSON(t, "webauthn.create", challenge, testRP.Origin)
+		att := encodeCBOR([]cborPair{{"fmt", "tpm"}, {"attStmt", []cborPair{}}, {"authData", authData}})
+		if _, err := testRP.VerifyRegistration(challenge, cd, att); err == nil {
+			t.Error("expected error for unsupported attestation format")
+		}
+	})
+}
+
+func TestVerifyAssertion(t *testing.T) {
+	a := newAuthenticator(t)
+	challenge := "login-challenge"
+	regChallenge := "register-challenge"
+	att := encodeCBOR([]cborPair{{"fmt", "none"}, {"attStmt", []cborPair{}}, {"authData", a.authData(testRP.ID, true)}})
+	cred, err := testRP.VerifyRegistration(regChallenge, clientDataJSON(t, "webauthn.create", regChallenge, testRP.Origin), att)
+	if err != nil {
+		t.Fatalf("VerifyRegistration failed: %v", err)
+	}
+
+	t.Run("Valid", func(t *testing.T) {
+		a.signCount = 5
+		authData := a.authData(testRP.ID, false)
+		cd := clientDataJSON(t, "webauthn.get", challenge, testRP.Origin)
+		count, err := testRP.VerifyAssertion(challenge, cred, cd, authData, a.sign(t, authData, cd))
+		if err != nil {
+			t.Fatalf("VerifyAssertion failed: %v", err)
+		}
+		if count != 5 {
+			t.Errorf("sign count = %d, want 5", count)
+		}
+		cred.SignCount = count
+	})
+
+	t.Run("Counter regressed", func(t *testing.T) {
+		a.signCount = 5
+		authData := a.authData(testRP.ID, false)
+		cd := clientDataJSON(t, "webauthn.get", challenge, testRP.Origin)
+		if _, err := testRP.VerifyAssertion(challenge, cred, cd, authData, a.sign(t, authData, cd)); !errors.Is(err, ErrSignCountRegressed) {
+			t.Errorf("expected ErrSignCountRegressed, got %v", err)
+		}
+	})
+
+	t.Run("Counter not used", func(t *testing.T) {
+		a.signCount = 0
+		synced := &Credential{ID: cred.ID, PublicKey: cred.PublicKey}
+		authData := a.authData(testRP.ID, false)
+		cd := clientDataJSON(t, "webauthn.get", challenge, testRP.Origin)
+		if _, err := testRP.VerifyAssertion(challenge, synced, cd, authData, a.sign(t, authData, cd)); err != nil {
+			t.Errorf("VerifyAssertion failed: %v", err)
+		}
+	})
+
+	t.Run("Signed by another key", func(t *testing.T) {
+		other := newAuthenticator(t)
+		other.signCount = 9
+		authData := other.authData(testRP.ID, false)
+		cd := clientDataJSON(t, "webauthn.get", challenge, testRP.Origin)
+		if _, err := testRP.VerifyAssertion(challenge, cred, cd, authData, other.sign(t, authData, cd)); !errors.Is(err, ErrInvalidSignature) {
+			t.Errorf("expected ErrInvalidSignature, got %v", err)
+		}
+	})
+
+	t.Run("User not verified", func(t *testing.T) {
+		a.signCount = 11
+		a.unverified = true
+		defer func() { a.unverified = false }()
+		authData := a.authData(testRP.ID, false)
+		cd := clientDataJSON(t, "webauthn.get", challenge, testRP.Origin)
+		if _, err := testRP.VerifyAssertion(challenge, cred, cd, authData, a.sign(t, authData, cd)); !errors.Is(err, ErrUserNotVerified) {
+			t.Errorf("expected ErrUserNotVerified, got %v", err)
+		}
+	})
+
+	t.Run("Wrong challenge", func(t *testing.T) {
+		a.signCount = 10
+		authData := a.authData(testRP.ID, false)
+		cd := clientDataJSON(t, "webauthn.get", "replayed", testRP.Origin)
+		if _, err := testRP.VerifyAssertion(challenge, cred, cd, authData, a.sign(t, authData, cd)); !errors.Is(err, ErrChallengeMismatch) {
+			t.Errorf("expected ErrChallengeMismatch, got %v", err)
+		}
+	})
+}
+
+func TestDecodeCBOR(t *testing.T) {
+	data := encodeCBOR([]cborPair{{"a", []any{1, -300, "x"}}, {-2, []byte{1, 2}}})
+	v, rest, err := decodeCBOR(append(data, 0xff))
+	if err != nil {
+		t.Fatalf("decodeCBOR failed: %v", err)
+	}
+	if len(rest) != 1 {
+		t.Errorf("expected 1 trailing byte, got %d", len(rest))
+	}
+	m := v.(map[any]any)
+	arr := m["a"].([]any)
+	if arr[0] != int64(1) || arr[1] != int64(-300) || arr[2] != "x" {
+		t.Errorf("unexpected array: %v", arr)
+	}
+	if b := m[int64(-2)].([]byte); len(b) != 2 {
+		t.Errorf("unexpected byte string: %v", b)
+	}
+
+	for i := range data {
+		if _, _, err := decodeCBOR(data[:i]); err == nil {
+			t.Errorf("expected error decoding %d of %d bytes", i, len(data))
+		}
+	}
+}
@@ -0,0 +1,233 @@
+// Package yearbook makes printable yearbooks of users' journals: a page holding every day of a year
+// with its watchword and doctrinal text and what the user wrote, a divider before each month, and an
+// index of the verses they highlighted. The page is styled for printing, so that a browser saves it as
+// a typeset PDF. Yearbooks are made by a background job, since a year of passages and entries takes a
+// while to gather.
+package yearbook
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"html/template"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"derrclan.com/moravian-soap/internal/config"
+	"derrclan.com/moravian-soap/internal/dailytexts"
+	"derrclan.com/moravian-soap/internal/esv"
+	"derrclan.com/moravian-soap/internal/journal"
+	"derrclan.com/moravian-soap/internal/maintenance"
+	"derrclan.com/moravian-soap/internal/markdown"
+	"derrclan.com/moravian-soap/internal/store"
+)
+
+// ErrNoEntries is returned by Build for a year the user wrote nothing in.
+var ErrNoEntries = errors.New("no journal entries in the year")
+
+// Book is a year of a user's journal laid out for printing.
+type Book struct {
+	Year   int
+	Months []*Month
+	// Journaled is the number of days the user wrote an entry on.
+	Journaled int
+	// Index lists the verses the user highlighted, in the order of the Bible.
+	Index []*IndexEntry
+}
+
+// Month is a month of a yearbook, which begins on a page of its own.
+type Month struct {
+	Name      string
+	Days      []*Day
+	Journaled int
+}
+
+// Day is a day of a yearbook.
+type Day struct {
+	Date      string
+	Title     string
+	Watchword string
+	Doctrinal string
+	// Reference is the passage the entry was written on: the verses highlighted or else the day's
+	// readings.
+	Reference string
+	// Sections are the sections of the entry that were written. A day without any has no entry.
+	Sections []journal.Entry
+}
+
+// IndexEntry is a highlighted passage and the days it was highlighted on.
+type IndexEntry struct {
+	Reference string
+	Dates     []string
+	// first is the ID of the first verse of the passage, by which the index is ordered.
+	first string
+}
+
+// Build lays out a user's journal of year, with every day's texts and the entries they wrote. It
+// returns ErrNoEntries if they wrote none that year.
+func Build(ctx context.Context, s store.Store, userID int64, year int) (*Book, error) {
+	entries, err := s.ListSOAPData(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("listing entries: %w", err)
+	}
+	prefix := fmt.Sprintf("%d-", year)
+	byDate := map[string]*store.SOAPData{}
+	for _, e := range entries {
+		if strings.HasPrefix(e.Date, prefix) {
+			byDate[e.Date] = e
+		}
+	}
+	prefs, err := s.GetPreferences(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("getting preferences: %w", err)
+	}
+	schema, err := journal.ParseSchema(prefs.JournalSchema)
+	if err != nil {
+		schema = journal.DefaultSchema()
+	}
+
+	b := &Book{Year: year}
+	index := map[string]*IndexEntry{}
+	for d := time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC); d.Year() == year; d = d.AddDate(0, 0, 1) {
+		if d.Day() == 1 {
+			b.Months = append(b.Months, &Month{Name: d.Month().String()})
+		}
+		month := b.Months[len(b.Months)-1]
+		date := d.Format(time.DateOnly)
+		day := &Day{Date: date, Title: d.Format("Monday, January 2")}
+		text, err := dailytexts.GetDailyText(date)
+		if err != nil {
+			return nil, fmt.Errorf("getting daily text for %s: %w", date, err)
+		}
+		if text != nil {
+			day.Watchword, day.Doctrinal = text.DailyWatchWord, text.Doctrinal
+			day.Reference = strings.Join(text.Verses, "; ")
+		}
+		if e, ok := byDate[date]; ok {
+			for _, sec := range schema.Entries(e.Sections) {
+				if strings.TrimSpace(sec.Content) != "" {
+					day.Sections = append(day.Sections, sec)
+				}
+			}
+			if len(e.SelectedVerses) > 0 {
+				day.Reference = esv.FormatReferences(e.SelectedVerses)
+				ie, ok := index[day.Reference]
+				if !ok {
+					ie = &IndexEntry{Reference: day.Reference, first: slices.Min(e.SelectedVerses)}
+					index[day.Reference] = ie
+				}
+				ie.Dates = append(ie.Dates, date)
+			}
+		}
+		if len(day.Sections) > 0 {
+			month.Journaled++
+			b.Journaled++
+		}
+		month.Days = append(month.Days, day)
+	}
+	if b.Journaled == 0 {
+		return nil, ErrNoEntries
+	}
+	for _, ie := range index {
+		b.Index = append(b.Index, ie)
+	}
+	slices.SortFunc(b.Index, func(a, b *IndexEntry) int {
+		if c := strings.Compare(a.first, b.first); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Reference, b.Reference)
+	})
+	return b, nil
+}
+
+//go:embed yearbook.html
+var pageTemplate string
+
+var page = template.Must(template.New("yearbook").Funcs(template.FuncMap{
+	"content": content,
+	"shortDate": func(date string) string {
+		t, err := time.Parse(time.DateOnly, date)
+		if err != nil {
+			return date
+		}
+		return t.Format("Jan 2")
+	},
+}).Parse(pageTemplate))
+
+// content renders what was written in a section, as Markdown if RENDER_MARKDOWN is set.
+func content(s string) template.HTML {
+	if config.Current().RenderMarkdown {
+		return markdown.Render(s)
+	}
+	return template.HTML("<p>" + template.HTMLEscapeString(s) + "</p>") // #nosec G203
+}
+
+// Render renders b as a page styled for printing.
+func Render(b *Book) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := page.Execute(&buf, b); err != nil {
+		return nil, fmt.Errorf("rendering yearbook: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// Make builds and renders a user's yearbook of year and records it, or records why it could not be
+// made.
+func Make(ctx context.Context, s store.Store, userID int64, year int) error {
+	b, err := Build(ctx, s, userID, year)
+	if errors.Is(err, ErrNoEntries) {
+		return s.SaveYearbook(ctx, userID, year, nil, fmt.Sprintf("You wrote no entries in %d.", year))
+	} else if err != nil {
+		return err
+	}
+	html, err := Render(b)
+	if err != nil {
+		return err
+	}
+	return s.SaveYearbook(ctx, userID, year, html, "")
+}
+
+// MakeQueued makes the yearbooks waiting to be made. A yearbook that fails to be made is marked failed
+// so that it is not tried again until the user asks again.
+func MakeQueued(ctx context.Context, s store.Store) {
+	queued, err := s.ListQueuedYearbooks(ctx)
+	if err != nil {
+		slog.Error("failed to list queued yearbooks", "error", err)
+		return
+	}
+	for _, y := range queued {
+		if err := Make(ctx, s, y.UserID, y.Year); err != nil {
+			slog.Error("failed to make yearbook", "user_id", y.UserID, "year", y.Year, "error", err)
+			if err := s.SaveYearbook(ctx, y.UserID, y.Year, nil, "Your yearbook could not be made. Try again later."); err != nil {
+				slog.Error("failed to record yearbook failure", "user_id", y.UserID, "year", y.Year, "error", err)
+			}
+			continue
+		}
+		slog.Info("made yearbook", "user_id", y.UserID, "year", y.Year)
+	}
+}
+
+// interval is how often the queue of yearbooks is checked.
+const interval = time.Minute
+
+// Start makes the queued yearbooks every minute until ctx is cancelled.
+func Start(ctx context.Context, s store.Store) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				maintenance.Run(func() { MakeQueued(ctx, s) })
+			case <-ctx.Done():
+				slog.Info("stopping yearbook service")
+				return
+			}
+		}
+	}()
+}
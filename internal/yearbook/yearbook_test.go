@@ -0,0 +1,100 @@
+package yearbook_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"derrclan.com/moravian-soap/internal/store"
+	"derrclan.com/moravian-soap/internal/store/sqlite"
+	"derrclan.com/moravian-soap/internal/testutil"
+	"derrclan.com/moravian-soap/internal/yearbook"
+)
+
+func TestBuild(t *testing.T) {
+	db := testutil.NewDB(t)
+	s := sqlite.New(db)
+	ctx := context.Background()
+	if _, err := db.Exec(`INSERT INTO users (id, email, password_hash, is_verified) VALUES (1, 'reader@example.com', 'x', 1)`); err != nil {
+		t.Fatalf("inserting user: %v", err)
+	}
+	if _, err := yearbook.Build(ctx, s, 1, 2026); !errors.Is(err, yearbook.ErrNoEntries) {
+		t.Fatalf("Build without entries: err = %v, want ErrNoEntries", err)
+	}
+
+	entries := []*store.SOAPData{
+		{Date: "2025-12-31", Sections: map[string]string{"observation": "Last year"}},
+		{Date: "2026-01-05", Sections: map[string]string{"observation": "Shepherd"}, SelectedVerses: []string{"19023001"}},
+		{Date: "2026-03-07", Sections: map[string]string{"prayer": "Grace <b>abounds</b>"}, SelectedVerses: []string{"45008001", "45008002"}},
+		{Date: "2026-03-08", Sections: map[string]string{"observation": "Again"}, SelectedVerses: []string{"19023001"}},
+	}
+	for _, e := range entries {
+		if err := s.SaveSOAPData(ctx, 1, e); err != nil {
+			t.Fatalf("SaveSOAPData failed: %v", err)
+		}
+	}
+
+	b, err := yearbook.Build(ctx, s, 1, 2026)
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	if len(b.Months) != 12 || len(b.Months[0].Days) != 31 || len(b.Months[1].Days) != 28 {
+		t.Fatalf("Build laid out %d months, want every day of 2026", len(b.Months))
+	}
+	if b.Journaled != 3 || b.Months[0].Journaled != 1 || b.Months[2].Journaled != 2 {
+		t.Errorf("journaled = %d (January %d, March %d), want 3 (1, 2)", b.Journaled, b.Months[0].Journaled, b.Months[2].Journaled)
+	}
+	jan5 := b.Months[0].Days[4]
+	if jan5.Watchword == "" || jan5.Reference != "Psalm 23:1" || len(jan5.Sections) != 1 || jan5.Sections[0].Content != "Shepherd" {
+		t.Errorf("January 5 = %+v, want its watchword and entry", jan5)
+	}
+	if jan6 := b.Months[0].Days[5]; jan6.Watchword == "" || len(jan6.Sections) != 0 {
+		t.Errorf("January 6 = %+v, want its watchword without an entry", jan6)
+	}
+	if len(b.Index) != 2 || b.Index[0].Reference != "Psalm 23:1" || strings.Join(b.Index[0].Dates, ",") != "2026-01-05,2026-03-08" ||
+		b.Index[1].Reference != "Romans 8:1-2" {
+		t.Errorf("index = %+v, want Psalm 23:1 on two days, then Romans 8:1-2", b.Index)
+	}
+
+	page, err := yearbook.Render(b)
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	for _, want := range []string{"3 days journaled", `id="March"`, "Monday, January 5", "Shepherd", "Grace &lt;b&gt;abounds&lt;/b&gt;", "Index of Highlighted Verses", `href="#day-2026-03-08">Mar 8<`} {
+		if !strings.Contains(string(page), want) {
+			t.Errorf("yearbook does not contain %q", want)
+		}
+	}
+	if strings.Contains(string(page), "Last year") {
+		t.Errorf("yearbook of 2026 contains an entry of 2025")
+	}
+}
+
+func TestMakeQueued(t *testing.T) {
+	db := testutil.NewDB(t)
+	s := sqlite.New(db)
+	ctx := context.Background()
+	if _, err := db.Exec(`INSERT INTO users (id, email, password_hash, is_verified) VALUES (1, 'reader@example.com', 'x', 1)`); err != nil {
+		t.Fatalf("inserting user: %v", err)
+	}
+	if err := s.SaveSOAPData(ctx, 1, &store.SOAPData{Date: "2026-01-05", Sections: map[string]string{"observation": "Shepherd"}}); err != nil {
+		t.Fatalf("SaveSOAPData failed: %v", err)
+	}
+	for _, year := range []int{2025, 2026} {
+		if err := s.QueueYearbook(ctx, 1, year); err != nil {
+			t.Fatalf("QueueYearbook failed: %v", err)
+		}
+	}
+
+	yearbook.MakeQueued(ctx, s)
+	if y, err := s.GetYearbook(ctx, 1, 2026); err != nil || y.Status != store.YearbookReady || !strings.Contains(string(y.HTML), "Shepherd") {
+		t.Errorf("2026 yearbook = %+v, %v; want it made", y, err)
+	}
+	if y, err := s.GetYearbook(ctx, 1, 2025); err != nil || y.Status != store.YearbookFailed || !strings.Contains(y.Error, "no entries") {
+		t.Errorf("2025 yearbook = %+v, %v; want it failed for want of entries", y, err)
+	}
+	if queued, _ := s.ListQueuedYearbooks(ctx); len(queued) != 0 {
+		t.Errorf("ListQueuedYearbooks = %+v, want none left", queued)
+	}
+}
@@ -0,0 +1,158 @@
+// Package yearreview makes the years in review that users opt in to share on a public page: a summary
+// of a year's journaling, the highlighted verses they chose, and a card image for previews of links to
+// the page. A review is made once its year is over in the user's timezone, or at once when the user
+// shares a year that is already over.
+package yearreview
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"derrclan.com/moravian-soap/internal/card"
+	"derrclan.com/moravian-soap/internal/clock"
+	"derrclan.com/moravian-soap/internal/config"
+	"derrclan.com/moravian-soap/internal/esv"
+	"derrclan.com/moravian-soap/internal/features"
+	"derrclan.com/moravian-soap/internal/maintenance"
+	"derrclan.com/moravian-soap/internal/store"
+)
+
+// topBooks is the number of books journaled on most that a review names.
+const topBooks = 3
+
+// Summarize summarizes a user's journaling in year: the days they journaled and the books they
+// journaled on the most days, with ties in the order of the Bible.
+func Summarize(ctx context.Context, s store.Store, userID int64, year int) (*store.YearSummary, error) {
+	from, to := fmt.Sprintf("%d-01-01", year), fmt.Sprintf("%d-12-31", year)
+	journaled, err := s.GetJournaledDates(ctx, userID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("getting journaled dates: %w", err)
+	}
+	concordance, err := s.GetConcordance(ctx, userID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("getting concordance: %w", err)
+	}
+
+	days := map[int]map[string]bool{}
+	for _, c := range concordance {
+		for _, d := range c.Dates {
+			if !d.Journaled || d.Date < from || d.Date > to {
+				continue
+			}
+			if days[c.Chapter.Book] == nil {
+				days[c.Chapter.Book] = map[string]bool{}
+			}
+			days[c.Chapter.Book][d.Date] = true
+		}
+	}
+	var books []int
+	for book := range days {
+		books = append(books, book)
+	}
+	slices.SortFunc(books, func(a, b int) int {
+		return cmp.Or(cmp.Compare(len(days[b]), len(days[a])), cmp.Compare(a, b))
+	})
+
+	summary := &store.YearSummary{Journaled: journaled, TopBooks: []string{}}
+	for _, book := range books[:min(len(books), topBooks)] {
+		summary.TopBooks = append(summary.TopBooks, esv.BookName(book))
+	}
+	if summary.Journaled == nil {
+		summary.Journaled = []string{}
+	}
+	return summary, nil
+}
+
+// Make summarizes the year of a review and draws its card, with weeks starting on the day the user
+// prefers, and records them.
+func Make(ctx context.Context, s store.Store, review *store.YearReview) error {
+	summary, err := Summarize(ctx, s, review.UserID, review.Year)
+	if err != nil {
+		return err
+	}
+	lines := []string{plural(len(summary.Journaled), "day") + " journaled"}
+	if len(summary.TopBooks) > 0 {
+		lines = append(lines, strings.Join(summary.TopBooks, " · "))
+	}
+	if len(review.Verses) > 0 {
+		lines = append(lines, plural(len(review.Verses), "favorite verse"))
+	}
+	prefs, err := s.GetPreferences(ctx, review.UserID)
+	if err != nil {
+		return err
+	}
+	img, err := card.PNG(card.Year(review.Year, lines, summary.Journaled, prefs.Weeks().FirstDay()))
+	if err != nil {
+		return err
+	}
+	if err := s.SaveYearReviewSummary(ctx, review.UserID, review.Year, summary, img); err != nil {
+		return err
+	}
+	review.Summary, review.Card = summary, img
+	return nil
+}
+
+// plural returns n and noun, adding an "s" to noun unless n is 1.
+func plural(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+// MakeDue makes every review not yet made whose year is over at now in its user's timezone, for users
+// with the stats available.
+func MakeDue(ctx context.Context, s store.Store, now time.Time) {
+	reviews, err := s.ListPendingYearReviews(ctx)
+	if err != nil {
+		slog.Error("failed to list pending year reviews", "error", err)
+		return
+	}
+	modes := config.Current().Features
+	for _, review := range reviews {
+		loc, err := time.LoadLocation(review.Timezone)
+		if err != nil {
+			loc = time.UTC
+		}
+		if now.In(loc).Year() <= review.Year {
+			continue
+		}
+		overrides, err := s.GetFeatureOverrides(ctx, review.UserID)
+		if err != nil {
+			slog.Error("failed to get feature overrides", "user_id", review.UserID, "error", err)
+			continue
+		}
+		if !modes.Enabled(features.Stats, overrides) {
+			continue
+		}
+		if err := Make(ctx, s, review); err != nil {
+			slog.Error("failed to make year review", "user_id", review.UserID, "year", review.Year, "error", err)
+			continue
+		}
+		slog.Info("made year review", "user_id", review.UserID, "year", review.Year)
+	}
+}
+
+// Start checks every hour for reviews whose year is over at the time told by clk, until ctx is
+// cancelled, so that each is made soon after the new year begins where its user is.
+func Start(ctx context.Context, s store.Store, clk clock.Clock) {
+	go func() {
+		ticker := time.NewTicker(time.Hour)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				maintenance.Run(func() { MakeDue(ctx, s, clk.Now()) })
+			case <-ctx.Done():
+				slog.Info("stopping year review service")
+				return
+			}
+		}
+	}()
+}
@@ -0,0 +1,61 @@
+package yearreview_test
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"derrclan.com/moravian-soap/internal/store"
+	"derrclan.com/moravian-soap/internal/store/sqlite"
+	"derrclan.com/moravian-soap/internal/testutil"
+	"derrclan.com/moravian-soap/internal/yearreview"
+)
+
+func TestMakeDue(t *testing.T) {
+	db := testutil.NewDB(t)
+	s := sqlite.New(db)
+	ctx := context.Background()
+
+	setup := `
+		INSERT INTO users (id, email, password_hash, is_verified, timezone) VALUES (1, 'reader@example.com', 'x', 1, 'America/Chicago');
+		INSERT INTO journal_sections (user_id, date, section, content) VALUES
+			(1, '2025-12-31', 'observation', 'Last year'),
+			(1, '2026-01-05', 'observation', 'Shepherd'),
+			(1, '2026-02-01', 'observation', 'Grace');
+		INSERT INTO journal_chapters (user_id, date, book, chapter) VALUES
+			(1, '2025-12-31', 43, 1),
+			(1, '2026-01-05', 19, 23),
+			(1, '2026-01-05', 45, 8),
+			(1, '2026-02-01', 19, 103);
+	`
+	if _, err := db.Exec(setup); err != nil {
+		t.Fatalf("failed to set up journal: %v", err)
+	}
+	review := &store.YearReview{UserID: 1, Year: 2026, Token: "share", Verses: []string{"Psalm 23:1"}}
+	if err := s.SaveYearReview(ctx, review); err != nil {
+		t.Fatalf("SaveYearReview failed: %v", err)
+	}
+
+	// 21:00 on New Year's Eve in Chicago: the year is not over there yet.
+	yearEnd := time.Date(2027, time.January, 1, 3, 0, 0, 0, time.UTC)
+	yearreview.MakeDue(ctx, s, yearEnd)
+	if got, err := s.GetYearReview(ctx, 1, 2026); err != nil || got.Summary != nil {
+		t.Fatalf("GetYearReview = %+v, %v before the year is over; want it not made", got, err)
+	}
+
+	yearreview.MakeDue(ctx, s, yearEnd.Add(6*time.Hour))
+	got, err := s.GetYearReview(ctx, 1, 2026)
+	if err != nil {
+		t.Fatalf("GetYearReview failed: %v", err)
+	}
+	if got.Summary == nil || len(got.Card) == 0 {
+		t.Fatalf("GetYearReview = %+v after the year is over, want it made", got)
+	}
+	if want := []string{"2026-01-05", "2026-02-01"}; !slices.Equal(got.Summary.Journaled, want) {
+		t.Errorf("journaled = %q, want %q", got.Summary.Journaled, want)
+	}
+	if want := []string{"Psalm", "Romans"}; !slices.Equal(got.Summary.TopBooks, want) {
+		t.Errorf("top books = %q, want %q", got.Summary.TopBooks, want)
+	}
+}